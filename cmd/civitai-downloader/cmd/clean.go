@@ -31,10 +31,35 @@ var cleanCmd = &cobra.Command{
 	Use:   "clean",
 	Short: "Remove temporary (.tmp) files from the download directory",
 	Long: `Recursively scans the configured SavePath and removes any files ending with the .tmp extension.
-Optionally removes *.torrent and *-magnet.txt files as well.`,
+Optionally removes *.torrent and *-magnet.txt files as well. The .part files of
+interrupted downloads are kept, as the next download run resumes them.`,
 	Run: runClean,
 }
 
+// resolveCleanSavePath returns the directory to clean: savePath, or the
+// directory of databasePath when savePath is empty. It must be an existing
+// directory.
+func resolveCleanSavePath(savePath, databasePath string) (string, error) {
+	if savePath == "" {
+		if databasePath == "" {
+			return "", fmt.Errorf("SavePath is not configured (and cannot be inferred from DatabasePath). Cannot determine where to clean")
+		}
+		savePath = filepath.Dir(databasePath)
+		log.Warnf("SavePath is empty, inferring base directory from DatabasePath: %s", savePath)
+	}
+	info, err := os.Stat(savePath)
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("SavePath directory does not exist: %s", savePath)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error accessing SavePath %q: %w", savePath, err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("SavePath is not a directory: %s", savePath)
+	}
+	return savePath, nil
+}
+
 func runClean(cmd *cobra.Command, args []string) {
 	// Access the globally loaded config from root.go's PersistentPreRunE
 	cfg := globalConfig // Use the globalConfig variable
@@ -46,30 +71,11 @@ func runClean(cmd *cobra.Command, args []string) {
 	cleanTorrents, _ := cmd.Flags().GetBool("torrents")
 	cleanMagnets, _ := cmd.Flags().GetBool("magnets")
 
-	// --- Path Validation --- (Moved up slightly)
-	if savePath == "" {
-		if cfg.DatabasePath != "" {
-			savePath = filepath.Dir(cfg.DatabasePath)
-			log.Warnf("SavePath is empty, inferring base directory from DatabasePath: %s", savePath)
-		} else {
-			log.Error("SavePath is not configured (and cannot be inferred from DatabasePath). Cannot determine where to clean.")
-			os.Exit(1)
-		}
-	}
-	info, err := os.Stat(savePath)
-	if os.IsNotExist(err) {
-		log.Errorf("SavePath directory does not exist: %s", savePath)
-		os.Exit(1)
-	}
+	savePath, err := resolveCleanSavePath(savePath, cfg.DatabasePath)
 	if err != nil {
-		log.Errorf("Error accessing SavePath %q: %v", savePath, err)
-		os.Exit(1)
-	}
-	if !info.IsDir() {
-		log.Errorf("SavePath is not a directory: %s", savePath)
+		log.Error(err)
 		os.Exit(1)
 	}
-	// --- End Path Validation ---
 
 	logLine := fmt.Sprintf("Scanning for .tmp files in %s", savePath)
 	if cleanTorrents {
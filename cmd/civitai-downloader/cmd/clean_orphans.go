@@ -0,0 +1,259 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-civitai-download/internal/config"
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Package-level variables for clean orphans flags
+var (
+	cleanOrphansDeleteFlag bool
+	cleanOrphansYesFlag    bool
+)
+
+func init() {
+	cleanCmd.AddCommand(cleanOrphansCmd)
+
+	cleanOrphansCmd.Flags().BoolVar(&cleanOrphansDeleteFlag, "delete", false, "Remove the orphaned files instead of only listing them")
+	cleanOrphansCmd.Flags().BoolVarP(&cleanOrphansYesFlag, "yes", "y", false, "Remove the orphaned files without prompting (with --delete)")
+}
+
+// cleanOrphansCmd finds model files under SavePath that no database entry points to
+var cleanOrphansCmd = &cobra.Command{
+	Use:   "orphans",
+	Short: "List (or delete) model files on disk that the database does not know about",
+	Long: `Walks the configured SavePath and lists every model file, i.e. every file with
+one of the Torrent.IncludeExtensions extensions, that no version entry in the
+database points to, e.g. after files were renamed or entries removed by hand.
+Files named {versionId}_... in the folder of a known version are its other
+files and are not reported. Metadata .json files, .torrent files and images
+folders are not checked. With --delete the orphaned files are removed, after
+a confirmation unless --yes is given, and the reclaimed space is shown.
+
+Examples:
+  civitai-downloader clean orphans
+  civitai-downloader clean orphans --delete
+  civitai-downloader clean orphans --delete --yes`,
+	Run: runCleanOrphans,
+}
+
+// orphanFile is a model file not referenced by the database.
+type orphanFile struct {
+	Path string
+	Size int64
+}
+
+func runCleanOrphans(cmd *cobra.Command, args []string) {
+	cfg := globalConfig
+	savePath, err := resolveCleanSavePath(cfg.SavePath, cfg.DatabasePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if cfg.DatabasePath == "" {
+		log.Fatal("Database path is not set in the configuration. Please check config file or path.")
+	}
+
+	db, err := database.OpenReadOnly(cfg.DatabasePath)
+	if err != nil {
+		log.WithError(err).Fatalf("Failed to open database at %s", cfg.DatabasePath)
+	}
+	expected, err := expectedModelPaths(db, savePath)
+	_ = db.Close()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to read the database entries")
+	}
+
+	extensions := cfg.Torrent.IncludeExtensions
+	if extensions == "" {
+		extensions = config.DefaultConfigTorrentIncludeExtensions
+	}
+	log.Infof("Scanning %s for model files missing from the database...", savePath)
+	orphans, err := findOrphans(savePath, expected, parseExtensionList(extensions))
+	if err != nil {
+		log.WithError(err).Fatalf("Failed to scan %s", savePath)
+	}
+
+	if !cleanOrphansDeleteFlag || len(orphans) == 0 {
+		printOrphans(os.Stdout, orphans, false)
+		return
+	}
+	if !cleanOrphansYesFlag {
+		listOrphans(os.Stdout, orphans)
+		if !confirmOrphanDeletion(os.Stdout, bufio.NewReader(os.Stdin), orphans) {
+			log.Info("Nothing removed.")
+			return
+		}
+	}
+
+	removed, failed := removeOrphans(orphans)
+	printOrphans(os.Stdout, removed, true)
+	if failed > 0 {
+		log.Errorf("Failed to remove %d orphaned file(s).", failed)
+		os.Exit(1)
+	}
+}
+
+// expectedFiles holds the files the database knows about.
+type expectedFiles struct {
+	paths    map[string]bool // Cleaned paths of entries and their objects
+	versions map[string]bool // Folder of each entry joined with "{versionId}_"
+}
+
+// contains reports whether path is a known file, or is named {versionId}_...
+// in the folder of that version: the database keeps one entry per version,
+// so the other files of a version are matched on their prefix.
+func (e expectedFiles) contains(path string) bool {
+	path = filepath.Clean(path)
+	if e.paths[path] {
+		return true
+	}
+	name := filepath.Base(path)
+	i := strings.Index(name, "_")
+	if i <= 0 {
+		return false
+	}
+	if _, err := strconv.Atoi(name[:i]); err != nil {
+		return false
+	}
+	return e.versions[filepath.Join(filepath.Dir(path), name[:i+1])]
+}
+
+// expectedModelPaths returns the files under savePath that the version entries
+// of db point to, including their content-addressed objects.
+func expectedModelPaths(db *database.DB, savePath string) (expectedFiles, error) {
+	expected := expectedFiles{paths: make(map[string]bool), versions: make(map[string]bool)}
+	err := db.Fold(func(key []byte, value []byte) error {
+		keyStr := string(key)
+		if !strings.HasPrefix(keyStr, "v_") {
+			return nil
+		}
+		var entry models.DatabaseEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			log.WithError(err).Warnf("Failed to unmarshal JSON for key %s, skipping it.", keyStr)
+			return nil
+		}
+		if entry.Filename != "" {
+			expected.paths[filepath.Join(savePath, entry.Folder, entry.Filename)] = true
+		}
+		if entry.Version.ID > 0 {
+			expected.versions[filepath.Join(savePath, entry.Folder, fmt.Sprintf("%d_", entry.Version.ID))] = true
+		}
+		if entry.ObjectPath != "" {
+			expected.paths[filepath.Join(savePath, entry.ObjectPath)] = true
+		}
+		return nil
+	})
+	return expected, err
+}
+
+// parseExtensionList splits a comma separated extension list into lowercase
+// extensions with a leading dot.
+func parseExtensionList(s string) []string {
+	var extensions []string
+	for _, ext := range strings.Split(s, ",") {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" {
+			continue
+		}
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		extensions = append(extensions, ext)
+	}
+	return extensions
+}
+
+// findOrphans walks savePath for files with one of extensions that are not in
+// expected. Folders named images are skipped. The result is sorted by path.
+func findOrphans(savePath string, expected expectedFiles, extensions []string) ([]orphanFile, error) {
+	var orphans []orphanFile
+	err := filepath.WalkDir(savePath, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			log.Warnf("Error accessing path %q during scan: %v", path, err)
+			return nil
+		}
+		if d.IsDir() {
+			if path != savePath && strings.EqualFold(d.Name(), "images") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil // Links made by ContentAddressed point at objects
+		}
+		ext := strings.ToLower(filepath.Ext(d.Name()))
+		if !helpers.StringSliceContains(extensions, ext) || expected.contains(path) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			log.Warnf("Error reading %q during scan: %v", path, err)
+			return nil
+		}
+		orphans = append(orphans, orphanFile{Path: path, Size: info.Size()})
+		return nil
+	})
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].Path < orphans[j].Path })
+	return orphans, err
+}
+
+// confirmOrphanDeletion asks on w whether to remove the orphaned files.
+func confirmOrphanDeletion(w io.Writer, reader *bufio.Reader, orphans []orphanFile) bool {
+	_, _ = fmt.Fprintf(w, "Remove these %d file(s)? This cannot be undone. (y/N): ", len(orphans))
+	input, _ := reader.ReadString('\n')
+	input = strings.TrimSpace(strings.ToLower(input))
+	return input == "y" || input == confirmYes
+}
+
+// removeOrphans deletes the orphaned files, returning those removed and how
+// many could not be.
+func removeOrphans(orphans []orphanFile) ([]orphanFile, int) {
+	var removed []orphanFile
+	failed := 0
+	for _, orphan := range orphans {
+		if err := os.Remove(orphan.Path); err != nil {
+			log.WithError(err).Errorf("Failed to remove %s", orphan.Path)
+			failed++
+			continue
+		}
+		log.Infof("Removed orphaned file: %s", orphan.Path)
+		removed = append(removed, orphan)
+	}
+	return removed, failed
+}
+
+// listOrphans lists the orphaned files with their sizes and returns their
+// total size.
+func listOrphans(w io.Writer, orphans []orphanFile) int64 {
+	var total int64
+	for _, orphan := range orphans {
+		_, _ = fmt.Fprintf(w, "%s\t%s\n", helpers.BytesToSize(uint64(orphan.Size)), orphan.Path)
+		total += orphan.Size
+	}
+	return total
+}
+
+// printOrphans lists the orphaned files with their sizes and the total.
+func printOrphans(w io.Writer, orphans []orphanFile, deleted bool) {
+	total := listOrphans(w, orphans)
+	if deleted {
+		_, _ = fmt.Fprintf(w, "\nRemoved %d orphaned file(s), reclaimed %s.\n", len(orphans), helpers.BytesToSize(uint64(total)))
+		return
+	}
+	_, _ = fmt.Fprintf(w, "\nFound %d orphaned file(s) using %s. Run with --delete to remove them.\n", len(orphans), helpers.BytesToSize(uint64(total)))
+}
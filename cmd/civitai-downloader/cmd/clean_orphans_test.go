@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindOrphans(t *testing.T) {
+	dir := t.TempDir()
+	write := func(rel string, size int) {
+		path := filepath.Join(dir, rel)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0700))
+		require.NoError(t, os.WriteFile(path, make([]byte, size), 0600))
+	}
+	write("lora/model/10_known.safetensors", 10)
+	write("lora/model/10_known.json", 1)
+	write("lora/model/10_second_file.safetensors", 15)
+	write("lora/model/11_unknown.safetensors", 4)
+	write("lora/other/10_elsewhere.safetensors", 6)
+	write("lora/model/renamed.safetensors", 20)
+	write("lora/model/images/preview.bin", 5)
+	write("lora/model/model.torrent", 1)
+	write("checkpoint/old.CKPT", 30)
+
+	known := diffTestEntry(10, models.StatusDownloaded, "AAAA")
+	known.Folder = filepath.Join("lora", "model")
+	known.Filename = "10_known.safetensors"
+	dbPath := filepath.Join(dir, "civitai.db")
+	writeDiffTestDB(t, dbPath, known)
+
+	db, err := database.OpenReadOnly(dbPath)
+	require.NoError(t, err)
+	expected, err := expectedModelPaths(db, dir)
+	_ = db.Close()
+	require.NoError(t, err)
+
+	orphans, err := findOrphans(dir, expected, parseExtensionList(".safetensors, ckpt,.bin"))
+	require.NoError(t, err)
+	assert.Equal(t, []orphanFile{
+		{Path: filepath.Join(dir, "checkpoint/old.CKPT"), Size: 30},
+		{Path: filepath.Join(dir, "lora/model/11_unknown.safetensors"), Size: 4},
+		{Path: filepath.Join(dir, "lora/model/renamed.safetensors"), Size: 20},
+		{Path: filepath.Join(dir, "lora/other/10_elsewhere.safetensors"), Size: 6},
+	}, orphans, "other files of a known version are matched on their prefix, in its folder only")
+
+	removed, failed := removeOrphans(orphans)
+	assert.Zero(t, failed)
+	assert.NoFileExists(t, filepath.Join(dir, "lora/model/renamed.safetensors"))
+	assert.FileExists(t, filepath.Join(dir, "lora/model/10_known.safetensors"))
+	assert.FileExists(t, filepath.Join(dir, "lora/model/10_second_file.safetensors"))
+
+	var out bytes.Buffer
+	printOrphans(&out, removed, true)
+	assert.Contains(t, out.String(), "Removed 4 orphaned file(s), reclaimed 60.00B.")
+}
+
+func TestConfirmOrphanDeletion(t *testing.T) {
+	orphans := []orphanFile{{Path: "a.safetensors", Size: 1}}
+	for input, want := range map[string]bool{"y\n": true, "YES\n": true, "n\n": false, "\n": false, "": false} {
+		var out bytes.Buffer
+		got := confirmOrphanDeletion(&out, bufio.NewReader(strings.NewReader(input)), orphans)
+		assert.Equal(t, want, got, "input %q", input)
+		assert.Contains(t, out.String(), "Remove these 1 file(s)?")
+	}
+}
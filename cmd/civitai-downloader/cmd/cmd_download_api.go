@@ -5,8 +5,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/rand"
 	"net/http"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -23,13 +25,82 @@ import (
 
 // --- Retry Logic Helper --- START ---
 
+// maintenanceThreshold is how many 503 responses in a row are taken to mean
+// Civitai is down for maintenance rather than briefly overloaded.
+const maintenanceThreshold = 3
+
+// maintenancePollInterval is how long to wait between checks while Civitai is
+// down for maintenance (WaitForMaintenance).
+var maintenancePollInterval = 5 * time.Minute
+
+// ErrRetryBudgetExhausted is returned when retrying stops because the attempts
+// made so far used up MaxRetryElapsedMs, before MaxRetries ran out.
+var ErrRetryBudgetExhausted = errors.New("retry time budget exhausted")
+
 // doRequestWithRetry performs an HTTP request with exponential backoff retries.
-// It now uses MaxRetries and InitialRetryDelayMs from the config.
+// It now uses MaxRetries, InitialRetryDelayMs, MaxRetryDelayMs and
+// MaxRetryElapsedMs from the config.
+// With WaitForMaintenance set, a run of 503 responses makes it wait for the
+// API to come back instead of failing.
 func doRequestWithRetry(client *http.Client, req *http.Request, cfg *models.Config, logPrefix string) (*http.Response, []byte, error) {
+	userAgent := cfg.UserAgent
+	if userAgent == "" {
+		userAgent = models.DefaultUserAgent()
+	}
+	req.Header.Set("User-Agent", userAgent)
+	resp, bodyBytes, unavailable, err := requestWithRetries(client, req, cfg, logPrefix)
+	if err == nil || !cfg.WaitForMaintenance || unavailable < min(maintenanceThreshold, cfg.MaxRetries+1) {
+		return resp, bodyBytes, err
+	}
+
+	log.Warnf("[%s] Civitai returned %d consecutive 503 responses and appears to be down for maintenance. Waiting for it to come back, checking every %v...", logPrefix, unavailable, maintenancePollInterval)
+	for {
+		time.Sleep(maintenancePollInterval)
+		resp, bodyBytes, unavailable, err = requestWithRetries(client, req, cfg, logPrefix)
+		if err == nil {
+			log.Infof("[%s] Civitai is reachable again, resuming.", logPrefix)
+			return resp, bodyBytes, nil
+		}
+		if unavailable == 0 {
+			// A different failure, not maintenance; let the caller handle it.
+			return resp, bodyBytes, err
+		}
+		log.Warnf("[%s] Civitai is still unavailable (503). Checking again in %v...", logPrefix, maintenancePollInterval)
+	}
+}
+
+// retryBackoff returns how long to wait before retry attempt (1 for the first
+// retry). A Retry-After wait asked for by the API is used as is. Otherwise the
+// ceiling is initial * 2^(attempt-1), capped at maxDelay when it is above 0;
+// with jitter a random duration up to the ceiling is used, so workers hit by
+// the same 429 do not retry in lockstep.
+func retryBackoff(initial, maxDelay time.Duration, attempt int, retryAfter time.Duration, jitter bool) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	ceiling := initial
+	for i := 1; i < attempt && (maxDelay <= 0 || ceiling < maxDelay); i++ {
+		ceiling *= 2
+	}
+	if maxDelay > 0 && ceiling > maxDelay {
+		ceiling = maxDelay
+	}
+	if !jitter {
+		return ceiling
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// requestWithRetries makes up to MaxRetries+1 attempts at req. Besides the
+// response it returns how many of the final attempts in a row got a 503.
+// With MaxRetryElapsedMs set it stops early with ErrRetryBudgetExhausted once
+// the next retry would end past that long after the first attempt.
+func requestWithRetries(client *http.Client, req *http.Request, cfg *models.Config, logPrefix string) (*http.Response, []byte, int, error) {
 	var resp *http.Response
 	var err error
 	var bodyBytes []byte
-	_ = bodyBytes // Explicitly use bodyBytes to satisfy linter (used indirectly in logging/errors)
+	_ = bodyBytes    // Explicitly use bodyBytes to satisfy linter (used indirectly in logging/errors)
+	unavailable := 0 // Consecutive 503 responses
 
 	maxRetries := cfg.MaxRetries                                                   // Get from config
 	initialRetryDelay := time.Duration(cfg.InitialRetryDelayMs) * time.Millisecond // Get from config
@@ -39,31 +110,49 @@ func doRequestWithRetry(client *http.Client, req *http.Request, cfg *models.Conf
 	if maxRetries < 0 {
 		maxRetries = 0 // Ensure non-negative retries
 	}
-	maxAttempts := maxRetries + 1 // Total attempts include the initial one
+	maxRetryDelay := time.Duration(cfg.MaxRetryDelayMs) * time.Millisecond // 0 = no cap
+	retryBudget := time.Duration(cfg.MaxRetryElapsedMs) * time.Millisecond // 0 = no budget
+	maxAttempts := maxRetries + 1                                          // Total attempts include the initial one
+	var retryAfter time.Duration                                           // Wait asked for by the last response's Retry-After header
+	var lastErr error                                                      // Failure of the last attempt, reported if the budget runs out
+	start := time.Now()
 
 	for attempt := 0; attempt < maxAttempts; attempt++ {
 		if attempt > 0 {
-			// Calculate backoff: initial * 2^(attempt-1)
-			backoff := initialRetryDelay * time.Duration(1<<(attempt-1))
+			backoff := retryBackoff(initialRetryDelay, maxRetryDelay, attempt, retryAfter, cfg.RetryJitter)
+			if retryBudget > 0 && time.Since(start)+backoff > retryBudget {
+				log.Warnf("[%s] Giving up on %s after %d attempts: retrying in %v would exceed the %v retry budget", logPrefix, req.URL.String(), attempt, backoff, retryBudget)
+				return nil, nil, unavailable, fmt.Errorf("[%s] %w (%v) after %d attempts for %s: %v", logPrefix, ErrRetryBudgetExhausted, retryBudget, attempt, req.URL.String(), lastErr)
+			}
 			log.Infof("[%s] Retrying request for %s in %v (Attempt %d/%d)...", logPrefix, req.URL.String(), backoff, attempt+1, maxAttempts)
 			time.Sleep(backoff)
 		}
+		if err := api.SharedBreaker.Allow(cfg.CircuitBreakerThreshold); err != nil {
+			return nil, nil, 0, fmt.Errorf("[%s] %w", logPrefix, err)
+		}
 
 		// Clone the request for the attempt, especially important if the body is consumed.
 		clonedReq := req.Clone(req.Context())
 		if req.Body != nil && req.GetBody != nil {
 			clonedReq.Body, err = req.GetBody()
 			if err != nil {
-				return nil, nil, fmt.Errorf("[%s] failed to get request body for retry clone (attempt %d): %w", logPrefix, attempt+1, err)
+				return nil, nil, unavailable, fmt.Errorf("[%s] failed to get request body for retry clone (attempt %d): %w", logPrefix, attempt+1, err)
 			}
 		} else if req.Body != nil {
 			log.Warnf("[%s] Cannot guarantee safe retry for request with non-nil body without GetBody defined (URL: %s)", logPrefix, req.URL.String())
 		}
 
+		if err := api.WaitForRequestSlot(clonedReq.Context()); err != nil {
+			return nil, nil, unavailable, fmt.Errorf("[%s] %w", logPrefix, err)
+		}
 		log.Debugf("[%s] Attempt %d/%d: Sending request to %s", logPrefix, attempt+1, maxAttempts, clonedReq.URL.String())
 		resp, err = client.Do(clonedReq)
 
 		if err != nil {
+			unavailable = 0
+			retryAfter = 0
+			lastErr = err
+			api.SharedBreaker.RecordFailure(cfg.CircuitBreakerThreshold)
 			log.WithError(err).Warnf("[%s] Attempt %d/%d failed for %s: %v", logPrefix, attempt+1, maxAttempts, clonedReq.URL.String(), err)
 			if resp != nil {
 				if closeErr := resp.Body.Close(); closeErr != nil {
@@ -71,7 +160,7 @@ func doRequestWithRetry(client *http.Client, req *http.Request, cfg *models.Conf
 				}
 			}
 			if attempt == maxRetries {
-				return nil, nil, fmt.Errorf("[%s] network error failed after %d attempts for %s: %w", logPrefix, maxAttempts, clonedReq.URL.String(), err)
+				return nil, nil, unavailable, fmt.Errorf("[%s] network error failed after %d attempts for %s: %w", logPrefix, maxAttempts, clonedReq.URL.String(), err)
 			}
 			continue // Retry
 		}
@@ -82,18 +171,29 @@ func doRequestWithRetry(client *http.Client, req *http.Request, cfg *models.Conf
 		}
 
 		if readErr != nil {
+			lastErr = readErr
+			api.SharedBreaker.RecordFailure(cfg.CircuitBreakerThreshold)
 			log.WithError(readErr).Warnf("[%s] Attempt %d/%d failed to read response body for %s: %v", logPrefix, attempt+1, maxAttempts, clonedReq.URL.String(), readErr)
 			if attempt == maxRetries {
-				return nil, nil, fmt.Errorf("[%s] failed to read body after %d attempts for %s: %w", logPrefix, maxAttempts, clonedReq.URL.String(), readErr)
+				return nil, nil, unavailable, fmt.Errorf("[%s] failed to read body after %d attempts for %s: %w", logPrefix, maxAttempts, clonedReq.URL.String(), readErr)
 			}
 			continue // Retry
 		}
 
 		if resp.StatusCode == http.StatusOK {
+			api.SharedBreaker.RecordSuccess()
 			log.Debugf("[%s] Attempt %d/%d successful for %s", logPrefix, attempt+1, maxAttempts, clonedReq.URL.String())
-			return resp, bodyBytes, nil // Success!
+			return resp, bodyBytes, 0, nil // Success!
 		}
 
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			unavailable++
+		} else {
+			unavailable = 0
+		}
+		retryAfter = api.RetryAfter(resp)
+		lastErr = fmt.Errorf("status %s", resp.Status)
+
 		bodySample := string(bodyBytes)
 		if len(bodySample) > 200 {
 			bodySample = bodySample[:200] + "..."
@@ -105,6 +205,11 @@ func doRequestWithRetry(client *http.Client, req *http.Request, cfg *models.Conf
 			resp.StatusCode == http.StatusTooManyRequests ||
 			resp.StatusCode == http.StatusGatewayTimeout
 
+		// With WaitForMaintenance, 503s are waited out below instead of tripping the breaker
+		if isRetryableStatus && !(cfg.WaitForMaintenance && resp.StatusCode == http.StatusServiceUnavailable) {
+			api.SharedBreaker.RecordFailure(cfg.CircuitBreakerThreshold)
+		}
+
 		if isRetryableStatus && attempt < maxRetries {
 			log.Warnf("[%s] Status %s is retryable.", logPrefix, resp.Status)
 		} else {
@@ -113,11 +218,11 @@ func doRequestWithRetry(client *http.Client, req *http.Request, cfg *models.Conf
 				errMsg = fmt.Sprintf("[%s] request failed with non-retryable status %s on attempt %d", logPrefix, resp.Status, attempt+1)
 			}
 			errMsg += fmt.Sprintf(". Body: %s", bodySample)
-			return resp, bodyBytes, errors.New(errMsg)
+			return resp, bodyBytes, unavailable, errors.New(errMsg)
 		}
 	} // End of retry loop
 
-	return nil, nil, fmt.Errorf("[%s] retry loop completed without success or error return for %s", logPrefix, req.URL.String())
+	return nil, nil, unavailable, fmt.Errorf("[%s] retry loop completed without success or error return for %s", logPrefix, req.URL.String())
 }
 
 // --- Retry Logic Helper --- END ---
@@ -125,23 +230,43 @@ func doRequestWithRetry(client *http.Client, req *http.Request, cfg *models.Conf
 // passesFileFilters checks if a given file passes the configured file-level filters.
 // Now uses the passed config struct.
 func passesFileFilters(file models.File, modelType string, cfg *models.Config) bool {
-	if file.Hashes.CRC32 == "" {
-		log.Debugf("Skipping file %s: Missing CRC32 hash.", file.Name)
+	if reason := fileFilterReason(file, modelType, cfg); reason != "" {
+		log.Debugf("Skipping file %s: %s.", file.Name, reason)
 		return false
 	}
+	return true
+}
+
+// fileFilterReason returns why the file-level filters drop file, or "" if it passes.
+func fileFilterReason(file models.File, modelType string, cfg *models.Config) string {
+	if file.Hashes.CRC32 == "" {
+		return "missing CRC32 hash"
+	}
+
+	// Workflow attachments are JSON, so the model file filters below don't apply
+	if cfg.Download.SaveWorkflows && isWorkflowFile(file) {
+		return ""
+	}
 
 	if cfg.Download.PrimaryOnly && !file.Primary {
-		log.Debugf("Skipping non-primary file %s.", file.Name)
-		return false
+		return "not the primary file (--primary-only)"
 	}
 
-	if file.Metadata.Format == "" {
-		log.Debugf("Skipping file %s: Missing metadata format.", file.Name)
-		return false
+	if len(cfg.Download.AllowedFormats) > 0 {
+		if file.Metadata.Format == "" {
+			return "missing metadata format"
+		}
+		if !formatAllowed(file.Metadata.Format, cfg.Download.AllowedFormats) {
+			return fmt.Sprintf("format %s is not allowed (--formats %s)", file.Metadata.Format, strings.Join(cfg.Download.AllowedFormats, ","))
+		}
 	}
-	if strings.ToLower(file.Metadata.Format) != "safetensor" {
-		log.Debugf("Skipping non-safetensor file %s (Format: %s).", file.Name, file.Metadata.Format)
-		return false
+
+	sizeMB := file.SizeKB / 1024
+	if cfg.Download.MinSizeMB > 0 && sizeMB < cfg.Download.MinSizeMB {
+		return fmt.Sprintf("size %.2f MB is below the minimum of %.2f MB (--min-size-mb)", sizeMB, cfg.Download.MinSizeMB)
+	}
+	if cfg.Download.MaxSizeMB > 0 && sizeMB > cfg.Download.MaxSizeMB {
+		return fmt.Sprintf("size %.2f MB is above the maximum of %.2f MB (--max-size-mb)", sizeMB, cfg.Download.MaxSizeMB)
 	}
 
 	if strings.EqualFold(modelType, "checkpoint") {
@@ -149,29 +274,91 @@ func passesFileFilters(file models.File, modelType string, cfg *models.Config) b
 		fpStr := fmt.Sprintf("%v", file.Metadata.Fp)
 
 		if cfg.Download.Pruned && !strings.EqualFold(sizeStr, "pruned") {
-			log.Debugf("Skipping non-pruned file %s (Size: %s) in checkpoint model.", file.Name, sizeStr)
-			return false
+			return fmt.Sprintf("checkpoint is not pruned (size: %s, --pruned)", sizeStr)
 		}
 		if cfg.Download.Fp16 && !strings.EqualFold(fpStr, "fp16") {
-			log.Debugf("Skipping non-fp16 file %s (FP: %s) in checkpoint model.", file.Name, fpStr)
-			return false
+			return fmt.Sprintf("checkpoint is not fp16 (fp: %s, --fp16)", fpStr)
 		}
 	}
 
-	ignoredFilenameStrings := cfg.Download.IgnoreFileNameStrings // Use config
-	if len(ignoredFilenameStrings) > 0 {
-		for _, ignoreFileName := range ignoredFilenameStrings {
-			if ignoreFileName != "" && strings.Contains(strings.ToLower(file.Name), strings.ToLower(ignoreFileName)) {
-				log.Debugf("      - Skipping file %s: Filename contains ignored string '%s'.", file.Name, ignoreFileName)
-				return false
-			}
+	for _, ignoreFileName := range cfg.Download.IgnoreFileNameStrings {
+		if ignoreFileName != "" && strings.Contains(strings.ToLower(file.Name), strings.ToLower(ignoreFileName)) {
+			return fmt.Sprintf("file name contains ignored string '%s'", ignoreFileName)
 		}
 	}
-	return true
+	return ""
+}
+
+// formatAliases maps file extensions users may give in AllowedFormats to the
+// format name the API reports for such files.
+var formatAliases = map[string]string{
+	"safetensors": "safetensor",
+	"ckpt":        "pickletensor",
+	"pt":          "pickletensor",
+	"pth":         "pickletensor",
+}
+
+// formatAllowed reports whether format, as reported in the file metadata, is
+// one of allowed, compared case-insensitively. Entries of allowed may also be
+// file extensions such as "pt" or ".ckpt" (see formatAliases).
+func formatAllowed(format string, allowed []string) bool {
+	format = strings.ToLower(format)
+	for _, entry := range allowed {
+		entry = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(entry)), ".")
+		if alias, ok := formatAliases[entry]; ok {
+			entry = alias
+		}
+		if entry == format {
+			return true
+		}
+	}
+	return false
+}
+
+// filterVersionFiles returns the files of a version that pass passesFileFilters.
+// With PrimaryOnly and PrimaryFileFallback, a version that flags no file as
+// primary gets its largest file passing the other filters instead (the first
+// one on a tie), so older versions predating the flag are not skipped.
+func filterVersionFiles(files []models.File, modelType string, cfg *models.Config) []models.File {
+	kept := make([]models.File, 0, len(files))
+	hasPrimary := false
+	for _, file := range files {
+		hasPrimary = hasPrimary || file.Primary
+		if passesFileFilters(file, modelType, cfg) {
+			kept = append(kept, file)
+		}
+	}
+	if !cfg.Download.PrimaryOnly || !cfg.Download.PrimaryFileFallback || hasPrimary {
+		return kept
+	}
+
+	fallbackCfg := *cfg
+	fallbackCfg.Download.PrimaryOnly = false
+	fallbackIndex := -1
+	for i, file := range files {
+		if isWorkflowFile(file) || !passesFileFilters(file, modelType, &fallbackCfg) {
+			continue
+		}
+		if fallbackIndex < 0 || file.SizeKB > files[fallbackIndex].SizeKB {
+			fallbackIndex = i
+		}
+	}
+	if fallbackIndex >= 0 {
+		log.Debugf("No primary file in version, falling back to %s.", files[fallbackIndex].Name)
+		kept = append(kept, files[fallbackIndex])
+	}
+	return kept
 }
 
-// Helper to build data map for path generation
-func buildPathData(model *models.Model, version *models.ModelVersion, file *models.File) map[string]string {
+// apiPrimaryFileOnly reports whether the API can drop non-primary files itself.
+// Not with PrimaryFileFallback, which needs every file of a version to pick from.
+func apiPrimaryFileOnly(cfg *models.Config) bool {
+	return cfg.Download.PrimaryOnly && !cfg.Download.PrimaryFileFallback
+}
+
+// Helper to build data map for path generation. typeFolders maps model types to
+// the folder name {modelType} should use instead (see Download.TypeFolderMap).
+func buildPathData(model *models.Model, version *models.ModelVersion, file *models.File, typeFolders map[string]string) map[string]string {
 	data := map[string]string{}
 	if model != nil {
 		data["modelId"] = strconv.Itoa(model.ID)
@@ -194,7 +381,16 @@ func buildPathData(model *models.Model, version *models.ModelVersion, file *mode
 			data["modelType"] = version.Model.Type
 		}
 	}
-	// Could add file-specific tags later if needed, like {fileId}, {fileName}
+	if file != nil {
+		data[paths.PlaceholderFileExt] = fileTagValue(strings.ToLower(strings.TrimPrefix(filepath.Ext(file.Name), ".")), "unknown_ext")
+		data[paths.PlaceholderFileFormat] = fileTagValue(file.Metadata.Format, "unknown_format")
+		data[paths.PlaceholderFp] = fileTagValue(file.Metadata.Fp, "unknown_fp")
+		data[paths.PlaceholderSize] = fileTagValue(file.Metadata.Size, "unknown_size")
+	}
+
+	if folder := typeFolder(typeFolders, data["modelType"]); folder != "" {
+		data[paths.FolderKey(paths.PlaceholderModelType)] = folder
+	}
 
 	// Ensure creator is never empty if possible (fallback needed?)
 	if data["creatorName"] == "" {
@@ -204,26 +400,46 @@ func buildPathData(model *models.Model, version *models.ModelVersion, file *mode
 	return data
 }
 
-// handleSingleVersionDownload Fetches details for a specific model version ID and processes it for download.
-// Now uses the passed config struct and api.Client.
-func handleSingleVersionDownload(versionID int, db *database.DB, apiClient *api.Client, cfg *models.Config) ([]potentialDownload, uint64, error) {
-	log.Debugf("Fetching details for model version ID: %d", versionID)
-	apiURL := fmt.Sprintf("https://civitai.com/api/v1/model-versions/%d", versionID)
-	logPrefix := fmt.Sprintf("Version %d", versionID)
+// fileTagValue returns value, or fallback if the API left it empty.
+func fileTagValue(value, fallback string) string {
+	if strings.TrimSpace(value) == "" {
+		return fallback
+	}
+	return value
+}
+
+// typeFolder returns the folder name configured for modelType, or "" if the
+// type is not mapped. Viper lower-cases map keys, so the match ignores case.
+func typeFolder(typeFolders map[string]string, modelType string) string {
+	if modelType == "" {
+		return ""
+	}
+	for apiType, folder := range typeFolders {
+		if strings.EqualFold(apiType, modelType) {
+			return folder
+		}
+	}
+	return ""
+}
+
+// fetchAPIObject fetches the JSON of the object with the given ID from an API
+// endpoint such as "models" or "model-versions", retrying like every download
+// request. what names the object in logs and errors.
+func fetchAPIObject(apiClient *api.Client, cfg *models.Config, endpoint, what string, id int) ([]byte, error) {
+	apiURL := fmt.Sprintf("%s/%s/%d", api.BaseURL(*cfg), endpoint, id)
+	logPrefix := fmt.Sprintf("%s%s %d", strings.ToUpper(what[:1]), what[1:], id)
 
 	req, err := http.NewRequest("GET", apiURL, nil)
 	if err != nil {
-		return nil, 0, fmt.Errorf("failed to create request for version %d: %w", versionID, err)
+		return nil, fmt.Errorf("failed to create request for %s %d: %w", what, id, err)
 	}
 	if cfg.APIKey != "" {
 		req.Header.Add("Authorization", "Bearer "+cfg.APIKey)
 	}
 
-	// Use Retry Helper with config for retry settings
 	_, bodyBytes, err := doRequestWithRetry(apiClient.HttpClient, req, cfg, logPrefix)
-
 	if err != nil {
-		finalErrMsg := fmt.Sprintf("failed to fetch version %d: %v", versionID, err)
+		finalErrMsg := fmt.Sprintf("failed to fetch %s %d: %v", what, id, err)
 		if !strings.Contains(err.Error(), "Body:") && len(bodyBytes) > 0 {
 			bodySample := string(bodyBytes)
 			if len(bodySample) > 200 {
@@ -231,7 +447,18 @@ func handleSingleVersionDownload(versionID int, db *database.DB, apiClient *api.
 			}
 			finalErrMsg += fmt.Sprintf(". Last Body: %s", bodySample)
 		}
-		return nil, 0, errors.New(finalErrMsg)
+		return nil, errors.New(finalErrMsg)
+	}
+	return bodyBytes, nil
+}
+
+// handleSingleVersionDownload Fetches details for a specific model version ID and processes it for download.
+// Now uses the passed config struct and api.Client.
+func handleSingleVersionDownload(versionID int, db *database.DB, apiClient *api.Client, cfg *models.Config) ([]potentialDownload, uint64, error) {
+	log.Debugf("Fetching details for model version ID: %d", versionID)
+	bodyBytes, err := fetchAPIObject(apiClient, cfg, "model-versions", "version", versionID)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	var versionResponse models.ModelVersion
@@ -239,6 +466,9 @@ func handleSingleVersionDownload(versionID int, db *database.DB, apiClient *api.
 		log.WithError(err).Errorf("Response body sample: %s", string(bodyBytes[:min(len(bodyBytes), 200)]))
 		return nil, 0, fmt.Errorf("failed to decode API response for version %d: %w", versionID, err)
 	}
+	if cfg.DB.StoreRawJSON {
+		versionResponse.RawJSON = bodyBytes
+	}
 
 	log.Infof("Successfully fetched details for version %d (%s) of model %s (%s)",
 		versionResponse.ID, versionResponse.Name, versionResponse.Model.Name, versionResponse.Model.Type)
@@ -275,18 +505,25 @@ func handleSingleVersionDownload(versionID int, db *database.DB, apiClient *api.
 		// Creator is missing here, buildPathData will use fallback
 	}
 
-	for _, file := range versionResponse.Files {
-		if !passesFileFilters(file, pseudoModel.Type, cfg) {
-			continue
+	files := filterVersionFiles(versionResponse.Files, pseudoModel.Type, cfg)
+	if cfg.Download.FileID > 0 && versionID == cfg.Download.ModelVersionID {
+		file, err := findVersionFile(versionResponse, cfg.Download.FileID)
+		if err != nil {
+			return nil, 0, err
 		}
+		log.Infof("Only downloading file %d (%s) of version %d (--file-id)", file.ID, file.Name, versionID)
+		files = []models.File{file}
+	}
 
+	for _, file := range files {
 		// --- Path Generation using pattern --- START ---
-		data := buildPathData(&pseudoModel, &versionResponse, &file)
+		data := buildPathData(&pseudoModel, &versionResponse, &file, cfg.Download.TypeFolderMap)
 		relPath, err := paths.GeneratePath(cfg.Download.VersionPathPattern, data)
 		if err != nil {
 			log.WithError(err).Errorf("Failed to generate path for version %d, file %s. Skipping.", versionResponse.ID, file.Name)
 			continue
 		}
+		relPath = workflowRelPath(relPath, file, cfg)
 		// --- Path Generation using pattern --- END ---
 
 		finalBaseFilename := fmt.Sprintf("%d_%s", versionResponse.ID, helpers.ConvertToSlug(file.Name))
@@ -314,33 +551,29 @@ func handleSingleVersionDownload(versionID int, db *database.DB, apiClient *api.
 	return processedDownloads, totalSize, nil
 }
 
+// findVersionFile returns the file of version with the given ID. The error
+// lists the files the version has.
+func findVersionFile(version models.ModelVersion, fileID int) (models.File, error) {
+	available := make([]string, 0, len(version.Files))
+	for _, file := range version.Files {
+		if file.ID == fileID {
+			return file, nil
+		}
+		available = append(available, fmt.Sprintf("%d (%s)", file.ID, file.Name))
+	}
+	if len(available) == 0 {
+		return models.File{}, fmt.Errorf("file %d is not part of version %d, which has no files", fileID, version.ID)
+	}
+	return models.File{}, fmt.Errorf("file %d is not part of version %d; its files are: %s", fileID, version.ID, strings.Join(available, ", "))
+}
+
 // handleSingleModelDownload Fetches all versions for a specific model ID and processes them.
 // Now uses the passed config struct and api.Client.
 func handleSingleModelDownload(modelID int, db *database.DB, apiClient *api.Client, imageDownloader *downloader.Downloader, cfg *models.Config) ([]potentialDownload, uint64, error) {
 	log.Debugf("Fetching details for model ID: %d", modelID)
-	apiURL := fmt.Sprintf("https://civitai.com/api/v1/models/%d", modelID)
-	logPrefix := fmt.Sprintf("Model %d", modelID)
-
-	req, err := http.NewRequest("GET", apiURL, nil)
-	if err != nil {
-		return nil, 0, fmt.Errorf("failed to create request for model %d: %w", modelID, err)
-	}
-	if cfg.APIKey != "" {
-		req.Header.Add("Authorization", "Bearer "+cfg.APIKey)
-	}
-
-	_, bodyBytes, err := doRequestWithRetry(apiClient.HttpClient, req, cfg, logPrefix)
-
+	bodyBytes, err := fetchAPIObject(apiClient, cfg, "models", "model", modelID)
 	if err != nil {
-		finalErrMsg := fmt.Sprintf("failed to fetch model %d: %v", modelID, err)
-		if !strings.Contains(err.Error(), "Body:") && len(bodyBytes) > 0 {
-			bodySample := string(bodyBytes)
-			if len(bodySample) > 200 {
-				bodySample = bodySample[:200] + "..."
-			}
-			finalErrMsg += fmt.Sprintf(". Last Body: %s", bodySample)
-		}
-		return nil, 0, errors.New(finalErrMsg)
+		return nil, 0, err
 	}
 
 	var modelResponse models.Model
@@ -348,6 +581,9 @@ func handleSingleModelDownload(modelID int, db *database.DB, apiClient *api.Clie
 		log.WithError(err).Errorf("Response body sample: %s", string(bodyBytes[:min(len(bodyBytes), 200)]))
 		return nil, 0, fmt.Errorf("failed to decode API response for model %d: %w", modelID, err)
 	}
+	if cfg.DB.StoreRawJSON {
+		api.AttachRawVersions(&modelResponse, bodyBytes)
+	}
 
 	log.Infof("Successfully fetched details for model %s (ID: %d, Type: %s, Creator: %s)",
 		modelResponse.Name, modelResponse.ID, modelResponse.Type, modelResponse.Creator.Username)
@@ -360,19 +596,14 @@ func handleSingleModelDownload(modelID int, db *database.DB, apiClient *api.Clie
 	// --- Model Images Processing --- START ---
 	if cfg.Download.SaveModelImages && imageDownloader != nil {
 		log.Warnf("Downloading model images for model %s (ID: %d). This may include images from multiple versions.", modelResponse.Name, modelResponse.ID)
-		// Collect all images from all versions
-		var allModelImages []models.ModelImage
-		for _, version := range modelResponse.ModelVersions {
-			if len(version.Images) > 0 {
-				allModelImages = append(allModelImages, version.Images...)
-			}
-		}
+		// Collect the images of all versions
+		allModelImages := collectModelImages(modelResponse, cfg.Download.DedupeImages)
 
 		if len(allModelImages) > 0 {
 			// Save model images to potentially multiple directories if structure is basemodel_centric
 			processedImageDirs := make(map[string]bool)
 			for _, version := range modelResponse.ModelVersions {
-				data := buildPathData(&modelResponse, &version, nil) // Build data map
+				data := buildPathData(&modelResponse, &version, nil, cfg.Download.TypeFolderMap) // Build data map
 
 				// If ModelInfoPathPattern (used for image base dir) uses {baseModel}, it's ambiguous.
 				// Ensure it resolves to "unknown_baseModel".
@@ -389,7 +620,7 @@ func handleSingleModelDownload(modelID int, db *database.DB, apiClient *api.Clie
 					log.WithError(err).Errorf("Failed to generate model image path for model %s (ID: %d) using pattern '%s'. Skipping image download for this path.", modelResponse.Name, modelResponse.ID, cfg.Download.ModelInfoPathPattern)
 					continue
 				}
-				modelImagesDirAbs := filepath.Join(cfg.SavePath, relModelInfoDir, "images")
+				modelImagesDirAbs := filepath.Join(metadataRoot(cfg), relModelInfoDir, "images")
 
 				if !processedImageDirs[modelImagesDirAbs] {
 					imgLogPrefix := fmt.Sprintf("[Model-%d-Images]", modelResponse.ID)
@@ -401,7 +632,8 @@ func handleSingleModelDownload(modelID int, db *database.DB, apiClient *api.Clie
 						modelImagesDirAbs,
 						imageDownloader,
 						cfg.Download.Concurrency,
-						cfg.Download.MaxImages,
+						imageLimit(cfg),
+						imageWorkflows(cfg),
 					)
 					log.Infof("%s Finished model image download for dir %s. Success: %d, Failures: %d",
 						imgLogPrefix, modelImagesDirAbs, imgSuccess, imgFail)
@@ -414,33 +646,38 @@ func handleSingleModelDownload(modelID int, db *database.DB, apiClient *api.Clie
 	}
 	// --- Model Images Processing --- END ---
 
+	versions := modelResponse.ModelVersions
+	if cfg.Download.AfterVersionID > 0 {
+		versions, err = versionsAfter(versions, cfg.Download.AfterVersionID)
+		if err != nil {
+			return nil, 0, fmt.Errorf("model %d: %w", modelID, err)
+		}
+		log.Infof("Found %d version(s) of model %d newer than version %d.", len(versions), modelID, cfg.Download.AfterVersionID)
+	}
+
 	var totalFiles int
-	for _, version := range modelResponse.ModelVersions {
+	for _, version := range versions {
 		totalFiles += len(version.Files)
 	}
 	potentialDownloadsPage := make([]potentialDownload, 0, totalFiles)
 
 	// Iterate through versions
-	for _, version := range modelResponse.ModelVersions {
+	for _, version := range versions {
 		log.Debugf("    Processing Version: %s (ID: %d)", version.Name, version.ID)
 
 		if !passesBaseModelsFilter(version, cfg) {
 			continue
 		}
 
-		for _, file := range version.Files {
-			// Pass config to filter function
-			if !passesFileFilters(file, modelResponse.Type, cfg) {
-				continue
-			}
-
+		for _, file := range filterVersionFiles(version.Files, modelResponse.Type, cfg) {
 			// --- Path Generation using pattern --- START ---
-			data := buildPathData(&modelResponse, &version, &file)
+			data := buildPathData(&modelResponse, &version, &file, cfg.Download.TypeFolderMap)
 			relPath, err := paths.GeneratePath(cfg.Download.VersionPathPattern, data)
 			if err != nil {
 				log.WithError(err).Errorf("Failed to generate path for model %d, version %d, file %s. Skipping.", modelResponse.ID, version.ID, file.Name)
 				continue
 			}
+			relPath = workflowRelPath(relPath, file, cfg)
 			// --- Path Generation using pattern --- END ---
 
 			// Construct full target path and base filename
@@ -473,7 +710,8 @@ func handleSingleModelDownload(modelID int, db *database.DB, apiClient *api.Clie
 			}
 			potentialDownloadsPage = append(potentialDownloadsPage, pd)
 		}
-		if !cfg.Download.AllVersions {
+		// --after-version-id asks for every newer version, not just the latest
+		if !cfg.Download.AllVersions && cfg.Download.AfterVersionID == 0 {
 			log.Debugf("Processing only latest version for model %d, breaking version loop.", modelResponse.ID)
 			break
 		}
@@ -483,6 +721,70 @@ func handleSingleModelDownload(modelID int, db *database.DB, apiClient *api.Clie
 	return processedDownloads, totalSize, nil
 }
 
+// isIgnoredBaseModel reports whether baseModel contains any of the
+// IgnoreBaseModels substrings (case-insensitive).
+func isIgnoredBaseModel(baseModel string, cfg *models.Config) bool {
+	if baseModel == "" {
+		return false
+	}
+	for _, ignoredBM := range cfg.Download.IgnoreBaseModels {
+		if ignoredBM != "" && strings.Contains(strings.ToLower(baseModel), strings.ToLower(ignoredBM)) {
+			return true
+		}
+	}
+	return false
+}
+
+// versionFilePath resolves VersionPathPattern for pd and returns the folder
+// (relative to SavePath) and the file name the download is saved under. With
+// Download.Flatten the folder is SavePath itself and the name carries the
+// creator and model instead.
+func versionFilePath(pd potentialDownload, cfg *models.Config) (relPath string, finalBaseFilename string, err error) {
+	data := buildPathData(&pd.FullModel, &pd.FullVersion, &pd.File, cfg.Download.TypeFolderMap)
+	if cfg.Download.Flatten {
+		return flatFolder, flatFileName(data, pd.File.Name), nil
+	}
+	relPath, err = paths.GeneratePath(cfg.Download.VersionPathPattern, data)
+	if err != nil {
+		return "", "", err
+	}
+	relPath = workflowRelPath(relPath, pd.File, cfg)
+	finalBaseFilename = fmt.Sprintf("%d_%s", pd.ModelVersionID, helpers.ConvertToSlug(pd.File.Name))
+	return relPath, finalBaseFilename, nil
+}
+
+// versionsAfter returns the versions newer than afterID: those with a higher ID,
+// or published after version afterID when it is still listed on the model.
+// It is an error if there are none.
+func versionsAfter(versions []models.ModelVersion, afterID int) ([]models.ModelVersion, error) {
+	var refPublished time.Time
+	for _, version := range versions {
+		if version.ID == afterID {
+			refPublished, _ = time.Parse(time.RFC3339, version.PublishedAt)
+			break
+		}
+	}
+
+	var newer []models.ModelVersion
+	for _, version := range versions {
+		if version.ID == afterID {
+			continue
+		}
+		if version.ID > afterID {
+			newer = append(newer, version)
+			continue
+		}
+		if published, err := time.Parse(time.RFC3339, version.PublishedAt); err == nil && !refPublished.IsZero() && published.After(refPublished) {
+			newer = append(newer, version)
+		}
+	}
+
+	if len(newer) == 0 {
+		return nil, fmt.Errorf("no versions newer than version %d", afterID)
+	}
+	return newer, nil
+}
+
 // filterAndPrepareDownloads checks potential downloads against the database, generates the final path,
 // and prepares them for the download queue.
 // Now uses the passed config struct.
@@ -493,13 +795,11 @@ func filterAndPrepareDownloads(potentialDownloadsPage []potentialDownload, db *d
 	for _, pd := range potentialDownloadsPage {
 		// --- Path Generation using pattern --- START ---
 		// This is now the single source of truth for path generation before queueing.
-		data := buildPathData(&pd.FullModel, &pd.FullVersion, &pd.File)
-		relPath, err := paths.GeneratePath(cfg.Download.VersionPathPattern, data)
+		relPath, finalBaseFilename, err := versionFilePath(pd, cfg)
 		if err != nil {
 			log.WithError(err).Errorf("Failed to generate path for version %d, file %s. Skipping.", pd.ModelVersionID, pd.File.Name)
 			continue
 		}
-		finalBaseFilename := fmt.Sprintf("%d_%s", pd.ModelVersionID, helpers.ConvertToSlug(pd.File.Name))
 		targetPath := filepath.Join(cfg.SavePath, relPath, finalBaseFilename)
 
 		// Update the potentialDownload with the final, correct path information
@@ -507,6 +807,15 @@ func filterAndPrepareDownloads(potentialDownloadsPage []potentialDownload, db *d
 		pd.FinalBaseFilename = finalBaseFilename
 		// --- Path Generation using pattern --- END ---
 
+		if reason := blockedReason(pd.ModelID, pd.ModelVersionID, cfg); reason != "" {
+			log.Infof("      - Skipping file %s (Version %d): %s.", pd.File.Name, pd.ModelVersionID, reason)
+			activeSummary.recordFiltered(pd, "", reason)
+			if cfg.Download.RecordBlocked && !cfg.Download.DryRun {
+				recordBlockedDownload(db, pd, relPath)
+			}
+			continue
+		}
+
 		dbKey := fmt.Sprintf("v_%d", pd.ModelVersionID)
 		shouldQueue := true
 		existingEntryBytes, errGet := db.Get([]byte(dbKey))
@@ -516,26 +825,36 @@ func filterAndPrepareDownloads(potentialDownloadsPage []potentialDownload, db *d
 		if errGet == nil {
 			var existingEntry models.DatabaseEntry
 			if errUnmarshal := json.Unmarshal(existingEntryBytes, &existingEntry); errUnmarshal == nil {
-				if existingEntry.File.ID == pd.File.ID && existingEntry.File.Hashes.CRC32 == pd.File.Hashes.CRC32 {
+				if cfg.Download.Force {
+					log.Infof("      - Re-queuing file %s (Version %d): --force ignores DB status %s.", pd.File.Name, pd.ModelVersionID, existingEntry.Status)
+					pd.ResetEntry = true
+				} else if existingEntry.File.ID == pd.File.ID && existingEntry.File.Hashes.CRC32 == pd.File.Hashes.CRC32 {
 					if existingEntry.Status == models.StatusDownloaded {
 						// Re-queue if images are requested, as they might need downloading.
-						if cfg.Download.SaveVersionImages || cfg.Download.SaveModelImages {
+						if cfg.Download.SaveVersionImages || cfg.Download.SaveModelImages || cfg.Download.SavePreview {
 							log.Debugf("      - Queuing downloaded file %s (Version %d, File %d) for image check.", pd.File.Name, pd.ModelVersionID, pd.File.ID)
 							shouldQueue = true
 						} else {
 							log.Debugf("      - Skipping file %s (Version %d, File %d): Already marked as downloaded in DB (images not requested).", pd.File.Name, pd.ModelVersionID, pd.File.ID)
+							existingPath := filepath.Join(cfg.SavePath, existingEntry.Folder, existingEntry.Filename)
+							activeSummary.record(progressEventSkipped, pd, existingPath, downloadedFileSize(existingPath, pd.File.SizeKB), existingEntry.Status, nil)
 							shouldQueue = false
 						}
+					} else if attemptsExhausted(&existingEntry, cfg) && !cfg.Download.ForceRetry {
+						log.Debugf("      - Skipping file %s (Version %d, File %d): failed %d times (MaxAttempts %d, use --force-retry to try again).", pd.File.Name, pd.ModelVersionID, pd.File.ID, existingEntry.AttemptCount, cfg.Download.MaxAttempts)
+						activeSummary.recordFiltered(pd, existingEntry.Status, exhaustedReason(&existingEntry, cfg))
+						shouldQueue = false
 					} else {
 						log.Debugf("      - Re-queuing file %s (Version %d, File %d): DB status is %s.", pd.File.Name, pd.ModelVersionID, pd.File.ID, existingEntry.Status)
 						// Correct Folder path if necessary
-						if existingEntry.Folder != correctFolderRelPath {
+						if existingEntry.Folder != correctFolderRelPath && !cfg.Download.DryRun {
 							log.Debugf("      - Correcting Folder path in DB for re-queued item %s: from '%s' to '%s'", dbKey, existingEntry.Folder, correctFolderRelPath)
 							entryToUpdate := existingEntry // Make a copy to modify
 							entryToUpdate.Folder = correctFolderRelPath
 							entryToUpdate.Status = models.StatusPending
 							entryToUpdate.ErrorDetails = ""
 							entryToUpdate.Version = pd.FullVersion
+							entryToUpdate.RawJSON = pd.FullVersion.RawJSON
 							entryToUpdate.File = pd.File
 
 							updatedEntryBytes, marshalErr := json.Marshal(entryToUpdate)
@@ -550,12 +869,18 @@ func filterAndPrepareDownloads(potentialDownloadsPage []potentialDownload, db *d
 					}
 				} else {
 					log.Debugf("      - Queuing file %s (Version %d, File %d): File ID/Hash mismatch with DB entry.", pd.File.Name, pd.ModelVersionID, pd.File.ID)
+					if existingEntry.Status == models.StatusDownloaded {
+						// Civitai replaced the file of a version we already have
+						markFileReplaced(&pd, existingEntry, cfg)
+					}
 					shouldQueue = true
 				}
 			} else {
 				log.WithError(errUnmarshal).Warnf("      - Failed to unmarshal existing DB entry for key %s. Re-queuing.", dbKey)
 				shouldQueue = true
 			}
+		} else if errors.Is(errGet, database.ErrNotFound) && cfg.Download.DryRun {
+			log.Debugf("      - Key %s not found in DB. Dry run, not creating a Pending entry.", dbKey)
 		} else if errors.Is(errGet, database.ErrNotFound) {
 			log.Debugf("      - Key %s not found in DB. Creating Pending entry.", dbKey)
 
@@ -577,6 +902,7 @@ func filterAndPrepareDownloads(potentialDownloadsPage []potentialDownload, db *d
 				Folder:       correctFolderRelPath, // Use the calculated relative path
 				Status:       models.StatusPending,
 				ErrorDetails: "",
+				RawJSON:      pd.FullVersion.RawJSON,
 			}
 			entryBytes, marshalErr := json.Marshal(newEntry)
 			if marshalErr != nil {
@@ -599,22 +925,17 @@ func filterAndPrepareDownloads(potentialDownloadsPage []potentialDownload, db *d
 			continue
 		}
 
-		ignoredBaseModels := cfg.Download.IgnoreBaseModels
-		baseModelMatch := false
-		if len(ignoredBaseModels) > 0 && pd.FullVersion.BaseModel != "" {
-			for _, ignoredBM := range ignoredBaseModels {
-				if ignoredBM != "" && strings.Contains(strings.ToLower(pd.FullVersion.BaseModel), strings.ToLower(ignoredBM)) {
-					baseModelMatch = true
-					break
-				}
-			}
-		}
-		if baseModelMatch {
+		if isIgnoredBaseModel(pd.FullVersion.BaseModel, cfg) {
 			log.Debugf("      - Skipping file %s (Version %d): Belongs to ignored base model '%s'.", pd.File.Name, pd.ModelVersionID, pd.FullVersion.BaseModel)
+			activeSummary.recordFiltered(pd, "", "ignored base model "+pd.FullVersion.BaseModel)
 			continue
 		}
 
 		downloadsToQueueFiltered = append(downloadsToQueueFiltered, pd)
+		if cfg.Download.ContentAddressed && storedObject(pd, cfg) != "" {
+			log.Debugf("      - File %s (Version %d) is already in the object store, it will only be linked.", pd.File.Name, pd.ModelVersionID)
+			continue
+		}
 		totalSizeFiltered += uint64(pd.File.SizeKB) * 1024
 	}
 	return downloadsToQueueFiltered, totalSizeFiltered
@@ -622,14 +943,14 @@ func filterAndPrepareDownloads(potentialDownloadsPage []potentialDownload, db *d
 
 // fetchModelsPaginated retrieves models page by page from the API.
 // ADDED userTotalLimit parameter.
-func fetchModelsPaginated(apiClient *api.Client, db *database.DB, imageDownloader *downloader.Downloader, queryParams models.QueryParameters, cfg *models.Config, userTotalLimit int) ([]potentialDownload, uint64, error) {
+func fetchModelsPaginated(apiClient *api.Client, db *database.DB, imageDownloader *downloader.Downloader, queryParams models.QueryParameters, cfg *models.Config, userTotalLimit int, report *filterReport) ([]potentialDownload, uint64, error) {
 	// Handle single model cases
 	if cfg.Download.ModelID != 0 {
 		return handleSingleModelCase(cfg.Download.ModelID, cfg.Download.AllVersions, db, apiClient, imageDownloader, cfg)
 	}
 
 	// Handle paginated search
-	return handlePaginatedSearch(apiClient, db, queryParams, cfg, userTotalLimit)
+	return handlePaginatedSearch(apiClient, db, queryParams, cfg, userTotalLimit, report)
 }
 
 // handleSingleModelCase handles downloading a single model by ID
@@ -653,8 +974,153 @@ func handleSingleModelCase(modelID int, allVersions bool, db *database.DB, apiCl
 	return handleSingleVersionDownload(latestVersionID, db, apiClient, cfg)
 }
 
-// handlePaginatedSearch handles the paginated API search for models
-func handlePaginatedSearch(apiClient *api.Client, db *database.DB, queryParams models.QueryParameters, cfg *models.Config, userTotalLimit int) ([]potentialDownload, uint64, error) {
+// handleModelIDList processes each model ID as if it had been passed via --model-id.
+// A failing ID is logged and skipped; an error is only returned if every ID failed.
+// With SkipUnchanged (update) models the API reports as not modified are
+// skipped once their versions are downloaded.
+func handleModelIDList(modelIDs []int, db *database.DB, apiClient *api.Client, imageDownloader *downloader.Downloader, cfg *models.Config) ([]potentialDownload, error) {
+	var allPotentialDownloads []potentialDownload
+	var lastErr error
+	failed, unchanged := 0, 0
+
+	for i, modelID := range modelIDs {
+		log.Infof("--- Model %d/%d (ID: %d) ---", i+1, len(modelIDs), modelID)
+		if cfg.Download.SkipUnchanged {
+			// The details are kept in the client's model cache for handleSingleModelCase
+			model, changed, err := apiClient.GetModelDetailsChanged(modelID)
+			if err == nil && !changed && versionsDownloaded(db, model, cfg.Download.AllVersions) {
+				log.Infof("Model %d has not changed since it was last checked, skipping.", modelID)
+				unchanged++
+				continue
+			}
+		}
+		downloads, _, err := handleSingleModelCase(modelID, cfg.Download.AllVersions, db, apiClient, imageDownloader, cfg)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to process model ID %d, continuing with the rest.", modelID)
+			lastErr = err
+			failed++
+		} else {
+			allPotentialDownloads = append(allPotentialDownloads, downloads...)
+		}
+
+		if cfg.APIDelayMs > 0 && i < len(modelIDs)-1 {
+			time.Sleep(time.Duration(cfg.APIDelayMs) * time.Millisecond)
+		}
+	}
+
+	if failed == len(modelIDs) {
+		return nil, fmt.Errorf("all %d model IDs failed, last error: %w", failed, lastErr)
+	}
+	if failed > 0 {
+		log.Warnf("%d of %d model IDs could not be processed.", failed, len(modelIDs))
+	}
+	if unchanged > 0 {
+		log.Infof("Skipped %d of %d models unchanged since they were last checked (--no-cache checks them all).", unchanged, len(modelIDs))
+	}
+	return allPotentialDownloads, nil
+}
+
+// versionsDownloaded reports whether the database records the versions of
+// model that update looks at (every version with allVersions, the latest one
+// otherwise) as Downloaded or Skipped. Validators are stored as soon as the
+// details are fetched, so an unchanged model can still have versions a
+// declined, failed or limited run never downloaded.
+func versionsDownloaded(db *database.DB, model models.Model, allVersions bool) bool {
+	versions := model.ModelVersions
+	if len(versions) == 0 {
+		return false
+	}
+	if !allVersions {
+		versions = versions[:1]
+	}
+	for _, version := range versions {
+		raw, err := db.Get([]byte(fmt.Sprintf("v_%d", version.ID)))
+		if err != nil {
+			return false
+		}
+		var entry models.DatabaseEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return false
+		}
+		if entry.Status != models.StatusDownloaded && entry.Status != models.StatusSkipped {
+			return false
+		}
+	}
+	return true
+}
+
+// handlePaginatedSearch handles the paginated API search for models. The API
+// filters by a single username, so with several Usernames the search is run
+// once per username and the results merged; versions already found for an
+// earlier username are not added again and userTotalLimit applies to the
+// combined total. Models whose files are all dropped by the file filters are
+// added to report.
+func handlePaginatedSearch(apiClient *api.Client, db *database.DB, queryParams models.QueryParameters, cfg *models.Config, userTotalLimit int, report *filterReport) ([]potentialDownload, uint64, error) {
+	if len(cfg.Download.Usernames) <= 1 {
+		return searchModelPages(apiClient, db, queryParams, cfg, userTotalLimit, report)
+	}
+
+	var allPotentialDownloads []potentialDownload
+	var totalDownloadSize uint64
+	seenVersions := make(map[string]bool)
+	for _, username := range cfg.Download.Usernames {
+		limit := userTotalLimit
+		if userTotalLimit > 0 {
+			limit = userTotalLimit - len(allPotentialDownloads)
+			if limit <= 0 {
+				log.Infof("Reached user download limit (%d), skipping the remaining usernames.", userTotalLimit)
+				break
+			}
+		}
+
+		log.Infof("=== Fetching models by %s ===", username)
+		userParams := queryParams
+		userParams.Username = username
+		downloads, _, err := searchModelPages(apiClient, db, userParams, cfg, limit, report)
+
+		newVersions := make(map[string]bool)
+		for _, pd := range downloads {
+			key := fmt.Sprintf("v_%d", pd.ModelVersionID)
+			if seenVersions[key] {
+				log.Debugf("Version %d of %s was already found for an earlier username, skipping.", pd.ModelVersionID, pd.ModelName)
+				continue
+			}
+			newVersions[key] = true
+			allPotentialDownloads = append(allPotentialDownloads, pd)
+			totalDownloadSize += uint64(pd.File.SizeKB) * 1024
+		}
+		for key := range newVersions {
+			seenVersions[key] = true
+		}
+
+		if err != nil {
+			return allPotentialDownloads, totalDownloadSize, err
+		}
+	}
+
+	log.Infof("Found %d potential downloads across %d usernames (%s).", len(allPotentialDownloads), len(cfg.Download.Usernames), usernameCounts(allPotentialDownloads, cfg.Download.Usernames))
+	return allPotentialDownloads, totalDownloadSize, nil
+}
+
+// usernameCounts formats how many of downloads were created by each of
+// usernames, e.g. "alice: 3, bob: 0".
+func usernameCounts(downloads []potentialDownload, usernames []string) string {
+	counts := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		count := 0
+		for _, pd := range downloads {
+			if strings.EqualFold(pd.Creator.Username, username) {
+				count++
+			}
+		}
+		counts = append(counts, fmt.Sprintf("%s: %d", username, count))
+	}
+	return strings.Join(counts, ", ")
+}
+
+// searchModelPages fetches the models of queryParams page by page and returns
+// their potential downloads.
+func searchModelPages(apiClient *api.Client, db *database.DB, queryParams models.QueryParameters, cfg *models.Config, userTotalLimit int, report *filterReport) ([]potentialDownload, uint64, error) {
 	var allPotentialDownloads []potentialDownload
 	var totalDownloadSize uint64
 	var nextCursor string
@@ -693,7 +1159,7 @@ func handlePaginatedSearch(apiClient *api.Client, db *database.DB, queryParams m
 		}
 
 		// Process models on this page
-		potentialDownloadsPage, reachedLimit := processModelsOnPage(response.Items, apiClient, cfg, userTotalLimit, len(allPotentialDownloads))
+		potentialDownloadsPage, reachedLimit := processModelsOnPage(response.Items, apiClient, cfg, userTotalLimit, len(allPotentialDownloads), report)
 
 		// Filter and add to results
 		processedDownloads, pageDownloadSize := filterAndPrepareDownloads(potentialDownloadsPage, db, cfg)
@@ -734,7 +1200,7 @@ func shouldExitEarly(userTotalLimit int, allVersions bool, pageCount int) bool {
 }
 
 // processModelsOnPage processes all models on a single page
-func processModelsOnPage(models []models.Model, apiClient *api.Client, cfg *models.Config, userTotalLimit, currentDownloadCount int) ([]potentialDownload, bool) {
+func processModelsOnPage(models []models.Model, apiClient *api.Client, cfg *models.Config, userTotalLimit, currentDownloadCount int, report *filterReport) ([]potentialDownload, bool) {
 	totalFiles := calculateTotalFiles(models)
 	potentialDownloadsPage := make([]potentialDownload, 0, totalFiles)
 	reachedLimit := false
@@ -752,12 +1218,23 @@ func processModelsOnPage(models []models.Model, apiClient *api.Client, cfg *mode
 			continue
 		}
 
+		// Checked on the list response, before fetching the model's details
+		if shouldSkipModelForStats(model, cfg) {
+			continue
+		}
+
+		// Blocked versions are still fetched when they are to be recorded in the DB
+		if !cfg.Download.RecordBlocked && isBlockedModel(model.ID, cfg) {
+			log.Infof("Skipping model %s (ID: %d): model is blocked", model.Name, model.ID)
+			continue
+		}
+
 		fullModelDetails, err := fetchFullModelDetails(model.ID, apiClient)
 		if err != nil {
 			continue
 		}
 
-		modelDownloads, modelReachedLimit := processModelVersions(fullModelDetails, cfg, userTotalLimit, currentDownloadCount+len(potentialDownloadsPage))
+		modelDownloads, modelReachedLimit := processModelVersions(fullModelDetails, cfg, userTotalLimit, currentDownloadCount+len(potentialDownloadsPage), report)
 		potentialDownloadsPage = append(potentialDownloadsPage, modelDownloads...)
 
 		if modelReachedLimit {
@@ -849,6 +1326,25 @@ func shouldSkipModelForTags(model models.Model, cfg *models.Config) bool {
 	return false
 }
 
+// shouldSkipModelForStats checks if a model has fewer downloads or ratings, or
+// a lower rating, than MinDownloads, MinRatingCount and MinRating require.
+func shouldSkipModelForStats(model models.Model, cfg *models.Config) bool {
+	stats := model.Stats
+	reason := ""
+	switch {
+	case cfg.Download.MinDownloads > 0 && stats.DownloadCount < cfg.Download.MinDownloads:
+		reason = fmt.Sprintf("%d downloads, below --min-downloads %d", stats.DownloadCount, cfg.Download.MinDownloads)
+	case cfg.Download.MinRatingCount > 0 && stats.RatingCount < cfg.Download.MinRatingCount:
+		reason = fmt.Sprintf("%d ratings, below --min-rating-count %d", stats.RatingCount, cfg.Download.MinRatingCount)
+	case cfg.Download.MinRating > 0 && stats.Rating < cfg.Download.MinRating:
+		reason = fmt.Sprintf("rating %.2f, below --min-rating %.2f", stats.Rating, cfg.Download.MinRating)
+	default:
+		return false
+	}
+	log.Debugf("Skipping model %s (ID: %d): %s", model.Name, model.ID, reason)
+	return true
+}
+
 // fetchFullModelDetails fetches complete model details from the API
 func fetchFullModelDetails(modelID int, apiClient *api.Client) (models.Model, error) {
 	log.Debugf("Fetching full details for model %d to ensure accurate version data...", modelID)
@@ -860,9 +1356,16 @@ func fetchFullModelDetails(modelID int, apiClient *api.Client) (models.Model, er
 	return fullModelDetails, nil
 }
 
-// processModelVersions processes all versions of a model and returns potential downloads
-func processModelVersions(fullModelDetails models.Model, cfg *models.Config, userTotalLimit, currentDownloadCount int) ([]potentialDownload, bool) {
+// processModelVersions processes all versions of a model and returns potential downloads.
+// A model whose versions have no file passing the file filters is added to report (may be nil).
+func processModelVersions(fullModelDetails models.Model, cfg *models.Config, userTotalLimit, currentDownloadCount int, report *filterReport) ([]potentialDownload, bool) {
 	var potentialDownloads []potentialDownload
+	var checkedVersions []models.ModelVersion
+
+	if re := cfg.Download.NameRegexp; re != nil && !re.MatchString(fullModelDetails.Name) {
+		log.Debugf("Skipping model %s (ID: %d): name does not match --name-regex %q", fullModelDetails.Name, fullModelDetails.ID, re.String())
+		return nil, false
+	}
 
 	for _, version := range fullModelDetails.ModelVersions {
 		if !passesBaseModelsFilter(version, cfg) {
@@ -874,7 +1377,8 @@ func processModelVersions(fullModelDetails models.Model, cfg *models.Config, use
 			continue
 		}
 
-		versionDownloads, reachedLimit := processVersionFiles(fullModelDetails, version, cfg, userTotalLimit, currentDownloadCount+len(potentialDownloads))
+		checkedVersions = append(checkedVersions, version)
+		versionDownloads, reachedLimit := processVersionFiles(fullModelDetails, version, cfg, userTotalLimit, currentDownloadCount+len(potentialDownloads), report)
 		potentialDownloads = append(potentialDownloads, versionDownloads...)
 
 		if reachedLimit {
@@ -887,18 +1391,34 @@ func processModelVersions(fullModelDetails models.Model, cfg *models.Config, use
 		}
 	}
 
+	if len(potentialDownloads) == 0 && len(checkedVersions) > 0 {
+		report.add(fullModelDetails, checkedVersions, cfg)
+	}
 	return potentialDownloads, false
 }
 
 // processVersionFiles processes all files in a model version
-func processVersionFiles(fullModelDetails models.Model, version models.ModelVersion, cfg *models.Config, userTotalLimit, currentDownloadCount int) ([]potentialDownload, bool) {
-	potentialDownloads := make([]potentialDownload, 0, len(version.Files))
-
-	for _, file := range version.Files {
-		if !passesFileFilters(file, fullModelDetails.Type, cfg) {
-			continue
+func processVersionFiles(fullModelDetails models.Model, version models.ModelVersion, cfg *models.Config, userTotalLimit, currentDownloadCount int, report *filterReport) ([]potentialDownload, bool) {
+	if publishedBeforeSince(version, cfg) {
+		log.Debugf("Skipping version %s (ID: %d) of model %d: published %s, before --since", version.Name, version.ID, fullModelDetails.ID, version.PublishedAt)
+		report.addSinceSkipped()
+		return nil, false
+	}
+	if !cfg.Download.IncludeEarlyAccess && earlyAccessActive(version, time.Now()) {
+		until := "for now"
+		if end, _ := earlyAccessEnd(version); !end.IsZero() {
+			until = "until " + end.Format(time.DateOnly)
 		}
+		log.Infof("Skipping version %s (ID: %d) of model %s: in early access %s (use --include-early-access to try anyway)", version.Name, version.ID, fullModelDetails.Name, until)
+		report.addEarlyAccessSkipped()
+		return nil, false
+	}
 
+	potentialDownloads := make([]potentialDownload, 0, len(version.Files))
+
+	kept := filterVersionFiles(version.Files, fullModelDetails.Type, cfg)
+	recordFilteredFiles(version, kept, fullModelDetails.Type, cfg)
+	for _, file := range kept {
 		// Ensure ModelId is set in the version struct
 		versionForPd := version
 		if versionForPd.ModelId == 0 && fullModelDetails.ID != 0 {
@@ -932,6 +1452,24 @@ func processVersionFiles(fullModelDetails models.Model, version models.ModelVers
 	return potentialDownloads, false
 }
 
+// recordFilteredFiles adds the files of version the file filters left out of
+// kept to the run summary.
+func recordFilteredFiles(version models.ModelVersion, kept []models.File, modelType string, cfg *models.Config) {
+	if activeSummary == nil {
+		return
+	}
+	for _, file := range version.Files {
+		if slices.ContainsFunc(kept, func(k models.File) bool { return k.ID == file.ID }) {
+			continue
+		}
+		reason := fileFilterReason(file, modelType, cfg)
+		if reason == "" {
+			reason = "not selected for the version"
+		}
+		activeSummary.recordFiltered(potentialDownload{ModelVersionID: version.ID, File: file}, "", reason)
+	}
+}
+
 // shouldStopPagination determines if pagination should stop based on various conditions
 func shouldStopPagination(userTotalLimit int, cfg *models.Config, pageCount, currentDownloadCount int, nextCursor string, reachedLimit bool) bool {
 	// Check if user limit reached after processing this page
@@ -959,13 +1497,13 @@ func shouldStopPagination(userTotalLimit int, cfg *models.Config, pageCount, cur
 // and populates a models.QueryParameters struct suitable for the Civitai models API.
 func CreateDownloadQueryParams(cfg *models.Config) models.QueryParameters {
 	// Note: cfg.Download.Usernames is []string but API takes single string.
-	// Taking the first one if provided, otherwise empty.
-	// This might need refinement based on desired behavior.
+	// The download command queries each username in turn; this returns the
+	// query for the first one.
 	username := ""
 	if len(cfg.Download.Usernames) > 0 {
 		username = cfg.Download.Usernames[0]
 		if len(cfg.Download.Usernames) > 1 {
-			log.Warnf("Multiple usernames found in config (Usernames list), but API only supports one. Using the first: %s", username)
+			log.Warnf("Multiple usernames found in config (Usernames list); showing the query for the first: %s", username)
 		}
 	}
 
@@ -977,10 +1515,10 @@ func CreateDownloadQueryParams(cfg *models.Config) models.QueryParameters {
 		Tag:             cfg.Download.Tag,
 		Types:           cfg.Download.ModelTypes,
 		BaseModels:      cfg.Download.BaseModels,
-		PrimaryFileOnly: cfg.Download.PrimaryOnly,
+		PrimaryFileOnly: apiPrimaryFileOnly(cfg),
 		Nsfw:            cfg.Download.Nsfw, // Directly assign the bool
-		// Favorites: // Does not exist in QueryParameters
-		// Hidden: // Does not exist in QueryParameters
+		Favorites:       cfg.Download.Favorites,
+		Hidden:          cfg.Download.Hidden,
 		// Rating: // Does not exist in QueryParameters
 		// Allow fields *do* exist in QueryParameters, but not currently in DownloadConfig
 		// AllowNoCredit:
@@ -995,14 +1533,21 @@ func CreateDownloadQueryParams(cfg *models.Config) models.QueryParameters {
 
 // fetchAndProcessModels orchestrates the entire model fetching process.
 // It sets up the API client and calls fetchModelsPaginated.
-func fetchAndProcessModels(apiClient *api.Client, db *database.DB, queryParams models.QueryParameters, cfg *models.Config) ([]potentialDownload, error) {
+func fetchAndProcessModels(apiClient *api.Client, db *database.DB, queryParams models.QueryParameters, cfg *models.Config, report *filterReport) ([]potentialDownload, error) {
 
 	// Setup image downloader (needed for all-versions case inside fetchModelsPaginated)
 	// Pass the correct arguments: http client, api key, and session cookie
 	imageDownloader := downloader.NewDownloader(apiClient.HttpClient, cfg.APIKey, cfg.SessionCookie)
-
-	// Fetch models - Pass userTotalLimit (cfg.Download.Limit) now
-	allPotentialDownloads, _, err := fetchModelsPaginated(apiClient, db, imageDownloader, queryParams, cfg, cfg.Download.Limit)
+	imageDownloader.SetUserAgent(cfg.UserAgent)
+
+	// Fetch models - Pass userTotalLimit (cfg.Download.Limit, or a larger MetadataLimit) now.
+	// A size-based QueueOrder needs the whole pool to choose from, so when --max-pages
+	// bounds the fetch the limit is only applied after sorting.
+	userTotalLimit := candidateLimit(cfg)
+	if cfg.Download.MaxPages > 0 && cfg.Download.QueueOrder != "" && cfg.Download.QueueOrder != queueOrderNone {
+		userTotalLimit = 0
+	}
+	allPotentialDownloads, _, err := fetchModelsPaginated(apiClient, db, imageDownloader, queryParams, cfg, userTotalLimit, report)
 	if err != nil {
 		// Log the error, but potentially return the downloads found so far?
 		// For now, just return the error.
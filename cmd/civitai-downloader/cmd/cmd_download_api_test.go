@@ -1,8 +1,19 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"go-civitai-download/internal/api"
+	"go-civitai-download/internal/database"
 	"go-civitai-download/internal/models"
 )
 
@@ -161,3 +172,502 @@ func TestShouldSkipModelForTags(t *testing.T) {
 		})
 	}
 }
+
+func TestShouldSkipModelForStats(t *testing.T) {
+	popular := models.Model{ID: 1, Name: "Popular", Stats: models.Stats{DownloadCount: 5000, RatingCount: 40, Rating: 4.8}}
+	obscure := models.Model{ID: 2, Name: "Obscure", Stats: models.Stats{DownloadCount: 12, RatingCount: 1, Rating: 5}}
+
+	tests := []struct {
+		name  string
+		model models.Model
+		cfg   models.DownloadConfig
+		want  bool
+	}{
+		{"no thresholds - never skip", obscure, models.DownloadConfig{}, false},
+		{"enough downloads", popular, models.DownloadConfig{MinDownloads: 1000}, false},
+		{"too few downloads", obscure, models.DownloadConfig{MinDownloads: 1000}, true},
+		{"high rating from a single rating", obscure, models.DownloadConfig{MinRating: 4.5}, false},
+		{"too few ratings", obscure, models.DownloadConfig{MinRating: 4.5, MinRatingCount: 10}, true},
+		{"rating too low", popular, models.DownloadConfig{MinRating: 4.9}, true},
+		{"all thresholds met", popular, models.DownloadConfig{MinDownloads: 5000, MinRating: 4.5, MinRatingCount: 40}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := models.Config{Download: tt.cfg}
+			if got := shouldSkipModelForStats(tt.model, &cfg); got != tt.want {
+				t.Errorf("shouldSkipModelForStats() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessModelVersions_NameRegex(t *testing.T) {
+	file := models.File{ID: 1, Name: "model.safetensors", Hashes: models.Hashes{CRC32: "abcd"}}
+	file.Metadata.Format = "SafeTensor"
+	newModel := func(name string) models.Model {
+		return models.Model{
+			ID:            1,
+			Name:          name,
+			Type:          "LORA",
+			ModelVersions: []models.ModelVersion{{ID: 10, Files: []models.File{file}}},
+		}
+	}
+
+	cfg := &models.Config{}
+	cfg.Download.NameRegexp = regexp.MustCompile(`(?i)^realistic`)
+
+	got, _ := processModelVersions(newModel("Realistic Vision"), cfg, 0, 0, nil)
+	if len(got) != 1 {
+		t.Errorf("matching model: got %d downloads, want 1", len(got))
+	}
+
+	got, _ = processModelVersions(newModel("Anime Style"), cfg, 0, 0, nil)
+	if len(got) != 0 {
+		t.Errorf("non-matching model: got %d downloads, want 0", len(got))
+	}
+}
+
+func TestFileFilterReason_AllowedFormats(t *testing.T) {
+	newFile := func(format string) models.File {
+		file := models.File{Name: "model", Hashes: models.Hashes{CRC32: "abcd"}}
+		file.Metadata.Format = format
+		return file
+	}
+
+	cfg := &models.Config{}
+	cfg.Download.AllowedFormats = []string{"safetensor"}
+	if reason := fileFilterReason(newFile("SafeTensor"), "LORA", cfg); reason != "" {
+		t.Errorf("expected SafeTensor to pass the default, got %q", reason)
+	}
+	if reason := fileFilterReason(newFile("GGUF"), "Checkpoint", cfg); reason == "" {
+		t.Error("expected GGUF to be dropped by the default")
+	}
+
+	cfg.Download.AllowedFormats = []string{"GGUF", ".pt"}
+	for _, format := range []string{"GGUF", "gguf", "PickleTensor"} {
+		if reason := fileFilterReason(newFile(format), "TextualInversion", cfg); reason != "" {
+			t.Errorf("expected %s to be allowed, got %q", format, reason)
+		}
+	}
+	if reason := fileFilterReason(newFile("SafeTensor"), "LORA", cfg); reason == "" {
+		t.Error("expected SafeTensor to be dropped when not listed")
+	}
+
+	// An empty list allows every format, even a missing one
+	cfg.Download.AllowedFormats = nil
+	for _, format := range []string{"Diffusers", "PickleTensor", ""} {
+		if reason := fileFilterReason(newFile(format), "Checkpoint", cfg); reason != "" {
+			t.Errorf("expected format %q to be allowed with an empty list, got %q", format, reason)
+		}
+	}
+}
+
+func TestFileFilterReason_SizeRange(t *testing.T) {
+	newFile := func(sizeKB float64) models.File {
+		return models.File{Name: "model.safetensors", SizeKB: sizeKB, Hashes: models.Hashes{CRC32: "abcd"}}
+	}
+
+	cfg := &models.Config{}
+	cfg.Download.MinSizeMB = 10
+	cfg.Download.MaxSizeMB = 500
+	tests := []struct {
+		sizeKB float64
+		passes bool
+	}{
+		{5 * 1024, false},
+		{10 * 1024, true},
+		{200 * 1024, true},
+		{500 * 1024, true},
+		{6000 * 1024, false},
+	}
+	for _, tt := range tests {
+		if reason := fileFilterReason(newFile(tt.sizeKB), "LORA", cfg); (reason == "") != tt.passes {
+			t.Errorf("file of %.0f KB: passes = %v, want %v (reason %q)", tt.sizeKB, reason == "", tt.passes, reason)
+		}
+	}
+
+	// 0 leaves a bound open
+	cfg.Download.MaxSizeMB = 0
+	if reason := fileFilterReason(newFile(6000*1024), "LORA", cfg); reason != "" {
+		t.Errorf("expected no upper bound with MaxSizeMB 0, got %q", reason)
+	}
+	cfg.Download.MinSizeMB = 0
+	if reason := fileFilterReason(newFile(1), "LORA", cfg); reason != "" {
+		t.Errorf("expected no lower bound with MinSizeMB 0, got %q", reason)
+	}
+}
+
+func TestFilterVersionFiles_PrimaryFallback(t *testing.T) {
+	newFile := func(id int, name, format string, sizeKB float64) models.File {
+		file := models.File{ID: id, Name: name, SizeKB: sizeKB, Hashes: models.Hashes{CRC32: "abcd"}}
+		file.Metadata.Format = format
+		return file
+	}
+	files := []models.File{
+		newFile(1, "small.safetensors", "SafeTensor", 100),
+		newFile(2, "huge.ckpt", "PickleTensor", 9000),
+		newFile(3, "large.safetensors", "SafeTensor", 500),
+	}
+	ids := func(files []models.File) []int {
+		var ids []int
+		for _, f := range files {
+			ids = append(ids, f.ID)
+		}
+		return ids
+	}
+
+	cfg := &models.Config{}
+	cfg.Download.AllowedFormats = []string{"safetensor"}
+	cfg.Download.PrimaryOnly = true
+	if got := filterVersionFiles(files, "LORA", cfg); len(got) != 0 {
+		t.Errorf("without fallback: got files %v, want none", ids(got))
+	}
+
+	cfg.Download.PrimaryFileFallback = true
+	if got := ids(filterVersionFiles(files, "LORA", cfg)); len(got) != 1 || got[0] != 3 {
+		t.Errorf("with fallback: got files %v, want [3]", got)
+	}
+
+	// A version that has a primary file is unaffected
+	withPrimary := append([]models.File{}, files...)
+	withPrimary[0].Primary = true
+	if got := ids(filterVersionFiles(withPrimary, "LORA", cfg)); len(got) != 1 || got[0] != 1 {
+		t.Errorf("with primary: got files %v, want [1]", got)
+	}
+
+	if apiPrimaryFileOnly(cfg) {
+		t.Error("the API must return every file when the fallback is enabled")
+	}
+}
+
+func TestVersionsAfter(t *testing.T) {
+	versions := []models.ModelVersion{
+		{ID: 400, PublishedAt: "2024-03-01T00:00:00.000Z"},
+		{ID: 150, PublishedAt: "2024-02-01T00:00:00.000Z"}, // Lower ID but published after 300
+		{ID: 300, PublishedAt: "2024-01-01T00:00:00.000Z"},
+		{ID: 100, PublishedAt: "2023-06-01T00:00:00.000Z"},
+	}
+
+	newer, err := versionsAfter(versions, 300)
+	if err != nil {
+		t.Fatalf("versionsAfter: %v", err)
+	}
+	var ids []int
+	for _, v := range newer {
+		ids = append(ids, v.ID)
+	}
+	if len(ids) != 2 || ids[0] != 400 || ids[1] != 150 {
+		t.Errorf("expected versions [400 150], got %v", ids)
+	}
+
+	if _, err := versionsAfter(versions, 400); err == nil {
+		t.Error("expected an error when nothing is newer than the latest version")
+	}
+
+	// A reference version no longer listed falls back to comparing IDs
+	newer, err = versionsAfter(versions, 200)
+	if err != nil || len(newer) != 2 {
+		t.Errorf("expected 2 versions with ID > 200, got %v (err %v)", newer, err)
+	}
+}
+
+func TestHandleSingleVersionDownload_FileID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id": 10, "modelId": 1, "name": "v1", "model": {"name": "Model", "type": "Checkpoint"}, "files": [
+			{"id": 100, "name": "full.safetensors", "primary": true, "hashes": {"CRC32": "abcd"}, "metadata": {"format": "SafeTensor"}},
+			{"id": 101, "name": "pruned.safetensors", "hashes": {"CRC32": "bcde"}, "metadata": {"format": "SafeTensor"}}]}`))
+	}))
+	defer server.Close()
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+
+	cfg := &models.Config{APIBaseURL: server.URL, SavePath: t.TempDir()}
+	cfg.Download.VersionPathPattern = "{modelId}"
+	cfg.Download.PrimaryOnly = true
+	cfg.Download.ModelVersionID = 10
+	cfg.Download.FileID = 101
+	apiClient := api.NewClient("", server.Client(), *cfg)
+
+	// The chosen file is downloaded even though --primary-only would drop it
+	got, _, err := handleSingleVersionDownload(10, db, apiClient, cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 1 || got[0].File.ID != 101 {
+		t.Fatalf("expected only file 101, got %+v", got)
+	}
+
+	cfg.Download.FileID = 999
+	_, _, err = handleSingleVersionDownload(10, db, apiClient, cfg)
+	if err == nil || !strings.Contains(err.Error(), "100 (full.safetensors), 101 (pruned.safetensors)") {
+		t.Errorf("expected an error listing the files of the version, got %v", err)
+	}
+}
+
+func TestDoRequestWithRetry_WaitsForMaintenance(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) <= 5 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("<html>Down for maintenance</html>"))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id": 1}`))
+	}))
+	defer server.Close()
+
+	oldInterval := maintenancePollInterval
+	maintenancePollInterval = 10 * time.Millisecond
+	defer func() { maintenancePollInterval = oldInterval }()
+
+	cfg := &models.Config{MaxRetries: 2, InitialRetryDelayMs: 1}
+	req, _ := http.NewRequest("GET", server.URL, nil)
+
+	if _, _, err := doRequestWithRetry(server.Client(), req, cfg, "test"); err == nil {
+		t.Fatal("expected an error after 3 attempts without WaitForMaintenance")
+	}
+
+	atomic.StoreInt32(&requests, 0)
+	cfg.WaitForMaintenance = true
+	_, body, err := doRequestWithRetry(server.Client(), req, cfg, "test")
+	if err != nil {
+		t.Fatalf("expected the request to succeed once maintenance ended: %v", err)
+	}
+	if string(body) != `{"id": 1}` {
+		t.Errorf("unexpected body %q", body)
+	}
+	if got := atomic.LoadInt32(&requests); got != 6 {
+		t.Errorf("expected 6 requests, got %d", got)
+	}
+}
+
+func TestDoRequestWithRetry_SetsUserAgent(t *testing.T) {
+	var agent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent = r.Header.Get("User-Agent")
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	if _, _, err := doRequestWithRetry(server.Client(), req, &models.Config{}, "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if agent != models.DefaultUserAgent() {
+		t.Errorf("expected the default User-Agent, got %q", agent)
+	}
+
+	req, _ = http.NewRequest("GET", server.URL, nil)
+	if _, _, err := doRequestWithRetry(server.Client(), req, &models.Config{UserAgent: "my-archiver/2.0"}, "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if agent != "my-archiver/2.0" {
+		t.Errorf("expected the configured User-Agent, got %q", agent)
+	}
+}
+
+// simulatedRequest starts a rate simulator answering with spec and returns a
+// request to it.
+func simulatedRequest(t *testing.T, spec string) (*api.RateSimulator, *http.Request) {
+	t.Helper()
+	responses, err := api.ParseSimulatedResponses(spec)
+	if err != nil {
+		t.Fatalf("parsing %q: %v", spec, err)
+	}
+	simulator := api.NewRateSimulator(responses)
+	baseURL, stop, err := simulator.Start()
+	if err != nil {
+		t.Fatalf("starting simulator: %v", err)
+	}
+	t.Cleanup(stop)
+	req, _ := http.NewRequest("GET", baseURL+"/models/1", nil)
+	return simulator, req
+}
+
+func TestDoRequestWithRetry_SimulatedRateLimits(t *testing.T) {
+	cfg := &models.Config{MaxRetries: 3, InitialRetryDelayMs: 1}
+
+	simulator, req := simulatedRequest(t, "429x2,503,200")
+	if _, body, err := doRequestWithRetry(http.DefaultClient, req, cfg, "test"); err != nil {
+		t.Fatalf("expected success after the simulated failures: %v", err)
+	} else if string(body) == "" {
+		t.Error("expected the simulated model body")
+	}
+	if got := simulator.Requests(); got != 4 {
+		t.Errorf("expected 4 requests, got %d", got)
+	}
+
+	// Not retried
+	simulator, req = simulatedRequest(t, "404")
+	if _, _, err := doRequestWithRetry(http.DefaultClient, req, cfg, "test"); err == nil {
+		t.Error("expected a 404 to fail")
+	}
+	if got := simulator.Requests(); got != 1 {
+		t.Errorf("expected a 404 not to be retried, got %d requests", got)
+	}
+
+	// Retries give up after MaxRetries
+	simulator, req = simulatedRequest(t, "429x10")
+	if _, _, err := doRequestWithRetry(http.DefaultClient, req, cfg, "test"); err == nil {
+		t.Error("expected an error once the retries ran out")
+	}
+	if got := simulator.Requests(); got != 4 {
+		t.Errorf("expected MaxRetries+1 = 4 requests, got %d", got)
+	}
+}
+
+func TestDoRequestWithRetry_HonorsRetryAfter(t *testing.T) {
+	cfg := &models.Config{MaxRetries: 1, InitialRetryDelayMs: 1}
+	_, req := simulatedRequest(t, "429:1,200")
+
+	start := time.Now()
+	if _, _, err := doRequestWithRetry(http.DefaultClient, req, cfg, "test"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("expected to wait the 1s Retry-After, retried after %v", elapsed)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	initial := 100 * time.Millisecond
+	if got := retryBackoff(initial, 0, 3, 0, false); got != 400*time.Millisecond {
+		t.Errorf("expected the full 400ms backoff without jitter, got %v", got)
+	}
+	if got := retryBackoff(initial, 0, 3, 2*time.Second, true); got != 2*time.Second {
+		t.Errorf("expected the Retry-After wait, got %v", got)
+	}
+	if got := retryBackoff(time.Second, 0, 1, 50*time.Millisecond, false); got != 50*time.Millisecond {
+		t.Errorf("expected a shorter Retry-After to win over the backoff, got %v", got)
+	}
+	for i := 0; i < 100; i++ {
+		if got := retryBackoff(initial, 0, 3, 0, true); got < 0 || got > 400*time.Millisecond {
+			t.Fatalf("jittered backoff %v outside [0, 400ms]", got)
+		}
+	}
+
+	// MaxRetryDelayMs caps the backoff, however many retries were made
+	if got := retryBackoff(initial, time.Second, 4, 0, false); got != 800*time.Millisecond {
+		t.Errorf("expected the 800ms backoff below the cap, got %v", got)
+	}
+	if got := retryBackoff(initial, time.Second, 5, 0, false); got != time.Second {
+		t.Errorf("expected the backoff capped at 1s, got %v", got)
+	}
+	if got := retryBackoff(initial, time.Second, 200, 0, false); got != time.Second {
+		t.Errorf("expected a large attempt number to stay at the 1s cap, got %v", got)
+	}
+	if got := retryBackoff(initial, time.Second, 200, 3*time.Second, false); got != 3*time.Second {
+		t.Errorf("expected a Retry-After wait above the cap to be used as sent, got %v", got)
+	}
+}
+
+func TestDoRequestWithRetry_ElapsedBudget(t *testing.T) {
+	cfg := &models.Config{MaxRetries: 10, InitialRetryDelayMs: 100, MaxRetryDelayMs: 100, MaxRetryElapsedMs: 250}
+	simulator, req := simulatedRequest(t, "503x20")
+
+	_, _, err := doRequestWithRetry(http.DefaultClient, req, cfg, "test")
+	if !errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Fatalf("expected the retry budget to run out, got %v", err)
+	}
+	if got := simulator.Requests(); got < 2 || got > 3 {
+		t.Errorf("expected the budget to stop retrying after 2-3 requests, got %d", got)
+	}
+
+	// Running out of attempts is a different error
+	cfg = &models.Config{MaxRetries: 2, InitialRetryDelayMs: 1, MaxRetryElapsedMs: 60000}
+	simulator, req = simulatedRequest(t, "503x20")
+	_, _, err = doRequestWithRetry(http.DefaultClient, req, cfg, "test")
+	if err == nil || errors.Is(err, ErrRetryBudgetExhausted) {
+		t.Fatalf("expected attempt exhaustion rather than the retry budget, got %v", err)
+	}
+	if got := simulator.Requests(); got != 3 {
+		t.Errorf("expected MaxRetries+1 = 3 requests, got %d", got)
+	}
+}
+
+func TestDoRequestWithRetry_SimulatedOutageOpensBreaker(t *testing.T) {
+	oldBreaker := api.SharedBreaker
+	api.SharedBreaker = api.NewCircuitBreaker(time.Minute, time.Minute)
+	defer func() { api.SharedBreaker = oldBreaker }()
+
+	cfg := &models.Config{MaxRetries: 5, InitialRetryDelayMs: 1, CircuitBreakerThreshold: 3}
+	simulator, req := simulatedRequest(t, "503x10")
+	_, _, err := doRequestWithRetry(http.DefaultClient, req, cfg, "test")
+	if !errors.Is(err, api.ErrCircuitOpen) {
+		t.Fatalf("expected the circuit breaker to open, got %v", err)
+	}
+	if got := simulator.Requests(); got != 3 {
+		t.Errorf("expected requests to stop at the threshold of 3, got %d", got)
+	}
+}
+
+func TestHandlePaginatedSearch_MultipleUsernames(t *testing.T) {
+	newModel := func(id int, creator string) string {
+		return fmt.Sprintf(`{"id": %d, "name": "Model %d", "type": "LORA", "creator": {"username": %q},
+			"modelVersions": [{"id": %d, "files": [{"id": %d, "name": "m%d.safetensors", "hashes": {"CRC32": "abcd"}, "metadata": {"format": "SafeTensor"}}]}]}`,
+			id, id, creator, id*10, id*100, id)
+	}
+	byUser := map[string][]int{"alice": {1, 2}, "bob": {3, 1}} // Model 1 shows up for both
+	creators := map[int]string{1: "alice", 2: "alice", 3: "bob"}
+	var queried []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/models" {
+			username := r.URL.Query().Get("username")
+			queried = append(queried, username)
+			var items []string
+			for _, id := range byUser[username] {
+				items = append(items, newModel(id, creators[id]))
+			}
+			_, _ = fmt.Fprintf(w, `{"items": [%s], "metadata": {}}`, strings.Join(items, ","))
+			return
+		}
+		var id int
+		_, _ = fmt.Sscanf(r.URL.Path, "/models/%d", &id)
+		_, _ = w.Write([]byte(newModel(id, creators[id])))
+	}))
+	defer server.Close()
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = db.Close() }()
+
+	cfg := &models.Config{APIBaseURL: server.URL, SavePath: t.TempDir()}
+	cfg.Download.Usernames = []string{"alice", "bob"}
+	cfg.Download.VersionPathPattern = "{modelId}"
+	apiClient := api.NewClient("", server.Client(), *cfg)
+
+	got, _, err := handlePaginatedSearch(apiClient, db, buildQueryParameters(cfg), cfg, 0, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(queried, ",") != "alice,bob" {
+		t.Errorf("queried usernames %v, want alice then bob", queried)
+	}
+	var versions []int
+	for _, pd := range got {
+		versions = append(versions, pd.ModelVersionID)
+	}
+	if fmt.Sprint(versions) != "[10 20 30]" {
+		t.Errorf("got versions %v, want [10 20 30] with version 10 only once", versions)
+	}
+	if counts := usernameCounts(got, cfg.Download.Usernames); counts != "alice: 2, bob: 1" {
+		t.Errorf("usernameCounts() = %q", counts)
+	}
+
+	// --limit applies to the combined total
+	queried = nil
+	got, _, err = handlePaginatedSearch(apiClient, db, buildQueryParameters(cfg), cfg, 2, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || strings.Join(queried, ",") != "alice" {
+		t.Errorf("with limit 2: got %d downloads from %v, want 2 from alice only", len(got), queried)
+	}
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteAria2InputFile(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "aria2.txt")
+	downloads := []potentialDownload{
+		{
+			ModelVersionID: 11,
+			TargetFilepath: filepath.Join("models", "lora", "11_model.safetensors"),
+			File: models.File{
+				DownloadUrl: "https://civitai.com/api/download/models/11?type=Model",
+				Hashes:      models.Hashes{SHA256: "ABCDEF"},
+			},
+		},
+		{
+			ModelVersionID: 12,
+			TargetFilepath: filepath.Join("models", "vae", "12_vae.pt"),
+			File:           models.File{DownloadUrl: "https://civitai.com/api/download/models/12"},
+		},
+	}
+
+	require.NoError(t, writeAria2InputFile(out, downloads, "secret", "test-agent/1.0"))
+
+	info, err := os.Stat(out)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), info.Mode().Perm())
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+
+	assert.Equal(t, "https://civitai.com/api/download/models/11?token=secret&type=Model", lines[0])
+	assert.Equal(t, "  dir="+filepath.Join("models", "lora"), lines[1])
+	assert.Equal(t, "  out=11_model.safetensors", lines[2])
+	assert.Equal(t, "  header=User-Agent: test-agent/1.0", lines[3])
+	assert.Equal(t, "  checksum=sha-256=abcdef", lines[4])
+	assert.Equal(t, "https://civitai.com/api/download/models/12?token=secret", lines[5])
+	assert.NotContains(t, strings.Join(lines[5:], "\n"), "checksum=", "no checksum without a SHA256")
+}
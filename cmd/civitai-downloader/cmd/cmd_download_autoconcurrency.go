@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// autoConcurrencyStart is how many downloads --concurrency auto starts with
+	autoConcurrencyStart = 2
+	// autoConcurrencyInterval is how often the throughput is measured and the limit adjusted
+	autoConcurrencyInterval = 10 * time.Second
+	// autoConcurrencyGain is how much the throughput must rise for another download to be kept
+	autoConcurrencyGain = 1.1
+)
+
+// autoConcurrency limits how many downloads run at once for --concurrency
+// auto (Download.AutoConcurrency). The pool is started with MaxConcurrency
+// workers and each one takes a slot before downloading; the limit starts low
+// and is raised while the throughput keeps rising, and lowered again when it
+// stops rising or downloads fail. A nil limiter does not limit.
+type autoConcurrency struct {
+	mu     sync.Mutex
+	wake   chan struct{} // Closed and replaced when a slot frees up or the limit is raised
+	limit  int
+	max    int
+	active int
+
+	lastRate     float64 // Bytes per second over the previous interval
+	lastFailures int64
+	grew         bool // The previous adjustment raised the limit
+}
+
+// newAutoConcurrency returns the limiter for a run, or nil when
+// Download.AutoConcurrency is off.
+func newAutoConcurrency(cfg *models.Config) *autoConcurrency {
+	if !cfg.Download.AutoConcurrency {
+		return nil
+	}
+	maxWorkers := cfg.Download.MaxConcurrency
+	if maxWorkers < 1 {
+		maxWorkers = 1
+	}
+	limit := autoConcurrencyStart
+	if limit > maxWorkers {
+		limit = maxWorkers
+	}
+	return &autoConcurrency{wake: make(chan struct{}), limit: limit, max: maxWorkers}
+}
+
+// acquire waits for a free slot. It returns false without one if either
+// context is done first.
+func (a *autoConcurrency) acquire(runCtx, stopCtx context.Context) bool {
+	if a == nil {
+		return true
+	}
+	for {
+		a.mu.Lock()
+		if a.active < a.limit {
+			a.active++
+			a.mu.Unlock()
+			return true
+		}
+		wake := a.wake
+		a.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-runCtx.Done():
+			return false
+		case <-stopCtx.Done():
+			return false
+		}
+	}
+}
+
+// release frees a slot taken by acquire.
+func (a *autoConcurrency) release() {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	a.active--
+	a.broadcast()
+	a.mu.Unlock()
+}
+
+// broadcast wakes every waiting acquire. a.mu must be held.
+func (a *autoConcurrency) broadcast() {
+	close(a.wake)
+	a.wake = make(chan struct{})
+}
+
+// current returns the limit.
+func (a *autoConcurrency) current() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.limit
+}
+
+// adjust moves the limit after an interval with the given throughput in bytes
+// per second and total number of failed downloads so far, and returns the new
+// limit. New failures lower it; otherwise it is raised by one while each step
+// brought at least autoConcurrencyGain more throughput, and the last step is
+// undone once it did not.
+func (a *autoConcurrency) adjust(rate float64, failures int64) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	newFailures := failures > a.lastFailures
+	a.lastFailures = failures
+	switch {
+	case newFailures:
+		if a.limit > 1 {
+			a.limit--
+		}
+		a.grew = false
+	case rate > 0 && (a.lastRate == 0 || rate >= a.lastRate*autoConcurrencyGain):
+		if a.limit < a.max {
+			a.limit++
+			a.grew = true
+			a.broadcast()
+		} else {
+			a.grew = false
+		}
+	case a.grew:
+		// The last extra download did not pay off
+		a.limit--
+		a.grew = false
+	}
+	if rate > 0 {
+		a.lastRate = rate
+	}
+	return a.limit
+}
+
+// start measures the throughput of fileDownloader every
+// autoConcurrencyInterval and adjusts the limit until the returned stop
+// function is called.
+func (a *autoConcurrency) start(fileDownloader *downloader.Downloader, tally *downloadTally) (stop func()) {
+	if a == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(autoConcurrencyInterval)
+		defer ticker.Stop()
+
+		lastBytes := fileDownloader.BytesReceived()
+		lastTime := time.Now()
+		for {
+			select {
+			case <-done:
+				return
+			case now := <-ticker.C:
+				bytes := fileDownloader.BytesReceived()
+				rate := float64(bytes-lastBytes) / now.Sub(lastTime).Seconds()
+				lastBytes, lastTime = bytes, now
+
+				before := a.current()
+				after := a.adjust(rate, atomic.LoadInt64(&tally.Failed))
+				if after != before {
+					log.Infof("Auto concurrency: %s/s, %d -> %d downloads", helpers.BytesToSize(uint64(rate)), before, after)
+				} else {
+					log.Debugf("Auto concurrency: %s/s, keeping %d downloads", helpers.BytesToSize(uint64(rate)), after)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"context"
+	"testing"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoConcurrencyAdjust(t *testing.T) {
+	cfg := &models.Config{}
+	assert.Nil(t, newAutoConcurrency(cfg), "off unless AutoConcurrency is set")
+
+	cfg.Download.AutoConcurrency = true
+	cfg.Download.MaxConcurrency = 4
+	a := newAutoConcurrency(cfg)
+	assert.Equal(t, autoConcurrencyStart, a.current())
+
+	// Raised while each step brings more throughput, up to MaxConcurrency
+	assert.Equal(t, 3, a.adjust(100, 0))
+	assert.Equal(t, 4, a.adjust(200, 0))
+	assert.Equal(t, 4, a.adjust(300, 0))
+
+	// Back one step once the throughput stops rising, then held
+	a.grew = true
+	assert.Equal(t, 3, a.adjust(305, 0))
+	assert.Equal(t, 3, a.adjust(300, 0))
+
+	// Failures lower it, but never below one
+	assert.Equal(t, 2, a.adjust(300, 1))
+	assert.Equal(t, 1, a.adjust(300, 2))
+	assert.Equal(t, 1, a.adjust(300, 3))
+	assert.Equal(t, 1, a.adjust(300, 3), "old failures do not count again")
+
+	cfg.Download.MaxConcurrency = 1
+	assert.Equal(t, 1, newAutoConcurrency(cfg).current(), "the start is capped by MaxConcurrency")
+}
+
+func TestAutoConcurrencyAcquire(t *testing.T) {
+	var a *autoConcurrency
+	assert.True(t, a.acquire(context.Background(), context.Background()), "a nil limiter does not limit")
+	a.release()
+
+	a = newAutoConcurrency(&models.Config{Download: models.DownloadConfig{AutoConcurrency: true, MaxConcurrency: 4}})
+	ctx := context.Background()
+	assert.True(t, a.acquire(ctx, ctx))
+	assert.True(t, a.acquire(ctx, ctx))
+
+	// Both slots taken: a stopped run gives up instead of waiting
+	stopped, cancel := context.WithCancel(ctx)
+	cancel()
+	assert.False(t, a.acquire(ctx, stopped))
+
+	// Raising the limit wakes a waiting worker
+	got := make(chan bool)
+	go func() { got <- a.acquire(ctx, ctx) }()
+	a.adjust(100, 0)
+	assert.True(t, <-got)
+
+	// So does a finished download
+	go func() { got <- a.acquire(ctx, ctx) }()
+	a.release()
+	assert.True(t, <-got)
+}
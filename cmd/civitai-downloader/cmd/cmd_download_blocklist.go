@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// parseBlocklist reads a blocklist: model IDs, and version IDs prefixed with
+// "v" (e.g. v123456), separated by whitespace or commas. Everything after a #
+// on a line is a comment.
+func parseBlocklist(r io.Reader) (modelIDs []int, versionIDs []int, err error) {
+	scanner := bufio.NewScanner(r)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		for _, token := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			isVersion := strings.HasPrefix(token, "v") || strings.HasPrefix(token, "V")
+			id, convErr := strconv.Atoi(strings.TrimLeft(token, "vV"))
+			if convErr != nil || id <= 0 {
+				return nil, nil, fmt.Errorf("line %d: invalid ID %q: use a model ID or v<version ID>", lineNum, token)
+			}
+			if isVersion {
+				versionIDs = append(versionIDs, id)
+			} else {
+				modelIDs = append(modelIDs, id)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, fmt.Errorf("reading blocklist: %w", err)
+	}
+	return modelIDs, versionIDs, nil
+}
+
+// loadBlocklistFile adds the IDs listed in cfg.Download.BlocklistFile to the
+// blocked model and version IDs.
+func loadBlocklistFile(cfg *models.Config) error {
+	path := cfg.Download.BlocklistFile
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(helpers.LongPath(path)) // #nosec G304 -- path comes from the user's config or flags
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	modelIDs, versionIDs, err := parseBlocklist(f)
+	if err != nil {
+		return err
+	}
+	cfg.Download.BlockedModelIDs = append(cfg.Download.BlockedModelIDs, modelIDs...)
+	cfg.Download.BlockedVersionIDs = append(cfg.Download.BlockedVersionIDs, versionIDs...)
+	log.Infof("Loaded %d blocked model ID(s) and %d blocked version ID(s) from %s", len(modelIDs), len(versionIDs), path)
+	return nil
+}
+
+// isBlockedModel reports whether modelID is in BlockedModelIDs.
+func isBlockedModel(modelID int, cfg *models.Config) bool {
+	for _, id := range cfg.Download.BlockedModelIDs {
+		if id == modelID {
+			return true
+		}
+	}
+	return false
+}
+
+// blockedReason returns why a version must not be downloaded, or "" when it is not blocked.
+func blockedReason(modelID, versionID int, cfg *models.Config) string {
+	if isBlockedModel(modelID, cfg) {
+		return fmt.Sprintf("model %d is blocked", modelID)
+	}
+	for _, id := range cfg.Download.BlockedVersionIDs {
+		if id == versionID {
+			return fmt.Sprintf("version %d is blocked", versionID)
+		}
+	}
+	return ""
+}
+
+// recordBlockedDownload stores a blocked version in the database with status
+// Skipped. Versions already downloaded are left as they are.
+func recordBlockedDownload(db *database.DB, pd potentialDownload, relPath string) {
+	dbKey := fmt.Sprintf("v_%d", pd.ModelVersionID)
+	var entry models.DatabaseEntry
+	raw, err := db.Get([]byte(dbKey))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			log.WithError(err).Warnf("      - Failed to unmarshal DB entry %s, not recording it as blocked", dbKey)
+			return
+		}
+		if entry.Status == models.StatusDownloaded || entry.Status == models.StatusSkipped {
+			return
+		}
+	case errors.Is(err, database.ErrNotFound):
+		entry = models.DatabaseEntry{
+			ModelID:   pd.ModelID,
+			ModelName: pd.ModelName,
+			ModelType: pd.ModelType,
+			Version:   pd.FullVersion,
+			Creator:   pd.Creator,
+			Filename:  pd.FinalBaseFilename,
+			Folder:    relPath,
+			RawJSON:   pd.FullVersion.RawJSON,
+		}
+		if entry.Version.ModelId == 0 {
+			entry.Version.ModelId = pd.ModelID
+		}
+	default:
+		log.WithError(err).Warnf("      - Failed to read DB entry %s, not recording it as blocked", dbKey)
+		return
+	}
+
+	entry.File = pd.File
+	entry.Status = models.StatusSkipped
+	entry.ErrorDetails = ""
+	entry.Timestamp = time.Now().Unix()
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		log.WithError(err).Warnf("      - Failed to marshal DB entry %s", dbKey)
+		return
+	}
+	if err := db.Put([]byte(dbKey), entryBytes); err != nil {
+		log.WithError(err).Warnf("      - Failed to record blocked version in DB entry %s", dbKey)
+		return
+	}
+	log.Debugf("      - Recorded %s as %s", dbKey, models.StatusSkipped)
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseBlocklist(t *testing.T) {
+	modelIDs, versionIDs, err := parseBlocklist(strings.NewReader("# never again\n123 456, v789\n\nV1011 # duplicate upload\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []int{123, 456}, modelIDs)
+	assert.Equal(t, []int{789, 1011}, versionIDs)
+
+	_, _, err = parseBlocklist(strings.NewReader("123\nabc\n"))
+	assert.ErrorContains(t, err, "line 2")
+}
+
+func TestFilterAndPrepareDownloads_Blocklist(t *testing.T) {
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.VersionPathPattern = "{modelType}/{modelName}"
+	cfg.Download.BlockedModelIDs = []int{5}
+	cfg.Download.BlockedVersionIDs = []int{601}
+
+	newPD := func(modelID, versionID int) potentialDownload {
+		return potentialDownload{
+			ModelID:        modelID,
+			ModelName:      "Model",
+			ModelVersionID: versionID,
+			FullModel:      models.Model{ID: modelID, Name: "Model", Type: "LORA"},
+			FullVersion:    models.ModelVersion{ID: versionID},
+			File:           models.File{ID: versionID, Name: "model.safetensors"},
+		}
+	}
+	candidates := []potentialDownload{newPD(5, 500), newPD(6, 600), newPD(6, 601)}
+
+	queue, _ := filterAndPrepareDownloads(candidates, db, cfg)
+	require.Len(t, queue, 1)
+	assert.Equal(t, 600, queue[0].ModelVersionID)
+	_, err = db.Get([]byte("v_500"))
+	assert.ErrorIs(t, err, database.ErrNotFound, "blocked versions are not recorded unless RecordBlocked is set")
+
+	cfg.Download.RecordBlocked = true
+	queue, _ = filterAndPrepareDownloads(candidates, db, cfg)
+	require.Len(t, queue, 1)
+	assert.Equal(t, models.StatusSkipped, entryStatus(t, db, 500))
+	assert.Equal(t, models.StatusSkipped, entryStatus(t, db, 601))
+	assert.Equal(t, models.StatusPending, entryStatus(t, db, 600))
+}
@@ -0,0 +1,89 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// civitaiInfo is the layout of the <model>.civitai.info sidecar read by the
+// Stable Diffusion WebUI Civitai Helper extension. It is the model-versions
+// API response, limited to the downloaded file.
+type civitaiInfo struct {
+	ID           int                  `json:"id"`
+	ModelID      int                  `json:"modelId"`
+	Name         string               `json:"name"`
+	CreatedAt    string               `json:"createdAt"`
+	UpdatedAt    string               `json:"updatedAt"`
+	TrainedWords []string             `json:"trainedWords"`
+	BaseModel    string               `json:"baseModel"`
+	Description  string               `json:"description"`
+	DownloadURL  string               `json:"downloadUrl"`
+	Model        models.BaseModelInfo `json:"model"`
+	Files        []models.File        `json:"files"`
+	Images       []models.ModelImage  `json:"images"`
+}
+
+// newCivitaiInfo builds the sidecar for the file of pd.
+func newCivitaiInfo(pd potentialDownload) civitaiInfo {
+	version := pd.FullVersion
+	info := civitaiInfo{
+		ID:           pd.ModelVersionID,
+		ModelID:      pd.ModelID,
+		Name:         version.Name,
+		CreatedAt:    version.CreatedAt,
+		UpdatedAt:    version.UpdatedAt,
+		TrainedWords: version.TrainedWords,
+		BaseModel:    version.BaseModel,
+		Description:  version.Description,
+		DownloadURL:  version.DownloadUrl,
+		Model:        version.Model,
+		Files:        []models.File{pd.File},
+		Images:       pd.OriginalImages,
+	}
+	// The extension expects lists, not null
+	if info.TrainedWords == nil {
+		info.TrainedWords = []string{}
+	}
+	if info.Images == nil {
+		info.Images = []models.ModelImage{}
+	}
+	if info.Name == "" {
+		info.Name = pd.VersionName
+	}
+	if info.BaseModel == "" {
+		info.BaseModel = pd.BaseModel
+	}
+	if info.Model.Name == "" {
+		info.Model.Name = pd.ModelName
+		info.Model.Type = pd.ModelType
+	}
+	return info
+}
+
+// saveCivitaiInfoFile writes the <model>.civitai.info sidecar next to the
+// downloaded model file (--civitai-info).
+func saveCivitaiInfoFile(pd potentialDownload, modelFilePath string, cfg *models.Config) error {
+	infoPath := strings.TrimSuffix(modelFilePath, filepath.Ext(modelFilePath)) + ".civitai.info"
+	log.Debugf("Attempting to save civitai.info to: %s", infoPath)
+
+	jsonData, jsonErr := helpers.MarshalMetadata(newCivitaiInfo(pd), cfg.JSONCompact)
+	if jsonErr != nil {
+		log.WithError(jsonErr).Errorf("Failed to marshal civitai.info for %s (VersionID: %d)", pd.ModelName, pd.ModelVersionID)
+		return fmt.Errorf("failed to marshal civitai.info: %w", jsonErr)
+	}
+
+	if writeErr := os.WriteFile(helpers.LongPath(infoPath), jsonData, 0600); writeErr != nil {
+		log.WithError(writeErr).Errorf("Failed to write civitai.info file %s", infoPath)
+		return fmt.Errorf("failed to write civitai.info file %s: %w", infoPath, writeErr)
+	}
+
+	log.Debugf("Successfully saved civitai.info file: %s", infoPath)
+	return nil
+}
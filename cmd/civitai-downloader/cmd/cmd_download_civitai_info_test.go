@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleMetadataSavingWritesCivitaiInfo(t *testing.T) {
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "42_model.safetensors")
+	pd := potentialDownload{
+		ModelName:      "My LoRA",
+		ModelType:      "LORA",
+		ModelID:        7,
+		ModelVersionID: 42,
+		FullVersion: models.ModelVersion{
+			ID:           42,
+			ModelId:      7,
+			Name:         "v1.0",
+			BaseModel:    "SDXL 1.0",
+			TrainedWords: []string{"mylora"},
+			Model:        models.BaseModelInfo{Name: "My LoRA", Type: "LORA"},
+			Files:        []models.File{{ID: 1, Name: "model.safetensors"}, {ID: 2, Name: "other.safetensors"}},
+		},
+		File:           models.File{ID: 1, Name: "model.safetensors", Hashes: models.Hashes{SHA256: "ABCD"}},
+		OriginalImages: []models.ModelImage{{ID: 9, URL: "https://image.civitai.com/9.jpeg"}},
+	}
+	cfg := &models.Config{}
+
+	require.NoError(t, handleMetadataSaving("test", pd, modelPath, models.StatusDownloaded, nil, cfg))
+	assert.NoFileExists(t, filepath.Join(dir, "42_model.civitai.info"), "disabled by default")
+
+	cfg.Download.SaveCivitaiInfo = true
+	require.NoError(t, handleMetadataSaving("test", pd, modelPath, models.StatusDownloaded, nil, cfg))
+	data, err := os.ReadFile(filepath.Join(dir, "42_model.civitai.info"))
+	require.NoError(t, err)
+
+	var info map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &info))
+	assert.EqualValues(t, 42, info["id"])
+	assert.EqualValues(t, 7, info["modelId"])
+	assert.Equal(t, "SDXL 1.0", info["baseModel"])
+	assert.Equal(t, []interface{}{"mylora"}, info["trainedWords"])
+	assert.Equal(t, "LORA", info["model"].(map[string]interface{})["type"])
+	files := info["files"].([]interface{})
+	require.Len(t, files, 1, "only the downloaded file is listed")
+	assert.Equal(t, "ABCD", files[0].(map[string]interface{})["hashes"].(map[string]interface{})["SHA256"])
+	assert.Len(t, info["images"], 1)
+}
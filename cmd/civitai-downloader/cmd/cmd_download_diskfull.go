@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// diskFullGate stops the run from working through the whole queue once the
+// disk is full. The first worker to hit a full disk asks the user to free up
+// space and continue, or to abort; under --yes the run is aborted right away.
+// While the question is open the other workers wait instead of failing too.
+type diskFullGate struct {
+	mu        sync.Mutex
+	in        *bufio.Reader // nil aborts without asking
+	out       io.Writer
+	cancel    func() // Stops the run on abort
+	resumedAt time.Time
+	aborted   bool
+}
+
+// newDiskFullGate returns a gate asking on in and out, or aborting without
+// asking when in is nil.
+func newDiskFullGate(in io.Reader, out io.Writer, cancel func()) *diskFullGate {
+	g := &diskFullGate{out: out, cancel: cancel}
+	if in != nil {
+		g.in = bufio.NewReader(in)
+	}
+	return g
+}
+
+// runDiskFullGate returns the gate for a download run: it asks on the
+// terminal, or aborts without asking under --yes.
+func runDiskFullGate(cfg *models.Config, cancel func()) *diskFullGate {
+	if cfg.Download.SkipConfirmation {
+		return newDiskFullGate(nil, os.Stdout, cancel)
+	}
+	return newDiskFullGate(os.Stdin, os.Stdout, cancel)
+}
+
+// wait handles a write that failed at failedAt because the disk is full. It
+// returns true when the write should be retried, false when the run is being
+// aborted. Writes that failed before the user last chose to continue are
+// retried without asking again.
+func (g *diskFullGate) wait(err error, failedAt time.Time) bool {
+	if g == nil {
+		return false
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.aborted {
+		return false
+	}
+	if !g.resumedAt.IsZero() && g.resumedAt.After(failedAt) {
+		return true
+	}
+	if g.in == nil {
+		log.Errorf("Disk full, aborting the run; remaining downloads stay queued for --resume: %v", err)
+		g.abort()
+		return false
+	}
+
+	_, _ = fmt.Fprintf(g.out, "\nDisk full: %v\n", err)
+	for {
+		_, _ = fmt.Fprint(g.out, "Free up space and continue, or abort the run? (c/a): ")
+		input, readErr := g.in.ReadString('\n')
+		if readErr != nil {
+			log.WithError(readErr).Error("Error reading input, aborting the run.")
+			g.abort()
+			return false
+		}
+		switch strings.TrimSpace(strings.ToLower(input)) {
+		case "c", "continue":
+			g.resumedAt = time.Now()
+			return true
+		case "a", "abort":
+			log.Warn("Run aborted because the disk is full; remaining downloads stay queued for --resume.")
+			g.abort()
+			return false
+		default:
+			_, _ = fmt.Fprintln(g.out, "Invalid input. Please enter 'c' or 'a'.")
+		}
+	}
+}
+
+// waitIfPaused blocks while another worker is asking what to do about a full disk.
+func (g *diskFullGate) waitIfPaused() {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+}
+
+func (g *diskFullGate) abort() {
+	g.aborted = true
+	if g.cancel != nil {
+		g.cancel()
+	}
+}
+
+// freeSpaceWarning returns a warning when the filesystem holding savePath has
+// less than needed bytes free, or "" when there is enough or it is unknown.
+func freeSpaceWarning(savePath string, needed uint64) string {
+	free, ok := helpers.FreeSpace(savePath)
+	if !ok || free >= needed {
+		return ""
+	}
+	return fmt.Sprintf("Only %s free in %s but the queued downloads need %s", helpers.BytesToSize(free), savePath, helpers.BytesToSize(needed))
+}
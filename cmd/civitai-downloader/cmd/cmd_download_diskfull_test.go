@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskFullGate(t *testing.T) {
+	errFull := errors.New("no space left on device")
+
+	// Under --yes the run is aborted without asking
+	cancelled := 0
+	gate := newDiskFullGate(nil, &bytes.Buffer{}, func() { cancelled++ })
+	assert.False(t, gate.wait(errFull, time.Now()))
+	assert.False(t, gate.wait(errFull, time.Now()), "an aborted run stays aborted")
+	assert.Equal(t, 1, cancelled)
+	assert.True(t, gate.aborted)
+
+	var out bytes.Buffer
+	cancelled = 0
+	gate = newDiskFullGate(strings.NewReader("x\nc\na\n"), &out, func() { cancelled++ })
+	failedAt := time.Now()
+	assert.True(t, gate.wait(errFull, failedAt), "continue retries the write")
+	assert.Contains(t, out.String(), "Invalid input")
+	assert.True(t, gate.wait(errFull, failedAt), "writes that failed before continuing are retried without asking")
+	assert.Zero(t, cancelled)
+
+	assert.False(t, gate.wait(errFull, time.Now()), "a full disk after continuing asks again")
+	assert.Equal(t, 1, cancelled)
+
+	var nilGate *diskFullGate
+	assert.False(t, nilGate.wait(errFull, time.Now()))
+	nilGate.waitIfPaused()
+}
+
+func TestFreeSpaceWarning(t *testing.T) {
+	dir := t.TempDir()
+	assert.Empty(t, freeSpaceWarning(dir, 0))
+	assert.Contains(t, freeSpaceWarning(dir, 1<<62), "free in "+dir)
+}
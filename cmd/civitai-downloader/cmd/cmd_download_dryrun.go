@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/helpers"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// openDryRunDatabase opens the database for --dry-run so nothing can be written
+// to it: a temporary migrated copy when it exists, so databases of older
+// versions work too, otherwise an empty one in memory.
+func openDryRunDatabase(path string) (*database.DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		log.Infof("Dry run: there is no database at %s yet, checking against an empty one", path)
+		return database.OpenMemory()
+	}
+	return database.OpenCopy(path)
+}
+
+// printDryRun writes the size and target path of every download in downloads,
+// followed by a summary of their count and total size.
+func printDryRun(w io.Writer, downloads []potentialDownload) {
+	var totalBytes uint64
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "Size\tTarget")
+	_, _ = fmt.Fprintln(tw, "----\t------")
+	for _, pd := range downloads {
+		size := uint64(pd.File.SizeKB * 1024)
+		totalBytes += size
+		target := pd.TargetFilepath
+		if pd.ReplacesFilepath != "" {
+			target += " (changed on Civitai, replaces the current file)"
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\n", helpers.BytesToSize(size), target)
+	}
+	if err := tw.Flush(); err != nil {
+		log.WithError(err).Error("Error flushing table writer for dry run")
+	}
+
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "\n--- Dry Run Summary ---")
+	_, _ = fmt.Fprintf(tw, "Files\t%d\n", len(downloads))
+	_, _ = fmt.Fprintf(tw, "Total size\t%.2f GB\n", float64(totalBytes)/1024/1024/1024)
+	if err := tw.Flush(); err != nil {
+		log.WithError(err).Error("Error flushing table writer for dry run")
+	}
+	_, _ = fmt.Fprintln(w, "Nothing was downloaded or written to the database.")
+}
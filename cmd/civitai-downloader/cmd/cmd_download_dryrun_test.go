@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterAndPrepareDownloadsDryRun(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "civitai.db")
+	db, err := database.Open(dbPath)
+	require.NoError(t, err)
+
+	// Downloaded before, but the file changed on Civitai since
+	oldFile := models.File{ID: 1, Name: "old.safetensors", Hashes: models.Hashes{CRC32: "AAAA"}}
+	changed := models.DatabaseEntry{ModelID: 5, Version: models.ModelVersion{ID: 500}, File: oldFile, Filename: "old.safetensors", Folder: "lora", Status: models.StatusDownloaded}
+	changedBytes, err := json.Marshal(changed)
+	require.NoError(t, err)
+	require.NoError(t, db.Put([]byte("v_500"), changedBytes))
+	require.NoError(t, db.Close())
+
+	cfg := &models.Config{SavePath: t.TempDir(), DatabasePath: dbPath}
+	cfg.Download.VersionPathPattern = "{modelType}"
+	cfg.Download.DryRun = true
+	newDownload := func(versionID int, crc string) potentialDownload {
+		return potentialDownload{
+			ModelID:        5,
+			ModelName:      "Model",
+			ModelVersionID: versionID,
+			FullModel:      models.Model{ID: 5, Name: "Model", Type: "LORA"},
+			FullVersion:    models.ModelVersion{ID: versionID},
+			File:           models.File{ID: versionID, Name: "model.safetensors", SizeKB: 1024 * 1024, Hashes: models.Hashes{CRC32: crc}},
+		}
+	}
+
+	ro, err := openDryRunDatabase(dbPath)
+	require.NoError(t, err)
+	defer func() { _ = ro.Close() }()
+	queue, _ := filterAndPrepareDownloads([]potentialDownload{newDownload(500, "BBBB"), newDownload(600, "CCCC")}, ro, cfg)
+	require.Len(t, queue, 2, "both are listed, nothing is written")
+	assert.NotEmpty(t, queue[0].ReplacesFilepath)
+
+	_ = ro.Close()
+	db, err = database.Open(dbPath)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	assert.False(t, db.Has([]byte("v_600")), "no Pending entry is created")
+	data, err := db.Get([]byte("v_500"))
+	require.NoError(t, err)
+	var entry models.DatabaseEntry
+	require.NoError(t, json.Unmarshal(data, &entry))
+	assert.Equal(t, models.StatusDownloaded, entry.Status, "the changed entry is not reset")
+
+	var out bytes.Buffer
+	printDryRun(&out, queue)
+	assert.Contains(t, out.String(), queue[1].TargetFilepath)
+	assert.Contains(t, out.String(), "Files       2")
+	assert.Contains(t, out.String(), "Total size  2.00 GB")
+}
+
+func TestOpenDryRunDatabaseWithoutFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.db")
+	db, err := openDryRunDatabase(path)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	assert.NoFileExists(t, path)
+}
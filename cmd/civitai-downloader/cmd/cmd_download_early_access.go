@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"net/http"
+	"time"
+
+	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/models"
+)
+
+// earlyAccessAvailability is the availability of a version in early access.
+const earlyAccessAvailability = "EarlyAccess"
+
+// earlyAccessEnd returns when the early access window of version closes, and
+// whether the version has one at all. The API gives the end as
+// earlyAccessEndsAt, or older responses only give earlyAccessTimeFrame, the
+// window in days from publishing. A zero time means the end is unknown.
+func earlyAccessEnd(version models.ModelVersion) (time.Time, bool) {
+	if end, err := time.Parse(time.RFC3339, version.EarlyAccessEndsAt); err == nil {
+		return end, true
+	}
+	if version.EarlyAccessTimeFrame > 0 {
+		published, err := time.Parse(time.RFC3339, version.PublishedAt)
+		if err != nil {
+			return time.Time{}, true
+		}
+		return published.AddDate(0, 0, version.EarlyAccessTimeFrame), true
+	}
+	return time.Time{}, version.Availability == earlyAccessAvailability
+}
+
+// earlyAccessActive reports whether version is still in early access at now,
+// so downloading it needs early access bought on Civitai. A window without a
+// known end counts as open only while the API marks the version EarlyAccess.
+func earlyAccessActive(version models.ModelVersion, now time.Time) bool {
+	end, ok := earlyAccessEnd(version)
+	if !ok {
+		return false
+	}
+	if end.IsZero() {
+		return version.Availability == earlyAccessAvailability
+	}
+	return now.Before(end)
+}
+
+// earlyAccessDenied reports whether err is the download of an early access
+// version being refused with 401 or 403.
+func earlyAccessDenied(pd potentialDownload, err error) bool {
+	if _, ok := earlyAccessEnd(pd.FullVersion); !ok {
+		return false
+	}
+	code := downloader.StatusCode(err)
+	return code == http.StatusUnauthorized || code == http.StatusForbidden
+}
@@ -0,0 +1,82 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEarlyAccessActive(t *testing.T) {
+	now := time.Date(2025, 6, 10, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		version models.ModelVersion
+		want    bool
+	}{
+		{"not early access", models.ModelVersion{PublishedAt: "2025-06-09T00:00:00Z", Availability: "Public"}, false},
+		{"ends in future", models.ModelVersion{EarlyAccessEndsAt: "2025-06-12T00:00:00Z"}, true},
+		{"ended", models.ModelVersion{EarlyAccessEndsAt: "2025-06-01T00:00:00Z"}, false},
+		{"time frame open", models.ModelVersion{EarlyAccessTimeFrame: 3, PublishedAt: "2025-06-09T00:00:00Z"}, true},
+		{"time frame over", models.ModelVersion{EarlyAccessTimeFrame: 3, PublishedAt: "2025-06-01T00:00:00Z"}, false},
+		{"availability without end", models.ModelVersion{Availability: "EarlyAccess"}, true},
+		{"time frame without publish date", models.ModelVersion{EarlyAccessTimeFrame: 3}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, earlyAccessActive(tt.version, now))
+		})
+	}
+}
+
+func TestProcessVersionFiles_EarlyAccess(t *testing.T) {
+	file := models.File{ID: 100, Name: "early.safetensors", Primary: true, Hashes: models.Hashes{CRC32: "abcd"}}
+	file.Metadata.Format = "SafeTensor"
+	version := models.ModelVersion{
+		ID:                10,
+		Files:             []models.File{file},
+		EarlyAccessEndsAt: time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339),
+	}
+	model := models.Model{ID: 1, Name: "Early", Type: "LORA", ModelVersions: []models.ModelVersion{version}}
+
+	cfg := &models.Config{}
+	report := &filterReport{}
+	got, _ := processVersionFiles(model, version, cfg, 0, 0, report)
+	assert.Empty(t, got)
+	assert.Equal(t, 1, report.EarlyAccessSkipped)
+	assert.Empty(t, report.Models, "skipped early access versions are not reported as filtered")
+
+	cfg.Download.IncludeEarlyAccess = true
+	got, _ = processVersionFiles(model, version, cfg, 0, 0, report)
+	require.Len(t, got, 1)
+	assert.Equal(t, 10, got[0].ModelVersionID)
+}
+
+func TestEarlyAccessDenied(t *testing.T) {
+	d := downloader.NewDownloader(nil, "", "")
+	forbidden := func(code int) error {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(code)
+		}))
+		defer server.Close()
+		_, err := d.DownloadFile(filepath.Join(t.TempDir(), "file.bin"), server.URL, models.Hashes{}, 1)
+		require.Error(t, err)
+		return err
+	}
+
+	early := potentialDownload{FullVersion: models.ModelVersion{EarlyAccessTimeFrame: 3}}
+	public := potentialDownload{}
+	assert.True(t, earlyAccessDenied(early, forbidden(403)))
+	assert.True(t, earlyAccessDenied(early, forbidden(401)))
+	assert.False(t, earlyAccessDenied(early, forbidden(404)))
+	assert.False(t, earlyAccessDenied(public, forbidden(403)))
+	assert.False(t, earlyAccessDenied(early, fmt.Errorf("connection reset")))
+}
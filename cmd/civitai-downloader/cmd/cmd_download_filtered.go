@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// filterReport collects the models that matched the query but had no file
+// passing the file filters, so a run that finds little to download can say why.
+// A nil report collects nothing.
+type filterReport struct {
+	Models []filteredModel
+	// Versions skipped for being published before --since
+	SinceSkipped int
+	// Versions skipped for still being in early access
+	EarlyAccessSkipped int
+}
+
+// filteredModel is a model whose files were all dropped, with the reason for each file.
+type filteredModel struct {
+	ID    int
+	Name  string
+	Type  string
+	Files []droppedFile
+}
+
+// droppedFile is one file dropped by the file filters.
+type droppedFile struct {
+	VersionID   int
+	VersionName string
+	Name        string
+	Reason      string
+}
+
+// add records model, explaining why each file of the checked versions was dropped.
+func (r *filterReport) add(model models.Model, versions []models.ModelVersion, cfg *models.Config) {
+	if r == nil {
+		return
+	}
+	filtered := filteredModel{ID: model.ID, Name: model.Name, Type: model.Type}
+	sinceOnly := true
+	for _, version := range versions {
+		// Counted in SinceSkipped or EarlyAccessSkipped instead, the files were never looked at
+		if publishedBeforeSince(version, cfg) || (!cfg.Download.IncludeEarlyAccess && earlyAccessActive(version, time.Now())) {
+			continue
+		}
+		sinceOnly = false
+		for _, file := range version.Files {
+			reason := fileFilterReason(file, model.Type, cfg)
+			if reason == "" {
+				// Passed on its own but not picked, e.g. a workflow without SaveWorkflows
+				reason = "not selected for the version"
+			}
+			filtered.Files = append(filtered.Files, droppedFile{
+				VersionID:   version.ID,
+				VersionName: version.Name,
+				Name:        file.Name,
+				Reason:      reason,
+			})
+		}
+	}
+	if sinceOnly {
+		return
+	}
+	log.Debugf("Model %s (ID: %d) has no files passing the file filters.", model.Name, model.ID)
+	r.Models = append(r.Models, filtered)
+}
+
+// addSinceSkipped counts a version skipped by --since.
+func (r *filterReport) addSinceSkipped() {
+	if r != nil {
+		r.SinceSkipped++
+	}
+}
+
+// addEarlyAccessSkipped counts a version skipped for being in early access.
+func (r *filterReport) addEarlyAccessSkipped() {
+	if r != nil {
+		r.EarlyAccessSkipped++
+	}
+}
+
+// logFilteredModels warns about the models in report, listing why every file
+// was dropped when explain is set.
+func logFilteredModels(report *filterReport, explain bool) {
+	if report == nil {
+		return
+	}
+	if report.SinceSkipped > 0 {
+		log.Infof("Skipped %d version(s) published before --since.", report.SinceSkipped)
+	}
+	if report.EarlyAccessSkipped > 0 {
+		log.Infof("Skipped %d version(s) still in early access (use --include-early-access to try them).", report.EarlyAccessSkipped)
+	}
+	if len(report.Models) == 0 {
+		return
+	}
+	if explain {
+		log.Warnf("%d model(s) matched the query but had no files passing the file filters:", len(report.Models))
+		printFilteredModels(os.Stdout, report)
+		return
+	}
+	log.Warnf("%d model(s) matched the query but had no files passing the file filters (use --explain-filtered to see why).", len(report.Models))
+}
+
+// printFilteredModels writes each model of report followed by its dropped files and the reason.
+func printFilteredModels(w io.Writer, report *filterReport) {
+	for _, model := range report.Models {
+		_, _ = fmt.Fprintf(w, "%s (ID: %d, %s)\n", model.Name, model.ID, model.Type)
+		if len(model.Files) == 0 {
+			_, _ = fmt.Fprintln(w, "  no files listed")
+			continue
+		}
+		for _, file := range model.Files {
+			_, _ = fmt.Fprintf(w, "  %s [version %d %s]: %s\n", file.Name, file.VersionID, file.VersionName, file.Reason)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProcessModelVersionsReportsFilteredModels(t *testing.T) {
+	newFile := func(id int, name, format string) models.File {
+		file := models.File{ID: id, Name: name, Hashes: models.Hashes{CRC32: "abcd"}}
+		file.Metadata.Format = format
+		return file
+	}
+	pickle := models.Model{
+		ID:   1,
+		Name: "Pickled",
+		Type: "LORA",
+		ModelVersions: []models.ModelVersion{{ID: 10, Name: "v1", Files: []models.File{
+			newFile(100, "pickled.ckpt", "PickleTensor"),
+			newFile(101, "pickled-nohash.safetensors", "SafeTensor"),
+		}}},
+	}
+	pickle.ModelVersions[0].Files[1].Hashes.CRC32 = ""
+	safe := models.Model{
+		ID:            2,
+		Name:          "Safe",
+		Type:          "LORA",
+		ModelVersions: []models.ModelVersion{{ID: 20, Files: []models.File{newFile(200, "safe.safetensors", "SafeTensor")}}},
+	}
+
+	cfg := &models.Config{}
+	cfg.Download.AllowedFormats = []string{"safetensor"}
+	report := &filterReport{}
+	got, _ := processModelVersions(pickle, cfg, 0, 0, report)
+	assert.Empty(t, got)
+	got, _ = processModelVersions(safe, cfg, 0, 0, report)
+	assert.Len(t, got, 1)
+
+	require.Len(t, report.Models, 1, "only the model without passing files is reported")
+	filtered := report.Models[0]
+	assert.Equal(t, 1, filtered.ID)
+	require.Len(t, filtered.Files, 2)
+	assert.Equal(t, "format PickleTensor is not allowed (--formats safetensor)", filtered.Files[0].Reason)
+	assert.Equal(t, "missing CRC32 hash", filtered.Files[1].Reason)
+
+	var out bytes.Buffer
+	printFilteredModels(&out, report)
+	assert.Equal(t, "Pickled (ID: 1, LORA)\n"+
+		"  pickled.ckpt [version 10 v1]: format PickleTensor is not allowed (--formats safetensor)\n"+
+		"  pickled-nohash.safetensors [version 10 v1]: missing CRC32 hash\n", out.String())
+
+	// A nil report collects nothing
+	assert.NotPanics(t, func() { processModelVersions(pickle, cfg, 0, 0, nil) })
+}
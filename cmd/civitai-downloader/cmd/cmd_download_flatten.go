@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+)
+
+// flatFolder is the Folder stored in the database for files saved with
+// Download.Flatten, directly in SavePath.
+const flatFolder = "."
+
+// flatFileName returns the name a file is handed to the downloader under with
+// Download.Flatten: {creator}_{modelName}_{filename}, each part slugified.
+// The downloader keeps it (see Downloader.SetKeepFileName) and prepends the
+// version ID. data is the path data of the file (see buildPathData).
+func flatFileName(data map[string]string, fileName string) string {
+	creator := data["creatorName"]
+	if creator == "" {
+		creator = "unknown_creator"
+	}
+	modelName := data["modelName"]
+	if modelName == "" {
+		modelName = "unknown_model"
+	}
+	return fmt.Sprintf("%s_%s_%s", helpers.ConvertToSlug(creator), helpers.ConvertToSlug(modelName), helpers.ConvertToSlug(fileName))
+}
+
+// modelImagesDir returns the folder the model gallery images of the file at
+// modelFilePath are saved in: images/ in the folder above its version folder,
+// or images/ in the metadata root with Download.Flatten, as flat files have
+// no version folder.
+func modelImagesDir(cfg *models.Config, modelFilePath string) string {
+	if cfg.Download.Flatten {
+		return filepath.Join(metadataRoot(cfg), "images")
+	}
+	versionSpecificDir := filepath.Dir(metadataFilePath(cfg, modelFilePath))
+	return filepath.Join(filepath.Dir(versionSpecificDir), "images")
+}
@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersionFilePath_Flatten(t *testing.T) {
+	cfg := &models.Config{SavePath: "/models"}
+	cfg.Download.VersionPathPattern = "{modelType}/{modelName}/{versionId}"
+	cfg.Download.Flatten = true
+	cfg.Download.SaveWorkflows = true
+
+	pd := potentialDownload{
+		ModelVersionID: 500,
+		FullModel:      models.Model{ID: 5, Name: "My Model", Type: "LORA", Creator: models.Creator{Username: "Some Artist"}},
+		FullVersion:    models.ModelVersion{ID: 500},
+		File:           models.File{ID: 1, Name: "My File.safetensors"},
+	}
+	relPath, filename, err := versionFilePath(pd, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, flatFolder, relPath)
+	assert.Equal(t, "some_artist_my_model_my_file.safetensors", filename)
+
+	// Workflow attachments are not moved into a workflows/ subfolder either
+	pd.File = models.File{ID: 2, Name: "flow.zip", Type: workflowFileType}
+	relPath, filename, err = versionFilePath(pd, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, flatFolder, relPath)
+	assert.Equal(t, "some_artist_my_model_flow.zip", filename)
+
+	// A model without a creator falls back like the path patterns do
+	pd.FullModel.Creator = models.Creator{}
+	_, filename, err = versionFilePath(pd, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "unknown_creator_my_model_flow.zip", filename)
+}
+
+func TestModelImagesDir(t *testing.T) {
+	cfg := &models.Config{SavePath: "/models"}
+	modelFile := filepath.Join("/models", "lora", "my_model", "500", "500_file.safetensors")
+	assert.Equal(t, filepath.Join("/models", "lora", "my_model", "images"), modelImagesDir(cfg, modelFile))
+
+	// Flat files have no version folder to go up from
+	cfg.Download.Flatten = true
+	assert.Equal(t, filepath.Join("/models", "images"), modelImagesDir(cfg, filepath.Join("/models", "500_file.safetensors")))
+
+	cfg.MetadataSavePath = "/meta"
+	assert.Equal(t, filepath.Join("/meta", "images"), modelImagesDir(cfg, filepath.Join("/models", "500_file.safetensors")))
+}
+
+func TestDeleteEntries_FlatKeepsSharedImages(t *testing.T) {
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	savePath := filepath.Join(tmpDir, "models")
+	imagesDir := filepath.Join(savePath, "images")
+	require.NoError(t, os.MkdirAll(imagesDir, 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(imagesDir, "1.jpeg"), []byte("image"), 0600))
+	filePath := filepath.Join(savePath, "500_artist_model_file.safetensors")
+	require.NoError(t, os.WriteFile(filePath, []byte("model"), 0600))
+
+	entry := models.DatabaseEntry{
+		ModelID:  5,
+		Version:  models.ModelVersion{ID: 500},
+		Filename: filepath.Base(filePath),
+		Folder:   flatFolder,
+		Status:   models.StatusDownloaded,
+	}
+	entryBytes, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, db.Put([]byte("v_500"), entryBytes))
+
+	deleted, _, errs := deleteEntries(db, []models.DatabaseEntry{entry}, savePath, false)
+	require.Empty(t, errs)
+	assert.Equal(t, 1, deleted)
+	assert.NoFileExists(t, filePath)
+	assert.FileExists(t, filepath.Join(imagesDir, "1.jpeg"), "images shared by flat entries must be kept")
+	assert.DirExists(t, savePath)
+}
+
+func TestFlatten_DownloadKeepsFileName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="server_name.safetensors"`)
+		_, _ = w.Write([]byte("model data"))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.Flatten = true
+	pd := potentialDownload{
+		ModelVersionID: 500,
+		FullModel:      models.Model{ID: 5, Name: "My Model", Type: "LORA", Creator: models.Creator{Username: "Some Artist"}},
+		FullVersion:    models.ModelVersion{ID: 500},
+		File:           models.File{ID: 1, Name: "My File.safetensors"},
+	}
+	relPath, filename, err := versionFilePath(pd, cfg)
+	require.NoError(t, err)
+
+	d := downloader.NewDownloader(server.Client(), "", "")
+	d.SetKeepFileName(cfg.Download.Flatten)
+	finalPath, err := d.DownloadFile(filepath.Join(tmpDir, relPath, filename), server.URL, models.Hashes{}, pd.ModelVersionID)
+	require.NoError(t, err)
+	assert.Equal(t, "500_some_artist_my_model_my_file.safetensors", filepath.Base(finalPath))
+	assert.FileExists(t, finalPath)
+
+	// A redownload of the stored name does not prefix the version ID again
+	finalPath, err = d.DownloadFile(finalPath, server.URL, models.Hashes{}, pd.ModelVersionID)
+	require.NoError(t, err)
+	assert.Equal(t, "500_some_artist_my_model_my_file.safetensors", filepath.Base(finalPath))
+}
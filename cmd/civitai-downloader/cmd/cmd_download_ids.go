@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// errStdinNotPiped is returned when --from-stdin is used without anything piped in.
+var errStdinNotPiped = errors.New("nothing piped on stdin, pipe model IDs in (e.g. echo 1234 5678 | civitai-downloader download --from-stdin)")
+
+// parseModelIDs reads whitespace/newline-separated model IDs from r.
+// Duplicate IDs are dropped while keeping the original order.
+func parseModelIDs(r io.Reader) ([]int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+
+	var ids []int
+	seen := make(map[int]bool)
+	for scanner.Scan() {
+		token := strings.TrimSpace(scanner.Text())
+		if token == "" {
+			continue
+		}
+		id, err := strconv.Atoi(token)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid model ID %q: must be a positive integer", token)
+		}
+		if seen[id] {
+			log.Debugf("Ignoring duplicate model ID %d", id)
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading model IDs: %w", err)
+	}
+	return ids, nil
+}
+
+// readModelIDsFromStdin parses model IDs piped on stdin. It refuses to read from an
+// interactive terminal so the command doesn't hang waiting for input.
+func readModelIDsFromStdin() ([]int, error) {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("checking stdin: %w", err)
+	}
+	if info.Mode()&os.ModeCharDevice != 0 {
+		return nil, errStdinNotPiped
+	}
+
+	ids, err := parseModelIDs(os.Stdin)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("no model IDs read from stdin")
+	}
+	log.Infof("Read %d model ID(s) from stdin", len(ids))
+	return ids, nil
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseModelIDs(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []int
+		wantErr bool
+	}{
+		{name: "space separated", input: "1234 5678", want: []int{1234, 5678}},
+		{name: "newlines and tabs", input: "1\n2\t3\r\n", want: []int{1, 2, 3}},
+		{name: "duplicates dropped", input: "7 8 7", want: []int{7, 8}},
+		{name: "empty input", input: "  \n ", want: nil},
+		{name: "non-numeric token", input: "12 abc", wantErr: true},
+		{name: "zero rejected", input: "0", wantErr: true},
+		{name: "negative rejected", input: "-5", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseModelIDs(strings.NewReader(tt.input))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
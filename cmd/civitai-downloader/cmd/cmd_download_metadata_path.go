@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"path/filepath"
+	"strings"
+
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// metadataRoot returns the directory sidecar files (metadata, info files,
+// trained words and images) are saved under: MetadataSavePath, or SavePath
+// when it is not set.
+func metadataRoot(cfg *models.Config) string {
+	if cfg.MetadataSavePath != "" {
+		return cfg.MetadataSavePath
+	}
+	return cfg.SavePath
+}
+
+// metadataFilePath returns the path the sidecars of the model file at
+// modelFilePath are named after. With MetadataSavePath set it mirrors the
+// file's path relative to SavePath under MetadataSavePath; otherwise, and for
+// a file outside SavePath, it is modelFilePath itself.
+func metadataFilePath(cfg *models.Config, modelFilePath string) string {
+	if cfg.MetadataSavePath == "" {
+		return modelFilePath
+	}
+	rel, err := filepath.Rel(cfg.SavePath, modelFilePath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		log.Warnf("%s is not under SavePath %s, saving its metadata next to it", modelFilePath, cfg.SavePath)
+		return modelFilePath
+	}
+	return filepath.Join(cfg.MetadataSavePath, rel)
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataFilePath(t *testing.T) {
+	cfg := &models.Config{SavePath: filepath.Join("data", "models")}
+	modelPath := filepath.Join("data", "models", "lora", "sdxl", "42_model.safetensors")
+
+	assert.Equal(t, modelPath, metadataFilePath(cfg, modelPath), "next to the model without MetadataSavePath")
+	assert.Equal(t, cfg.SavePath, metadataRoot(cfg))
+
+	cfg.MetadataSavePath = filepath.Join("data", "meta")
+	assert.Equal(t, filepath.Join("data", "meta", "lora", "sdxl", "42_model.safetensors"), metadataFilePath(cfg, modelPath))
+	assert.Equal(t, cfg.MetadataSavePath, metadataRoot(cfg))
+
+	outside := filepath.Join("elsewhere", "42_model.safetensors")
+	assert.Equal(t, outside, metadataFilePath(cfg, outside), "a file outside SavePath keeps its sidecars next to it")
+}
+
+func TestHandleMetadataSavingUsesMetadataSavePath(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &models.Config{SavePath: filepath.Join(dir, "models"), MetadataSavePath: filepath.Join(dir, "meta")}
+	cfg.Download.SaveMetadata = true
+	cfg.Download.SaveCivitaiInfo = true
+	cfg.Download.SaveTrainedWords = true
+	cfg.Download.TrainedWordsPathPattern = "{modelName}"
+	cfg.Download.SaveModelInfo = true
+	cfg.Download.ModelInfoPathPattern = "{modelName}"
+
+	modelPath := filepath.Join(cfg.SavePath, "lora", "42_model.safetensors")
+	pd := potentialDownload{
+		ModelName:      "Mine",
+		ModelVersionID: 42,
+		FullModel:      models.Model{ID: 7, Name: "Mine"},
+		FullVersion:    models.ModelVersion{ID: 42, Name: "v1", TrainedWords: []string{"mine"}},
+	}
+
+	require.NoError(t, handleMetadataSaving("test", pd, modelPath, models.StatusDownloaded, nil, cfg))
+	assert.FileExists(t, filepath.Join(dir, "meta", "lora", "42_model.json"))
+	assert.FileExists(t, filepath.Join(dir, "meta", "lora", "42_model.civitai.info"))
+	assert.FileExists(t, filepath.Join(dir, "meta", "mine", "42_model.txt"))
+	assert.FileExists(t, filepath.Join(dir, "meta", "mine", "7-mine.json"))
+	assert.NoDirExists(t, filepath.Join(dir, "models"), "nothing is written to the model tree")
+}
@@ -0,0 +1,167 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-civitai-download/internal/api"
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// mirrorPageLimit is the number of models asked for per page when listing
+// the models of the creator to mirror.
+const mirrorPageLimit = 100
+
+// runMirror deletes the local files and database entries of the versions of
+// the --username creator that are no longer on Civitai. The creator's models
+// are listed without the download filters and at every browsing level, so a
+// version is only a candidate when Civitai does not list it at all, and each
+// candidate is then looked up on its own and kept unless Civitai answers that
+// it does not exist. The deletion has to be confirmed unless --yes is given.
+func runMirror(db *database.DB, apiClient *api.Client, cfg *models.Config) error {
+	username := cfg.Download.Usernames[0]
+	log.Infof("Mirror: listing the current versions of %s...", username)
+	upstream, modelCount, err := fetchCreatorVersionIDs(apiClient, username, cfg)
+	if err != nil {
+		return fmt.Errorf("--mirror: error listing the models of %s: %w", username, err)
+	}
+	if modelCount == 0 {
+		log.Warnf("Mirror: Civitai lists no models for %s, not deleting anything. Check the username.", username)
+		return nil
+	}
+	log.Infof("Mirror: %s has %d versions in %d models on Civitai.", username, len(upstream), modelCount)
+
+	candidates, err := findMirrorCandidates(db, username, upstream)
+	if err != nil {
+		return fmt.Errorf("--mirror: error reading database: %w", err)
+	}
+	stale := versionsGone(apiClient, candidates, cfg)
+	if len(stale) == 0 {
+		log.Infof("Mirror: every version of %s in the database is still on Civitai.", username)
+		return nil
+	}
+
+	displayDeletionTable(stale, cfg.SavePath)
+	if cfg.Download.DryRun {
+		fmt.Println("\n[DRY RUN] --mirror would delete the above entries and their files. No changes will be made.")
+		return nil
+	}
+	// Only the flag skips this prompt, not SkipConfirmation from the config file
+	if !downloadYesFlag && !confirmDeletion(stale, false, false) {
+		log.Info("Mirror: deletion canceled.")
+		return nil
+	}
+
+	deleted := 0
+	for _, entry := range stale {
+		log.Infof("Mirror: deleting %s - %s (version %d), removed from Civitai", entry.ModelName, entry.Version.Name, entry.Version.ID)
+		n, _, errs := deleteEntries(db, []models.DatabaseEntry{entry}, cfg.SavePath, false)
+		for _, e := range errs {
+			log.Error(e)
+		}
+		deleted += n
+	}
+	log.Infof("Mirror: deleted %d of %d versions of %s no longer on Civitai.", deleted, len(stale), username)
+	return nil
+}
+
+// fetchCreatorVersionIDs lists every model of username and returns the IDs of
+// all their versions and the number of models.
+func fetchCreatorVersionIDs(apiClient *api.Client, username string, cfg *models.Config) (map[int]struct{}, int, error) {
+	params := models.QueryParameters{
+		Username:               username,
+		Sort:                   "Newest",
+		Period:                 "AllTime",
+		Limit:                  mirrorPageLimit,
+		AllowNoCredit:          true,
+		AllowDerivatives:       true,
+		AllowDifferentLicenses: true,
+		AllowCommercialUse:     "Any",
+		BrowsingLevel:          models.BrowsingLevelAll,
+	}
+
+	versionIDs := make(map[int]struct{})
+	modelCount := 0
+	cursor := ""
+	for {
+		nextCursor, response, err := apiClient.GetModels(cursor, params)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, model := range response.Items {
+			modelCount++
+			for _, version := range model.ModelVersions {
+				versionIDs[version.ID] = struct{}{}
+			}
+		}
+		if nextCursor == "" || len(response.Items) == 0 {
+			break
+		}
+		cursor = nextCursor
+		if cfg.APIDelayMs > 0 {
+			time.Sleep(time.Duration(cfg.APIDelayMs) * time.Millisecond)
+		}
+	}
+	return versionIDs, modelCount, nil
+}
+
+// findMirrorCandidates returns the entries of username's models whose version
+// is not in upstream, in version order.
+func findMirrorCandidates(db *database.DB, username string, upstream map[int]struct{}) ([]models.DatabaseEntry, error) {
+	var candidates []models.DatabaseEntry
+	err := db.Fold(func(key []byte, value []byte) error {
+		keyStr := string(key)
+		if !strings.HasPrefix(keyStr, "v_") {
+			return nil
+		}
+		var entry models.DatabaseEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			log.WithError(err).Warnf("Failed to unmarshal JSON for key %s", keyStr)
+			return nil
+		}
+		if !strings.EqualFold(entry.Creator.Username, username) {
+			return nil
+		}
+		if _, ok := upstream[entry.Version.ID]; !ok {
+			candidates = append(candidates, entry)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Version.ID < candidates[j].Version.ID
+	})
+	return candidates, nil
+}
+
+// versionsGone returns the candidates whose version Civitai reports as not
+// found. A version that can still be fetched, or that cannot be checked, is
+// kept.
+func versionsGone(apiClient *api.Client, candidates []models.DatabaseEntry, cfg *models.Config) []models.DatabaseEntry {
+	var gone []models.DatabaseEntry
+	for i, entry := range candidates {
+		if i > 0 && cfg.APIDelayMs > 0 {
+			time.Sleep(time.Duration(cfg.APIDelayMs) * time.Millisecond)
+		}
+		_, err := apiClient.GetModelVersionDetails(entry.Version.ID)
+		switch {
+		case errors.Is(err, api.ErrNotFound):
+			gone = append(gone, entry)
+		case err != nil:
+			log.WithError(err).Warnf("Mirror: could not check version %d, keeping it", entry.Version.ID)
+		default:
+			log.Infof("Mirror: version %d (%s) is not listed under %s but still exists, keeping it", entry.Version.ID, entry.ModelName, entry.Creator.Username)
+		}
+	}
+	return gone
+}
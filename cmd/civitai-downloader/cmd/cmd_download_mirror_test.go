@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-civitai-download/internal/api"
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunMirror(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/models":
+			// Every browsing level, whatever the download filters are
+			assert.Equal(t, "artist", r.URL.Query().Get("username"))
+			assert.Equal(t, "31", r.URL.Query().Get("browsingLevel"))
+			_, _ = w.Write([]byte(`{"items": [{"id": 1, "name": "Model", "modelVersions": [{"id": 10}, {"id": 11}]}], "metadata": {}}`))
+		case r.URL.Path == "/model-versions/13":
+			// Not listed, but still there, e.g. moved to another model
+			_, _ = w.Write([]byte(`{"id": 13}`))
+		case strings.HasPrefix(r.URL.Path, "/model-versions/"):
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	cfg := &models.Config{APIBaseURL: server.URL, SavePath: filepath.Join(tmpDir, "models")}
+	cfg.Download.Usernames = []string{"artist"}
+	apiClient := api.NewClient("", server.Client(), *cfg)
+
+	put := func(versionID int, creator string) string {
+		folder := filepath.Join("lora", "model")
+		path := filepath.Join(cfg.SavePath, folder, fmt.Sprintf("%d_file.safetensors", versionID))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		require.NoError(t, os.WriteFile(path, []byte("model"), 0600))
+		entry := models.DatabaseEntry{
+			Creator:  models.Creator{Username: creator},
+			ModelID:  1,
+			Version:  models.ModelVersion{ID: versionID},
+			Filename: filepath.Base(path),
+			Folder:   folder,
+			Status:   models.StatusDownloaded,
+		}
+		entryBytes, err := json.Marshal(entry)
+		require.NoError(t, err)
+		require.NoError(t, db.Put([]byte(fmt.Sprintf("v_%d", versionID)), entryBytes))
+		return path
+	}
+	listed := put(10, "Artist")
+	removed := put(12, "Artist")
+	moved := put(13, "Artist")
+	otherCreator := put(14, "someone_else")
+
+	candidates, err := findMirrorCandidates(db, "artist", map[int]struct{}{10: {}, 11: {}})
+	require.NoError(t, err)
+	require.Len(t, candidates, 2)
+	assert.Equal(t, 12, candidates[0].Version.ID)
+	assert.Equal(t, 13, candidates[1].Version.ID)
+
+	// The dry run lists what would go but deletes nothing
+	cfg.Download.DryRun = true
+	require.NoError(t, runMirror(db, apiClient, cfg))
+	assert.FileExists(t, removed)
+
+	cfg.Download.DryRun = false
+	downloadYesFlag = true
+	defer func() { downloadYesFlag = false }()
+	require.NoError(t, runMirror(db, apiClient, cfg))
+
+	assert.NoFileExists(t, removed)
+	_, err = db.Get([]byte("v_12"))
+	assert.ErrorIs(t, err, database.ErrNotFound)
+	for _, path := range []string{listed, moved, otherCreator} {
+		assert.FileExists(t, path)
+	}
+	_, err = db.Get([]byte("v_13"))
+	assert.NoError(t, err)
+}
+
+func TestRunMirror_EmptyListingDeletesNothing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models" {
+			t.Errorf("unexpected request %s", r.URL)
+		}
+		_, _ = w.Write([]byte(`{"items": [], "metadata": {}}`))
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	entry := models.DatabaseEntry{Creator: models.Creator{Username: "artist"}, Version: models.ModelVersion{ID: 10}, Status: models.StatusDownloaded}
+	entryBytes, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, db.Put([]byte("v_10"), entryBytes))
+
+	cfg := &models.Config{APIBaseURL: server.URL, SavePath: tmpDir}
+	cfg.Download.Usernames = []string{"artist"}
+	downloadYesFlag = true
+	defer func() { downloadYesFlag = false }()
+	require.NoError(t, runMirror(db, api.NewClient("", server.Client(), *cfg), cfg))
+
+	_, err = db.Get([]byte("v_10"))
+	assert.NoError(t, err, "a creator without listed models is not mirrored")
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// objectsDir is the folder under SavePath holding the files of a
+// Download.ContentAddressed archive, one object per distinct SHA256.
+const objectsDir = "objects"
+
+// objectPath returns where the file with the given SHA256 is stored, or "" if
+// the hash is not a SHA256.
+func objectPath(savePath, sha256 string) string {
+	sha := strings.ToLower(strings.TrimSpace(sha256))
+	if len(sha) != 64 {
+		return ""
+	}
+	return filepath.Join(savePath, objectsDir, sha[:2], sha)
+}
+
+// linkObject makes linkPath refer to object, replacing whatever linkPath was.
+// A hard link is used when possible, a symlink otherwise (e.g. when the
+// object store is on another filesystem).
+func linkObject(object, linkPath string) error {
+	if err := os.Remove(helpers.LongPath(linkPath)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("removing %s: %w", linkPath, err)
+	}
+	err := os.Link(helpers.LongPath(object), helpers.LongPath(linkPath))
+	if err == nil {
+		return nil
+	}
+	log.WithError(err).Debugf("Hard link to %s failed, using a symlink", object)
+
+	// A relative target keeps working when the archive is moved
+	target, relErr := filepath.Rel(filepath.Dir(linkPath), object)
+	if relErr != nil {
+		target = object
+	}
+	if err := os.Symlink(target, helpers.LongPath(linkPath)); err != nil {
+		return fmt.Errorf("linking %s to %s: %w", linkPath, object, err)
+	}
+	return nil
+}
+
+// storedObject returns the object already stored for pd's expected SHA256, or
+// "" if there is none.
+func storedObject(pd potentialDownload, cfg *models.Config) string {
+	object := objectPath(cfg.SavePath, pd.File.Hashes.SHA256)
+	if object == "" {
+		return ""
+	}
+	if _, err := os.Stat(helpers.LongPath(object)); err != nil {
+		return ""
+	}
+	return object
+}
+
+// linkExistingObject links linkPath, the final path of pd's file, to the
+// stored object of its expected SHA256, so a file already in the archive is
+// not downloaded again. It returns the object path and whether the object
+// existed.
+func linkExistingObject(pd potentialDownload, linkPath string, cfg *models.Config) (string, bool) {
+	object := storedObject(pd, cfg)
+	if object == "" {
+		return "", false
+	}
+	if err := linkObject(object, linkPath); err != nil {
+		log.WithError(err).Warnf("Failed to link %s to the stored object, downloading it instead", linkPath)
+		return "", false
+	}
+	return object, true
+}
+
+// storeObject moves a downloaded file into the object store and links it back
+// at its path. When an identical object is already stored the file is replaced
+// by a link to it. expectedSHA256 is the hash the API reported, used to skip
+// hashing a file that already is a link to its object. It returns the object
+// path.
+func storeObject(path, expectedSHA256 string, cfg *models.Config) (string, error) {
+	if object := objectPath(cfg.SavePath, expectedSHA256); object != "" && sameFile(path, object) {
+		return object, nil
+	}
+
+	sum, err := helpers.FileSHA256(path)
+	if err != nil {
+		return "", err
+	}
+	object := objectPath(cfg.SavePath, sum)
+	if sameFile(path, object) {
+		return object, nil
+	}
+	if err := os.MkdirAll(helpers.LongPath(filepath.Dir(object)), 0750); err != nil {
+		return "", fmt.Errorf("creating object directory: %w", err)
+	}
+
+	if _, err := os.Stat(helpers.LongPath(object)); err == nil {
+		log.Infof("%s is identical to stored object %s, linking it", filepath.Base(path), sum)
+	} else if err := os.Rename(helpers.LongPath(path), helpers.LongPath(object)); err != nil {
+		return "", fmt.Errorf("moving %s into the object store: %w", path, err)
+	}
+	if err := linkObject(object, path); err != nil {
+		return "", err
+	}
+	return object, nil
+}
+
+// sameFile reports whether both paths exist and are the same file, following symlinks.
+func sameFile(a, b string) bool {
+	infoA, err := os.Stat(helpers.LongPath(a))
+	if err != nil {
+		return false
+	}
+	infoB, err := os.Stat(helpers.LongPath(b))
+	if err != nil {
+		return false
+	}
+	return os.SameFile(infoA, infoB)
+}
+
+// relObjectPath returns object relative to SavePath for the database.
+func relObjectPath(object string, cfg *models.Config) string {
+	if rel, err := filepath.Rel(cfg.SavePath, object); err == nil {
+		return rel
+	}
+	return object
+}
@@ -0,0 +1,109 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContentAddressedDownloadsShareObject(t *testing.T) {
+	content := []byte("shared-model-bytes")
+	sum := sha256.Sum256(content)
+	sha := hex.EncodeToString(sum[:])
+
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Disposition", "attachment; filename=model.safetensors")
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.Concurrency = 1
+	cfg.Download.VersionPathPattern = "{modelType}/{modelName}"
+	cfg.Download.ContentAddressed = true
+
+	download := func(modelID, versionID int, name string, hashes models.Hashes) potentialDownload {
+		return potentialDownload{
+			ModelID:        modelID,
+			ModelName:      name,
+			ModelVersionID: versionID,
+			FullModel:      models.Model{ID: modelID, Name: name, Type: "LORA"},
+			FullVersion:    models.ModelVersion{ID: versionID},
+			File:           models.File{ID: versionID, Primary: true, Name: "model.safetensors", DownloadUrl: server.URL, Hashes: hashes},
+		}
+	}
+	fileDownloader := downloader.NewDownloader(&http.Client{}, "", "")
+
+	// The API gave no SHA256, so the file is hashed once downloaded
+	queue, _ := filterAndPrepareDownloads([]potentialDownload{download(1, 100, "First", models.Hashes{})}, db, cfg)
+	require.Len(t, queue, 1)
+	require.NoError(t, executeDownloads(queue, db, fileDownloader, nil, cfg))
+	first := queue[0].TargetFilepath
+
+	object := objectPath(tmpDir, sha)
+	assert.FileExists(t, object)
+	assert.True(t, sameFile(first, object), "the download must be linked to its object")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+
+	raw, err := db.Get([]byte("v_100"))
+	require.NoError(t, err)
+	var entry models.DatabaseEntry
+	require.NoError(t, json.Unmarshal(raw, &entry))
+	assert.Equal(t, models.StatusDownloaded, entry.Status)
+	assert.Equal(t, filepath.Join(objectsDir, sha[:2], sha), entry.ObjectPath)
+
+	// A file already stored is linked instead of downloaded and adds nothing to the queued size
+	queue, size := filterAndPrepareDownloads([]potentialDownload{download(2, 200, "Second", models.Hashes{SHA256: sha})}, db, cfg)
+	require.Len(t, queue, 1)
+	assert.Zero(t, size)
+	require.NoError(t, executeDownloads(queue, db, fileDownloader, nil, cfg))
+	second := fileDownloader.FinalPath(queue[0].TargetFilepath, "model.safetensors", 200)
+	assert.Equal(t, "200_model.safetensors", filepath.Base(second), "the link must get the name a download would")
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests), "a stored object must not be downloaded again")
+	assert.True(t, sameFile(second, object))
+	assert.Equal(t, models.StatusDownloaded, entryStatus(t, db, 200))
+	data, err := os.ReadFile(second)
+	require.NoError(t, err)
+	assert.Equal(t, content, data)
+}
+
+func TestStoreObjectReplacesDuplicate(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &models.Config{SavePath: dir}
+	a := filepath.Join(dir, "a", "model.safetensors")
+	b := filepath.Join(dir, "b", "model.safetensors")
+	for _, path := range []string{a, b} {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		require.NoError(t, os.WriteFile(path, []byte("same"), 0600))
+	}
+
+	objectA, err := storeObject(a, "", cfg)
+	require.NoError(t, err)
+	objectB, err := storeObject(b, "", cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, objectA, objectB)
+	assert.True(t, sameFile(a, b))
+	assert.Equal(t, "", objectPath(dir, "not-a-sha256"))
+}
@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go-civitai-download/internal/api"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// fallbackSegmentPrefixes mark path segments built from missing metadata,
+// e.g. "unknown_creator" or "empty_baseModel".
+var fallbackSegmentPrefixes = []string{"unknown_", "empty_"}
+
+// runPreviewPaths runs the metadata phase of the download command and prints
+// where each file would be saved. Nothing is downloaded and the DB is not opened.
+func runPreviewPaths(cmd *cobra.Command, args []string) error {
+	cfg, err := validateDownloadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	httpClient := &http.Client{Transport: globalHttpTransport}
+	apiClient := api.NewClient(cfg.APIKey, httpClient, *cfg)
+
+	candidates, err := fetchPreviewCandidates(cfg, apiClient)
+	if err != nil {
+		return err
+	}
+
+	// Same client-side filter the download command applies after its DB check
+	kept := candidates[:0]
+	for _, pd := range candidates {
+		if !isIgnoredBaseModel(pd.FullVersion.BaseModel, cfg) && blockedReason(pd.ModelID, pd.ModelVersionID, cfg) == "" {
+			kept = append(kept, pd)
+		}
+	}
+	candidates = kept
+
+	if fallbacks := printPreviewPaths(os.Stdout, candidates, cfg); fallbacks > 0 {
+		log.Warnf("%d of %d paths contain fallback segments; check VersionPathPattern (%s).", fallbacks, len(candidates), cfg.Download.VersionPathPattern)
+	}
+	return nil
+}
+
+// fetchPreviewCandidates collects the files the download command would consider,
+// without the DB filtering done by fetchDownloadCandidates. Only the first page
+// of a query is fetched unless MaxPages is set.
+func fetchPreviewCandidates(cfg *models.Config, apiClient *api.Client) ([]potentialDownload, error) {
+	limit := cfg.Download.Limit
+
+	if cfg.Download.ModelID > 0 {
+		model, err := apiClient.GetModelDetails(cfg.Download.ModelID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get model details for ID %d: %w", cfg.Download.ModelID, err)
+		}
+		if model.Creator.Username == "" {
+			model.Creator.Username = "unknown_creator"
+		}
+		candidates, _ := processModelVersions(model, cfg, limit, 0, nil)
+		return candidates, nil
+	}
+
+	maxPages := cfg.Download.MaxPages
+	if maxPages <= 0 {
+		maxPages = 1
+	}
+	queryParams := buildQueryParameters(cfg)
+
+	var candidates []potentialDownload
+	var cursor string
+	for page := 1; page <= maxPages; page++ {
+		nextCursor, response, err := apiClient.GetModels(cursor, queryParams)
+		if err != nil {
+			handleAPIError(err, page)
+			return candidates, err
+		}
+
+		pageCandidates, reachedLimit := processModelsOnPage(response.Items, apiClient, cfg, limit, len(candidates), nil)
+		candidates = append(candidates, pageCandidates...)
+		if reachedLimit || nextCursor == "" || len(response.Items) == 0 {
+			break
+		}
+		cursor = nextCursor
+
+		if cfg.APIDelayMs > 0 {
+			time.Sleep(time.Duration(cfg.APIDelayMs) * time.Millisecond)
+		}
+	}
+	return candidates, nil
+}
+
+// printPreviewPaths writes one resolved path per candidate to w and returns how
+// many of them contain fallback segments.
+func printPreviewPaths(w io.Writer, candidates []potentialDownload, cfg *models.Config) int {
+	fallbacks := 0
+	for _, pd := range candidates {
+		relPath, finalBaseFilename, err := versionFilePath(pd, cfg)
+		if err != nil {
+			_, _ = fmt.Fprintf(w, "ERROR  version %d, file %s: %v\n", pd.ModelVersionID, pd.File.Name, err)
+			continue
+		}
+
+		path := filepath.Join(relPath, finalBaseFilename)
+		if segment := fallbackSegment(relPath); segment != "" {
+			fallbacks++
+			_, _ = fmt.Fprintf(w, "%s  [WARNING: fallback segment %q]\n", path, segment)
+			continue
+		}
+		_, _ = fmt.Fprintln(w, path)
+	}
+	return fallbacks
+}
+
+// fallbackSegment returns the first segment of relPath that was filled in
+// from missing metadata, or "" if there is none.
+func fallbackSegment(relPath string) string {
+	for _, segment := range strings.Split(filepath.ToSlash(relPath), "/") {
+		for _, prefix := range fallbackSegmentPrefixes {
+			if strings.HasPrefix(strings.ToLower(segment), prefix) {
+				return segment
+			}
+		}
+	}
+	return ""
+}
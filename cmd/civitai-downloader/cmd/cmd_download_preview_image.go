@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// previewImageSuffix replaces the extension of a model file to name its preview.
+const previewImageSuffix = ".preview.png"
+
+// previewImagePath returns where the preview of the model file at modelFilePath is
+// saved: next to it, in the version folder.
+func previewImagePath(modelFilePath string) string {
+	return strings.TrimSuffix(modelFilePath, filepath.Ext(modelFilePath)) + previewImageSuffix
+}
+
+// selectPreviewImage picks the first image that is not NSFW, or else the
+// first image, in which case safe is false. ok is false without images.
+func selectPreviewImage(images []models.ModelImage) (image models.ModelImage, safe bool, ok bool) {
+	for _, img := range images {
+		if img.URL == "" || img.Nsfw {
+			continue
+		}
+		if rank, known := models.ImageNsfwRank(img.NsfwLevel); known && rank > 0 {
+			continue
+		}
+		return img, true, true
+	}
+	for _, img := range images {
+		if img.URL != "" {
+			return img, false, true
+		}
+	}
+	return models.ModelImage{}, false, false
+}
+
+// handlePreview saves the preview image of a successful download if enabled
+// (--preview).
+func (ctx *WorkerContext) handlePreview(pd potentialDownload, finalPath, finalStatus string) {
+	if !ctx.Config.Download.SavePreview || finalStatus != models.StatusDownloaded {
+		return
+	}
+	logPrefix := fmt.Sprintf("[%s-Preview]", ctx.LogPrefix)
+	if err := savePreviewImage(logPrefix, pd, metadataFilePath(ctx.Config, finalPath), ctx.ImageDownloader); err != nil {
+		log.WithError(err).Errorf("%s Failed to save preview for %s", logPrefix, filepath.Base(finalPath))
+	}
+}
+
+// savePreviewImage downloads the image chosen by selectPreviewImage to
+// previewImagePath(modelFilePath). An existing preview is kept. The image keeps its
+// format; like the WebUI, only the name says .png.
+func savePreviewImage(logPrefix string, pd potentialDownload, modelFilePath string, imageDownloader *downloader.Downloader) error {
+	target := previewImagePath(modelFilePath)
+	if _, err := os.Stat(helpers.LongPath(target)); err == nil {
+		log.Debugf("%s Preview %s already exists, skipping.", logPrefix, target)
+		return nil
+	}
+
+	image, safe, ok := selectPreviewImage(pd.OriginalImages)
+	if !ok {
+		log.Debugf("%s No images to take a preview from for %s", logPrefix, pd.FinalBaseFilename)
+		return nil
+	}
+	if !safe {
+		log.Warnf("%s Version %d has no non-NSFW image, using image %d as the preview of %s", logPrefix, pd.ModelVersionID, image.ID, filepath.Base(modelFilePath))
+	}
+	if imageDownloader == nil {
+		return errors.New("image downloader is not initialized")
+	}
+
+	// Download under the image's own extension; the downloader may correct it
+	// from the content, so the result is renamed afterwards
+	ext := ".jpeg"
+	if parsed, err := url.Parse(image.URL); err == nil && filepath.Ext(parsed.Path) != "" {
+		ext = filepath.Ext(parsed.Path)
+	}
+	downloadPath := strings.TrimSuffix(target, filepath.Ext(target)) + ext
+	savedPath, err := imageDownloader.DownloadFile(downloadPath, image.URL, models.Hashes{}, 0)
+	if err != nil {
+		return fmt.Errorf("downloading image %d: %w", image.ID, err)
+	}
+	if savedPath != target {
+		if err := os.Rename(helpers.LongPath(savedPath), helpers.LongPath(target)); err != nil {
+			return fmt.Errorf("renaming %s to %s: %w", savedPath, target, err)
+		}
+	}
+	log.Infof("%s Saved preview %s", logPrefix, target)
+	return nil
+}
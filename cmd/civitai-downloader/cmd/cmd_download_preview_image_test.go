@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelectPreviewImage(t *testing.T) {
+	images := []models.ModelImage{
+		{ID: 1, URL: "https://image.civitai.com/1.jpeg", Nsfw: true},
+		{ID: 2, URL: "https://image.civitai.com/2.jpeg", NsfwLevel: "Mature"},
+		{ID: 3, URL: "https://image.civitai.com/3.jpeg", NsfwLevel: "None"},
+	}
+	image, safe, ok := selectPreviewImage(images)
+	require.True(t, ok)
+	assert.True(t, safe)
+	assert.Equal(t, 3, image.ID)
+
+	image, safe, ok = selectPreviewImage(images[:2])
+	require.True(t, ok)
+	assert.False(t, safe, "falls back to the first image")
+	assert.Equal(t, 1, image.ID)
+
+	_, _, ok = selectPreviewImage(nil)
+	assert.False(t, ok)
+}
+
+func TestSavePreviewImage(t *testing.T) {
+	jpeg := []byte("\xff\xd8\xff\xe0\x00\x10JFIF\x00\x01\x01\x00\x00\x01\x00\x01\x00\x00")
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.Equal(t, "/safe.jpeg", r.URL.Path)
+		_, _ = w.Write(jpeg)
+	}))
+	defer server.Close()
+
+	dir := chdirTemp(t)
+	versionDir := filepath.Join(dir, "lora", "model", "42-model")
+	require.NoError(t, os.MkdirAll(versionDir, 0750))
+	modelPath := filepath.Join(versionDir, "42_model.safetensors")
+	pd := potentialDownload{
+		ModelVersionID: 42,
+		OriginalImages: []models.ModelImage{
+			{ID: 1, URL: server.URL + "/nsfw.jpeg", NsfwLevel: "X"},
+			{ID: 2, URL: server.URL + "/safe.jpeg", NsfwLevel: "None"},
+		},
+	}
+	imageDownloader := downloader.NewDownloader(server.Client(), "", "")
+
+	require.NoError(t, savePreviewImage("test", pd, modelPath, imageDownloader))
+	data, err := os.ReadFile(filepath.Join(versionDir, "42_model.preview.png"))
+	require.NoError(t, err)
+	assert.Equal(t, jpeg, data)
+	entries, err := os.ReadDir(versionDir)
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "only the preview is left in the version folder")
+
+	// An existing preview is kept
+	require.NoError(t, savePreviewImage("test", pd, modelPath, imageDownloader))
+	assert.Equal(t, 1, requests)
+}
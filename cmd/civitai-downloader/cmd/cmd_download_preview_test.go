@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-civitai-download/internal/models"
+	"go-civitai-download/internal/paths"
+)
+
+func TestPrintPreviewPaths(t *testing.T) {
+	cfg := &models.Config{}
+	cfg.Download.VersionPathPattern = "{modelType}/{creatorName}/{baseModel}/{modelName}"
+
+	complete := potentialDownload{
+		ModelVersionID: 11,
+		FullModel:      models.Model{ID: 1, Name: "My Model", Type: "LORA", Creator: models.Creator{Username: "alice"}},
+		FullVersion:    models.ModelVersion{ID: 11, BaseModel: "SDXL 1.0"},
+		File:           models.File{Name: "my_model.safetensors"},
+	}
+	noCreator := complete
+	noCreator.ModelVersionID = 12
+	noCreator.FullModel.Creator = models.Creator{}
+	noCreator.FullVersion.ID = 12
+
+	var out bytes.Buffer
+	fallbacks := printPreviewPaths(&out, []potentialDownload{complete, noCreator}, cfg)
+	if fallbacks != 1 {
+		t.Errorf("expected 1 path with fallback segments, got %d", fallbacks)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", out.String())
+	}
+	if strings.Contains(lines[0], "WARNING") || !strings.HasPrefix(lines[0], filepath.Join("lora", "alice")) {
+		t.Errorf("unexpected path for complete metadata: %s", lines[0])
+	}
+	if !strings.Contains(lines[1], "unknown_creator") || !strings.Contains(lines[1], "WARNING") {
+		t.Errorf("expected a fallback warning for the missing creator: %s", lines[1])
+	}
+}
+
+func TestBuildPathData_TypeFolderMap(t *testing.T) {
+	cfg := &models.Config{}
+	cfg.Download.VersionPathPattern = "{modelType}/{modelName}"
+	// Viper lower-cases the keys read from the config file
+	cfg.Download.TypeFolderMap = map[string]string{"lora": "Lora", "TextualInversion": "embeddings"}
+
+	tests := map[string]string{
+		"LORA":             filepath.Join("Lora", "my_model"),
+		"TextualInversion": filepath.Join("embeddings", "my_model"),
+		"Checkpoint":       filepath.Join("checkpoint", "my_model"),
+	}
+	for modelType, want := range tests {
+		model := models.Model{ID: 1, Name: "My Model", Type: modelType}
+		data := buildPathData(&model, &models.ModelVersion{ID: 11}, nil, cfg.Download.TypeFolderMap)
+		got, err := paths.GeneratePath(cfg.Download.VersionPathPattern, data)
+		if err != nil {
+			t.Fatalf("GeneratePath: %v", err)
+		}
+		if got != want {
+			t.Errorf("type %s: got path %q, want %q", modelType, got, want)
+		}
+	}
+}
+
+func TestBuildPathData_FileTags(t *testing.T) {
+	pattern := "{modelType}/{baseModel}/{fp}/{size}-{fileFormat}.{fileExt}"
+	model := models.Model{ID: 1, Name: "My Model", Type: "Checkpoint"}
+	version := models.ModelVersion{ID: 11, BaseModel: "SDXL 1.0"}
+
+	file := models.File{Name: "model.SafeTensors", Metadata: models.Metadata{Fp: "fp16", Size: "pruned", Format: "SafeTensor"}}
+	got, err := paths.GeneratePath(pattern, buildPathData(&model, &version, &file, nil))
+	if err != nil {
+		t.Fatalf("GeneratePath: %v", err)
+	}
+	if want := filepath.Join("checkpoint", "sdxl_1.0", "fp16", "pruned-safetensor.safetensors"); got != want {
+		t.Errorf("got path %q, want %q", got, want)
+	}
+
+	got, err = paths.GeneratePath(pattern, buildPathData(&model, &version, &models.File{Name: "model"}, nil))
+	if err != nil {
+		t.Fatalf("GeneratePath: %v", err)
+	}
+	if want := filepath.Join("checkpoint", "sdxl_1.0", "unknown_fp", "unknown_size-unknown_format.unknown_ext"); got != want {
+		t.Errorf("got path %q, want %q", got, want)
+	}
+	if segment := fallbackSegment(got); segment != "unknown_fp" {
+		t.Errorf("expected the missing fp to be flagged, got %q", segment)
+	}
+}
+
+func TestFallbackSegment(t *testing.T) {
+	tests := map[string]string{
+		"lora/alice/sdxl-1.0":        "",
+		"lora/unknown_creator/sdxl":  "unknown_creator",
+		"lora/alice/empty_baseModel": "empty_baseModel",
+	}
+	for relPath, want := range tests {
+		if got := fallbackSegment(relPath); got != want {
+			t.Errorf("fallbackSegment(%q) = %q, want %q", relPath, got, want)
+		}
+	}
+}
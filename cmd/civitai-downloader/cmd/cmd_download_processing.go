@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/url"
 	"os"
@@ -24,8 +23,12 @@ type imageDownloadJob struct {
 	SourceURL   string
 	TargetPath  string
 	LogFilename string // Keep base filename for logging
+	// Interface
+	Meta interface{} // Generation metadata from the API, checked for a workflow
 	// Integer
 	ImageID int // Keep ID for logging
+	// Struct
+	Workflows imageWorkflowSettings
 }
 
 // --- Structs for Concurrent Image Downloads --- END ---
@@ -82,7 +85,7 @@ func imageDownloadWorkerInternal(id int, jobs <-chan imageDownloadJob, imageDown
 		// Download the image
 		log.Debugf("[%s-Worker-%d] Downloading image %s from %s", logPrefix, id, job.LogFilename, job.SourceURL)
 		// Always pass empty hashes for images, as API doesn't provide standard ones
-		_, dlErr := imageDownloader.DownloadFile(job.TargetPath, job.SourceURL, models.Hashes{}, 0)
+		finalPath, dlErr := imageDownloader.DownloadFile(job.TargetPath, job.SourceURL, models.Hashes{}, 0)
 
 		if dlErr != nil {
 			log.WithError(dlErr).Errorf("[%s-Worker-%d] Failed to download image %s from %s", logPrefix, id, job.LogFilename, job.SourceURL)
@@ -90,6 +93,9 @@ func imageDownloadWorkerInternal(id int, jobs <-chan imageDownloadJob, imageDown
 		} else {
 			log.Debugf("[%s-Worker-%d] Downloaded image %s successfully.", logPrefix, id, job.LogFilename)
 			atomic.AddInt64(successCounter, 1)
+			if job.Workflows.Save {
+				saveImageWorkflow(finalPath, job.Meta, job.Workflows.Compact)
+			}
 		}
 	}
 	log.Debugf("[%s-Worker-%d] Finishing internal image worker", logPrefix, id)
@@ -114,18 +120,18 @@ func saveModelInfoFile(pd potentialDownload, cfg *models.Config) error {
 	// We need to build the data map for the path generator.
 	// We use the specific version data from the potential download to ensure
 	// placeholders like {baseModel} are resolved correctly for this version.
-	data := buildPathData(&model, &pd.FullVersion, &pd.File)
+	data := buildPathData(&model, &pd.FullVersion, &pd.File, cfg.Download.TypeFolderMap)
 
 	relModelInfoDir, err := paths.GeneratePath(cfg.Download.ModelInfoPathPattern, data)
 	if err != nil {
 		log.WithError(err).Errorf("Failed to generate model info path for model %s (ID: %d) using pattern '%s'. Skipping info save.", model.Name, model.ID, cfg.Download.ModelInfoPathPattern)
 		return err
 	}
-	infoDirPath := filepath.Join(cfg.SavePath, relModelInfoDir)
+	infoDirPath := filepath.Join(metadataRoot(cfg), relModelInfoDir)
 	// --- End Path Generation ---
 
 	// Ensure the directory exists
-	if err := os.MkdirAll(infoDirPath, 0750); err != nil {
+	if err := os.MkdirAll(helpers.LongPath(infoDirPath), 0750); err != nil {
 		log.WithError(err).Errorf("Failed to create model info directory: %s", infoDirPath)
 		return fmt.Errorf("failed to create directory %s: %w", infoDirPath, err)
 	}
@@ -139,14 +145,14 @@ func saveModelInfoFile(pd potentialDownload, cfg *models.Config) error {
 	filePath := filepath.Join(infoDirPath, fileName)
 
 	// Marshal the full model info
-	jsonData, jsonErr := json.MarshalIndent(model, "", "  ")
+	jsonData, jsonErr := helpers.MarshalMetadata(model, cfg.JSONCompact)
 	if jsonErr != nil {
 		log.WithError(jsonErr).Warnf("Failed to marshal full model info for model %d (%s)", model.ID, model.Name)
 		return fmt.Errorf("failed to marshal model info for %d: %w", model.ID, jsonErr)
 	}
 
 	// Write the file (overwrite if exists)
-	if writeErr := os.WriteFile(filePath, jsonData, 0600); writeErr != nil {
+	if writeErr := os.WriteFile(helpers.LongPath(filePath), jsonData, 0600); writeErr != nil {
 		log.WithError(writeErr).Warnf("Failed to write model info file %s", filePath)
 		return fmt.Errorf("failed to write model info file %s: %w", filePath, writeErr)
 	}
@@ -155,9 +161,49 @@ func saveModelInfoFile(pd potentialDownload, cfg *models.Config) error {
 	return nil
 }
 
+// imageLimit returns the maxImages value to pass to downloadImages.
+// PrimaryImageOnly keeps just the first image, which is the cover image Civitai shows for a version.
+func imageLimit(cfg *models.Config) int {
+	if cfg.Download.PrimaryImageOnly {
+		return 1
+	}
+	return cfg.Download.MaxImages
+}
+
+// collectModelImages gathers the gallery images of every version of model.
+// With dedupe (Download.DedupeImages) each image is kept once: the cover image
+// is usually repeated in every version, so images are matched by ID and,
+// failing that, by URL. The image Hash is a blurhash preview, not a content
+// hash, so it is not used.
+func collectModelImages(model models.Model, dedupe bool) []models.ModelImage {
+	var images []models.ModelImage
+	seenIDs := make(map[int]bool)
+	seenURLs := make(map[string]bool)
+	for _, version := range model.ModelVersions {
+		if !dedupe {
+			images = append(images, version.Images...)
+			continue
+		}
+		for _, image := range version.Images {
+			if (image.ID != 0 && seenIDs[image.ID]) || (image.URL != "" && seenURLs[image.URL]) {
+				continue
+			}
+			if image.ID != 0 {
+				seenIDs[image.ID] = true
+			}
+			if image.URL != "" {
+				seenURLs[image.URL] = true
+			}
+			images = append(images, image)
+		}
+	}
+	return images
+}
+
 // downloadImages handles downloading a list of images concurrently to a specified directory.
 // If maxImages > 0, only the first maxImages images will be downloaded.
-func downloadImages(logPrefix string, images []models.ModelImage, targetImageDir string, imageDownloader *downloader.Downloader, numWorkers int, maxImages int) (finalSuccessCount, finalFailCount int) {
+// When workflows.Save is set, each image's ComfyUI workflow is saved next to it.
+func downloadImages(logPrefix string, images []models.ModelImage, targetImageDir string, imageDownloader *downloader.Downloader, numWorkers int, maxImages int, workflows imageWorkflowSettings) (finalSuccessCount, finalFailCount int) {
 	if imageDownloader == nil {
 		log.Warnf("[%s] Image downloader is nil, cannot download images.", logPrefix)
 		return 0, len(images) // Count all as failed if downloader doesn't exist
@@ -244,6 +290,8 @@ func downloadImages(logPrefix string, images []models.ModelImage, targetImageDir
 			TargetPath:  imgTargetPath,
 			ImageID:     image.ID,
 			LogFilename: imgFilename, // Pass for consistent logging
+			Meta:        image.Meta,
+			Workflows:   workflows,
 		}
 		log.Debugf("[%s] Queueing image job: ID %d -> %s", logPrefix, job.ImageID, job.TargetPath)
 		jobs <- job
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectModelImages_DedupesAcrossVersions(t *testing.T) {
+	cover := models.ModelImage{ID: 1, URL: "https://image.civitai.com/cover.jpeg"}
+	model := models.Model{
+		ModelVersions: []models.ModelVersion{
+			{ID: 10, Images: []models.ModelImage{cover, {ID: 2, URL: "https://image.civitai.com/a.jpeg"}}},
+			{ID: 11, Images: []models.ModelImage{cover, {ID: 3, URL: "https://image.civitai.com/b.jpeg"}}},
+			// Same URL without an ID, and a different image that shares a blurhash
+			{ID: 12, Images: []models.ModelImage{
+				{URL: "https://image.civitai.com/a.jpeg"},
+				{ID: 4, URL: "https://image.civitai.com/c.jpeg", Hash: "UABC"},
+				{ID: 5, URL: "https://image.civitai.com/d.jpeg", Hash: "UABC"},
+			}},
+		},
+	}
+
+	ids := func(dedupe bool) []int {
+		var ids []int
+		for _, image := range collectModelImages(model, dedupe) {
+			ids = append(ids, image.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids(true))
+	assert.Equal(t, []int{1, 2, 1, 3, 0, 4, 5}, ids(false), "without DedupeImages every listed image is kept")
+}
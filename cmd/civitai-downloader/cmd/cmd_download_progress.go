@@ -0,0 +1,146 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Events of the --progress-json stream.
+const (
+	progressEventStarted   = "started"   // The download of a file begins
+	progressEventProgress  = "progress"  // More of the file was received
+	progressEventCompleted = "completed" // The file was downloaded, found on disk or linked
+	progressEventSkipped   = "skipped"   // Already downloaded according to the database
+	progressEventFailed    = "failed"    // The download failed
+	progressEventCancelled = "cancelled" // The run stopped before the file was done; it stays Pending
+)
+
+// progressJSONStderr is the Download.ProgressJSON value of a bare
+// --progress-json, which writes the events to stderr.
+const progressJSONStderr = "-"
+
+// progressJSONInterval is how often a download in progress is checked for a
+// progress event.
+var progressJSONInterval = time.Second
+
+// progressEvent is one line of the --progress-json stream.
+type progressEvent struct {
+	Event           string `json:"event"`
+	VersionID       int    `json:"versionId"`
+	FileID          int    `json:"fileId"`
+	Filename        string `json:"filename"`
+	BytesDownloaded uint64 `json:"bytesDownloaded"`
+	TotalBytes      uint64 `json:"totalBytes"`
+	Status          string `json:"status"`
+	Error           string `json:"error,omitempty"`
+	Time            string `json:"time"`
+}
+
+// progressReporter writes the --progress-json stream: one JSON object per
+// line for every state change of a download. The workers share it, so writes
+// are serialised. A nil reporter writes nothing.
+type progressReporter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+// newProgressReporter returns a reporter writing to w.
+func newProgressReporter(w io.Writer) *progressReporter {
+	return &progressReporter{enc: json.NewEncoder(w)}
+}
+
+// runProgressReporter returns the reporter for a download run and a function
+// closing its output: with --progress-json a reporter writing to stderr, or
+// to the file given, and nil otherwise.
+func runProgressReporter(cfg *models.Config) (*progressReporter, func(), error) {
+	switch cfg.Download.ProgressJSON {
+	case "":
+		return nil, func() {}, nil
+	case progressJSONStderr:
+		return newProgressReporter(os.Stderr), func() {}, nil
+	}
+	// #nosec G304 -- the path is given by the user
+	f, err := os.OpenFile(helpers.LongPath(cfg.Download.ProgressJSON), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600) //nolint:gosec
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open --progress-json file: %w", err)
+	}
+	return newProgressReporter(f), func() { _ = f.Close() }, nil
+}
+
+// progressLogOutput moves the log to stdout when --progress-json (given as
+// progressJSON) writes its events to stderr, so stderr carries nothing but
+// the events.
+func progressLogOutput(progressJSON string) {
+	if progressJSON == progressJSONStderr {
+		log.SetOutput(os.Stdout)
+	}
+}
+
+// report writes an event for the file of pd.
+func (r *progressReporter) report(event string, pd potentialDownload, filename string, bytesDownloaded uint64, status string, err error) {
+	if r == nil {
+		return
+	}
+	ev := progressEvent{
+		Event:           event,
+		VersionID:       pd.ModelVersionID,
+		FileID:          pd.File.ID,
+		Filename:        filepath.Base(filename),
+		BytesDownloaded: bytesDownloaded,
+		TotalBytes:      uint64(pd.File.SizeKB * 1024),
+		Status:          status,
+		Time:            time.Now().UTC().Format(time.RFC3339),
+	}
+	if err != nil {
+		ev.Error = err.Error()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_ = r.enc.Encode(ev)
+}
+
+// watch reports progress events for the download of pd into targetPath until
+// the returned function is called. The bytes received are read from received,
+// which the downloader keeps up to date (see downloader.WithFileProgress).
+func (r *progressReporter) watch(pd potentialDownload, targetPath string, received *atomic.Uint64) (stop func()) {
+	if r == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(progressJSONInterval)
+		defer ticker.Stop()
+		var last uint64
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				n := received.Load()
+				if n == last {
+					continue
+				}
+				last = n
+				r.report(progressEventProgress, pd, targetPath, n, models.StatusPending, nil)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-finished
+	}
+}
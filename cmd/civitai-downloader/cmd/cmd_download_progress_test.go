@@ -0,0 +1,197 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/models"
+
+	"github.com/gosuri/uilive"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// decodeProgressEvents parses the --progress-json lines written to out.
+func decodeProgressEvents(t *testing.T, out *bytes.Buffer) []progressEvent {
+	t.Helper()
+	var events []progressEvent
+	dec := json.NewDecoder(out)
+	for dec.More() {
+		var ev progressEvent
+		require.NoError(t, dec.Decode(&ev))
+		events = append(events, ev)
+	}
+	return events
+}
+
+func TestPerformFileDownload_ProgressJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("model-bytes"))
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	var out bytes.Buffer
+	ctx := &WorkerContext{
+		RunCtx:         context.Background(),
+		FileDownloader: downloader.NewDownloader(&http.Client{}, "", ""),
+		Writer:         uilive.New(),
+		Progress:       newProgressReporter(&out),
+		Config:         &models.Config{SavePath: tmpDir},
+		LogPrefix:      "test",
+	}
+	newDownload := func(versionID int, url string) potentialDownload {
+		file := models.File{ID: versionID * 10, Name: "model.safetensors", SizeKB: 1, DownloadUrl: url}
+		return potentialDownload{ModelVersionID: versionID, File: file, TargetFilepath: filepath.Join(tmpDir, "model.safetensors")}
+	}
+
+	pd := newDownload(1, server.URL+"/ok")
+	_, status, err := ctx.performFileDownload(pd, "v_1", models.StatusPending, pd.TargetFilepath)
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusDownloaded, status)
+
+	events := decodeProgressEvents(t, &out)
+	require.Len(t, events, 2)
+	assert.Equal(t, progressEventStarted, events[0].Event)
+	assert.Equal(t, 1, events[0].VersionID)
+	assert.Equal(t, 10, events[0].FileID)
+	assert.Equal(t, uint64(1024), events[0].TotalBytes)
+	assert.Equal(t, progressEventCompleted, events[1].Event)
+	assert.Equal(t, "1_model.safetensors", events[1].Filename, "the name the file was saved as")
+	assert.Equal(t, uint64(len("model-bytes")), events[1].BytesDownloaded)
+	assert.Equal(t, models.StatusDownloaded, events[1].Status)
+
+	// Already downloaded according to the database
+	_, _, err = ctx.performFileDownload(pd, "v_1", models.StatusDownloaded, pd.TargetFilepath)
+	require.NoError(t, err)
+	events = decodeProgressEvents(t, &out)
+	require.Len(t, events, 1)
+	assert.Equal(t, progressEventSkipped, events[0].Event)
+
+	pd = newDownload(2, server.URL+"/fail")
+	pd.TargetFilepath = filepath.Join(tmpDir, "other.safetensors")
+	_, status, err = ctx.performFileDownload(pd, "v_2", models.StatusPending, pd.TargetFilepath)
+	require.Error(t, err)
+	assert.Equal(t, models.StatusError, status)
+	events = decodeProgressEvents(t, &out)
+	require.Len(t, events, 2)
+	assert.Equal(t, progressEventFailed, events[1].Event)
+	assert.Equal(t, models.StatusError, events[1].Status)
+	assert.NotEmpty(t, events[1].Error)
+}
+
+func TestProgressReporter_Nil(t *testing.T) {
+	var reporter *progressReporter
+	assert.NotPanics(t, func() {
+		reporter.report(progressEventStarted, potentialDownload{}, "model.safetensors", 0, models.StatusPending, nil)
+		reporter.watch(potentialDownload{}, "model.safetensors", &atomic.Uint64{})()
+	})
+	reporter, closeReporter, err := runProgressReporter(&models.Config{})
+	require.NoError(t, err)
+	closeReporter()
+	assert.Nil(t, reporter)
+}
+
+func TestRunProgressReporter_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "progress.ndjson")
+	cfg := &models.Config{}
+	cfg.Download.ProgressJSON = path
+	reporter, closeReporter, err := runProgressReporter(cfg)
+	require.NoError(t, err)
+	reporter.report(progressEventStarted, potentialDownload{ModelVersionID: 3}, "model.safetensors", 0, models.StatusPending, nil)
+	closeReporter()
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	events := decodeProgressEvents(t, bytes.NewBuffer(raw))
+	require.Len(t, events, 1)
+	assert.Equal(t, 3, events[0].VersionID)
+}
+
+func TestProgressJSON_StderrHasOnlyEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("model-bytes"))
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	queue := []potentialDownload{queueTestDownload(t, db, tmpDir, 701, server.URL+"/ok")}
+
+	stdout, err := os.Create(filepath.Join(tmpDir, "stdout"))
+	require.NoError(t, err)
+	stderr, err := os.Create(filepath.Join(tmpDir, "stderr"))
+	require.NoError(t, err)
+	oldStdout, oldStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	defer func() {
+		os.Stdout, os.Stderr = oldStdout, oldStderr
+		log.SetOutput(os.Stderr)
+		_ = stdout.Close()
+		_ = stderr.Close()
+	}()
+
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.Concurrency = 1
+	cfg.Download.ProgressJSON = progressJSONStderr
+	progressLogOutput(cfg.Download.ProgressJSON)
+	log.Warn("a log line")
+	require.NoError(t, executeDownloads(queue, db, downloader.NewDownloader(&http.Client{}, "", ""), nil, cfg))
+
+	raw, err := os.ReadFile(stderr.Name())
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(raw)), "\n")
+	require.NotEmpty(t, lines)
+	for _, line := range lines {
+		var ev progressEvent
+		require.NoError(t, json.Unmarshal([]byte(line), &ev), "stderr line %q is not a progress event", line)
+		assert.NotEmpty(t, ev.Event)
+	}
+	assert.Equal(t, progressEventCompleted, decodeProgressEvents(t, bytes.NewBuffer(raw))[len(lines)-1].Event)
+
+	logged, err := os.ReadFile(stdout.Name())
+	require.NoError(t, err)
+	assert.Contains(t, string(logged), "a log line", "the log moves to stdout")
+}
+
+func TestProgressReporter_WatchReportsBytesReceived(t *testing.T) {
+	oldInterval := progressJSONInterval
+	progressJSONInterval = 10 * time.Millisecond
+	defer func() { progressJSONInterval = oldInterval }()
+
+	// A segmented download preallocates the .part file, so its size says nothing
+	target := filepath.Join(t.TempDir(), "model.safetensors")
+	require.NoError(t, os.WriteFile(target+".part", make([]byte, 4096), 0600))
+	var received atomic.Uint64
+	received.Store(512)
+
+	var out bytes.Buffer
+	reporter := newProgressReporter(&out)
+	stop := reporter.watch(potentialDownload{ModelVersionID: 7}, target, &received)
+	time.Sleep(50 * time.Millisecond)
+	stop()
+
+	events := decodeProgressEvents(t, &out)
+	require.Len(t, events, 1, "an unchanged byte count is reported once")
+	assert.Equal(t, progressEventProgress, events[0].Event)
+	assert.Equal(t, 7, events[0].VersionID)
+	assert.Equal(t, uint64(512), events[0].BytesDownloaded)
+}
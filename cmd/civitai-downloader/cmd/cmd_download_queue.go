@@ -0,0 +1,219 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Values accepted for Download.QueueOrder.
+const (
+	queueOrderNone     = "none"
+	queueOrderSizeAsc  = "size-asc"
+	queueOrderSizeDesc = "size-desc"
+)
+
+func isValidQueueOrder(order string) bool {
+	switch order {
+	case "", queueOrderNone, queueOrderSizeAsc, queueOrderSizeDesc:
+		return true
+	}
+	return false
+}
+
+// sortDownloadQueue orders downloads by file size in place. Files of equal
+// size, and every file with "none", keep the order the API returned them in.
+func sortDownloadQueue(downloads []potentialDownload, order string) {
+	switch order {
+	case queueOrderSizeAsc:
+		sort.SliceStable(downloads, func(i, j int) bool {
+			return downloads[i].File.SizeKB < downloads[j].File.SizeKB
+		})
+	case queueOrderSizeDesc:
+		sort.SliceStable(downloads, func(i, j int) bool {
+			return downloads[i].File.SizeKB > downloads[j].File.SizeKB
+		})
+	}
+}
+
+// interleaveByModel reorders downloads round-robin across models, so consecutive
+// jobs come from different models where possible. Each model keeps its own order
+// and models take turns in the order they first appear.
+func interleaveByModel(downloads []potentialDownload) []potentialDownload {
+	var modelOrder []int
+	byModel := make(map[int][]potentialDownload)
+	for _, pd := range downloads {
+		if _, ok := byModel[pd.ModelID]; !ok {
+			modelOrder = append(modelOrder, pd.ModelID)
+		}
+		byModel[pd.ModelID] = append(byModel[pd.ModelID], pd)
+	}
+
+	interleaved := make([]potentialDownload, 0, len(downloads))
+	for round := 0; len(interleaved) < len(downloads); round++ {
+		for _, modelID := range modelOrder {
+			if round < len(byModel[modelID]) {
+				interleaved = append(interleaved, byModel[modelID][round])
+			}
+		}
+	}
+	return interleaved
+}
+
+// saveDownloadQueue replaces the persistent download queue with downloads,
+// keeping their order, so a later `download --resume` can continue them
+// without repeating the metadata fetch. An empty list leaves the queue as it is.
+func saveDownloadQueue(db *database.DB, downloads []potentialDownload) error {
+	if len(downloads) == 0 {
+		return nil
+	}
+	items := make([]database.QueueItem, 0, len(downloads))
+	for i, pd := range downloads {
+		payload, err := json.Marshal(pd)
+		if err != nil {
+			return fmt.Errorf("encoding queued download for version %d: %w", pd.ModelVersionID, err)
+		}
+		items = append(items, database.QueueItem{
+			VersionID: pd.ModelVersionID,
+			FileID:    pd.File.ID,
+			Priority:  i,
+			Payload:   payload,
+		})
+	}
+
+	if err := db.ReplaceQueue(items); err != nil {
+		return err
+	}
+	log.Infof("Saved %d downloads to the queue (continue later with --resume).", len(items))
+	return nil
+}
+
+// replaceDownloadQueue saves downloads as the queue for --resume. When the saved
+// queue still has unfinished downloads the user is asked on in and out before
+// they are dropped; with a nil in (under --yes) the saved queue is kept and
+// this run's downloads are not saved.
+func replaceDownloadQueue(db *database.DB, downloads []potentialDownload, in io.Reader, out io.Writer) error {
+	if len(downloads) == 0 {
+		return nil
+	}
+	unfinished, err := db.QueuedItems()
+	if err != nil {
+		return err
+	}
+	if len(unfinished) > 0 && !confirmQueueReplace(len(unfinished), in, out) {
+		log.Warnf("Keeping the saved queue with %d unfinished downloads (continue it with --resume); this run's downloads are not saved to it.", len(unfinished))
+		return nil
+	}
+	return saveDownloadQueue(db, downloads)
+}
+
+// confirmQueueReplace asks whether the saved queue with unfinished downloads
+// may be replaced. A nil in declines without asking.
+func confirmQueueReplace(unfinished int, in io.Reader, out io.Writer) bool {
+	if in == nil {
+		return false
+	}
+	reader := bufio.NewReader(in)
+	for {
+		_, _ = fmt.Fprintf(out, "The saved queue still has %d unfinished downloads. Replace it with this run's downloads? (y/n): ", unfinished)
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			log.WithError(err).Error("Error reading input, keeping the saved queue.")
+			return false
+		}
+		switch strings.TrimSpace(strings.ToLower(input)) {
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		default:
+			_, _ = fmt.Fprintln(out, "Invalid input. Please enter 'y' or 'n'.")
+		}
+	}
+}
+
+// loadDownloadQueue returns the downloads still queued from a previous run, in order.
+func loadDownloadQueue(db *database.DB) ([]potentialDownload, error) {
+	items, err := db.QueuedItems()
+	if err != nil {
+		return nil, err
+	}
+
+	downloads := make([]potentialDownload, 0, len(items))
+	for _, item := range items {
+		var pd potentialDownload
+		if err := json.Unmarshal(item.Payload, &pd); err != nil {
+			log.WithError(err).Warnf("Skipping unreadable queue entry for version %d", item.VersionID)
+			continue
+		}
+		downloads = append(downloads, pd)
+	}
+	return downloads, nil
+}
+
+// attemptsExhausted reports whether entry has failed MaxAttempts times and
+// should no longer be retried automatically.
+func attemptsExhausted(entry *models.DatabaseEntry, cfg *models.Config) bool {
+	maxAttempts := cfg.Download.MaxAttempts
+	return maxAttempts > 0 && entry.Status == models.StatusError && entry.AttemptCount >= maxAttempts
+}
+
+// exhaustedReason is the summary reason of a file left out for attemptsExhausted.
+func exhaustedReason(entry *models.DatabaseEntry, cfg *models.Config) string {
+	return fmt.Sprintf("failed %d times (MaxAttempts %d)", entry.AttemptCount, cfg.Download.MaxAttempts)
+}
+
+// dropExhaustedDownloads removes downloads whose DB entry has run out of
+// attempts, unless --force-retry is set.
+func dropExhaustedDownloads(db *database.DB, downloads []potentialDownload, cfg *models.Config) []potentialDownload {
+	if cfg.Download.ForceRetry {
+		return downloads
+	}
+
+	kept := downloads[:0]
+	for _, pd := range downloads {
+		raw, err := db.Get([]byte(fmt.Sprintf("v_%d", pd.ModelVersionID)))
+		if err == nil {
+			var entry models.DatabaseEntry
+			if json.Unmarshal(raw, &entry) == nil && attemptsExhausted(&entry, cfg) {
+				log.Debugf("Skipping queued version %d: failed %d times", pd.ModelVersionID, entry.AttemptCount)
+				activeSummary.recordFiltered(pd, entry.Status, exhaustedReason(&entry, cfg))
+				continue
+			}
+		}
+		kept = append(kept, pd)
+	}
+	return kept
+}
+
+// reportExhaustedEntries logs how many downloads have been given up on.
+func reportExhaustedEntries(db *database.DB, cfg *models.Config) {
+	count, err := db.CountExhaustedEntries(cfg.Download.MaxAttempts)
+	if err != nil {
+		log.WithError(err).Warn("Failed to count permanently failed downloads")
+		return
+	}
+	if count > 0 {
+		log.Warnf("%d download(s) have failed %d or more times and are no longer retried; pass --force-retry to try them again.", count, cfg.Download.MaxAttempts)
+	}
+}
+
+// markQueueResult records the outcome of a job in the persistent queue.
+// attempted tells whether a download of the file was started.
+func (ctx *WorkerContext) markQueueResult(pd potentialDownload, finalStatus string, attempted bool) {
+	status := database.QueueStatusDone
+	if finalStatus != models.StatusDownloaded {
+		status = database.QueueStatusFailed
+	}
+	if err := ctx.DB.MarkQueueItem(pd.ModelVersionID, pd.File.ID, status, attempted); err != nil {
+		log.WithError(err).Warnf("[%s] Failed to update download queue for file %d of version %d", ctx.LogPrefix, pd.File.ID, pd.ModelVersionID)
+	}
+}
@@ -0,0 +1,304 @@
+package cmd
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadQueue_ResumeContinuesRemainingInOrder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("model-bytes"))
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	queue := []potentialDownload{
+		queueTestDownload(t, db, tmpDir, 303, server.URL+"/ok"),
+		queueTestDownload(t, db, tmpDir, 301, server.URL+"/fail"),
+		queueTestDownload(t, db, tmpDir, 302, server.URL+"/ok"),
+	}
+	require.NoError(t, saveDownloadQueue(db, queue))
+
+	resumed, err := loadDownloadQueue(db)
+	require.NoError(t, err)
+	require.Len(t, resumed, 3)
+	assert.Equal(t, []int{303, 301, 302}, []int{resumed[0].ModelVersionID, resumed[1].ModelVersionID, resumed[2].ModelVersionID})
+	assert.Equal(t, queue[0].TargetFilepath, resumed[0].TargetFilepath)
+
+	// Only the first item runs, as if the process died afterwards.
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.Concurrency = 1
+	fileDownloader := downloader.NewDownloader(&http.Client{}, "", "")
+	require.NoError(t, executeDownloads(resumed[:1], db, fileDownloader, nil, cfg))
+
+	remaining, err := loadDownloadQueue(db)
+	require.NoError(t, err)
+	require.Len(t, remaining, 2)
+	assert.Equal(t, 301, remaining[0].ModelVersionID)
+	assert.Equal(t, 302, remaining[1].ModelVersionID)
+
+	// Failed items stay queued for a retry; finished ones leave the queue.
+	require.NoError(t, executeDownloads(remaining, db, fileDownloader, nil, cfg))
+	remaining, err = loadDownloadQueue(db)
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, 301, remaining[0].ModelVersionID)
+	assert.Equal(t, models.StatusError, entryStatus(t, db, 301))
+	assert.Equal(t, models.StatusDownloaded, entryStatus(t, db, 302))
+}
+
+func TestDownloadQueue_ResumeKeepsEveryFileOfAVersion(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("model-bytes"))
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	// One version with a model file and a VAE
+	model := queueTestDownload(t, db, tmpDir, 501, server.URL+"/fail")
+	vae := model
+	vae.File = models.File{ID: 5011, Name: "vae.safetensors", DownloadUrl: server.URL + "/ok"}
+	vae.TargetFilepath = filepath.Join(tmpDir, vae.File.Name)
+	require.NoError(t, saveDownloadQueue(db, []potentialDownload{model, vae}))
+
+	resumed, err := loadDownloadQueue(db)
+	require.NoError(t, err)
+	require.Len(t, resumed, 2, "every file of the version is queued")
+	assert.Equal(t, 501, resumed[0].File.ID)
+	assert.Equal(t, 5011, resumed[1].File.ID)
+
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.Concurrency = 1
+	fileDownloader := downloader.NewDownloader(&http.Client{}, "", "")
+	require.NoError(t, executeDownloads(resumed, db, fileDownloader, nil, cfg))
+
+	// The VAE is done, the failed model file stays queued
+	remaining, err := loadDownloadQueue(db)
+	require.NoError(t, err)
+	require.Len(t, remaining, 1)
+	assert.Equal(t, 501, remaining[0].File.ID)
+}
+
+func TestDownloadQueue_LaterRunsKeepUnfinishedQueue(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("model-bytes"))
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	// A partial run: the first of three downloads finished
+	queue := []potentialDownload{
+		queueTestDownload(t, db, tmpDir, 601, server.URL+"/ok"),
+		queueTestDownload(t, db, tmpDir, 602, server.URL+"/ok"),
+		queueTestDownload(t, db, tmpDir, 603, server.URL+"/ok"),
+	}
+	require.NoError(t, saveDownloadQueue(db, queue))
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.Concurrency = 1
+	fileDownloader := downloader.NewDownloader(&http.Client{}, "", "")
+	require.NoError(t, executeDownloads(queue[:1], db, fileDownloader, nil, cfg))
+
+	remainingIDs := func() []int {
+		remaining, err := loadDownloadQueue(db)
+		require.NoError(t, err)
+		ids := make([]int, 0, len(remaining))
+		for _, pd := range remaining {
+			ids = append(ids, pd.ModelVersionID)
+		}
+		return ids
+	}
+	require.Equal(t, []int{602, 603}, remainingIDs())
+
+	// A run that finds nothing to download
+	require.NoError(t, confirmAndDownload(nil, nil, db, fileDownloader, nil, cfg))
+	assert.Equal(t, []int{602, 603}, remainingIDs(), "an empty run must not clear the queue")
+
+	// A run the user declines at the prompt
+	other := []potentialDownload{queueTestDownload(t, db, tmpDir, 604, server.URL+"/ok")}
+	stdin, err := os.CreateTemp(tmpDir, "stdin")
+	require.NoError(t, err)
+	_, err = stdin.WriteString("n\n")
+	require.NoError(t, err)
+	_, err = stdin.Seek(0, io.SeekStart)
+	require.NoError(t, err)
+	oldStdin := os.Stdin
+	os.Stdin = stdin
+	defer func() { os.Stdin = oldStdin; _ = stdin.Close() }()
+	require.NoError(t, confirmAndDownload(other, other, db, fileDownloader, nil, cfg))
+	assert.Equal(t, []int{602, 603}, remainingIDs(), "a declined run must not replace the queue")
+	assert.Equal(t, models.StatusPending, entryStatus(t, db, 604))
+
+	// Under --yes a new run leaves the unfinished queue in place
+	require.NoError(t, replaceDownloadQueue(db, other, nil, io.Discard))
+	assert.Equal(t, []int{602, 603}, remainingIDs())
+
+	// Asked first, the user may replace it
+	require.NoError(t, replaceDownloadQueue(db, other, strings.NewReader("y\n"), io.Discard))
+	assert.Equal(t, []int{604}, remainingIDs())
+}
+
+func TestDropExhaustedDownloads(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	queue := []potentialDownload{queueTestDownload(t, db, tmpDir, 401, server.URL+"/gone")}
+
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.Concurrency = 1
+	cfg.Download.MaxAttempts = 2
+	fileDownloader := downloader.NewDownloader(&http.Client{}, "", "")
+
+	require.NoError(t, executeDownloads(queue, db, fileDownloader, nil, cfg))
+	assert.Len(t, dropExhaustedDownloads(db, queue, cfg), 1, "one failure is below MaxAttempts")
+
+	require.NoError(t, executeDownloads(queue, db, fileDownloader, nil, cfg))
+	assert.Empty(t, dropExhaustedDownloads(db, queue, cfg), "entry should be given up on after MaxAttempts failures")
+
+	count, err := db.CountExhaustedEntries(cfg.Download.MaxAttempts)
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	cfg.Download.ForceRetry = true
+	assert.Len(t, dropExhaustedDownloads(db, queue, cfg), 1, "--force-retry keeps exhausted entries")
+}
+
+func TestSortDownloadQueue(t *testing.T) {
+	newQueue := func() []potentialDownload {
+		return []potentialDownload{
+			{ModelVersionID: 1, File: models.File{SizeKB: 300}},
+			{ModelVersionID: 2, File: models.File{SizeKB: 100}},
+			{ModelVersionID: 3, File: models.File{SizeKB: 200}},
+			{ModelVersionID: 4, File: models.File{SizeKB: 100}},
+		}
+	}
+	versionIDs := func(downloads []potentialDownload) []int {
+		ids := make([]int, len(downloads))
+		for i, pd := range downloads {
+			ids[i] = pd.ModelVersionID
+		}
+		return ids
+	}
+
+	tests := map[string][]int{
+		queueOrderNone:     {1, 2, 3, 4},
+		"":                 {1, 2, 3, 4},
+		queueOrderSizeAsc:  {2, 4, 3, 1}, // equal sizes keep API order
+		queueOrderSizeDesc: {1, 3, 2, 4},
+	}
+	for order, want := range tests {
+		queue := newQueue()
+		sortDownloadQueue(queue, order)
+		assert.Equal(t, want, versionIDs(queue), "order %q", order)
+	}
+
+	// --limit truncates after sorting, so smallest-first keeps the most files
+	cfg := &models.Config{}
+	cfg.Download.Limit = 2
+	queue := newQueue()
+	sortDownloadQueue(queue, queueOrderSizeAsc)
+	assert.Equal(t, []int{2, 4}, versionIDs(applyDownloadLimits(queue, cfg)))
+
+	assert.True(t, isValidQueueOrder(queueOrderSizeDesc))
+	assert.False(t, isValidQueueOrder("size"))
+}
+
+func TestApplyMetadataLimit(t *testing.T) {
+	queue := make([]potentialDownload, 10)
+	for i := range queue {
+		queue[i].ModelVersionID = i + 1
+	}
+
+	cfg := &models.Config{}
+	cfg.Download.Limit = 3
+	cfg.Download.MetadataLimit = 7
+	assert.Equal(t, 7, candidateLimit(cfg), "the scan must find enough candidates for the metadata")
+	assert.Len(t, applyMetadataLimit(queue, cfg), 7)
+	assert.Len(t, applyDownloadLimits(queue, cfg), 3)
+
+	// A MetadataLimit below Limit changes nothing
+	cfg.Download.MetadataLimit = 2
+	assert.Equal(t, 3, candidateLimit(cfg))
+	assert.Len(t, applyMetadataLimit(queue, cfg), 3)
+
+	// Without a Limit everything is downloaded anyway
+	cfg.Download.Limit = 0
+	cfg.Download.MetadataLimit = 5
+	assert.Zero(t, candidateLimit(cfg))
+	assert.Len(t, applyMetadataLimit(queue, cfg), 10)
+}
+
+func TestInterleaveByModel(t *testing.T) {
+	queue := []potentialDownload{
+		{ModelID: 1, ModelVersionID: 11},
+		{ModelID: 1, ModelVersionID: 12},
+		{ModelID: 1, ModelVersionID: 13},
+		{ModelID: 2, ModelVersionID: 21},
+		{ModelID: 3, ModelVersionID: 31},
+		{ModelID: 3, ModelVersionID: 32},
+	}
+	var got []int
+	for _, pd := range interleaveByModel(queue) {
+		got = append(got, pd.ModelVersionID)
+	}
+	assert.Equal(t, []int{11, 21, 31, 12, 32, 13}, got)
+}
+
+func TestModelLimiter(t *testing.T) {
+	limiter := newModelLimiter(1)
+	ctx := context.Background()
+	require.True(t, limiter.acquire(ctx, ctx, 1))
+	assert.True(t, limiter.acquire(ctx, ctx, 2), "other models have their own slots")
+
+	// The second download of model 1 waits until the run stops
+	stopCtx, stop := context.WithCancel(ctx)
+	stop()
+	assert.False(t, limiter.acquire(ctx, stopCtx, 1))
+
+	limiter.release(1)
+	assert.True(t, limiter.acquire(ctx, ctx, 1))
+
+	var unlimited *modelLimiter
+	assert.Nil(t, newModelLimiter(0))
+	assert.True(t, unlimited.acquire(ctx, stopCtx, 1))
+	unlimited.release(1)
+}
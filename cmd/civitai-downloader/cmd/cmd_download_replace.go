@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// backupSuffix is appended to the previous copy of a replaced file (BackupOnReplace).
+const backupSuffix = ".bak"
+
+// markFileReplaced records that a version already downloaded has a different file
+// on Civitai now (updated in place, same version ID but a new hash). The worker
+// resets the DB entry once it picks the download up, so the new file is
+// downloaded instead of skipped as already downloaded; until then, e.g. when the
+// download is not confirmed, the entry is left as it is.
+func markFileReplaced(pd *potentialDownload, existing models.DatabaseEntry, cfg *models.Config) {
+	pd.ReplacesFilepath = filepath.Join(cfg.SavePath, existing.Folder, existing.Filename)
+	pd.PreviousHash = existing.File.Hashes.CRC32
+	pd.ResetEntry = true
+	log.Infof("Version %d file changed, hash %s -> %s, re-downloading %s", pd.ModelVersionID, pd.PreviousHash, pd.File.Hashes.CRC32, pd.File.Name)
+}
+
+// resetEntryForDownload resets the DB entry of a download marked ResetEntry (a
+// changed file, or a version queued with --force) to Pending with the freshly
+// fetched metadata, so the worker downloads it again whatever its previous
+// status was.
+func resetEntryForDownload(db *database.DB, dbKey string, pd potentialDownload, cfg *models.Config) error {
+	folder, err := filepath.Rel(cfg.SavePath, filepath.Dir(pd.TargetFilepath))
+	if err != nil {
+		return fmt.Errorf("resolving folder of %s: %w", pd.TargetFilepath, err)
+	}
+	return updateDbEntry(db, dbKey, models.StatusPending, func(entry *models.DatabaseEntry) {
+		entry.ErrorDetails = ""
+		entry.AttemptCount = 0
+		entry.Folder = folder
+		entry.Filename = pd.FinalBaseFilename
+		entry.Version = pd.FullVersion
+		entry.RawJSON = pd.FullVersion.RawJSON
+		entry.File = pd.File
+	})
+}
+
+// countReplacedFiles returns how many queued downloads replace a file that changed on Civitai.
+func countReplacedFiles(downloads []potentialDownload) int {
+	count := 0
+	for _, pd := range downloads {
+		if pd.ReplacesFilepath != "" {
+			count++
+		}
+	}
+	return count
+}
+
+// logReplacedFiles lists the queued downloads that replace a changed file.
+func logReplacedFiles(downloads []potentialDownload) {
+	count := countReplacedFiles(downloads)
+	if count == 0 {
+		return
+	}
+	log.Infof("%d file(s) changed on Civitai since they were downloaded and will be replaced:", count)
+	for _, pd := range downloads {
+		if pd.ReplacesFilepath != "" {
+			log.Infof("  Version %d (%s): %s, hash %s -> %s", pd.ModelVersionID, pd.ModelName, pd.File.Name, pd.PreviousHash, pd.File.Hashes.CRC32)
+		}
+	}
+}
+
+// backupReplacedFile moves the previous copy of a changed file aside to <path>.bak
+// before it is downloaded again. It returns the backup path, or "" if nothing was
+// backed up.
+func backupReplacedFile(pd potentialDownload, cfg *models.Config) (string, error) {
+	if pd.ReplacesFilepath == "" || !cfg.Download.BackupOnReplace {
+		return "", nil
+	}
+	if _, err := os.Stat(pd.ReplacesFilepath); err != nil {
+		log.Debugf("Previous copy %s not found, nothing to back up", pd.ReplacesFilepath)
+		return "", nil
+	}
+
+	backupPath := pd.ReplacesFilepath + backupSuffix
+	if err := os.Rename(pd.ReplacesFilepath, backupPath); err != nil {
+		return "", fmt.Errorf("backing up %s: %w", pd.ReplacesFilepath, err)
+	}
+	log.Infof("Kept previous copy of version %d as %s", pd.ModelVersionID, backupPath)
+	return backupPath, nil
+}
+
+// restoreReplacedFile puts a backup made by backupReplacedFile back in place after
+// the new download failed, so a failed update does not lose the working file.
+func restoreReplacedFile(pd potentialDownload, backupPath string) {
+	if backupPath == "" {
+		return
+	}
+	if err := os.Rename(backupPath, pd.ReplacesFilepath); err != nil {
+		log.WithError(err).Warnf("Failed to restore %s from %s", pd.ReplacesFilepath, backupPath)
+		return
+	}
+	log.Infof("Download of the changed file failed, restored previous copy %s", pd.ReplacesFilepath)
+}
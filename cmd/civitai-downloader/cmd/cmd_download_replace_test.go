@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedFileIsReplacedWithBackup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", "attachment; filename=model.safetensors")
+		_, _ = w.Write([]byte("new-model-bytes"))
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.Concurrency = 1
+	cfg.Download.VersionPathPattern = "{modelType}/{modelName}"
+	cfg.Download.BackupOnReplace = true
+
+	pd := potentialDownload{
+		ModelID:        5,
+		ModelName:      "Model",
+		ModelVersionID: 500,
+		FullModel:      models.Model{ID: 5, Name: "Model", Type: "LORA"},
+		FullVersion:    models.ModelVersion{ID: 500},
+		File:           models.File{ID: 1, Primary: true, Name: "model.safetensors", DownloadUrl: server.URL, Hashes: models.Hashes{CRC32: "F08E2913"}},
+	}
+
+	// The file was downloaded before, when Civitai served a different version of it
+	relPath, filename, err := versionFilePath(pd, cfg)
+	require.NoError(t, err)
+	oldPath := filepath.Join(tmpDir, relPath, filename)
+	require.NoError(t, os.MkdirAll(filepath.Dir(oldPath), 0750))
+	require.NoError(t, os.WriteFile(oldPath, []byte("old-model-bytes"), 0600))
+	oldFile := pd.File
+	oldFile.Hashes.CRC32 = "AAAA"
+	oldVersion := models.ModelVersion{ID: 500, Files: []models.File{oldFile}}
+	entry := models.DatabaseEntry{ModelID: 5, Version: oldVersion, File: oldFile, Filename: filename, Folder: relPath, Status: models.StatusDownloaded}
+	entryBytes, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, db.Put([]byte("v_500"), entryBytes))
+
+	queue, _ := filterAndPrepareDownloads([]potentialDownload{pd}, db, cfg)
+	require.Len(t, queue, 1)
+	assert.Equal(t, oldPath, queue[0].ReplacesFilepath)
+	assert.Equal(t, "AAAA", queue[0].PreviousHash)
+	assert.Equal(t, 1, countReplacedFiles(queue))
+	assert.True(t, queue[0].ResetEntry, "changed file must not be skipped as already downloaded")
+	assert.Equal(t, models.StatusDownloaded, entryStatus(t, db, 500), "the entry is only reset once the download runs")
+
+	fileDownloader := downloader.NewDownloader(&http.Client{}, "", "")
+	require.NoError(t, executeDownloads(queue, db, fileDownloader, nil, cfg))
+
+	assert.Equal(t, models.StatusDownloaded, entryStatus(t, db, 500))
+	backup, err := os.ReadFile(oldPath + backupSuffix)
+	require.NoError(t, err)
+	assert.Equal(t, "old-model-bytes", string(backup))
+	replaced, err := os.ReadFile(oldPath)
+	require.NoError(t, err)
+	assert.Equal(t, "new-model-bytes", string(replaced))
+}
+
+func TestRestoreReplacedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "model.safetensors")
+	require.NoError(t, os.WriteFile(path, []byte("old"), 0600))
+
+	cfg := &models.Config{}
+	pd := potentialDownload{ModelVersionID: 1, ReplacesFilepath: path}
+
+	// Without BackupOnReplace the file is left alone
+	backupPath, err := backupReplacedFile(pd, cfg)
+	require.NoError(t, err)
+	assert.Empty(t, backupPath)
+
+	cfg.Download.BackupOnReplace = true
+	backupPath, err = backupReplacedFile(pd, cfg)
+	require.NoError(t, err)
+	assert.NoFileExists(t, path)
+
+	// A failed download puts the previous copy back
+	restoreReplacedFile(pd, backupPath)
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(content))
+	assert.NoFileExists(t, backupPath)
+}
+
+func TestForceRedownloadsDownloadedEntry(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", "attachment; filename=model.safetensors")
+		_, _ = w.Write([]byte("fresh-model-bytes"))
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.Concurrency = 1
+	cfg.Download.VersionPathPattern = "{modelType}/{modelName}"
+
+	pd := potentialDownload{
+		ModelID:        5,
+		ModelName:      "Model",
+		ModelVersionID: 500,
+		FullModel:      models.Model{ID: 5, Name: "Model", Type: "LORA"},
+		FullVersion:    models.ModelVersion{ID: 500, Name: "v2"},
+		File:           models.File{ID: 1, Primary: true, Name: "model.safetensors", DownloadUrl: server.URL},
+	}
+
+	// Downloaded and on disk, so normally skipped
+	relPath, filename, err := versionFilePath(pd, cfg)
+	require.NoError(t, err)
+	path := filepath.Join(tmpDir, relPath, filename)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+	require.NoError(t, os.WriteFile(path, []byte("suspect-model-bytes"), 0600))
+	oldVersion := models.ModelVersion{ID: 500, Name: "v1", Files: []models.File{pd.File}}
+	entry := models.DatabaseEntry{ModelID: 5, Version: oldVersion, File: pd.File, Filename: filename, Folder: relPath, Status: models.StatusDownloaded}
+	entryBytes, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, db.Put([]byte("v_500"), entryBytes))
+
+	queue, _ := filterAndPrepareDownloads([]potentialDownload{pd}, db, cfg)
+	assert.Empty(t, queue)
+
+	cfg.Download.Force = true
+	queue, _ = filterAndPrepareDownloads([]potentialDownload{pd}, db, cfg)
+	require.Len(t, queue, 1)
+	assert.True(t, queue[0].ResetEntry)
+	assert.Equal(t, models.StatusDownloaded, entryStatus(t, db, 500), "the entry is only reset once the download runs")
+
+	fileDownloader := downloader.NewDownloader(&http.Client{}, "", "")
+	fileDownloader.SetOverwrite(true)
+	require.NoError(t, executeDownloads(queue, db, fileDownloader, nil, cfg))
+
+	assert.Equal(t, models.StatusDownloaded, entryStatus(t, db, 500))
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "fresh-model-bytes", string(content))
+	raw, err := db.Get([]byte("v_500"))
+	require.NoError(t, err)
+	var updated models.DatabaseEntry
+	require.NoError(t, json.Unmarshal(raw, &updated))
+	assert.Equal(t, "v2", updated.Version.Name, "the entry is updated with the fresh metadata")
+}
@@ -26,6 +26,19 @@ var allowedPeriods = map[string]bool{
 	"Day":     true,
 }
 
+// downloadBrowsingLevel returns the browsingLevel to query models with.
+// Without an explicit BrowsingLevel the Nsfw setting is translated: all levels
+// when NSFW is allowed, PG and PG13 otherwise.
+func downloadBrowsingLevel(cfg *models.Config) int {
+	if cfg.Download.BrowsingLevel > 0 {
+		return cfg.Download.BrowsingLevel
+	}
+	if cfg.Download.Nsfw {
+		return models.BrowsingLevelAll
+	}
+	return models.BrowsingLevelSFW
+}
+
 // buildQueryParameters initializes the query parameters based on the final loaded config.
 // It no longer uses Viper.
 func buildQueryParameters(cfg *models.Config) models.QueryParameters {
@@ -47,13 +60,10 @@ func buildQueryParameters(cfg *models.Config) models.QueryParameters {
 		period = "AllTime" // Default period
 	}
 
-	// Handle Username vs Usernames mismatch
-	// Use the first username if the list is not empty, otherwise empty string
+	// The API takes a single username; with several, handlePaginatedSearch
+	// runs the query once per username, starting from the first
 	username := ""
 	if len(cfg.Download.Usernames) > 0 {
-		if len(cfg.Download.Usernames) > 1 {
-			log.Warnf("Multiple usernames found in config Usernames list (%v), but API/flag only supports one. Using the first: '%s'", cfg.Download.Usernames, cfg.Download.Usernames[0])
-		}
 		username = cfg.Download.Usernames[0]
 	}
 
@@ -66,13 +76,14 @@ func buildQueryParameters(cfg *models.Config) models.QueryParameters {
 		Types:           cfg.Download.ModelTypes,
 		Sort:            sort,
 		Period:          period,
-		PrimaryFileOnly: cfg.Download.PrimaryOnly,
+		PrimaryFileOnly: apiPrimaryFileOnly(cfg),
 		// Defaults for fields not typically overridden by user flags/config
 		AllowNoCredit:          true,
 		AllowDerivatives:       true,
 		AllowDifferentLicenses: true,
 		AllowCommercialUse:     "Any",
 		Nsfw:                   cfg.Download.Nsfw,
+		BrowsingLevel:          downloadBrowsingLevel(cfg),
 		BaseModels:             cfg.Download.BaseModels,
 	}
 
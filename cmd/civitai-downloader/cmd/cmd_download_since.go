@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// parseSince turns the --since value into the cutoff time. It takes an RFC3339
+// timestamp, a plain date (2006-01-02, UTC) or an age counted back from now:
+// a number of days or weeks such as 7d or 2w, or a Go duration such as 12h.
+func parseSince(value string, now time.Time) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+
+	var age time.Duration
+	if n, unit := strings.TrimRight(value, "dw"), strings.TrimLeft(value, "0123456789"); len(n) > 0 && (unit == "d" || unit == "w") {
+		count, err := strconv.Atoi(n)
+		if err != nil {
+			return time.Time{}, errors.New("must be an RFC3339 date, a date such as 2024-05-01 or an age such as 7d or 12h")
+		}
+		age = time.Duration(count) * 24 * time.Hour
+		if unit == "w" {
+			age *= 7
+		}
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return time.Time{}, errors.New("must be an RFC3339 date, a date such as 2024-05-01 or an age such as 7d or 12h")
+		}
+		age = d
+	}
+	if age <= 0 {
+		return time.Time{}, errors.New("the age must be positive")
+	}
+	return now.Add(-age), nil
+}
+
+// publishedBeforeSince reports whether version was published before the
+// --since cutoff. Versions without a readable publish date are kept.
+func publishedBeforeSince(version models.ModelVersion, cfg *models.Config) bool {
+	if cfg.Download.SinceTime.IsZero() {
+		return false
+	}
+	published, err := time.Parse(time.RFC3339, version.PublishedAt)
+	if err != nil {
+		log.Debugf("Version %d has no readable publish date (%q), keeping it despite --since", version.ID, version.PublishedAt)
+		return false
+	}
+	return published.Before(cfg.Download.SinceTime)
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := map[string]time.Time{
+		"2024-05-01T08:30:00Z": time.Date(2024, 5, 1, 8, 30, 0, 0, time.UTC),
+		"2024-05-01":           time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC),
+		"7d":                   now.AddDate(0, 0, -7),
+		"2w":                   now.AddDate(0, 0, -14),
+		"12h":                  now.Add(-12 * time.Hour),
+	}
+	for value, want := range tests {
+		got, err := parseSince(value, now)
+		require.NoError(t, err, value)
+		assert.True(t, want.Equal(got), "%s: got %s, want %s", value, got, want)
+	}
+
+	for _, value := range []string{"yesterday", "d", "-3d", "0h", "2024-13-01"} {
+		_, err := parseSince(value, now)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestProcessModelVersionsSince(t *testing.T) {
+	newVersion := func(id int, published string) models.ModelVersion {
+		file := models.File{ID: id * 10, Name: "model.safetensors", Hashes: models.Hashes{CRC32: "abcd"}}
+		file.Metadata.Format = "SafeTensor"
+		return models.ModelVersion{ID: id, PublishedAt: published, Files: []models.File{file}}
+	}
+	model := models.Model{ID: 1, Name: "Model", Type: "LORA", ModelVersions: []models.ModelVersion{
+		newVersion(3, "2024-06-01T00:00:00.000Z"),
+		newVersion(2, "2024-04-01T00:00:00.000Z"),
+		newVersion(1, ""), // No publish date, kept
+	}}
+
+	cfg := &models.Config{}
+	cfg.Download.AllVersions = true
+	cfg.Download.SinceTime = time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	report := &filterReport{}
+
+	got, _ := processModelVersions(model, cfg, 0, 0, report)
+	require.Len(t, got, 2)
+	assert.Equal(t, 3, got[0].ModelVersionID)
+	assert.Equal(t, 1, got[1].ModelVersionID)
+	assert.Equal(t, 1, report.SinceSkipped)
+
+	// A model with only older versions is not reported as filtered by the file filters
+	old := models.Model{ID: 2, Name: "Old", Type: "LORA", ModelVersions: []models.ModelVersion{newVersion(20, "2023-01-01T00:00:00Z")}}
+	got, _ = processModelVersions(old, cfg, 0, 0, report)
+	assert.Empty(t, got)
+	assert.Equal(t, 2, report.SinceSkipped)
+	assert.Empty(t, report.Models)
+}
@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// summaryFile is the outcome of one file in the --summary-json summary.
+type summaryFile struct {
+	VersionID int    `json:"versionId"`
+	FileID    int    `json:"fileId"`
+	Filename  string `json:"filename"`
+	Result    string `json:"result"` // completed, skipped, failed, cancelled (see the progress events) or filtered
+	Status    string `json:"status"` // The status recorded in the database
+	Bytes     uint64 `json:"bytes"`
+	Error     string `json:"error,omitempty"`
+	Reason    string `json:"reason,omitempty"` // Why a filtered file was left out
+}
+
+// summaryResultFiltered is the result of a file left out of the queue by a
+// filter, the blocklist or MaxAttempts.
+const summaryResultFiltered = "filtered"
+
+// runSummary is the document written by --summary-json.
+type runSummary struct {
+	Downloaded int           `json:"downloaded"`
+	Skipped    int           `json:"skipped"`
+	Failed     int           `json:"failed"`
+	Cancelled  int           `json:"cancelled"`
+	Filtered   int           `json:"filtered"`
+	TotalBytes uint64        `json:"totalBytes"`
+	Files      []summaryFile `json:"files"`
+	Error      string        `json:"error,omitempty"` // Why the run stopped early, e.g. --fail-fast
+}
+
+// downloadSummary collects the outcome of every file of a download run for
+// --summary-json. The workers share it, so records are serialised. A nil
+// summary records nothing.
+type downloadSummary struct {
+	mu    sync.Mutex
+	files []summaryFile
+}
+
+// activeSummary is the summary of the download run in progress, from the
+// filtering of the candidates to the last worker. It is nil without
+// --summary-json.
+var activeSummary *downloadSummary
+
+// startDownloadSummary starts the summary of a download run: a collector
+// with --summary-json, nil otherwise.
+func startDownloadSummary(cfg *models.Config) *downloadSummary {
+	activeSummary = nil
+	if cfg.Download.SummaryJSON != "" && !cfg.Download.DryRun {
+		activeSummary = &downloadSummary{}
+	}
+	return activeSummary
+}
+
+// finishDownloadSummary writes the summary of the download run in progress,
+// if any, and ends it. runErr is why the run stopped early.
+func finishDownloadSummary(cfg *models.Config, runErr error) {
+	summary := activeSummary
+	activeSummary = nil
+	if err := summary.write(cfg.Download.SummaryJSON, runErr); err != nil {
+		log.WithError(err).Error("Failed to write --summary-json")
+	}
+}
+
+// record adds the outcome of the file of pd. result is one of the progress
+// events completed, skipped, failed or cancelled.
+func (s *downloadSummary) record(result string, pd potentialDownload, filename string, bytes uint64, status string, err error) {
+	if s == nil {
+		return
+	}
+	file := summaryFile{
+		VersionID: pd.ModelVersionID,
+		FileID:    pd.File.ID,
+		Filename:  filepath.Base(filename),
+		Result:    result,
+		Status:    status,
+		Bytes:     bytes,
+	}
+	if err != nil {
+		file.Error = err.Error()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files = append(s.files, file)
+}
+
+// recordFiltered adds the file of pd as left out of the queue for reason.
+// status is its database status, if it has an entry.
+func (s *downloadSummary) recordFiltered(pd potentialDownload, status, reason string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files = append(s.files, summaryFile{
+		VersionID: pd.ModelVersionID,
+		FileID:    pd.File.ID,
+		Filename:  pd.File.Name,
+		Result:    summaryResultFiltered,
+		Status:    status,
+		Reason:    reason,
+	})
+}
+
+// build returns the summary of the recorded files, in version order.
+func (s *downloadSummary) build(runErr error) runSummary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	summary := runSummary{Files: append([]summaryFile{}, s.files...)}
+	sort.Slice(summary.Files, func(i, j int) bool {
+		if summary.Files[i].VersionID != summary.Files[j].VersionID {
+			return summary.Files[i].VersionID < summary.Files[j].VersionID
+		}
+		return summary.Files[i].FileID < summary.Files[j].FileID
+	})
+	for _, file := range summary.Files {
+		switch file.Result {
+		case progressEventCompleted:
+			summary.Downloaded++
+			summary.TotalBytes += file.Bytes
+		case progressEventSkipped:
+			summary.Skipped++
+		case progressEventFailed:
+			summary.Failed++
+		case progressEventCancelled:
+			summary.Cancelled++
+		case summaryResultFiltered:
+			summary.Filtered++
+		}
+	}
+	if runErr != nil {
+		summary.Error = runErr.Error()
+	}
+	return summary
+}
+
+// write saves the summary as JSON to path, or prints it to stdout when path
+// is "-".
+func (s *downloadSummary) write(path string, runErr error) error {
+	if s == nil {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.build(runErr), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal download summary: %w", err)
+	}
+	data = append(data, '\n')
+	if path == "-" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(helpers.LongPath(path), data, 0600); err != nil {
+		return fmt.Errorf("failed to write download summary %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteDownloads_SummaryJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("model-bytes"))
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	queue := []potentialDownload{
+		queueTestDownload(t, db, tmpDir, 202, server.URL+"/fail"),
+		queueTestDownload(t, db, tmpDir, 201, server.URL+"/ok"),
+	}
+
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.Concurrency = 2
+	cfg.Download.SummaryJSON = filepath.Join(tmpDir, "summary.json")
+
+	fileDownloader := downloader.NewDownloader(&http.Client{}, "", "")
+	require.NoError(t, executeDownloads(queue, db, fileDownloader, nil, cfg))
+
+	raw, err := os.ReadFile(cfg.Download.SummaryJSON)
+	require.NoError(t, err)
+	var summary runSummary
+	require.NoError(t, json.Unmarshal(raw, &summary))
+
+	assert.Equal(t, 1, summary.Downloaded)
+	assert.Equal(t, 1, summary.Failed)
+	assert.Equal(t, 0, summary.Skipped)
+	assert.Equal(t, uint64(len("model-bytes")), summary.TotalBytes)
+	assert.Empty(t, summary.Error)
+
+	require.Len(t, summary.Files, 2)
+	assert.Equal(t, 201, summary.Files[0].VersionID, "files are in version order")
+	assert.Equal(t, progressEventCompleted, summary.Files[0].Result)
+	assert.Equal(t, models.StatusDownloaded, summary.Files[0].Status)
+	assert.Equal(t, "201_model-201.safetensors", summary.Files[0].Filename)
+	assert.Equal(t, 202, summary.Files[1].VersionID)
+	assert.Equal(t, progressEventFailed, summary.Files[1].Result)
+	assert.Equal(t, models.StatusError, summary.Files[1].Status)
+	assert.NotEmpty(t, summary.Files[1].Error)
+
+	// A second run skips the file the first one downloaded
+	require.NoError(t, executeDownloads(queue[1:], db, fileDownloader, nil, cfg))
+
+	raw, err = os.ReadFile(cfg.Download.SummaryJSON)
+	require.NoError(t, err)
+	summary = runSummary{}
+	require.NoError(t, json.Unmarshal(raw, &summary))
+	assert.Equal(t, 1, summary.Skipped)
+	assert.Equal(t, 0, summary.Downloaded)
+	assert.Equal(t, uint64(0), summary.TotalBytes)
+}
+
+func TestDownloadSummary_Nil(t *testing.T) {
+	var summary *downloadSummary
+	assert.NotPanics(t, func() {
+		summary.record(progressEventCompleted, potentialDownload{}, "model.safetensors", 1, models.StatusDownloaded, nil)
+		assert.NoError(t, summary.write("summary.json", nil))
+	})
+	_, err := os.Stat("summary.json")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestDownloadSummary_FilesLeftOutOfTheQueue(t *testing.T) {
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.VersionPathPattern = "{modelType}"
+	cfg.Download.MaxAttempts = 2
+	cfg.Download.SummaryJSON = filepath.Join(tmpDir, "summary.json")
+	putEntry := func(versionID int, status string, attempts int) potentialDownload {
+		file := models.File{ID: versionID, Primary: true, Name: "model.safetensors", Hashes: models.Hashes{CRC32: "AAAA"}}
+		entry := models.DatabaseEntry{ModelID: 5, Version: models.ModelVersion{ID: versionID, Files: []models.File{file}}, File: file, Filename: "model.safetensors", Status: status, AttemptCount: attempts}
+		data, err := json.Marshal(entry)
+		require.NoError(t, err)
+		require.NoError(t, db.Put([]byte(fmt.Sprintf("v_%d", versionID)), data))
+		return potentialDownload{ModelID: 5, ModelVersionID: versionID, FullModel: models.Model{ID: 5, Name: "Model", Type: "LORA"}, FullVersion: entry.Version, File: file}
+	}
+
+	startDownloadSummary(cfg)
+	queue, _ := filterAndPrepareDownloads([]potentialDownload{
+		putEntry(300, models.StatusDownloaded, 0),
+		putEntry(301, models.StatusError, 2),
+	}, db, cfg)
+	assert.Empty(t, queue)
+	recordFilteredFiles(models.ModelVersion{ID: 302, Files: []models.File{{ID: 9, Name: "nohash.safetensors"}}}, nil, "LORA", cfg)
+	// Nothing is left to download, so the prompt is never shown; the summary is still written
+	assert.False(t, confirmDownload(queue, cfg))
+	finishDownloadSummary(cfg, nil)
+
+	raw, err := os.ReadFile(cfg.Download.SummaryJSON)
+	require.NoError(t, err)
+	var summary runSummary
+	require.NoError(t, json.Unmarshal(raw, &summary))
+	assert.Equal(t, 1, summary.Skipped, "an already downloaded file is skipped")
+	assert.Equal(t, 2, summary.Filtered)
+	require.Len(t, summary.Files, 3)
+	assert.Equal(t, progressEventSkipped, summary.Files[0].Result)
+	assert.Equal(t, models.StatusDownloaded, summary.Files[0].Status)
+	assert.Equal(t, summaryResultFiltered, summary.Files[1].Result)
+	assert.Equal(t, "failed 2 times (MaxAttempts 2)", summary.Files[1].Reason)
+	assert.Equal(t, "missing CRC32 hash", summary.Files[2].Reason)
+	assert.Nil(t, activeSummary)
+}
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+	"go-civitai-download/internal/paths"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// trainedWordsFilePath resolves TrainedWordsPathPattern for the file of pd.
+// {trainedWordsFilename} is the model file name with a .txt extension; a
+// pattern without it gets that name appended.
+func trainedWordsFilePath(pd potentialDownload, modelFilePath string, cfg *models.Config) (string, error) {
+	base := filepath.Base(modelFilePath)
+	filename := strings.TrimSuffix(base, filepath.Ext(base)) + ".txt"
+
+	data := buildPathData(&pd.FullModel, &pd.FullVersion, &pd.File, cfg.Download.TypeFolderMap)
+	data[paths.FolderKey(paths.PlaceholderTrainedWordsFilename)] = filename
+
+	relPath, err := paths.GeneratePath(cfg.Download.TrainedWordsPathPattern, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate trained words path: %w", err)
+	}
+	if !strings.Contains(cfg.Download.TrainedWordsPathPattern, "{"+paths.PlaceholderTrainedWordsFilename+"}") {
+		relPath = filepath.Join(relPath, filename)
+	}
+	return filepath.Join(metadataRoot(cfg), relPath), nil
+}
+
+// saveTrainedWordsFile writes the trained words of the version of pd, one per
+// line, to TrainedWordsPathPattern (--trained-words). Versions without trained
+// words are skipped.
+func saveTrainedWordsFile(pd potentialDownload, modelFilePath string, cfg *models.Config) error {
+	var words []string
+	for _, word := range pd.FullVersion.TrainedWords {
+		if word = strings.TrimSpace(word); word != "" {
+			words = append(words, word)
+		}
+	}
+	if len(words) == 0 {
+		log.Debugf("No trained words for %s (VersionID: %d), skipping trained words file", pd.ModelName, pd.ModelVersionID)
+		return nil
+	}
+
+	wordsPath, err := trainedWordsFilePath(pd, modelFilePath, cfg)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to resolve trained words path for %s (VersionID: %d)", pd.ModelName, pd.ModelVersionID)
+		return err
+	}
+	log.Debugf("Attempting to save trained words to: %s", wordsPath)
+
+	if mkErr := os.MkdirAll(helpers.LongPath(filepath.Dir(wordsPath)), 0750); mkErr != nil {
+		log.WithError(mkErr).Errorf("Failed to create directory for trained words file %s", wordsPath)
+		return fmt.Errorf("failed to create directory for trained words file %s: %w", wordsPath, mkErr)
+	}
+
+	content := strings.Join(words, "\n") + "\n"
+	if writeErr := os.WriteFile(helpers.LongPath(wordsPath), []byte(content), 0600); writeErr != nil {
+		log.WithError(writeErr).Errorf("Failed to write trained words file %s", wordsPath)
+		return fmt.Errorf("failed to write trained words file %s: %w", wordsPath, writeErr)
+	}
+
+	log.Debugf("Successfully saved trained words file: %s", wordsPath)
+	return nil
+}
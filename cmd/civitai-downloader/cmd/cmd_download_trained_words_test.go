@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/config"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSaveTrainedWordsFile(t *testing.T) {
+	dir := t.TempDir()
+	pd := potentialDownload{
+		ModelName:      "My LoRA",
+		ModelVersionID: 42,
+		FullModel:      models.Model{ID: 7, Name: "My LoRA", Type: "LORA"},
+		FullVersion: models.ModelVersion{
+			ID:           42,
+			Name:         "v1.0",
+			BaseModel:    "SDXL 1.0",
+			TrainedWords: []string{"mylora", " red hair ", ""},
+		},
+		File: models.File{ID: 1, Name: "model.safetensors"},
+	}
+	cfg := &models.Config{SavePath: dir}
+	cfg.Download.TrainedWordsPathPattern = config.DefaultConfigDownloadTrainedWordsPathPattern
+	modelPath := filepath.Join(dir, "42_model.safetensors")
+
+	require.NoError(t, saveTrainedWordsFile(pd, modelPath, cfg))
+	wordsPath := filepath.Join(dir, "lora", "my_lora", "sdxl_1.0", "42-v1.0", "42_model.txt")
+	content, err := os.ReadFile(wordsPath)
+	require.NoError(t, err)
+	assert.Equal(t, "mylora\nred hair\n", string(content))
+
+	// A pattern without {trainedWordsFilename} gets the file name appended
+	cfg.Download.TrainedWordsPathPattern = "trigger-words/{modelName}"
+	require.NoError(t, saveTrainedWordsFile(pd, modelPath, cfg))
+	assert.FileExists(t, filepath.Join(dir, "trigger-words", "my_lora", "42_model.txt"))
+
+	// No trained words, no file
+	pd.FullVersion.TrainedWords = nil
+	cfg.Download.TrainedWordsPathPattern = "empty/{trainedWordsFilename}"
+	require.NoError(t, saveTrainedWordsFile(pd, modelPath, cfg))
+	assert.NoDirExists(t, filepath.Join(dir, "empty"))
+}
@@ -12,6 +12,8 @@ type potentialDownload struct {
 	BaseModel         string // Base model string (e.g., "SD 1.5")
 	Slug              string // Model name slug
 	VersionName       string // Name of the model version
+	ReplacesFilepath  string // Previously downloaded file this download replaces (file changed on Civitai)
+	PreviousHash      string // CRC32 of the replaced file, for reporting
 	// Slices
 	OriginalImages []models.ModelImage // Images associated with this version
 	// Large structs
@@ -23,6 +25,8 @@ type potentialDownload struct {
 	// Integers
 	ModelID        int // Added: ID of the parent model
 	ModelVersionID int // ID of this specific version
+	// Booleans
+	ResetEntry bool // The worker resets the DB entry before downloading, see resetEntryForDownload
 }
 
 // downloadJob represents a download task passed to workers.
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -8,10 +9,12 @@ import (
 	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go-civitai-download/internal/database"
 	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/helpers"
 	"go-civitai-download/internal/models"
 
 	"github.com/gosuri/uilive"
@@ -84,25 +87,68 @@ func updateDbEntry(db *database.DB, key string, newStatus string, updateFunc fun
 
 // handleMetadataSaving checks config flags and calls the appropriate metadata saving functions.
 // It's called by the worker after a file download has successfully completed.
-func handleMetadataSaving(logPrefix string, pd potentialDownload, finalPath string, finalStatus string, writer *uilive.Writer, cfg *models.Config) {
+// It returns the error of a write that failed because the disk is full.
+func handleMetadataSaving(logPrefix string, pd potentialDownload, finalPath string, finalStatus string, writer *uilive.Writer, cfg *models.Config) (diskFullErr error) {
 	if finalStatus != models.StatusDownloaded {
 		log.Debugf("[%s] Skipping all metadata saving for %s due to download status: %s.", logPrefix, pd.TargetFilepath, finalStatus)
-		return
+		return nil
+	}
+
+	// Sidecars are named after the model file, under MetadataSavePath if set
+	metaPath := metadataFilePath(cfg, finalPath)
+	if metaPath != finalPath && (cfg.Download.SaveMetadata || cfg.Download.SaveCivitaiInfo) {
+		if mkErr := os.MkdirAll(helpers.LongPath(filepath.Dir(metaPath)), 0750); mkErr != nil {
+			log.WithError(mkErr).Errorf("[%s] Failed to create metadata directory %s", logPrefix, filepath.Dir(metaPath))
+			if helpers.IsDiskFull(mkErr) {
+				return mkErr
+			}
+		}
 	}
 
 	// Save Version-Specific Metadata JSON (--metadata)
 	if cfg.Download.SaveMetadata {
 		log.Debugf("[%s] Saving version metadata for successfully downloaded file: %s", logPrefix, finalPath)
-		if metaErr := saveVersionMetadataFile(pd, finalPath); metaErr != nil {
+		if metaErr := saveVersionMetadataFile(pd, metaPath, cfg); metaErr != nil {
 			if writer != nil {
 				_, _ = fmt.Fprintf(writer.Newline(), "[%s] Error saving version metadata for %s: %v\n", logPrefix, filepath.Base(finalPath), metaErr) //nolint:errcheck
 			}
 			// Error is already logged by saveVersionMetadataFile
+			if helpers.IsDiskFull(metaErr) {
+				return metaErr
+			}
 		}
 	} else {
 		log.Debugf("[%s] Skipping version metadata save (disabled by --metadata) for %s.", logPrefix, finalPath)
 	}
 
+	// Save the WebUI Civitai Helper sidecar (--civitai-info)
+	if cfg.Download.SaveCivitaiInfo {
+		log.Debugf("[%s] Saving civitai.info for successfully downloaded file: %s", logPrefix, finalPath)
+		if infoErr := saveCivitaiInfoFile(pd, metaPath, cfg); infoErr != nil {
+			if writer != nil {
+				_, _ = fmt.Fprintf(writer.Newline(), "[%s] Error saving civitai.info for %s: %v\n", logPrefix, filepath.Base(finalPath), infoErr) //nolint:errcheck
+			}
+			// Error is already logged by saveCivitaiInfoFile
+			if helpers.IsDiskFull(infoErr) {
+				return infoErr
+			}
+		}
+	}
+
+	// Save the trained words .txt file (--trained-words)
+	if cfg.Download.SaveTrainedWords {
+		log.Debugf("[%s] Saving trained words for successfully downloaded file: %s", logPrefix, finalPath)
+		if wordsErr := saveTrainedWordsFile(pd, finalPath, cfg); wordsErr != nil {
+			if writer != nil {
+				_, _ = fmt.Fprintf(writer.Newline(), "[%s] Error saving trained words for %s: %v\n", logPrefix, filepath.Base(finalPath), wordsErr) //nolint:errcheck
+			}
+			// Error is already logged by saveTrainedWordsFile
+			if helpers.IsDiskFull(wordsErr) {
+				return wordsErr
+			}
+		}
+	}
+
 	// Save Model Info JSON (--model-info)
 	if cfg.Download.SaveModelInfo {
 		log.Debugf("[%s] Saving model info for successfully downloaded file: %s", logPrefix, finalPath)
@@ -112,10 +158,14 @@ func handleMetadataSaving(logPrefix string, pd potentialDownload, finalPath stri
 				_, _ = fmt.Fprintf(writer.Newline(), "[%s] Error saving model info for %s: %v\n", logPrefix, pd.ModelName, infoErr) //nolint:errcheck
 			}
 			// Error is already logged by saveModelInfoFile
+			if helpers.IsDiskFull(infoErr) {
+				return infoErr
+			}
 		}
 	} else {
 		log.Debugf("[%s] Skipping model info save (disabled by --model-info) for %s.", logPrefix, finalPath)
 	}
+	return nil
 }
 
 // handleModelImages handles the download of all images for a given model if the --model-images flag is set.
@@ -135,13 +185,8 @@ func handleModelImages(logPrefix string, pd potentialDownload, finalPath string,
 		return
 	}
 
-	// Collect all images from all versions
-	var allModelImages []models.ModelImage
-	for _, version := range pd.FullModel.ModelVersions {
-		if len(version.Images) > 0 {
-			allModelImages = append(allModelImages, version.Images...)
-		}
-	}
+	// Collect the images of all versions
+	allModelImages := collectModelImages(pd.FullModel, cfg.Download.DedupeImages)
 
 	if len(allModelImages) == 0 {
 		log.Debugf("%s No model images found across all versions for model %d.", logPrefix, pd.ModelID)
@@ -155,9 +200,8 @@ func handleModelImages(logPrefix string, pd potentialDownload, finalPath string,
 	// The `finalPath` is the absolute path to the downloaded *version file*.
 	// The directory containing this file is the version-specific directory.
 	// The directory containing the version-specific directory is the model's base directory.
-	versionSpecificDir := filepath.Dir(finalPath)
-	modelBaseDir := filepath.Dir(versionSpecificDir)
-	modelImageDir := filepath.Join(modelBaseDir, "images")
+	// With MetadataSavePath set, the same directories under that root are used.
+	modelImageDir := modelImagesDir(cfg, finalPath)
 	imgLogPrefix := fmt.Sprintf("[%s-ModelImg]", logPrefix)
 
 	// Now, `modelImageDir` should be correct, e.g., `/path/to/downloads/lora/sdxl/model-name/images`
@@ -168,7 +212,7 @@ func handleModelImages(logPrefix string, pd potentialDownload, finalPath string,
 	}
 
 	log.Infof("%s Downloading %d model images to %s", imgLogPrefix, len(allModelImages), modelImageDir)
-	imgSuccess, imgFail := downloadImages(imgLogPrefix, allModelImages, modelImageDir, imageDownloader, cfg.Download.Concurrency, cfg.Download.MaxImages)
+	imgSuccess, imgFail := downloadImages(imgLogPrefix, allModelImages, modelImageDir, imageDownloader, cfg.Download.Concurrency, imageLimit(cfg), imageWorkflows(cfg))
 	log.Infof("%s Finished downloading model images. Success: %d, Failures: %d", imgLogPrefix, imgSuccess, imgFail)
 
 	processedModelImagesLock.Lock()
@@ -176,12 +220,79 @@ func handleModelImages(logPrefix string, pd potentialDownload, finalPath string,
 	processedModelImagesLock.Unlock()
 }
 
+// downloadTally counts job outcomes across all workers of a run.
+type downloadTally struct {
+	Downloaded int64
+	Failed     int64
+	LeftQueued int64 // Not started or cancelled, still Pending for --resume
+}
+
+// modelLimiter caps how many downloads of the same model run at once
+// (Download.PerModelConcurrency). A nil limiter does not limit.
+type modelLimiter struct {
+	slots map[int]chan struct{}
+	mu    sync.Mutex
+	limit int
+}
+
+// newModelLimiter returns a limiter allowing limit downloads per model, or nil when limit is 0 or less.
+func newModelLimiter(limit int) *modelLimiter {
+	if limit <= 0 {
+		return nil
+	}
+	return &modelLimiter{slots: make(map[int]chan struct{}), limit: limit}
+}
+
+// acquire waits for a free slot for modelID. It returns false, without a slot,
+// when runCtx or stopCtx is done first.
+func (l *modelLimiter) acquire(runCtx, stopCtx context.Context, modelID int) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	slot, ok := l.slots[modelID]
+	if !ok {
+		slot = make(chan struct{}, l.limit)
+		l.slots[modelID] = slot
+	}
+	l.mu.Unlock()
+
+	select {
+	case slot <- struct{}{}:
+		return true
+	case <-runCtx.Done():
+		return false
+	case <-stopCtx.Done():
+		return false
+	}
+}
+
+// release frees a slot taken by acquire.
+func (l *modelLimiter) release(modelID int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	slot := l.slots[modelID]
+	l.mu.Unlock()
+	<-slot
+}
+
 // WorkerContext holds the context for a download worker
 type WorkerContext struct {
+	RunCtx          context.Context // Shared across workers; cancelled by --fail-fast
+	StopCtx         context.Context // Done at the --max-runtime deadline; no new jobs start after it
+	Abort           func(error)     // Records the first failure and cancels RunCtx (nil when --fail-fast is off)
+	Tally           *downloadTally
+	ModelSlots      *modelLimiter    // Per-model concurrency cap (nil = no cap)
+	DiskFull        *diskFullGate    // Pauses or aborts the run once the disk is full
+	AutoSlots       *autoConcurrency // --concurrency auto limit (nil = every worker downloads)
 	DB              *database.DB
 	FileDownloader  *downloader.Downloader
 	ImageDownloader *downloader.Downloader
 	Writer          *uilive.Writer
+	Progress        *progressReporter // --progress-json events (nil = off)
+	Summary         *downloadSummary  // --summary-json outcomes (nil = off)
 	Config          *models.Config
 	LogPrefix       string
 	ID              int
@@ -238,38 +349,97 @@ func (ctx *WorkerContext) ensureDirectory(directoryPath, dbKey string, errGet er
 func (ctx *WorkerContext) performFileDownload(pd potentialDownload, dbKey string, initialStatus string, targetPath string) (string, string, error) {
 	if initialStatus == models.StatusDownloaded {
 		log.Infof("[%s] Initial status is '%s', skipping main file download.", ctx.LogPrefix, initialStatus)
+		ctx.Progress.report(progressEventSkipped, pd, targetPath, downloadedFileSize(targetPath, pd.File.SizeKB), initialStatus, nil)
 		return targetPath, initialStatus, nil
 	}
 
 	log.Infof("[%s] Status is '%s', proceeding with download check/process.", ctx.LogPrefix, initialStatus)
+	if ctx.Config.Download.ContentAddressed {
+		linkPath := ctx.FileDownloader.FinalPath(pd.TargetFilepath, pd.File.Name, pd.ModelVersionID)
+		if object, ok := linkExistingObject(pd, linkPath, ctx.Config); ok {
+			log.Infof("[%s] %s is already stored as %s, linked it instead of downloading", ctx.LogPrefix, filepath.Base(linkPath), filepath.Base(object))
+			_, _ = fmt.Fprintf(ctx.Writer.Newline(), "[%s] Linked %s to the stored copy\n", ctx.LogPrefix, filepath.Base(linkPath)) //nolint:errcheck
+			ctx.Progress.report(progressEventCompleted, pd, linkPath, downloadedFileSize(linkPath, pd.File.SizeKB), models.StatusDownloaded, nil)
+			return linkPath, models.StatusDownloaded, nil
+		}
+	}
 	startTime := time.Now()
 	_, _ = fmt.Fprintf(ctx.Writer.Newline(), "Worker %d: Checking/Downloading %s...\n", ctx.ID, filepath.Base(pd.TargetFilepath)) //nolint:errcheck
 
-	actualFinalPath, downloadErr := ctx.FileDownloader.DownloadFile(pd.TargetFilepath, pd.File.DownloadUrl, pd.File.Hashes, pd.ModelVersionID)
+	ctx.Progress.report(progressEventStarted, pd, pd.TargetFilepath, 0, models.StatusPending, nil)
+	var received atomic.Uint64
+	stopWatch := ctx.Progress.watch(pd, pd.TargetFilepath, &received)
+	actualFinalPath, downloadErr := ctx.FileDownloader.DownloadFileWithContext(downloader.WithFileProgress(ctx.RunCtx, &received), pd.TargetFilepath, pd.File.DownloadUrl, pd.File.Hashes, pd.ModelVersionID)
+	stopWatch()
 
 	var finalStatus string
 	if downloadErr != nil {
 		finalStatus = models.StatusError
+		if earlyAccessDenied(pd, downloadErr) {
+			log.Warnf("[%s] %s was refused while version %d is in early access, recording it as %s", ctx.LogPrefix, filepath.Base(pd.TargetFilepath), pd.ModelVersionID, models.StatusEarlyAccess)
+			finalStatus = models.StatusEarlyAccess
+		}
+		if ctx.RunCtx.Err() == nil { // A cancelled run is reported by processJob
+			ctx.Progress.report(progressEventFailed, pd, pd.TargetFilepath, 0, finalStatus, downloadErr)
+		}
 	} else {
 		finalStatus = models.StatusDownloaded
 		duration := time.Since(startTime)
-		log.Infof("[%s] Successfully downloaded %s in %v", ctx.LogPrefix, actualFinalPath, duration)
-		_, _ = fmt.Fprintf(ctx.Writer.Newline(), "[%s] Success downloading %s\n", ctx.LogPrefix, filepath.Base(actualFinalPath)) //nolint:errcheck
+		size := downloadedFileSize(actualFinalPath, pd.File.SizeKB)
+		rate := helpers.TransferRate(size, duration)
+		log.Infof("[%s] Successfully downloaded %s (%s) in %v (%.2f MB/s)", ctx.LogPrefix, actualFinalPath, helpers.BytesToSize(size), duration.Round(time.Millisecond), rate)
+		_, _ = fmt.Fprintf(ctx.Writer.Newline(), "[%s] Success downloading %s (%s, %.2f MB/s)\n", ctx.LogPrefix, filepath.Base(actualFinalPath), helpers.BytesToSize(size), rate) //nolint:errcheck
+		ctx.Progress.report(progressEventCompleted, pd, actualFinalPath, size, finalStatus, nil)
 	}
 
 	return actualFinalPath, finalStatus, downloadErr
 }
 
+// downloadedFileSize returns the on-disk size of a completed download, falling
+// back to the size reported by the API if the file cannot be stat'ed.
+func downloadedFileSize(path string, sizeKB float64) uint64 {
+	if info, err := os.Stat(path); err == nil {
+		return uint64(info.Size()) // #nosec G115 -- file sizes are never negative
+	}
+	return uint64(sizeKB * 1024)
+}
+
+// storeDownloadedObject moves a finished download into the object store under
+// Download.ContentAddressed and returns the object path relative to SavePath.
+// If that fails the file stays where it is and "" is returned.
+func (ctx *WorkerContext) storeDownloadedObject(pd potentialDownload, finalPath string) string {
+	if !ctx.Config.Download.ContentAddressed {
+		return ""
+	}
+	object, err := storeObject(finalPath, pd.File.Hashes.SHA256, ctx.Config)
+	if err != nil {
+		log.WithError(err).Warnf("[%s] Failed to store %s in the object store, keeping it in place", ctx.LogPrefix, finalPath)
+		return ""
+	}
+	log.Debugf("[%s] %s stored as %s", ctx.LogPrefix, finalPath, object)
+	return relObjectPath(object, ctx.Config)
+}
+
 // updateDatabaseAfterDownload updates the database entry after download attempt
-func (ctx *WorkerContext) updateDatabaseAfterDownload(dbKey string, pd potentialDownload, finalPath, finalStatus string, downloadErr error) error {
+func (ctx *WorkerContext) updateDatabaseAfterDownload(dbKey string, pd potentialDownload, finalPath, objectPath, finalStatus string, downloadErr error) error {
 	updateErr := updateDbEntry(ctx.DB, dbKey, finalStatus, func(entry *models.DatabaseEntry) {
-		if downloadErr != nil {
+		if finalStatus == models.StatusEarlyAccess {
+			// Not a failure of the file; it is tried again once early access ends
+			entry.ErrorDetails = downloadErr.Error()
+		} else if downloadErr != nil {
 			entry.ErrorDetails = downloadErr.Error()
+			entry.AttemptCount++
+			if attemptsExhausted(entry, ctx.Config) {
+				log.Warnf("[%s] %s has failed %d times, giving up on it (MaxAttempts=%d)", ctx.LogPrefix, dbKey, entry.AttemptCount, ctx.Config.Download.MaxAttempts)
+			}
 		} else {
 			entry.ErrorDetails = ""
+			entry.AttemptCount = 0
 			entry.Filename = filepath.Base(finalPath)
 			entry.File = pd.File
 			entry.Version = pd.FullVersion
+			entry.RawJSON = pd.FullVersion.RawJSON
+			entry.ObjectPath = objectPath
 
 			actualFileDir := filepath.Dir(finalPath)
 			folderRelToSavePath, err := filepath.Rel(ctx.Config.SavePath, actualFileDir)
@@ -308,7 +478,7 @@ func (ctx *WorkerContext) handleVersionImages(pd potentialDownload, finalPath, f
 		return
 	}
 
-	versionOutputDir := filepath.Dir(finalPath)
+	versionOutputDir := filepath.Dir(metadataFilePath(ctx.Config, finalPath))
 	imageSubDir := filepath.Join(versionOutputDir, "images")
 
 	if err := os.MkdirAll(imageSubDir, 0750); err != nil {
@@ -317,18 +487,77 @@ func (ctx *WorkerContext) handleVersionImages(pd potentialDownload, finalPath, f
 	}
 
 	log.Infof("%s Downloading %d version images for %s to %s", imgLogPrefix, len(pd.OriginalImages), filepath.Base(finalPath), imageSubDir)
-	imgSuccess, imgFail := downloadImages(imgLogPrefix, pd.OriginalImages, imageSubDir, ctx.ImageDownloader, ctx.Config.Download.Concurrency, ctx.Config.Download.MaxImages)
+	imgSuccess, imgFail := downloadImages(imgLogPrefix, pd.OriginalImages, imageSubDir, ctx.ImageDownloader, ctx.Config.Download.Concurrency, imageLimit(ctx.Config), imageWorkflows(ctx.Config))
 	log.Infof("%s Finished downloading version images. Success: %d, Failures: %d", imgLogPrefix, imgSuccess, imgFail)
 }
 
+// abortRun triggers the --fail-fast abort, if enabled.
+func (ctx *WorkerContext) abortRun(err error) {
+	if ctx.Abort == nil {
+		return
+	}
+	log.Errorf("[%s] Aborting run due to --fail-fast: %v", ctx.LogPrefix, err)
+	_, _ = fmt.Fprintf(ctx.Writer.Newline(), "[%s] Failure with --fail-fast set, cancelling remaining downloads\n", ctx.LogPrefix) //nolint:errcheck
+	ctx.Abort(err)
+}
+
+// markCancelled resets an in-flight job back to Pending so the next run picks it up.
+func (ctx *WorkerContext) markCancelled(dbKey string) {
+	log.Infof("[%s] Download for %s cancelled, leaving it %s", ctx.LogPrefix, dbKey, models.StatusPending)
+	if err := updateDbEntry(ctx.DB, dbKey, models.StatusPending, func(entry *models.DatabaseEntry) {
+		entry.ErrorDetails = ""
+	}); err != nil {
+		log.WithError(err).Warnf("[%s] Failed to reset cancelled job %s to %s", ctx.LogPrefix, dbKey, models.StatusPending)
+	}
+}
+
+// recordSummary adds the outcome of a finished job to the --summary-json
+// summary.
+func (ctx *WorkerContext) recordSummary(pd potentialDownload, initialStatus, finalPath, finalStatus string, downloadErr error) {
+	if ctx.Summary == nil {
+		return
+	}
+	switch {
+	case initialStatus == models.StatusDownloaded:
+		ctx.Summary.record(progressEventSkipped, pd, finalPath, downloadedFileSize(finalPath, pd.File.SizeKB), finalStatus, nil)
+	case finalStatus == models.StatusDownloaded:
+		ctx.Summary.record(progressEventCompleted, pd, finalPath, downloadedFileSize(finalPath, pd.File.SizeKB), finalStatus, nil)
+	default:
+		ctx.Summary.record(progressEventFailed, pd, finalPath, 0, finalStatus, downloadErr)
+	}
+}
+
 // processJob processes a single download job
 func (ctx *WorkerContext) processJob(job downloadJob) {
 	pd := job.PotentialDownload
 	dbKey := job.DatabaseKey
 
+	ctx.DiskFull.waitIfPaused()
+	acquired := ctx.ModelSlots.acquire(ctx.RunCtx, ctx.StopCtx, pd.ModelID)
+	if acquired {
+		defer ctx.ModelSlots.release(pd.ModelID)
+		acquired = ctx.AutoSlots.acquire(ctx.RunCtx, ctx.StopCtx)
+		if acquired {
+			defer ctx.AutoSlots.release()
+		}
+	}
+	if !acquired || ctx.RunCtx.Err() != nil || ctx.StopCtx.Err() != nil {
+		log.Infof("[%s] Run stopped, leaving %s as %s (DB Key: %s)", ctx.LogPrefix, filepath.Base(pd.TargetFilepath), models.StatusPending, dbKey)
+		ctx.Summary.record(progressEventCancelled, pd, pd.TargetFilepath, 0, models.StatusPending, nil)
+		atomic.AddInt64(&ctx.Tally.LeftQueued, 1)
+		ctx.ProcessedCount++
+		return
+	}
+
 	log.Infof("[%s] Processing job for %s (DB Key: %s)", ctx.LogPrefix, pd.TargetFilepath, dbKey)
 	_, _ = fmt.Fprintf(ctx.Writer, "[%s] Preparing %s... (%d/%d)\n", ctx.LogPrefix, filepath.Base(pd.TargetFilepath), ctx.ProcessedCount+1, ctx.TotalJobs) //nolint:errcheck
 
+	if pd.ResetEntry {
+		if err := resetEntryForDownload(ctx.DB, dbKey, pd, ctx.Config); err != nil {
+			log.WithError(err).Warnf("[%s] Failed to reset DB entry %s for the new download", ctx.LogPrefix, dbKey)
+		}
+	}
+
 	// Check initial database status
 	initialDbStatus, finalPath, errGet := ctx.checkInitialDBStatus(dbKey, pd.TargetFilepath)
 
@@ -336,25 +565,72 @@ func (ctx *WorkerContext) processJob(job downloadJob) {
 	directoryPath := filepath.Dir(pd.TargetFilepath)
 	if err := ctx.ensureDirectory(directoryPath, dbKey, errGet); err != nil {
 		ctx.ProcessedCount++
+		atomic.AddInt64(&ctx.Tally.Failed, 1)
+		ctx.Summary.record(progressEventFailed, pd, pd.TargetFilepath, 0, models.StatusError, err)
+		ctx.markQueueResult(pd, models.StatusError, false)
+		ctx.abortRun(fmt.Errorf("creating directory for %s: %w", dbKey, err))
 		return
 	}
 
+	// Keep the previous copy of a file that changed on Civitai (BackupOnReplace)
+	var backupPath string
+	if initialDbStatus != models.StatusDownloaded {
+		var backupErr error
+		if backupPath, backupErr = backupReplacedFile(pd, ctx.Config); backupErr != nil {
+			log.WithError(backupErr).Warnf("[%s] Failed to back up previous copy, it will be overwritten", ctx.LogPrefix)
+		}
+	}
+
 	// Perform file download
 	actualFinalPath, finalStatus, downloadErr := ctx.performFileDownload(pd, dbKey, initialDbStatus, finalPath)
+	for helpers.IsDiskFull(downloadErr) && ctx.DiskFull.wait(downloadErr, time.Now()) {
+		log.Infof("[%s] Retrying %s after the disk was full", ctx.LogPrefix, dbKey)
+		actualFinalPath, finalStatus, downloadErr = ctx.performFileDownload(pd, dbKey, initialDbStatus, finalPath)
+	}
 	if downloadErr == nil {
 		finalPath = actualFinalPath
+	} else {
+		restoreReplacedFile(pd, backupPath)
+		if ctx.RunCtx.Err() != nil {
+			// Cancelled by another worker's failure or --max-runtime, not a failure of this file.
+			ctx.markCancelled(dbKey)
+			ctx.Progress.report(progressEventCancelled, pd, pd.TargetFilepath, 0, models.StatusPending, nil)
+			ctx.Summary.record(progressEventCancelled, pd, pd.TargetFilepath, 0, models.StatusPending, nil)
+			atomic.AddInt64(&ctx.Tally.LeftQueued, 1)
+			ctx.ProcessedCount++
+			return
+		}
 	}
 
 	// Update database if download was attempted
 	if initialDbStatus != models.StatusDownloaded {
-		if updateErr := ctx.updateDatabaseAfterDownload(dbKey, pd, finalPath, finalStatus, downloadErr); updateErr != nil {
+		var objectPath string
+		if downloadErr == nil {
+			objectPath = ctx.storeDownloadedObject(pd, finalPath)
+		}
+		if updateErr := ctx.updateDatabaseAfterDownload(dbKey, pd, finalPath, objectPath, finalStatus, downloadErr); updateErr != nil {
 			log.WithError(updateErr).Errorf("[%s] Failed to update database after download", ctx.LogPrefix)
 		}
 	}
 
+	ctx.markQueueResult(pd, finalStatus, initialDbStatus != models.StatusDownloaded)
+	if finalStatus == models.StatusDownloaded {
+		atomic.AddInt64(&ctx.Tally.Downloaded, 1)
+	} else {
+		atomic.AddInt64(&ctx.Tally.Failed, 1)
+	}
+	ctx.recordSummary(pd, initialDbStatus, finalPath, finalStatus, downloadErr)
+
+	if finalStatus == models.StatusError {
+		ctx.abortRun(fmt.Errorf("downloading %s: %w", dbKey, downloadErr))
+	}
+
 	// Handle post-download operations
-	handleMetadataSaving(ctx.LogPrefix, pd, finalPath, finalStatus, ctx.Writer, ctx.Config)
+	if err := handleMetadataSaving(ctx.LogPrefix, pd, finalPath, finalStatus, ctx.Writer, ctx.Config); err != nil && ctx.DiskFull.wait(err, time.Now()) {
+		_ = handleMetadataSaving(ctx.LogPrefix, pd, finalPath, finalStatus, ctx.Writer, ctx.Config)
+	}
 	ctx.handleVersionImages(pd, finalPath, finalStatus)
+	ctx.handlePreview(pd, finalPath, finalStatus)
 
 	if finalStatus == models.StatusDownloaded {
 		handleModelImages(ctx.LogPrefix, pd, finalPath, ctx.ImageDownloader, ctx.Config)
@@ -365,10 +641,17 @@ func (ctx *WorkerContext) processJob(job downloadJob) {
 }
 
 // downloadWorker handles the actual download of files and updates the database.
-func downloadWorker(id int, jobs <-chan downloadJob, db *database.DB, fileDownloader *downloader.Downloader, imageDownloader *downloader.Downloader, wg *sync.WaitGroup, writer *uilive.Writer, totalJobs int, cfg *models.Config) {
+func downloadWorker(runCtx, stopCtx context.Context, abort func(error), tally *downloadTally, modelSlots *modelLimiter, diskFull *diskFullGate, autoSlots *autoConcurrency, id int, jobs <-chan downloadJob, db *database.DB, fileDownloader *downloader.Downloader, imageDownloader *downloader.Downloader, wg *sync.WaitGroup, writer *uilive.Writer, progress *progressReporter, summary *downloadSummary, totalJobs int, cfg *models.Config) {
 	defer wg.Done()
 
 	ctx := &WorkerContext{
+		RunCtx:          runCtx,
+		StopCtx:         stopCtx,
+		Abort:           abort,
+		Tally:           tally,
+		ModelSlots:      modelSlots,
+		DiskFull:        diskFull,
+		AutoSlots:       autoSlots,
 		ID:              id,
 		LogPrefix:       fmt.Sprintf("Worker-%d", id),
 		ProcessedCount:  0,
@@ -377,6 +660,8 @@ func downloadWorker(id int, jobs <-chan downloadJob, db *database.DB, fileDownlo
 		FileDownloader:  fileDownloader,
 		ImageDownloader: imageDownloader,
 		Writer:          writer,
+		Progress:        progress,
+		Summary:         summary,
 		Config:          cfg,
 	}
 
@@ -391,21 +676,21 @@ func downloadWorker(id int, jobs <-chan downloadJob, db *database.DB, fileDownlo
 
 // saveVersionMetadataFile saves the full model version metadata to a .json file.
 // It derives the filename from the model file path.
-func saveVersionMetadataFile(pd potentialDownload, modelFilePath string) error {
+func saveVersionMetadataFile(pd potentialDownload, modelFilePath string, cfg *models.Config) error {
 	// Derive metadata path from the model file path
 	metadataPath := strings.TrimSuffix(modelFilePath, filepath.Ext(modelFilePath)) + ".json"
 	log.Debugf("Attempting to save metadata to: %s", metadataPath)
 
 	// Marshal the FULL version info from the potential download struct
 	// Use the FullVersion field which should hold the necessary data
-	jsonData, jsonErr := json.MarshalIndent(pd.FullVersion, "", "  ")
+	jsonData, jsonErr := helpers.MarshalMetadata(pd.FullVersion, cfg.JSONCompact)
 	if jsonErr != nil {
 		log.WithError(jsonErr).Errorf("Failed to marshal full version metadata for %s (VersionID: %d)", pd.ModelName, pd.ModelVersionID)
 		return fmt.Errorf("failed to marshal metadata: %w", jsonErr)
 	}
 
 	// Write the file
-	if writeErr := os.WriteFile(metadataPath, jsonData, 0600); writeErr != nil {
+	if writeErr := os.WriteFile(helpers.LongPath(metadataPath), jsonData, 0600); writeErr != nil {
 		log.WithError(writeErr).Errorf("Failed to write version metadata file %s", metadataPath)
 		return fmt.Errorf("failed to write metadata file %s: %w", metadataPath, writeErr)
 	}
@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// queueTestDownload creates a Pending DB entry and returns the matching potentialDownload.
+func queueTestDownload(t *testing.T, db *database.DB, dir string, versionID int, url string) potentialDownload {
+	t.Helper()
+	file := models.File{ID: versionID, Name: fmt.Sprintf("model-%d.safetensors", versionID), DownloadUrl: url}
+	entry := models.DatabaseEntry{
+		ModelID:  1,
+		Version:  models.ModelVersion{ID: versionID},
+		File:     file,
+		Filename: file.Name,
+		Status:   models.StatusPending,
+	}
+	data, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, db.Put([]byte(fmt.Sprintf("v_%d", versionID)), data))
+
+	return potentialDownload{
+		ModelID:           1,
+		ModelVersionID:    versionID,
+		File:              file,
+		FullVersion:       entry.Version,
+		TargetFilepath:    filepath.Join(dir, file.Name),
+		FinalBaseFilename: file.Name,
+	}
+}
+
+// chdirTemp switches into a fresh temp dir for the duration of the test.
+// Download paths go through helpers.SanitizePath, which makes absolute paths
+// relative, so the tests work with paths relative to the temp dir.
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+	require.NoError(t, os.Chdir(t.TempDir()))
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	return "."
+}
+
+func entryStatus(t *testing.T, db *database.DB, versionID int) string {
+	t.Helper()
+	raw, err := db.Get([]byte(fmt.Sprintf("v_%d", versionID)))
+	require.NoError(t, err)
+	var entry models.DatabaseEntry
+	require.NoError(t, json.Unmarshal(raw, &entry))
+	return entry.Status
+}
+
+func TestExecuteDownloads_FailFast(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/fail":
+			// Give the slow download time to start before failing.
+			time.Sleep(100 * time.Millisecond)
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/slow":
+			w.Header().Set("Content-Length", "1048576")
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+			case <-time.After(10 * time.Second):
+			}
+		}
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	queue := []potentialDownload{
+		queueTestDownload(t, db, tmpDir, 101, server.URL+"/fail"),
+		queueTestDownload(t, db, tmpDir, 102, server.URL+"/slow"),
+		queueTestDownload(t, db, tmpDir, 103, server.URL+"/slow"),
+	}
+
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.Concurrency = 2
+	cfg.Download.FailFast = true
+
+	fileDownloader := downloader.NewDownloader(&http.Client{}, "", "")
+
+	start := time.Now()
+	err = executeDownloads(queue, db, fileDownloader, nil, cfg)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "--fail-fast")
+	assert.Less(t, time.Since(start), 5*time.Second, "in-flight download should have been cancelled")
+
+	assert.Equal(t, models.StatusError, entryStatus(t, db, 101))
+	assert.Equal(t, models.StatusPending, entryStatus(t, db, 102))
+	assert.Equal(t, models.StatusPending, entryStatus(t, db, 103))
+}
+
+func TestExecuteDownloads_BestEffortByDefault(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte("model-bytes"))
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	queue := []potentialDownload{
+		queueTestDownload(t, db, tmpDir, 201, server.URL+"/fail"),
+		queueTestDownload(t, db, tmpDir, 202, server.URL+"/ok"),
+	}
+
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.Concurrency = 1
+
+	fileDownloader := downloader.NewDownloader(&http.Client{}, "", "")
+	require.NoError(t, executeDownloads(queue, db, fileDownloader, nil, cfg))
+
+	assert.Equal(t, models.StatusError, entryStatus(t, db, 201))
+	assert.Equal(t, models.StatusDownloaded, entryStatus(t, db, 202))
+}
+
+func TestExecuteDownloads_MaxRuntime(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Content-Length", "1048576")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-time.After(10 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	fileDownloader := downloader.NewDownloader(&http.Client{}, "", "")
+
+	// Deadline already passed: nothing is started
+	cfg := &models.Config{SavePath: tmpDir}
+	cfg.Download.Concurrency = 1
+	cfg.Download.Deadline = time.Now().Add(-time.Second)
+	queue := []potentialDownload{queueTestDownload(t, db, tmpDir, 301, server.URL+"/a")}
+	require.NoError(t, executeDownloads(queue, db, fileDownloader, nil, cfg))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&requests))
+	assert.Equal(t, models.StatusPending, entryStatus(t, db, 301))
+
+	// MaxRuntimeCancel stops the in-flight download at the deadline
+	cfg.Download.Deadline = time.Now().Add(200 * time.Millisecond)
+	cfg.Download.MaxRuntimeCancel = true
+	queue = []potentialDownload{
+		queueTestDownload(t, db, tmpDir, 302, server.URL+"/b"),
+		queueTestDownload(t, db, tmpDir, 303, server.URL+"/c"),
+	}
+	start := time.Now()
+	require.NoError(t, executeDownloads(queue, db, fileDownloader, nil, cfg))
+	assert.Less(t, time.Since(start), 5*time.Second, "in-flight download should have been cancelled")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests), "no new download should start after the deadline")
+	assert.Equal(t, models.StatusPending, entryStatus(t, db, 302))
+	assert.Equal(t, models.StatusPending, entryStatus(t, db, 303))
+}
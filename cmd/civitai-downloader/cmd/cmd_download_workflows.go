@@ -0,0 +1,209 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// workflowFileType is the file type Civitai uses for workflow attachments on a version.
+const workflowFileType = "Workflow"
+
+// workflowsDirName is the folder (inside the version folder) workflow attachments are saved to.
+const workflowsDirName = "workflows"
+
+// workflowSidecarSuffix replaces an image's extension for its extracted workflow.
+const workflowSidecarSuffix = ".workflow.json"
+
+// imageWorkflowSettings controls workflow extraction for downloaded images.
+type imageWorkflowSettings struct {
+	Save    bool
+	Compact bool
+}
+
+// imageWorkflows returns the workflow extraction settings for image downloads.
+func imageWorkflows(cfg *models.Config) imageWorkflowSettings {
+	return imageWorkflowSettings{Save: cfg.Download.SaveWorkflows, Compact: cfg.JSONCompact}
+}
+
+// isWorkflowFile reports whether a version file is a workflow attachment.
+func isWorkflowFile(file models.File) bool {
+	return strings.EqualFold(file.Type, workflowFileType)
+}
+
+// workflowRelPath moves workflow attachments into a workflows/ subfolder of
+// the version folder when SaveWorkflows is set; other files keep relPath.
+func workflowRelPath(relPath string, file models.File, cfg *models.Config) string {
+	if cfg.Download.SaveWorkflows && isWorkflowFile(file) {
+		return filepath.Join(relPath, workflowsDirName)
+	}
+	return relPath
+}
+
+// saveImageWorkflow writes the ComfyUI workflow of a downloaded image to a
+// .workflow.json sidecar. The image's API metadata is checked first, then the
+// metadata embedded in the PNG or WebP file. Images without one are skipped.
+func saveImageWorkflow(imagePath string, meta interface{}, compact bool) {
+	workflow := workflowFromMeta(meta)
+	if workflow == nil {
+		workflow = workflowFromImageFile(imagePath)
+	}
+	if workflow == nil {
+		return
+	}
+
+	var parsed interface{}
+	if err := json.Unmarshal(workflow, &parsed); err != nil {
+		log.WithError(err).Debugf("Ignoring unreadable workflow in %s", imagePath)
+		return
+	}
+	data, err := helpers.MarshalMetadata(parsed, compact)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to encode workflow for %s", imagePath)
+		return
+	}
+
+	sidecar := strings.TrimSuffix(imagePath, filepath.Ext(imagePath)) + workflowSidecarSuffix
+	if err := os.WriteFile(helpers.LongPath(sidecar), data, 0600); err != nil {
+		log.WithError(err).Warnf("Failed to write workflow file %s", sidecar)
+		return
+	}
+	log.Debugf("Saved workflow to %s", sidecar)
+}
+
+// workflowFromMeta returns the ComfyUI workflow from an image's API metadata.
+// Civitai keeps it under "comfy", usually as a JSON string holding both the
+// "prompt" (API graph) and the "workflow" (UI graph); the workflow is preferred.
+func workflowFromMeta(meta interface{}) []byte {
+	fields, ok := meta.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	var comfy []byte
+	switch v := fields["comfy"].(type) {
+	case string:
+		comfy = []byte(v)
+	case map[string]interface{}:
+		comfy, _ = json.Marshal(v)
+	}
+	if len(comfy) == 0 || !json.Valid(comfy) {
+		return nil
+	}
+
+	var parts struct {
+		Workflow json.RawMessage `json:"workflow"`
+	}
+	if err := json.Unmarshal(comfy, &parts); err == nil && len(parts.Workflow) > 0 && string(parts.Workflow) != "null" {
+		return parts.Workflow
+	}
+	return comfy
+}
+
+// workflowFromImageFile reads a workflow embedded by ComfyUI in a PNG or WebP file.
+func workflowFromImageFile(path string) []byte {
+	data, err := os.ReadFile(helpers.LongPath(path)) // #nosec G304 -- path is an image this run just downloaded
+	if err != nil {
+		return nil
+	}
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")):
+		return workflowFromPNG(data)
+	case len(data) >= 12 && string(data[:4]) == "RIFF" && string(data[8:12]) == "WEBP":
+		return workflowFromWebP(data)
+	}
+	return nil
+}
+
+// workflowFromPNG returns the "workflow" (or, failing that, "prompt") text
+// chunk ComfyUI writes into PNG files.
+func workflowFromPNG(data []byte) []byte {
+	texts := map[string][]byte{}
+	for pos := 8; pos+12 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos : pos+4]))
+		chunkType := string(data[pos+4 : pos+8])
+		end := pos + 8 + length
+		if length < 0 || end+4 > len(data) {
+			break
+		}
+		chunk := data[pos+8 : end]
+		pos = end + 4 // Skip the CRC
+
+		switch chunkType {
+		case "tEXt":
+			if i := bytes.IndexByte(chunk, 0); i > 0 {
+				texts[string(chunk[:i])] = chunk[i+1:]
+			}
+		case "iTXt":
+			// keyword\0 compressionFlag compressionMethod languageTag\0 translatedKeyword\0 text
+			i := bytes.IndexByte(chunk, 0)
+			if i <= 0 || i+3 > len(chunk) || chunk[i+1] != 0 {
+				continue // Compressed text is not used by ComfyUI
+			}
+			rest := chunk[i+3:]
+			for n := 0; n < 2; n++ {
+				j := bytes.IndexByte(rest, 0)
+				if j < 0 {
+					rest = nil
+					break
+				}
+				rest = rest[j+1:]
+			}
+			if rest != nil {
+				texts[string(chunk[:i])] = rest
+			}
+		case "IEND":
+			pos = len(data)
+		}
+	}
+
+	for _, key := range []string{"workflow", "prompt"} {
+		if text := texts[key]; json.Valid(text) {
+			return text
+		}
+	}
+	return nil
+}
+
+// workflowFromWebP returns the workflow ComfyUI stores in the EXIF chunk of
+// WebP files, as a "workflow:{...}" (or "prompt:{...}") string value.
+func workflowFromWebP(data []byte) []byte {
+	for pos := 12; pos+8 <= len(data); {
+		chunkType := string(data[pos : pos+4])
+		length := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		end := pos + 8 + length
+		if length < 0 || end > len(data) {
+			break
+		}
+		if chunkType == "EXIF" {
+			return workflowFromExif(data[pos+8 : end])
+		}
+		pos = end + length%2 // Chunks are padded to an even size
+	}
+	return nil
+}
+
+func workflowFromExif(exif []byte) []byte {
+	for _, prefix := range []string{"workflow:", "prompt:"} {
+		i := bytes.Index(exif, []byte(prefix))
+		if i < 0 {
+			continue
+		}
+		value := exif[i+len(prefix):]
+		if j := bytes.IndexByte(value, 0); j >= 0 {
+			value = value[:j]
+		}
+		if json.Valid(value) {
+			return value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// buildPNG returns a minimal PNG byte stream with the given tEXt chunks. CRCs
+// are left zero since the parser does not check them.
+func buildPNG(texts map[string]string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	writeChunk := func(chunkType string, data []byte) {
+		_ = binary.Write(&buf, binary.BigEndian, uint32(len(data)))
+		buf.WriteString(chunkType)
+		buf.Write(data)
+		buf.Write([]byte{0, 0, 0, 0})
+	}
+	writeChunk("IHDR", make([]byte, 13))
+	for key, value := range texts {
+		writeChunk("tEXt", append([]byte(key+"\x00"), value...))
+	}
+	writeChunk("IEND", nil)
+	return buf.Bytes()
+}
+
+func TestWorkflowFromPNG(t *testing.T) {
+	data := buildPNG(map[string]string{
+		"prompt":   `{"3":{"class_type":"KSampler"}}`,
+		"workflow": `{"nodes":[]}`,
+	})
+	assert.JSONEq(t, `{"nodes":[]}`, string(workflowFromPNG(data)))
+
+	promptOnly := buildPNG(map[string]string{"prompt": `{"3":{"class_type":"KSampler"}}`})
+	assert.JSONEq(t, `{"3":{"class_type":"KSampler"}}`, string(workflowFromPNG(promptOnly)))
+
+	assert.Nil(t, workflowFromPNG(buildPNG(map[string]string{"parameters": "a cat, Steps: 20"})))
+}
+
+func TestWorkflowFromWebP(t *testing.T) {
+	exif := []byte("II*\x00junkworkflow:{\"nodes\":[1]}\x00more")
+	var buf bytes.Buffer
+	buf.WriteString("RIFF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(0))
+	buf.WriteString("WEBP")
+	buf.WriteString("VP8 ")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(3))
+	buf.Write([]byte{1, 2, 3, 0}) // Odd size, padded
+	buf.WriteString("EXIF")
+	_ = binary.Write(&buf, binary.LittleEndian, uint32(len(exif)))
+	buf.Write(exif)
+
+	assert.JSONEq(t, `{"nodes":[1]}`, string(workflowFromWebP(buf.Bytes())))
+}
+
+func TestWorkflowFromMeta(t *testing.T) {
+	meta := map[string]interface{}{
+		"prompt": "a cat",
+		"comfy":  `{"prompt":{"3":{}},"workflow":{"nodes":[2]}}`,
+	}
+	assert.JSONEq(t, `{"nodes":[2]}`, string(workflowFromMeta(meta)))
+
+	assert.Nil(t, workflowFromMeta(map[string]interface{}{"prompt": "a cat"}))
+	assert.Nil(t, workflowFromMeta(nil))
+}
+
+func TestSaveImageWorkflow(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "123.png")
+	require.NoError(t, os.WriteFile(imagePath, buildPNG(map[string]string{"workflow": `{"nodes":[]}`}), 0600))
+
+	saveImageWorkflow(imagePath, nil, true)
+	data, err := os.ReadFile(filepath.Join(dir, "123.workflow.json"))
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"nodes":[]}`, string(data))
+
+	// No workflow: nothing is written
+	plainPath := filepath.Join(dir, "456.jpeg")
+	require.NoError(t, os.WriteFile(plainPath, []byte("not a png"), 0600))
+	saveImageWorkflow(plainPath, map[string]interface{}{"prompt": "a cat"}, true)
+	assert.NoFileExists(t, filepath.Join(dir, "456.workflow.json"))
+}
+
+func TestWorkflowAttachments(t *testing.T) {
+	cfg := &models.Config{}
+	cfg.Download.AllowedFormats = []string{"safetensor"}
+	workflow := models.File{Name: "workflow.json", Type: "Workflow", Hashes: models.Hashes{CRC32: "ABCD"}}
+	modelFile := models.File{Name: "model.safetensors", Type: "Model"}
+
+	assert.Equal(t, "lora/foo", workflowRelPath("lora/foo", workflow, cfg))
+	assert.False(t, passesFileFilters(workflow, "LORA", cfg), "workflows are only downloaded with SaveWorkflows")
+
+	cfg.Download.SaveWorkflows = true
+	assert.Equal(t, filepath.Join("lora/foo", "workflows"), workflowRelPath("lora/foo", workflow, cfg))
+	assert.Equal(t, "lora/foo", workflowRelPath("lora/foo", modelFile, cfg))
+	assert.True(t, passesFileFilters(workflow, "LORA", cfg))
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// imageQueryHash identifies an images query in pagination_state. The cursor
+// and page size do not change which images the query returns, so they are
+// left out.
+func imageQueryHash(params models.ImageAPIParameters) string {
+	params.Cursor = ""
+	params.Limit = 0
+	data, _ := json.Marshal(params)
+	sum := sha256.Sum256(data)
+	return "images_" + hex.EncodeToString(sum[:8])
+}
+
+// imageCursorState keeps the cursor of the next page of an images query in
+// the database, so an interrupted run can continue with --resume. A nil state
+// saves nothing.
+type imageCursorState struct {
+	db   *database.DB
+	hash string
+}
+
+// openImageCursorState opens the database to keep the cursor of the query of
+// params in. It returns nil, saving nothing, when there is no database.
+func openImageCursorState(cfg *models.Config, params models.ImageAPIParameters) *imageCursorState {
+	if cfg.DatabasePath == "" {
+		return nil
+	}
+	db, err := database.Open(cfg.DatabasePath)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to open database at %s; the images cursor will not be saved for --resume", cfg.DatabasePath)
+		return nil
+	}
+	return &imageCursorState{db: db, hash: imageQueryHash(params)}
+}
+
+// saved returns the cursor saved by an earlier run of the query and how many
+// pages that run fetched, or "" if there is none.
+func (s *imageCursorState) saved() (string, int) {
+	if s == nil {
+		return "", 0
+	}
+	cursor, pages, err := s.db.GetPageCursor(s.hash)
+	if err != nil {
+		log.WithError(err).Warn("Failed to read the saved images cursor")
+		return "", 0
+	}
+	return cursor, pages
+}
+
+// save records cursor as the next page to fetch after pages pages.
+func (s *imageCursorState) save(cursor string, pages int) {
+	if s == nil {
+		return
+	}
+	if err := s.db.SetPageCursor(s.hash, cursor, pages); err != nil {
+		log.WithError(err).Warn("Failed to save the images cursor")
+	}
+}
+
+// clear forgets the saved cursor once the query has been fetched to the end.
+func (s *imageCursorState) clear() {
+	if s == nil {
+		return
+	}
+	if err := s.db.DeletePageState(s.hash); err != nil {
+		log.WithError(err).Warn("Failed to clear the saved images cursor")
+	}
+}
+
+func (s *imageCursorState) close() {
+	if s == nil {
+		return
+	}
+	_ = s.db.Close()
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/api"
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageQueryHash(t *testing.T) {
+	params := models.ImageAPIParameters{Username: "alice", Sort: "Newest", Limit: 100}
+	other := params
+	other.Cursor = "123|456"
+	other.Limit = 200
+	assert.Equal(t, imageQueryHash(params), imageQueryHash(other), "cursor and page size do not change the query")
+
+	other.Username = "bob"
+	assert.NotEqual(t, imageQueryHash(params), imageQueryHash(other))
+}
+
+func TestFetchImageListResumesFromSavedCursor(t *testing.T) {
+	// Three pages of one image each, linked by cursors c2 and c3
+	next := map[string]string{"": "c2", "c2": "c3", "c3": ""}
+	ids := map[string]int{"": 1, "c2": 2, "c3": 3}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cursor := r.URL.Query().Get("cursor")
+		_, _ = fmt.Fprintf(w, `{"items": [{"id": %d}], "metadata": {"nextCursor": %q}}`, ids[cursor], next[cursor])
+	}))
+	defer server.Close()
+
+	db, err := database.Open(filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	cfg := &models.Config{APIBaseURL: server.URL}
+	cfg.Images.Username = "alice"
+	apiClient := api.NewClient("", server.Client(), *cfg)
+	state := &imageCursorState{db: db, hash: imageQueryHash(CreateImageQueryParams(cfg))}
+
+	// Stopped by --max-pages, the cursor of the next page is kept
+	images, err := fetchImageList(cfg, apiClient, 0, 1, "", state)
+	require.NoError(t, err)
+	require.Len(t, images, 1)
+	assert.Equal(t, 1, images[0].ID)
+	cursor, pages := state.saved()
+	assert.Equal(t, "c2", cursor)
+	assert.Equal(t, 1, pages)
+
+	// --resume picks it up and the end of the results clears it
+	start := resolveImageStartCursor("", true, state)
+	require.Equal(t, "c2", start)
+	images, err = fetchImageList(cfg, apiClient, 0, 0, start, state)
+	require.NoError(t, err)
+	require.Len(t, images, 2)
+	assert.Equal(t, 2, images[0].ID)
+	assert.Equal(t, 3, images[1].ID)
+	cursor, _ = state.saved()
+	assert.Empty(t, cursor)
+
+	// --start-cursor wins over the saved cursor
+	state.save("c2", 1)
+	assert.Equal(t, "c3", resolveImageStartCursor("c3", true, state))
+	assert.Empty(t, resolveImageStartCursor("", false, state))
+	assert.Empty(t, resolveImageStartCursor("", true, nil))
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go-civitai-download/internal/helpers"
+)
+
+// generationParamKeys maps the meta keys of the images API to the parameter
+// names of the AUTOMATIC1111 WebUI "parameters" text, in the order the WebUI
+// writes them. The API uses both spellings for some keys.
+var generationParamKeys = []struct {
+	metaKeys []string
+	name     string
+}{
+	{[]string{"steps"}, "Steps"},
+	{[]string{"sampler"}, "Sampler"},
+	{[]string{"cfgScale"}, "CFG scale"},
+	{[]string{"seed"}, "Seed"},
+	{[]string{"Size", "size"}, "Size"},
+	{[]string{"Model hash"}, "Model hash"},
+	{[]string{"Model"}, "Model"},
+	{[]string{"Denoising strength", "denoise"}, "Denoising strength"},
+	{[]string{"clipSkip", "Clip skip"}, "Clip skip"},
+	{[]string{"Hires upscale"}, "Hires upscale"},
+	{[]string{"Hires upscaler"}, "Hires upscaler"},
+	{[]string{"Hires steps"}, "Hires steps"},
+	{[]string{"VAE"}, "VAE"},
+}
+
+// imageGenerationText formats the generation metadata of an image (the meta
+// object of the images API) like the "parameters" text the WebUI embeds in
+// PNGs: the prompt, a "Negative prompt:" line and a line of comma separated
+// parameters. It returns "" when meta holds no prompt or parameters.
+func imageGenerationText(meta interface{}) string {
+	m, ok := meta.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	var lines []string
+	if prompt := metaString(m["prompt"]); prompt != "" {
+		lines = append(lines, prompt)
+	}
+	if negative := metaString(m["negativePrompt"]); negative != "" {
+		lines = append(lines, "Negative prompt: "+negative)
+	}
+
+	var params []string
+	for _, key := range generationParamKeys {
+		for _, metaKey := range key.metaKeys {
+			if value := metaString(m[metaKey]); value != "" {
+				if strings.ContainsAny(value, ",:") {
+					value = strconv.Quote(value)
+				}
+				params = append(params, key.name+": "+value)
+				break
+			}
+		}
+	}
+	if len(params) > 0 {
+		lines = append(lines, strings.Join(params, ", "))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// metaString returns a string or number meta value as text, without a
+// fraction for whole numbers such as seeds. Other values give "".
+func metaString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
+
+// saveImageGenerationText writes the generation metadata of an image next to
+// it as <image name>.txt (see imageGenerationText). Images without generation
+// metadata get no file.
+func saveImageGenerationText(imagePath string, meta interface{}) (string, error) {
+	text := imageGenerationText(meta)
+	if text == "" {
+		return "", nil
+	}
+	textPath := strings.TrimSuffix(imagePath, filepath.Ext(imagePath)) + ".txt"
+	if err := os.WriteFile(helpers.LongPath(textPath), []byte(text), 0600); err != nil {
+		return "", fmt.Errorf("failed to write generation parameters %s: %w", textPath, err)
+	}
+	return textPath, nil
+}
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageGenerationText(t *testing.T) {
+	meta := map[string]interface{}{
+		"prompt":         "a castle on a hill, sunset",
+		"negativePrompt": "blurry",
+		"steps":          30.0,
+		"sampler":        "DPM++ 2M Karras",
+		"cfgScale":       7.5,
+		"seed":           3141592653.0,
+		"Size":           "832x1216",
+		"Model hash":     "31e35c80fc",
+		"Model":          "sd_xl_base_1.0",
+		"clipSkip":       2.0,
+		"resources":      []interface{}{map[string]interface{}{"name": "ignored"}},
+	}
+	assert.Equal(t, "a castle on a hill, sunset\n"+
+		"Negative prompt: blurry\n"+
+		"Steps: 30, Sampler: DPM++ 2M Karras, CFG scale: 7.5, Seed: 3141592653, Size: 832x1216, Model hash: 31e35c80fc, Model: sd_xl_base_1.0, Clip skip: 2\n",
+		imageGenerationText(meta))
+
+	// Values with separators are quoted like the WebUI does
+	assert.Equal(t, "Sampler: \"Euler a, custom\"\n", imageGenerationText(map[string]interface{}{"sampler": "Euler a, custom"}))
+
+	assert.Empty(t, imageGenerationText(nil))
+	assert.Empty(t, imageGenerationText(map[string]interface{}{"resources": []interface{}{}}))
+}
+
+func TestSaveImageGenerationText(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "12345.png")
+
+	textPath, err := saveImageGenerationText(imagePath, map[string]interface{}{"prompt": "a cat"})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "12345.txt"), textPath)
+	content, err := os.ReadFile(textPath)
+	require.NoError(t, err)
+	assert.Equal(t, "a cat\n", string(content))
+
+	// No generation metadata, no file
+	textPath, err = saveImageGenerationText(filepath.Join(dir, "67890.png"), nil)
+	require.NoError(t, err)
+	assert.Empty(t, textPath)
+	assert.NoFileExists(t, filepath.Join(dir, "67890.txt"))
+}
@@ -31,6 +31,10 @@ func runImages(cmd *cobra.Command, args []string) {
 	numWorkers := cfg.Images.Concurrency
 	maxPages := cfg.Images.MaxPages
 
+	if _, _, err := models.ParseImageNsfwRange(cfg.Images.NsfwMin, cfg.Images.NsfwMax); err != nil {
+		log.Fatalf("Invalid --nsfw-min/--nsfw-max: %v", err)
+	}
+
 	handleDebugAPIURL(cmd, &cfg)
 
 	confirmConfiguration(&cfg)
@@ -55,9 +59,15 @@ func runImages(cmd *cobra.Command, args []string) {
 
 	// Pre-fetch ModelID if only ModelVersionID is provided
 	prefetchedModelID := resolveModelID(&cfg, apiClient)
+	modelCtx := resolveImageModelContext(&cfg, apiClient, prefetchedModelID)
+
+	// Keep the cursor of the query in the database so an interrupted run can resume
+	cursorState := openImageCursorState(&cfg, CreateImageQueryParams(&cfg))
+	defer cursorState.close()
+	startCursor := resolveImageStartCursor(imagesStartCursorFlag, imagesResumeFlag, cursorState)
 
 	// Fetch image list from API
-	allImages, loopErr := fetchImageList(&cfg, apiClient, userTotalLimit, maxPages)
+	allImages, loopErr := fetchImageList(&cfg, apiClient, userTotalLimit, maxPages, startCursor, cursorState)
 
 	if loopErr != nil {
 		log.WithError(loopErr).Error("Image fetching stopped due to an error.")
@@ -76,7 +86,7 @@ func runImages(cmd *cobra.Command, args []string) {
 	log.Infof("Found %d total images to potentially download.", len(allImages))
 
 	// Download images using worker pool
-	downloadAllImages(&cfg, allImages, targetDir, saveMeta, numWorkers, prefetchedModelID, apiClient)
+	downloadAllImages(&cfg, allImages, targetDir, saveMeta, numWorkers, prefetchedModelID, apiClient, modelCtx)
 }
 
 // resolveModelID pre-fetches the parent model ID if only ModelVersionID is provided.
@@ -93,18 +103,76 @@ func resolveModelID(cfg *models.Config, apiClient *api.Client) int {
 	return versionDetails.ModelId
 }
 
+// resolveImageModelContext fetches the model the query is scoped to when
+// images are grouped by model. It returns nil, keeping the PathPattern
+// layout, when grouping is off or there is no model to group by.
+func resolveImageModelContext(cfg *models.Config, apiClient *api.Client, modelID int) *imageModelContext {
+	if !cfg.Images.GroupByModel {
+		return nil
+	}
+	if modelID == 0 {
+		log.Warn("--group-images-by-model needs --model-id or --model-version-id; saving images by PathPattern only.")
+		return nil
+	}
+	model, err := apiClient.GetModelDetails(modelID)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to get details for model %d; saving images by PathPattern only.", modelID)
+		return nil
+	}
+
+	modelCtx := &imageModelContext{
+		VersionNames: make(map[int]string, len(model.ModelVersions)),
+		ModelName:    model.Name,
+		ModelID:      modelID,
+		VersionID:    cfg.Images.ModelVersionID,
+	}
+	for _, version := range model.ModelVersions {
+		modelCtx.VersionNames[version.ID] = version.Name
+	}
+	log.Infof("Grouping images under model '%s' using pattern '%s'.", model.Name, cfg.Images.SubfolderPattern)
+	return modelCtx
+}
+
+// resolveImageStartCursor picks the cursor to start the images feed at:
+// --start-cursor first, then with --resume the cursor saved by the last run of
+// the query. It returns "" to start from --page.
+func resolveImageStartCursor(startCursor string, resume bool, cursorState *imageCursorState) string {
+	if startCursor != "" {
+		log.Infof("Starting the images feed at cursor %s (--start-cursor).", startCursor)
+		return startCursor
+	}
+	if !resume {
+		return ""
+	}
+	if cursorState == nil {
+		log.Warn("--resume needs a database to read the saved cursor from; starting from the beginning.")
+		return ""
+	}
+	cursor, pages := cursorState.saved()
+	if cursor == "" {
+		log.Info("No saved cursor for this query; starting from the beginning.")
+		return ""
+	}
+	log.Infof("Resuming the images feed after %d page(s) fetched by an earlier run.", pages)
+	return cursor
+}
+
 // fetchImageList handles cursor-advance and main API fetching to collect all images.
-func fetchImageList(cfg *models.Config, apiClient *api.Client, userTotalLimit int, maxPages int) ([]models.ImageApiItem, error) {
+// It starts at startCursor when set and keeps the cursor of the next page in
+// cursorState, clearing it once the end of the results is reached.
+func fetchImageList(cfg *models.Config, apiClient *api.Client, userTotalLimit int, maxPages int, startCursor string, cursorState *imageCursorState) ([]models.ImageApiItem, error) {
 	log.Info("Fetching image list from Civitai API...")
 	initialApiParams := CreateImageQueryParams(cfg)
 
 	pageCount := 0
-	var nextCursor string
-
-	// Cursor-advance for Page > 1
-	nextCursor, loopErr := advanceCursorToPage(cfg, apiClient, initialApiParams, maxPages, &pageCount)
-	if loopErr != nil {
-		return nil, loopErr
+	nextCursor := startCursor
+	if nextCursor == "" {
+		// Cursor-advance for Page > 1
+		var loopErr error
+		nextCursor, loopErr = advanceCursorToPage(cfg, apiClient, initialApiParams, maxPages, &pageCount)
+		if loopErr != nil {
+			return nil, loopErr
+		}
 	}
 
 	// Main fetching loop
@@ -113,6 +181,9 @@ func fetchImageList(cfg *models.Config, apiClient *api.Client, userTotalLimit in
 		pageCount++
 		if maxPages > 0 && pageCount > maxPages {
 			log.Infof("Reached max pages limit (%d). Stopping.", maxPages)
+			if nextCursor != "" {
+				log.Infof("Continue with --resume or --start-cursor %s", nextCursor)
+			}
 			break
 		}
 
@@ -128,13 +199,20 @@ func fetchImageList(cfg *models.Config, apiClient *api.Client, userTotalLimit in
 
 		if len(response.Items) == 0 {
 			log.Info("Received empty items list from API. Assuming end of results.")
+			cursorState.clear()
 			break
 		}
-		allImages = append(allImages, response.Items...)
+		allImages = append(allImages, filterImagesByNsfwRange(cfg, response.Items)...)
 		log.Infof("Received %d images from API page %d. Total collected so far: %d", len(response.Items), pageCount, len(allImages))
 
 		if userTotalLimit > 0 && len(allImages) >= userTotalLimit {
 			log.Infof("Reached total image limit (%d). Stopping image fetching.", userTotalLimit)
+			if len(allImages) > userTotalLimit {
+				// Part of this page is left, so a resumed run fetches it again
+				cursorState.save(currentApiParams.Cursor, pageCount-1)
+			} else if cursor := response.Metadata.NextCursor.String(); cursor != "" {
+				cursorState.save(cursor, pageCount)
+			}
 			allImages = allImages[:userTotalLimit]
 			break
 		}
@@ -142,9 +220,11 @@ func fetchImageList(cfg *models.Config, apiClient *api.Client, userTotalLimit in
 		nextCursor = response.Metadata.NextCursor.String()
 		if nextCursor == "" {
 			log.Info("No next cursor found. Finished fetching all available images for the query.")
+			cursorState.clear()
 			break
 		}
 		log.Debugf("Next cursor for images API: %s", nextCursor)
+		cursorState.save(nextCursor, pageCount)
 
 		if cfg.APIDelayMs > 0 {
 			log.Debugf("Applying API delay: %d ms", cfg.APIDelayMs)
@@ -166,7 +246,7 @@ func advanceCursorToPage(cfg *models.Config, apiClient *api.Client, initialApiPa
 		cfg.Images.Page = 50
 	}
 	if cfg.Images.Page > 10 {
-		log.Warnf("Page %d may trigger rate limiting due to %d cursor-advance API calls.", cfg.Images.Page, cfg.Images.Page-1)
+		log.Warnf("Page %d may trigger rate limiting due to %d cursor-advance API calls. Use --start-cursor or --resume to continue deep in the feed instead.", cfg.Images.Page, cfg.Images.Page-1)
 	}
 
 	skipCount := cfg.Images.Page - 1
@@ -213,13 +293,14 @@ func advanceCursorToPage(cfg *models.Config, apiClient *api.Client, initialApiPa
 }
 
 // downloadAllImages sets up worker pool and downloads all collected images.
-func downloadAllImages(cfg *models.Config, allImages []models.ImageApiItem, targetDir string, saveMeta bool, numWorkers int, prefetchedModelID int, apiClient *api.Client) {
+func downloadAllImages(cfg *models.Config, allImages []models.ImageApiItem, targetDir string, saveMeta bool, numWorkers int, prefetchedModelID int, apiClient *api.Client, modelCtx *imageModelContext) {
 	downloadHttpClient := &http.Client{
 		Transport: globalHttpTransport,
 		Timeout:   0,
 	}
 	dl := downloader.NewDownloader(downloadHttpClient, cfg.APIKey, cfg.SessionCookie)
 	dl.SetDetectImageMimeType(cfg.Images.DetectImageMimeType)
+	dl.SetUserAgent(cfg.UserAgent)
 
 	finalBaseTargetDir := targetDir
 	log.Infof("Preparing to download images to base directory: %s", finalBaseTargetDir)
@@ -239,7 +320,7 @@ func downloadAllImages(cfg *models.Config, allImages []models.ImageApiItem, targ
 	log.Infof("Starting %d image download workers...", numWorkers)
 	for i := 1; i <= numWorkers; i++ {
 		wg.Add(1)
-		go imageDownloadWorker(i, jobs, dl, &wg, writer, &successCount, &failureCount, saveMeta, finalBaseTargetDir, apiClient, cfg)
+		go imageDownloadWorker(i, jobs, dl, &wg, writer, &successCount, &failureCount, saveMeta, finalBaseTargetDir, apiClient, cfg, modelCtx)
 	}
 
 	log.Infof("Queueing %d image download jobs...", len(allImages))
@@ -301,18 +382,63 @@ func CreateImageQueryParams(cfg *models.Config) models.ImageAPIParameters {
 		BrowsingLevel:  cfg.Images.BrowsingLevel,
 	}
 
+	// --nsfw-min/--nsfw-max select their levels with the browsing level, which
+	// the API prefers over nsfw. An explicit --browsing-level still wins.
+	if lo, hi, ok := imageNsfwRange(cfg); ok {
+		if params.BrowsingLevel > 0 {
+			log.Warnf("Both --browsing-level and --nsfw-min/--nsfw-max are set; using browsing level %d for the API and the range only to filter the results", params.BrowsingLevel)
+		} else {
+			params.BrowsingLevel = models.NsfwRangeBrowsingLevel(lo, hi)
+		}
+	}
+
 	log.Debugf("Created Image API Params: ImageID=%d, ModelID=%d, ModelVersionID=%d, PostID=%d, Username='%s', Limit=%d, Sort='%s', Period='%s', Nsfw='%s', BrowsingLevel=%d",
 		params.ImageID, params.ModelID, params.ModelVersionID, params.PostID, params.Username, params.Limit, params.Sort, params.Period, params.Nsfw, params.BrowsingLevel)
 	return params
 }
 
+// imageNsfwRange returns the ranks of the --nsfw-min/--nsfw-max range, and
+// false when neither is set or the range is invalid.
+func imageNsfwRange(cfg *models.Config) (int, int, bool) {
+	if cfg.Images.NsfwMin == "" && cfg.Images.NsfwMax == "" {
+		return 0, 0, false
+	}
+	lo, hi, err := models.ParseImageNsfwRange(cfg.Images.NsfwMin, cfg.Images.NsfwMax)
+	if err != nil {
+		return 0, 0, false
+	}
+	return lo, hi, true
+}
+
+// filterImagesByNsfwRange drops the images whose nsfwLevel is outside the
+// --nsfw-min/--nsfw-max range, in case the API returns levels the browsing
+// level did not ask for. Images without a recognised level are kept.
+func filterImagesByNsfwRange(cfg *models.Config, items []models.ImageApiItem) []models.ImageApiItem {
+	lo, hi, ok := imageNsfwRange(cfg)
+	if !ok {
+		return items
+	}
+	kept := make([]models.ImageApiItem, 0, len(items))
+	for _, item := range items {
+		if rank, known := models.ImageNsfwRank(item.NsfwLevel); known && (rank < lo || rank > hi) {
+			log.Debugf("Skipping image %d: NSFW level %v is outside %s-%s", item.ID, item.NsfwLevel, models.ImageNsfwLevels[lo], models.ImageNsfwLevels[hi])
+			continue
+		}
+		kept = append(kept, item)
+	}
+	if dropped := len(items) - len(kept); dropped > 0 {
+		log.Infof("Skipped %d image(s) outside the NSFW level range %s-%s", dropped, models.ImageNsfwLevels[lo], models.ImageNsfwLevels[hi])
+	}
+	return kept
+}
+
 // handleDebugAPIURL handles the debug API URL flag
 func handleDebugAPIURL(cmd *cobra.Command, cfg *models.Config) {
 	if printUrlFlag, _ := cmd.Flags().GetBool("debug-print-api-url"); printUrlFlag {
 		log.Info("--- Debug API URL (--debug-print-api-url) for Images ---")
 		tempApiParams := CreateImageQueryParams(cfg)
 		tempUrlValues := api.ConvertImageAPIParamsToURLValues(tempApiParams)
-		requestURL := fmt.Sprintf("%s/images?%s", api.CivitaiApiBaseUrl, tempUrlValues.Encode())
+		requestURL := fmt.Sprintf("%s/images?%s", api.BaseURL(*cfg), tempUrlValues.Encode())
 		fmt.Println(requestURL)
 		log.Info("Exiting after printing images API URL.")
 		os.Exit(0)
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"testing"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateImageQueryParamsNsfwRange(t *testing.T) {
+	cfg := &models.Config{}
+	cfg.Images.Username = "alice"
+	assert.Zero(t, CreateImageQueryParams(cfg).BrowsingLevel, "no range leaves the browsing level unset")
+
+	cfg.Images.NsfwMin = "Soft"
+	assert.Equal(t, models.BrowsingLevelPG13|models.BrowsingLevelR|models.BrowsingLevelX|models.BrowsingLevelXXX, CreateImageQueryParams(cfg).BrowsingLevel)
+
+	cfg.Images.NsfwMax = "Mature"
+	assert.Equal(t, models.BrowsingLevelPG13|models.BrowsingLevelR, CreateImageQueryParams(cfg).BrowsingLevel)
+
+	// An explicit --browsing-level wins
+	cfg.Images.BrowsingLevel = models.BrowsingLevelSFW
+	assert.Equal(t, models.BrowsingLevelSFW, CreateImageQueryParams(cfg).BrowsingLevel)
+}
+
+func TestFilterImagesByNsfwRange(t *testing.T) {
+	items := []models.ImageApiItem{
+		{ID: 1, NsfwLevel: "None"},
+		{ID: 2, NsfwLevel: "Soft"},
+		{ID: 3, NsfwLevel: "Mature"},
+		{ID: 4, NsfwLevel: float64(models.BrowsingLevelXXX)},
+		{ID: 5},
+	}
+	ids := func(items []models.ImageApiItem) []int {
+		var ids []int
+		for _, item := range items {
+			ids = append(ids, item.ID)
+		}
+		return ids
+	}
+
+	cfg := &models.Config{}
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids(filterImagesByNsfwRange(cfg, items)))
+
+	cfg.Images.NsfwMin = "soft"
+	cfg.Images.NsfwMax = "mature"
+	assert.Equal(t, []int{2, 3, 5}, ids(filterImagesByNsfwRange(cfg, items)), "images without a level are kept")
+}
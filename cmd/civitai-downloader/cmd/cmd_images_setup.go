@@ -11,6 +11,8 @@ var (
 	imagesImageIDFlag          int
 	imagesUsernameFlag         string
 	imagesNsfwFlag             string
+	imagesNsfwMinFlag          string
+	imagesNsfwMaxFlag          string
 	imagesSortFlag             string
 	imagesPeriodFlag           string
 	imagesPageFlag             int
@@ -20,6 +22,10 @@ var (
 	imagesMetadataFlag         bool
 	imagesDisableImageMimeFlag bool
 	imagesBrowsingLevelFlag    int
+	imagesGroupByModelFlag     bool
+	imagesOutputTemplateFlag   string
+	imagesStartCursorFlag      string // Cursor to start the feed at (flag only)
+	imagesResumeFlag           bool   // Continue from the cursor saved by the last run (flag only)
 )
 
 func init() {
@@ -35,9 +41,13 @@ func init() {
 	imagesCmd.Flags().StringVarP(&imagesUsernameFlag, "username", "u", "", "Filter by username.")
 	// Use string for nsfw flag to handle both boolean and enum values easily
 	imagesCmd.Flags().StringVar(&imagesNsfwFlag, flagNsfw, "", "Filter by NSFW level (None, Soft, Mature, X) or boolean (true/false). Empty means all.")
+	imagesCmd.Flags().StringVar(&imagesNsfwMinFlag, "nsfw-min", "", "Lowest NSFW level to download (None, Soft, Mature, X). Sets the browsing level unless --browsing-level is given.")
+	imagesCmd.Flags().StringVar(&imagesNsfwMaxFlag, "nsfw-max", "", "Highest NSFW level to download (None, Soft, Mature, X). Sets the browsing level unless --browsing-level is given.")
 	imagesCmd.Flags().StringVarP(&imagesSortFlag, "sort", "s", "Newest", "Sort order (Most Reactions, Most Comments, Newest).")
 	imagesCmd.Flags().StringVarP(&imagesPeriodFlag, "period", "p", "AllTime", "Time period for sorting (AllTime, Year, Month, Week, Day).")
 	imagesCmd.Flags().IntVar(&imagesPageFlag, "page", 1, "Starting page number (uses cursor-advance for images API).") // Images API uses cursor-based pagination; Page config triggers cursor-advance
+	imagesCmd.Flags().StringVar(&imagesStartCursorFlag, "start-cursor", "", "Start the feed at this API cursor instead of --page (e.g. a nextCursor printed by an earlier run).")
+	imagesCmd.Flags().BoolVar(&imagesResumeFlag, "resume", false, "Continue the feed from the cursor saved in the database by the last interrupted run of the same query.")
 	imagesCmd.Flags().IntVar(&imagesMaxPagesFlag, "max-pages", 0, "Maximum number of API pages to fetch (0 for no limit)")
 	imagesCmd.Flags().StringVarP(&imagesOutputDirFlag, "output-dir", "o", "", "Directory to save images (default: [SavePath]/images).")
 	// Link to package-level variable
@@ -46,8 +56,10 @@ func init() {
 	imagesCmd.Flags().BoolVar(&imagesMetadataFlag, "metadata", false, "Save a .json metadata file alongside each downloaded image.")
 	// Add the disable-image-mime flag (default false; presence disables MIME detection)
 	imagesCmd.Flags().BoolVar(&imagesDisableImageMimeFlag, "disable-image-mime", false, "Disable MIME type detection; keep original URL-derived file extensions")
-	// Add the browsing-level flag for precise Civitai content filtering (bitmask: 1=PG, 3=SFW, 31=All)
-	imagesCmd.Flags().IntVar(&imagesBrowsingLevelFlag, "browsing-level", 0, "Civitai browsing level bitmask (1=PG, 3=SFW, 31=All). Overrides --nsfw when set.")
+	imagesCmd.Flags().BoolVar(&imagesGroupByModelFlag, "group-images-by-model", false, "With --model-id or --model-version-id, save images under a model/version folder (Images.SubfolderPattern) above the PathPattern folders.")
+	imagesCmd.Flags().StringVar(&imagesOutputTemplateFlag, "output-template", "", "Folder pattern for images (overrides Images.PathPattern), e.g. \"{username}/{postId}/{width}x{height}\".")
+	// Add the browsing-level flag for precise Civitai content filtering (bitmask: 1=PG, 3=SFW, 31=All, or level names)
+	imagesCmd.Flags().Var(newBrowsingLevelValue(&imagesBrowsingLevelFlag), "browsing-level", "Civitai browsing level: a bitmask (1=PG, 3=SFW, 31=All) or names like PG,PG13,R. Overrides --nsfw when set.")
 
 	// Hidden flag for testing API URL generation
 	imagesCmd.Flags().Bool("debug-print-api-url", false, "Print the constructed API URL for image fetching and exit")
@@ -1,16 +1,17 @@
 package cmd
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strconv"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"go-civitai-download/internal/api"
 	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/helpers"
 	"go-civitai-download/internal/models"
 	"go-civitai-download/internal/paths"
 
@@ -28,6 +29,93 @@ type imageJob struct {
 	ImageID int
 }
 
+// imageModelContext names the model the images command is scoped to, for
+// Images.SubfolderPattern (--group-images-by-model).
+type imageModelContext struct {
+	// Map first
+	VersionNames map[int]string // Version ID -> name, from the model details
+	// String
+	ModelName string
+	// Integers
+	ModelID   int
+	VersionID int // --model-version-id, used for images that don't name their version
+}
+
+// subfolderData returns the placeholder values for Images.SubfolderPattern.
+func (m *imageModelContext) subfolderData(item models.ImageApiItem) map[string]string {
+	versionID := item.ModelVersionID
+	if versionID == 0 {
+		versionID = m.VersionID
+	}
+	data := map[string]string{
+		"modelId":     strconv.Itoa(m.ModelID),
+		"modelName":   m.ModelName,
+		"versionName": m.VersionNames[versionID],
+		"username":    item.Username.String(),
+		"baseModel":   item.BaseModel,
+	}
+	if versionID != 0 {
+		data["versionId"] = strconv.Itoa(versionID)
+	}
+	if data["versionName"] == "" {
+		data["versionName"] = "unknown_version"
+	}
+	return data
+}
+
+// imagePathData returns the placeholder values for Images.PathPattern, all
+// taken from the images API item so no model API calls are needed.
+func imagePathData(item models.ImageApiItem, imageID int) map[string]string {
+	data := map[string]string{
+		paths.PlaceholderUsername:  item.Username.String(),
+		paths.PlaceholderBaseModel: item.BaseModel,
+		paths.PlaceholderImageID:   strconv.Itoa(imageID),
+		paths.PlaceholderPostID:    "unknown_post",
+		paths.PlaceholderWidth:     strconv.Itoa(item.Width),
+		paths.PlaceholderHeight:    strconv.Itoa(item.Height),
+		paths.PlaceholderNsfwLevel: "unknown_nsfw",
+		paths.PlaceholderCreatedAt: "unknown_date",
+	}
+
+	// Fallback values for missing data
+	if data[paths.PlaceholderUsername] == "" {
+		data[paths.PlaceholderUsername] = "unknown_user"
+	}
+	if data[paths.PlaceholderBaseModel] == "" {
+		data[paths.PlaceholderBaseModel] = "unknown_basemodel"
+	}
+	if item.PostID != nil {
+		data[paths.PlaceholderPostID] = strconv.Itoa(*item.PostID)
+	}
+	if rank, known := models.ImageNsfwRank(item.NsfwLevel); known {
+		data[paths.PlaceholderNsfwLevel] = models.ImageNsfwLevels[rank]
+	}
+	if createdAt, err := time.Parse(time.RFC3339, item.CreatedAt); err == nil {
+		data[paths.PlaceholderCreatedAt] = createdAt.UTC().Format(time.DateOnly)
+	}
+	return data
+}
+
+// imageRelPath returns the folder, relative to the output directory, for an
+// image: Images.PathPattern, below the Images.SubfolderPattern folder when
+// modelCtx is set.
+func imageRelPath(cfg *models.Config, item models.ImageApiItem, imageID int, modelCtx *imageModelContext) (string, error) {
+	// Use the Images.PathPattern instead of the complex VersionPathPattern
+	relPath, err := paths.GeneratePath(cfg.Images.PathPattern, imagePathData(item, imageID))
+	if err != nil {
+		return "", fmt.Errorf("using pattern '%s': %w", cfg.Images.PathPattern, err)
+	}
+	if modelCtx == nil {
+		return relPath, nil
+	}
+
+	subfolder, err := paths.GeneratePath(cfg.Images.SubfolderPattern, modelCtx.subfolderData(item))
+	if err != nil {
+		return "", fmt.Errorf("using subfolder pattern '%s': %w", cfg.Images.SubfolderPattern, err)
+	}
+	return filepath.Join(subfolder, relPath), nil
+}
+
 // imageMetadataWithURL wraps ImageApiItem with an additional page_url field for Civitai linking.
 type imageMetadataWithURL struct {
 	// Strings first (for field alignment)
@@ -50,6 +138,7 @@ func imageDownloadWorker(
 	baseDir string, // The root directory for all image downloads (e.g., "downloads/images")
 	apiClient *api.Client, // API client to fetch model details
 	cfg *models.Config,
+	modelCtx *imageModelContext, // nil unless images are grouped by model
 ) {
 	defer wg.Done()
 	logPrefix := fmt.Sprintf("ImgWorker-%d", id)
@@ -59,25 +148,10 @@ func imageDownloadWorker(
 		log.Infof("[%s] Processing image ID %d", logPrefix, job.ImageID)
 		_, _ = fmt.Fprintf(writer, "[%s] Processing image %d...\n", logPrefix, job.ImageID) //nolint:errcheck
 
-		// Step 1: Generate path using simple data from images API (no expensive model API calls)
-		imageData := map[string]string{
-			"username":  job.Metadata.Username.String(),
-			"baseModel": job.Metadata.BaseModel,
-			"imageId":   strconv.Itoa(job.ImageID),
-		}
-
-		// Fallback values for missing data
-		if imageData["username"] == "" {
-			imageData["username"] = "unknown_user"
-		}
-		if imageData["baseModel"] == "" {
-			imageData["baseModel"] = "unknown_basemodel"
-		}
-
-		// Use the Images.PathPattern instead of the complex VersionPathPattern
-		relPath, err := paths.GeneratePath(cfg.Images.PathPattern, imageData)
+		// Step 1: Generate the path
+		relPath, err := imageRelPath(cfg, job.Metadata, job.ImageID, modelCtx)
 		if err != nil {
-			log.WithError(err).Errorf("[%s] Failed to generate path for image %d using pattern '%s'. Skipping.", logPrefix, job.ImageID, cfg.Images.PathPattern)
+			log.WithError(err).Errorf("[%s] Failed to generate path for image %d. Skipping.", logPrefix, job.ImageID)
 			atomic.AddInt64(failureCount, 1)
 			continue
 		}
@@ -112,17 +186,25 @@ func imageDownloadWorker(
 				ImageApiItem: job.Metadata,
 			}
 
-			metaBytes, err := json.MarshalIndent(metaWithURL, "", "  ")
+			metaBytes, err := helpers.MarshalMetadata(metaWithURL, cfg.JSONCompact)
 			if err != nil {
 				log.WithError(err).Errorf("[%s] Failed to marshal metadata for image %d.", logPrefix, job.ImageID)
 				// Don't count this as a full failure, as the image downloaded
 			} else {
-				if err := os.WriteFile(metaPath, metaBytes, 0600); err != nil {
+				if err := os.WriteFile(helpers.LongPath(metaPath), metaBytes, 0600); err != nil {
 					log.WithError(err).Errorf("[%s] Failed to write metadata file %s.", logPrefix, metaPath)
 				} else {
 					log.Debugf("[%s] Successfully saved metadata to %s", logPrefix, metaPath)
 				}
 			}
+
+			// The prompt and parameters in the WebUI format, for reuse as a generation reference
+			textPath, err := saveImageGenerationText(filepath.Join(finalImageDir, imageFilename), job.Metadata.Meta)
+			if err != nil {
+				log.WithError(err).Errorf("[%s] Failed to save generation parameters for image %d.", logPrefix, job.ImageID)
+			} else if textPath != "" {
+				log.Debugf("[%s] Successfully saved generation parameters to %s", logPrefix, textPath)
+			}
 		}
 		_, _ = fmt.Fprintf(writer.Newline(), "[%s] Successfully processed image %d -> %s\n", logPrefix, job.ImageID, imageFilename) //nolint:errcheck
 	}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImageRelPath(t *testing.T) {
+	cfg := &models.Config{Images: models.ImagesConfig{
+		PathPattern:      "{username}/{baseModel}",
+		SubfolderPattern: "{modelName}/{versionName}",
+	}}
+	item := models.ImageApiItem{Username: "alice", BaseModel: "SDXL 1.0", ModelVersionID: 11}
+
+	relPath, err := imageRelPath(cfg, item, 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("alice", "sdxl_1.0"), relPath)
+
+	modelCtx := &imageModelContext{
+		VersionNames: map[int]string{11: "v1.0", 12: "v2.0"},
+		ModelName:    "Cool Model",
+		ModelID:      5,
+	}
+	relPath, err = imageRelPath(cfg, item, 1, modelCtx)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("cool_model", "v1.0", "alice", "sdxl_1.0"), relPath)
+
+	// Images without a version fall back to --model-version-id, then to unknown_version
+	item.ModelVersionID = 0
+	modelCtx.VersionID = 12
+	relPath, err = imageRelPath(cfg, item, 1, modelCtx)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("cool_model", "v2.0", "alice", "sdxl_1.0"), relPath)
+
+	modelCtx.VersionID = 0
+	relPath, err = imageRelPath(cfg, item, 1, modelCtx)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("cool_model", "unknown_version", "alice", "sdxl_1.0"), relPath)
+
+	cfg.Images.SubfolderPattern = "{bogus}"
+	_, err = imageRelPath(cfg, item, 1, modelCtx)
+	assert.Error(t, err)
+}
+
+func TestImageRelPath_ImageTags(t *testing.T) {
+	cfg := &models.Config{Images: models.ImagesConfig{PathPattern: "{postId}/{width}x{height}/{nsfwLevel}/{createdAt}"}}
+	postID := 987
+	item := models.ImageApiItem{
+		PostID:    &postID,
+		Width:     832,
+		Height:    1216,
+		NsfwLevel: "Soft",
+		CreatedAt: "2024-05-01T21:13:45.123Z",
+	}
+
+	relPath, err := imageRelPath(cfg, item, 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("987", "832x1216", "soft", "2024-05-01"), relPath)
+
+	// Missing values get a fallback rather than "<nil>" or an empty folder
+	relPath, err = imageRelPath(cfg, models.ImageApiItem{NsfwLevel: 4.0}, 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join("unknown_post", "0x0", "mature", "unknown_date"), relPath)
+}
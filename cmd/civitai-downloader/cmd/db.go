@@ -5,10 +5,16 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"reflect"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"text/tabwriter"
 	"time"
 
@@ -23,8 +29,31 @@ import (
 
 // Package-level variables for db verify flags
 var (
-	DbVerifyCheckHashFlag bool
-	DbVerifyYesFlag       bool
+	DbVerifyCheckHashFlag      bool
+	DbVerifyYesFlag            bool
+	DbVerifyHashAlgoFlag       string
+	DbVerifyForceFlag          bool
+	DbVerifyConcurrencyFlag    int
+	DbVerifyRepairMetadataFlag bool
+)
+
+// Package-level variables for db view flags
+var (
+	dbViewShowDatesFlag bool
+	dbViewSortByFlag    string
+)
+
+// Package-level variables for db search flags
+var (
+	dbSearchFTSFlag   bool
+	dbSearchLimitFlag int
+)
+
+// Orders accepted by db view --sort-by.
+const (
+	viewSortKey  = "key"
+	viewSortDate = "date"
+	viewSortName = "name"
 )
 
 // dbCmd represents the base command for database operations
@@ -39,8 +68,12 @@ var dbCmd = &cobra.Command{
 var dbViewCmd = &cobra.Command{
 	Use:   "view",
 	Short: "View entries stored in the database",
-	Long:  `Lists the models and files that have been recorded in the database.`,
-	Run:   runDbView,
+	Long: `Lists the models and files that have been recorded in the database.
+
+--show-dates adds the published and updated dates of each version, and
+--sort-by date lists the versions updated longest ago first, handy for spotting
+stale entries.`,
+	Run: runDbView,
 }
 
 // dbVerifyCmd represents the command to verify database entries against the filesystem
@@ -67,7 +100,11 @@ var dbSearchCmd = &cobra.Command{
 	Use:   "search [MODEL_NAME_QUERY]",
 	Short: "Search database entries by model name",
 	Long: `Searches database entries for models whose names contain the provided query text (case-insensitive).
-Prints matching entries.`,
+Prints matching entries.
+
+With --fts the full-text index is searched instead: model and version names,
+version descriptions, trained words and creator names. Entries must contain
+every word of the query and are listed best match first.`,
 	Args: cobra.ExactArgs(1), // Requires exactly one argument
 	Run:  runDbSearch,
 }
@@ -81,11 +118,20 @@ func init() {
 
 	// Add flags specific to db view if needed (e.g., filtering)
 	// dbViewCmd.Flags().StringP("filter", "f", "", "Filter results (e.g., by model name)")
+	dbViewCmd.Flags().BoolVar(&dbViewShowDatesFlag, "show-dates", false, "Add the published and updated dates of each version")
+	dbViewCmd.Flags().StringVar(&dbViewSortByFlag, "sort-by", viewSortKey, "Order of the entries: key (version ID), date (updated longest ago first) or name")
+
+	dbSearchCmd.Flags().BoolVar(&dbSearchFTSFlag, "fts", false, "Full-text search of names, descriptions, trained words and creators, ranked by relevance")
+	dbSearchCmd.Flags().IntVar(&dbSearchLimitFlag, "limit", 50, "With --fts, show at most this many matches (0 shows all)")
 
 	// Add flags specific to db verify
 	// These flags will be used by config.Initialize to populate globalConfig.DB.Verify
 	dbVerifyCmd.Flags().BoolVar(&DbVerifyCheckHashFlag, "check-hash", true, "Perform hash check for existing files")
 	dbVerifyCmd.Flags().BoolVarP(&DbVerifyYesFlag, "yes", "y", false, "Automatically attempt to redownload missing/mismatched files without prompting")
+	dbVerifyCmd.Flags().StringVar(&DbVerifyHashAlgoFlag, "hash-algo", "", "Only compare this hash: sha256, blake3, crc32 or autov2 (default: any available)")
+	dbVerifyCmd.Flags().BoolVar(&DbVerifyForceFlag, "force", false, "Hash every file, even those verified within SkipIfVerifiedWithin")
+	dbVerifyCmd.Flags().IntVarP(&DbVerifyConcurrencyFlag, "concurrency", "c", 0, "Number of files to check at once (default: DB.Verify.Concurrency, or the number of CPUs)")
+	dbVerifyCmd.Flags().BoolVar(&DbVerifyRepairMetadataFlag, "repair-metadata", false, "Update the fields of metadata .json files of OK files that differ from the database entry")
 
 	// Add flags specific to db redownload if needed (e.g., force overwrite without hash check?)
 	// dbRedownloadCmd.Flags().Bool("force", false, "Force redownload even if file exists and hash matches")
@@ -94,6 +140,11 @@ func init() {
 func runDbView(cmd *cobra.Command, args []string) {
 	log.Info("Viewing database entries...")
 
+	sortBy := strings.ToLower(strings.TrimSpace(dbViewSortByFlag))
+	if sortBy != viewSortKey && sortBy != viewSortDate && sortBy != viewSortName {
+		log.Fatalf("Invalid --sort-by %q (expected key, date or name)", dbViewSortByFlag)
+	}
+
 	// Use globalConfig loaded by PersistentPreRunE
 	if globalConfig.DatabasePath == "" {
 		log.Fatal("Database path is not set in the configuration. Please check config file or path.")
@@ -106,11 +157,7 @@ func runDbView(cmd *cobra.Command, args []string) {
 	}
 	defer func() { _ = db.Close() }()
 
-	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0) // Adjust padding and alignment
-	_, _ = fmt.Fprintln(tw, "Model Name\tVersion Name\tFilename\tFolder\tType\tBase Model\tCreator\tStatus\tDB Key (VersionID)")
-	_, _ = fmt.Fprintln(tw, "----------\t------------\t--------\t------\t----\t----------\t-------\t------\t------------------")
-
-	count := 0
+	var entries []models.DatabaseEntry
 	// Use Fold to iterate over key-value pairs
 	errFold := db.Fold(func(key []byte, value []byte) error {
 		keyStr := string(key)
@@ -126,22 +173,7 @@ func runDbView(cmd *cobra.Command, args []string) {
 			log.WithError(err).Warnf("Failed to unmarshal JSON for key %s: %s", keyStr, string(value))
 			return nil // Continue folding over other keys
 		}
-
-		// Print table row using the added fields, including Status
-		// Extract version ID from key for display
-		versionIDStr := strings.TrimPrefix(keyStr, "v_")
-		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", //nolint:errcheck
-			entry.ModelName, // Use added ModelName
-			entry.Version.Name,
-			entry.Filename,
-			entry.Folder,
-			entry.ModelType, // Use added ModelType
-			entry.Version.BaseModel,
-			entry.Creator.Username, // Print the username from the Creator struct
-			entry.Status,           // Added Status field
-			versionIDStr,           // Display the version ID
-		)
-		count++
+		entries = append(entries, entry)
 		return nil
 	})
 
@@ -149,12 +181,90 @@ func runDbView(cmd *cobra.Command, args []string) {
 		log.WithError(errFold).Error("Error occurred during database scan (Fold)")
 	}
 
+	sortViewEntries(entries, sortBy)
+	printDbView(os.Stdout, entries, dbViewShowDatesFlag)
+	log.Infof("Displayed %d entries.", len(entries))
+}
+
+// printDbView writes the db view table, with the version dates when showDates is set.
+func printDbView(w io.Writer, entries []models.DatabaseEntry, showDates bool) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0) // Adjust padding and alignment
+	header := "Model Name\tVersion Name\tFilename\tFolder\tType\tBase Model\tCreator\tStatus"
+	divider := "----------\t------------\t--------\t------\t----\t----------\t-------\t------"
+	if showDates {
+		header += "\tPublished\tUpdated"
+		divider += "\t---------\t-------"
+	}
+	_, _ = fmt.Fprintln(tw, header+"\tDB Key (VersionID)")
+	_, _ = fmt.Fprintln(tw, divider+"\t------------------")
+
+	for _, entry := range entries {
+		row := []string{
+			entry.ModelName,
+			entry.Version.Name,
+			entry.Filename,
+			entry.Folder,
+			entry.ModelType,
+			entry.Version.BaseModel,
+			entry.Creator.Username,
+			entry.Status,
+		}
+		if showDates {
+			row = append(row, shortDate(entry.Version.PublishedAt), shortDate(entry.Version.UpdatedAt))
+		}
+		row = append(row, strconv.Itoa(entry.Version.ID))
+		_, _ = fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
 	if err := tw.Flush(); err != nil {
 		log.WithError(err).Error("Error flushing table writer for db view")
 	}
-	log.Infof("Displayed %d entries.", count)
 }
 
+// sortViewEntries orders entries for db view. Entries keep the database order
+// (by version ID) for viewSortKey and as a tie-breaker.
+func sortViewEntries(entries []models.DatabaseEntry, sortBy string) {
+	switch sortBy {
+	case viewSortDate:
+		sort.SliceStable(entries, func(i, j int) bool {
+			return versionDate(entries[i]).Before(versionDate(entries[j]))
+		})
+	case viewSortName:
+		sort.SliceStable(entries, func(i, j int) bool {
+			return strings.ToLower(entries[i].ModelName) < strings.ToLower(entries[j].ModelName)
+		})
+	}
+}
+
+// versionDate returns when a version was last updated on Civitai, or when it
+// was published if it has no update date. Versions without either sort first.
+func versionDate(entry models.DatabaseEntry) time.Time {
+	for _, value := range []string{entry.Version.UpdatedAt, entry.Version.PublishedAt} {
+		if date, err := time.Parse(time.RFC3339, value); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
+
+// shortDate returns the date part of an API timestamp such as
+// "2024-03-01T12:00:00.000Z", or "-" when there is none.
+func shortDate(value string) string {
+	if value == "" {
+		return "-"
+	}
+	if len(value) >= len("2006-01-02") {
+		return value[:len("2006-01-02")]
+	}
+	return value
+}
+
+// Reasons reported by verifyMainFile for files that did not verify cleanly.
+const (
+	reasonHashMismatch    = "Hash Mismatch"
+	reasonHashUnavailable = "Hash Unavailable"
+)
+
 type verificationProblem struct {
 	Reason string
 	DbKey  string
@@ -164,6 +274,20 @@ type verificationProblem struct {
 func runDbVerify(cmd *cobra.Command, args []string) {
 	log.Info("Verifying database entries against filesystem...")
 
+	hashAlgo, err := helpers.ParseHashAlgo(globalConfig.DB.Verify.HashAlgo)
+	if err != nil {
+		log.Fatal(err)
+	}
+	globalConfig.DB.Verify.HashAlgo = hashAlgo
+
+	skipWithin, err := parseVerifyWindow(globalConfig.DB.Verify.SkipIfVerifiedWithin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if DbVerifyForceFlag || !globalConfig.DB.Verify.CheckHash {
+		skipWithin = 0
+	}
+
 	// Validate configuration and open database
 	db, err := initializeVerificationDatabase()
 	if err != nil {
@@ -171,8 +295,14 @@ func runDbVerify(cmd *cobra.Command, args []string) {
 	}
 	defer func() { _ = db.Close() }()
 
+	workers := globalConfig.DB.Verify.Concurrency
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
 	// Scan database and verify files
-	stats, problemsToAddress := scanDatabaseEntries(db)
+	stats, problemsToAddress, verifications := scanDatabaseEntries(db, skipWithin, time.Now(), workers)
+	recordVerifications(db, verifications)
 	logInitialScanSummary(stats)
 
 	// Handle redownloads if problems found
@@ -191,6 +321,17 @@ type VerificationStats struct {
 	FoundOk           int
 	FoundHashMismatch int
 	Missing           int
+	HashUnavailable   int
+	RecentlyVerified  int // Skipped because they were hashed OK within SkipIfVerifiedWithin
+	Blocked           int // Blocked versions recorded as Skipped, which have no file
+	MetadataRepaired  int // Stale metadata files rewritten by --repair-metadata
+}
+
+// verificationRecord is a hash check outcome to store in the database once the scan is done.
+type verificationRecord struct {
+	Hash       string // Empty when the file failed
+	VersionID  int
+	VerifiedAt int64 // 0 when the file failed
 }
 
 // initializeVerificationDatabase validates config and opens the database
@@ -216,12 +357,43 @@ func initializeVerificationDatabase() (*database.DB, error) {
 	return db, nil
 }
 
-// scanDatabaseEntries scans all database entries and verifies files
-func scanDatabaseEntries(db *database.DB) (VerificationStats, []verificationProblem) {
-	var stats VerificationStats
-	var problemsToAddress []verificationProblem
+// verifyScan collects the results of the workers of scanDatabaseEntries.
+type verifyScan struct {
+	mu            sync.Mutex
+	stats         VerificationStats
+	problems      []verificationProblem
+	verifications []verificationRecord
+}
+
+// verifyJob is a database entry for a scanDatabaseEntries worker to check.
+type verifyJob struct {
+	dbKey string
+	entry models.DatabaseEntry
+}
+
+// scanDatabaseEntries scans all database entries and verifies their files with
+// workers files checked at once. Files hashed OK within skipWithin (if
+// non-zero) and not modified since are not hashed again. The returned
+// problems are ordered by version ID; the records hold the hash check
+// outcomes to store.
+func scanDatabaseEntries(db *database.DB, skipWithin time.Duration, now time.Time, workers int) (VerificationStats, []verificationProblem, []verificationRecord) {
+	if workers < 1 {
+		workers = 1
+	}
+	scan := &verifyScan{}
+	jobs := make(chan verifyJob, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				scan.verifyEntry(job.dbKey, job.entry, skipWithin, now)
+			}
+		}()
+	}
 
-	log.Info("Scanning database entries...")
+	log.Infof("Scanning database entries (%d at once)...", workers)
 
 	errFold := db.Fold(func(key []byte, value []byte) error {
 		keyStr := string(key)
@@ -229,40 +401,163 @@ func scanDatabaseEntries(db *database.DB) (VerificationStats, []verificationProb
 			return nil // Skip non-version keys
 		}
 
-		stats.TotalEntries++
+		scan.mu.Lock()
+		scan.stats.TotalEntries++
+		scan.mu.Unlock()
 
 		var entry models.DatabaseEntry
 		if err := json.Unmarshal(value, &entry); err != nil {
 			log.WithError(err).Warnf("Failed to unmarshal JSON for key %s, skipping verification for this entry.", keyStr)
 			return nil // Continue folding
 		}
-
-		expectedPath := filepath.Join(globalConfig.SavePath, entry.Folder, entry.Filename)
-		mainFileFound, hashOK, problemReason := verifyMainFile(expectedPath, entry)
-
-		updateVerificationStats(&stats, mainFileFound, hashOK, problemReason)
-
-		if problemReason != "" {
-			problemsToAddress = append(problemsToAddress, verificationProblem{
-				Entry:  entry,
-				Reason: problemReason,
-				DbKey:  keyStr,
-			})
-		}
-
-		// Handle metadata files if main file is OK
-		if mainFileFound && hashOK {
-			handleMetadataVerification(expectedPath, entry)
+		if entry.Status == models.StatusSkipped {
+			scan.mu.Lock()
+			scan.stats.Blocked++
+			scan.mu.Unlock()
+			return nil
 		}
 
+		jobs <- verifyJob{dbKey: keyStr, entry: entry}
 		return nil // Continue folding
 	})
+	close(jobs)
+	wg.Wait()
 
 	if errFold != nil {
 		log.WithError(errFold).Error("Error occurred during database scan (Fold)")
 	}
 
-	return stats, problemsToAddress
+	// Workers finish in any order; keep the redownload prompts in version order
+	sort.Slice(scan.problems, func(i, j int) bool {
+		return scan.problems[i].Entry.Version.ID < scan.problems[j].Entry.Version.ID
+	})
+	return scan.stats, scan.problems, scan.verifications
+}
+
+// verifyEntry checks the file of one database entry and adds the outcome to
+// the scan. It is called by several workers at once.
+func (s *verifyScan) verifyEntry(dbKey string, entry models.DatabaseEntry, skipWithin time.Duration, now time.Time) {
+	expectedPath := filepath.Join(globalConfig.SavePath, entry.Folder, entry.Filename)
+	expectedHash := expectedFileHash(entry.File.Hashes, globalConfig.DB.Verify.HashAlgo)
+	if recentlyVerified(expectedPath, entry, expectedHash, skipWithin, now) {
+		log.WithFields(log.Fields{"path": expectedPath, "status": entry.Status}).Infof("[SKIP] Hash verified on %s.", time.Unix(entry.LastVerifiedAt, 0).Format("2006-01-02"))
+		s.mu.Lock()
+		s.stats.RecentlyVerified++
+		s.mu.Unlock()
+		s.verifyMetadata(expectedPath, entry)
+		return
+	}
+
+	mainFileFound, hashOK, problemReason := verifyMainFile(expectedPath, entry)
+
+	s.mu.Lock()
+	updateVerificationStats(&s.stats, mainFileFound, hashOK, problemReason)
+
+	// Remember files confirmed good, and forget earlier confirmations of files that now fail
+	if globalConfig.DB.Verify.CheckHash && mainFileFound && hashOK && expectedHash != "" {
+		s.verifications = append(s.verifications, verificationRecord{VersionID: entry.Version.ID, VerifiedAt: now.Unix(), Hash: expectedHash})
+	} else if problemReason != "" && entry.LastVerifiedAt != 0 {
+		s.verifications = append(s.verifications, verificationRecord{VersionID: entry.Version.ID})
+	}
+
+	// Redownloading cannot supply a missing hash, so only report these.
+	if problemReason != "" && problemReason != reasonHashUnavailable {
+		s.problems = append(s.problems, verificationProblem{
+			Entry:  entry,
+			Reason: problemReason,
+			DbKey:  dbKey,
+		})
+	}
+	s.mu.Unlock()
+
+	// Handle metadata files if main file is OK
+	if mainFileFound && hashOK {
+		s.verifyMetadata(expectedPath, entry)
+	}
+}
+
+// verifyMetadata checks the metadata file of an OK entry and counts it when
+// --repair-metadata rewrote it.
+func (s *verifyScan) verifyMetadata(expectedPath string, entry models.DatabaseEntry) {
+	if handleMetadataVerification(expectedPath, entry, DbVerifyRepairMetadataFlag) {
+		s.mu.Lock()
+		s.stats.MetadataRepaired++
+		s.mu.Unlock()
+	}
+}
+
+// parseVerifyWindow parses SkipIfVerifiedWithin: a Go duration ("12h") or a
+// number of days ("30d"). Empty or zero disables skipping.
+func parseVerifyWindow(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid SkipIfVerifiedWithin %q: use a number of days like \"30d\" or a duration like \"12h\"", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid SkipIfVerifiedWithin %q: use a number of days like \"30d\" or a duration like \"12h\"", s)
+	}
+	return d, nil
+}
+
+// expectedFileHash returns the recorded hash that verification compares for
+// algo. With no algo it is the first available in the order CheckHash uses.
+func expectedFileHash(hashes models.Hashes, algo string) string {
+	var candidates []string
+	switch algo {
+	case helpers.HashAlgoSHA256:
+		candidates = []string{hashes.SHA256}
+	case helpers.HashAlgoBLAKE3:
+		candidates = []string{hashes.BLAKE3}
+	case helpers.HashAlgoCRC32:
+		candidates = []string{hashes.CRC32}
+	case helpers.HashAlgoAutoV2:
+		candidates = []string{hashes.AutoV2}
+	default:
+		candidates = []string{hashes.BLAKE3, hashes.SHA256, hashes.CRC32, hashes.AutoV2}
+	}
+	for _, h := range candidates {
+		if h != "" {
+			return strings.ToUpper(h)
+		}
+	}
+	return ""
+}
+
+// recentlyVerified reports whether the entry's file was hashed OK against
+// expectedHash within skipWithin of now and has not been modified since.
+func recentlyVerified(path string, entry models.DatabaseEntry, expectedHash string, skipWithin time.Duration, now time.Time) bool {
+	if skipWithin <= 0 || entry.LastVerifiedAt == 0 || expectedHash == "" {
+		return false
+	}
+	if !strings.EqualFold(entry.LastVerifiedHash, expectedHash) {
+		return false // The recorded hash changed since, e.g. the file was replaced
+	}
+	verifiedAt := time.Unix(entry.LastVerifiedAt, 0)
+	if now.Sub(verifiedAt) >= skipWithin {
+		return false
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return !info.ModTime().After(verifiedAt)
+}
+
+// recordVerifications stores the hash check outcomes of a scan.
+func recordVerifications(db *database.DB, verifications []verificationRecord) {
+	for _, v := range verifications {
+		if err := db.SetVerification(v.VersionID, v.VerifiedAt, v.Hash); err != nil {
+			log.WithError(err).Warnf("Failed to record verification for version %d", v.VersionID)
+		}
+	}
 }
 
 // verifyMainFile checks if the main model file exists and has correct hash
@@ -273,12 +568,20 @@ func verifyMainFile(expectedPath string, entry models.DatabaseEntry) (bool, bool
 	if statErr == nil {
 		// File exists
 		if checkHashFlag {
-			if helpers.CheckHash(expectedPath, entry.File.Hashes) {
+			match, err := helpers.CheckHashWithAlgo(expectedPath, entry.File.Hashes, globalConfig.DB.Verify.HashAlgo)
+			if errors.Is(err, helpers.ErrHashUnavailable) {
+				log.WithFields(log.Fields{"path": expectedPath, "status": entry.Status}).Errorf("[NO HASH] No %s hash recorded for this file.", globalConfig.DB.Verify.HashAlgo)
+				return true, false, reasonHashUnavailable
+			} else if err != nil {
+				log.WithError(err).Errorf("[ERROR] Could not hash %s", expectedPath)
+				return true, false, reasonHashMismatch
+			}
+			if match {
 				log.WithFields(log.Fields{"path": expectedPath, "status": entry.Status}).Info("[OK] File exists and hash matches.") //nolint:goconst
 				return true, true, ""
 			} else {
 				log.WithFields(log.Fields{"path": expectedPath, "status": entry.Status}).Warn("[MISMATCH] File exists but hash mismatch.") //nolint:goconst
-				return true, false, "Hash Mismatch"
+				return true, false, reasonHashMismatch
 			}
 		} else {
 			log.WithFields(log.Fields{"path": expectedPath, "status": entry.Status}).Info("[FOUND] File exists (hash check skipped).") //nolint:goconst
@@ -297,6 +600,8 @@ func verifyMainFile(expectedPath string, entry models.DatabaseEntry) (bool, bool
 func updateVerificationStats(stats *VerificationStats, mainFileFound, hashOK bool, problemReason string) {
 	if mainFileFound && hashOK {
 		stats.FoundOk++
+	} else if problemReason == reasonHashUnavailable {
+		stats.HashUnavailable++
 	} else if mainFileFound && !hashOK {
 		stats.FoundHashMismatch++
 	} else if problemReason == "Missing" {
@@ -304,43 +609,141 @@ func updateVerificationStats(stats *VerificationStats, mainFileFound, hashOK boo
 	}
 }
 
-// handleMetadataVerification handles verification and creation of metadata files
-func handleMetadataVerification(expectedPath string, entry models.DatabaseEntry) {
-	if !globalConfig.Download.SaveMetadata {
-		return
+// handleMetadataVerification handles verification and creation of metadata files.
+// Missing files are created if Metadata is enabled. With repair, an existing
+// file that differs from the entry's version is rewritten, and true returned.
+func handleMetadataVerification(expectedPath string, entry models.DatabaseEntry, repair bool) bool {
+	if !globalConfig.Download.SaveMetadata && !repair {
+		return false
 	}
 
 	metaFilename := strings.TrimSuffix(entry.Filename, filepath.Ext(entry.Filename)) + ".json"
-	metaFilepath := filepath.Join(globalConfig.SavePath, entry.Folder, metaFilename)
+	metaFilepath := filepath.Join(metadataRoot(&globalConfig), entry.Folder, metaFilename)
 
 	if _, metaStatErr := os.Stat(metaFilepath); metaStatErr != nil {
 		if os.IsNotExist(metaStatErr) {
-			createMetadataFile(metaFilepath, entry.Version)
+			if globalConfig.Download.SaveMetadata {
+				createMetadataFile(metaFilepath, entry.Version, globalConfig.JSONCompact)
+			}
 		} else {
 			log.WithError(metaStatErr).Errorf("[METADATA ERROR] Could not check metadata file status for %s", metaFilepath)
 		}
-	} else {
-		log.WithField("path", metaFilepath).Info("[METADATA OK] Metadata file exists.")
+		return false
+	}
+	if repair {
+		return repairMetadataFile(metaFilepath, entry.Version, globalConfig.JSONCompact)
 	}
+	log.WithField("path", metaFilepath).Info("[METADATA OK] Metadata file exists.")
+	return false
+}
+
+// metadataDBFields are the metadata file keys the database holds in full. The
+// entry's version is rebuilt from the database without the model, download
+// URLs, availability and most file and image details, so the other keys of a
+// metadata file are left as they are.
+var metadataDBFields = []string{"name", "publishedAt", "updatedAt", "description", "trainedWords", "baseModel", "earlyAccessTimeFrame", "stats"}
+
+// repairMetadataFile updates the metadata file at metaFilepath when one of
+// metadataDBFields differs from version, e.g. after the trained words changed.
+// Both sides are compared parsed, so formatting does not count, and values the
+// database does not hold never replace what the file has. It reports whether
+// the file was rewritten.
+func repairMetadataFile(metaFilepath string, version models.ModelVersion, compact bool) bool {
+	current, err := os.ReadFile(helpers.LongPath(metaFilepath))
+	if err != nil {
+		log.WithError(err).Errorf("[METADATA ERROR] Could not read metadata file %s", metaFilepath)
+		return false
+	}
+	var onDisk map[string]any
+	if err := json.Unmarshal(current, &onDisk); err != nil || onDisk == nil {
+		log.WithError(err).Errorf("[METADATA ERROR] %s is not a JSON object, leaving it alone", metaFilepath)
+		return false
+	}
+	fresh, err := json.Marshal(version)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to marshal metadata for %s", filepath.Base(metaFilepath))
+		return false
+	}
+	var fromDB map[string]any
+	if err := json.Unmarshal(fresh, &fromDB); err != nil {
+		log.WithError(err).Errorf("Failed to marshal metadata for %s", filepath.Base(metaFilepath))
+		return false
+	}
+
+	changed := false
+	for _, key := range metadataDBFields {
+		value := fromDB[key]
+		if emptyJSONValue(value) || reflect.DeepEqual(onDisk[key], value) {
+			continue
+		}
+		onDisk[key] = value
+		changed = true
+	}
+	if !changed {
+		log.WithField("path", metaFilepath).Info("[METADATA OK] Metadata file is up to date.")
+		return false
+	}
+
+	jsonData, err := helpers.MarshalMetadata(onDisk, compact)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to marshal metadata for %s", filepath.Base(metaFilepath))
+		return false
+	}
+	if err := os.WriteFile(helpers.LongPath(metaFilepath), jsonData, 0600); err != nil {
+		log.WithError(err).Errorf("Failed to write metadata file %s", metaFilepath)
+		return false
+	}
+	log.WithField("path", metaFilepath).Warn("[METADATA REPAIRED] Rewrote stale metadata file.")
+	return true
+}
+
+// emptyJSONValue reports whether a decoded JSON value holds no data: null, "",
+// 0, false, or an array or object of such values.
+func emptyJSONValue(value any) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	case bool:
+		return !v
+	case []any:
+		for _, item := range v {
+			if !emptyJSONValue(item) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		for _, item := range v {
+			if !emptyJSONValue(item) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
 }
 
 // createMetadataFile creates a metadata file for a model version
-func createMetadataFile(metaFilepath string, version models.ModelVersion) {
+func createMetadataFile(metaFilepath string, version models.ModelVersion, compact bool) {
 	log.WithField("path", metaFilepath).Warn("[METADATA MISSING] Creating metadata file...")
 
-	jsonData, err := json.MarshalIndent(version, "", "  ")
+	jsonData, err := helpers.MarshalMetadata(version, compact)
 	if err != nil {
 		log.WithError(err).Errorf("Failed to marshal metadata for %s", filepath.Base(metaFilepath))
 		return
 	}
 
 	metaDir := filepath.Dir(metaFilepath)
-	if err := os.MkdirAll(metaDir, 0700); err != nil {
+	if err := os.MkdirAll(helpers.LongPath(metaDir), 0700); err != nil {
 		log.WithError(err).Errorf("Failed to create directory for metadata file %s", metaFilepath)
 		return
 	}
 
-	if err := os.WriteFile(metaFilepath, jsonData, 0600); err != nil {
+	if err := os.WriteFile(helpers.LongPath(metaFilepath), jsonData, 0600); err != nil {
 		log.WithError(err).Errorf("Failed to write metadata file %s", metaFilepath)
 		return
 	}
@@ -352,6 +755,19 @@ func createMetadataFile(metaFilepath string, version models.ModelVersion) {
 func logInitialScanSummary(stats VerificationStats) {
 	log.Infof("Initial Scan Summary: Total Entries=%d, OK=%d, Missing=%d, Mismatch=%d",
 		stats.TotalEntries, stats.FoundOk, stats.Missing, stats.FoundHashMismatch)
+	if stats.Blocked > 0 {
+		log.Infof("%d blocked version(s) recorded as %s were not checked.", stats.Blocked, models.StatusSkipped)
+	}
+	if DbVerifyRepairMetadataFlag {
+		log.Infof("Regenerated %d stale metadata file(s).", stats.MetadataRepaired)
+	}
+	if stats.RecentlyVerified > 0 {
+		log.Infof("%d file(s) were not hashed again: verified within SkipIfVerifiedWithin (use --force to check them).", stats.RecentlyVerified)
+	}
+	if stats.HashUnavailable > 0 {
+		log.Errorf("%d file(s) could not be verified: no %s hash recorded in the database.",
+			stats.HashUnavailable, globalConfig.DB.Verify.HashAlgo)
+	}
 }
 
 // handleRedownloads processes files that need to be redownloaded
@@ -436,7 +852,9 @@ func initializeDownloader() *downloader.Downloader {
 	}
 
 	log.Debug("Downloader initialized.")
-	return downloader.NewDownloader(httpClient, globalConfig.APIKey, globalConfig.SessionCookie)
+	d := downloader.NewDownloader(httpClient, globalConfig.APIKey, globalConfig.SessionCookie)
+	d.SetUserAgent(globalConfig.UserAgent)
+	return d
 }
 
 // performRedownload performs the actual redownload of a file
@@ -453,6 +871,8 @@ func performRedownload(db *database.DB, problem verificationProblem, fileDownloa
 		return false
 	}
 
+	// Flat files keep their stored name, see flatFileName
+	fileDownloader.SetKeepFileName(entry.Folder == flatFolder)
 	finalPath, downloadErr := fileDownloader.DownloadFile(targetPath, entry.File.DownloadUrl, entry.File.Hashes, entry.Version.ID)
 
 	finalStatus := models.StatusError
@@ -551,9 +971,13 @@ func runDbRedownload(cmd *cobra.Command, args []string) {
 	// createDownloaderClient is in download.go, cannot be called directly here.
 	// Create a new client instance for this command.
 	// TODO: Refactor client creation/sharing?
-	downloaderHttpClient := &http.Client{Timeout: 30 * time.Minute} // Longer timeout for downloads
+	downloaderHttpClient := &http.Client{Timeout: 30 * time.Minute, Transport: globalHttpTransport} // Longer timeout for downloads
 	// Use correct case for APIKey
 	fileDownloader := downloader.NewDownloader(downloaderHttpClient, globalConfig.APIKey, globalConfig.SessionCookie)
+	fileDownloader.SetUserAgent(globalConfig.UserAgent)
+	fileDownloader.SetRateLimiter(downloader.NewRateLimiter(globalConfig.Download.MaxBytesPerSecond))
+	fileDownloader.SetSegments(globalConfig.Download.SegmentsPerFile)
+	fileDownloader.SetKeepFileName(entry.Folder == flatFolder)
 
 	// Perform the download, checking the error
 	// Pass the Model Version ID from the database entry
@@ -585,7 +1009,11 @@ func runDbRedownload(cmd *cobra.Command, args []string) {
 
 func runDbSearch(cmd *cobra.Command, args []string) {
 	searchTerm := strings.ToLower(args[0]) // Case-insensitive search
-	log.Infof("Searching database entries for model name containing: '%s'", searchTerm)
+	if dbSearchFTSFlag {
+		log.Infof("Full-text searching database entries for: '%s'", args[0])
+	} else {
+		log.Infof("Searching database entries for model name containing: '%s'", searchTerm)
+	}
 
 	// Use globalConfig loaded by PersistentPreRunE
 	if globalConfig.DatabasePath == "" {
@@ -602,6 +1030,25 @@ func runDbSearch(cmd *cobra.Command, args []string) {
 	_, _ = fmt.Fprintln(tw, "Model Name\tVersion Name\tFilename\tFolder\tType\tBase Model\tCreator\tStatus\tDB Key (VersionID)")
 	_, _ = fmt.Fprintln(tw, "----------\t------------\t--------\t------\t----\t----------\t-------\t------\t------------------")
 
+	var matchCount int
+	if dbSearchFTSFlag {
+		matchCount, err = printFTSMatches(tw, db, args[0], dbSearchLimitFlag)
+		if err != nil {
+			log.WithError(err).Error("Full-text search failed")
+		}
+	} else {
+		matchCount = printNameMatches(tw, db, searchTerm)
+	}
+
+	if err := tw.Flush(); err != nil {
+		log.WithError(err).Error("Error flushing table writer for db search")
+	}
+	log.Infof("Found %d matching entries for query '%s'.", matchCount, args[0])
+}
+
+// printNameMatches writes the entries whose model name contains searchTerm,
+// which must be lower case, and returns how many there were.
+func printNameMatches(tw io.Writer, db *database.DB, searchTerm string) int {
 	matchCount := 0
 	errFold := db.Fold(func(key []byte, value []byte) error {
 		keyStr := string(key)
@@ -620,19 +1067,7 @@ func runDbSearch(cmd *cobra.Command, args []string) {
 		// Perform case-insensitive substring search
 		if strings.Contains(strings.ToLower(entry.ModelName), searchTerm) {
 			matchCount++
-			// Extract version ID from key for display
-			versionIDStr := strings.TrimPrefix(keyStr, "v_")
-			_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", //nolint:errcheck
-				entry.ModelName,
-				entry.Version.Name,
-				entry.Filename,
-				entry.Folder,
-				entry.ModelType,
-				entry.Version.BaseModel,
-				entry.Creator.Username,
-				entry.Status, // Added Status field
-				versionIDStr, // Display the version ID
-			)
+			printSearchMatch(tw, entry, strings.TrimPrefix(keyStr, "v_"))
 		}
 		return nil
 	})
@@ -640,9 +1075,46 @@ func runDbSearch(cmd *cobra.Command, args []string) {
 	if errFold != nil {
 		log.WithError(errFold).Error("Error occurred during database scan (Fold)")
 	}
+	return matchCount
+}
 
-	if err := tw.Flush(); err != nil {
-		log.WithError(err).Error("Error flushing table writer for db search")
+// printFTSMatches writes the entries matching query in the full-text index,
+// best match first, and returns how many there were.
+func printFTSMatches(tw io.Writer, db *database.DB, query string, limit int) (int, error) {
+	versionIDs, err := db.SearchText(query, limit)
+	if err != nil {
+		return 0, err
 	}
-	log.Infof("Found %d matching entries for query '%s'.", matchCount, searchTerm)
+	matchCount := 0
+	for _, versionID := range versionIDs {
+		versionIDStr := strconv.Itoa(versionID)
+		value, err := db.Get([]byte("v_" + versionIDStr))
+		if err != nil {
+			log.WithError(err).Warnf("Failed to read entry for version %d, skipping it.", versionID)
+			continue
+		}
+		var entry models.DatabaseEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			log.WithError(err).Warnf("Failed to unmarshal JSON for version %d, skipping it.", versionID)
+			continue
+		}
+		matchCount++
+		printSearchMatch(tw, entry, versionIDStr)
+	}
+	return matchCount, nil
+}
+
+// printSearchMatch writes one db search result row.
+func printSearchMatch(tw io.Writer, entry models.DatabaseEntry, versionIDStr string) {
+	_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", //nolint:errcheck
+		entry.ModelName,
+		entry.Version.Name,
+		entry.Filename,
+		entry.Folder,
+		entry.ModelType,
+		entry.Version.BaseModel,
+		entry.Creator.Username,
+		entry.Status, // Added Status field
+		versionIDStr, // Display the version ID
+	)
 }
@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Package-level variables for db diff flags
+var (
+	dbDiffOtherFlag  string
+	dbDiffFormatFlag string
+)
+
+func init() {
+	dbCmd.AddCommand(dbDiffCmd)
+
+	dbDiffCmd.Flags().StringVar(&dbDiffOtherFlag, "other", "", "Path to the database to compare against (required)")
+	dbDiffCmd.Flags().StringVar(&dbDiffFormatFlag, "format", "table", "Output format: table or json")
+	_ = dbDiffCmd.MarkFlagRequired("other")
+}
+
+// dbDiffCmd compares the configured database with another one
+var dbDiffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the database with another database",
+	Long: `Lists model versions present in only one of the two databases, and versions
+present in both whose status or file hash differs. Both databases are read through
+a migrated copy and left unchanged.
+
+Examples:
+  # Compare with a database copied from another machine
+  civitai-downloader db diff --other /mnt/nas/civitai.db
+
+  # Machine readable output
+  civitai-downloader db diff --other other.db --format json`,
+	Run: runDbDiff,
+}
+
+// dbDiffEntry describes one version in a db diff. The Other* fields are only
+// set for versions present in both databases.
+type dbDiffEntry struct {
+	ModelName   string `json:"modelName"`
+	VersionName string `json:"versionName"`
+	Filename    string `json:"filename"`
+	Status      string `json:"status"`
+	Hash        string `json:"hash,omitempty"`
+	OtherStatus string `json:"otherStatus,omitempty"`
+	OtherHash   string `json:"otherHash,omitempty"`
+	ModelID     int    `json:"modelId"`
+	VersionID   int    `json:"versionId"`
+}
+
+// dbDiffResult holds the differences between two databases, each list sorted by version ID.
+type dbDiffResult struct {
+	OnlyInThis  []dbDiffEntry `json:"onlyInThis"`
+	OnlyInOther []dbDiffEntry `json:"onlyInOther"`
+	Different   []dbDiffEntry `json:"different"`
+}
+
+func runDbDiff(cmd *cobra.Command, args []string) {
+	if dbDiffFormatFlag != "table" && dbDiffFormatFlag != "json" {
+		log.Fatalf("Invalid --format %q: must be table or json", dbDiffFormatFlag)
+	}
+	if globalConfig.DatabasePath == "" {
+		log.Fatal("Database path is not set in the configuration. Please check config file or path.")
+	}
+
+	this, err := loadDiffEntries(globalConfig.DatabasePath)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to read database")
+	}
+	other, err := loadDiffEntries(dbDiffOtherFlag)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to read other database")
+	}
+
+	result := diffDatabases(this, other)
+	if dbDiffFormatFlag == "json" {
+		out, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.WithError(err).Fatal("Failed to encode diff")
+		}
+		fmt.Println(string(out))
+		return
+	}
+	printDbDiff(os.Stdout, result, globalConfig.DatabasePath, dbDiffOtherFlag)
+}
+
+// loadDiffEntries reads every version entry of the database at path, keyed by
+// version ID. It reads a migrated copy, so older databases can be compared.
+func loadDiffEntries(path string) (map[int]models.DatabaseEntry, error) {
+	db, err := database.OpenCopy(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = db.Close() }()
+
+	entries := make(map[int]models.DatabaseEntry)
+	err = db.Fold(func(key []byte, value []byte) error {
+		keyStr := string(key)
+		if !strings.HasPrefix(keyStr, "v_") {
+			return nil
+		}
+		var entry models.DatabaseEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			log.WithError(err).Warnf("Failed to unmarshal JSON for key %s in %s", keyStr, path)
+			return nil
+		}
+		entries[entry.Version.ID] = entry
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scanning %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// diffDatabases compares the entries of two databases by version ID.
+func diffDatabases(this, other map[int]models.DatabaseEntry) dbDiffResult {
+	result := dbDiffResult{
+		OnlyInThis:  []dbDiffEntry{},
+		OnlyInOther: []dbDiffEntry{},
+		Different:   []dbDiffEntry{},
+	}
+
+	for versionID, entry := range this {
+		otherEntry, ok := other[versionID]
+		if !ok {
+			result.OnlyInThis = append(result.OnlyInThis, newDbDiffEntry(entry))
+			continue
+		}
+		if entry.Status != otherEntry.Status || entryFileHash(entry) != entryFileHash(otherEntry) {
+			diff := newDbDiffEntry(entry)
+			diff.OtherStatus = otherEntry.Status
+			diff.OtherHash = entryFileHash(otherEntry)
+			result.Different = append(result.Different, diff)
+		}
+	}
+	for versionID, entry := range other {
+		if _, ok := this[versionID]; !ok {
+			result.OnlyInOther = append(result.OnlyInOther, newDbDiffEntry(entry))
+		}
+	}
+
+	for _, list := range [][]dbDiffEntry{result.OnlyInThis, result.OnlyInOther, result.Different} {
+		sort.Slice(list, func(i, j int) bool { return list[i].VersionID < list[j].VersionID })
+	}
+	return result
+}
+
+func newDbDiffEntry(entry models.DatabaseEntry) dbDiffEntry {
+	return dbDiffEntry{
+		ModelName:   entry.ModelName,
+		VersionName: entry.Version.Name,
+		Filename:    entry.Filename,
+		Status:      entry.Status,
+		Hash:        entryFileHash(entry),
+		ModelID:     entry.ModelID,
+		VersionID:   entry.Version.ID,
+	}
+}
+
+// entryFileHash returns the hash used to tell whether two entries hold the same
+// file. CRC32 is what the download command compares, SHA256 is the fallback.
+func entryFileHash(entry models.DatabaseEntry) string {
+	if entry.File.Hashes.CRC32 != "" {
+		return strings.ToUpper(entry.File.Hashes.CRC32)
+	}
+	return strings.ToUpper(entry.File.Hashes.SHA256)
+}
+
+// printDbDiff writes the diff as tables, one section per kind of difference.
+func printDbDiff(w io.Writer, result dbDiffResult, thisPath, otherPath string) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	printSection := func(title string, entries []dbDiffEntry) {
+		_, _ = fmt.Fprintf(tw, "\n%s (%d)\n", title, len(entries))
+		if len(entries) == 0 {
+			return
+		}
+		_, _ = fmt.Fprintln(tw, "Version ID\tModel ID\tModel Name\tVersion Name\tFilename\tStatus")
+		for _, e := range entries {
+			_, _ = fmt.Fprintf(tw, "%d\t%d\t%s\t%s\t%s\t%s\n", e.VersionID, e.ModelID, e.ModelName, e.VersionName, e.Filename, e.Status)
+		}
+	}
+	printSection("Only in "+thisPath, result.OnlyInThis)
+	printSection("Only in "+otherPath, result.OnlyInOther)
+
+	_, _ = fmt.Fprintf(tw, "\nDifferent status or file hash (%d)\n", len(result.Different))
+	if len(result.Different) > 0 {
+		_, _ = fmt.Fprintln(tw, "Version ID\tModel Name\tStatus\tOther Status\tHash\tOther Hash")
+		for _, e := range result.Different {
+			_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\n", e.VersionID, e.ModelName, e.Status, e.OtherStatus, e.Hash, e.OtherHash)
+		}
+	}
+
+	if err := tw.Flush(); err != nil {
+		log.WithError(err).Error("Error flushing table writer for db diff")
+	}
+}
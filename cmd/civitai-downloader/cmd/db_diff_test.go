@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func diffTestEntry(versionID int, status, crc string) models.DatabaseEntry {
+	file := models.File{ID: versionID, Name: "model.safetensors", Primary: true, Hashes: models.Hashes{CRC32: crc}}
+	return models.DatabaseEntry{
+		ModelID:   versionID / 10,
+		ModelName: "Model",
+		Filename:  "model.safetensors",
+		Status:    status,
+		File:      file,
+		Version:   models.ModelVersion{ID: versionID, Name: "v1", Files: []models.File{file}},
+	}
+}
+
+func writeDiffTestDB(t *testing.T, path string, entries ...models.DatabaseEntry) {
+	t.Helper()
+	db, err := database.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	for _, entry := range entries {
+		data, err := json.Marshal(entry)
+		require.NoError(t, err)
+		require.NoError(t, db.Put([]byte(fmt.Sprintf("v_%d", entry.Version.ID)), data))
+	}
+}
+
+func TestDiffDatabases(t *testing.T) {
+	dir := t.TempDir()
+	thisPath := filepath.Join(dir, "this.db")
+	otherPath := filepath.Join(dir, "other.db")
+	writeDiffTestDB(t, thisPath,
+		diffTestEntry(10, models.StatusDownloaded, "AAAA"),
+		diffTestEntry(20, models.StatusDownloaded, "BBBB"),
+		diffTestEntry(30, models.StatusDownloaded, "CCCC"),
+		diffTestEntry(40, models.StatusDownloaded, "DDDD"),
+	)
+	writeDiffTestDB(t, otherPath,
+		diffTestEntry(20, models.StatusDownloaded, "BBBB"), // identical
+		diffTestEntry(30, models.StatusError, "CCCC"),      // status differs
+		diffTestEntry(40, models.StatusDownloaded, "EEEE"), // file changed
+		diffTestEntry(50, models.StatusDownloaded, "FFFF"),
+	)
+
+	this, err := loadDiffEntries(thisPath)
+	require.NoError(t, err)
+	other, err := loadDiffEntries(otherPath)
+	require.NoError(t, err)
+	result := diffDatabases(this, other)
+
+	require.Len(t, result.OnlyInThis, 1)
+	assert.Equal(t, 10, result.OnlyInThis[0].VersionID)
+	require.Len(t, result.OnlyInOther, 1)
+	assert.Equal(t, 50, result.OnlyInOther[0].VersionID)
+	assert.Equal(t, 5, result.OnlyInOther[0].ModelID)
+
+	require.Len(t, result.Different, 2)
+	assert.Equal(t, 30, result.Different[0].VersionID)
+	assert.Equal(t, models.StatusError, result.Different[0].OtherStatus)
+	assert.Equal(t, 40, result.Different[1].VersionID)
+	assert.Equal(t, "DDDD", result.Different[1].Hash)
+	assert.Equal(t, "EEEE", result.Different[1].OtherHash)
+
+	var out bytes.Buffer
+	printDbDiff(&out, result, thisPath, otherPath)
+	assert.Contains(t, out.String(), "Only in "+otherPath+" (1)")
+	assert.Contains(t, out.String(), "Different status or file hash (2)")
+
+	_, err = loadDiffEntries(filepath.Join(dir, "missing.db"))
+	assert.Error(t, err, "a missing database must not be created")
+}
+
+func TestLoadDiffEntries_OldSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	writeDiffTestDB(t, path, diffTestEntry(10, models.StatusDownloaded, "AAAA"))
+	dropTestColumn(t, path, "attempt_count")
+
+	entries, err := loadDiffEntries(path)
+	require.NoError(t, err)
+	require.Contains(t, entries, 10)
+	assert.Equal(t, models.StatusDownloaded, entries[10].Status)
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Package-level variables for db export flags
+var (
+	dbExportFormatFlag string
+	dbExportOutputFlag string
+)
+
+func init() {
+	dbCmd.AddCommand(dbExportCmd)
+
+	dbExportCmd.Flags().StringVar(&dbExportFormatFlag, "format", "json", "Output format: json or csv")
+	dbExportCmd.Flags().StringVarP(&dbExportOutputFlag, "output", "o", "", "File to write the export to (default: stdout)")
+}
+
+// dbExportCmd writes the version entries of the database to a JSON or CSV file
+var dbExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Export the database entries as JSON or CSV",
+	Long: `Writes every model version entry in the database as a JSON array of the full
+entries, or as CSV with one row per version for spreadsheets. Entries are
+ordered by version ID. Only the database is read.
+
+Examples:
+  # Inventory for a spreadsheet
+  civitai-downloader db export --format csv --output inventory.csv
+
+  # Everything, for scripts
+  civitai-downloader db export | jq '.[] | select(.status == "Error")'`,
+	Run: runDbExport,
+}
+
+// dbExportCSVHeader are the columns of db export --format csv.
+var dbExportCSVHeader = []string{
+	"ModelID", "VersionID", "ModelName", "VersionName", "Filename", "Folder",
+	"ModelType", "BaseModel", "Creator", "Status", "SizeKB", "SHA256",
+}
+
+func runDbExport(cmd *cobra.Command, args []string) {
+	format := strings.ToLower(strings.TrimSpace(dbExportFormatFlag))
+	if format != "json" && format != "csv" {
+		log.Fatalf("Invalid --format %q (expected json or csv)", dbExportFormatFlag)
+	}
+	if globalConfig.DatabasePath == "" {
+		log.Fatal("Database path is not set in the configuration. Please check config file or path.")
+	}
+
+	db, err := database.OpenReadOnly(globalConfig.DatabasePath)
+	if err != nil {
+		log.WithError(err).Fatalf("Failed to open database at %s", globalConfig.DatabasePath)
+	}
+	defer func() { _ = db.Close() }()
+
+	entries, err := loadExportEntries(db)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to read database")
+	}
+
+	var w io.Writer = os.Stdout
+	if dbExportOutputFlag != "" && dbExportOutputFlag != "-" {
+		f, err := os.Create(dbExportOutputFlag) // #nosec G304 -- path is the user's --output argument
+		if err != nil {
+			log.WithError(err).Fatalf("Failed to create %s", dbExportOutputFlag)
+		}
+		defer func() {
+			if err := f.Close(); err != nil {
+				log.WithError(err).Errorf("Failed to close %s", dbExportOutputFlag)
+			}
+		}()
+		w = f
+	}
+
+	if format == "csv" {
+		err = writeExportCSV(w, entries)
+	} else {
+		err = writeExportJSON(w, entries)
+	}
+	if err != nil {
+		log.WithError(err).Fatal("Failed to write the export")
+	}
+	if w != os.Stdout {
+		log.Infof("Exported %d entries to %s", len(entries), dbExportOutputFlag)
+	}
+}
+
+// loadExportEntries returns every version entry of db, sorted by version ID.
+func loadExportEntries(db *database.DB) ([]models.DatabaseEntry, error) {
+	entries := []models.DatabaseEntry{}
+	err := db.Fold(func(key []byte, value []byte) error {
+		keyStr := string(key)
+		if !strings.HasPrefix(keyStr, "v_") {
+			return nil
+		}
+		var entry models.DatabaseEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			log.WithError(err).Warnf("Failed to unmarshal JSON for key %s", keyStr)
+			return nil
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Version.ID < entries[j].Version.ID
+	})
+	return entries, nil
+}
+
+// writeExportJSON writes entries as an indented JSON array.
+func writeExportJSON(w io.Writer, entries []models.DatabaseEntry) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
+
+// writeExportCSV writes entries as CSV with the dbExportCSVHeader columns.
+func writeExportCSV(w io.Writer, entries []models.DatabaseEntry) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(dbExportCSVHeader); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		record := []string{
+			strconv.Itoa(entry.ModelID),
+			strconv.Itoa(entry.Version.ID),
+			entry.ModelName,
+			entry.Version.Name,
+			entry.Filename,
+			entry.Folder,
+			entry.ModelType,
+			entry.Version.BaseModel,
+			entry.Creator.Username,
+			entry.Status,
+			strconv.FormatFloat(entry.File.SizeKB, 'f', -1, 64),
+			entry.File.Hashes.SHA256,
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("writing version %d: %w", entry.Version.ID, err)
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
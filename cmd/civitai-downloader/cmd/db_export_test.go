@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDbExport(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	withDetails := diffTestEntry(10, models.StatusDownloaded, "AAAA")
+	withDetails.ModelName = "Cool, \"quoted\" LoRA"
+	withDetails.ModelType = "LORA"
+	withDetails.Folder = "lora/cool"
+	withDetails.Creator.Username = "alice"
+	withDetails.Version.BaseModel = "SDXL 1.0"
+	withDetails.File.SizeKB = 1536.5
+	withDetails.File.Hashes.SHA256 = "ABCDEF"
+	withDetails.Version.Files = []models.File{withDetails.File}
+	writeDiffTestDB(t, path,
+		diffTestEntry(20, models.StatusError, "BBBB"),
+		withDetails,
+	)
+
+	db, err := database.OpenReadOnly(path)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	entries, err := loadExportEntries(db)
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, 10, entries[0].Version.ID, "sorted by version ID")
+
+	var csvOut bytes.Buffer
+	require.NoError(t, writeExportCSV(&csvOut, entries))
+	assert.Equal(t, "ModelID,VersionID,ModelName,VersionName,Filename,Folder,ModelType,BaseModel,Creator,Status,SizeKB,SHA256\n"+
+		"1,10,\"Cool, \"\"quoted\"\" LoRA\",v1,model.safetensors,lora/cool,LORA,SDXL 1.0,alice,Downloaded,1536.5,ABCDEF\n"+
+		"2,20,Model,v1,model.safetensors,,,,,Error,0,\n", csvOut.String())
+
+	var jsonOut bytes.Buffer
+	require.NoError(t, writeExportJSON(&jsonOut, entries))
+	var decoded []models.DatabaseEntry
+	require.NoError(t, json.Unmarshal(jsonOut.Bytes(), &decoded))
+	require.Len(t, decoded, 2)
+	assert.Equal(t, "alice", decoded[0].Creator.Username)
+	assert.Equal(t, models.StatusError, decoded[1].Status)
+}
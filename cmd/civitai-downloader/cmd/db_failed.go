@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// reasonDownloadError is the verificationProblem reason for entries retried by db failed.
+const reasonDownloadError = "Download Error"
+
+// Package-level variables for db failed flags
+var (
+	dbFailedRetryFlag bool
+	dbFailedJSONFlag  bool
+)
+
+func init() {
+	dbCmd.AddCommand(dbFailedCmd)
+
+	dbFailedCmd.Flags().BoolVar(&dbFailedRetryFlag, "retry", false, "Redownload every failed entry after listing them")
+	dbFailedCmd.Flags().BoolVar(&dbFailedJSONFlag, "json", false, "Print the failed entries as JSON")
+}
+
+// dbFailedCmd lists the database entries whose download failed
+var dbFailedCmd = &cobra.Command{
+	Use:   "failed",
+	Short: "List database entries whose download failed",
+	Long: `Lists only the database entries with status Error, with the model, version,
+error details and number of failed attempts. Use --retry to redownload all of them.
+
+Examples:
+  # Triage after a big run
+  civitai-downloader db failed
+
+  # Retry everything that failed
+  civitai-downloader db failed --retry
+
+  # For scripts
+  civitai-downloader db failed --json`,
+	Run: runDbFailed,
+}
+
+// dbFailedEntry is one failed download as listed by db failed.
+type dbFailedEntry struct {
+	ModelName    string `json:"modelName"`
+	VersionName  string `json:"versionName"`
+	Filename     string `json:"filename"`
+	Folder       string `json:"folder"`
+	ErrorDetails string `json:"errorDetails"`
+	dbKey        string
+	entry        models.DatabaseEntry
+	ModelID      int `json:"modelId"`
+	VersionID    int `json:"versionId"`
+	AttemptCount int `json:"attemptCount"`
+}
+
+func runDbFailed(cmd *cobra.Command, args []string) {
+	if globalConfig.DatabasePath == "" {
+		log.Fatal("Database path is not set in the configuration. Please check config file or path.")
+	}
+
+	db, err := database.Open(globalConfig.DatabasePath)
+	if err != nil {
+		log.WithError(err).Fatalf("Failed to open database at %s", globalConfig.DatabasePath)
+	}
+	defer func() { _ = db.Close() }()
+
+	failed, err := loadFailedEntries(db)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to read database")
+	}
+
+	if dbFailedJSONFlag {
+		out, err := json.MarshalIndent(failed, "", "  ")
+		if err != nil {
+			log.WithError(err).Fatal("Failed to encode failed entries")
+		}
+		fmt.Println(string(out))
+	} else {
+		printFailedEntries(os.Stdout, failed)
+	}
+
+	if !dbFailedRetryFlag || len(failed) == 0 {
+		return
+	}
+	if globalConfig.SavePath == "" {
+		log.Fatal("Save path is not set in the configuration. Please check config file or path.")
+	}
+
+	problems := make([]verificationProblem, 0, len(failed))
+	for _, f := range failed {
+		problems = append(problems, verificationProblem{Reason: reasonDownloadError, DbKey: f.dbKey, Entry: f.entry})
+	}
+	log.Infof("Retrying %d failed download(s)...", len(problems))
+	var fileDownloader *downloader.Downloader
+	stats := processRedownloadRequests(db, problems, &fileDownloader, nil, true)
+	logRedownloadSummary(stats)
+}
+
+// loadFailedEntries returns the entries with status Error, sorted by model name and version ID.
+func loadFailedEntries(db *database.DB) ([]dbFailedEntry, error) {
+	failed := []dbFailedEntry{}
+	err := db.Fold(func(key []byte, value []byte) error {
+		keyStr := string(key)
+		if !strings.HasPrefix(keyStr, "v_") {
+			return nil
+		}
+		var entry models.DatabaseEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			log.WithError(err).Warnf("Failed to unmarshal JSON for key %s", keyStr)
+			return nil
+		}
+		if entry.Status != models.StatusError {
+			return nil
+		}
+		failed = append(failed, dbFailedEntry{
+			ModelName:    entry.ModelName,
+			VersionName:  entry.Version.Name,
+			Filename:     entry.Filename,
+			Folder:       entry.Folder,
+			ErrorDetails: entry.ErrorDetails,
+			dbKey:        keyStr,
+			entry:        entry,
+			ModelID:      entry.ModelID,
+			VersionID:    entry.Version.ID,
+			AttemptCount: entry.AttemptCount,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(failed, func(i, j int) bool {
+		if failed[i].ModelName != failed[j].ModelName {
+			return strings.ToLower(failed[i].ModelName) < strings.ToLower(failed[j].ModelName)
+		}
+		return failed[i].VersionID < failed[j].VersionID
+	})
+	return failed, nil
+}
+
+// printFailedEntries writes the failed entries as a table.
+func printFailedEntries(w io.Writer, failed []dbFailedEntry) {
+	if len(failed) == 0 {
+		_, _ = fmt.Fprintln(w, "No failed downloads in the database.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "Version ID\tModel Name\tVersion Name\tFilename\tAttempts\tError")
+	_, _ = fmt.Fprintln(tw, "----------\t----------\t------------\t--------\t--------\t-----")
+	for _, f := range failed {
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%d\t%s\n", f.VersionID, f.ModelName, f.VersionName, f.Filename, f.AttemptCount, f.ErrorDetails)
+	}
+	if err := tw.Flush(); err != nil {
+		log.WithError(err).Error("Error flushing table writer for db failed")
+	}
+	_, _ = fmt.Fprintf(w, "\n%d failed download(s).\n", len(failed))
+}
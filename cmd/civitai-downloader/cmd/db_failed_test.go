@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadFailedEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	failedB := diffTestEntry(20, models.StatusError, "BBBB")
+	failedB.ModelName = "beta"
+	failedB.ErrorDetails = "HTTP 404"
+	failedB.AttemptCount = 3
+	failedA := diffTestEntry(30, models.StatusError, "CCCC")
+	failedA.ModelName = "Alpha"
+	writeDiffTestDB(t, path,
+		diffTestEntry(10, models.StatusDownloaded, "AAAA"),
+		failedB,
+		failedA,
+		diffTestEntry(40, models.StatusPending, "DDDD"),
+	)
+
+	db, err := database.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	failed, err := loadFailedEntries(db)
+	require.NoError(t, err)
+	require.Len(t, failed, 2)
+	assert.Equal(t, 30, failed[0].VersionID, "sorted by model name, case-insensitively")
+	assert.Equal(t, "v_30", failed[0].dbKey)
+	assert.Equal(t, 20, failed[1].VersionID)
+	assert.Equal(t, "HTTP 404", failed[1].ErrorDetails)
+	assert.Equal(t, 3, failed[1].AttemptCount)
+
+	var out bytes.Buffer
+	printFailedEntries(&out, failed)
+	assert.Contains(t, out.String(), "HTTP 404")
+	assert.Contains(t, out.String(), "2 failed download(s).")
+
+	out.Reset()
+	printFailedEntries(&out, nil)
+	assert.Contains(t, out.String(), "No failed downloads")
+}
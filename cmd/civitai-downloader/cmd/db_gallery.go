@@ -0,0 +1,357 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// galleryIndexName is the file name of every generated gallery page.
+const galleryIndexName = "index.html"
+
+// Package-level variables for db gallery flags
+var (
+	dbGalleryOutputDirFlag string
+)
+
+func init() {
+	dbCmd.AddCommand(dbGalleryCmd)
+
+	dbGalleryCmd.Flags().StringVarP(&dbGalleryOutputDirFlag, "output-dir", "o", "", "Write the pages here instead of into the model folders under SavePath")
+}
+
+// dbGalleryCmd builds static HTML pages for browsing the downloaded models offline
+var dbGalleryCmd = &cobra.Command{
+	Use:   "gallery",
+	Short: "Generate static index.html pages for browsing downloaded models offline",
+	Long: `Builds an index.html per downloaded model listing its versions, files (with
+sizes and hashes), trained words and the preview images saved next to them,
+plus a top-level index.html linking every model. The pages are plain static
+files: open them in a browser, no server needed.
+
+By default each model page is written to a <modelId>-<slug> folder inside the
+model's folder (the folder shared by its versions) and the top-level index into
+SavePath. With --output-dir the model folders go there instead and link back to
+the files under SavePath.
+
+Examples:
+  # Turn the download folder into a browsable mirror
+  civitai-downloader db gallery
+
+  # Keep the pages out of the download folder
+  civitai-downloader db gallery --output-dir ~/civitai-gallery`,
+	Run: runDbGallery,
+}
+
+// galleryModel is one model page of the gallery.
+type galleryModel struct {
+	Name     string
+	Type     string
+	Creator  string
+	PageDir  string // Directory the page is written to
+	Link     string // Page URL relative to the top-level index
+	Versions []galleryVersion
+	ID       int
+}
+
+// galleryVersion is one downloaded version on a model page.
+type galleryVersion struct {
+	Name         string
+	BaseModel    string
+	PublishedAt  string
+	Dir          string // Absolute version folder
+	ImagesDir    string // Folder of the version images, under MetadataSavePath if set
+	TrainedWords []string
+	Files        []galleryFile
+	Images       []string // Image URLs relative to the model page
+	ID           int
+}
+
+// galleryFile is a downloaded file of a version.
+type galleryFile struct {
+	Name   string
+	Size   string
+	SHA256 string
+	CRC32  string
+	Link   string // URL relative to the model page
+}
+
+func runDbGallery(cmd *cobra.Command, args []string) {
+	if globalConfig.DatabasePath == "" {
+		log.Fatal("Database path is not set in the configuration. Please check config file or path.")
+	}
+	if globalConfig.SavePath == "" {
+		log.Fatal("Save path is not set in the configuration. Please check config file or path.")
+	}
+
+	db, err := database.Open(globalConfig.DatabasePath)
+	if err != nil {
+		log.WithError(err).Fatalf("Failed to open database at %s", globalConfig.DatabasePath)
+	}
+	defer func() { _ = db.Close() }()
+
+	galleryModels, err := loadGalleryModels(db, globalConfig.SavePath, metadataRoot(&globalConfig))
+	if err != nil {
+		log.WithError(err).Fatal("Failed to read database")
+	}
+	if len(galleryModels) == 0 {
+		log.Info("No downloaded models in the database, nothing to do.")
+		return
+	}
+
+	indexPath, err := writeGallery(galleryModels, globalConfig.SavePath, dbGalleryOutputDirFlag)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to write gallery")
+	}
+	log.Infof("Wrote gallery pages for %d model(s). Open %s in a browser.", len(galleryModels), indexPath)
+}
+
+// loadGalleryModels groups the downloaded entries by model, sorted by model name
+// with the newest versions first. Images are looked up under metadataPath.
+func loadGalleryModels(db *database.DB, savePath, metadataPath string) ([]*galleryModel, error) {
+	byID := make(map[int]*galleryModel)
+	err := db.Fold(func(key []byte, value []byte) error {
+		keyStr := string(key)
+		if !strings.HasPrefix(keyStr, "v_") {
+			return nil
+		}
+		var entry models.DatabaseEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			log.WithError(err).Warnf("Failed to unmarshal JSON for key %s", keyStr)
+			return nil
+		}
+		if entry.Status != models.StatusDownloaded {
+			return nil
+		}
+
+		model, ok := byID[entry.ModelID]
+		if !ok {
+			model = &galleryModel{ID: entry.ModelID, Name: entry.ModelName, Type: entry.ModelType, Creator: entry.Creator.Username}
+			byID[entry.ModelID] = model
+		}
+		model.Versions = append(model.Versions, galleryVersionFromEntry(entry, savePath, metadataPath))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	galleryModels := make([]*galleryModel, 0, len(byID))
+	for _, model := range byID {
+		sort.Slice(model.Versions, func(i, j int) bool { return model.Versions[i].ID > model.Versions[j].ID })
+		galleryModels = append(galleryModels, model)
+	}
+	sort.Slice(galleryModels, func(i, j int) bool {
+		if !strings.EqualFold(galleryModels[i].Name, galleryModels[j].Name) {
+			return strings.ToLower(galleryModels[i].Name) < strings.ToLower(galleryModels[j].Name)
+		}
+		return galleryModels[i].ID < galleryModels[j].ID
+	})
+	return galleryModels, nil
+}
+
+func galleryVersionFromEntry(entry models.DatabaseEntry, savePath, metadataPath string) galleryVersion {
+	version := galleryVersion{
+		ID:           entry.Version.ID,
+		Name:         entry.Version.Name,
+		BaseModel:    entry.Version.BaseModel,
+		TrainedWords: entry.Version.TrainedWords,
+		Dir:          filepath.Join(savePath, entry.Folder),
+		ImagesDir:    filepath.Join(metadataPath, entry.Folder, "images"),
+	}
+	if len(entry.Version.PublishedAt) >= len("2006-01-02") {
+		version.PublishedAt = entry.Version.PublishedAt[:len("2006-01-02")]
+	}
+
+	size := uint64(entry.File.SizeKB * 1024)
+	if info, err := os.Stat(filepath.Join(version.Dir, entry.Filename)); err == nil {
+		size = uint64(info.Size()) // #nosec G115 -- file sizes are never negative
+	}
+	version.Files = []galleryFile{{
+		Name:   entry.Filename,
+		Size:   helpers.BytesToSize(size),
+		SHA256: entry.File.Hashes.SHA256,
+		CRC32:  entry.File.Hashes.CRC32,
+	}}
+	return version
+}
+
+// writeGallery writes a page per model and the top-level index, returning the index path.
+func writeGallery(galleryModels []*galleryModel, savePath, outputDir string) (string, error) {
+	indexDir := savePath
+	if outputDir != "" {
+		indexDir = outputDir
+	}
+
+	for _, model := range galleryModels {
+		// The model ID keeps apart models whose names give the same folder
+		pageDirName := strconv.Itoa(model.ID) + "-" + helpers.ConvertToSlug(model.Name)
+		if outputDir != "" {
+			model.PageDir = filepath.Join(outputDir, pageDirName)
+		} else {
+			dirs := make([]string, 0, len(model.Versions))
+			for _, version := range model.Versions {
+				dirs = append(dirs, version.Dir)
+			}
+			model.PageDir = filepath.Join(commonDir(dirs), pageDirName)
+		}
+		model.Link = relURL(indexDir, filepath.Join(model.PageDir, galleryIndexName))
+
+		for i := range model.Versions {
+			version := &model.Versions[i]
+			for j := range version.Files {
+				version.Files[j].Link = relURL(model.PageDir, filepath.Join(version.Dir, version.Files[j].Name))
+			}
+			version.Images = nil
+			for _, image := range galleryImages(version.ImagesDir) {
+				version.Images = append(version.Images, relURL(model.PageDir, image))
+			}
+		}
+
+		if err := writeGalleryPage(filepath.Join(model.PageDir, galleryIndexName), galleryModelTemplate, model); err != nil {
+			return "", err
+		}
+	}
+
+	indexPath := filepath.Join(indexDir, galleryIndexName)
+	if err := writeGalleryPage(indexPath, galleryIndexTemplate, galleryModels); err != nil {
+		return "", err
+	}
+	return indexPath, nil
+}
+
+func writeGalleryPage(path string, tmpl *template.Template, data interface{}) error {
+	if err := os.MkdirAll(helpers.LongPath(filepath.Dir(path)), 0750); err != nil {
+		return fmt.Errorf("creating %s: %w", filepath.Dir(path), err)
+	}
+	f, err := os.Create(helpers.LongPath(path)) // #nosec G304 -- path is built from SavePath or --output-dir
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", path, err)
+	}
+	if err := tmpl.Execute(f, data); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("writing %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// galleryImages returns the image files in dir, sorted by name.
+func galleryImages(dir string) []string {
+	entries, err := os.ReadDir(helpers.LongPath(dir))
+	if err != nil {
+		return nil
+	}
+	var images []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".jpg", ".jpeg", ".png", ".webp", ".gif", ".avif":
+			images = append(images, filepath.Join(dir, entry.Name()))
+		}
+	}
+	sort.Strings(images)
+	return images
+}
+
+// commonDir returns the deepest directory containing all of dirs.
+func commonDir(dirs []string) string {
+	if len(dirs) == 0 {
+		return ""
+	}
+	common := filepath.Clean(dirs[0])
+	for _, dir := range dirs[1:] {
+		dir = filepath.Clean(dir)
+		for common != dir && !strings.HasPrefix(dir, common+string(filepath.Separator)) {
+			parent := filepath.Dir(common)
+			if parent == common {
+				break
+			}
+			common = parent
+		}
+	}
+	return common
+}
+
+// relURL returns target relative to fromDir as a URL path, falling back to a
+// file:// URL when there is no relative path (e.g. another drive on Windows).
+func relURL(fromDir, target string) string {
+	rel, err := filepath.Rel(fromDir, target)
+	if err != nil {
+		abs, absErr := filepath.Abs(target)
+		if absErr != nil {
+			abs = target
+		}
+		return (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String()
+	}
+	segments := strings.Split(filepath.ToSlash(rel), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+const galleryStyle = `<style>
+body { font-family: sans-serif; margin: 2em; background: #fafafa; color: #222; }
+table { border-collapse: collapse; margin: 0.5em 0; }
+td, th { border: 1px solid #ccc; padding: 0.3em 0.6em; text-align: left; }
+code { font-size: 0.85em; }
+.images img { height: 200px; margin: 0.2em; object-fit: cover; }
+.version { border-top: 1px solid #ccc; margin-top: 1.5em; }
+</style>`
+
+var galleryIndexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Civitai archive</title>
+` + galleryStyle + `
+</head>
+<body>
+<h1>Civitai archive</h1>
+<p>{{len .}} model(s)</p>
+<table>
+<tr><th>Model</th><th>Type</th><th>Creator</th><th>Versions</th></tr>
+{{range .}}<tr><td><a href="{{.Link}}">{{.Name}}</a></td><td>{{.Type}}</td><td>{{.Creator}}</td><td>{{len .Versions}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+var galleryModelTemplate = template.Must(template.New("model").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}</title>
+` + galleryStyle + `
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<p>{{if .Type}}{{.Type}} &middot; {{end}}{{if .Creator}}by {{.Creator}} &middot; {{end}}<a href="https://civitai.com/models/{{.ID}}">civitai.com/models/{{.ID}}</a></p>
+{{range .Versions}}<div class="version">
+<h2>{{.Name}}</h2>
+<p>Version {{.ID}}{{if .BaseModel}} &middot; {{.BaseModel}}{{end}}{{if .PublishedAt}} &middot; published {{.PublishedAt}}{{end}}</p>
+{{if .TrainedWords}}<p>Trained words: {{range $i, $w := .TrainedWords}}{{if $i}}, {{end}}<code>{{$w}}</code>{{end}}</p>
+{{end}}<table>
+<tr><th>File</th><th>Size</th><th>SHA256</th><th>CRC32</th></tr>
+{{range .Files}}<tr><td><a href="{{.Link}}">{{.Name}}</a></td><td>{{.Size}}</td><td><code>{{.SHA256}}</code></td><td><code>{{.CRC32}}</code></td></tr>
+{{end}}</table>
+{{if .Images}}<div class="images">{{range .Images}}<a href="{{.}}"><img src="{{.}}" loading="lazy" alt=""></a>{{end}}</div>
+{{end}}</div>
+{{end}}</body>
+</html>
+`))
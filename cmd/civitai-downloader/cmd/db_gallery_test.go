@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteGallery(t *testing.T) {
+	dir := t.TempDir()
+	savePath := filepath.Join(dir, "downloads")
+	dbPath := filepath.Join(dir, "test.db")
+
+	older := diffTestEntry(100, models.StatusDownloaded, "AAAA")
+	older.ModelName = "My <Model>"
+	older.Folder = filepath.Join("lora", "my-model", "v1")
+	older.File.Hashes.SHA256 = "ABCDEF"
+	older.Version.Files = []models.File{older.File}
+	older.Version.TrainedWords = []string{"trigger word"}
+	newer := diffTestEntry(101, models.StatusDownloaded, "BBBB")
+	newer.ModelName = older.ModelName
+	newer.Folder = filepath.Join("lora", "my-model", "v2")
+	newer.Version.Name = "v2"
+	failed := diffTestEntry(200, models.StatusError, "CCCC")
+	writeDiffTestDB(t, dbPath, older, newer, failed)
+
+	imagesDir := filepath.Join(savePath, older.Folder, "images")
+	require.NoError(t, os.MkdirAll(imagesDir, 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(imagesDir, "1 a.jpeg"), []byte("img"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(imagesDir, "1.json"), []byte("{}"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(savePath, older.Folder, older.Filename), []byte("model"), 0600))
+
+	db, err := database.Open(dbPath)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	galleryModels, err := loadGalleryModels(db, savePath, savePath)
+	require.NoError(t, err)
+	require.Len(t, galleryModels, 1, "only downloaded entries are listed")
+	require.Len(t, galleryModels[0].Versions, 2)
+	assert.Equal(t, 101, galleryModels[0].Versions[0].ID, "newest version first")
+
+	indexPath, err := writeGallery(galleryModels, savePath, "")
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(savePath, "index.html"), indexPath)
+
+	page, err := os.ReadFile(filepath.Join(savePath, "lora", "my-model", "10-my_model", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), "My &lt;Model&gt;")
+	assert.Contains(t, string(page), `href="../v1/model.safetensors"`)
+	assert.Contains(t, string(page), `src="../v1/images/1%20a.jpeg"`)
+	assert.NotContains(t, string(page), "1.json")
+	assert.Contains(t, string(page), "trigger word")
+	assert.Contains(t, string(page), "ABCDEF")
+
+	index, err := os.ReadFile(indexPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(index), `href="lora/my-model/10-my_model/index.html"`)
+
+	// With an output dir the pages link back into SavePath
+	outputDir := filepath.Join(dir, "gallery")
+	_, err = writeGallery(galleryModels, savePath, outputDir)
+	require.NoError(t, err)
+	page, err = os.ReadFile(filepath.Join(outputDir, "10-my_model", "index.html"))
+	require.NoError(t, err)
+	assert.Contains(t, string(page), `href="../../downloads/lora/my-model/v1/model.safetensors"`)
+}
+
+func TestWriteGallery_SameFolderModels(t *testing.T) {
+	savePath := t.TempDir()
+	galleryModels := []*galleryModel{
+		{ID: 1, Name: "Model", Versions: []galleryVersion{{ID: 10, Dir: savePath}}},
+		{ID: 2, Name: "Model", Versions: []galleryVersion{{ID: 20, Dir: savePath}}},
+	}
+	_, err := writeGallery(galleryModels, savePath, "")
+	require.NoError(t, err)
+	assert.FileExists(t, filepath.Join(savePath, "1-model", "index.html"))
+	assert.FileExists(t, filepath.Join(savePath, "2-model", "index.html"))
+}
+
+func TestCommonDir(t *testing.T) {
+	assert.Equal(t, filepath.Join("a", "b"), commonDir([]string{filepath.Join("a", "b", "c"), filepath.Join("a", "b", "d")}))
+	assert.Equal(t, filepath.Join("a", "b", "c"), commonDir([]string{filepath.Join("a", "b", "c")}))
+	assert.Equal(t, "a", commonDir([]string{filepath.Join("a", "bc"), filepath.Join("a", "b")}))
+}
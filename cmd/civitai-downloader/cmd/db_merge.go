@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Package-level variables for db merge flags
+var (
+	dbMergeOutputFlag string
+)
+
+func init() {
+	dbCmd.AddCommand(dbMergeCmd)
+
+	dbMergeCmd.Flags().StringVarP(&dbMergeOutputFlag, "output", "o", "", "Path to the database to merge into, created if missing (required)")
+	_ = dbMergeCmd.MarkFlagRequired("output")
+}
+
+// dbMergeCmd merges the version entries of several databases into one
+var dbMergeCmd = &cobra.Command{
+	Use:   "merge SOURCE.db [SOURCE.db...]",
+	Short: "Merge several databases into one",
+	Long: `Copies the model version entries of every source database into the --output
+database, e.g. to consolidate runs made in different directories. When a version
+is in more than one database, a Downloaded entry wins over a Pending or Error
+one, otherwise the entry with the newer timestamp wins. Entries already in the
+output database take part in this as well. The sources are read through a
+migrated copy and left unchanged; only version entries are merged, not pagination or torrent state.
+
+Examples:
+  civitai-downloader db merge old/civitai.db nas/civitai.db --output merged.db`,
+	Args: cobra.MinimumNArgs(1),
+	Run:  runDbMerge,
+}
+
+// dbMergeStats counts what happened to the versions of one source.
+type dbMergeStats struct {
+	Source   string
+	Versions int // Version entries in the source
+	Added    int // Not in the output yet
+	Replaced int // Won a conflict with the entry in the output
+	Kept     int // Lost a conflict, the output entry was kept
+}
+
+func runDbMerge(cmd *cobra.Command, args []string) {
+	for _, source := range args {
+		if samePath(source, dbMergeOutputFlag) {
+			log.Fatalf("Source %s is also the --output database", source)
+		}
+	}
+
+	out, err := database.Open(dbMergeOutputFlag)
+	if err != nil {
+		log.WithError(err).Fatalf("Failed to open output database at %s", dbMergeOutputFlag)
+	}
+	defer func() { _ = out.Close() }()
+
+	var allStats []dbMergeStats
+	for _, source := range args {
+		stats, err := mergeDatabase(out, source)
+		if err != nil {
+			log.WithError(err).Fatalf("Failed to merge %s", source)
+		}
+		allStats = append(allStats, stats)
+	}
+	printDbMergeStats(os.Stdout, allStats, dbMergeOutputFlag)
+}
+
+// mergeDatabase copies the version entries of the database at sourcePath into
+// out, resolving conflicts with preferMergeEntry. The source is read through a
+// migrated copy, so a database written by an older version can be merged.
+func mergeDatabase(out *database.DB, sourcePath string) (dbMergeStats, error) {
+	stats := dbMergeStats{Source: sourcePath}
+	src, err := database.OpenCopy(sourcePath)
+	if err != nil {
+		return stats, err
+	}
+	defer func() { _ = src.Close() }()
+
+	var entries []models.DatabaseEntry
+	err = src.Fold(func(key []byte, value []byte) error {
+		keyStr := string(key)
+		if !strings.HasPrefix(keyStr, "v_") {
+			return nil
+		}
+		var entry models.DatabaseEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			log.WithError(err).Warnf("Failed to unmarshal JSON for key %s in %s", keyStr, sourcePath)
+			return nil
+		}
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return stats, err
+	}
+
+	for _, entry := range entries {
+		stats.Versions++
+		key := []byte(fmt.Sprintf("v_%d", entry.Version.ID))
+
+		existingBytes, err := out.Get(key)
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			stats.Added++
+		case err != nil:
+			return stats, fmt.Errorf("reading %s from the output database: %w", key, err)
+		default:
+			var existing models.DatabaseEntry
+			if err := json.Unmarshal(existingBytes, &existing); err == nil && !preferMergeEntry(entry, existing) {
+				stats.Kept++
+				continue
+			}
+			stats.Replaced++
+		}
+
+		// The raw API JSON is not part of the entry read by Fold
+		if raw, err := src.GetRawJSON(entry.Version.ID); err == nil {
+			entry.RawJSON = raw
+		}
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return stats, fmt.Errorf("encoding %s: %w", key, err)
+		}
+		if err := out.Put(key, data); err != nil {
+			return stats, fmt.Errorf("writing %s: %w", key, err)
+		}
+	}
+	log.Infof("Merged %s: %d versions, %d added, %d replaced, %d kept", sourcePath, stats.Versions, stats.Added, stats.Replaced, stats.Kept)
+	return stats, nil
+}
+
+// preferMergeEntry reports whether candidate should replace existing in a
+// merge: a Downloaded entry wins over any other status, otherwise the newer
+// Timestamp wins. On a tie existing is kept.
+func preferMergeEntry(candidate, existing models.DatabaseEntry) bool {
+	candidateDownloaded := candidate.Status == models.StatusDownloaded
+	existingDownloaded := existing.Status == models.StatusDownloaded
+	if candidateDownloaded != existingDownloaded {
+		return candidateDownloaded
+	}
+	return candidate.Timestamp > existing.Timestamp
+}
+
+// samePath reports whether both paths refer to the same file, comparing the
+// absolute paths when either does not exist yet.
+func samePath(a, b string) bool {
+	if infoA, err := os.Stat(a); err == nil {
+		if infoB, err := os.Stat(b); err == nil {
+			return os.SameFile(infoA, infoB)
+		}
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// printDbMergeStats writes the per-source counts of a merge as a table.
+func printDbMergeStats(w io.Writer, allStats []dbMergeStats, output string) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "Source\tVersions\tAdded\tReplaced\tKept")
+	_, _ = fmt.Fprintln(tw, "------\t--------\t-----\t--------\t----")
+	conflicts := 0
+	for _, stats := range allStats {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%d\n", stats.Source, stats.Versions, stats.Added, stats.Replaced, stats.Kept)
+		conflicts += stats.Replaced + stats.Kept
+	}
+	if err := tw.Flush(); err != nil {
+		log.WithError(err).Error("Error flushing table writer for db merge")
+	}
+	_, _ = fmt.Fprintf(w, "\nMerged %d source(s) into %s, %d conflict(s) resolved.\n", len(allStats), output, conflicts)
+}
@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeDatabase(t *testing.T) {
+	dir := t.TempDir()
+	firstPath := filepath.Join(dir, "first.db")
+	secondPath := filepath.Join(dir, "second.db")
+
+	withTime := func(entry models.DatabaseEntry, timestamp int64) models.DatabaseEntry {
+		entry.Timestamp = timestamp
+		return entry
+	}
+	writeDiffTestDB(t, firstPath,
+		withTime(diffTestEntry(10, models.StatusDownloaded, "AAAA"), 100),
+		withTime(diffTestEntry(20, models.StatusError, "BBBB"), 100),
+		withTime(diffTestEntry(30, models.StatusPending, "CCCC"), 100),
+	)
+	writeDiffTestDB(t, secondPath,
+		withTime(diffTestEntry(10, models.StatusError, "AAAA"), 200),      // Downloaded wins over newer Error
+		withTime(diffTestEntry(20, models.StatusDownloaded, "BBBB"), 50),  // Downloaded wins over Error
+		withTime(diffTestEntry(30, models.StatusError, "CCCC"), 200),      // newer wins
+		withTime(diffTestEntry(40, models.StatusDownloaded, "DDDD"), 100), // only here
+	)
+
+	out, err := database.Open(filepath.Join(dir, "merged.db"))
+	require.NoError(t, err)
+	defer func() { _ = out.Close() }()
+
+	first, err := mergeDatabase(out, firstPath)
+	require.NoError(t, err)
+	assert.Equal(t, dbMergeStats{Source: firstPath, Versions: 3, Added: 3}, first)
+
+	second, err := mergeDatabase(out, secondPath)
+	require.NoError(t, err)
+	assert.Equal(t, dbMergeStats{Source: secondPath, Versions: 4, Added: 1, Replaced: 2, Kept: 1}, second)
+
+	status := func(versionID int) string {
+		data, err := out.Get([]byte(fmt.Sprintf("v_%d", versionID)))
+		require.NoError(t, err)
+		var entry models.DatabaseEntry
+		require.NoError(t, json.Unmarshal(data, &entry))
+		return entry.Status
+	}
+	assert.Equal(t, models.StatusDownloaded, status(10))
+	assert.Equal(t, models.StatusDownloaded, status(20))
+	assert.Equal(t, models.StatusError, status(30))
+	assert.Equal(t, models.StatusDownloaded, status(40))
+
+	var buf bytes.Buffer
+	printDbMergeStats(&buf, []dbMergeStats{first, second}, "merged.db")
+	assert.Contains(t, buf.String(), "Merged 2 source(s) into merged.db, 3 conflict(s) resolved.")
+}
+
+// dropTestColumn removes column from the models table of the database at path,
+// simulating a database created by an older version.
+func dropTestColumn(t *testing.T, path, column string) {
+	t.Helper()
+	db, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+	_, err = db.Exec("ALTER TABLE models DROP COLUMN " + column)
+	require.NoError(t, err)
+}
+
+func TestMergeDatabase_OldSchema(t *testing.T) {
+	dir := t.TempDir()
+	sourcePath := filepath.Join(dir, "old.db")
+	writeDiffTestDB(t, sourcePath, diffTestEntry(10, models.StatusDownloaded, "AAAA"))
+	dropTestColumn(t, sourcePath, "attempt_count")
+
+	out, err := database.Open(filepath.Join(dir, "merged.db"))
+	require.NoError(t, err)
+	defer func() { _ = out.Close() }()
+
+	stats, err := mergeDatabase(out, sourcePath)
+	require.NoError(t, err)
+	assert.Equal(t, dbMergeStats{Source: sourcePath, Versions: 1, Added: 1}, stats)
+}
+
+func TestSamePath(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "civitai.db")
+	assert.True(t, samePath(path, filepath.Join(dir, ".", "civitai.db")))
+	assert.False(t, samePath(path, filepath.Join(dir, "other.db")))
+}
@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Package-level variables for db prune flags
+var (
+	dbPruneYesFlag    bool
+	dbPruneStatusFlag string
+)
+
+// pruneStatuses are the statuses db prune --status accepts.
+var pruneStatuses = []string{models.StatusPending, models.StatusDownloaded, models.StatusError, models.StatusSkipped, models.StatusEarlyAccess}
+
+func init() {
+	dbCmd.AddCommand(dbPruneCmd)
+
+	dbPruneCmd.Flags().BoolVarP(&dbPruneYesFlag, "yes", "y", false, "Remove every matching entry without prompting")
+	dbPruneCmd.Flags().StringVar(&dbPruneStatusFlag, "status", models.StatusError, "Only remove entries with this status: "+strings.Join(pruneStatuses, ", "))
+}
+
+// dbPruneCmd removes the entries whose file is gone from disk
+var dbPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Remove database entries whose file is missing from disk",
+	Long: `Removes the database entries whose file is not on disk and whose status is
+Error (or the status given with --status), e.g. after deleting models you do not
+want, so they no longer show up in db view or get flagged by db verify. The
+files, images and stats stored for the version are removed with the entry.
+Each entry is confirmed unless --yes is given.
+
+Examples:
+  # Review failed entries without a file one by one
+  civitai-downloader db prune
+
+  # Forget downloaded models whose files were deleted by hand
+  civitai-downloader db prune --status Downloaded --yes`,
+	Run: runDbPrune,
+}
+
+// pruneCandidate is an entry db prune offers to remove.
+type pruneCandidate struct {
+	dbKey string
+	path  string
+	entry models.DatabaseEntry
+}
+
+func runDbPrune(cmd *cobra.Command, args []string) {
+	status, err := parsePruneStatus(dbPruneStatusFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if globalConfig.DatabasePath == "" {
+		log.Fatal("Database path is not set in the configuration. Please check config file or path.")
+	}
+	if globalConfig.SavePath == "" {
+		log.Fatal("Save path is not set in the configuration. Please check config file or path.")
+	}
+
+	db, err := database.Open(globalConfig.DatabasePath)
+	if err != nil {
+		log.WithError(err).Fatalf("Failed to open database at %s", globalConfig.DatabasePath)
+	}
+	defer func() { _ = db.Close() }()
+
+	candidates, err := findPruneCandidates(db, globalConfig.SavePath, status)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to read database")
+	}
+	if len(candidates) == 0 {
+		log.Infof("No %s entries with a missing file.", status)
+		return
+	}
+	log.Infof("Found %d %s entries with a missing file.", len(candidates), status)
+
+	reader := bufio.NewReader(os.Stdin)
+	removed := 0
+	for _, c := range candidates {
+		if !dbPruneYesFlag && !confirmPrune(c, reader) {
+			log.Infof("Keeping %s.", c.dbKey)
+			continue
+		}
+		if err := db.Delete([]byte(c.dbKey)); err != nil {
+			log.WithError(err).Errorf("Failed to remove %s", c.dbKey)
+			continue
+		}
+		log.Infof("Removed %s (%s - %s).", c.dbKey, c.entry.ModelName, c.entry.Version.Name)
+		removed++
+	}
+	log.Infof("Removed %d of %d entries.", removed, len(candidates))
+}
+
+// parsePruneStatus returns the status named by --status, in any case.
+func parsePruneStatus(value string) (string, error) {
+	for _, status := range pruneStatuses {
+		if strings.EqualFold(value, status) {
+			return status, nil
+		}
+	}
+	return "", fmt.Errorf("invalid --status %q: must be one of %s", value, strings.Join(pruneStatuses, ", "))
+}
+
+// findPruneCandidates returns the entries with status whose file is missing
+// under savePath, in version order.
+func findPruneCandidates(db *database.DB, savePath, status string) ([]pruneCandidate, error) {
+	var candidates []pruneCandidate
+	err := db.Fold(func(key []byte, value []byte) error {
+		keyStr := string(key)
+		if !strings.HasPrefix(keyStr, "v_") {
+			return nil
+		}
+		var entry models.DatabaseEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			log.WithError(err).Warnf("Failed to unmarshal JSON for key %s", keyStr)
+			return nil
+		}
+		if entry.Status != status {
+			return nil
+		}
+		path := filepath.Join(savePath, entry.Folder, entry.Filename)
+		if _, err := os.Stat(helpers.LongPath(path)); !errors.Is(err, os.ErrNotExist) {
+			if err != nil {
+				log.WithError(err).Warnf("Could not check %s, keeping %s", path, keyStr)
+			}
+			return nil
+		}
+		candidates = append(candidates, pruneCandidate{dbKey: keyStr, path: path, entry: entry})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].entry.Version.ID < candidates[j].entry.Version.ID
+	})
+	return candidates, nil
+}
+
+// confirmPrune asks whether to remove the entry of c.
+func confirmPrune(c pruneCandidate, reader *bufio.Reader) bool {
+	fmt.Printf("%s (%s - %s) is %s and %s is missing. Remove from database? (y/N): ", c.dbKey, c.entry.ModelName, c.entry.Version.Name, c.entry.Status, c.path)
+	input, _ := reader.ReadString('\n')
+	return strings.TrimSpace(strings.ToLower(input)) == "y"
+}
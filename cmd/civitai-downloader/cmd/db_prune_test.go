@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindPruneCandidates(t *testing.T) {
+	dir := t.TempDir()
+	savePath := filepath.Join(dir, "models")
+	path := filepath.Join(dir, "test.db")
+
+	present := diffTestEntry(10, models.StatusError, "AAAA")
+	present.Folder = "present"
+	missingB := diffTestEntry(30, models.StatusError, "CCCC")
+	missingB.Folder = "gone-b"
+	missingA := diffTestEntry(20, models.StatusError, "BBBB")
+	missingA.Folder = "gone-a"
+	downloaded := diffTestEntry(40, models.StatusDownloaded, "DDDD")
+	downloaded.Folder = "gone-d"
+	writeDiffTestDB(t, path, present, missingB, missingA, downloaded)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(savePath, "present"), 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(savePath, "present", "model.safetensors"), []byte("x"), 0600))
+
+	db, err := database.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	candidates, err := findPruneCandidates(db, savePath, models.StatusError)
+	require.NoError(t, err)
+	require.Len(t, candidates, 2, "the entry with its file on disk and the Downloaded one are kept")
+	assert.Equal(t, "v_20", candidates[0].dbKey)
+	assert.Equal(t, "v_30", candidates[1].dbKey)
+	assert.Equal(t, filepath.Join(savePath, "gone-a", "model.safetensors"), candidates[0].path)
+
+	candidates, err = findPruneCandidates(db, savePath, models.StatusDownloaded)
+	require.NoError(t, err)
+	require.Len(t, candidates, 1)
+	assert.Equal(t, "v_40", candidates[0].dbKey)
+
+	// A pruned entry is gone from the database
+	require.NoError(t, db.Delete([]byte("v_40")))
+	_, err = db.Get([]byte("v_40"))
+	assert.ErrorIs(t, err, database.ErrNotFound)
+}
+
+func TestParsePruneStatus(t *testing.T) {
+	status, err := parsePruneStatus("downloaded")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusDownloaded, status)
+
+	status, err = parsePruneStatus("EarlyAccess")
+	require.NoError(t, err)
+	assert.Equal(t, models.StatusEarlyAccess, status)
+
+	_, err = parsePruneStatus("Gone")
+	assert.Error(t, err)
+}
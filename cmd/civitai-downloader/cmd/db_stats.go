@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/helpers"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Package-level variables for db stats flags
+var (
+	dbStatsJSONFlag bool
+)
+
+func init() {
+	dbCmd.AddCommand(dbStatsCmd)
+
+	dbStatsCmd.Flags().BoolVar(&dbStatsJSONFlag, "json", false, "Print the statistics as JSON")
+}
+
+// dbStatsCmd reports aggregate numbers over the database
+var dbStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show totals of the database by status, model type and base model",
+	Long: `Reports the number of entries, how they split by status, model type and base
+model, the combined size of the downloaded files and the 10 largest downloaded
+files. Sizes are those the API reported for each version's file. The numbers are
+computed by the database itself, so this stays fast on large collections.
+
+Examples:
+  civitai-downloader db stats
+  civitai-downloader db stats --json`,
+	Run: runDbStats,
+}
+
+func runDbStats(cmd *cobra.Command, args []string) {
+	if globalConfig.DatabasePath == "" {
+		log.Fatal("Database path is not set in the configuration. Please check config file or path.")
+	}
+
+	db, err := database.OpenReadOnly(globalConfig.DatabasePath)
+	if err != nil {
+		log.WithError(err).Fatalf("Failed to open database at %s", globalConfig.DatabasePath)
+	}
+	defer func() { _ = db.Close() }()
+
+	stats, err := db.Stats()
+	if err != nil {
+		log.WithError(err).Fatal("Failed to read database")
+	}
+
+	if dbStatsJSONFlag {
+		out, err := json.MarshalIndent(stats, "", "  ")
+		if err != nil {
+			log.WithError(err).Fatal("Failed to encode database statistics")
+		}
+		fmt.Println(string(out))
+		return
+	}
+	printDbStats(os.Stdout, stats)
+}
+
+// kbToSize formats a size in KB as reported by the API.
+func kbToSize(sizeKB float64) string {
+	return helpers.BytesToSize(uint64(sizeKB * 1024))
+}
+
+// printDbStats writes stats as a series of tables.
+func printDbStats(w io.Writer, stats database.Stats) {
+	_, _ = fmt.Fprintf(w, "Entries:    %d\n", stats.TotalEntries)
+	_, _ = fmt.Fprintf(w, "Downloaded: %s\n", kbToSize(stats.DownloadedKB))
+	if stats.TotalEntries == 0 {
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, section := range []struct {
+		title  string
+		groups []database.StatsGroup
+	}{
+		{"Status", stats.ByStatus},
+		{"Model Type", stats.ByModelType},
+		{"Base Model", stats.ByBaseModel},
+	} {
+		_, _ = fmt.Fprintf(tw, "\n%s\tEntries\tSize\n", section.title)
+		for _, group := range section.groups {
+			name := group.Name
+			if name == "" {
+				name = "(none)"
+			}
+			_, _ = fmt.Fprintf(tw, "%s\t%d\t%s\n", name, group.Count, kbToSize(group.SizeKB))
+		}
+	}
+
+	if len(stats.LargestFiles) > 0 {
+		_, _ = fmt.Fprintln(tw, "\nLargest Files\tVersion ID\tSize\tFilename")
+		for _, file := range stats.LargestFiles {
+			_, _ = fmt.Fprintf(tw, "%s - %s\t%d\t%s\t%s\n", file.ModelName, file.VersionName, file.VersionID, kbToSize(file.SizeKB), file.Filename)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		log.WithError(err).Error("Error flushing table writer for db stats")
+	}
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"go-civitai-download/internal/database"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrintDbStats(t *testing.T) {
+	var out bytes.Buffer
+	printDbStats(&out, database.Stats{})
+	assert.Equal(t, "Entries:    0\nDownloaded: 0B\n", out.String())
+
+	out.Reset()
+	printDbStats(&out, database.Stats{
+		TotalEntries: 2,
+		DownloadedKB: 2048,
+		ByStatus:     []database.StatsGroup{{Name: "Downloaded", Count: 1, SizeKB: 2048}, {Name: "Pending", Count: 1}},
+		ByModelType:  []database.StatsGroup{{Name: "LORA", Count: 2, SizeKB: 2048}},
+		ByBaseModel:  []database.StatsGroup{{Name: "", Count: 2, SizeKB: 2048}},
+		LargestFiles: []database.StatsFile{{ModelName: "My LoRA", VersionName: "v1", Filename: "1_my_lora.safetensors", VersionID: 1, SizeKB: 2048}},
+	})
+	text := out.String()
+	assert.Contains(t, text, "Downloaded: 2.00MB\n")
+	assert.Contains(t, text, "Pending     1")
+	assert.Contains(t, text, "(none)", "entries without a base model are named")
+	assert.Contains(t, text, "My LoRA - v1")
+	assert.Contains(t, text, "1_my_lora.safetensors")
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// Package-level variables for db tag-frequencies flags
+var (
+	dbTagFreqTopFlag       int
+	dbTagFreqModelTypeFlag string
+	dbTagFreqJSONFlag      bool
+)
+
+func init() {
+	dbCmd.AddCommand(dbTagFrequenciesCmd)
+
+	dbTagFrequenciesCmd.Flags().IntVar(&dbTagFreqTopFlag, "top", 50, "Only show the N most common tags (0 shows all)")
+	dbTagFrequenciesCmd.Flags().StringVar(&dbTagFreqModelTypeFlag, "model-type", "", "Only count versions of this model type, e.g. LORA")
+	dbTagFrequenciesCmd.Flags().BoolVar(&dbTagFreqJSONFlag, "json", false, "Print the tags as JSON")
+}
+
+// dbTagFrequenciesCmd reports the most common trained words of the downloaded versions
+var dbTagFrequenciesCmd = &cobra.Command{
+	Use:   "tag-frequencies",
+	Short: "Report the most common trained words across downloaded models",
+	Long: `Counts the trained words (trigger words) Civitai lists for every downloaded
+version in the database and prints them from most to least common, to see which
+concepts the collection covers. Comma separated words are counted one by one,
+ignoring case. Only the database is read.
+
+Examples:
+  # The 20 most common tags of your LoRAs
+  civitai-downloader db tag-frequencies --model-type LORA --top 20
+
+  # Every tag, for scripts
+  civitai-downloader db tag-frequencies --top 0 --json`,
+	Run: runDbTagFrequencies,
+}
+
+// tagFrequency is one trained word and the number of versions listing it.
+type tagFrequency struct {
+	Tag      string `json:"tag"`
+	Versions int    `json:"versions"`
+}
+
+func runDbTagFrequencies(cmd *cobra.Command, args []string) {
+	if globalConfig.DatabasePath == "" {
+		log.Fatal("Database path is not set in the configuration. Please check config file or path.")
+	}
+
+	db, err := database.OpenReadOnly(globalConfig.DatabasePath)
+	if err != nil {
+		log.WithError(err).Fatalf("Failed to open database at %s", globalConfig.DatabasePath)
+	}
+	defer func() { _ = db.Close() }()
+
+	tags, err := loadTagFrequencies(db, dbTagFreqModelTypeFlag)
+	if err != nil {
+		log.WithError(err).Fatal("Failed to read database")
+	}
+	if dbTagFreqTopFlag > 0 && len(tags) > dbTagFreqTopFlag {
+		tags = tags[:dbTagFreqTopFlag]
+	}
+
+	if dbTagFreqJSONFlag {
+		out, err := json.MarshalIndent(tags, "", "  ")
+		if err != nil {
+			log.WithError(err).Fatal("Failed to encode tag frequencies")
+		}
+		fmt.Println(string(out))
+		return
+	}
+	printTagFrequencies(os.Stdout, tags)
+}
+
+// loadTagFrequencies counts in how many downloaded versions each trained word
+// appears, optionally only for one model type. Words are compared ignoring
+// case and keep the spelling seen first. The result is sorted by count, then
+// alphabetically.
+func loadTagFrequencies(db *database.DB, modelType string) ([]tagFrequency, error) {
+	counts := make(map[string]*tagFrequency)
+	err := db.Fold(func(key []byte, value []byte) error {
+		keyStr := string(key)
+		if !strings.HasPrefix(keyStr, "v_") {
+			return nil
+		}
+		var entry models.DatabaseEntry
+		if err := json.Unmarshal(value, &entry); err != nil {
+			log.WithError(err).Warnf("Failed to unmarshal JSON for key %s", keyStr)
+			return nil
+		}
+		if entry.Status != models.StatusDownloaded {
+			return nil
+		}
+		if modelType != "" && !strings.EqualFold(entry.ModelType, modelType) {
+			return nil
+		}
+
+		seen := make(map[string]bool)
+		for _, words := range entry.Version.TrainedWords {
+			for _, word := range strings.Split(words, ",") {
+				word = strings.TrimSpace(word)
+				norm := strings.ToLower(word)
+				if word == "" || seen[norm] {
+					continue
+				}
+				seen[norm] = true
+				if tag, ok := counts[norm]; ok {
+					tag.Versions++
+				} else {
+					counts[norm] = &tagFrequency{Tag: word, Versions: 1}
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make([]tagFrequency, 0, len(counts))
+	for _, tag := range counts {
+		tags = append(tags, *tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if tags[i].Versions != tags[j].Versions {
+			return tags[i].Versions > tags[j].Versions
+		}
+		return strings.ToLower(tags[i].Tag) < strings.ToLower(tags[j].Tag)
+	})
+	return tags, nil
+}
+
+// printTagFrequencies writes the tags as a table.
+func printTagFrequencies(w io.Writer, tags []tagFrequency) {
+	if len(tags) == 0 {
+		_, _ = fmt.Fprintln(w, "No trained words found for downloaded models.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "Versions\tTag")
+	_, _ = fmt.Fprintln(tw, "--------\t---")
+	for _, tag := range tags {
+		_, _ = fmt.Fprintf(tw, "%d\t%s\n", tag.Versions, tag.Tag)
+	}
+	if err := tw.Flush(); err != nil {
+		log.WithError(err).Error("Error flushing table writer for db tag-frequencies")
+	}
+}
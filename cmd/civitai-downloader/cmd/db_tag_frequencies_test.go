@@ -0,0 +1,58 @@
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTagFrequencies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	lora1 := diffTestEntry(10, models.StatusDownloaded, "AAAA")
+	lora1.ModelType = "LORA"
+	lora1.Version.TrainedWords = []string{"Anime, watercolor", "sketch"}
+	lora2 := diffTestEntry(20, models.StatusDownloaded, "BBBB")
+	lora2.ModelType = "LORA"
+	lora2.Version.TrainedWords = []string{"anime", "anime"} // counted once per version
+	checkpoint := diffTestEntry(30, models.StatusDownloaded, "CCCC")
+	checkpoint.ModelType = "Checkpoint"
+	checkpoint.Version.TrainedWords = []string{"watercolor"}
+	pending := diffTestEntry(40, models.StatusPending, "DDDD")
+	pending.ModelType = "LORA"
+	pending.Version.TrainedWords = []string{"sketch"}
+	writeDiffTestDB(t, path, lora1, lora2, checkpoint, pending)
+
+	db, err := database.Open(path)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	tags, err := loadTagFrequencies(db, "")
+	require.NoError(t, err)
+	assert.Equal(t, []tagFrequency{
+		{Tag: "Anime", Versions: 2},
+		{Tag: "watercolor", Versions: 2},
+		{Tag: "sketch", Versions: 1},
+	}, tags)
+
+	tags, err = loadTagFrequencies(db, "lora")
+	require.NoError(t, err)
+	assert.Equal(t, []tagFrequency{
+		{Tag: "Anime", Versions: 2},
+		{Tag: "sketch", Versions: 1},
+		{Tag: "watercolor", Versions: 1},
+	}, tags)
+
+	var out bytes.Buffer
+	printTagFrequencies(&out, tags)
+	assert.Contains(t, out.String(), "Anime")
+
+	out.Reset()
+	printTagFrequencies(&out, nil)
+	assert.Contains(t, out.String(), "No trained words")
+}
@@ -0,0 +1,212 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseVerifyWindow(t *testing.T) {
+	for input, want := range map[string]time.Duration{
+		"":    0,
+		"0":   0,
+		"30d": 30 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+	} {
+		got, err := parseVerifyWindow(input)
+		require.NoError(t, err, input)
+		assert.Equal(t, want, got, input)
+	}
+	for _, input := range []string{"xd", "-1d", "soon", "-5h"} {
+		_, err := parseVerifyWindow(input)
+		assert.Error(t, err, input)
+	}
+}
+
+func TestExpectedFileHash(t *testing.T) {
+	hashes := models.Hashes{SHA256: "abc", CRC32: "def"}
+	assert.Equal(t, "ABC", expectedFileHash(hashes, ""), "SHA256 comes before CRC32 when no algo is chosen")
+	assert.Equal(t, "DEF", expectedFileHash(hashes, helpers.HashAlgoCRC32))
+	assert.Equal(t, "", expectedFileHash(hashes, helpers.HashAlgoBLAKE3))
+}
+
+func TestRecentlyVerified(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "model.safetensors")
+	require.NoError(t, os.WriteFile(path, []byte("data"), 0600))
+	modified := time.Now().Add(-48 * time.Hour)
+	require.NoError(t, os.Chtimes(path, modified, modified))
+
+	now := time.Now()
+	entry := models.DatabaseEntry{
+		LastVerifiedAt:   now.Add(-24 * time.Hour).Unix(),
+		LastVerifiedHash: "ABC",
+	}
+	week := 7 * 24 * time.Hour
+
+	assert.True(t, recentlyVerified(path, entry, "abc", week, now))
+	assert.False(t, recentlyVerified(path, entry, "abc", 0, now), "skipping is disabled without a window")
+	assert.False(t, recentlyVerified(path, entry, "abc", 12*time.Hour, now), "verified too long ago")
+	assert.False(t, recentlyVerified(path, entry, "OTHER", week, now), "the recorded hash changed since")
+	assert.False(t, recentlyVerified(filepath.Join(filepath.Dir(path), "missing"), entry, "abc", week, now))
+	assert.False(t, recentlyVerified(path, models.DatabaseEntry{}, "abc", week, now), "never verified")
+
+	// Modified after the verification: hash it again
+	require.NoError(t, os.Chtimes(path, now, now))
+	assert.False(t, recentlyVerified(path, entry, "abc", week, now))
+}
+
+func TestScanDatabaseEntriesConcurrently(t *testing.T) {
+	saved := globalConfig
+	t.Cleanup(func() { globalConfig = saved })
+	dir := t.TempDir()
+	globalConfig = models.Config{SavePath: dir}
+	globalConfig.DB.Verify.CheckHash = true
+
+	content := []byte("model weights")
+	sum := crc32.Checksum(content, crc32.MakeTable(crc32.Castagnoli))
+	goodCRC := hex.EncodeToString([]byte{byte(sum >> 24), byte(sum >> 16), byte(sum >> 8), byte(sum)})
+
+	// Every third version is missing, every third has the wrong hash
+	var entries []models.DatabaseEntry
+	for id := 1; id <= 30; id++ {
+		entry := diffTestEntry(id, models.StatusDownloaded, goodCRC)
+		entry.Folder = fmt.Sprintf("m%d", id)
+		switch id % 3 {
+		case 0:
+			entry.File.Hashes.CRC32 = "DEADBEEF"
+			entry.Version.Files = []models.File{entry.File}
+			fallthrough
+		case 1:
+			require.NoError(t, os.MkdirAll(filepath.Join(dir, entry.Folder), 0700))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, entry.Folder, entry.Filename), content, 0600))
+		}
+		entries = append(entries, entry)
+	}
+	dbPath := filepath.Join(dir, "civitai.db")
+	writeDiffTestDB(t, dbPath, entries...)
+	db, err := database.Open(dbPath)
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	stats, problems, verifications := scanDatabaseEntries(db, 0, time.Now(), 4)
+	assert.Equal(t, VerificationStats{TotalEntries: 30, FoundOk: 10, Missing: 10, FoundHashMismatch: 10}, stats)
+	assert.Len(t, verifications, 10)
+	require.Len(t, problems, 20)
+	for i := 1; i < len(problems); i++ {
+		assert.Less(t, problems[i-1].Entry.Version.ID, problems[i].Entry.Version.ID, "problems are in version order")
+	}
+}
+
+func TestHandleMetadataVerificationRepair(t *testing.T) {
+	saved := globalConfig
+	t.Cleanup(func() { globalConfig = saved })
+	dir := t.TempDir()
+	globalConfig = models.Config{SavePath: dir}
+
+	entry := diffTestEntry(10, models.StatusDownloaded, "AAAA")
+	entry.Folder = "lora"
+	entry.Version.TrainedWords = []string{"fresh"}
+	metaPath := filepath.Join(dir, "lora", "model.json")
+	require.NoError(t, os.MkdirAll(filepath.Dir(metaPath), 0700))
+	require.NoError(t, os.WriteFile(metaPath, []byte(`{"id":10,"trainedWords":["stale"]}`), 0600))
+
+	assert.False(t, handleMetadataVerification("", entry, false), "not repaired without the flag")
+	assert.True(t, handleMetadataVerification("", entry, true))
+	got, err := os.ReadFile(metaPath)
+	require.NoError(t, err)
+	var repaired map[string]any
+	require.NoError(t, json.Unmarshal(got, &repaired))
+	assert.Equal(t, []any{"fresh"}, repaired["trainedWords"])
+	assert.Equal(t, float64(10), repaired["id"])
+
+	assert.False(t, handleMetadataVerification("", entry, true), "an up to date file is left alone")
+
+	require.NoError(t, os.Remove(metaPath))
+	assert.False(t, handleMetadataVerification("", entry, true))
+	assert.NoFileExists(t, metaPath, "missing files are only created with Metadata enabled")
+}
+
+func TestRepairMetadataFile_KeepsDataTheDatabaseLacks(t *testing.T) {
+	dir := t.TempDir()
+	metaPath := filepath.Join(dir, "model.json")
+	// Written from the full API version, with fields the database does not store
+	full := `{
+  "id": 10,
+  "modelId": 1,
+  "name": "v1",
+  "createdAt": "2024-01-01T00:00:00Z",
+  "downloadUrl": "https://civitai.com/api/download/models/10",
+  "model": {"name": "Model", "type": "LORA"},
+  "availability": "Public",
+  "trainedWords": ["stale"],
+  "images": [{"url": "https://image.civitai.com/a.png", "meta": {"prompt": "a cat"}}]
+}`
+	require.NoError(t, os.WriteFile(metaPath, []byte(full), 0600))
+
+	// As rebuilt from the database
+	version := models.ModelVersion{ID: 10, Name: "v1", TrainedWords: []string{"stale"}, Images: []models.ModelImage{{URL: "https://image.civitai.com/a.png"}}}
+	assert.False(t, repairMetadataFile(metaPath, version, true), "formatting and fields the database lacks do not make a file stale")
+	got, err := os.ReadFile(metaPath)
+	require.NoError(t, err)
+	assert.Equal(t, full, string(got))
+
+	version.TrainedWords = []string{"fresh"}
+	assert.True(t, repairMetadataFile(metaPath, version, false))
+	got, err = os.ReadFile(metaPath)
+	require.NoError(t, err)
+	var repaired map[string]any
+	require.NoError(t, json.Unmarshal(got, &repaired))
+	assert.Equal(t, []any{"fresh"}, repaired["trainedWords"])
+	assert.Equal(t, float64(1), repaired["modelId"])
+	assert.Equal(t, "https://civitai.com/api/download/models/10", repaired["downloadUrl"])
+	assert.Equal(t, "Public", repaired["availability"])
+	assert.Equal(t, map[string]any{"name": "Model", "type": "LORA"}, repaired["model"])
+	images := repaired["images"].([]any)
+	assert.Equal(t, map[string]any{"prompt": "a cat"}, images[0].(map[string]any)["meta"], "image meta is kept")
+}
+
+func TestDbViewSortAndDates(t *testing.T) {
+	entry := func(id int, name, published, updated string) models.DatabaseEntry {
+		return models.DatabaseEntry{ModelName: name, Version: models.ModelVersion{ID: id, PublishedAt: published, UpdatedAt: updated}}
+	}
+	entries := []models.DatabaseEntry{
+		entry(1, "beta", "2024-01-01T00:00:00.000Z", "2024-06-01T00:00:00.000Z"),
+		entry(2, "Alpha", "2023-05-01T00:00:00.000Z", ""),
+		entry(3, "gamma", "", ""),
+	}
+
+	sortViewEntries(entries, viewSortDate)
+	assert.Equal(t, []int{3, 2, 1}, viewIDs(entries), "undated first, then by updated date falling back to published")
+
+	sortViewEntries(entries, viewSortName)
+	assert.Equal(t, []int{2, 1, 3}, viewIDs(entries))
+
+	var out bytes.Buffer
+	printDbView(&out, entries, true)
+	assert.Contains(t, out.String(), "Published")
+	assert.Contains(t, out.String(), "2024-06-01")
+	out.Reset()
+	printDbView(&out, entries, false)
+	assert.NotContains(t, out.String(), "Published")
+}
+
+func viewIDs(entries []models.DatabaseEntry) []int {
+	ids := make([]int, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.Version.ID
+	}
+	return ids
+}
@@ -17,6 +17,7 @@ func init() {
 	rootCmd.AddCommand(debugCmd)
 	debugCmd.AddCommand(debugShowConfigCmd)
 	debugCmd.AddCommand(debugPrintApiUrlCmd)
+	debugCmd.AddCommand(debugPreviewPathsCmd)
 
 	// Add subcommands for print-api-url
 	debugPrintApiUrlCmd.AddCommand(debugPrintApiUrlDownloadCmd)
@@ -29,6 +30,9 @@ func init() {
 	// Flags for 'debug print-api-url download' (mirroring download.go)
 	addDownloadFlags(debugPrintApiUrlDownloadCmd)
 
+	// Flags for 'debug preview-paths' (same filters as download)
+	addDownloadFlags(debugPreviewPathsCmd)
+
 	// Flags for 'debug print-api-url images' (mirroring images.go/cmd_images_setup.go)
 	addImagesFlags(debugPrintApiUrlImagesCmd)
 
@@ -87,7 +91,7 @@ var debugPrintApiUrlDownloadCmd = &cobra.Command{
 		// globalConfig is populated
 		// Call the exported helper function from cmd_download_api.go
 		queryParams := CreateDownloadQueryParams(&globalConfig)
-		baseURL := api.CivitaiApiBaseUrl + "/models" // Use exported base URL + path
+		baseURL := api.BaseURL(globalConfig) + "/models" // Use exported base URL + path
 
 		// Construct the URL using the exported helper and Sprintf
 		urlValues := api.ConvertQueryParamsToURLValues(queryParams)
@@ -105,7 +109,7 @@ var debugPrintApiUrlImagesCmd = &cobra.Command{
 		// globalConfig is populated
 		// Call the exported helper function from cmd_images_run.go
 		queryParams := CreateImageQueryParams(&globalConfig)
-		baseURL := api.CivitaiApiBaseUrl + "/images" // Use exported base URL + path
+		baseURL := api.BaseURL(globalConfig) + "/images" // Use exported base URL + path
 
 		// Construct the URL using the exported helper and Sprintf
 		urlValues := api.ConvertImageAPIParamsToURLValues(queryParams)
@@ -115,17 +119,41 @@ var debugPrintApiUrlImagesCmd = &cobra.Command{
 	},
 }
 
+// --- debug preview-paths ---
+
+var debugPreviewPathsCmd = &cobra.Command{
+	Use:   "preview-paths",
+	Short: "Print where each matching file would be saved, without downloading",
+	Long: `Runs the metadata phase of the download command for the current query (or --model-id)
+and prints the path, relative to SavePath, that each file would be saved to.
+Nothing is downloaded and the database is not touched. Paths containing fallback
+segments such as "unknown_creator" are flagged with a warning.
+Only the first page of results is previewed unless --max-pages is set.`,
+	// PersistentPreRunE: loadGlobalConfig, // Relies on rootCmd's PersistentPreRunE
+	RunE: runPreviewPaths,
+}
+
 // Helper function to add download flags (to avoid duplication)
 func addDownloadFlags(cmd *cobra.Command) {
 	// Reuse flags from download.go
-	cmd.Flags().IntVarP(&downloadConcurrencyFlag, "concurrency", "c", -1, "Number of concurrent download workers (-1 uses config)")
+	cmd.Flags().VarP(newConcurrencyValue(&downloadConcurrencyFlag, &downloadAutoConcurrencyFlag, -1), "concurrency", "c", "Number of concurrent download workers or auto (-1 uses config)")
+	cmd.Flags().IntVar(&downloadPerModelConcurrencyFlag, "per-model-concurrency", -1, "Maximum concurrent downloads of the same model (-1 uses config)")
+	cmd.Flags().IntVar(&downloadMaxConcurrencyFlag, "max-concurrency", -1, "Upper bound for --concurrency auto (-1 uses config)")
+	cmd.Flags().IntVar(&downloadSegmentsPerFileFlag, "segments-per-file", 0, "Connections per file download (0 uses config)")
+	cmd.Flags().Var(newByteRateValue(&downloadMaxRateFlag), "max-rate", "Combined download bandwidth cap, e.g. 2MB (0 uses config)")
 	cmd.Flags().StringVarP(&downloadTagFlag, "tag", "", "", "Filter by tag (API)")
 	cmd.Flags().StringVarP(&downloadQueryFlag, "query", "q", "", "Filter by text query (API)")
+	cmd.Flags().StringVar(&downloadNameRegexFlag, "name-regex", "", "Only keep models whose name matches this regex (Client Filter)")
+	cmd.Flags().StringVar(&downloadQueueOrderFlag, "queue-order", "", "Download order: size-asc, size-desc or none")
+	cmd.Flags().StringVar(&downloadMaxRuntimeFlag, "max-runtime", "", "Stop starting new downloads after this long")
+	cmd.Flags().StringVar(&downloadSinceFlag, "since", "", "Skip versions published before this date or age, e.g. 7d (Client Filter)")
 	cmd.Flags().StringSliceVarP(&downloadModelTypesFlag, "model-types", "", []string{}, "Filter by model types (API, comma-separated or multiple flags)")
 	cmd.Flags().StringSliceVarP(&downloadBaseModelsFlag, "base-models", "", []string{}, "Filter by base models (API, comma-separated or multiple flags)")
 	cmd.Flags().StringVarP(&downloadUsernameFlag, "username", "", "", "Filter by username (API)")
 	cmd.Flags().BoolVarP(&downloadNsfwFlag, flagNsfw, "", false, "Include NSFW models (API)") // Note: Cobra bool defaults to false if flag not present
+	cmd.Flags().Var(newBrowsingLevelValue(&downloadBrowsingLevelFlag), "browsing-level", "Content level bitmask or names, e.g. PG,PG13 (API)")
 	cmd.Flags().IntVarP(&downloadLimitFlag, "limit", "l", -1, "Limit number of models per page (-1 uses config, API)")
+	cmd.Flags().IntVar(&downloadMetadataLimitFlag, "metadata-limit", 0, "Save metadata for up to this many files when above --limit (0 uses --limit)")
 	cmd.Flags().IntVarP(&downloadMaxPagesFlag, "max-pages", "p", -1, "Maximum number of pages to fetch (-1 uses config)")
 	cmd.Flags().StringVarP(&downloadSortFlag, "sort", "s", "", "Sort order (API, overrides config)")
 	cmd.Flags().StringVarP(&downloadPeriodFlag, "period", "", "", "Sort period (API, overrides config)")
@@ -138,12 +166,39 @@ func addDownloadFlags(cmd *cobra.Command) {
 	cmd.Flags().StringSliceVar(&downloadIgnoreBaseModelsFlag, "ignore-base-models", []string{}, "Base models to ignore (Client Filter, comma-separated or multiple flags)")
 	cmd.Flags().StringSliceVar(&downloadIgnoreFileNameStringsFlag, "ignore-filename-strings", []string{}, "Substrings in filenames to ignore (Client Filter, comma-separated or multiple flags)")
 	cmd.Flags().StringSliceVar(&downloadIgnoreTagsFlag, "ignore-tags", []string{}, "Tags to ignore (Client Filter, comma-separated or multiple flags)")
+	cmd.Flags().StringSliceVar(&downloadAllowedFormatsFlag, "formats", []string{"safetensor"}, "File formats to download (Client Filter, comma-separated, empty allows all)")
+	cmd.Flags().IntSliceVar(&downloadBlockModelIDsFlag, "block-model-id", []int{}, "Model IDs to never download (Client Filter)")
+	cmd.Flags().IntSliceVar(&downloadBlockVersionIDsFlag, "block-version-id", []int{}, "Model version IDs to never download (Client Filter)")
+	cmd.Flags().StringVar(&downloadBlocklistFileFlag, "blocklist-file", "", "File of model/version IDs to never download")
+	cmd.Flags().StringToStringVar(&downloadTypeSubdirMapFlag, "type-subdir-map", map[string]string{}, "Folder name for {modelType} per model type, e.g. LORA=Lora")
 	cmd.Flags().BoolVarP(&downloadYesFlag, "yes", "y", false, "Skip confirmation prompts")
+	cmd.Flags().Float64Var(&downloadAutoConfirmUnderGBFlag, "auto-confirm-under-gb", 0, "Skip the download prompt below this total size in GB")
+	cmd.Flags().Float64Var(&downloadMinSizeMBFlag, "min-size-mb", 0, "Skip files smaller than this many MB (Client Filter)")
+	cmd.Flags().Float64Var(&downloadMaxSizeMBFlag, "max-size-mb", 0, "Skip files larger than this many MB (Client Filter)")
+	cmd.Flags().IntVar(&downloadMinDownloadsFlag, "min-downloads", 0, "Skip models downloaded fewer times than this (Client Filter)")
+	cmd.Flags().Float64Var(&downloadMinRatingFlag, "min-rating", 0, "Skip models rated below this (Client Filter)")
+	cmd.Flags().IntVar(&downloadMinRatingCountFlag, "min-rating-count", 0, "Skip models with fewer ratings than this (Client Filter)")
 	cmd.Flags().BoolVar(&downloadMetadataFlag, "metadata", false, "Save model metadata file")
 	cmd.Flags().BoolVar(&downloadModelInfoFlag, "model-info", false, "Save full model info file")
 	cmd.Flags().BoolVar(&downloadVersionImagesFlag, "version-images", false, "Save model version images")
 	cmd.Flags().BoolVar(&downloadModelImagesFlag, "model-images", false, "Save all model gallery images")
 	cmd.Flags().BoolVar(&downloadMetaOnlyFlag, "meta-only", false, "Only download metadata/images, skip model file")
+	cmd.Flags().BoolVar(&downloadFailFastFlag, "fail-fast", false, "Abort the run on the first download error")
+	cmd.Flags().BoolVar(&downloadTrainedWordsFlag, "trained-words", false, "Write the version's trained words to a .txt file")
+	cmd.Flags().BoolVar(&downloadPreviewFlag, "preview", false, "Save a <model>.preview.png next to each model")
+	cmd.Flags().BoolVar(&downloadCivitaiInfoFlag, "civitai-info", false, "Write a <model>.civitai.info sidecar for each model")
+	cmd.Flags().BoolVar(&downloadPrimaryFileFallbackFlag, "primary-file-fallback", false, "With --primary-only, fall back to the largest matching file when a version has no primary file")
+	cmd.Flags().BoolVar(&downloadRecordBlockedFlag, "record-blocked", false, "Record blocked versions as Skipped in the database")
+	cmd.Flags().BoolVar(&downloadMaxRuntimeCancelFlag, "max-runtime-cancel", false, "Cancel in-flight downloads at the --max-runtime deadline")
+	cmd.Flags().BoolVar(&downloadSaveWorkflowsFlag, "save-workflows", false, "Save image workflows and workflow attachments")
+	cmd.Flags().BoolVar(&downloadIncludeEarlyAccessFlag, "include-early-access", false, "Include versions still in early access")
+	cmd.Flags().BoolVar(&downloadFlattenFlag, "flatten", false, "Save every file directly in the save path")
+	cmd.Flags().BoolVar(&downloadFavoritesFlag, "favorites", false, "Only list models favorited by the API key's account (API)")
+	cmd.Flags().BoolVar(&downloadHiddenFlag, "hidden", false, "Only list models hidden by the API key's account (API)")
+	cmd.Flags().BoolVar(&downloadBackupOnReplaceFlag, "backup-on-replace", false, "Keep the previous copy of a changed file as .bak")
+	cmd.Flags().BoolVar(&downloadPrimaryImageOnlyFlag, "primary-image-only", false, "Only save the first (cover) image")
+	cmd.Flags().BoolVar(&downloadContentAddressedFlag, "content-addressed", false, "Store files once by SHA256 and link them at their normal path")
+	cmd.Flags().BoolVar(&downloadDedupeImagesFlag, "dedupe-images", false, "Save model images shared by several versions once")
 }
 
 // Helper function to add images flags (to avoid duplication)
@@ -155,6 +210,8 @@ func addImagesFlags(cmd *cobra.Command) {
 	cmd.Flags().IntVar(&imagesModelVersionIDFlag, "model-version-id", 0, "Filter by specific model version ID (API)")
 	cmd.Flags().StringVarP(&imagesUsernameFlag, "username", "u", "", "Filter by username (API)")
 	cmd.Flags().StringVar(&imagesNsfwFlag, flagNsfw, "", "Filter by NSFW level (None, Soft, Mature, X, All - API, overrides config)")
+	cmd.Flags().StringVar(&imagesNsfwMinFlag, "nsfw-min", "", "Lowest NSFW level (None, Soft, Mature, X - API, overrides config)")
+	cmd.Flags().StringVar(&imagesNsfwMaxFlag, "nsfw-max", "", "Highest NSFW level (None, Soft, Mature, X - API, overrides config)")
 	cmd.Flags().StringVarP(&imagesSortFlag, "sort", "s", "", "Sort order (API, overrides config)")
 	cmd.Flags().StringVar(&imagesPeriodFlag, "period", "", "Sort period (API, overrides config)")
 	cmd.Flags().IntVarP(&imagesPageFlag, "page", "p", -1, "API page to start fetching from (-1 uses config)")
@@ -162,4 +219,5 @@ func addImagesFlags(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&imagesOutputDirFlag, "output-dir", "o", "", "Directory to save images (overrides config SavePath + /images)")
 	cmd.Flags().IntVarP(&imagesConcurrencyFlag, "concurrency", "c", -1, "Number of concurrent image download workers (-1 uses config)")
 	cmd.Flags().BoolVar(&imagesMetadataFlag, "metadata", false, "Save image metadata file")
+	cmd.Flags().StringVar(&imagesOutputTemplateFlag, "output-template", "", "Folder pattern for images (overrides config Images.PathPattern)")
 }
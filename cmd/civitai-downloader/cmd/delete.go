@@ -423,9 +423,10 @@ func deleteEntries(db *database.DB, entries []models.DatabaseEntry, savePath str
 				skipped++
 			}
 
-			// Also try to delete images directory if it exists
+			// Also try to delete images directory if it exists. Flat entries
+			// share SavePath/images, so it is left alone for them.
 			imagesDir := filepath.Join(savePath, entry.Folder, "images")
-			if info, err := os.Stat(imagesDir); err == nil && info.IsDir() {
+			if info, err := os.Stat(imagesDir); err == nil && info.IsDir() && entry.Folder != flatFolder {
 				if err := os.RemoveAll(imagesDir); err != nil {
 					log.Warnf("Failed to remove images directory %s: %v", imagesDir, err)
 				} else {
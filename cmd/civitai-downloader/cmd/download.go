@@ -2,17 +2,22 @@ package cmd
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"go-civitai-download/internal/api"
 	"go-civitai-download/internal/database"
 	"go-civitai-download/internal/downloader"
+	"go-civitai-download/internal/helpers"
 	"go-civitai-download/internal/models"
 
 	"github.com/gosuri/uilive"
@@ -25,6 +30,10 @@ var (
 	downloadConcurrencyFlag           int
 	downloadTagFlag                   string
 	downloadQueryFlag                 string
+	downloadNameRegexFlag             string
+	downloadQueueOrderFlag            string
+	downloadMaxRuntimeFlag            string
+	downloadSinceFlag                 string
 	downloadModelTypesFlag            []string
 	downloadBaseModelsFlag            []string
 	downloadUsernameFlag              string
@@ -32,6 +41,18 @@ var (
 	downloadLimitFlag                 int
 	downloadMaxPagesFlag              int
 	downloadMaxImagesFlag             int
+	downloadPerModelConcurrencyFlag   int
+	downloadMaxConcurrencyFlag        int
+	downloadSegmentsPerFileFlag       int // Corresponds to SegmentsPerFile
+	downloadMetadataLimitFlag         int
+	downloadMaxRateFlag               int64 // Corresponds to MaxBytesPerSecond, set from a size like 2MB
+	downloadBrowsingLevelFlag         int   // Bitmask, set from a number or level names
+	downloadAutoConfirmUnderGBFlag    float64
+	downloadMinSizeMBFlag             float64
+	downloadMaxSizeMBFlag             float64
+	downloadMinDownloadsFlag          int
+	downloadMinRatingFlag             float64
+	downloadMinRatingCountFlag        int
 	downloadSortFlag                  string
 	downloadPeriodFlag                string
 	downloadModelIDFlag               int
@@ -43,12 +64,47 @@ var (
 	downloadIgnoreBaseModelsFlag      []string
 	downloadIgnoreFileNameStringsFlag []string
 	downloadIgnoreTagsFlag            []string
-	downloadYesFlag                   bool // Corresponds to SkipConfirmation
-	downloadMetadataFlag              bool // Corresponds to SaveMetadata
-	downloadModelInfoFlag             bool // Corresponds to SaveModelInfo
-	downloadVersionImagesFlag         bool // Corresponds to SaveVersionImages
-	downloadModelImagesFlag           bool // Corresponds to SaveModelImages
-	downloadMetaOnlyFlag              bool // Corresponds to DownloadMetaOnly
+	downloadAllowedFormatsFlag        []string
+	downloadBlockModelIDsFlag         []int
+	downloadBlockVersionIDsFlag       []int
+	downloadBlocklistFileFlag         string
+	downloadTypeSubdirMapFlag         map[string]string
+	downloadYesFlag                   bool   // Corresponds to SkipConfirmation
+	downloadMetadataFlag              bool   // Corresponds to SaveMetadata
+	downloadModelInfoFlag             bool   // Corresponds to SaveModelInfo
+	downloadVersionImagesFlag         bool   // Corresponds to SaveVersionImages
+	downloadModelImagesFlag           bool   // Corresponds to SaveModelImages
+	downloadMetaOnlyFlag              bool   // Corresponds to DownloadMetaOnly
+	downloadFailFastFlag              bool   // Corresponds to FailFast
+	downloadTrainedWordsFlag          bool   // Corresponds to SaveTrainedWords
+	downloadPreviewFlag               bool   // Corresponds to SavePreview
+	downloadCivitaiInfoFlag           bool   // Corresponds to SaveCivitaiInfo
+	downloadPrimaryFileFallbackFlag   bool   // Corresponds to PrimaryFileFallback
+	downloadRecordBlockedFlag         bool   // Corresponds to RecordBlocked
+	downloadMaxRuntimeCancelFlag      bool   // Corresponds to MaxRuntimeCancel
+	downloadSaveWorkflowsFlag         bool   // Corresponds to SaveWorkflows
+	downloadIncludeEarlyAccessFlag    bool   // Corresponds to IncludeEarlyAccess
+	downloadFlattenFlag               bool   // Corresponds to Flatten
+	downloadFavoritesFlag             bool   // Corresponds to Favorites
+	downloadHiddenFlag                bool   // Corresponds to Hidden
+	downloadBackupOnReplaceFlag       bool   // Corresponds to BackupOnReplace
+	downloadPrimaryImageOnlyFlag      bool   // Corresponds to PrimaryImageOnly
+	downloadContentAddressedFlag      bool   // Corresponds to ContentAddressed
+	downloadDedupeImagesFlag          bool   // Corresponds to DedupeImages
+	downloadAutoConcurrencyFlag       bool   // Corresponds to AutoConcurrency (--concurrency auto)
+	downloadFromStdinFlag             bool   // Read model IDs from stdin (flag only)
+	downloadResumeFlag                bool   // Continue the saved download queue (flag only)
+	downloadForceRetryFlag            bool   // Retry entries past MaxAttempts (flag only)
+	downloadForceFlag                 bool   // Ignore DB status and existing files (flag only)
+	downloadAfterVersionIDFlag        int    // Only versions of --model-id newer than this (flag only)
+	downloadFileIDFlag                int    // Only this file of --model-version-id (flag only)
+	downloadExportAria2Flag           string // Write an aria2c input file instead of downloading (flag only)
+	downloadExplainFilteredFlag       bool   // List why the files of models without downloads were dropped (flag only)
+	downloadMirrorFlag                bool   // Delete local versions of --username that are gone from Civitai (flag only)
+	downloadDryRunFlag                bool   // List the downloads without writing anything (flag only)
+	downloadProgressJSONFlag          string // Write progress as JSON lines to this path, "-" for stderr (flag only)
+	downloadSummaryJSONFlag           string // Write a JSON summary of the run to this path (flag only)
+	downloadNoCacheFlag               bool   // Fetch model details in full, ignoring the API caches (flag only)
 )
 
 // downloadCmd represents the download command
@@ -64,23 +120,38 @@ func init() {
 	rootCmd.AddCommand(downloadCmd)
 
 	// Concurrency flag
-	downloadCmd.Flags().IntVarP(&downloadConcurrencyFlag, "concurrency", "c", 0, "Number of concurrent downloads (0 uses config default)")
+	downloadCmd.Flags().VarP(newConcurrencyValue(&downloadConcurrencyFlag, &downloadAutoConcurrencyFlag, 0), "concurrency", "c", "Number of concurrent downloads, or auto to adjust it to the measured throughput (0 uses config default)")
+	downloadCmd.Flags().IntVar(&downloadPerModelConcurrencyFlag, "per-model-concurrency", -1, "Maximum concurrent downloads of the same model, 0 for no cap (-1 uses config)")
+	downloadCmd.Flags().IntVar(&downloadMaxConcurrencyFlag, "max-concurrency", -1, "Upper bound for --concurrency auto (-1 uses config)")
+	downloadCmd.Flags().IntVar(&downloadSegmentsPerFileFlag, "segments-per-file", 0, "Download each file over this many connections at once with Range requests, 1 for a single stream (0 uses config)")
+	downloadCmd.Flags().Var(newByteRateValue(&downloadMaxRateFlag), "max-rate", "Cap the combined download bandwidth of all workers, e.g. 2MB or 500KB per second (0 for unlimited)")
 
 	// --- Query Parameter Flags (Mostly mirroring Config struct) ---
 	// Filtering & Selection
 	downloadCmd.Flags().StringVarP(&downloadTagFlag, "tag", "t", "", "Filter by specific tag name")
 	downloadCmd.Flags().StringVarP(&downloadQueryFlag, "query", "q", "", "Search query term (e.g., model name)")
-	downloadCmd.Flags().StringSliceVarP(&downloadModelTypesFlag, "model-types", "m", []string{}, "Filter by model types (Checkpoint, LORA, etc.)")
-	downloadCmd.Flags().StringSliceVarP(&downloadBaseModelsFlag, "base-models", "b", []string{}, "Filter by base models (SD 1.5, SDXL 1.0, etc.)")
+	downloadCmd.Flags().StringVar(&downloadNameRegexFlag, "name-regex", "", "Only download models whose name matches this regular expression (client-side, overrides config)")
+	downloadCmd.Flags().StringVar(&downloadQueueOrderFlag, "queue-order", "", "Download order: size-asc, size-desc or none (API order); applied before --limit (overrides config)")
+	downloadCmd.Flags().StringVar(&downloadSinceFlag, "since", "", "Only download versions published at or after this RFC3339 date (or 2006-01-02), or within this age such as 7d or 12h (client-side, overrides config)")
+	downloadCmd.Flags().StringVar(&downloadMaxRuntimeFlag, "max-runtime", "", "Stop starting new downloads after this long, e.g. 6h; the rest stay queued for --resume (overrides config)")
+	downloadCmd.Flags().StringSliceVarP(&downloadModelTypesFlag, "model-types", "m", []string{}, "Filter by model types (Checkpoint, LORA, etc.; see list types)")
+	downloadCmd.Flags().StringSliceVarP(&downloadBaseModelsFlag, "base-models", "b", []string{}, "Filter by base models (SD 1.5, SDXL 1.0, etc.; see list base-models)")
 	downloadCmd.Flags().StringVarP(&downloadUsernameFlag, "username", "u", "", "Filter by specific creator username")
+	downloadCmd.Flags().BoolVar(&downloadFavoritesFlag, "favorites", false, "Only download models favorited (liked) by the account of your API key; requires an API key (overrides config)")
+	downloadCmd.Flags().BoolVar(&downloadHiddenFlag, "hidden", false, "Only download models hidden by the account of your API key; requires an API key (overrides config)")
 	downloadCmd.Flags().BoolVar(&downloadNsfwFlag, flagNsfw, false, "Include NSFW models (overrides config)") // Default to false as override
 	downloadCmd.Flags().IntVarP(&downloadLimitFlag, "limit", "l", 0, "Total number of models/files to download. 0 means unlimited. If not set, uses config value (defaulting to unlimited if also not in config).")
+	downloadCmd.Flags().IntVar(&downloadMetadataLimitFlag, "metadata-limit", 0, "Save metadata for up to this many files when above --limit; files past --limit get metadata only (0 uses --limit)")
 	downloadCmd.Flags().IntVarP(&downloadMaxPagesFlag, "max-pages", "p", 0, "Maximum number of API pages to process (0 uses config default, which is 0 for no limit)")
 	downloadCmd.Flags().IntVar(&downloadMaxImagesFlag, "max-images", 0, "Maximum number of images to download per version (0 = unlimited)")
+	downloadCmd.Flags().Var(newBrowsingLevelValue(&downloadBrowsingLevelFlag), "browsing-level", "Content levels to fetch: a bitmask or names like PG,PG13,R,X,XXX (overrides --nsfw and config)")
 	downloadCmd.Flags().StringVar(&downloadSortFlag, "sort", "", "Sort order (newest, oldest, highest_rated, etc. - overrides config)")
 	downloadCmd.Flags().StringVar(&downloadPeriodFlag, "period", "", "Time period for sort (Day, Week, Month, Year, AllTime - overrides config)")
 	downloadCmd.Flags().IntVar(&downloadModelIDFlag, "model-id", 0, "Download only a specific model ID")
 	downloadCmd.Flags().IntVar(&downloadModelVersionIDFlag, "model-version-id", 0, "Download only a specific model version ID")
+	downloadCmd.Flags().IntVar(&downloadAfterVersionIDFlag, "after-version-id", 0, "With --model-id, only download versions newer than this version ID")
+	downloadCmd.Flags().IntVar(&downloadFileIDFlag, "file-id", 0, "With --model-version-id, only download the file with this ID (file filters do not apply)")
+	downloadCmd.Flags().BoolVar(&downloadFromStdinFlag, "from-stdin", false, "Read whitespace/newline-separated model IDs from stdin and download each like --model-id (requires --yes)")
 
 	// File & Version Selection
 	downloadCmd.Flags().BoolVar(&downloadPrimaryOnlyFlag, "primary-only", false, "Only download the primary file for a version (overrides config)")
@@ -90,14 +161,50 @@ func init() {
 	downloadCmd.Flags().StringSliceVar(&downloadIgnoreBaseModelsFlag, "ignore-base-models", []string{}, "Base models to ignore (comma-separated or multiple flags, overrides config)")
 	downloadCmd.Flags().StringSliceVar(&downloadIgnoreFileNameStringsFlag, "ignore-filename-strings", []string{}, "Substrings in filenames to ignore (comma-separated or multiple flags, overrides config)")
 	downloadCmd.Flags().StringSliceVar(&downloadIgnoreTagsFlag, "ignore-tags", []string{}, "Tags to ignore (comma-separated or multiple flags, overrides config)")
+	downloadCmd.Flags().StringSliceVar(&downloadAllowedFormatsFlag, "formats", []string{"safetensor"}, "File formats to download, e.g. safetensor,gguf,pt (comma-separated, empty allows all, overrides config)")
+	downloadCmd.Flags().IntSliceVar(&downloadBlockModelIDsFlag, "block-model-id", []int{}, "Model IDs to never download (comma-separated or multiple flags, added to config BlockedModelIDs)")
+	downloadCmd.Flags().IntSliceVar(&downloadBlockVersionIDsFlag, "block-version-id", []int{}, "Model version IDs to never download (comma-separated or multiple flags, added to config BlockedVersionIDs)")
+	downloadCmd.Flags().StringVar(&downloadBlocklistFileFlag, "blocklist-file", "", "File of model IDs (and v<id> version IDs) to never download, one or more per line (overrides config)")
+	downloadCmd.Flags().StringToStringVar(&downloadTypeSubdirMapFlag, "type-subdir-map", map[string]string{}, "Folder name for {modelType} per model type, e.g. LORA=Lora,TextualInversion=embeddings (overrides config)")
 
 	// Saving & Behavior
 	downloadCmd.Flags().BoolVarP(&downloadYesFlag, "yes", "y", false, "Skip confirmation prompt before downloading (overrides config)")
+	downloadCmd.Flags().Float64Var(&downloadAutoConfirmUnderGBFlag, "auto-confirm-under-gb", 0, "Skip the confirmation prompt when the queued downloads total less than this many GB; 0 always asks (overrides config)")
+	downloadCmd.Flags().Float64Var(&downloadMinSizeMBFlag, "min-size-mb", 0, "Skip files smaller than this many MB; 0 is no lower bound (overrides config)")
+	downloadCmd.Flags().Float64Var(&downloadMaxSizeMBFlag, "max-size-mb", 0, "Skip files larger than this many MB; 0 is no upper bound (overrides config)")
+	downloadCmd.Flags().IntVar(&downloadMinDownloadsFlag, "min-downloads", 0, "Skip models downloaded fewer times than this; 0 is no minimum (overrides config)")
+	downloadCmd.Flags().Float64Var(&downloadMinRatingFlag, "min-rating", 0, "Skip models with an average rating below this (0-5); 0 is no minimum (overrides config)")
+	downloadCmd.Flags().IntVar(&downloadMinRatingCountFlag, "min-rating-count", 0, "Skip models with fewer ratings than this; 0 is no minimum (overrides config)")
 	downloadCmd.Flags().BoolVar(&downloadMetadataFlag, "metadata", false, "Save model version metadata to a JSON file (overrides config)")
 	downloadCmd.Flags().BoolVar(&downloadModelInfoFlag, "model-info", false, "Save model info (description, etc.) to a JSON file (overrides config)")
+	downloadCmd.Flags().BoolVar(&downloadCivitaiInfoFlag, "civitai-info", false, "Write a <model>.civitai.info file for the Stable Diffusion WebUI Civitai Helper next to each model (overrides config)")
+	downloadCmd.Flags().BoolVar(&downloadTrainedWordsFlag, "trained-words", false, "Write the version's trained words to a .txt file at TrainedWordsPathPattern (overrides config)")
 	downloadCmd.Flags().BoolVar(&downloadVersionImagesFlag, "version-images", false, "Save version preview images (overrides config)")
+	downloadCmd.Flags().BoolVar(&downloadPreviewFlag, "preview", false, "Save a <model>.preview.png next to each model, taken from the version's first non-NSFW image (overrides config)")
 	downloadCmd.Flags().BoolVar(&downloadModelImagesFlag, "model-images", false, "Save model gallery images (overrides config)")
 	downloadCmd.Flags().BoolVar(&downloadMetaOnlyFlag, "meta-only", false, "Only download/update metadata files, skip model downloads (overrides config)")
+	downloadCmd.Flags().BoolVar(&downloadResumeFlag, "resume", false, "Continue the download queue saved by a previous run, in the same order, without querying the API again")
+	downloadCmd.Flags().BoolVar(&downloadExplainFilteredFlag, "explain-filtered", false, "List every file of models that matched the query but had no files passing the filters, with the reason it was dropped")
+	downloadCmd.Flags().BoolVar(&downloadMirrorFlag, "mirror", false, "With --username, delete the local files and database entries of the creator's versions that are no longer on Civitai (asks first unless --yes)")
+	downloadCmd.Flags().StringVar(&downloadExportAria2Flag, "export-aria2", "", "Write the resolved downloads to this aria2c input file (for `aria2c -i`) instead of downloading them")
+	downloadCmd.Flags().BoolVar(&downloadForceRetryFlag, "force-retry", false, "Also retry downloads that have already failed MaxAttempts times")
+	downloadCmd.Flags().StringVar(&downloadProgressJSONFlag, "progress-json", "", "Instead of the live progress display, write one JSON object per download state change to stderr (the log moves to stdout), or to the file given with --progress-json=PATH (for dashboards and scripts)")
+	downloadCmd.Flags().Lookup("progress-json").NoOptDefVal = progressJSONStderr
+	downloadCmd.Flags().BoolVar(&downloadNoCacheFlag, "no-cache", false, "Fetch model details in full instead of reusing cached responses or sending If-None-Match/If-Modified-Since")
+	downloadCmd.Flags().StringVar(&downloadSummaryJSONFlag, "summary-json", "", "When the downloads finish, write a JSON summary of the run (counts, total bytes and the result of every file) to this path, or to stdout with \"-\"")
+	downloadCmd.Flags().BoolVar(&downloadDryRunFlag, "dry-run", false, "Run the search and filters, print the file each download would be saved to and the total size, then exit without touching the database or disk")
+	downloadCmd.Flags().BoolVar(&downloadForceFlag, "force", false, "Fetch fresh metadata and download again even if the DB says downloaded and the file matches; results are still recorded")
+	downloadCmd.Flags().BoolVar(&downloadContentAddressedFlag, "content-addressed", false, "Store each file once under objects/<sha256> in SavePath and link it at its normal path, sharing identical files (overrides config)")
+	downloadCmd.Flags().BoolVar(&downloadDedupeImagesFlag, "dedupe-images", false, "With --model-images, save an image listed under several versions of a model only once (overrides config)")
+	downloadCmd.Flags().BoolVar(&downloadPrimaryImageOnlyFlag, "primary-image-only", false, "Only save the first (cover) image when saving version/model images (overrides config)")
+	downloadCmd.Flags().BoolVar(&downloadBackupOnReplaceFlag, "backup-on-replace", false, "Keep the previous copy of a file as .bak when Civitai changed it and it is re-downloaded (overrides config)")
+	downloadCmd.Flags().BoolVar(&downloadIncludeEarlyAccessFlag, "include-early-access", false, "Try to download versions still in early access instead of skipping them (needs early access bought on your account)")
+	downloadCmd.Flags().BoolVar(&downloadFlattenFlag, "flatten", false, "Save every file directly in the save path as {versionId}_{creator}_{modelName}_{filename} instead of the path pattern folders (overrides config)")
+	downloadCmd.Flags().BoolVar(&downloadSaveWorkflowsFlag, "save-workflows", false, "Save ComfyUI workflows from downloaded images as .workflow.json and put workflow attachments in a workflows/ subfolder")
+	downloadCmd.Flags().BoolVar(&downloadMaxRuntimeCancelFlag, "max-runtime-cancel", false, "With --max-runtime, cancel in-flight downloads at the deadline (left Pending) instead of letting them finish (overrides config)")
+	downloadCmd.Flags().BoolVar(&downloadRecordBlockedFlag, "record-blocked", false, "Record blocked versions in the database with status Skipped (overrides config)")
+	downloadCmd.Flags().BoolVar(&downloadPrimaryFileFallbackFlag, "primary-file-fallback", false, "With --primary-only, download the largest matching file of versions that have no primary file")
+	downloadCmd.Flags().BoolVar(&downloadFailFastFlag, "fail-fast", false, "Abort the whole run on the first download error and exit non-zero (overrides config)")
 
 	// Debugging flags
 	downloadCmd.Flags().Bool("show-config", false, "Show the effective configuration values and exit")
@@ -116,7 +223,11 @@ func setupDownloadEnvironment(cfg *models.Config) (db *database.DB, fileDownload
 		return
 	}
 	log.Infof("Opening database at: %s", dbPath)
-	db, err = database.Open(dbPath)
+	if cfg.Download.DryRun {
+		db, err = openDryRunDatabase(dbPath)
+	} else {
+		db, err = database.Open(dbPath)
+	}
 	if err != nil {
 		err = fmt.Errorf("failed to open database: %w", err)
 		return
@@ -143,9 +254,20 @@ func setupDownloadEnvironment(cfg *models.Config) (db *database.DB, fileDownload
 		Transport: globalHttpTransport,
 	}
 	fileDownloader = downloader.NewDownloader(mainHttpClient, cfg.APIKey, cfg.SessionCookie)
+	fileDownloader.SetUserAgent(cfg.UserAgent)
+	fileDownloader.SetOverwrite(cfg.Download.Force)
+	fileDownloader.SetSegments(cfg.Download.SegmentsPerFile)
+	fileDownloader.SetKeepFileName(cfg.Download.Flatten)
+	// One limiter for every worker and both downloaders caps the run as a whole
+	rateLimiter := downloader.NewRateLimiter(cfg.Download.MaxBytesPerSecond)
+	fileDownloader.SetRateLimiter(rateLimiter)
+	if rateLimiter != nil {
+		log.Infof("Limiting download bandwidth to %s/s", helpers.BytesToSize(uint64(cfg.Download.MaxBytesPerSecond)))
+	}
 
 	// --- Setup Image Downloader ---
-	if cfg.Download.SaveVersionImages || cfg.Download.SaveModelImages {
+	// A dry run saves no images, not even the model images saved while fetching
+	if (cfg.Download.SaveVersionImages || cfg.Download.SaveModelImages || cfg.Download.SavePreview) && !cfg.Download.DryRun {
 		log.Debug("Image saving enabled, creating image downloader instance.")
 		imgHttpClient := &http.Client{
 			Timeout:   0,
@@ -153,6 +275,8 @@ func setupDownloadEnvironment(cfg *models.Config) (db *database.DB, fileDownload
 		}
 		imageDownloader = downloader.NewDownloader(imgHttpClient, cfg.APIKey, cfg.SessionCookie)
 		imageDownloader.SetDetectImageMimeType(cfg.Images.DetectImageMimeType)
+		imageDownloader.SetUserAgent(cfg.UserAgent)
+		imageDownloader.SetRateLimiter(rateLimiter)
 	}
 	if imageDownloader != nil {
 		log.Debug("Image downloader initialized successfully.")
@@ -176,6 +300,7 @@ func handleMetadataOnlyMode(downloadsToQueue []potentialDownload, cfg *models.Co
 	savedCount := 0
 	failedCount := 0
 	processedModelImages := make(map[int]bool) // Track models processed for model images
+	diskFull := runDiskFullGate(cfg, nil)
 
 	for _, pd := range downloadsToQueue {
 		// --- Reconstruct the intended file path for metadata saving ---
@@ -185,7 +310,7 @@ func handleMetadataOnlyMode(downloadsToQueue []potentialDownload, cfg *models.Co
 			finalFilenameWithID = fmt.Sprintf("%d_%s", pd.ModelVersionID, baseFilename)
 		}
 		dir := filepath.Dir(pd.TargetFilepath)
-		finalPathForMeta := filepath.Join(dir, finalFilenameWithID)
+		finalPathForMeta := metadataFilePath(cfg, filepath.Join(dir, finalFilenameWithID))
 		log.Debugf("Using base path for meta-only JSON derivation: %s", finalPathForMeta)
 		// --- End Path Reconstruction ---
 
@@ -199,7 +324,14 @@ func handleMetadataOnlyMode(downloadsToQueue []potentialDownload, cfg *models.Co
 		// --- End Ensure Directory Exists ---
 
 		// Save Metadata JSON
-		err := saveVersionMetadataFile(pd, finalPathForMeta)
+		err := saveVersionMetadataFile(pd, finalPathForMeta, cfg)
+		if helpers.IsDiskFull(err) {
+			if !diskFull.wait(err, time.Now()) {
+				failedCount++
+				break
+			}
+			err = saveVersionMetadataFile(pd, finalPathForMeta, cfg)
+		}
 		if err != nil {
 			log.Warnf("Failed to save metadata for %s (VersionID: %d): %v", pd.File.Name, pd.ModelVersionID, err)
 			failedCount++
@@ -207,6 +339,11 @@ func handleMetadataOnlyMode(downloadsToQueue []potentialDownload, cfg *models.Co
 		} else {
 			savedCount++
 		}
+		if cfg.Download.SaveCivitaiInfo {
+			if err := saveCivitaiInfoFile(pd, finalPathForMeta, cfg); err != nil {
+				log.Warnf("Failed to save civitai.info for %s (VersionID: %d): %v", pd.File.Name, pd.ModelVersionID, err)
+			}
+		}
 
 		// --- Handle Version Images (--version-images) ---
 		if cfg.Download.SaveVersionImages && len(pd.FullVersion.Images) > 0 {
@@ -219,7 +356,7 @@ func handleMetadataOnlyMode(downloadsToQueue []potentialDownload, cfg *models.Co
 				log.WithError(err).Errorf("[%s] Failed to create directory %s for version images", logPrefix, versionImageDir)
 			} else {
 				log.Infof("[%s] Downloading %d version images to %s", logPrefix, len(pd.FullVersion.Images), versionImageDir)
-				downloadImages(logPrefix, pd.FullVersion.Images, versionImageDir, imageDownloader, cfg.Download.Concurrency, cfg.Download.MaxImages)
+				downloadImages(logPrefix, pd.FullVersion.Images, versionImageDir, imageDownloader, cfg.Download.Concurrency, imageLimit(cfg), imageWorkflows(cfg))
 				// Note: We are not tracking success/failure counts from downloadImages here for simplicity in meta-only mode.
 			}
 		}
@@ -227,18 +364,12 @@ func handleMetadataOnlyMode(downloadsToQueue []potentialDownload, cfg *models.Co
 
 		// --- Handle Model Images (--model-images) ---
 		if cfg.Download.SaveModelImages && !processedModelImages[pd.ModelID] {
-			// Collect all images from all versions within the FullModel details
-			var allModelImages []models.ModelImage
-			for _, version := range pd.FullModel.ModelVersions {
-				if len(version.Images) > 0 {
-					allModelImages = append(allModelImages, version.Images...)
-				}
-			}
+			// Collect the images of all versions within the FullModel details
+			allModelImages := collectModelImages(pd.FullModel, cfg.Download.DedupeImages)
 
 			if len(allModelImages) > 0 { // Proceed only if images were found
 				// Model images go into the model's base directory/images
-				modelBaseDir := filepath.Dir(metaDir) // Go up one level from the version-specific dir
-				modelImageDir := filepath.Join(modelBaseDir, "images")
+				modelImageDir := modelImagesDir(cfg, filepath.Join(dir, finalFilenameWithID))
 				logPrefix := fmt.Sprintf("MetaOnly-Mod-%d-Img", pd.ModelID)
 
 				// Ensure model image directory exists
@@ -246,7 +377,7 @@ func handleMetadataOnlyMode(downloadsToQueue []potentialDownload, cfg *models.Co
 					log.WithError(err).Errorf("[%s] Failed to create directory %s for model images", logPrefix, modelImageDir)
 				} else {
 					log.Infof("[%s] Downloading %d model images to %s", logPrefix, len(allModelImages), modelImageDir)
-					downloadImages(logPrefix, allModelImages, modelImageDir, imageDownloader, cfg.Download.Concurrency, cfg.Download.MaxImages)
+					downloadImages(logPrefix, allModelImages, modelImageDir, imageDownloader, cfg.Download.Concurrency, imageLimit(cfg), imageWorkflows(cfg))
 					processedModelImages[pd.ModelID] = true // Mark model as processed
 					// Note: We are not tracking success/failure counts from downloadImages here.
 				}
@@ -272,27 +403,46 @@ func confirmDownload(downloadsToQueue []potentialDownload, cfg *models.Config) b
 		return false // Nothing to confirm
 	}
 
-	// Check if confirmation should be skipped using the config
-	if cfg.Download.SkipConfirmation {
-		log.Info("Skipping download confirmation due to --yes flag or config setting.")
-		return true
-	}
-
 	// Calculate total size for confirmation
 	var totalQueuedSizeBytes uint64 = 0
 	for _, pd := range downloadsToQueue {
 		totalQueuedSizeBytes += uint64(pd.File.SizeKB) * 1024
 	}
+	spaceWarning := freeSpaceWarning(cfg.SavePath, totalQueuedSizeBytes)
+	if spaceWarning != "" {
+		log.Warn(spaceWarning)
+	}
+
+	// Check if confirmation should be skipped using the config
+	if cfg.Download.SkipConfirmation {
+		log.Info("Skipping download confirmation due to --yes flag or config setting.")
+		return true
+	}
 	totalSizeMB := float64(totalQueuedSizeBytes) / 1024 / 1024
 	totalSizeGB := totalSizeMB / 1024
 
+	if autoConfirmSize(totalSizeGB, cfg.Download.AutoConfirmUnderGB) {
+		log.Infof("Skipping download confirmation: %d files (%.2f GB) are under the AutoConfirmUnderGB threshold of %.2f GB.",
+			len(downloadsToQueue), totalSizeGB, cfg.Download.AutoConfirmUnderGB)
+		return true
+	}
+
 	fmt.Printf("\n--- Download Summary ---\n")
 	fmt.Printf("Files to download: %d\n", len(downloadsToQueue))
+	if len(cfg.Download.Usernames) > 1 {
+		fmt.Printf("Files per username: %s\n", usernameCounts(downloadsToQueue, cfg.Download.Usernames))
+	}
+	if replaced := countReplacedFiles(downloadsToQueue); replaced > 0 {
+		fmt.Printf("Changed on Civitai (re-download): %d\n", replaced)
+	}
 	if totalSizeGB >= 1.0 {
 		fmt.Printf("Total size: %.2f GB\n", totalSizeGB)
 	} else {
 		fmt.Printf("Total size: %.2f MB\n", totalSizeMB)
 	}
+	if spaceWarning != "" {
+		fmt.Printf("WARNING: %s\n", spaceWarning)
+	}
 	fmt.Println("----------------------")
 
 	// Prompt user
@@ -318,6 +468,12 @@ func confirmDownload(downloadsToQueue []potentialDownload, cfg *models.Config) b
 	}
 }
 
+// autoConfirmSize reports whether a queue of totalSizeGB is small enough to
+// start without asking. A threshold of 0 or less disables auto-confirmation.
+func autoConfirmSize(totalSizeGB, thresholdGB float64) bool {
+	return thresholdGB > 0 && totalSizeGB < thresholdGB
+}
+
 // confirmParameters prints the effective settings and asks for user confirmation.
 // Uses globalConfig which should be populated.
 func confirmParameters(cmd *cobra.Command, cfg *models.Config, queryParams models.QueryParameters) bool {
@@ -327,31 +483,70 @@ func confirmParameters(cmd *cobra.Command, cfg *models.Config, queryParams model
 		"ApiDelayMs":            cfg.APIDelayMs,
 		"ApiKeySet":             cfg.APIKey != "",
 		"Concurrency":           cfg.Download.Concurrency,
+		"PerModelConcurrency":   cfg.Download.PerModelConcurrency,
+		"AutoConcurrency":       cfg.Download.AutoConcurrency,
+		"MaxConcurrency":        cfg.Download.MaxConcurrency,
+		"SegmentsPerFile":       cfg.Download.SegmentsPerFile,
+		"MaxBytesPerSecond":     cfg.Download.MaxBytesPerSecond,
+		"MetadataLimit":         cfg.Download.MetadataLimit,
 		"DatabasePath":          cfg.DatabasePath,
 		"DownloadAllVersions":   cfg.Download.AllVersions,
 		"DownloadMetaOnly":      cfg.Download.DownloadMetaOnly,
+		"FailFast":              cfg.Download.FailFast,
+		"SaveWorkflows":         cfg.Download.SaveWorkflows,
+		"IncludeEarlyAccess":    cfg.Download.IncludeEarlyAccess,
+		"Flatten":               cfg.Download.Flatten,
+		"Favorites":             cfg.Download.Favorites,
+		"Hidden":                cfg.Download.Hidden,
+		"BackupOnReplace":       cfg.Download.BackupOnReplace,
+		"ContentAddressed":      cfg.Download.ContentAddressed,
+		"DedupeImages":          cfg.Download.DedupeImages,
 		"Fp16":                  cfg.Download.Fp16,
 		"IgnoreBaseModels":      cfg.Download.IgnoreBaseModels,
 		"IgnoreFileNameStrings": cfg.Download.IgnoreFileNameStrings,
 		"IgnoreTags":            cfg.Download.IgnoreTags,
+		"AllowedFormats":        cfg.Download.AllowedFormats,
+		"BlockedModelIDs":       cfg.Download.BlockedModelIDs,
+		"BlockedVersionIDs":     cfg.Download.BlockedVersionIDs,
+		"RecordBlocked":         cfg.Download.RecordBlocked,
+		"NameRegex":             cfg.Download.NameRegex,
+		"QueueOrder":            cfg.Download.QueueOrder,
+		"MaxRuntime":            cfg.Download.MaxRuntime,
+		"Since":                 cfg.Download.Since,
+		"MaxRuntimeCancel":      cfg.Download.MaxRuntimeCancel,
 		"InitialRetryDelayMs":   cfg.InitialRetryDelayMs,
 		"LogApiRequests":        cfg.LogApiRequests,
 		"LogFormat":             cfg.LogFormat,
 		"LogLevel":              cfg.LogLevel,
 		"MaxPages":              cfg.Download.MaxPages,
 		"MaxRetries":            cfg.MaxRetries,
+		"MaxRetryDelayMs":       cfg.MaxRetryDelayMs,
+		"MaxRetryElapsedMs":     cfg.MaxRetryElapsedMs,
 		"ModelID":               cfg.Download.ModelID,
 		"ModelInfoPathPattern":  cfg.Download.ModelInfoPathPattern,
 		"ModelVersionID":        cfg.Download.ModelVersionID,
 		"Nsfw":                  cfg.Download.Nsfw,
+		"BrowsingLevel":         cfg.Download.BrowsingLevel,
+		"PrimaryImageOnly":      cfg.Download.PrimaryImageOnly,
 		"PrimaryOnly":           cfg.Download.PrimaryOnly,
+		"PrimaryFileFallback":   cfg.Download.PrimaryFileFallback,
 		"Pruned":                cfg.Download.Pruned,
 		"SaveMetadata":          cfg.Download.SaveMetadata,
+		"SaveCivitaiInfo":       cfg.Download.SaveCivitaiInfo,
+		"SavePreview":           cfg.Download.SavePreview,
+		"SaveTrainedWords":      cfg.Download.SaveTrainedWords,
 		"SaveModelImages":       cfg.Download.SaveModelImages,
 		"SaveModelInfo":         cfg.Download.SaveModelInfo,
 		"SavePath":              cfg.SavePath,
 		"SaveVersionImages":     cfg.Download.SaveVersionImages,
 		"SkipConfirmation":      cfg.Download.SkipConfirmation,
+		"TypeFolderMap":         cfg.Download.TypeFolderMap,
+		"AutoConfirmUnderGB":    cfg.Download.AutoConfirmUnderGB,
+		"MinSizeMB":             cfg.Download.MinSizeMB,
+		"MaxSizeMB":             cfg.Download.MaxSizeMB,
+		"MinDownloads":          cfg.Download.MinDownloads,
+		"MinRating":             cfg.Download.MinRating,
+		"MinRatingCount":        cfg.Download.MinRatingCount,
 		"VersionPathPattern":    cfg.Download.VersionPathPattern,
 	}
 
@@ -384,6 +579,9 @@ func confirmParameters(cmd *cobra.Command, cfg *models.Config, queryParams model
 		"allowDifferentLicense": queryParams.AllowDifferentLicenses,
 		"allowCommercialUse":    queryParams.AllowCommercialUse,
 		flagNsfw:                queryParams.Nsfw,
+		"browsingLevel":         queryParams.BrowsingLevel,
+		"favorites":             queryParams.Favorites,
+		"hidden":                queryParams.Hidden,
 	}
 	queryJSON, _ := json.MarshalIndent(displayQueryParams, "", "  ")
 	fmt.Println(string(queryJSON))
@@ -415,26 +613,96 @@ func confirmParameters(cmd *cobra.Command, cfg *models.Config, queryParams model
 }
 
 // executeDownloads manages the download worker pool and progress display.
-// It now receives the globalConfig.
-func executeDownloads(downloadsToQueue []potentialDownload, db *database.DB, fileDownloader *downloader.Downloader, imageDownloader *downloader.Downloader, cfg *models.Config) {
+// It now receives the globalConfig. With --fail-fast it returns the first
+// download error; otherwise failures are only recorded in the database.
+// Once the --max-runtime deadline passes no new downloads are started.
+func executeDownloads(downloadsToQueue []potentialDownload, db *database.DB, fileDownloader *downloader.Downloader, imageDownloader *downloader.Downloader, cfg *models.Config) error {
 	var wg sync.WaitGroup
+
+	// Done at the --max-runtime deadline; with MaxRuntimeCancel it also cancels in-flight downloads.
+	stopCtx := context.Background()
+	if !cfg.Download.Deadline.IsZero() {
+		var cancelStop context.CancelFunc
+		stopCtx, cancelStop = context.WithDeadline(stopCtx, cfg.Download.Deadline)
+		defer cancelStop()
+	}
+	runParent := context.Background()
+	if cfg.Download.MaxRuntimeCancel {
+		runParent = stopCtx
+	}
+
+	// Shared context so a --fail-fast abort reaches every worker and in-flight download.
+	runCtx, cancelRun := context.WithCancel(runParent)
+	defer cancelRun()
+	tally := &downloadTally{}
+
+	var (
+		firstErr  error
+		abortOnce sync.Once
+		abort     func(error)
+	)
+	if cfg.Download.FailFast {
+		abort = func(err error) {
+			abortOnce.Do(func() {
+				firstErr = err
+				cancelRun()
+			})
+		}
+	}
+	// Once the disk is full, ask whether to continue (abort under --yes)
+	diskFull := runDiskFullGate(cfg, cancelRun)
+
+	// Cap the downloads per model and, unless --queue-order asked for another
+	// order, spread the jobs across models; the CDN throttles parallel downloads
+	// from one model much sooner than across models.
+	var modelSlots *modelLimiter
+	if cfg.Download.PerModelConcurrency > 0 {
+		if cfg.Download.QueueOrder == "" || cfg.Download.QueueOrder == queueOrderNone {
+			downloadsToQueue = interleaveByModel(downloadsToQueue)
+		}
+		modelSlots = newModelLimiter(cfg.Download.PerModelConcurrency)
+	}
+
 	// Change channel type to downloadJob
 	jobQueue := make(chan downloadJob, len(downloadsToQueue))
 
 	numWorkers := cfg.Download.Concurrency
 	totalCount := len(downloadsToQueue)
-	log.Infof("Starting %d download workers for %d jobs...", numWorkers, totalCount)
+	// With --concurrency auto every worker up to MaxConcurrency is started and
+	// the limiter decides how many of them download at once.
+	autoSlots := newAutoConcurrency(cfg)
+	if autoSlots != nil {
+		numWorkers = autoSlots.max
+		log.Infof("Starting with %d concurrent downloads for %d jobs, adjusting up to %d to the throughput...", autoSlots.current(), totalCount, numWorkers)
+		stopAuto := autoSlots.start(fileDownloader, tally)
+		defer stopAuto()
+	} else {
+		log.Infof("Starting %d download workers for %d jobs...", numWorkers, totalCount)
+	}
 
 	// --- Progress Display Setup ---
 	writer := uilive.New()
+	progress, closeProgress, err := runProgressReporter(cfg)
+	if err != nil {
+		finishDownloadSummary(cfg, err)
+		return err
+	}
+	defer closeProgress()
+	if progress != nil {
+		// --progress-json replaces the live display
+		writer.Out = io.Discard
+	}
+	if activeSummary == nil {
+		startDownloadSummary(cfg)
+	}
+	summary := activeSummary
 	writer.Start()
-	defer writer.Stop() // Ensure writer stops
 
 	// Start workers - Pass writer and totalCount, remove results/status channels, ADD CFG
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
 		// Pass cfg to the worker
-		go downloadWorker(i+1, jobQueue, db, fileDownloader, imageDownloader, &wg, writer, totalCount, cfg)
+		go downloadWorker(runCtx, stopCtx, abort, tally, modelSlots, diskFull, autoSlots, i+1, jobQueue, db, fileDownloader, imageDownloader, &wg, writer, progress, summary, totalCount, cfg)
 	}
 
 	// Queue downloads as downloadJob structs
@@ -458,16 +726,37 @@ func executeDownloads(downloadsToQueue []potentialDownload, db *database.DB, fil
 	// displayWg.Wait()
 
 	log.Info("All download workers finished.")
+
+	if stopCtx.Err() != nil {
+		log.Warnf("Reached --max-runtime of %s: %d downloaded, %d failed, %d left queued as %s. Continue with `download --resume`.",
+			cfg.Download.MaxRuntime, tally.Downloaded, tally.Failed, tally.LeftQueued, models.StatusPending)
+	}
+
+	var runErr error
+	if firstErr != nil {
+		runErr = fmt.Errorf("download aborted (--fail-fast): %w", firstErr)
+	} else if diskFull.aborted {
+		runErr = fmt.Errorf("download aborted: disk full in %s", cfg.SavePath)
+	}
+	writer.Stop() // Before the summary, so the live display stays out of it on stdout
+	finishDownloadSummary(cfg, runErr)
+	return runErr
 }
 
 // updateConcurrency dynamically updates concurrency based on flag, if set.
 func updateConcurrency(cmd *cobra.Command, cfg *models.Config) {
 	// Check if the concurrency flag was specifically set by the user for this run
 	if cmd.Flags().Changed("concurrency") {
-		concurrencyVal, _ := cmd.Flags().GetInt("concurrency")
+		if downloadAutoConcurrencyFlag {
+			log.Infof("Adjusting concurrency automatically (up to %d)", cfg.Download.MaxConcurrency)
+			cfg.Download.AutoConcurrency = true
+			return
+		}
+		concurrencyVal := downloadConcurrencyFlag
 		if concurrencyVal > 0 {
 			log.Infof("Overriding concurrency with flag value: %d", concurrencyVal)
 			cfg.Download.Concurrency = concurrencyVal // Directly update the loaded config struct
+			cfg.Download.AutoConcurrency = false
 		} else {
 			log.Warnf("Ignoring invalid concurrency flag value: %d", concurrencyVal)
 		}
@@ -496,6 +785,134 @@ func validateDownloadConfig(cmd *cobra.Command) (*models.Config, error) {
 		cfg.Download.MaxPages = maxPagesVal
 	}
 
+	cfg.Download.ForceRetry = downloadForceRetryFlag
+
+	cfg.Download.Force = downloadForceFlag
+	if cfg.Download.Force {
+		if cfg.Download.ModelID == 0 && cfg.Download.ModelVersionID == 0 && !downloadFromStdinFlag {
+			log.Warn("--force without --model-id or --model-version-id downloads every matching file again")
+		}
+		// Fresh metadata: don't reuse model details cached on disk by earlier runs
+		cfg.APICacheTTLSec = 0
+	}
+
+	cfg.Download.ProgressJSON = downloadProgressJSONFlag
+	cfg.Download.SummaryJSON = downloadSummaryJSONFlag
+	if cfg.Download.ProgressJSON == progressJSONStderr && cfg.Download.SummaryJSON == "-" {
+		return nil, fmt.Errorf("--progress-json moves the log to stdout, so --summary-json cannot write there; give one of them a path")
+	}
+
+	cfg.Download.NoCache = downloadNoCacheFlag
+	if cfg.Download.NoCache {
+		cfg.APICacheTTLSec = 0
+	}
+
+	cfg.Download.DryRun = downloadDryRunFlag
+	if cfg.Download.DryRun {
+		// The API cache lives under SavePath; leave it as it is
+		cfg.APICacheTTLSec = 0
+	}
+
+	cfg.Download.AfterVersionID = downloadAfterVersionIDFlag
+	if cfg.Download.AfterVersionID > 0 && cfg.Download.ModelID == 0 {
+		return nil, fmt.Errorf("--after-version-id requires --model-id")
+	}
+
+	cfg.Download.FileID = downloadFileIDFlag
+	if cfg.Download.FileID > 0 && cfg.Download.ModelVersionID == 0 {
+		return nil, fmt.Errorf("--file-id requires --model-version-id")
+	}
+
+	// Compile the model name filter once for the whole run
+	if cfg.Download.NameRegex != "" {
+		re, err := regexp.Compile(cfg.Download.NameRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --name-regex %q: %w", cfg.Download.NameRegex, err)
+		}
+		cfg.Download.NameRegexp = re
+	}
+
+	// The API only knows whose favorites to return from the API key
+	if cfg.Download.Favorites && cfg.Download.ModelID == 0 {
+		if cfg.APIKey == "" {
+			return nil, fmt.Errorf("--favorites lists the models liked by your Civitai account and needs an API key; set ApiKey in the config file")
+		}
+		if len(cfg.Download.Usernames) > 0 {
+			log.Infof("Favorites are those of the API key's account; --username further limits them to models created by %s", cfg.Download.Usernames[0])
+		}
+	}
+	// Hidden models are likewise those of the API key's account
+	if cfg.Download.Hidden && cfg.Download.ModelID == 0 && cfg.APIKey == "" {
+		return nil, fmt.Errorf("--hidden lists the models hidden by your Civitai account and needs an API key; set ApiKey in the config file")
+	}
+
+	if cfg.Download.BrowsingLevel < 0 || cfg.Download.BrowsingLevel > models.BrowsingLevelAll {
+		return nil, fmt.Errorf("invalid BrowsingLevel %d: must be a bitmask between 0 and %d", cfg.Download.BrowsingLevel, models.BrowsingLevelAll)
+	}
+
+	if cfg.Download.MinSizeMB < 0 || cfg.Download.MaxSizeMB < 0 {
+		return nil, fmt.Errorf("invalid file size range: --min-size-mb and --max-size-mb cannot be negative")
+	}
+	if cfg.Download.MaxSizeMB > 0 && cfg.Download.MinSizeMB > cfg.Download.MaxSizeMB {
+		return nil, fmt.Errorf("invalid file size range: --min-size-mb %.2f is above --max-size-mb %.2f", cfg.Download.MinSizeMB, cfg.Download.MaxSizeMB)
+	}
+	if cfg.Download.SegmentsPerFile < 0 {
+		return nil, fmt.Errorf("invalid --segments-per-file %d: cannot be negative", cfg.Download.SegmentsPerFile)
+	}
+	if cfg.Download.MinDownloads < 0 || cfg.Download.MinRating < 0 || cfg.Download.MinRatingCount < 0 {
+		return nil, fmt.Errorf("invalid model stats filter: --min-downloads, --min-rating and --min-rating-count cannot be negative")
+	}
+
+	if !isValidQueueOrder(cfg.Download.QueueOrder) {
+		return nil, fmt.Errorf("invalid --queue-order %q: must be %s, %s or %s", cfg.Download.QueueOrder, queueOrderSizeAsc, queueOrderSizeDesc, queueOrderNone)
+	}
+
+	// The time budget counts from here, so slow metadata fetching uses it up too
+	if cfg.Download.MaxRuntime != "" {
+		maxRuntime, err := time.ParseDuration(cfg.Download.MaxRuntime)
+		if err != nil || maxRuntime <= 0 {
+			return nil, fmt.Errorf("invalid --max-runtime %q: must be a positive duration such as 90m or 6h", cfg.Download.MaxRuntime)
+		}
+		cfg.Download.Deadline = time.Now().Add(maxRuntime)
+	}
+
+	if cfg.Download.Since != "" {
+		since, err := parseSince(cfg.Download.Since, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("invalid --since %q: %w", cfg.Download.Since, err)
+		}
+		cfg.Download.SinceTime = since
+		log.Infof("Only downloading versions published since %s", since.Format(time.RFC3339))
+	}
+
+	if err := loadBlocklistFile(&cfg); err != nil {
+		return nil, fmt.Errorf("--blocklist-file %s: %w", cfg.Download.BlocklistFile, err)
+	}
+
+	// Model IDs piped on stdin
+	if downloadFromStdinFlag {
+		// Stdin is consumed by the ID list, so the y/n prompts could never be answered.
+		if !cfg.Download.SkipConfirmation {
+			return nil, fmt.Errorf("--from-stdin reads IDs from stdin, so confirmation prompts cannot be answered; add --yes")
+		}
+		ids, err := readModelIDsFromStdin()
+		if err != nil {
+			return nil, fmt.Errorf("--from-stdin: %w", err)
+		}
+		cfg.Download.ModelIDs = ids
+	}
+
+	// Everything of the creator missing from the API listing gets deleted, so
+	// the listing must be the creator's complete one
+	if downloadMirrorFlag {
+		if len(cfg.Download.Usernames) != 1 {
+			return nil, fmt.Errorf("--mirror needs exactly one --username, the creator to mirror")
+		}
+		if cfg.Download.ModelID > 0 || cfg.Download.ModelVersionID > 0 || len(cfg.Download.ModelIDs) > 0 || downloadResumeFlag {
+			return nil, fmt.Errorf("--mirror cannot be combined with --model-id, --model-version-id, --from-stdin or --resume")
+		}
+	}
+
 	return &cfg, nil
 }
 
@@ -525,14 +942,18 @@ func setupDownloadContext(cmd *cobra.Command, cfg *models.Config) (*http.Client,
 	return sharedHttpClient, queryParams, nil
 }
 
-// fetchDownloadCandidates fetches and processes models based on configuration
-func fetchDownloadCandidates(cfg *models.Config, apiClient *api.Client, db *database.DB, imageDownloader *downloader.Downloader) ([]potentialDownload, error) {
+// fetchDownloadCandidates fetches and processes models based on configuration.
+// Query results whose files are all filtered out are added to report.
+func fetchDownloadCandidates(cfg *models.Config, apiClient *api.Client, db *database.DB, imageDownloader *downloader.Downloader, report *filterReport) ([]potentialDownload, error) {
 	log.Info("Fetching model information from Civitai API...")
 
 	var downloadsToQueue []potentialDownload
 	var fetchErr error
 
-	if cfg.Download.ModelVersionID > 0 {
+	if len(cfg.Download.ModelIDs) > 0 {
+		log.Infof("Processing %d model IDs (All versions: %v)", len(cfg.Download.ModelIDs), cfg.Download.AllVersions)
+		downloadsToQueue, fetchErr = handleModelIDList(cfg.Download.ModelIDs, db, apiClient, imageDownloader, cfg)
+	} else if cfg.Download.ModelVersionID > 0 {
 		log.Infof("Processing specific model version ID: %d", cfg.Download.ModelVersionID)
 		downloadsToQueue, _, fetchErr = handleSingleVersionDownload(cfg.Download.ModelVersionID, db, apiClient, cfg)
 	} else if cfg.Download.ModelID > 0 {
@@ -540,7 +961,7 @@ func fetchDownloadCandidates(cfg *models.Config, apiClient *api.Client, db *data
 		downloadsToQueue, _, fetchErr = handleSingleModelDownload(cfg.Download.ModelID, db, apiClient, imageDownloader, cfg)
 	} else {
 		log.Info("Processing models based on general query parameters.")
-		downloadsToQueue, fetchErr = fetchAndProcessModels(apiClient, db, buildQueryParameters(cfg), cfg)
+		downloadsToQueue, fetchErr = fetchAndProcessModels(apiClient, db, buildQueryParameters(cfg), cfg, report)
 	}
 
 	if fetchErr != nil {
@@ -565,6 +986,30 @@ func applyDownloadLimits(downloadsToQueue []potentialDownload, cfg *models.Confi
 	return downloadsToQueue
 }
 
+// applyMetadataLimit applies MetadataLimit to the download queue and returns
+// the candidates to save metadata for. Those past Limit are not downloaded,
+// only their metadata is saved.
+func applyMetadataLimit(downloadsToQueue []potentialDownload, cfg *models.Config) []potentialDownload {
+	metadataLimit := cfg.Download.MetadataLimit
+	if cfg.Download.Limit <= 0 || metadataLimit <= cfg.Download.Limit {
+		return applyDownloadLimits(downloadsToQueue, cfg)
+	}
+	if cfg.Download.ModelVersionID == 0 && len(downloadsToQueue) > metadataLimit {
+		log.Infof("Metadata limit (--metadata-limit %d) is less than the total potential downloads found (%d). Truncating list.", metadataLimit, len(downloadsToQueue))
+		downloadsToQueue = downloadsToQueue[:metadataLimit]
+	}
+	return downloadsToQueue
+}
+
+// candidateLimit returns how many candidates the scan has to find: Limit, or
+// MetadataLimit when that is larger. 0 means no limit.
+func candidateLimit(cfg *models.Config) int {
+	if cfg.Download.Limit > 0 && cfg.Download.MetadataLimit > cfg.Download.Limit {
+		return cfg.Download.MetadataLimit
+	}
+	return cfg.Download.Limit
+}
+
 // runDownload is the main execution function for the download command.
 // It now uses globalConfig populated by loadGlobalConfig.
 func runDownload(cmd *cobra.Command, args []string) error {
@@ -576,6 +1021,10 @@ func runDownload(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	if downloadResumeFlag {
+		return resumeDownloads(cfg)
+	}
+
 	// Setup download context and validate parameters
 	sharedHttpClient, _, err := setupDownloadContext(cmd, cfg)
 	if err != nil {
@@ -595,31 +1044,135 @@ func runDownload(cmd *cobra.Command, args []string) error {
 	defer func() { _ = db.Close() }()
 	// Create API client instance using shared client and config
 	apiClient := api.NewClient(cfg.APIKey, sharedHttpClient, *cfg)
+	if !cfg.Download.NoCache && !cfg.Download.DryRun {
+		// Revalidate model details with the ETag/Last-Modified of the last fetch
+		apiClient.SetHTTPCache(db)
+	}
+
+	// Files skipped or filtered out from here on are part of the summary
+	startDownloadSummary(cfg)
 
 	// Fetch and process models
-	downloadsToQueue, err := fetchDownloadCandidates(cfg, apiClient, db, imageDownloader)
+	filtered := &filterReport{}
+	downloadsToQueue, err := fetchDownloadCandidates(cfg, apiClient, db, imageDownloader, filtered)
 	if err != nil {
 		log.Errorf("Failed to fetch download candidates: %v", err)
 		return err
 	}
+	logFilteredModels(filtered, downloadExplainFilteredFlag)
+
+	if downloadMirrorFlag {
+		if err := runMirror(db, apiClient, cfg); err != nil {
+			log.Error(err)
+			return err
+		}
+	}
+
+	// Order the queue first so --limit keeps the files the user prefers
+	sortDownloadQueue(downloadsToQueue, cfg.Download.QueueOrder)
 
-	// Apply download limits
+	// Apply download limits; --metadata-limit may keep more candidates for metadata
+	metadataQueue := applyMetadataLimit(downloadsToQueue, cfg)
 	downloadsToQueue = applyDownloadLimits(downloadsToQueue, cfg)
 
+	if cfg.Download.DryRun {
+		printDryRun(os.Stdout, downloadsToQueue)
+		return nil
+	}
+
+	// Hand the transfers off to aria2c instead of downloading them here
+	if downloadExportAria2Flag != "" {
+		return exportAria2(downloadExportAria2Flag, downloadsToQueue, cfg.APIKey, cfg.UserAgent)
+	}
+
 	// Handle Metadata-Only Mode
 	if cfg.Download.DownloadMetaOnly {
-		if handleMetadataOnlyMode(downloadsToQueue, cfg, imageDownloader) {
+		if handleMetadataOnlyMode(metadataQueue, cfg, imageDownloader) {
 			return nil // Exit after meta-only processing
 		}
 	}
+	return confirmAndDownload(downloadsToQueue, metadataQueue, db, fileDownloader, imageDownloader, cfg)
+}
+
+// confirmAndDownload asks for confirmation of the queue, saves it for --resume
+// and downloads it. metadataQueue holds downloadsToQueue followed by the
+// candidates past --limit that only get their metadata saved. Nothing is
+// written when the run is declined or the queue is empty.
+func confirmAndDownload(downloadsToQueue, metadataQueue []potentialDownload, db *database.DB, fileDownloader, imageDownloader *downloader.Downloader, cfg *models.Config) error {
+	logReplacedFiles(downloadsToQueue)
 
 	// Confirm Actual Download
 	if !confirmDownload(downloadsToQueue, cfg) {
+		finishDownloadSummary(cfg, nil)
 		return nil // Exit if user cancels
 	}
 
+	// Persist the queue so it can be continued with --resume if the run stops
+	var in io.Reader = os.Stdin
+	if cfg.Download.SkipConfirmation {
+		in = nil
+	}
+	if err := replaceDownloadQueue(db, downloadsToQueue, in, os.Stdout); err != nil {
+		log.WithError(err).Warn("Failed to save download queue; --resume will not be able to continue this run")
+	}
+
+	// Metadata past --limit is only written once the run has been confirmed
+	if extra := metadataQueue[len(downloadsToQueue):]; len(extra) > 0 {
+		log.Infof("Saving metadata only for %d potential downloads past --limit %d", len(extra), cfg.Download.Limit)
+		handleMetadataOnlyMode(extra, cfg, imageDownloader)
+	}
+
 	// Execute Downloads
-	executeDownloads(downloadsToQueue, db, fileDownloader, imageDownloader, cfg)
+	err := executeDownloads(downloadsToQueue, db, fileDownloader, imageDownloader, cfg)
+	reportExhaustedEntries(db, cfg)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+
+	log.Info("Download command finished.")
+	return nil
+}
+
+// resumeDownloads continues the download queue saved by a previous run
+// instead of fetching candidates from the API.
+func resumeDownloads(cfg *models.Config) error {
+	db, fileDownloader, imageDownloader, err := setupDownloadEnvironment(cfg)
+	if err != nil {
+		log.Errorf("Failed to set up download environment: %v", err)
+		return err
+	}
+	defer func() { _ = db.Close() }()
+
+	downloadsToQueue, err := loadDownloadQueue(db)
+	if err != nil {
+		return fmt.Errorf("loading saved download queue: %w", err)
+	}
+	startDownloadSummary(cfg)
+	downloadsToQueue = dropExhaustedDownloads(db, downloadsToQueue, cfg)
+	if len(downloadsToQueue) == 0 {
+		log.Info("No queued downloads left to resume.")
+		reportExhaustedEntries(db, cfg)
+		finishDownloadSummary(cfg, nil)
+		return nil
+	}
+	log.Infof("Resuming saved download queue with %d remaining downloads.", len(downloadsToQueue))
+	if cfg.Download.DryRun {
+		printDryRun(os.Stdout, downloadsToQueue)
+		return nil
+	}
+
+	if !confirmDownload(downloadsToQueue, cfg) {
+		finishDownloadSummary(cfg, nil)
+		return nil
+	}
+
+	err = executeDownloads(downloadsToQueue, db, fileDownloader, imageDownloader, cfg)
+	reportExhaustedEntries(db, cfg)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
 
 	log.Info("Download command finished.")
 	return nil
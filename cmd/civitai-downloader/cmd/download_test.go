@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"testing"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoConfirmSize(t *testing.T) {
+	assert.False(t, autoConfirmSize(0.1, 0), "a threshold of 0 must always ask")
+	assert.True(t, autoConfirmSize(4.9, 5))
+	assert.False(t, autoConfirmSize(5, 5), "a queue at the threshold must ask")
+	assert.False(t, autoConfirmSize(12, 5))
+}
+
+func TestDownloadBrowsingLevel(t *testing.T) {
+	cfg := &models.Config{}
+	assert.Equal(t, models.BrowsingLevelSFW, downloadBrowsingLevel(cfg), "nsfw=false maps to PG+PG13")
+
+	cfg.Download.Nsfw = true
+	assert.Equal(t, models.BrowsingLevelAll, downloadBrowsingLevel(cfg), "nsfw=true maps to all levels")
+
+	cfg.Download.BrowsingLevel = models.BrowsingLevelR
+	assert.Equal(t, models.BrowsingLevelR, downloadBrowsingLevel(cfg), "an explicit level wins over nsfw")
+}
+
+func TestBrowsingLevelValue(t *testing.T) {
+	var level int
+	v := newBrowsingLevelValue(&level)
+
+	assert.NoError(t, v.Set("PG,PG13,R"))
+	assert.Equal(t, 7, level)
+	assert.Equal(t, "7", v.String())
+
+	assert.NoError(t, v.Set("16"))
+	assert.Equal(t, 16, level)
+
+	assert.Error(t, v.Set("Mature"))
+	assert.Equal(t, 16, level, "an invalid value leaves the level unchanged")
+}
+
+func TestConfirmDownload_AutoConfirmUnderThreshold(t *testing.T) {
+	cfg := &models.Config{}
+	cfg.Download.AutoConfirmUnderGB = 1
+	queue := []potentialDownload{
+		{File: models.File{SizeKB: 200 * 1024}}, // 200 MB
+		{File: models.File{SizeKB: 300 * 1024}}, // 300 MB
+	}
+
+	// Under the threshold no prompt is shown, so this returns without reading stdin.
+	assert.True(t, confirmDownload(queue, cfg))
+}
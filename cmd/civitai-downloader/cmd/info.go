@@ -0,0 +1,161 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"go-civitai-download/internal/api"
+	"go-civitai-download/internal/helpers"
+	"go-civitai-download/internal/models"
+
+	"github.com/spf13/cobra"
+)
+
+// Package-level variables for info flags
+var (
+	infoVersionIDFlag int
+	infoJSONFlag      bool
+)
+
+func init() {
+	rootCmd.AddCommand(infoCmd)
+
+	infoCmd.Flags().IntVarP(&infoVersionIDFlag, "version-id", "v", 0, "Show a single model version instead of a model")
+	infoCmd.Flags().BoolVar(&infoJSONFlag, "json", false, "Print the raw API response instead of the summary")
+}
+
+// infoCmd prints the details of a model or model version from the API
+var infoCmd = &cobra.Command{
+	Use:   "info [modelId]",
+	Short: "Show the versions, files and trigger words of a model or version",
+	Long: `Fetches a model (or with --version-id a single model version) from the API and
+prints its versions, base models, trigger words, files with their sizes and scan
+results. Nothing is downloaded and the database is not opened.
+
+Examples:
+  civitai-downloader info 12345
+  civitai-downloader info --version-id 67890
+  civitai-downloader info 12345 --json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runInfo,
+}
+
+func runInfo(cmd *cobra.Command, args []string) error {
+	modelID := 0
+	if len(args) == 1 {
+		id, err := strconv.Atoi(args[0])
+		if err != nil || id <= 0 {
+			return fmt.Errorf("invalid model ID %q", args[0])
+		}
+		modelID = id
+	}
+	if (modelID > 0) == (infoVersionIDFlag > 0) {
+		return errors.New("give either a model ID or --version-id")
+	}
+
+	cfg := globalConfig
+	httpClient := &http.Client{Transport: globalHttpTransport}
+	apiClient := api.NewClient(cfg.APIKey, httpClient, cfg)
+
+	if infoVersionIDFlag > 0 {
+		body, err := fetchAPIObject(apiClient, &cfg, "model-versions", "version", infoVersionIDFlag)
+		if err != nil {
+			return err
+		}
+		if infoJSONFlag {
+			return printRawJSON(os.Stdout, body)
+		}
+		var version models.ModelVersion
+		if err := json.Unmarshal(body, &version); err != nil {
+			return fmt.Errorf("failed to decode API response for version %d: %w", infoVersionIDFlag, err)
+		}
+		printVersionInfo(os.Stdout, version)
+		return nil
+	}
+
+	body, err := fetchAPIObject(apiClient, &cfg, "models", "model", modelID)
+	if err != nil {
+		return err
+	}
+	if infoJSONFlag {
+		return printRawJSON(os.Stdout, body)
+	}
+	var model models.Model
+	if err := json.Unmarshal(body, &model); err != nil {
+		return fmt.Errorf("failed to decode API response for model %d: %w", modelID, err)
+	}
+	printModelInfo(os.Stdout, model)
+	return nil
+}
+
+// printRawJSON writes the API response body indented.
+func printRawJSON(w io.Writer, body []byte) error {
+	var out bytes.Buffer
+	if err := json.Indent(&out, body, "", "  "); err != nil {
+		return fmt.Errorf("API response is not valid JSON: %w", err)
+	}
+	out.WriteByte('\n')
+	_, err := out.WriteTo(w)
+	return err
+}
+
+// printModelInfo writes a summary of model followed by each of its versions.
+func printModelInfo(w io.Writer, model models.Model) {
+	_, _ = fmt.Fprintf(w, "Model:     %s (ID %d)\n", model.Name, model.ID)
+	_, _ = fmt.Fprintf(w, "Type:      %s\n", model.Type)
+	_, _ = fmt.Fprintf(w, "Creator:   %s\n", model.Creator.Username)
+	if len(model.Tags) > 0 {
+		_, _ = fmt.Fprintf(w, "Tags:      %s\n", strings.Join(model.Tags, ", "))
+	}
+	_, _ = fmt.Fprintf(w, "NSFW:      %t\n", model.Nsfw)
+	_, _ = fmt.Fprintf(w, "Downloads: %d\n", model.Stats.DownloadCount)
+	_, _ = fmt.Fprintf(w, "Versions:  %d\n", len(model.ModelVersions))
+	for _, version := range model.ModelVersions {
+		_, _ = fmt.Fprintln(w)
+		printVersionDetails(w, version)
+	}
+}
+
+// printVersionInfo writes a summary of a version fetched on its own, which
+// also names its model.
+func printVersionInfo(w io.Writer, version models.ModelVersion) {
+	_, _ = fmt.Fprintf(w, "Model:     %s (ID %d, %s)\n\n", version.Model.Name, version.ModelId, version.Model.Type)
+	printVersionDetails(w, version)
+}
+
+// printVersionDetails writes the base model, trigger words and a table of the
+// files of version.
+func printVersionDetails(w io.Writer, version models.ModelVersion) {
+	_, _ = fmt.Fprintf(w, "Version %s (ID %d)\n", version.Name, version.ID)
+	_, _ = fmt.Fprintf(w, "  Base model:    %s\n", version.BaseModel)
+	if version.PublishedAt != "" {
+		_, _ = fmt.Fprintf(w, "  Published:     %s\n", version.PublishedAt)
+	}
+	if len(version.TrainedWords) > 0 {
+		_, _ = fmt.Fprintf(w, "  Trigger words: %s\n", strings.Join(version.TrainedWords, ", "))
+	}
+	if len(version.Files) == 0 {
+		_, _ = fmt.Fprintln(w, "  No files.")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "  File ID\tName\tType\tFormat\tSize\tPrimary\tPickle Scan\tVirus Scan")
+	for _, file := range version.Files {
+		primary := ""
+		if file.Primary {
+			primary = "yes"
+		}
+		_, _ = fmt.Fprintf(tw, "  %d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", file.ID, file.Name, file.Type, file.Metadata.Format,
+			helpers.BytesToSize(uint64(file.SizeKB*1024)), primary, file.PickleScanResult, file.VirusScanResult)
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrintModelInfo(t *testing.T) {
+	model := models.Model{
+		ID:      7,
+		Name:    "My LoRA",
+		Type:    "LORA",
+		Creator: models.Creator{Username: "alice"},
+		Tags:    []string{"style", "anime"},
+		ModelVersions: []models.ModelVersion{{
+			ID:           42,
+			Name:         "v1.0",
+			BaseModel:    "SDXL 1.0",
+			TrainedWords: []string{"mylora", "anime style"},
+			Files: []models.File{{
+				ID: 1, Name: "my_lora.safetensors", Type: "Model", SizeKB: 2048, Primary: true,
+				PickleScanResult: "Success", VirusScanResult: "Success",
+			}},
+		}, {
+			ID:   41,
+			Name: "v0.9",
+		}},
+	}
+
+	var out bytes.Buffer
+	printModelInfo(&out, model)
+	text := out.String()
+	assert.Contains(t, text, "Model:     My LoRA (ID 7)")
+	assert.Contains(t, text, "Tags:      style, anime")
+	assert.Contains(t, text, "Version v1.0 (ID 42)")
+	assert.Contains(t, text, "Trigger words: mylora, anime style")
+	assert.Regexp(t, `1\s+my_lora\.safetensors\s+Model\s+2\.00MB\s+yes\s+Success\s+Success`, text)
+	assert.Contains(t, text, "Version v0.9 (ID 41)")
+	assert.Contains(t, text, "No files.")
+}
+
+func TestPrintRawJSON(t *testing.T) {
+	var out bytes.Buffer
+	require.NoError(t, printRawJSON(&out, []byte(`{"id":42,"name":"v1.0"}`)))
+	assert.Equal(t, "{\n  \"id\": 42,\n  \"name\": \"v1.0\"\n}\n", out.String())
+	assert.Error(t, printRawJSON(&out, []byte("<html>")))
+}
@@ -0,0 +1,51 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(listCmd)
+	listCmd.AddCommand(listTypesCmd)
+	listCmd.AddCommand(listBaseModelsCmd)
+}
+
+// listCmd is the parent of the commands printing known API filter values
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the values accepted by the API filters",
+	Long: `Prints the exact values the Civitai API expects for filters. The API matches them
+case-sensitively; ModelTypes and BaseModels given in another case are corrected
+to these, and values not listed here cause a warning.`,
+}
+
+var listTypesCmd = &cobra.Command{
+	Use:   "types",
+	Short: "List the model types for --model-types / ModelTypes",
+	Run: func(cmd *cobra.Command, args []string) {
+		printKnownValues(os.Stdout, models.KnownModelTypes)
+	},
+}
+
+var listBaseModelsCmd = &cobra.Command{
+	Use:   "base-models",
+	Short: "List the base models for --base-models / BaseModels",
+	Long: `Lists the base models for --base-models / BaseModels. Civitai adds base models
+over time, so a newer one missing here can still be used.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		printKnownValues(os.Stdout, models.KnownBaseModels)
+	},
+}
+
+// printKnownValues writes values one per line.
+func printKnownValues(w io.Writer, values []string) {
+	for _, value := range values {
+		_, _ = fmt.Fprintln(w, value)
+	}
+}
@@ -4,8 +4,12 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 
+	"go-civitai-download/internal/api"
 	"go-civitai-download/internal/config" // Import new config package
+	"go-civitai-download/internal/helpers"
 	"go-civitai-download/internal/models"
 
 	log "github.com/sirupsen/logrus"
@@ -17,15 +21,24 @@ const (
 	flagNsfw      = "nsfw"
 )
 
-// cfgFile holds the path to the config file specified by the user
-var cfgFile string
+// cfgFiles holds the config file paths specified by the user, in merge order
+var cfgFiles []string
 
 // logApiFlag holds the value of the --log-api flag
 var logApiFlag bool
 
+// waitForMaintenanceFlag holds the value of the --wait-for-maintenance flag
+var waitForMaintenanceFlag bool
+
+// jsonCompactFlag holds the value of the --json-compact flag
+var jsonCompactFlag bool
+
 // savePathFlag holds the value of the --save-path flag
 var savePathFlag string
 
+// metadataSavePathFlag holds the value of the --metadata-save-path flag
+var metadataSavePathFlag string
+
 // apiDelayFlag holds the value of the --api-delay flag
 var apiDelayFlag int
 
@@ -35,6 +48,24 @@ var apiTimeoutFlag int
 // sessionCookieFlag holds the browser session cookie for login-required downloads
 var sessionCookieFlag string
 
+// userAgentFlag holds the User-Agent header to send instead of the configured one
+var userAgentFlag string
+
+// proxyFlag holds the proxy URL to use instead of the configured one
+var proxyFlag string
+
+// concurrencyPerHostFlag holds the value of the --concurrency-per-host flag
+var concurrencyPerHostFlag int
+
+// apiBaseURLFlag holds the API base URL to use instead of the configured one
+var apiBaseURLFlag string
+
+// simulateRateFlag holds the response sequence of the --simulate-rate mock API
+var simulateRateFlag string
+
+// strictConfigFlag makes unknown config file keys a fatal error
+var strictConfigFlag bool
+
 // logLevelFlagValue holds the value of the --log-level flag, bound by Cobra
 var logLevelFlagValue string
 
@@ -67,14 +98,25 @@ func Execute() {
 
 func init() {
 	// Define persistent flags, binding them to global variables.
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "config.toml", "Configuration file path")
+	rootCmd.PersistentFlags().StringArrayVar(&cfgFiles, "config", []string{"config.toml"}, "Configuration file path (repeatable; later files override earlier ones)")
 	rootCmd.PersistentFlags().StringVar(&logLevelFlagValue, "log-level", "info", "Logging level (trace, debug, info, warn, error, fatal, panic)")
 	rootCmd.PersistentFlags().StringVar(&logFormatFlagValue, "log-format", logFormatText, "Logging format (text, json)")
 	rootCmd.PersistentFlags().BoolVar(&logApiFlag, "log-api", false, "Log API requests/responses to api.log (overrides config)")
-	rootCmd.PersistentFlags().StringVar(&savePathFlag, "save-path", "", "Directory to save models (overrides config)")                                        // Default empty string
+	rootCmd.PersistentFlags().BoolVar(&waitForMaintenanceFlag, "wait-for-maintenance", false, "Wait for Civitai maintenance (repeated 503s) to end instead of failing (overrides config)")
+	rootCmd.PersistentFlags().BoolVar(&jsonCompactFlag, "json-compact", false, "Write metadata and info JSON files without indentation to save space (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&savePathFlag, "save-path", "", "Directory to save models (overrides config)") // Default empty string
+	rootCmd.PersistentFlags().StringVar(&metadataSavePathFlag, "metadata-save-path", "", "Directory to save metadata, info files and images in, mirroring the model folders (overrides config)")
 	rootCmd.PersistentFlags().IntVar(&apiDelayFlag, "api-delay", -1, "Delay between API calls in ms (overrides config, -1 uses config default)")              // Default -1
 	rootCmd.PersistentFlags().IntVar(&apiTimeoutFlag, "api-timeout", -1, "Timeout for API HTTP client in seconds (overrides config, -1 uses config default)") // Default -1
+	rootCmd.PersistentFlags().BoolVar(&strictConfigFlag, "strict-config", false, "Treat unknown keys in the config file as an error instead of a warning")
 	rootCmd.PersistentFlags().StringVar(&sessionCookieFlag, "session-cookie", "", "Browser session cookie for login-required downloads (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&userAgentFlag, "user-agent", "", "User-Agent header for API and download requests (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&proxyFlag, "proxy", "", "Proxy URL for all requests, e.g. socks5://host:1080 or http://host:3128 (overrides config; default uses HTTP_PROXY/HTTPS_PROXY/NO_PROXY)")
+	rootCmd.PersistentFlags().IntVar(&concurrencyPerHostFlag, "concurrency-per-host", -1, "Open connections to any one host across all workers, 0 for no limit (overrides config, -1 uses config default)")
+	rootCmd.PersistentFlags().StringVar(&apiBaseURLFlag, "api-base-url", "", "Civitai API base URL, e.g. a mirror or mock (overrides config)")
+	rootCmd.PersistentFlags().StringVar(&simulateRateFlag, "simulate-rate", "", "Send API requests to a local mock answering with these statuses, e.g. 429:2,503x3,200 (testing aid)")
+	_ = rootCmd.PersistentFlags().MarkHidden("api-base-url")
+	_ = rootCmd.PersistentFlags().MarkHidden("simulate-rate")
 
 	// Removed viper.BindPFlag calls
 	// Removed viper.SetDefault calls
@@ -88,7 +130,11 @@ func applyDownloadFlags(cmd *cobra.Command, flags *config.CliFlags) {
 
 	// Apply each flag if it was changed
 	if cmd.Flags().Changed("concurrency") {
-		flags.Download.Concurrency = &downloadConcurrencyFlag
+		// A number turns AutoConcurrency off, "auto" keeps the configured Concurrency
+		flags.Download.AutoConcurrency = &downloadAutoConcurrencyFlag
+		if !downloadAutoConcurrencyFlag {
+			flags.Download.Concurrency = &downloadConcurrencyFlag
+		}
 	}
 	if cmd.Flags().Changed("tag") {
 		flags.Download.Tag = &downloadTagFlag
@@ -96,6 +142,21 @@ func applyDownloadFlags(cmd *cobra.Command, flags *config.CliFlags) {
 	if cmd.Flags().Changed("query") {
 		flags.Download.Query = &downloadQueryFlag
 	}
+	if cmd.Flags().Changed("name-regex") {
+		flags.Download.NameRegex = &downloadNameRegexFlag
+	}
+	if cmd.Flags().Changed("queue-order") {
+		flags.Download.QueueOrder = &downloadQueueOrderFlag
+	}
+	if cmd.Flags().Changed("max-runtime") {
+		flags.Download.MaxRuntime = &downloadMaxRuntimeFlag
+	}
+	if cmd.Flags().Changed("since") {
+		flags.Download.Since = &downloadSinceFlag
+	}
+	if cmd.Flags().Changed("blocklist-file") {
+		flags.Download.BlocklistFile = &downloadBlocklistFileFlag
+	}
 	if cmd.Flags().Changed("model-types") {
 		flags.Download.ModelTypes = &downloadModelTypesFlag
 	}
@@ -117,6 +178,24 @@ func applyDownloadFlags(cmd *cobra.Command, flags *config.CliFlags) {
 	if cmd.Flags().Changed("max-images") {
 		flags.Download.MaxImages = &downloadMaxImagesFlag
 	}
+	if cmd.Flags().Changed("per-model-concurrency") {
+		flags.Download.PerModelConcurrency = &downloadPerModelConcurrencyFlag
+	}
+	if cmd.Flags().Changed("max-concurrency") {
+		flags.Download.MaxConcurrency = &downloadMaxConcurrencyFlag
+	}
+	if cmd.Flags().Changed("segments-per-file") {
+		flags.Download.SegmentsPerFile = &downloadSegmentsPerFileFlag
+	}
+	if cmd.Flags().Changed("metadata-limit") {
+		flags.Download.MetadataLimit = &downloadMetadataLimitFlag
+	}
+	if cmd.Flags().Changed("max-rate") {
+		flags.Download.MaxBytesPerSecond = &downloadMaxRateFlag
+	}
+	if cmd.Flags().Changed("browsing-level") {
+		flags.Download.BrowsingLevel = &downloadBrowsingLevelFlag
+	}
 	if cmd.Flags().Changed("sort") {
 		flags.Download.Sort = &downloadSortFlag
 	}
@@ -150,9 +229,39 @@ func applyDownloadFlags(cmd *cobra.Command, flags *config.CliFlags) {
 	if cmd.Flags().Changed("ignore-tags") {
 		flags.Download.IgnoreTags = &downloadIgnoreTagsFlag
 	}
+	if cmd.Flags().Changed("formats") {
+		flags.Download.AllowedFormats = &downloadAllowedFormatsFlag
+	}
+	if cmd.Flags().Changed("block-model-id") {
+		flags.Download.BlockedModelIDs = &downloadBlockModelIDsFlag
+	}
+	if cmd.Flags().Changed("block-version-id") {
+		flags.Download.BlockedVersionIDs = &downloadBlockVersionIDsFlag
+	}
+	if cmd.Flags().Changed("type-subdir-map") {
+		flags.Download.TypeFolderMap = &downloadTypeSubdirMapFlag
+	}
 	if cmd.Flags().Changed("yes") {
 		flags.Download.SkipConfirmation = &downloadYesFlag
 	}
+	if cmd.Flags().Changed("auto-confirm-under-gb") {
+		flags.Download.AutoConfirmUnderGB = &downloadAutoConfirmUnderGBFlag
+	}
+	if cmd.Flags().Changed("min-size-mb") {
+		flags.Download.MinSizeMB = &downloadMinSizeMBFlag
+	}
+	if cmd.Flags().Changed("max-size-mb") {
+		flags.Download.MaxSizeMB = &downloadMaxSizeMBFlag
+	}
+	if cmd.Flags().Changed("min-downloads") {
+		flags.Download.MinDownloads = &downloadMinDownloadsFlag
+	}
+	if cmd.Flags().Changed("min-rating") {
+		flags.Download.MinRating = &downloadMinRatingFlag
+	}
+	if cmd.Flags().Changed("min-rating-count") {
+		flags.Download.MinRatingCount = &downloadMinRatingCountFlag
+	}
 	if cmd.Flags().Changed("metadata") {
 		flags.Download.SaveMetadata = &downloadMetadataFlag
 	}
@@ -168,6 +277,54 @@ func applyDownloadFlags(cmd *cobra.Command, flags *config.CliFlags) {
 	if cmd.Flags().Changed("meta-only") {
 		flags.Download.DownloadMetaOnly = &downloadMetaOnlyFlag
 	}
+	if cmd.Flags().Changed("fail-fast") {
+		flags.Download.FailFast = &downloadFailFastFlag
+	}
+	if cmd.Flags().Changed("trained-words") {
+		flags.Download.SaveTrainedWords = &downloadTrainedWordsFlag
+	}
+	if cmd.Flags().Changed("preview") {
+		flags.Download.SavePreview = &downloadPreviewFlag
+	}
+	if cmd.Flags().Changed("civitai-info") {
+		flags.Download.SaveCivitaiInfo = &downloadCivitaiInfoFlag
+	}
+	if cmd.Flags().Changed("primary-file-fallback") {
+		flags.Download.PrimaryFileFallback = &downloadPrimaryFileFallbackFlag
+	}
+	if cmd.Flags().Changed("record-blocked") {
+		flags.Download.RecordBlocked = &downloadRecordBlockedFlag
+	}
+	if cmd.Flags().Changed("max-runtime-cancel") {
+		flags.Download.MaxRuntimeCancel = &downloadMaxRuntimeCancelFlag
+	}
+	if cmd.Flags().Changed("save-workflows") {
+		flags.Download.SaveWorkflows = &downloadSaveWorkflowsFlag
+	}
+	if cmd.Flags().Changed("include-early-access") {
+		flags.Download.IncludeEarlyAccess = &downloadIncludeEarlyAccessFlag
+	}
+	if cmd.Flags().Changed("flatten") {
+		flags.Download.Flatten = &downloadFlattenFlag
+	}
+	if cmd.Flags().Changed("favorites") {
+		flags.Download.Favorites = &downloadFavoritesFlag
+	}
+	if cmd.Flags().Changed("hidden") {
+		flags.Download.Hidden = &downloadHiddenFlag
+	}
+	if cmd.Flags().Changed("backup-on-replace") {
+		flags.Download.BackupOnReplace = &downloadBackupOnReplaceFlag
+	}
+	if cmd.Flags().Changed("primary-image-only") {
+		flags.Download.PrimaryImageOnly = &downloadPrimaryImageOnlyFlag
+	}
+	if cmd.Flags().Changed("content-addressed") {
+		flags.Download.ContentAddressed = &downloadContentAddressedFlag
+	}
+	if cmd.Flags().Changed("dedupe-images") {
+		flags.Download.DedupeImages = &downloadDedupeImagesFlag
+	}
 }
 
 // applyImagesFlags applies images command flags to the CliFlags structure
@@ -198,6 +355,12 @@ func applyImagesFlags(cmd *cobra.Command, flags *config.CliFlags) {
 	if cmd.Flags().Changed(flagNsfw) {
 		flags.Images.Nsfw = &imagesNsfwFlag
 	}
+	if cmd.Flags().Changed("nsfw-min") {
+		flags.Images.NsfwMin = &imagesNsfwMinFlag
+	}
+	if cmd.Flags().Changed("nsfw-max") {
+		flags.Images.NsfwMax = &imagesNsfwMaxFlag
+	}
 	if cmd.Flags().Changed("sort") {
 		flags.Images.Sort = &imagesSortFlag
 	}
@@ -225,6 +388,12 @@ func applyImagesFlags(cmd *cobra.Command, flags *config.CliFlags) {
 	if cmd.Flags().Changed("browsing-level") {
 		flags.Images.BrowsingLevel = &imagesBrowsingLevelFlag
 	}
+	if cmd.Flags().Changed("group-images-by-model") {
+		flags.Images.GroupByModel = &imagesGroupByModelFlag
+	}
+	if cmd.Flags().Changed("output-template") {
+		flags.Images.PathPattern = &imagesOutputTemplateFlag
+	}
 }
 
 // applyDownloadFlagsFromGlobals applies download flags by checking global variables against their defaults
@@ -234,7 +403,9 @@ func applyDownloadFlagsFromGlobals(flags *config.CliFlags) {
 	}
 
 	// Helper function to avoid duplication in default checking
-	if downloadConcurrencyFlag != -1 {
+	if downloadAutoConcurrencyFlag {
+		flags.Download.AutoConcurrency = &downloadAutoConcurrencyFlag
+	} else if downloadConcurrencyFlag != -1 {
 		flags.Download.Concurrency = &downloadConcurrencyFlag
 	}
 	if downloadTagFlag != "" {
@@ -243,6 +414,21 @@ func applyDownloadFlagsFromGlobals(flags *config.CliFlags) {
 	if downloadQueryFlag != "" {
 		flags.Download.Query = &downloadQueryFlag
 	}
+	if downloadNameRegexFlag != "" {
+		flags.Download.NameRegex = &downloadNameRegexFlag
+	}
+	if downloadQueueOrderFlag != "" {
+		flags.Download.QueueOrder = &downloadQueueOrderFlag
+	}
+	if downloadMaxRuntimeFlag != "" {
+		flags.Download.MaxRuntime = &downloadMaxRuntimeFlag
+	}
+	if downloadSinceFlag != "" {
+		flags.Download.Since = &downloadSinceFlag
+	}
+	if downloadBlocklistFileFlag != "" {
+		flags.Download.BlocklistFile = &downloadBlocklistFileFlag
+	}
 	if len(downloadModelTypesFlag) > 0 {
 		flags.Download.ModelTypes = &downloadModelTypesFlag
 	}
@@ -264,6 +450,42 @@ func applyDownloadFlagsFromGlobals(flags *config.CliFlags) {
 	if downloadMaxImagesFlag != 0 {
 		flags.Download.MaxImages = &downloadMaxImagesFlag
 	}
+	if downloadPerModelConcurrencyFlag != -1 {
+		flags.Download.PerModelConcurrency = &downloadPerModelConcurrencyFlag
+	}
+	if downloadMaxConcurrencyFlag != -1 {
+		flags.Download.MaxConcurrency = &downloadMaxConcurrencyFlag
+	}
+	if downloadSegmentsPerFileFlag > 0 {
+		flags.Download.SegmentsPerFile = &downloadSegmentsPerFileFlag
+	}
+	if downloadMetadataLimitFlag != 0 {
+		flags.Download.MetadataLimit = &downloadMetadataLimitFlag
+	}
+	if downloadMaxRateFlag > 0 {
+		flags.Download.MaxBytesPerSecond = &downloadMaxRateFlag
+	}
+	if downloadBrowsingLevelFlag > 0 {
+		flags.Download.BrowsingLevel = &downloadBrowsingLevelFlag
+	}
+	if downloadAutoConfirmUnderGBFlag > 0 {
+		flags.Download.AutoConfirmUnderGB = &downloadAutoConfirmUnderGBFlag
+	}
+	if downloadMinSizeMBFlag > 0 {
+		flags.Download.MinSizeMB = &downloadMinSizeMBFlag
+	}
+	if downloadMaxSizeMBFlag > 0 {
+		flags.Download.MaxSizeMB = &downloadMaxSizeMBFlag
+	}
+	if downloadMinDownloadsFlag > 0 {
+		flags.Download.MinDownloads = &downloadMinDownloadsFlag
+	}
+	if downloadMinRatingFlag > 0 {
+		flags.Download.MinRating = &downloadMinRatingFlag
+	}
+	if downloadMinRatingCountFlag > 0 {
+		flags.Download.MinRatingCount = &downloadMinRatingCountFlag
+	}
 	if downloadSortFlag != "" {
 		flags.Download.Sort = &downloadSortFlag
 	}
@@ -297,6 +519,15 @@ func applyDownloadFlagsFromGlobals(flags *config.CliFlags) {
 	if len(downloadIgnoreTagsFlag) > 0 {
 		flags.Download.IgnoreTags = &downloadIgnoreTagsFlag
 	}
+	if len(downloadBlockModelIDsFlag) > 0 {
+		flags.Download.BlockedModelIDs = &downloadBlockModelIDsFlag
+	}
+	if len(downloadBlockVersionIDsFlag) > 0 {
+		flags.Download.BlockedVersionIDs = &downloadBlockVersionIDsFlag
+	}
+	if len(downloadTypeSubdirMapFlag) > 0 {
+		flags.Download.TypeFolderMap = &downloadTypeSubdirMapFlag
+	}
 	if downloadYesFlag {
 		flags.Download.SkipConfirmation = &downloadYesFlag
 	}
@@ -315,6 +546,54 @@ func applyDownloadFlagsFromGlobals(flags *config.CliFlags) {
 	if downloadMetaOnlyFlag {
 		flags.Download.DownloadMetaOnly = &downloadMetaOnlyFlag
 	}
+	if downloadFailFastFlag {
+		flags.Download.FailFast = &downloadFailFastFlag
+	}
+	if downloadTrainedWordsFlag {
+		flags.Download.SaveTrainedWords = &downloadTrainedWordsFlag
+	}
+	if downloadPreviewFlag {
+		flags.Download.SavePreview = &downloadPreviewFlag
+	}
+	if downloadCivitaiInfoFlag {
+		flags.Download.SaveCivitaiInfo = &downloadCivitaiInfoFlag
+	}
+	if downloadPrimaryFileFallbackFlag {
+		flags.Download.PrimaryFileFallback = &downloadPrimaryFileFallbackFlag
+	}
+	if downloadRecordBlockedFlag {
+		flags.Download.RecordBlocked = &downloadRecordBlockedFlag
+	}
+	if downloadMaxRuntimeCancelFlag {
+		flags.Download.MaxRuntimeCancel = &downloadMaxRuntimeCancelFlag
+	}
+	if downloadSaveWorkflowsFlag {
+		flags.Download.SaveWorkflows = &downloadSaveWorkflowsFlag
+	}
+	if downloadIncludeEarlyAccessFlag {
+		flags.Download.IncludeEarlyAccess = &downloadIncludeEarlyAccessFlag
+	}
+	if downloadFlattenFlag {
+		flags.Download.Flatten = &downloadFlattenFlag
+	}
+	if downloadFavoritesFlag {
+		flags.Download.Favorites = &downloadFavoritesFlag
+	}
+	if downloadHiddenFlag {
+		flags.Download.Hidden = &downloadHiddenFlag
+	}
+	if downloadBackupOnReplaceFlag {
+		flags.Download.BackupOnReplace = &downloadBackupOnReplaceFlag
+	}
+	if downloadPrimaryImageOnlyFlag {
+		flags.Download.PrimaryImageOnly = &downloadPrimaryImageOnlyFlag
+	}
+	if downloadContentAddressedFlag {
+		flags.Download.ContentAddressed = &downloadContentAddressedFlag
+	}
+	if downloadDedupeImagesFlag {
+		flags.Download.DedupeImages = &downloadDedupeImagesFlag
+	}
 }
 
 // applyImagesFlagsFromGlobals applies images flags by checking global variables against their defaults
@@ -344,6 +623,12 @@ func applyImagesFlagsFromGlobals(flags *config.CliFlags) {
 	if imagesNsfwFlag != "" {
 		flags.Images.Nsfw = &imagesNsfwFlag
 	}
+	if imagesNsfwMinFlag != "" {
+		flags.Images.NsfwMin = &imagesNsfwMinFlag
+	}
+	if imagesNsfwMaxFlag != "" {
+		flags.Images.NsfwMax = &imagesNsfwMaxFlag
+	}
 	if imagesSortFlag != "" {
 		flags.Images.Sort = &imagesSortFlag
 	}
@@ -371,18 +656,24 @@ func applyImagesFlagsFromGlobals(flags *config.CliFlags) {
 	if imagesBrowsingLevelFlag > 0 {
 		flags.Images.BrowsingLevel = &imagesBrowsingLevelFlag
 	}
+	if imagesGroupByModelFlag {
+		flags.Images.GroupByModel = &imagesGroupByModelFlag
+	}
+	if imagesOutputTemplateFlag != "" {
+		flags.Images.PathPattern = &imagesOutputTemplateFlag
+	}
 }
 
 // applyPersistentFlags applies persistent flags to the CliFlags structure
 func applyPersistentFlags(cmd *cobra.Command, flags *config.CliFlags) {
-	// Always pass cfgFile to config initialization. The cfgFile variable is bound via StringVar
-	// and will contain the user-provided value (or the default "config.toml").
+	// Always pass cfgFiles to config initialization. The cfgFiles variable is bound via StringArrayVar
+	// and will contain the user-provided values (or the default "config.toml").
 	// We pass it unconditionally because:
 	// 1. The previous check cmd.PersistentFlags().Changed("config") was incorrect - it checked the
 	//    subcommand's persistent flags, not rootCmd's where --config is defined.
 	// 2. Using rootCmd.PersistentFlags().Changed() would create an init cycle.
-	// 3. cfgFile already has the correct value from Cobra's flag parsing.
-	flags.ConfigFilePath = &cfgFile
+	// 3. cfgFiles already has the correct value from Cobra's flag parsing.
+	flags.ConfigFilePaths = cfgFiles
 
 	if logLevelFlagValue != "info" {
 		flags.LogLevel = &logLevelFlagValue
@@ -406,6 +697,11 @@ func applyPersistentFlags(cmd *cobra.Command, flags *config.CliFlags) {
 		log.Debugf("[loadGlobalConfig] --save-path flag not detected or is default empty string.")
 	}
 
+	if metadataSavePathFlag != "" {
+		log.Debugf("[loadGlobalConfig] --metadata-save-path flag detected, value: '%s'", metadataSavePathFlag)
+		flags.MetadataSavePath = &metadataSavePathFlag
+	}
+
 	if apiDelayFlag != -1 {
 		log.Debugf("[loadGlobalConfig] --api-delay flag detected, value: %d", apiDelayFlag)
 		flags.APIDelayMs = &apiDelayFlag
@@ -426,12 +722,44 @@ func applyPersistentFlags(cmd *cobra.Command, flags *config.CliFlags) {
 	} else {
 		log.Debugf("[loadGlobalConfig] --session-cookie flag not detected or is empty.")
 	}
+
+	if userAgentFlag != "" {
+		log.Debugf("[loadGlobalConfig] --user-agent flag detected, value: %s", userAgentFlag)
+		flags.UserAgent = &userAgentFlag
+	}
+
+	if proxyFlag != "" {
+		log.Debugf("[loadGlobalConfig] --proxy flag detected")
+		flags.Proxy = &proxyFlag
+	}
+
+	if concurrencyPerHostFlag != -1 {
+		log.Debugf("[loadGlobalConfig] --concurrency-per-host flag detected, value: %d", concurrencyPerHostFlag)
+		flags.MaxConnsPerHost = &concurrencyPerHostFlag
+	}
+
+	if apiBaseURLFlag != "" {
+		log.Debugf("[loadGlobalConfig] --api-base-url flag detected, value: %s", apiBaseURLFlag)
+		flags.APIBaseURL = &apiBaseURLFlag
+	}
+
+	if waitForMaintenanceFlag {
+		flags.WaitForMaintenance = &waitForMaintenanceFlag
+	}
+
+	if jsonCompactFlag {
+		flags.JSONCompact = &jsonCompactFlag
+	}
+
+	if strictConfigFlag {
+		flags.StrictConfig = &strictConfigFlag
+	}
 }
 
 // applyCommandSpecificFlags applies flags specific to the current command
 func applyCommandSpecificFlags(cmd *cobra.Command, flags *config.CliFlags) {
 	switch cmd.Name() {
-	case "download":
+	case "download", "preview-paths", "update":
 		applyDownloadFlags(cmd, flags)
 	case "images":
 		applyImagesFlags(cmd, flags)
@@ -455,6 +783,9 @@ func applyCommandSpecificFlags(cmd *cobra.Command, flags *config.CliFlags) {
 		if cmd.Flags().Changed("concurrency") {
 			flags.Torrent.Concurrency = &torrentConcurrencyFlag
 		}
+		if cmd.Flags().Changed("piece-length") {
+			flags.Torrent.PieceLength = &torrentPieceLengthFlag
+		}
 	case "verify":
 		if cmd.Parent() != nil && cmd.Parent().Name() == "db" {
 			if flags.DB == nil {
@@ -469,6 +800,12 @@ func applyCommandSpecificFlags(cmd *cobra.Command, flags *config.CliFlags) {
 			if cmd.Flags().Changed("yes") {
 				flags.DB.Verify.AutoRedownload = &DbVerifyYesFlag
 			}
+			if cmd.Flags().Changed("hash-algo") {
+				flags.DB.Verify.HashAlgo = &DbVerifyHashAlgoFlag
+			}
+			if cmd.Flags().Changed("concurrency") {
+				flags.DB.Verify.Concurrency = &DbVerifyConcurrencyFlag
+			}
 		}
 	case "clean":
 		flags.Clean = &config.CliCleanFlags{}
@@ -483,6 +820,10 @@ func applyCommandSpecificFlags(cmd *cobra.Command, flags *config.CliFlags) {
 
 // loadGlobalConfig populates the config.CliFlags struct and initializes the global configuration
 func loadGlobalConfig(cmd *cobra.Command, args []string) error {
+	if cmd == downloadCmd {
+		// Before the first log line, so stderr only carries --progress-json events
+		progressLogOutput(downloadProgressJSONFlag)
+	}
 	log.Debug("Attempting to load global configuration...")
 	flags := config.CliFlags{}
 
@@ -514,6 +855,13 @@ func loadGlobalConfig(cmd *cobra.Command, args []string) error {
 	// Reconfigure logging with final config
 	log.Debug("Re-configuring logging based on final loaded configuration...")
 	configureLogging(&globalConfig)
+	api.SetRequestsPerMinute(globalConfig.RequestsPerMinute)
+
+	if simulateRateFlag != "" {
+		if err := startRateSimulator(simulateRateFlag, &globalConfig); err != nil {
+			return err
+		}
+	}
 
 	log.Debugf("Global configuration loaded: %+v", globalConfig)
 	log.Debugf("Global HTTP transport configured: type %T", globalHttpTransport)
@@ -521,6 +869,23 @@ func loadGlobalConfig(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// startRateSimulator points cfg at a local mock API answering with the
+// --simulate-rate sequence. The mock runs until the process exits.
+func startRateSimulator(spec string, cfg *models.Config) error {
+	responses, err := api.ParseSimulatedResponses(spec)
+	if err != nil {
+		return fmt.Errorf("invalid --simulate-rate: %w", err)
+	}
+	baseURL, _, err := api.NewRateSimulator(responses).Start()
+	if err != nil {
+		return err
+	}
+	cfg.APIBaseURL = baseURL
+	cfg.APICacheTTLSec = 0 // Cached responses would bypass the simulator
+	log.Warnf("Simulating the API at %s with %d programmed response(s), nothing is fetched from Civitai.", baseURL, len(responses))
+	return nil
+}
+
 // configureLoggingFromFlags sets up initial logging based *only* on flag values.
 // This is used before the full config is loaded to see early debug messages.
 func configureLoggingFromFlags(levelStr, formatStr string) {
@@ -575,3 +940,103 @@ func configureLogging(cfg *models.Config) {
 	}
 	log.Infof("Logging configured: Level=%s, Format=%s", level.String(), cfg.LogFormat)
 }
+
+// browsingLevelValue is a pflag.Value for --browsing-level, accepting either a
+// bitmask or level names (see models.ParseBrowsingLevel).
+type browsingLevelValue struct {
+	level *int
+}
+
+func newBrowsingLevelValue(level *int) *browsingLevelValue {
+	return &browsingLevelValue{level: level}
+}
+
+func (b *browsingLevelValue) String() string {
+	if b.level == nil {
+		return "0"
+	}
+	return strconv.Itoa(*b.level)
+}
+
+func (b *browsingLevelValue) Set(s string) error {
+	level, err := models.ParseBrowsingLevel(s)
+	if err != nil {
+		return err
+	}
+	*b.level = level
+	return nil
+}
+
+func (b *browsingLevelValue) Type() string {
+	return "level"
+}
+
+// byteRateValue is a pflag.Value for --max-rate, accepting a number of bytes
+// per second or a size such as 2MB (see helpers.ParseByteSize).
+type byteRateValue struct {
+	bytesPerSecond *int64
+}
+
+func newByteRateValue(bytesPerSecond *int64) *byteRateValue {
+	return &byteRateValue{bytesPerSecond: bytesPerSecond}
+}
+
+func (b *byteRateValue) String() string {
+	if b.bytesPerSecond == nil {
+		return "0"
+	}
+	return strconv.FormatInt(*b.bytesPerSecond, 10)
+}
+
+func (b *byteRateValue) Set(s string) error {
+	size, err := helpers.ParseByteSize(s)
+	if err != nil {
+		return err
+	}
+	*b.bytesPerSecond = int64(size)
+	return nil
+}
+
+func (b *byteRateValue) Type() string {
+	return "rate"
+}
+
+// concurrencyValue is a pflag.Value for the download --concurrency, accepting
+// either a number of workers or "auto" (Download.AutoConcurrency).
+type concurrencyValue struct {
+	workers *int
+	auto    *bool
+}
+
+func newConcurrencyValue(workers *int, auto *bool, defaultWorkers int) *concurrencyValue {
+	*workers = defaultWorkers
+	return &concurrencyValue{workers: workers, auto: auto}
+}
+
+func (c *concurrencyValue) String() string {
+	if c.auto != nil && *c.auto {
+		return "auto"
+	}
+	if c.workers == nil {
+		return "0"
+	}
+	return strconv.Itoa(*c.workers)
+}
+
+func (c *concurrencyValue) Set(s string) error {
+	if strings.EqualFold(strings.TrimSpace(s), "auto") {
+		*c.auto = true
+		return nil
+	}
+	workers, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return fmt.Errorf("expected a number of workers or \"auto\", got %q", s)
+	}
+	*c.workers = workers
+	*c.auto = false
+	return nil
+}
+
+func (c *concurrencyValue) Type() string {
+	return "int|auto"
+}
@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"bytes"
+	"crypto/sha1" // #nosec G505 -- BitTorrent v1 piece hashes are SHA-1
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -31,24 +35,72 @@ type torrentJob struct {
 	ModelType      string
 	Trackers       []string
 	ModelID        int
+	PieceLengthKB  int // 0 picks the piece length from the directory size
+	Filter         torrentFileFilter
 	Overwrite      bool
 	GenerateMagnet bool
+	Verify         bool
+	Force          bool // Regenerate even if the directory is unchanged since the last run
 }
 
 // torrentWorker function - Uses helper for indexing
-func torrentWorker(id int, jobs <-chan torrentJob, wg *sync.WaitGroup, successCounter *atomic.Int64, failureCounter *atomic.Int64) {
+func torrentWorker(id int, jobs <-chan torrentJob, db *database.DB, wg *sync.WaitGroup, successCounter *atomic.Int64, skippedCounter *atomic.Int64, failureCounter *atomic.Int64) {
 	defer wg.Done()
 	log.Debugf("Torrent Worker %d starting", id)
 	for job := range jobs {
 		log.WithFields(job.LogFields).Infof("Worker %d: Processing torrent job for model directory %s", id, job.SourcePath)
+
+		overwrite := job.Overwrite || job.Force
+		outPath, err := determineOutputPath(job.SourcePath, job.OutputDir)
+		if err != nil {
+			failureCounter.Add(1)
+			continue
+		}
+		fingerprint, err := torrentFingerprint(job.SourcePath, job.Trackers, job.PieceLengthKB, job.Filter)
+		if err != nil {
+			log.WithFields(job.LogFields).WithError(err).Warnf("Worker %d: Could not check %s for changes", id, job.SourcePath)
+		} else if !job.Force {
+			switch torrentState(db, job, outPath, fingerprint) {
+			case torrentUnchanged:
+				log.WithFields(job.LogFields).Infof("Worker %d: %s is unchanged since its torrent was generated, skipping (use --force to regenerate)", id, job.SourcePath)
+				skippedCounter.Add(1)
+				continue
+			case torrentChanged:
+				log.WithFields(job.LogFields).Infof("Worker %d: %s changed since its torrent was generated, regenerating", id, job.SourcePath)
+				overwrite = true
+			}
+		}
+		_, statErr := os.Stat(outPath)
+		keptExisting := !overwrite && statErr == nil
+
 		// Generate torrent for the entire model directory
-		_, _, _, err := generateTorrentFile(job.SourcePath, job.Trackers, job.OutputDir, job.Overwrite, job.GenerateMagnet)
+		torrentPath, _, _, err := generateTorrentFile(job.SourcePath, job.Trackers, job.PieceLengthKB, job.Filter, job.OutputDir, overwrite, job.GenerateMagnet)
 		if err != nil {
 			log.WithFields(job.LogFields).WithError(err).Errorf("Worker %d: Failed to generate torrent for %s", id, job.SourcePath)
 			failureCounter.Add(1)
 			continue // Skip indexing if torrent failed
 		}
 
+		if job.Verify {
+			if err := verifyTorrentFile(torrentPath, job.SourcePath); err != nil {
+				log.WithFields(job.LogFields).WithError(err).Errorf("Worker %d: Torrent %s failed verification", id, torrentPath)
+				failureCounter.Add(1)
+				continue
+			}
+			log.WithFields(job.LogFields).Infof("Worker %d: Verified %s against the files on disk", id, torrentPath)
+		}
+
+		if keptExisting {
+			skippedCounter.Add(1)
+			continue
+		}
+		if fingerprint != "" {
+			record := database.TorrentRecord{SourcePath: job.SourcePath, TorrentPath: torrentPath, Fingerprint: fingerprint}
+			if err := db.PutTorrentRecord(record); err != nil {
+				log.WithFields(job.LogFields).WithError(err).Warnf("Worker %d: Failed to record torrent state, it will be regenerated next run", id)
+			}
+		}
+
 		log.WithFields(job.LogFields).Infof("Worker %d: Successfully generated torrent for %s", id, job.SourcePath)
 		successCounter.Add(1)
 	} // end for job := range jobs
@@ -62,6 +114,9 @@ var (
 	overwriteTorrents      bool
 	generateMagnetLinks    bool
 	torrentConcurrencyFlag int // Added package-level var for concurrency flag
+	torrentPieceLengthFlag int
+	torrentVerifyFlag      bool
+	torrentForceFlag       bool
 )
 
 var torrentCmd = &cobra.Command{
@@ -69,7 +124,12 @@ var torrentCmd = &cobra.Command{
 	Short: "Generate .torrent files for downloaded models (one per model directory)",
 	Long: `Generates a single BitTorrent metainfo (.torrent) file for each downloaded model's main directory,
 encompassing all its downloaded versions and files. Requires access to the download history database
-and the downloaded files themselves. You must specify tracker announce URLs.`,
+and the downloaded files themselves. You must specify tracker announce URLs.
+
+The database remembers what each model directory looked like when its torrent was
+generated (file names, sizes and modification times, and the trackers). Re-runs skip
+unchanged directories and regenerate changed ones, so torrents can be kept up to date
+incrementally as models are added. Use --force to regenerate every torrent anyway.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		if len(announceURLs) == 0 {
 			return errors.New("at least one --announce URL is required")
@@ -83,6 +143,12 @@ and the downloaded files themselves. You must specify tracker announce URLs.`,
 			concurrency = 4
 		}
 
+		if err := validatePieceLengthKB(cfg.Torrent.PieceLengthKB); err != nil {
+			return err
+		}
+		filter := newTorrentFileFilter(cfg.Torrent.IncludeExtensions, cfg.Torrent.ExcludeFileTypes)
+		log.Debugf("Torrent file filter: %s", filter)
+
 		savePath := cfg.SavePath // Use global config
 		if savePath == "" {
 			log.Error("Save path is not configured (--save-path or config file)")
@@ -112,6 +178,7 @@ and the downloaded files themselves. You must specify tracker announce URLs.`,
 		// Map to store model directory paths and associated info (to avoid duplicate jobs)
 		modelDirsToProcess := make(map[string]torrentJob)
 		modelIDSet := make(map[int]struct{})
+		flatSkipped := 0 // Entries saved with --flatten have no model directory
 		if len(torrentModelIDs) > 0 {
 			for _, id := range torrentModelIDs {
 				modelIDSet[id] = struct{}{}
@@ -147,6 +214,11 @@ and the downloaded files themselves. You must specify tracker announce URLs.`,
 				}).Warn("Skipping entry due to missing Folder path.")
 				return nil
 			}
+			if entry.Folder == flatFolder {
+				log.Debugf("Skipping version %d: saved flat in the save path, not in a model directory", entry.Version.ID)
+				flatSkipped++
+				return nil
+			}
 
 			// --- Derive the MODEL directory path ---
 			// Assumes Folder structure is like: type/modelName/baseModel/versionSlug
@@ -186,9 +258,13 @@ and the downloaded files themselves. You must specify tracker announce URLs.`,
 				job := torrentJob{
 					SourcePath:     modelDir, // Target the model directory
 					Trackers:       announceURLs,
+					PieceLengthKB:  cfg.Torrent.PieceLengthKB,
+					Filter:         filter,
 					OutputDir:      torrentOutputDirEffective,    // Use viper value
 					Overwrite:      overwriteTorrentsEffective,   // Use viper value
 					GenerateMagnet: generateMagnetLinksEffective, // Use viper value
+					Verify:         torrentVerifyFlag,
+					Force:          torrentForceFlag,
 					LogFields: log.Fields{ // Context for the model directory
 						"modelID":   entry.ModelID,
 						"modelName": entry.ModelName, // Use ModelName from entry
@@ -208,6 +284,9 @@ and the downloaded files themselves. You must specify tracker announce URLs.`,
 			log.WithError(errFold).Error("Error scanning database")
 			return fmt.Errorf("error scanning database: %w", errFold)
 		}
+		if flatSkipped > 0 {
+			log.Infof("Skipped %d entries saved with --flatten: torrents are generated per model directory.", flatSkipped)
+		}
 
 		if len(modelDirsToProcess) == 0 {
 			if len(torrentModelIDs) > 0 {
@@ -224,12 +303,13 @@ and the downloaded files themselves. You must specify tracker announce URLs.`,
 		jobs := make(chan torrentJob, concurrency) // Buffered channel
 		var wg sync.WaitGroup
 		var successCounter atomic.Int64
+		var skippedCounter atomic.Int64
 		var failureCounter atomic.Int64
 
 		// Start workers
 		for i := 1; i <= concurrency; i++ {
 			wg.Add(1)
-			go torrentWorker(i, jobs, &wg, &successCounter, &failureCounter)
+			go torrentWorker(i, jobs, db, &wg, &successCounter, &skippedCounter, &failureCounter)
 		}
 
 		// --- Queue Jobs ---
@@ -247,9 +327,10 @@ and the downloaded files themselves. You must specify tracker announce URLs.`,
 
 		// --- Final Summary ---
 		successCount := successCounter.Load()
+		skippedCount := skippedCounter.Load()
 		failCount := failureCounter.Load()
 
-		log.Infof("Torrent generation complete. Success: %d, Failed: %d", successCount, failCount)
+		log.Infof("Torrent generation complete. Generated: %d, Skipped (up to date): %d, Failed: %d", successCount, skippedCount, failCount)
 		if failCount > 0 {
 			log.Errorf("%d torrents failed to generate", failCount)
 			return fmt.Errorf("%d torrents failed to generate", failCount)
@@ -258,11 +339,13 @@ and the downloaded files themselves. You must specify tracker announce URLs.`,
 	},
 }
 
-// generateTorrentFile creates a .torrent file for the given sourcePath (directory).
+// generateTorrentFile creates a .torrent file of the files in sourcePath (directory)
+// that filter includes, with pieces of pieceLengthKB, or a size picked by
+// autoPieceLength when 0.
 // It can optionally also create a text file containing the magnet link.
 // It returns the path to the generated .torrent file, the magnet link file (if created),
 // the magnet URI string itself, or an error.
-func generateTorrentFile(sourcePath string, trackers []string, outputDir string, overwrite bool, generateMagnetLinks bool) (torrentFilePath string, magnetFilePath string, magnetURI string, err error) {
+func generateTorrentFile(sourcePath string, trackers []string, pieceLengthKB int, filter torrentFileFilter, outputDir string, overwrite bool, generateMagnetLinks bool) (torrentFilePath string, magnetFilePath string, magnetURI string, err error) {
 	// Validate source path
 	if err := validateSourcePath(sourcePath); err != nil {
 		return "", "", "", err
@@ -282,7 +365,7 @@ func generateTorrentFile(sourcePath string, trackers []string, outputDir string,
 	}
 
 	// Create torrent metainfo
-	mi, info, err := createTorrentMetainfo(sourcePath, trackers)
+	mi, info, err := createTorrentMetainfo(sourcePath, trackers, pieceLengthKB, filter)
 	if err != nil {
 		return "", "", "", err
 	}
@@ -368,7 +451,7 @@ func checkExistingFiles(outPath string, overwrite, generateMagnetLinks bool) (st
 }
 
 // createTorrentMetainfo creates the torrent metainfo and info structures
-func createTorrentMetainfo(sourcePath string, trackers []string) (*metainfo.MetaInfo, metainfo.Info, error) {
+func createTorrentMetainfo(sourcePath string, trackers []string, pieceLengthKB int, filter torrentFileFilter) (*metainfo.MetaInfo, metainfo.Info, error) {
 	mi := metainfo.MetaInfo{}
 
 	// Validate and set trackers
@@ -384,24 +467,35 @@ func createTorrentMetainfo(sourcePath string, trackers []string) (*metainfo.Meta
 	mi.CreatedBy = "go-civitai-download"
 	mi.CreationDate = time.Now().Unix()
 
+	// Only the files passing the extension filters go into the torrent
+	files, totalSize, err := torrentFiles(sourcePath, filter)
+	if err != nil {
+		log.WithError(err).WithField("path", sourcePath).Error("Error listing files for torrent")
+		return nil, metainfo.Info{}, err
+	}
+	if len(files) == 0 {
+		log.WithField("path", sourcePath).Errorf("No files match the torrent extension filters (%s)", filter)
+		return nil, metainfo.Info{}, fmt.Errorf("no files in %s match Torrent.IncludeExtensions and Torrent.ExcludeFileTypes", sourcePath)
+	}
+
 	// Create info structure
-	const pieceLength = 512 * 1024 // 512 KiB
+	pieceLength := torrentPieceLength(totalSize, pieceLengthKB)
+	log.WithField("directory", sourcePath).Debugf("Using a piece length of %s for %d file(s)", helpers.BytesToSize(uint64(pieceLength)), len(files))
 	info := metainfo.Info{
 		PieceLength: pieceLength,
 		Name:        filepath.Base(sourcePath),
+		Files:       files,
 	}
 
 	log.WithField("directory", sourcePath).Debug("Building torrent info...")
-	if err := info.BuildFromFilePath(sourcePath); err != nil {
-		log.WithError(err).WithField("path", sourcePath).Error("Error building torrent info from path")
+	err = info.GeneratePieces(func(fi metainfo.FileInfo) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(append([]string{sourcePath}, fi.BestPath()...)...)) // #nosec G304 -- path was listed from this directory
+	})
+	if err != nil {
+		log.WithError(err).WithField("path", sourcePath).Error("Error hashing torrent pieces")
 		return nil, metainfo.Info{}, fmt.Errorf("error building torrent info from path %s: %w", sourcePath, err)
 	}
 
-	// Validate that files were added
-	if err := validateTorrentInfo(sourcePath, info); err != nil {
-		return nil, metainfo.Info{}, err
-	}
-
 	// Marshal the info dictionary
 	infoBytes, err := bencode.Marshal(info)
 	if err != nil {
@@ -413,6 +507,49 @@ func createTorrentMetainfo(sourcePath string, trackers []string) (*metainfo.Meta
 	return &mi, info, nil
 }
 
+// validatePieceLengthKB checks Torrent.PieceLengthKB (--piece-length): 0 for
+// auto, otherwise a power of two from 16 KB, the size of a BitTorrent block.
+func validatePieceLengthKB(pieceLengthKB int) error {
+	if pieceLengthKB == 0 {
+		return nil
+	}
+	if pieceLengthKB < 16 || pieceLengthKB&(pieceLengthKB-1) != 0 {
+		return fmt.Errorf("invalid torrent piece length %d KB: must be 0 (auto) or a power of two of at least 16 KB", pieceLengthKB)
+	}
+	return nil
+}
+
+// torrentPieceLength returns the piece length in bytes for a torrent of
+// totalSize bytes: pieceLengthKB, or when 0 one picked by autoPieceLength.
+func torrentPieceLength(totalSize int64, pieceLengthKB int) int64 {
+	if pieceLengthKB > 0 {
+		return int64(pieceLengthKB) * 1024
+	}
+	return autoPieceLength(totalSize)
+}
+
+const (
+	autoPieceCount     = 1500             // Pieces aimed for by autoPieceLength
+	minAutoPieceLength = 256 * 1024       // 256 KiB
+	maxAutoPieceLength = 16 * 1024 * 1024 // 16 MiB
+)
+
+// autoPieceLength picks the power of two piece length closest to splitting
+// totalSize into autoPieceCount pieces, between 256 KiB and 16 MiB. Small
+// directories get more pieces, very large ones fewer.
+func autoPieceLength(totalSize int64) int64 {
+	target := totalSize / autoPieceCount
+	pieceLength := int64(minAutoPieceLength)
+	for pieceLength < maxAutoPieceLength && pieceLength*2 <= target {
+		pieceLength *= 2
+	}
+	// Round up when the target is closer to the next power of two
+	if pieceLength < maxAutoPieceLength && target-pieceLength > pieceLength*2-target {
+		pieceLength *= 2
+	}
+	return pieceLength
+}
+
 // validateTrackers validates tracker URLs and returns only valid ones
 func validateTrackers(trackers []string) []string {
 	validTrackers := make([]string, 0, len(trackers))
@@ -427,28 +564,6 @@ func validateTrackers(trackers []string) []string {
 	return validTrackers
 }
 
-// validateTorrentInfo validates that the torrent info contains files
-func validateTorrentInfo(sourcePath string, info metainfo.Info) error {
-	if len(info.Files) == 0 && info.Length == 0 {
-		stat, _ := os.Stat(sourcePath)
-		if !stat.IsDir() {
-			log.WithField("path", sourcePath).Error("Source path is not a directory after check.")
-			return fmt.Errorf("source path %s is not a directory", sourcePath)
-		}
-
-		dirEntries, readDirErr := os.ReadDir(sourcePath)
-		if readDirErr != nil {
-			log.WithError(readDirErr).WithField("path", sourcePath).Warn("Could not read directory contents to check for emptiness.")
-		} else if len(dirEntries) == 0 {
-			log.WithField("path", sourcePath).Warn("Source directory is empty. Torrent will be generated but contain no files.")
-		} else {
-			log.WithField("path", sourcePath).Error("No files added to torrent info despite directory not being empty.")
-			return fmt.Errorf("failed to add files from path %s to torrent info", sourcePath)
-		}
-	}
-	return nil
-}
-
 // writeTorrentFile writes the torrent metainfo to a file
 func writeTorrentFile(outPath string, mi *metainfo.MetaInfo) error {
 	f, err := os.Create(helpers.SanitizePath(outPath))
@@ -480,6 +595,95 @@ func writeTorrentFile(outPath string, mi *metainfo.MetaInfo) error {
 	return nil
 }
 
+// verifyTorrentFile re-reads the .torrent at torrentPath and hashes the files
+// under sourcePath against its piece hashes, catching files that changed while
+// the torrent was being built. Mismatched pieces are logged with the files
+// they cover.
+func verifyTorrentFile(torrentPath, sourcePath string) error {
+	mi, err := metainfo.LoadFromFile(torrentPath)
+	if err != nil {
+		return fmt.Errorf("error reading torrent file %s: %w", torrentPath, err)
+	}
+	info, err := mi.UnmarshalInfo()
+	if err != nil {
+		return fmt.Errorf("error reading torrent info from %s: %w", torrentPath, err)
+	}
+	if info.PieceLength <= 0 {
+		return fmt.Errorf("torrent %s has an invalid piece length %d", torrentPath, info.PieceLength)
+	}
+
+	files := info.UpvertedV1Files()
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(writeTorrentData(pw, sourcePath, files))
+	}()
+	defer func() { _ = pr.Close() }()
+
+	badFiles := make(map[string]struct{})
+	badPieces := 0
+	numPieces := info.NumPieces()
+	buf := make([]byte, info.PieceLength)
+	for i := 0; i < numPieces; i++ {
+		piece := info.Piece(i)
+		data := buf[:piece.V1Length()]
+		if _, err := io.ReadFull(pr, data); err != nil {
+			return fmt.Errorf("error reading data for piece %d: %w", i, err)
+		}
+		sum := sha1.Sum(data) // #nosec G401 -- BitTorrent v1 piece hashes are SHA-1
+		if bytes.Equal(sum[:], info.Pieces[i*sha1.Size:(i+1)*sha1.Size]) {
+			continue
+		}
+		badPieces++
+		names := pieceFiles(files, piece.Offset(), int64(len(data)), info.BestName())
+		log.WithField("torrent", torrentPath).Errorf("Piece %d does not match: %s", i, strings.Join(names, ", "))
+		for _, name := range names {
+			badFiles[name] = struct{}{}
+		}
+	}
+
+	if badPieces > 0 {
+		names := make([]string, 0, len(badFiles))
+		for name := range badFiles {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("%d of %d pieces do not match the files on disk (%s)", badPieces, numPieces, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// writeTorrentData writes the torrent's files under sourcePath to w, in torrent order.
+func writeTorrentData(w io.Writer, sourcePath string, files []metainfo.FileInfo) error {
+	for _, fi := range files {
+		path := filepath.Join(append([]string{sourcePath}, fi.BestPath()...)...)
+		f, err := os.Open(path) // #nosec G304 -- path is listed in the torrent built from this directory
+		if err != nil {
+			return fmt.Errorf("error opening %s: %w", path, err)
+		}
+		n, err := io.CopyN(w, f, fi.Length)
+		_ = f.Close()
+		if err != nil {
+			return fmt.Errorf("error reading %s (%d of %d bytes): %w", path, n, fi.Length, err)
+		}
+	}
+	return nil
+}
+
+// pieceFiles returns the paths of the files overlapping the piece at offset with the given length.
+func pieceFiles(files []metainfo.FileInfo, offset, length int64, name string) []string {
+	var names []string
+	for _, fi := range files {
+		if fi.TorrentOffset < offset+length && fi.TorrentOffset+fi.Length > offset {
+			if len(fi.BestPath()) == 0 {
+				names = append(names, name) // Single-file torrent
+			} else {
+				names = append(names, strings.Join(fi.BestPath(), "/"))
+			}
+		}
+	}
+	return names
+}
+
 // generateMagnetURI generates a magnet URI from torrent metainfo and info
 func generateMagnetURI(mi *metainfo.MetaInfo, info metainfo.Info) string {
 	infoHash := mi.HashInfoBytes()
@@ -578,6 +782,9 @@ func init() {
 	torrentCmd.Flags().StringVarP(&torrentOutputDir, "output-dir", "o", "", "Directory to save generated .torrent files (default: place inside each model's directory)")
 	torrentCmd.Flags().BoolVarP(&overwriteTorrents, "overwrite", "f", false, "Overwrite existing .torrent files")
 	torrentCmd.Flags().BoolVar(&generateMagnetLinks, "magnet-links", false, "Generate a .txt file containing the magnet link alongside each .torrent file")
+	torrentCmd.Flags().BoolVar(&torrentForceFlag, "force", false, "Regenerate torrents even for model directories unchanged since the last run")
+	torrentCmd.Flags().IntVar(&torrentPieceLengthFlag, "piece-length", 256, "Torrent piece length in KB, a power of two (0 = pick from the directory size)")
+	torrentCmd.Flags().BoolVar(&torrentVerifyFlag, "verify", false, "Re-read each .torrent and re-hash the model files to check every piece matches")
 
 	// Concurrency is often a command-line only setting, but could be bound too
 	// Link to package-level variable
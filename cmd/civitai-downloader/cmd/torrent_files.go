@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/anacrolix/torrent/metainfo"
+)
+
+// torrentFileFilter picks the files of a model directory that go into its
+// torrent, from Torrent.IncludeExtensions and Torrent.ExcludeFileTypes.
+type torrentFileFilter struct {
+	Include []string // Extensions to include; empty includes every file
+	Exclude []string // Extensions never included, even when in Include
+}
+
+// newTorrentFileFilter builds a torrentFileFilter from comma separated
+// extension lists.
+func newTorrentFileFilter(include, exclude string) torrentFileFilter {
+	return torrentFileFilter{Include: parseExtensionList(include), Exclude: parseExtensionList(exclude)}
+}
+
+// includes reports whether the file name belongs in the torrent. Generated
+// .torrent and magnet files never do.
+func (f torrentFileFilter) includes(name string) bool {
+	if isGeneratedTorrentFile(name) {
+		return false
+	}
+	name = strings.ToLower(name)
+	hasExt := func(extensions []string) bool {
+		for _, ext := range extensions {
+			if strings.HasSuffix(name, ext) {
+				return true
+			}
+		}
+		return false
+	}
+	if hasExt(f.Exclude) {
+		return false
+	}
+	return len(f.Include) == 0 || hasExt(f.Include)
+}
+
+// String lists the extensions of the filter, for logs and the fingerprint.
+func (f torrentFileFilter) String() string {
+	return fmt.Sprintf("include=%s exclude=%s", strings.Join(f.Include, ","), strings.Join(f.Exclude, ","))
+}
+
+// torrentFiles lists the files under sourcePath that filter includes, sorted
+// by path as BitTorrent clients expect, and their combined size.
+func torrentFiles(sourcePath string, filter torrentFileFilter) ([]metainfo.FileInfo, int64, error) {
+	var files []metainfo.FileInfo
+	var totalSize int64
+	err := filepath.WalkDir(sourcePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !filter.includes(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(sourcePath, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, metainfo.FileInfo{
+			Path:   strings.Split(rel, string(filepath.Separator)),
+			Length: info.Size(),
+		})
+		totalSize += info.Size()
+		return nil
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("error listing files in %s: %w", sourcePath, err)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return strings.Join(files[i].Path, "/") < strings.Join(files[j].Path, "/")
+	})
+	return files, totalSize, nil
+}
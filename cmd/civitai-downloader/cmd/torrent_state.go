@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"go-civitai-download/internal/database"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// torrentStatus is what the stored record says about a directory's torrent.
+type torrentStatus int
+
+const (
+	torrentUnknown   torrentStatus = iota // No usable record, the usual --overwrite rules apply
+	torrentUnchanged                      // Generated from the directory as it is now
+	torrentChanged                        // Ours, but the directory changed since
+)
+
+// torrentFingerprint summarises sourcePath for telling whether its torrent is
+// still up to date: the path, size and modification time of every file filter
+// includes, plus the trackers, piece length setting and filter. Only the
+// directory tree is read, not the file contents.
+func torrentFingerprint(sourcePath string, trackers []string, pieceLengthKB int, filter torrentFileFilter) (string, error) {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "pieceLengthKB\x00%d\n", pieceLengthKB)
+	_, _ = fmt.Fprintf(h, "filter\x00%s\n", filter)
+	for _, tracker := range trackers {
+		_, _ = fmt.Fprintf(h, "tracker\x00%s\n", tracker)
+	}
+	err := filepath.WalkDir(sourcePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !filter.includes(d.Name()) {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(sourcePath, path)
+		if err != nil {
+			return err
+		}
+		_, _ = fmt.Fprintf(h, "%s\x00%d\x00%d\n", filepath.ToSlash(rel), info.Size(), info.ModTime().UnixNano())
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error scanning %s: %w", sourcePath, err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// isGeneratedTorrentFile reports whether name is a file the torrent command
+// writes itself, which must not make a directory count as changed.
+func isGeneratedTorrentFile(name string) bool {
+	return strings.HasSuffix(name, ".torrent") || strings.HasSuffix(name, "-magnet.txt")
+}
+
+// torrentState compares the stored record of job's directory with fingerprint.
+// A record for another torrent path (e.g. a different --output-dir) or a
+// torrent that is gone counts as no record. A missing magnet file counts as
+// changed when magnet links are wanted.
+func torrentState(db *database.DB, job torrentJob, torrentPath, fingerprint string) torrentStatus {
+	record, err := db.GetTorrentRecord(job.SourcePath)
+	if err != nil {
+		if !errors.Is(err, database.ErrNotFound) {
+			log.WithError(err).Warnf("Failed to read torrent state for %s", job.SourcePath)
+		}
+		return torrentUnknown
+	}
+	if record.TorrentPath != torrentPath {
+		return torrentUnknown
+	}
+	if _, err := os.Stat(torrentPath); err != nil {
+		return torrentUnknown
+	}
+	if record.Fingerprint != fingerprint {
+		return torrentChanged
+	}
+	if job.GenerateMagnet {
+		magnetPath := strings.TrimSuffix(torrentPath, filepath.Ext(torrentPath)) + "-magnet.txt"
+		if _, err := os.Stat(magnetPath); err != nil {
+			return torrentChanged
+		}
+	}
+	return torrentUnchanged
+}
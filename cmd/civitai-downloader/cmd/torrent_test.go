@@ -0,0 +1,147 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"go-civitai-download/internal/database"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyTorrentFile(t *testing.T) {
+	chdirTemp(t) // Torrent output paths are sanitized, so keep them relative
+	sourceDir := "model"
+	require.NoError(t, os.MkdirAll(sourceDir, 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "model.safetensors"), bytes.Repeat([]byte("a"), 300000), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "model.json"), []byte(`{"id":1}`), 0600))
+
+	torrentPath, _, _, err := generateTorrentFile(sourceDir, []string{"udp://tracker.example.com:1337/announce"}, 256, torrentFileFilter{}, "torrents", false, false)
+	require.NoError(t, err)
+	require.NoError(t, verifyTorrentFile(torrentPath, sourceDir))
+
+	// Same size, different content: only the piece hashes can catch it
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "model.json"), []byte(`{"id":2}`), 0600))
+	err = verifyTorrentFile(torrentPath, sourceDir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "model.json")
+
+	// Missing files are reported rather than hanging the reader
+	require.NoError(t, os.Remove(filepath.Join(sourceDir, "model.safetensors")))
+	assert.Error(t, verifyTorrentFile(torrentPath, sourceDir))
+}
+
+func TestTorrentWorkerSkipsUnchangedDirectories(t *testing.T) {
+	chdirTemp(t)
+	db, err := database.Open("test.db")
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	sourceDir := filepath.Join("lora", "model")
+	require.NoError(t, os.MkdirAll(sourceDir, 0750))
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "model.safetensors"), []byte("weights"), 0600))
+
+	run := func(force bool) (generated, skipped, failed int64) {
+		var wg sync.WaitGroup
+		var success, skip, failure atomic.Int64
+		jobs := make(chan torrentJob, 1)
+		jobs <- torrentJob{SourcePath: sourceDir, Trackers: []string{"udp://tracker.example.com:1337/announce"}, Force: force}
+		close(jobs)
+		wg.Add(1)
+		torrentWorker(1, jobs, db, &wg, &success, &skip, &failure)
+		return success.Load(), skip.Load(), failure.Load()
+	}
+
+	generated, skipped, _ := run(false)
+	assert.Equal(t, int64(1), generated)
+	assert.Zero(t, skipped)
+
+	// The torrent written into the directory does not count as a change
+	generated, skipped, _ = run(false)
+	assert.Zero(t, generated)
+	assert.Equal(t, int64(1), skipped)
+
+	generated, _, _ = run(true)
+	assert.Equal(t, int64(1), generated, "--force regenerates unchanged directories")
+
+	// A changed directory is regenerated without --overwrite
+	require.NoError(t, os.WriteFile(filepath.Join(sourceDir, "model.json"), []byte(`{"id":1}`), 0600))
+	generated, skipped, failed := run(false)
+	assert.Equal(t, int64(1), generated)
+	assert.Zero(t, skipped)
+	assert.Zero(t, failed)
+}
+
+func TestAutoPieceLength(t *testing.T) {
+	const kb, mb = int64(1024), int64(1024 * 1024)
+	assert.Equal(t, 256*kb, autoPieceLength(0))
+	assert.Equal(t, 256*kb, autoPieceLength(100*mb))
+	assert.Equal(t, 1*mb, autoPieceLength(1500*mb))   // Exactly 1 MiB per piece
+	assert.Equal(t, 2*mb, autoPieceLength(2500*mb))   // ~1.67 MiB rounds up
+	assert.Equal(t, 1*mb, autoPieceLength(2000*mb))   // ~1.33 MiB rounds down
+	assert.Equal(t, 4*mb, autoPieceLength(6500*mb))   // ~4.33 MiB rounds down
+	assert.Equal(t, 16*mb, autoPieceLength(1<<40))    // 1 TiB hits the cap
+	assert.Equal(t, 16*mb, autoPieceLength(20000*mb)) // ~13.3 MiB rounds up to the cap
+}
+
+func TestTorrentPieceLength(t *testing.T) {
+	assert.Equal(t, int64(1024*1024), torrentPieceLength(100, 1024))
+	assert.Equal(t, int64(256*1024), torrentPieceLength(100, 0), "auto uses the minimum for a tiny directory")
+	assert.Equal(t, int64(2*1024*1024), torrentPieceLength(2500*1024*1024, 0))
+}
+
+func TestTorrentFilesFiltersExtensions(t *testing.T) {
+	sourceDir := t.TempDir()
+	for name, content := range map[string]string{
+		"b/model.safetensors": "weights",
+		"a/model.GGUF":        "quantized",
+		"b/model.json":        `{"id":1}`,
+		"b/notes.txt":         "notes",
+		"b/preview.png":       "png",
+		"model.torrent":       "torrent",
+	} {
+		path := filepath.Join(sourceDir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0750))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+
+	filter := newTorrentFileFilter(".safetensors,.gguf,.json", ".json,.txt")
+	files, totalSize, err := torrentFiles(sourceDir, filter)
+	require.NoError(t, err)
+	var paths []string
+	for _, fi := range files {
+		paths = append(paths, strings.Join(fi.Path, "/"))
+	}
+	assert.Equal(t, []string{"a/model.GGUF", "b/model.safetensors"}, paths, "sorted, exclusions win over inclusions")
+	assert.Equal(t, int64(len("quantized")+len("weights")), totalSize)
+
+	// Without extensions everything but generated torrent files is included
+	files, _, err = torrentFiles(sourceDir, torrentFileFilter{})
+	require.NoError(t, err)
+	assert.Len(t, files, 5)
+}
+
+func TestGenerateTorrentFileFailsWithoutMatchingFiles(t *testing.T) {
+	chdirTemp(t)
+	require.NoError(t, os.MkdirAll("model", 0750))
+	require.NoError(t, os.WriteFile(filepath.Join("model", "model.json"), []byte(`{"id":1}`), 0600))
+
+	filter := newTorrentFileFilter(".safetensors", ".json")
+	_, _, _, err := generateTorrentFile("model", []string{"udp://tracker.example.com:1337/announce"}, 256, filter, "torrents", false, false)
+	assert.Error(t, err)
+}
+
+func TestValidatePieceLengthKB(t *testing.T) {
+	for _, valid := range []int{0, 16, 256, 512, 16384} {
+		assert.NoError(t, validatePieceLengthKB(valid), valid)
+	}
+	for _, invalid := range []int{-256, 8, 300, 1000} {
+		assert.Error(t, validatePieceLengthKB(invalid), invalid)
+	}
+}
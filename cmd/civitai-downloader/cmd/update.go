@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"go-civitai-download/internal/database"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+
+	// The same package-level variables as the download command, so the
+	// download flag handling in loadGlobalConfig applies to update too
+	updateCmd.Flags().BoolVar(&downloadAllVersionsFlag, "all-versions", false, "Download every version missing from the database, not just each model's latest (overrides config)")
+	updateCmd.Flags().StringSliceVarP(&downloadModelTypesFlag, "model-types", "", []string{}, "Only check tracked models of these types (comma-separated or multiple flags, overrides config)")
+	updateCmd.Flags().VarP(newConcurrencyValue(&downloadConcurrencyFlag, &downloadAutoConcurrencyFlag, 0), "concurrency", "c", "Number of concurrent downloads, or auto (0 uses config default)")
+	updateCmd.Flags().BoolVarP(&downloadYesFlag, "yes", "y", false, "Skip confirmation prompt before downloading (overrides config)")
+	updateCmd.Flags().BoolVar(&downloadDryRunFlag, "dry-run", false, "Print the new versions that would be downloaded and exit")
+	updateCmd.Flags().BoolVar(&downloadNoCacheFlag, "no-cache", false, "Fetch every model in full, also those the API reports as unchanged since the last check")
+}
+
+// updateCmd downloads new versions of the models already in the database
+var updateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Download new versions of the models already in the database",
+	Long: `Fetches the details of every model that has an entry in the database and
+downloads its versions that the database does not know yet, without running a
+search query. By default only each model's latest version is considered, so a
+model whose latest version is already downloaded is left alone; --all-versions
+downloads every version missing from the database instead.
+
+The files are filtered and saved exactly as the download command would with
+--model-id. Use --model-types (or ModelTypes in the config) to only check models
+of some types. Each model costs one API request, so large databases take a while.
+Model details are fetched with the ETag/Last-Modified of the previous fetch, and
+models the API reports as not modified are skipped once their versions are
+downloaded; --no-cache checks them all.
+
+Examples:
+  civitai-downloader update
+  civitai-downloader update --model-types LORA,LoCon --dry-run
+  civitai-downloader update --all-versions -y`,
+	RunE: runUpdate,
+}
+
+func runUpdate(cmd *cobra.Command, args []string) error {
+	if globalConfig.DatabasePath == "" {
+		return errors.New("database path is not set in the configuration")
+	}
+	if _, err := os.Stat(globalConfig.DatabasePath); errors.Is(err, os.ErrNotExist) {
+		log.Infof("No database at %s yet, nothing to update. Download some models first.", globalConfig.DatabasePath)
+		return nil
+	}
+
+	modelIDs, err := trackedModelIDs(globalConfig.DatabasePath, globalConfig.Download.ModelTypes)
+	if err != nil {
+		return err
+	}
+	if len(modelIDs) == 0 {
+		log.Info("No models in the database to update.")
+		return nil
+	}
+	log.Infof("Checking %d model(s) from the database for new versions (All versions: %v)", len(modelIDs), globalConfig.Download.AllVersions)
+
+	// Processed like a list of --model-id by the download command
+	globalConfig.Download.ModelIDs = modelIDs
+	globalConfig.Download.SkipUnchanged = true
+	return runDownload(cmd, args)
+}
+
+// trackedModelIDs returns the IDs of the models in the database at dbPath,
+// limited to modelTypes when given.
+func trackedModelIDs(dbPath string, modelTypes []string) ([]int, error) {
+	db, err := database.OpenReadOnly(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening database at %s: %w", dbPath, err)
+	}
+	defer func() { _ = db.Close() }()
+
+	modelIDs, err := db.ModelIDs(modelTypes)
+	if err != nil {
+		return nil, fmt.Errorf("error reading models from the database: %w", err)
+	}
+	return modelIDs, nil
+}
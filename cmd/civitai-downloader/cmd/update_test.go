@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"go-civitai-download/internal/api"
+	"go-civitai-download/internal/database"
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackedModelIDs(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := database.Open(dbPath)
+	require.NoError(t, err)
+	for versionID, entry := range map[int]models.DatabaseEntry{
+		1: {ModelID: 100, ModelType: "LORA", Status: models.StatusDownloaded},
+		2: {ModelID: 100, ModelType: "LORA", Status: models.StatusError},
+		3: {ModelID: 200, ModelType: "Checkpoint", Status: models.StatusDownloaded},
+	} {
+		entry.Version.ID = versionID
+		data, err := json.Marshal(entry)
+		require.NoError(t, err)
+		require.NoError(t, db.Put([]byte(fmt.Sprintf("v_%d", versionID)), data))
+	}
+	require.NoError(t, db.Close())
+
+	modelIDs, err := trackedModelIDs(dbPath, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{100, 200}, modelIDs)
+
+	modelIDs, err = trackedModelIDs(dbPath, []string{"checkpoint"})
+	require.NoError(t, err)
+	assert.Equal(t, []int{200}, modelIDs)
+}
+
+func TestHandleModelIDList_SkipsUnchanged(t *testing.T) {
+	var versionRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/models/1":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+			w.Header().Set("ETag", `"v1"`)
+			_, _ = w.Write([]byte(`{"id": 1, "name": "Model", "modelVersions": [{"id": 10}]}`))
+		case "/model-versions/10":
+			atomic.AddInt32(&versionRequests, 1)
+			_, _ = w.Write([]byte(`{"id": 10, "modelId": 1, "model": {"name": "Model", "type": "LORA"}}`))
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+		}
+	}))
+	defer server.Close()
+
+	tmpDir := chdirTemp(t)
+	db, err := database.Open(filepath.Join(tmpDir, "test.db"))
+	require.NoError(t, err)
+	defer func() { _ = db.Close() }()
+
+	cfg := &models.Config{APIBaseURL: server.URL, SavePath: tmpDir}
+	cfg.Download.SkipUnchanged = true
+	// A new client per run, as each update run starts without cached details
+	check := func(cache api.HTTPCache) {
+		t.Helper()
+		apiClient := api.NewClient("", server.Client(), *cfg)
+		if cache != nil {
+			apiClient.SetHTTPCache(cache)
+		}
+		_, err := handleModelIDList([]int{1}, db, apiClient, nil, cfg)
+		require.NoError(t, err)
+	}
+
+	check(db)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&versionRequests), "the first check processes the model")
+
+	// The first run's download failed, so the unchanged model is retried
+	check(db)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&versionRequests), "a model whose version is not downloaded is not skipped")
+
+	entry := models.DatabaseEntry{ModelID: 1, Version: models.ModelVersion{ID: 10}, Filename: "model.safetensors", Status: models.StatusDownloaded}
+	data, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, db.Put([]byte("v_10"), data))
+	check(db)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&versionRequests), "an unchanged, downloaded model is skipped")
+
+	// --no-cache leaves the HTTP cache off, so the model is processed again
+	check(nil)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&versionRequests))
+}
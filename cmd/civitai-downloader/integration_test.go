@@ -761,6 +761,33 @@ func TestRealDownload_PageFlagApplied(t *testing.T) {
 	}
 }
 
+// TestRealDownload_PostIDFlagApplied validates that --post-id reaches the images API URL as postId,
+// which is how all images of a single post are fetched.
+func TestRealDownload_PostIDFlagApplied(t *testing.T) {
+	apiKey := skipIfNoAPIKey(t)
+
+	binaryPath := buildTestBinary(t)
+	defer os.Remove(binaryPath)
+
+	tempDir := t.TempDir()
+
+	output, err := runCLIWithTimeout(t, binaryPath, []string{
+		"--save-path", tempDir,
+		"images",
+		"--debug-print-api-url",
+		"--post-id", "987",
+	}, append(os.Environ(), "CIVITAI_API_KEY="+apiKey))
+
+	if err != nil {
+		t.Logf("Output: %s", output)
+		t.Fatalf("Command failed: %v", err)
+	}
+
+	if !strings.Contains(output, "postId=987") {
+		t.Errorf("Expected postId=987 in URL, got: %s", output)
+	}
+}
+
 // TestRealDownload_ImageExtension validates that downloaded images have correct extensions.
 func TestRealDownload_ImageExtension(t *testing.T) {
 	apiKey := skipIfNoAPIKey(t)
@@ -12,6 +12,7 @@ require (
 	github.com/spf13/viper v1.20.1
 	github.com/stretchr/testify v1.10.0
 	github.com/zeebo/blake3 v0.2.4
+	golang.org/x/time v0.9.0
 	lukechampine.com/blake3 v1.1.6
 	modernc.org/sqlite v1.21.1
 )
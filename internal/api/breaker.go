@@ -0,0 +1,96 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// ErrCircuitOpen is returned for API requests refused while the circuit breaker is open.
+var ErrCircuitOpen = errors.New("API circuit breaker open")
+
+// CircuitBreaker stops a run from grinding through thousands of retries when
+// the API is broadly failing. Once threshold request attempts have failed
+// within the window it opens and refuses requests for the cool-down. After
+// that a single failure opens it again, while a success closes it.
+// The threshold is passed on each call; 0 or less disables the breaker.
+type CircuitBreaker struct {
+	mu        sync.Mutex
+	failures  []time.Time // Failed attempts within window, oldest first
+	openUntil time.Time
+	tripped   bool // Opened and not yet closed by a successful request
+
+	window   time.Duration
+	cooldown time.Duration
+}
+
+// SharedBreaker is the run-wide breaker used by Client and by the download
+// command's own request retries, so failures anywhere count towards it.
+var SharedBreaker = NewCircuitBreaker(time.Minute, 2*time.Minute)
+
+// NewCircuitBreaker creates a closed breaker counting failures within window
+// and staying open for cooldown once tripped.
+func NewCircuitBreaker(window, cooldown time.Duration) *CircuitBreaker {
+	return &CircuitBreaker{window: window, cooldown: cooldown}
+}
+
+// Allow returns an error wrapping ErrCircuitOpen while the breaker is open.
+func (b *CircuitBreaker) Allow(threshold int) error {
+	if threshold <= 0 {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if remaining := time.Until(b.openUntil); remaining > 0 {
+		return fmt.Errorf("%w: too many failed requests, Civitai appears to be having problems; not sending requests for another %v",
+			ErrCircuitOpen, remaining.Round(time.Second))
+	}
+	return nil
+}
+
+// RecordFailure counts a failed request attempt and opens the breaker once
+// threshold failures fall within the window.
+func (b *CircuitBreaker) RecordFailure(threshold int) {
+	if threshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if b.tripped {
+		// Still failing after the cool-down, open again straight away
+		b.openUntil = now.Add(b.cooldown)
+		log.Errorf("API requests are still failing after the cool-down. Pausing API requests for another %v.", b.cooldown)
+		return
+	}
+
+	b.failures = append(b.failures, now)
+	cutoff := now.Add(-b.window)
+	for len(b.failures) > 0 && b.failures[0].Before(cutoff) {
+		b.failures = b.failures[1:]
+	}
+	if len(b.failures) >= threshold {
+		log.Errorf("%d API requests failed within %v (CircuitBreakerThreshold). Civitai appears to be failing broadly; refusing API requests for %v instead of retrying each one.",
+			len(b.failures), b.window, b.cooldown)
+		b.tripped = true
+		b.openUntil = now.Add(b.cooldown)
+		b.failures = nil
+	}
+}
+
+// RecordSuccess closes a breaker that was opened before.
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tripped {
+		log.Info("API requests are succeeding again, circuit breaker closed.")
+		b.tripped = false
+		b.failures = nil
+	}
+}
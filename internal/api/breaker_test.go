@@ -0,0 +1,60 @@
+package api
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker_TripsAndRecovers(t *testing.T) {
+	b := NewCircuitBreaker(time.Minute, 50*time.Millisecond)
+	const threshold = 3
+
+	for i := 0; i < threshold-1; i++ {
+		b.RecordFailure(threshold)
+	}
+	if err := b.Allow(threshold); err != nil {
+		t.Fatalf("breaker opened before reaching the threshold: %v", err)
+	}
+
+	b.RecordFailure(threshold)
+	if err := b.Allow(threshold); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen after %d failures, got %v", threshold, err)
+	}
+
+	// After the cool-down one failure is enough to open it again.
+	time.Sleep(60 * time.Millisecond)
+	if err := b.Allow(threshold); err != nil {
+		t.Fatalf("expected requests to be allowed after the cool-down, got %v", err)
+	}
+	b.RecordFailure(threshold)
+	if err := b.Allow(threshold); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected a failure after the cool-down to reopen the breaker, got %v", err)
+	}
+
+	// A success after the next cool-down closes it, so single failures are tolerated again.
+	time.Sleep(60 * time.Millisecond)
+	b.RecordSuccess()
+	b.RecordFailure(threshold)
+	if err := b.Allow(threshold); err != nil {
+		t.Fatalf("expected breaker to be closed after a success, got %v", err)
+	}
+}
+
+func TestCircuitBreaker_WindowAndDisabled(t *testing.T) {
+	b := NewCircuitBreaker(20*time.Millisecond, time.Minute)
+	b.RecordFailure(2)
+	time.Sleep(30 * time.Millisecond)
+	b.RecordFailure(2)
+	if err := b.Allow(2); err != nil {
+		t.Errorf("failures outside the window should not count, got %v", err)
+	}
+
+	disabled := NewCircuitBreaker(time.Minute, time.Minute)
+	for i := 0; i < 100; i++ {
+		disabled.RecordFailure(0)
+	}
+	if err := disabled.Allow(0); err != nil {
+		t.Errorf("a threshold of 0 should disable the breaker, got %v", err)
+	}
+}
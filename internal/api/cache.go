@@ -0,0 +1,140 @@
+package api
+
+import (
+	"container/list"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultModelCacheSize is how many model detail responses a Client keeps in memory.
+const DefaultModelCacheSize = 256
+
+// modelCache holds raw /models/{id} responses so repeated detail fetches in a
+// run (and, with a disk directory, in later runs within the TTL) skip the API.
+// The endpoint always returns every version of the model, so a cached response
+// serves --all-versions and latest-only callers alike.
+type modelCache struct {
+	mu       sync.Mutex
+	entries  map[int]*list.Element
+	order    *list.List // Front is most recently used
+	capacity int
+
+	dir string        // Disk cache directory; empty disables it
+	ttl time.Duration // Disk entries older than this are refetched
+}
+
+type modelCacheEntry struct {
+	body []byte
+	id   int
+}
+
+func newModelCache(capacity int, dir string, ttl time.Duration) *modelCache {
+	if capacity <= 0 {
+		capacity = DefaultModelCacheSize
+	}
+	if ttl <= 0 {
+		dir = ""
+	}
+	return &modelCache{
+		entries:  make(map[int]*list.Element),
+		order:    list.New(),
+		capacity: capacity,
+		dir:      dir,
+		ttl:      ttl,
+	}
+}
+
+// get returns the cached response for modelID, checking memory first and then
+// the disk cache.
+func (c *modelCache) get(modelID int) ([]byte, bool) {
+	c.mu.Lock()
+	if elem, ok := c.entries[modelID]; ok {
+		c.order.MoveToFront(elem)
+		body := elem.Value.(*modelCacheEntry).body
+		c.mu.Unlock()
+		return body, true
+	}
+	c.mu.Unlock()
+
+	body, ok := c.readDisk(modelID)
+	if ok {
+		c.remember(modelID, body)
+	}
+	return body, ok
+}
+
+// put stores a response in memory and, if enabled, on disk.
+func (c *modelCache) put(modelID int, body []byte) {
+	c.remember(modelID, body)
+	c.writeDisk(modelID, body)
+}
+
+// forget drops modelID, e.g. after its cached body failed to decode.
+func (c *modelCache) forget(modelID int) {
+	c.mu.Lock()
+	if elem, ok := c.entries[modelID]; ok {
+		c.order.Remove(elem)
+		delete(c.entries, modelID)
+	}
+	c.mu.Unlock()
+
+	if c.dir != "" {
+		_ = os.Remove(c.diskPath(modelID))
+	}
+}
+
+func (c *modelCache) remember(modelID int, body []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[modelID]; ok {
+		elem.Value.(*modelCacheEntry).body = body
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[modelID] = c.order.PushFront(&modelCacheEntry{id: modelID, body: body})
+	for c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*modelCacheEntry).id)
+	}
+}
+
+func (c *modelCache) diskPath(modelID int) string {
+	return filepath.Join(c.dir, fmt.Sprintf("model-%d.json", modelID))
+}
+
+func (c *modelCache) readDisk(modelID int) ([]byte, bool) {
+	if c.dir == "" {
+		return nil, false
+	}
+	path := c.diskPath(modelID)
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > c.ttl {
+		return nil, false
+	}
+	body, err := os.ReadFile(path) // #nosec G304 -- path is built from the cache dir and a model ID
+	if err != nil {
+		return nil, false
+	}
+	log.Debugf("Using cached details for model %d from %s", modelID, path)
+	return body, true
+}
+
+func (c *modelCache) writeDisk(modelID int, body []byte) {
+	if c.dir == "" {
+		return
+	}
+	if err := os.MkdirAll(c.dir, 0750); err != nil {
+		log.WithError(err).Warnf("Failed to create API cache directory %s", c.dir)
+		return
+	}
+	if err := os.WriteFile(c.diskPath(modelID), body, 0600); err != nil {
+		log.WithError(err).Warnf("Failed to write API cache entry for model %d", modelID)
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-civitai-download/internal/models"
+)
+
+// countingTransport answers every request with a minimal model body and
+// counts how many requests reached the "network".
+type countingTransport struct {
+	calls int32
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&t.calls, 1)
+	id := req.URL.Path[strings.LastIndex(req.URL.Path, "/")+1:]
+	body := fmt.Sprintf(`{"id": %s, "name": "Model %s", "modelVersions": [{"id": 1}, {"id": 2}]}`, id, id)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Header:     make(http.Header),
+		Request:    req,
+	}, nil
+}
+
+func TestGetModelDetails_UsesMemoryCache(t *testing.T) {
+	transport := &countingTransport{}
+	client := NewClient("", &http.Client{Transport: transport}, models.Config{})
+
+	for i := 0; i < 3; i++ {
+		model, err := client.GetModelDetails(42)
+		if err != nil {
+			t.Fatalf("GetModelDetails: %v", err)
+		}
+		if model.ID != 42 || len(model.ModelVersions) != 2 {
+			t.Fatalf("unexpected model from cache: %+v", model)
+		}
+	}
+	if transport.calls != 1 {
+		t.Errorf("expected 1 API request, got %d", transport.calls)
+	}
+
+	// Callers may modify what they get back without affecting the cache.
+	model, _ := client.GetModelDetails(42)
+	model.ModelVersions[0].ID = 99
+	again, _ := client.GetModelDetails(42)
+	if again.ModelVersions[0].ID != 1 {
+		t.Error("cached model was modified through a returned value")
+	}
+}
+
+func TestModelCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newModelCache(2, "", 0)
+	cache.put(1, []byte("one"))
+	cache.put(2, []byte("two"))
+	cache.get(1) // 2 is now least recently used
+	cache.put(3, []byte("three"))
+
+	if _, ok := cache.get(2); ok {
+		t.Error("expected model 2 to be evicted")
+	}
+	for _, id := range []int{1, 3} {
+		if _, ok := cache.get(id); !ok {
+			t.Errorf("expected model %d to still be cached", id)
+		}
+	}
+}
+
+func TestGetModelDetails_DiskCacheTTL(t *testing.T) {
+	cfg := models.Config{SavePath: t.TempDir(), APICacheTTLSec: 3600}
+
+	first := &countingTransport{}
+	if _, err := NewClient("", &http.Client{Transport: first}, cfg).GetModelDetails(7); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new client (next run) within the TTL reads the response from disk.
+	second := &countingTransport{}
+	client := NewClient("", &http.Client{Transport: second}, cfg)
+	model, err := client.GetModelDetails(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.calls != 0 || model.ID != 7 {
+		t.Errorf("expected disk cache hit, got %d requests and model %+v", second.calls, model)
+	}
+
+	// Once the entry is older than the TTL it is fetched again.
+	old := time.Now().Add(-2 * time.Hour)
+	if err := os.Chtimes(client.modelCache.diskPath(7), old, old); err != nil {
+		t.Fatal(err)
+	}
+	third := &countingTransport{}
+	if _, err := NewClient("", &http.Client{Transport: third}, cfg).GetModelDetails(7); err != nil {
+		t.Fatal(err)
+	}
+	if third.calls != 1 {
+		t.Errorf("expected expired entry to be refetched, got %d requests", third.calls)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -26,17 +27,63 @@ var (
 
 const CivitaiApiBaseUrl = "https://civitai.com/api/v1"
 
-// UserAgent is the browser User-Agent string used for HTTP requests to avoid 401 errors
-const UserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
+// BaseURL returns the API base URL to use for cfg: APIBaseURL when set,
+// CivitaiApiBaseUrl otherwise.
+func BaseURL(cfg models.Config) string {
+	if cfg.APIBaseURL == "" {
+		return CivitaiApiBaseUrl
+	}
+	return strings.TrimSuffix(cfg.APIBaseURL, "/")
+}
+
+// maxRetryAfter caps how long a Retry-After header can make a request wait.
+const maxRetryAfter = 10 * time.Minute
+
+// RetryAfter returns the wait asked for by the Retry-After header of resp,
+// given in seconds or as an HTTP date, capped at maxRetryAfter. It returns 0
+// when there is no usable header.
+func RetryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	value := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if value == "" {
+		return 0
+	}
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(value); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		wait = time.Until(at)
+	}
+	if wait <= 0 {
+		return 0
+	}
+	if wait > maxRetryAfter {
+		return maxRetryAfter
+	}
+	return wait
+}
 
 // Client struct for interacting with the Civitai API
 type Client struct {
 	// Pointer first
 	HttpClient *http.Client // Use a shared client
+	modelCache *modelCache  // Model detail responses, see cache.go
+	httpCache  HTTPCache    // Validators for conditional re-fetches (nil = off), see httpcache.go
 	// String
-	ApiKey string
+	ApiKey    string
+	userAgent string // User-Agent header for every request
+	baseURL   string // See BaseURL
+	// Int
+	breakerThreshold int // Failed attempts that open SharedBreaker, see breaker.go
+	// Bool
+	storeRawJSON bool // Keep each version's original JSON in ModelVersion.RawJSON
 }
 
+// APICacheDirName is the directory under SavePath holding cached API responses.
+const APICacheDirName = ".api-cache"
+
 // NewClient creates a new API client
 func NewClient(apiKey string, httpClient *http.Client, cfg models.Config) *Client {
 	if httpClient == nil {
@@ -44,9 +91,21 @@ func NewClient(apiKey string, httpClient *http.Client, cfg models.Config) *Clien
 	}
 	log.Debugf("NewClient called (API logging handled by transport if enabled)")
 
+	cacheTTL := time.Duration(cfg.APICacheTTLSec) * time.Second
+	cacheDir := filepath.Join(cfg.SavePath, APICacheDirName)
+	userAgent := cfg.UserAgent
+	if userAgent == "" {
+		userAgent = models.DefaultUserAgent()
+	}
+
 	return &Client{
-		ApiKey:     apiKey,
-		HttpClient: httpClient,
+		ApiKey:           apiKey,
+		HttpClient:       httpClient,
+		modelCache:       newModelCache(DefaultModelCacheSize, cacheDir, cacheTTL),
+		breakerThreshold: cfg.CircuitBreakerThreshold,
+		userAgent:        userAgent,
+		baseURL:          BaseURL(cfg),
+		storeRawJSON:     cfg.DB.StoreRawJSON,
 	}
 }
 
@@ -57,9 +116,16 @@ func (c *Client) RetryableHTTPRequest(req *http.Request) (*http.Response, error)
 	var lastErr error
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
+		if err := SharedBreaker.Allow(c.breakerThreshold); err != nil {
+			return nil, err
+		}
+		if err := WaitForRequestSlot(req.Context()); err != nil {
+			return nil, err
+		}
 		resp, err := c.HttpClient.Do(req)
 
 		if err != nil {
+			SharedBreaker.RecordFailure(c.breakerThreshold)
 			lastErr = fmt.Errorf("http request failed (attempt %d/%d): %w", attempt+1, maxRetries, err)
 			if attempt < maxRetries-1 {
 				log.WithError(err).Warnf("Retrying (%d/%d)...", attempt+1, maxRetries)
@@ -70,12 +136,14 @@ func (c *Client) RetryableHTTPRequest(req *http.Request) (*http.Response, error)
 		}
 
 		switch resp.StatusCode {
-		case http.StatusOK:
+		case http.StatusOK, http.StatusNotModified: // 304 only answers conditional requests
+			SharedBreaker.RecordSuccess()
 			return resp, nil
 		case http.StatusTooManyRequests:
+			SharedBreaker.RecordFailure(c.breakerThreshold)
 			lastErr = ErrRateLimited
 			if attempt < maxRetries-1 {
-				sleepDuration := time.Duration(attempt+1) * 5 * time.Second
+				sleepDuration := max(time.Duration(attempt+1)*5*time.Second, RetryAfter(resp))
 				log.WithError(lastErr).Warnf("Rate limited. Retrying (%d/%d) after %s...", attempt+1, maxRetries, sleepDuration)
 				c.closeResponseBody(resp)
 				time.Sleep(sleepDuration)
@@ -88,9 +156,11 @@ func (c *Client) RetryableHTTPRequest(req *http.Request) (*http.Response, error)
 			c.closeResponseBody(resp)
 			return nil, ErrNotFound
 		case http.StatusServiceUnavailable:
+			SharedBreaker.RecordFailure(c.breakerThreshold)
 			lastErr = fmt.Errorf("%w (status code 503)", ErrServerError)
 		default:
 			if resp.StatusCode >= 500 {
+				SharedBreaker.RecordFailure(c.breakerThreshold)
 				lastErr = fmt.Errorf("%w (status code %d)", ErrServerError, resp.StatusCode)
 			} else {
 				c.closeResponseBody(resp)
@@ -108,6 +178,7 @@ func (c *Client) RetryableHTTPRequest(req *http.Request) (*http.Response, error)
 			} else {
 				sleepDuration = time.Duration(attempt+1) * 3 * time.Second
 			}
+			sleepDuration = max(sleepDuration, RetryAfter(resp))
 			log.WithError(lastErr).Warnf("Server error. Retrying (%d/%d) after %s...", attempt+1, maxRetries, sleepDuration)
 			time.Sleep(sleepDuration)
 		} else {
@@ -135,7 +206,7 @@ func (c *Client) GetModels(cursor string, queryParams models.QueryParameters) (s
 		values.Add("cursor", cursor)
 	}
 
-	reqURL := fmt.Sprintf("%s/models?%s", CivitaiApiBaseUrl, values.Encode())
+	reqURL := fmt.Sprintf("%s/models?%s", c.baseURL, values.Encode())
 
 	req, err := http.NewRequest("GET", reqURL, nil)
 	if err != nil {
@@ -144,7 +215,7 @@ func (c *Client) GetModels(cursor string, queryParams models.QueryParameters) (s
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("User-Agent", c.userAgent)
 	if c.ApiKey != "" {
 		req.Header.Set("Authorization", "Bearer "+c.ApiKey)
 	}
@@ -161,25 +232,84 @@ func (c *Client) GetModels(cursor string, queryParams models.QueryParameters) (s
 		return "", models.ApiResponse{}, fmt.Errorf("error reading response body: %w", err)
 	}
 
-	var response models.ApiResponse
-	err = json.Unmarshal(body, &response)
+	response, skipped, err := decodeModelsPage(body, c.storeRawJSON)
 	if err != nil {
 		log.WithError(err).Errorf("Error unmarshalling response JSON")
 		log.Debugf("Response body causing unmarshal error: %s", string(body))
 		return "", models.ApiResponse{}, fmt.Errorf("error unmarshalling response JSON: %w", err)
 	}
+	if skipped > 0 {
+		log.Warnf("Skipped %d undecodable model(s) on this page; kept %d", skipped, len(response.Items))
+	}
 
 	return response.Metadata.NextCursor.String(), response, nil
 }
 
+// decodeModelsPage decodes a /models page response one item at a time, so a
+// single malformed model does not discard the rest of the page. It returns
+// the number of items that could not be decoded. An error is only returned
+// if the page envelope itself (items array or metadata) is malformed. With
+// keepRaw the original JSON of every version is kept, see AttachRawVersions.
+func decodeModelsPage(body []byte, keepRaw bool) (models.ApiResponse, int, error) {
+	var page struct {
+		Items    []json.RawMessage         `json:"items"`
+		Metadata models.PaginationMetadata `json:"metadata"`
+	}
+	if err := json.Unmarshal(body, &page); err != nil {
+		return models.ApiResponse{}, 0, err
+	}
+
+	response := models.ApiResponse{
+		Items:    make([]models.Model, 0, len(page.Items)),
+		Metadata: page.Metadata,
+	}
+	skipped := 0
+	for i, raw := range page.Items {
+		var model models.Model
+		if err := json.Unmarshal(raw, &model); err != nil {
+			skipped++
+			log.WithError(err).Debugf("Failed to decode model at index %d: %s", i, string(raw))
+			continue
+		}
+		if keepRaw {
+			AttachRawVersions(&model, raw)
+		}
+		response.Items = append(response.Items, model)
+	}
+
+	return response, skipped, nil
+}
+
+// AttachRawVersions sets RawJSON of every version of model to the version's
+// JSON in body, the model's original API response.
+func AttachRawVersions(model *models.Model, body []byte) {
+	var raw struct {
+		ModelVersions []json.RawMessage `json:"modelVersions"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		log.WithError(err).Debugf("Failed to extract raw versions of model %d", model.ID)
+		return
+	}
+	for i := range model.ModelVersions {
+		if i < len(raw.ModelVersions) {
+			model.ModelVersions[i].RawJSON = raw.ModelVersions[i]
+		}
+	}
+}
+
 // ConvertQueryParamsToURLValues converts the QueryParameters struct into url.Values
 // suitable for Civitai API requests.
 func ConvertQueryParamsToURLValues(queryParams models.QueryParameters) url.Values {
 	values := url.Values{}
 	values.Add("sort", queryParams.Sort)
 	values.Add("period", queryParams.Period)
-	// Always include the nsfw parameter, converting the boolean to string "true" or "false"
-	values.Add("nsfw", fmt.Sprintf("%t", queryParams.Nsfw))
+	// browsingLevel replaces nsfw when set; otherwise always include the nsfw
+	// parameter, converting the boolean to string "true" or "false"
+	if queryParams.BrowsingLevel > 0 {
+		values.Add("browsingLevel", strconv.Itoa(queryParams.BrowsingLevel))
+	} else {
+		values.Add("nsfw", fmt.Sprintf("%t", queryParams.Nsfw))
+	}
 	values.Add("limit", fmt.Sprintf("%d", queryParams.Limit))
 	for _, t := range queryParams.Types {
 		values.Add("types", t)
@@ -199,6 +329,14 @@ func ConvertQueryParamsToURLValues(queryParams models.QueryParameters) url.Value
 	if queryParams.Username != "" {
 		values.Add("username", queryParams.Username)
 	}
+	if queryParams.Favorites {
+		// Favorites of the authenticated user, so the request must carry the API key
+		values.Add("favorites", "true")
+	}
+	if queryParams.Hidden {
+		// Likewise the models the authenticated user has hidden
+		values.Add("hidden", "true")
+	}
 
 	// Note: Cursor/Page parameters are typically added separately based on pagination logic.
 	return values
@@ -206,46 +344,87 @@ func ConvertQueryParamsToURLValues(queryParams models.QueryParameters) url.Value
 
 // GetModelDetails fetches details for a specific model ID.
 func (c *Client) GetModelDetails(modelID int) (models.Model, error) {
-	reqURL := fmt.Sprintf("%s/models/%d", CivitaiApiBaseUrl, modelID)
+	modelDetails, _, err := c.GetModelDetailsChanged(modelID)
+	return modelDetails, err
+}
+
+// GetModelDetailsChanged is GetModelDetails that also reports whether the
+// model changed since it was last fetched. It is false only when the API
+// answered a conditional request with 304 Not Modified, see SetHTTPCache.
+func (c *Client) GetModelDetailsChanged(modelID int) (models.Model, bool, error) {
+	reqURL := fmt.Sprintf("%s/models/%d", c.baseURL, modelID)
 	var modelDetails models.Model
 
+	if c.modelCache != nil {
+		if body, ok := c.modelCache.get(modelID); ok {
+			if err := json.Unmarshal(body, &modelDetails); err == nil {
+				log.Debugf("Model details cache hit for model %d", modelID)
+				if c.storeRawJSON {
+					AttachRawVersions(&modelDetails, body)
+				}
+				return modelDetails, true, nil
+			}
+			log.Debugf("Discarding unreadable cached details for model %d", modelID)
+			c.modelCache.forget(modelID)
+			modelDetails = models.Model{}
+		}
+	}
+
 	req, err := http.NewRequest("GET", reqURL, nil)
 	if err != nil {
 		log.WithError(err).Errorf("Error creating request for model details %d", modelID)
-		return modelDetails, fmt.Errorf("error creating request for model %d: %w", modelID, err)
+		return modelDetails, false, fmt.Errorf("error creating request for model %d: %w", modelID, err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("User-Agent", c.userAgent)
 	if c.ApiKey != "" {
 		req.Header.Set("Authorization", "Bearer "+c.ApiKey)
 	}
+	storedBody := c.setConditionalHeaders(req, reqURL)
 
 	resp, err := c.RetryableHTTPRequest(req)
 	if err != nil {
-		return models.Model{}, err
+		return models.Model{}, false, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		log.WithError(err).Error("Error reading final model details response body")
-		return models.Model{}, fmt.Errorf("error reading model details response body: %w", err)
+	changed := true
+	var body []byte
+	if resp.StatusCode == http.StatusNotModified && storedBody != nil {
+		log.Debugf("Model %d not modified since it was last fetched", modelID)
+		changed = false
+		body = storedBody
+	} else {
+		body, err = io.ReadAll(resp.Body)
+		if err != nil {
+			log.WithError(err).Error("Error reading final model details response body")
+			return models.Model{}, false, fmt.Errorf("error reading model details response body: %w", err)
+		}
 	}
 
 	err = json.Unmarshal(body, &modelDetails)
 	if err != nil {
 		log.WithError(err).Errorf("Error unmarshalling model details JSON for model ID %d", modelID)
 		log.Debugf("Response body causing unmarshal error: %s", string(body))
-		return models.Model{}, fmt.Errorf("error unmarshalling model details JSON: %w", err)
+		return models.Model{}, false, fmt.Errorf("error unmarshalling model details JSON: %w", err)
 	}
 
-	return modelDetails, nil
+	if changed {
+		c.storeValidators(reqURL, resp, body)
+	}
+	if c.modelCache != nil {
+		c.modelCache.put(modelID, body)
+	}
+	if c.storeRawJSON {
+		AttachRawVersions(&modelDetails, body)
+	}
+	return modelDetails, changed, nil
 }
 
 // GetModelVersionDetails fetches details for a specific model version ID.
 func (c *Client) GetModelVersionDetails(versionID int) (models.ModelVersion, error) {
-	reqURL := fmt.Sprintf("%s/model-versions/%d", CivitaiApiBaseUrl, versionID)
+	reqURL := fmt.Sprintf("%s/model-versions/%d", c.baseURL, versionID)
 	var versionDetails models.ModelVersion
 
 	req, err := http.NewRequest("GET", reqURL, nil)
@@ -254,7 +433,7 @@ func (c *Client) GetModelVersionDetails(versionID int) (models.ModelVersion, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("User-Agent", c.userAgent)
 	if c.ApiKey != "" {
 		req.Header.Set("Authorization", "Bearer "+c.ApiKey)
 	}
@@ -275,6 +454,9 @@ func (c *Client) GetModelVersionDetails(versionID int) (models.ModelVersion, err
 		log.Debugf("Response body causing unmarshal error: %s", string(body))
 		return versionDetails, fmt.Errorf("error unmarshalling model version details JSON: %w", err)
 	}
+	if c.storeRawJSON {
+		versionDetails.RawJSON = body
+	}
 
 	return versionDetails, nil
 }
@@ -286,7 +468,7 @@ func (c *Client) GetImages(cursor string, queryParams models.ImageAPIParameters)
 		values.Add("cursor", cursor)
 	}
 
-	reqURL := fmt.Sprintf("%s/images?%s", CivitaiApiBaseUrl, values.Encode())
+	reqURL := fmt.Sprintf("%s/images?%s", c.baseURL, values.Encode())
 	var response models.ImageApiResponse
 
 	req, err := http.NewRequest("GET", reqURL, nil)
@@ -295,7 +477,7 @@ func (c *Client) GetImages(cursor string, queryParams models.ImageAPIParameters)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("User-Agent", c.userAgent)
 	if c.ApiKey != "" {
 		req.Header.Set("Authorization", "Bearer "+c.ApiKey)
 	}
@@ -274,6 +274,74 @@ func TestGetModels_Integration(t *testing.T) {
 	}
 }
 
+// TestDecodeModelsPage_SkipsMalformedItems checks that one bad model entry
+// does not discard the rest of the page.
+func TestDecodeModelsPage_SkipsMalformedItems(t *testing.T) {
+	body := []byte(`{
+		"items": [
+			{"id": 1, "name": "good-one"},
+			{"id": "not-a-number", "name": "bad"},
+			{"id": 3, "name": "good-two"}
+		],
+		"metadata": {"nextCursor": "abc"}
+	}`)
+
+	page, skipped, err := decodeModelsPage(body, false)
+	if err != nil {
+		t.Fatalf("decodeModelsPage returned error: %v", err)
+	}
+	if skipped != 1 {
+		t.Errorf("skipped = %d, want 1", skipped)
+	}
+	if len(page.Items) != 2 || page.Items[0].ID != 1 || page.Items[1].ID != 3 {
+		t.Errorf("unexpected items: %+v", page.Items)
+	}
+	if page.Metadata.NextCursor.String() != "abc" {
+		t.Errorf("NextCursor = %q, want %q", page.Metadata.NextCursor.String(), "abc")
+	}
+
+	if _, _, err := decodeModelsPage([]byte(`{"items": {`), false); err == nil {
+		t.Error("expected error for malformed page envelope")
+	}
+}
+
+// TestDecodeModelsPage_KeepsRawVersions checks that keepRaw attaches each
+// version's original JSON, including fields the models do not know.
+func TestDecodeModelsPage_KeepsRawVersions(t *testing.T) {
+	body := []byte(`{
+		"items": [
+			{"id": 1, "name": "m", "modelVersions": [
+				{"id": 10, "name": "v1", "newApiField": [1, 2]},
+				{"id": 11, "name": "v2"}
+			]}
+		],
+		"metadata": {}
+	}`)
+
+	page, _, err := decodeModelsPage(body, true)
+	if err != nil {
+		t.Fatalf("decodeModelsPage returned error: %v", err)
+	}
+	versions := page.Items[0].ModelVersions
+	if len(versions) != 2 {
+		t.Fatalf("got %d versions, want 2", len(versions))
+	}
+	if got := string(versions[0].RawJSON); got != `{"id": 10, "name": "v1", "newApiField": [1, 2]}` {
+		t.Errorf("RawJSON of first version = %s", got)
+	}
+	if got := string(versions[1].RawJSON); got != `{"id": 11, "name": "v2"}` {
+		t.Errorf("RawJSON of second version = %s", got)
+	}
+
+	page, _, err = decodeModelsPage(body, false)
+	if err != nil {
+		t.Fatalf("decodeModelsPage returned error: %v", err)
+	}
+	if page.Items[0].ModelVersions[0].RawJSON != nil {
+		t.Error("RawJSON set without keepRaw")
+	}
+}
+
 // TestGetModelDetails_Integration tests fetching detailed model information
 func TestGetModelDetails_Integration(t *testing.T) {
 	apiKey := getTestAPIKey(t)
@@ -425,6 +493,61 @@ func TestAPIErrorHandling(t *testing.T) {
 	}
 }
 
+func TestConvertQueryParamsToURLValues_Favorites(t *testing.T) {
+	values := ConvertQueryParamsToURLValues(models.QueryParameters{Favorites: true})
+	if got := values.Get("favorites"); got != "true" {
+		t.Errorf("expected favorites=true, got %q", got)
+	}
+
+	values = ConvertQueryParamsToURLValues(models.QueryParameters{})
+	if values.Has("favorites") {
+		t.Error("favorites should not be sent unless requested")
+	}
+	if values.Has("hidden") {
+		t.Error("hidden should not be sent unless requested")
+	}
+
+	values = ConvertQueryParamsToURLValues(models.QueryParameters{Hidden: true})
+	if got := values.Get("hidden"); got != "true" {
+		t.Errorf("expected hidden=true, got %q", got)
+	}
+}
+
+func TestConvertQueryParamsToURLValues_BrowsingLevel(t *testing.T) {
+	values := ConvertQueryParamsToURLValues(models.QueryParameters{BrowsingLevel: 7, Nsfw: true})
+	if got := values.Get("browsingLevel"); got != "7" {
+		t.Errorf("expected browsingLevel=7, got %q", got)
+	}
+	if values.Has("nsfw") {
+		t.Error("nsfw should not be sent together with browsingLevel")
+	}
+
+	values = ConvertQueryParamsToURLValues(models.QueryParameters{Nsfw: true})
+	if values.Has("browsingLevel") || values.Get("nsfw") != "true" {
+		t.Errorf("expected only nsfw=true without a browsing level, got %v", values)
+	}
+}
+
+// TestConvertImageAPIParamsToURLValues_Filters tests that the ID and username
+// filters of the images command reach the URL.
+func TestConvertImageAPIParamsToURLValues_Filters(t *testing.T) {
+	values := ConvertImageAPIParamsToURLValues(models.ImageAPIParameters{PostID: 987, Nsfw: "None"})
+	if got := values.Get("postId"); got != "987" {
+		t.Errorf("expected postId=987, got %q (%v)", got, values)
+	}
+	if values.Has("modelId") || values.Has("modelVersionId") || values.Has("username") {
+		t.Errorf("expected no other filters, got %v", values)
+	}
+
+	values = ConvertImageAPIParamsToURLValues(models.ImageAPIParameters{ModelVersionID: 12, Username: "someone"})
+	if values.Has("postId") {
+		t.Errorf("expected no postId without a post ID, got %v", values)
+	}
+	if values.Get("modelVersionId") != "12" || values.Get("username") != "someone" {
+		t.Errorf("expected modelVersionId=12 and username=someone, got %v", values)
+	}
+}
+
 // TestConvertImageAPIParamsToURLValues_Nsfw tests the NSFW and BrowsingLevel
 // parameter generation for the /api/v1/images endpoint.
 func TestConvertImageAPIParamsToURLValues_Nsfw(t *testing.T) {
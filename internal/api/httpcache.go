@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// HTTPCache stores the validators (ETag, Last-Modified) and body of API
+// responses by URL so later fetches can be conditional. *database.DB
+// implements it with its http_cache table.
+type HTTPCache interface {
+	GetHTTPCache(url string) (etag, lastModified string, body []byte, err error)
+	PutHTTPCache(url, etag, lastModified string, body []byte) error
+}
+
+// SetHTTPCache makes GetModelDetails send If-None-Match / If-Modified-Since
+// with the validators stored in cache and reuse the stored body when the API
+// answers 304 Not Modified. Without a cache every fetch is a full one.
+func (c *Client) SetHTTPCache(cache HTTPCache) {
+	c.httpCache = cache
+}
+
+// setConditionalHeaders adds the validators stored for reqURL to req and
+// returns the stored body, or nil when there is nothing to revalidate.
+func (c *Client) setConditionalHeaders(req *http.Request, reqURL string) []byte {
+	if c.httpCache == nil {
+		return nil
+	}
+	etag, lastModified, body, err := c.httpCache.GetHTTPCache(reqURL)
+	if err != nil || len(body) == 0 || (etag == "" && lastModified == "") {
+		return nil
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	return body
+}
+
+// storeValidators remembers the validators of resp and its body for reqURL.
+// Responses without an ETag or Last-Modified header are not stored.
+func (c *Client) storeValidators(reqURL string, resp *http.Response, body []byte) {
+	if c.httpCache == nil {
+		return
+	}
+	etag, lastModified := resp.Header.Get("ETag"), resp.Header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return
+	}
+	if err := c.httpCache.PutHTTPCache(reqURL, etag, lastModified, body); err != nil {
+		log.WithError(err).Warnf("Failed to store cache validators for %s", reqURL)
+	}
+}
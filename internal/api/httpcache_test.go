@@ -0,0 +1,106 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"go-civitai-download/internal/models"
+)
+
+// mapHTTPCache is an in-memory HTTPCache.
+type mapHTTPCache map[string][3]string
+
+func (m mapHTTPCache) GetHTTPCache(url string) (string, string, []byte, error) {
+	entry, ok := m[url]
+	if !ok {
+		return "", "", nil, errors.New("not found")
+	}
+	return entry[0], entry[1], []byte(entry[2]), nil
+}
+
+func (m mapHTTPCache) PutHTTPCache(url, etag, lastModified string, body []byte) error {
+	m[url] = [3]string{etag, lastModified, string(body)}
+	return nil
+}
+
+func TestGetModelDetailsChanged_ConditionalRequest(t *testing.T) {
+	var full, notModified int32
+	etag := `"v1"`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == etag {
+			atomic.AddInt32(&notModified, 1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		atomic.AddInt32(&full, 1)
+		w.Header().Set("ETag", etag)
+		_, _ = w.Write([]byte(`{"id": 7, "name": "Model", "modelVersions": [{"id": 70}]}`))
+	}))
+	defer server.Close()
+
+	cache := mapHTTPCache{}
+	// A new client per run, so the in-memory model cache does not answer
+	fetch := func() (models.Model, bool) {
+		t.Helper()
+		client := NewClient("", server.Client(), models.Config{APIBaseURL: server.URL})
+		client.SetHTTPCache(cache)
+		model, changed, err := client.GetModelDetailsChanged(7)
+		if err != nil {
+			t.Fatalf("GetModelDetailsChanged: %v", err)
+		}
+		return model, changed
+	}
+
+	model, changed := fetch()
+	if !changed || model.ID != 7 {
+		t.Fatalf("first fetch: changed=%v model=%+v", changed, model)
+	}
+	if cache[server.URL+"/models/7"][0] != etag {
+		t.Fatalf("ETag not stored: %v", cache)
+	}
+
+	model, changed = fetch()
+	if changed {
+		t.Error("expected the 304 answer to report no change")
+	}
+	if model.ID != 7 || len(model.ModelVersions) != 1 || model.ModelVersions[0].ID != 70 {
+		t.Errorf("expected the stored model, got %+v", model)
+	}
+	if full != 1 || notModified != 1 {
+		t.Errorf("expected 1 full and 1 conditional request, got %d and %d", full, notModified)
+	}
+
+	// A new ETag is a change and replaces the stored one
+	etag = `"v2"`
+	if _, changed = fetch(); !changed {
+		t.Error("expected a changed ETag to report a change")
+	}
+	if cache[server.URL+"/models/7"][0] != `"v2"` {
+		t.Errorf("ETag not replaced: %v", cache)
+	}
+}
+
+func TestGetModelDetailsChanged_WithoutCache(t *testing.T) {
+	var conditional int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			atomic.AddInt32(&conditional, 1)
+		}
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte(`{"id": 7}`))
+	}))
+	defer server.Close()
+
+	for i := 0; i < 2; i++ {
+		client := NewClient("", server.Client(), models.Config{APIBaseURL: server.URL})
+		if _, changed, err := client.GetModelDetailsChanged(7); err != nil || !changed {
+			t.Fatalf("GetModelDetailsChanged: changed=%v err=%v", changed, err)
+		}
+	}
+	if conditional != 0 {
+		t.Errorf("expected no conditional requests without a cache, got %d", conditional)
+	}
+}
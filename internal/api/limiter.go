@@ -0,0 +1,33 @@
+package api
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
+)
+
+// SharedLimiter paces every Civitai API request of the run, those sent by
+// Client and the download command's own request retries alike, so concurrent
+// workers and pagination loops cannot burst past RequestsPerMinute together.
+// It does not limit until SetRequestsPerMinute is called.
+var SharedLimiter = rate.NewLimiter(rate.Inf, 1)
+
+// SetRequestsPerMinute sets the pace of SharedLimiter. 0 or less removes the
+// limit. The burst is one request, so requests are spread evenly.
+func SetRequestsPerMinute(perMinute int) {
+	if perMinute <= 0 {
+		SharedLimiter.SetLimit(rate.Inf)
+		return
+	}
+	SharedLimiter.SetLimit(rate.Every(time.Minute / time.Duration(perMinute)))
+	SharedLimiter.SetBurst(1)
+	log.Debugf("Limiting API requests to %d per minute", perMinute)
+}
+
+// WaitForRequestSlot blocks until SharedLimiter lets the next API request go,
+// or ctx is done.
+func WaitForRequestSlot(ctx context.Context) error {
+	return SharedLimiter.Wait(ctx)
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSetRequestsPerMinute(t *testing.T) {
+	t.Cleanup(func() { SetRequestsPerMinute(0) })
+
+	// 600 per minute lets one request through every 100ms
+	SetRequestsPerMinute(600)
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := WaitForRequestSlot(context.Background()); err != nil {
+			t.Fatalf("WaitForRequestSlot: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("expected 3 requests to take about 200ms, took %v", elapsed)
+	}
+
+	// Removing the limit lets requests through at once
+	SetRequestsPerMinute(0)
+	start = time.Now()
+	for i := 0; i < 100; i++ {
+		if err := WaitForRequestSlot(context.Background()); err != nil {
+			t.Fatalf("WaitForRequestSlot: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("expected unlimited requests not to wait, took %v", elapsed)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/http/httputil"
 	"os"
+	"regexp"
 	"strings"
 	sync "sync"
 	time "time"
@@ -17,28 +18,186 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
+// DefaultAPILogMaxBackups is the number of rotated api.log files kept on disk
+// (api.log.1 is the most recent, api.log.N the oldest).
+const DefaultAPILogMaxBackups = 3
+
 // Global slice to keep track of all logging transports created
 var (
 	activeLoggingTransports []*LoggingTransport
 	transportsMu            sync.Mutex
 )
 
+// Registry of open log sinks keyed by sanitized file path. Every transport
+// pointing at the same file shares one sink so writes go through a single
+// handle and a single lock.
+var (
+	activeLogSinks = map[string]*apiLogSink{}
+	sinksMu        sync.Mutex
+)
+
+// apiLogSink serializes writes to one API log file and rotates it by size.
+type apiLogSink struct {
+	file       *os.File
+	writer     *bufio.Writer
+	path       string
+	size       int64
+	maxSize    int64 // 0 disables rotation
+	maxBackups int
+	refs       int
+	mu         sync.Mutex
+}
+
+// acquireLogSink returns the shared sink for path, opening the file if needed.
+// A non-zero maxSizeBytes updates the rotation threshold of an existing sink.
+func acquireLogSink(path string, maxSizeBytes int64) (*apiLogSink, error) {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+
+	if s, ok := activeLogSinks[path]; ok {
+		s.mu.Lock()
+		s.refs++
+		if maxSizeBytes > 0 {
+			s.maxSize = maxSizeBytes
+		}
+		s.mu.Unlock()
+		return s, nil
+	}
+
+	s := &apiLogSink{
+		path:       path,
+		maxSize:    maxSizeBytes,
+		maxBackups: DefaultAPILogMaxBackups,
+		refs:       1,
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	activeLogSinks[path] = s
+	return s, nil
+}
+
+// open (re)opens the log file for appending and records its current size.
+// Caller must hold s.mu or be the only owner of s.
+func (s *apiLogSink) open() error {
+	// #nosec G304
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open API log file %s: %w", s.path, err)
+	}
+	var size int64
+	if info, statErr := f.Stat(); statErr == nil {
+		size = info.Size()
+	}
+	s.file = f
+	s.writer = bufio.NewWriter(f)
+	s.size = size
+	return nil
+}
+
+// rotate shifts api.log -> api.log.1 -> api.log.2 ... and reopens a fresh file.
+// Caller must hold s.mu.
+func (s *apiLogSink) rotate() error {
+	if err := s.writer.Flush(); err != nil {
+		return fmt.Errorf("failed to flush API log before rotation: %w", err)
+	}
+	if err := s.file.Close(); err != nil {
+		return fmt.Errorf("failed to close API log before rotation: %w", err)
+	}
+
+	// Drop the oldest backup, then shift the rest up by one.
+	oldest := fmt.Sprintf("%s.%d", s.path, s.maxBackups)
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		log.WithError(err).Warnf("[LogTransport] Failed to remove oldest API log backup %s", oldest)
+	}
+	for i := s.maxBackups - 1; i >= 1; i-- {
+		from := fmt.Sprintf("%s.%d", s.path, i)
+		to := fmt.Sprintf("%s.%d", s.path, i+1)
+		if err := os.Rename(from, to); err != nil && !os.IsNotExist(err) {
+			log.WithError(err).Warnf("[LogTransport] Failed to shift API log backup %s -> %s", from, to)
+		}
+	}
+	if s.maxBackups > 0 {
+		if err := os.Rename(s.path, s.path+".1"); err != nil {
+			log.WithError(err).Warnf("[LogTransport] Failed to rotate API log %s", s.path)
+		}
+	} else if err := os.Remove(s.path); err != nil && !os.IsNotExist(err) {
+		log.WithError(err).Warnf("[LogTransport] Failed to truncate API log %s", s.path)
+	}
+
+	return s.open()
+}
+
+// write appends one complete log entry. The entry is written and flushed while
+// holding the sink lock, so concurrent callers can never interleave.
+func (s *apiLogSink) write(entry string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file == nil {
+		fmt.Fprintf(os.Stderr, "API log file %s already closed, dropping entry:\n%s\n", s.path, entry)
+		return
+	}
+
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(entry)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error rotating API log file: %v\n", err)
+			if s.file == nil {
+				return
+			}
+		}
+	}
+
+	n, err := s.writer.WriteString(entry)
+	s.size += int64(n)
+	if err != nil {
+		// Log to stderr if writing to file fails
+		fmt.Fprintf(os.Stderr, "Error writing to API log file: %v\nLog message: %s\n", err, entry)
+		return
+	}
+	if errFlush := s.writer.Flush(); errFlush != nil {
+		fmt.Fprintf(os.Stderr, "Error flushing API log file: %v\n", errFlush)
+	}
+}
+
+// release drops one reference and closes the file once nobody uses it.
+func (s *apiLogSink) release() error {
+	sinksMu.Lock()
+	defer sinksMu.Unlock()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.refs--
+	if s.refs > 0 || s.file == nil {
+		return nil
+	}
+	delete(activeLogSinks, s.path)
+
+	errFlush := s.writer.Flush() // Ensure buffer is flushed before closing
+	errClose := s.file.Close()
+	s.file = nil
+	if errFlush != nil {
+		return fmt.Errorf("failed to flush API log buffer: %w", errFlush)
+	}
+	return errClose // Return close error if flush was successful
+}
+
 // LoggingTransport wraps an http.RoundTripper to log request and response details.
 type LoggingTransport struct {
 	Transport http.RoundTripper
-	logFile   *os.File
-	writer    *bufio.Writer
-	mu        sync.Mutex
+	sink      *apiLogSink
+	closeOnce sync.Once
 }
 
 // NewLoggingTransport creates a new LoggingTransport.
-// It opens the specified log file for appending.
-func NewLoggingTransport(transport http.RoundTripper, logFilePath string) (*LoggingTransport, error) {
+// It opens the specified log file for appending. Transports created for the same
+// file share a single writer. When maxSizeBytes is greater than zero the file is
+// rotated once it would grow beyond that size.
+func NewLoggingTransport(transport http.RoundTripper, logFilePath string, maxSizeBytes int64) (*LoggingTransport, error) {
 	safeLogFilePath := helpers.SanitizePath(logFilePath)
-	// #nosec G304
-	f, err := os.OpenFile(safeLogFilePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	sink, err := acquireLogSink(safeLogFilePath, maxSizeBytes)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open API log file %s: %w", safeLogFilePath, err)
+		return nil, err
 	}
 
 	// Use default transport if none provided
@@ -48,8 +207,7 @@ func NewLoggingTransport(transport http.RoundTripper, logFilePath string) (*Logg
 
 	lt := &LoggingTransport{
 		Transport: transport,
-		logFile:   f,
-		writer:    bufio.NewWriter(f), // Use a buffered writer
+		sink:      sink,
 	}
 
 	// Register the new transport
@@ -61,36 +219,79 @@ func NewLoggingTransport(transport http.RoundTripper, logFilePath string) (*Logg
 	return lt, nil
 }
 
+// redactedHeaders are request headers carrying credentials. Their values are
+// replaced in the log so the API key and session cookie never end up on disk.
+var redactedHeaders = []string{"Authorization", "Cookie"}
+
+// tokenParam matches the value of the token query parameter, which download
+// URLs carry the API key in.
+var tokenParam = regexp.MustCompile(`(?i)([?&]token=)[^&#\s]*`)
+
+// redactURL returns s with the value of any token query parameter replaced.
+func redactURL(s string) string {
+	return tokenParam.ReplaceAllString(s, "${1}[REDACTED]")
+}
+
+// redactCredentials returns the request or response dump with the values of
+// redactedHeaders replaced, and the token query parameter redacted from the
+// request line and Location headers. Only the header block is changed, not
+// the body.
+func redactCredentials(dump []byte) string {
+	head, body, found := strings.Cut(string(dump), "\r\n\r\n")
+	lines := strings.Split(head, "\r\n")
+	for i, line := range lines {
+		if i == 0 {
+			lines[i] = redactURL(line)
+			continue
+		}
+		name, _, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		if strings.EqualFold(name, "Location") {
+			lines[i] = redactURL(line)
+		}
+		for _, header := range redactedHeaders {
+			if strings.EqualFold(name, header) {
+				lines[i] = name + ": [REDACTED]"
+			}
+		}
+	}
+	redacted := strings.Join(lines, "\r\n")
+	if found {
+		redacted += "\r\n\r\n" + body
+	}
+	return redacted
+}
+
 // RoundTrip executes a single HTTP transaction, logging details.
+// The request and its response are written as one entry so that entries from
+// concurrent workers never interleave.
 func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
-	// Lock is now more granular to only protect file writing, not the entire network request.
 	log.Debug("[LogTransport] RoundTrip: Entered")
 	startTime := time.Now()
 
-	// Log request before sending
+	var entry strings.Builder
+
+	// Capture request before sending
 	reqDump, err := httputil.DumpRequestOut(req, true)
 	if err != nil {
 		log.WithError(err).Error("[LogTransport] Failed to dump API request for logging")
+		fmt.Fprintf(&entry, "--- Request (%s) ---\n%s %s\n(Request dump failed)\n\n", startTime.Format(time.RFC3339), req.Method, redactURL(req.URL.String()))
 	} else {
-		t.mu.Lock()
-		t.writeLog(fmt.Sprintf("--- Request (%s) ---\n%s\n", startTime.Format(time.RFC3339), string(reqDump)))
-		t.mu.Unlock()
+		fmt.Fprintf(&entry, "--- Request (%s) ---\n%s\n\n", startTime.Format(time.RFC3339), redactCredentials(reqDump))
 	}
 
-	// Perform the actual request (outside the lock)
+	// Perform the actual request (no lock held)
 	log.Debug("[LogTransport] RoundTrip: Performing underlying Transport.RoundTrip...")
 	resp, err := t.Transport.RoundTrip(req)
 	log.Debugf("[LogTransport] RoundTrip: Underlying Transport.RoundTrip returned. Err: %v", err)
 
 	duration := time.Since(startTime)
 
-	// Lock again to write the response log
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	// Log response or error
+	// Capture response or error
 	if err != nil {
-		t.writeLog(fmt.Sprintf("--- Response Error (%s, Duration: %v) ---\n%s\n", time.Now().Format(time.RFC3339), duration, err.Error()))
+		fmt.Fprintf(&entry, "--- Response Error (%s, Duration: %v) ---\n%s\n", time.Now().Format(time.RFC3339), duration, err.Error())
 	} else {
 		contentType := resp.Header.Get("Content-Type")
 		logBody := strings.HasPrefix(contentType, "application/json")
@@ -100,7 +301,7 @@ func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error)
 			if readErr != nil {
 				log.WithError(readErr).Error("[LogTransport] Failed to read response body for logging")
 				respDump, _ := httputil.DumpResponse(resp, false)
-				t.writeLog(fmt.Sprintf("--- Response Headers (%s, Duration: %v) ---\n%s\n(Body read failed)\n", time.Now().Format(time.RFC3339), duration, string(respDump)))
+				fmt.Fprintf(&entry, "--- Response Headers (%s, Duration: %v) ---\n%s\n(Body read failed)\n", time.Now().Format(time.RFC3339), duration, redactCredentials(respDump))
 			} else {
 				if closeErr := resp.Body.Close(); closeErr != nil {
 					log.WithError(closeErr).Warn("[LogTransport] Failed to close original response body before replacing it")
@@ -108,42 +309,33 @@ func (t *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error)
 				resp.Body = io.NopCloser(bytes.NewReader(bodyBytes))
 
 				respDumpHeader, _ := httputil.DumpResponse(resp, false)
-				t.writeLog(fmt.Sprintf("--- Response Headers (%s, Duration: %v) ---\n%s\n--- Response Body (%s) ---\n%s\n", time.Now().Format(time.RFC3339), duration, string(respDumpHeader), contentType, string(bodyBytes)))
+				fmt.Fprintf(&entry, "--- Response Headers (%s, Duration: %v) ---\n%s\n--- Response Body (%s) ---\n%s\n", time.Now().Format(time.RFC3339), duration, redactCredentials(respDumpHeader), contentType, string(bodyBytes))
 			}
 		} else {
 			respDump, _ := httputil.DumpResponse(resp, false)
-			t.writeLog(fmt.Sprintf("--- Response Headers (%s, Duration: %v, Type: %s) ---\n%s\n(Body not logged)\n", time.Now().Format(time.RFC3339), duration, contentType, string(respDump)))
+			fmt.Fprintf(&entry, "--- Response Headers (%s, Duration: %v, Type: %s) ---\n%s\n(Body not logged)\n", time.Now().Format(time.RFC3339), duration, contentType, redactCredentials(respDump))
 		}
 	}
 
-	if errFlush := t.writer.Flush(); errFlush != nil {
-		log.WithError(errFlush).Error("[LogTransport] Failed to flush log writer")
-	}
+	t.writeLog(entry.String())
 
 	log.Debug("[LogTransport] RoundTrip: Exiting")
 	return resp, err
 }
 
-// writeLog writes a string to the buffered writer.
+// writeLog hands a complete entry to the shared sink.
 func (t *LoggingTransport) writeLog(logString string) {
-	_, err := t.writer.WriteString(logString + "\n\n")
-	if err != nil {
-		// Log to stderr if writing to file fails
-		fmt.Fprintf(os.Stderr, "Error writing to API log file: %v\nLog message: %s\n", err, logString)
-	}
+	t.sink.write(logString + "\n\n")
 }
 
-// Close closes the underlying log file.
+// Close releases this transport's handle on the log file. The file itself is
+// closed once the last transport sharing it is closed.
 func (t *LoggingTransport) Close() error {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	errFlush := t.writer.Flush() // Ensure buffer is flushed before closing
-	errClose := t.logFile.Close()
-	if errFlush != nil {
-		return fmt.Errorf("failed to flush API log buffer: %w", errFlush)
-	}
-	return errClose // Return close error if flush was successful
+	var err error
+	t.closeOnce.Do(func() {
+		err = t.sink.release()
+	})
+	return err
 }
 
 // CloseAllLoggingTransports iterates over all created transports and closes them.
@@ -154,10 +346,10 @@ func CloseAllLoggingTransports() {
 	log.Debugf("Attempting to close %d active logging transports.", len(activeLoggingTransports))
 	closedCount := 0
 	for i, t := range activeLoggingTransports {
-		log.Debugf("Closing transport #%d for file: %s", i+1, t.logFile.Name())
+		log.Debugf("Closing transport #%d for file: %s", i+1, t.sink.path)
 		if err := t.Close(); err != nil {
 			// Log error to stderr as the primary logger might also be closing
-			fmt.Fprintf(os.Stderr, "Error closing logging transport for %s: %v\n", t.logFile.Name(), err)
+			fmt.Fprintf(os.Stderr, "Error closing logging transport for %s: %v\n", t.sink.path, err)
 		} else {
 			closedCount++
 		}
@@ -174,7 +366,7 @@ func DeregisterLoggingTransport(transportToDeregister *LoggingTransport) {
 	transportsMu.Lock()
 	defer transportsMu.Unlock()
 
-	log.Debugf("Attempting to deregister logging transport for file: %s", transportToDeregister.logFile.Name())
+	log.Debugf("Attempting to deregister logging transport for file: %s", transportToDeregister.sink.path)
 	found := false
 	newActiveTransports := []*LoggingTransport{}
 	for _, t := range activeLoggingTransports {
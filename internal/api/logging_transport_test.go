@@ -0,0 +1,240 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// TestLoggingTransport_ConcurrentWrites checks that concurrent requests through
+// transports sharing one log file produce whole, non-interleaved entries.
+func TestLoggingTransport_ConcurrentWrites(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(`{"items":[],"padding":"` + strings.Repeat("x", 8192) + `"}`))
+	}))
+	defer server.Close()
+
+	logPath := filepath.Join(chdirTemp(t), "api.log")
+
+	// Two transports on the same path mimic separate clients in one run.
+	lt1, err := NewLoggingTransport(http.DefaultTransport, logPath, 0)
+	if err != nil {
+		t.Fatalf("Failed to create logging transport: %v", err)
+	}
+	lt2, err := NewLoggingTransport(http.DefaultTransport, logPath, 0)
+	if err != nil {
+		t.Fatalf("Failed to create second logging transport: %v", err)
+	}
+	if lt1.sink != lt2.sink {
+		t.Fatal("Expected transports for the same file to share a sink")
+	}
+
+	const requests = 40
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client := &http.Client{Transport: lt1}
+			if i%2 == 1 {
+				client.Transport = lt2
+			}
+			resp, err := client.Get(fmt.Sprintf("%s/req-%d", server.URL, i))
+			if err != nil {
+				t.Errorf("Request %d failed: %v", i, err)
+				return
+			}
+			_ = resp.Body.Close()
+		}(i)
+	}
+	wg.Wait()
+
+	if err := lt1.Close(); err != nil {
+		t.Fatalf("Failed to close first transport: %v", err)
+	}
+	if err := lt2.Close(); err != nil {
+		t.Fatalf("Failed to close second transport: %v", err)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+
+	entries := strings.Split(string(data), "--- Request (")[1:]
+	if len(entries) != requests {
+		t.Fatalf("Expected %d entries, got %d", requests, len(entries))
+	}
+	for _, entry := range entries {
+		if strings.Count(entry, "--- Response Headers") != 1 || strings.Count(entry, "GET /req-") != 1 {
+			t.Errorf("Entry is not a single request/response pair:\n%.300s", entry)
+		}
+	}
+}
+
+// TestLoggingTransport_Rotation checks that the log is rotated once it exceeds
+// the configured size.
+func TestLoggingTransport_Rotation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"data":"` + strings.Repeat("y", 2048) + `"}`))
+	}))
+	defer server.Close()
+
+	logPath := filepath.Join(chdirTemp(t), "api.log")
+	const maxSize = 4096
+
+	lt, err := NewLoggingTransport(http.DefaultTransport, logPath, maxSize)
+	if err != nil {
+		t.Fatalf("Failed to create logging transport: %v", err)
+	}
+	client := &http.Client{Transport: lt}
+	for i := 0; i < 10; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("Request %d failed: %v", i, err)
+		}
+		_ = resp.Body.Close()
+	}
+	if err := lt.Close(); err != nil {
+		t.Fatalf("Failed to close transport: %v", err)
+	}
+
+	for _, p := range []string{logPath, logPath + ".1", logPath + fmt.Sprintf(".%d", DefaultAPILogMaxBackups)} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("Expected %s to exist: %v", p, err)
+		}
+	}
+	if _, err := os.Stat(fmt.Sprintf("%s.%d", logPath, DefaultAPILogMaxBackups+1)); !os.IsNotExist(err) {
+		t.Errorf("Expected no more than %d backups to be kept", DefaultAPILogMaxBackups)
+	}
+
+	info, err := os.Stat(logPath)
+	if err != nil {
+		t.Fatalf("Failed to stat log: %v", err)
+	}
+	// A single entry may exceed maxSize on its own, but the file should never hold two.
+	if info.Size() > 2*maxSize {
+		t.Errorf("Expected rotated log to stay small, got %d bytes", info.Size())
+	}
+}
+
+// TestLoggingTransport_RedactsCredentials checks that the API key and session
+// cookie are not written to the log, while the server still receives them.
+func TestLoggingTransport_RedactsCredentials(t *testing.T) {
+	var gotCookie string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotCookie = r.Header.Get("Cookie")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"items":[]}`))
+	}))
+	defer server.Close()
+
+	logPath := filepath.Join(chdirTemp(t), "api.log")
+	lt, err := NewLoggingTransport(http.DefaultTransport, logPath, 0)
+	if err != nil {
+		t.Fatalf("Failed to create logging transport: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer secret-api-key")
+	req.Header.Set("Cookie", "__Secure-civitai-token=secret-session")
+	resp, err := (&http.Client{Transport: lt}).Do(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if err := lt.Close(); err != nil {
+		t.Fatalf("Failed to close transport: %v", err)
+	}
+
+	if gotCookie != "__Secure-civitai-token=secret-session" {
+		t.Errorf("Expected the server to receive the cookie, got %q", gotCookie)
+	}
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	logged := string(data)
+	for _, secret := range []string{"secret-api-key", "secret-session"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("Expected %q to be redacted from the log:\n%s", secret, logged)
+		}
+	}
+	if !strings.Contains(logged, "Cookie: [REDACTED]") || !strings.Contains(logged, "Authorization: [REDACTED]") {
+		t.Errorf("Expected redacted headers in the log:\n%s", logged)
+	}
+}
+
+// TestLoggingTransport_RedactsTokenParam checks that the API key passed as the
+// token query parameter is redacted from the request line and from the
+// Location header of a redirect.
+func TestLoggingTransport_RedactsTokenParam(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "https://cdn.example.com/file?token=secret-redirect&expires=1")
+		w.WriteHeader(http.StatusFound)
+	}))
+	defer server.Close()
+
+	logPath := filepath.Join(chdirTemp(t), "api.log")
+	lt, err := NewLoggingTransport(http.DefaultTransport, logPath, 0)
+	if err != nil {
+		t.Fatalf("Failed to create logging transport: %v", err)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/api/download/models/1?type=Model&token=secret-api-key", nil)
+	if err != nil {
+		t.Fatalf("Failed to create request: %v", err)
+	}
+	resp, err := lt.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	_ = resp.Body.Close()
+	if err := lt.Close(); err != nil {
+		t.Fatalf("Failed to close transport: %v", err)
+	}
+
+	data, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("Failed to read log: %v", err)
+	}
+	logged := string(data)
+	for _, secret := range []string{"secret-api-key", "secret-redirect"} {
+		if strings.Contains(logged, secret) {
+			t.Errorf("Expected %q to be redacted from the log:\n%s", secret, logged)
+		}
+	}
+	for _, want := range []string{"type=Model&token=[REDACTED]", "file?token=[REDACTED]&expires=1"} {
+		if !strings.Contains(logged, want) {
+			t.Errorf("Expected %q in the log:\n%s", want, logged)
+		}
+	}
+}
+
+// chdirTemp switches into a fresh temp directory for the duration of the test
+// and returns a relative path to it. NewLoggingTransport sanitizes paths to be
+// relative, so the log must live under the working directory.
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Failed to change to temp directory: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+	return "."
+}
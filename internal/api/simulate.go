@@ -0,0 +1,139 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SimulatedResponse is one programmed answer of a RateSimulator.
+type SimulatedResponse struct {
+	Status     int
+	RetryAfter int // Seconds sent in a Retry-After header (0 = no header)
+}
+
+// RateSimulator stands in for the Civitai API and answers requests with a
+// programmed sequence of statuses, so the retry, Retry-After and circuit
+// breaker handling can be exercised deterministically. Once the sequence is
+// used up every request succeeds with an empty but valid response.
+type RateSimulator struct {
+	mu        sync.Mutex
+	responses []SimulatedResponse
+	requests  int
+}
+
+// NewRateSimulator returns a simulator answering with responses in order.
+func NewRateSimulator(responses []SimulatedResponse) *RateSimulator {
+	return &RateSimulator{responses: responses}
+}
+
+// ParseSimulatedResponses parses a sequence such as "429:2,503x3,200": comma
+// separated statuses, each optionally followed by ":<seconds>" for a
+// Retry-After header and "x<count>" to repeat it.
+func ParseSimulatedResponses(spec string) ([]SimulatedResponse, error) {
+	var responses []SimulatedResponse
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		count := 1
+		if i := strings.IndexByte(item, 'x'); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid repeat count in %q", item)
+			}
+			count = n
+			item = item[:i]
+		}
+		var response SimulatedResponse
+		statusStr, retryAfterStr, hasRetryAfter := strings.Cut(item, ":")
+		status, err := strconv.Atoi(statusStr)
+		if err != nil || status < 100 || status > 599 {
+			return nil, fmt.Errorf("invalid HTTP status %q", statusStr)
+		}
+		response.Status = status
+		if hasRetryAfter {
+			seconds, err := strconv.Atoi(retryAfterStr)
+			if err != nil || seconds < 0 {
+				return nil, fmt.Errorf("invalid Retry-After seconds in %q", item)
+			}
+			response.RetryAfter = seconds
+		}
+		for i := 0; i < count; i++ {
+			responses = append(responses, response)
+		}
+	}
+	if len(responses) == 0 {
+		return nil, fmt.Errorf("no statuses in %q", spec)
+	}
+	return responses, nil
+}
+
+// Requests returns how many requests the simulator has answered.
+func (s *RateSimulator) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// ServeHTTP answers with the next programmed response.
+func (s *RateSimulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	response := SimulatedResponse{Status: http.StatusOK}
+	if s.requests < len(s.responses) {
+		response = s.responses[s.requests]
+	}
+	s.requests++
+	n := s.requests
+	s.mu.Unlock()
+
+	log.Debugf("[RateSimulator] Request %d for %s: answering %d", n, r.URL.Path, response.Status)
+	w.Header().Set("Content-Type", "application/json")
+	if response.RetryAfter > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(response.RetryAfter))
+	}
+	w.WriteHeader(response.Status)
+	if response.Status != http.StatusOK {
+		_, _ = fmt.Fprintf(w, `{"error":"simulated %d %s"}`, response.Status, http.StatusText(response.Status))
+		return
+	}
+	_, _ = w.Write([]byte(simulatedBody(r.URL.Path)))
+}
+
+// simulatedBody returns a minimal successful response for an API path.
+func simulatedBody(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	last := segments[len(segments)-1]
+	id, err := strconv.Atoi(last)
+	if err != nil {
+		// A list endpoint such as /models or /images
+		return `{"items":[],"metadata":{}}`
+	}
+	if len(segments) >= 2 && segments[len(segments)-2] == "model-versions" {
+		return fmt.Sprintf(`{"id":%d,"modelId":%d,"name":"Simulated version","files":[],"images":[]}`, id, id)
+	}
+	return fmt.Sprintf(`{"id":%d,"name":"Simulated model","type":"Checkpoint","modelVersions":[]}`, id)
+}
+
+// Start serves the simulator on a free local port. It returns the base URL to
+// use as APIBaseURL and a function stopping the server.
+func (s *RateSimulator) Start() (string, func(), error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("starting rate simulator: %w", err)
+	}
+	server := &http.Server{Handler: s, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			log.WithError(err).Error("Rate simulator stopped")
+		}
+	}()
+	return "http://" + listener.Addr().String() + "/api/v1", func() { _ = server.Close() }, nil
+}
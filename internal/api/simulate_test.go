@@ -0,0 +1,74 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"go-civitai-download/internal/models"
+)
+
+func TestParseSimulatedResponses(t *testing.T) {
+	responses, err := ParseSimulatedResponses("429:2, 503x2,200")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []SimulatedResponse{{Status: 429, RetryAfter: 2}, {Status: 503}, {Status: 503}, {Status: 200}}
+	if len(responses) != len(want) {
+		t.Fatalf("got %v, want %v", responses, want)
+	}
+	for i := range want {
+		if responses[i] != want[i] {
+			t.Errorf("response %d: got %+v, want %+v", i, responses[i], want[i])
+		}
+	}
+
+	for _, spec := range []string{"", "abc", "429:x", "429x0", "42"} {
+		if _, err := ParseSimulatedResponses(spec); err == nil {
+			t.Errorf("expected an error for %q", spec)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	resp := &http.Response{Header: http.Header{}}
+	if got := RetryAfter(resp); got != 0 {
+		t.Errorf("no header: got %v", got)
+	}
+	resp.Header.Set("Retry-After", "3")
+	if got := RetryAfter(resp); got != 3*time.Second {
+		t.Errorf("seconds: got %v", got)
+	}
+	resp.Header.Set("Retry-After", time.Now().Add(time.Hour).UTC().Format(http.TimeFormat))
+	if got := RetryAfter(resp); got != maxRetryAfter {
+		t.Errorf("date an hour away: got %v, want the %v cap", got, maxRetryAfter)
+	}
+	resp.Header.Set("Retry-After", "soon")
+	if got := RetryAfter(resp); got != 0 {
+		t.Errorf("invalid header: got %v", got)
+	}
+}
+
+func TestClientUsesAPIBaseURL(t *testing.T) {
+	simulator := NewRateSimulator([]SimulatedResponse{{Status: http.StatusOK}})
+	baseURL, stop, err := simulator.Start()
+	if err != nil {
+		t.Fatalf("starting simulator: %v", err)
+	}
+	defer stop()
+
+	client := NewClient("", nil, models.Config{APIBaseURL: baseURL + "/"})
+	model, err := client.GetModelDetails(7)
+	if err != nil {
+		t.Fatalf("GetModelDetails: %v", err)
+	}
+	if model.ID != 7 {
+		t.Errorf("expected the simulated model 7, got %d", model.ID)
+	}
+	if got := simulator.Requests(); got != 1 {
+		t.Errorf("expected 1 request to the simulator, got %d", got)
+	}
+	if got := BaseURL(models.Config{}); got != CivitaiApiBaseUrl {
+		t.Errorf("expected the Civitai API by default, got %s", got)
+	}
+}
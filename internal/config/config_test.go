@@ -1,7 +1,13 @@
 package config
 
 import (
+	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"go-civitai-download/internal/api"
+	"go-civitai-download/internal/models"
 )
 
 // TestConfigInitialization tests basic configuration initialization
@@ -246,6 +252,34 @@ func TestMultipleFlagTypes(t *testing.T) {
 	}
 }
 
+// TestFilterNamesNormalized tests that model types and base models get the
+// case the API expects, while unknown values are passed through
+func TestFilterNamesNormalized(t *testing.T) {
+	modelTypes := []string{"lora", "textualinversion", "BrandNewType"}
+	baseModels := []string{"sdxl 1.0", "Pony"}
+	flags := CliFlags{
+		Download: &CliDownloadFlags{
+			ModelTypes: &modelTypes,
+			BaseModels: &baseModels,
+		},
+	}
+
+	cfg, _, err := Initialize(flags)
+	if err != nil {
+		t.Fatalf("Failed to initialize config: %v", err)
+	}
+
+	wantTypes := []string{"LORA", "TextualInversion", "BrandNewType"}
+	for i, want := range wantTypes {
+		if cfg.Download.ModelTypes[i] != want {
+			t.Errorf("ModelTypes[%d] = %q, want %q", i, cfg.Download.ModelTypes[i], want)
+		}
+	}
+	if cfg.Download.BaseModels[0] != "SDXL 1.0" || cfg.Download.BaseModels[1] != "Pony" {
+		t.Errorf("BaseModels = %v, want [SDXL 1.0 Pony]", cfg.Download.BaseModels)
+	}
+}
+
 // TestImagesFlagOverrides tests that images-specific CLI flags are properly applied
 func TestImagesFlagOverrides(t *testing.T) {
 	nsfw := "Soft"
@@ -283,3 +317,207 @@ func TestImagesFlagOverrides(t *testing.T) {
 		t.Errorf("Expected Images.Limit 50 (from flags), got %d", cfg.Images.Limit)
 	}
 }
+
+// TestLayeredConfigFiles tests that later --config files override earlier ones
+// key by key, replace lists as a whole, and still lose to CLI flags
+func TestLayeredConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.toml")
+	local := filepath.Join(dir, "local.toml")
+	baseContent := `ApiDelayMs = 1500
+
+[Download]
+Limit = 20
+Sort = "Newest"
+ModelTypes = ["Checkpoint", "LORA"]
+`
+	localContent := `SavePath = "/data/models"
+
+[Download]
+Limit = 40
+ModelTypes = ["VAE"]
+`
+	if err := os.WriteFile(base, []byte(baseContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(local, []byte(localContent), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	concurrency := 6
+	limit := 60
+	flags := CliFlags{
+		ConfigFilePaths: []string{base, local},
+		Download:        &CliDownloadFlags{Concurrency: &concurrency},
+	}
+	cfg, _, err := Initialize(flags)
+	if err != nil {
+		t.Fatalf("Failed to initialize config: %v", err)
+	}
+
+	if cfg.APIDelayMs != 1500 || cfg.Download.Sort != "Newest" {
+		t.Errorf("Expected settings only in base.toml to be kept, got ApiDelayMs=%d Sort=%q", cfg.APIDelayMs, cfg.Download.Sort)
+	}
+	if cfg.SavePath != "/data/models" || cfg.Download.Limit != 40 {
+		t.Errorf("Expected local.toml to override base.toml, got SavePath=%q Limit=%d", cfg.SavePath, cfg.Download.Limit)
+	}
+	if len(cfg.Download.ModelTypes) != 1 || cfg.Download.ModelTypes[0] != "VAE" {
+		t.Errorf("Expected ModelTypes to be replaced by local.toml, got %v", cfg.Download.ModelTypes)
+	}
+	if cfg.Download.Concurrency != 6 {
+		t.Errorf("Expected download concurrency 6 (from flags), got %d", cfg.Download.Concurrency)
+	}
+
+	flags.Download.Limit = &limit
+	cfg, _, err = Initialize(flags)
+	if err != nil {
+		t.Fatalf("Failed to initialize config: %v", err)
+	}
+	if cfg.Download.Limit != 60 {
+		t.Errorf("Expected download limit 60 (from flags), got %d", cfg.Download.Limit)
+	}
+}
+
+func TestTypeFolderMapFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := `[Download.TypeFolderMap]
+LORA = "Lora"
+TextualInversion = "embeddings"
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _, err := Initialize(CliFlags{ConfigFilePaths: []string{path}})
+	if err != nil {
+		t.Fatalf("Failed to initialize config: %v", err)
+	}
+	// Keys come back lower-cased from Viper; the folder names keep their case.
+	if cfg.Download.TypeFolderMap["lora"] != "Lora" || cfg.Download.TypeFolderMap["textualinversion"] != "embeddings" {
+		t.Errorf("Unexpected TypeFolderMap: %v", cfg.Download.TypeFolderMap)
+	}
+}
+
+func TestExpandConfigPaths(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skipf("No home directory: %v", err)
+	}
+	t.Setenv("CIVITAI_TEST_MODELS", "/mnt/models")
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := `SavePath = "${CIVITAI_TEST_MODELS}/civitai"
+DatabasePath = "$CIVITAI_TEST_MODELS/civitai.db"
+
+[Images]
+OutputDir = "~/pictures"
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _, err := Initialize(CliFlags{ConfigFilePaths: []string{path}})
+	if err != nil {
+		t.Fatalf("Failed to initialize config: %v", err)
+	}
+	if cfg.SavePath != "/mnt/models/civitai" {
+		t.Errorf("Expected SavePath /mnt/models/civitai, got %q", cfg.SavePath)
+	}
+	if cfg.DatabasePath != "/mnt/models/civitai.db" {
+		t.Errorf("Expected DatabasePath /mnt/models/civitai.db, got %q", cfg.DatabasePath)
+	}
+	if want := filepath.Join(home, "pictures"); cfg.Images.OutputDir != want {
+		t.Errorf("Expected Images.OutputDir %q, got %q", want, cfg.Images.OutputDir)
+	}
+
+	if got := expandPath("~"); got != home {
+		t.Errorf("Expected ~ to expand to %q, got %q", home, got)
+	}
+	if got := expandPath("~user/models"); got != "~user/models" {
+		t.Errorf("Expected ~user paths to be left alone, got %q", got)
+	}
+}
+
+// TestProxyTransport tests that Proxy routes requests through the given proxy,
+// also when API logging wraps the transport
+func TestProxyTransport(t *testing.T) {
+	req, err := http.NewRequest("GET", "https://civitai.com/api/v1/models", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transport, err := newBaseTransport("", 0)
+	if err != nil || transport.Proxy == nil {
+		t.Fatalf("expected the environment proxy settings without Proxy, got %v", err)
+	}
+
+	for _, invalid := range []string{"ftp://host:21", "host:1080", "socks5://"} {
+		if _, err := newBaseTransport(invalid, 0); err == nil {
+			t.Errorf("expected an error for proxy %q", invalid)
+		}
+	}
+
+	// Write api.log into a temp dir
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	cfg := &models.Config{Proxy: "socks5://127.0.0.1:1080", LogApiRequests: true, SavePath: "."}
+	roundTripper, err := setupHTTPTransport(cfg)
+	if err != nil {
+		t.Fatalf("setupHTTPTransport: %v", err)
+	}
+	logging, ok := roundTripper.(*api.LoggingTransport)
+	if !ok {
+		t.Fatalf("expected a logging transport, got %T", roundTripper)
+	}
+	defer func() { _ = logging.Close() }()
+	inner, ok := logging.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("expected the logging transport to wrap an *http.Transport, got %T", logging.Transport)
+	}
+	proxyURL, err := inner.Proxy(req)
+	if err != nil || proxyURL == nil || proxyURL.String() != cfg.Proxy {
+		t.Errorf("expected requests to go through %s, got %v (%v)", cfg.Proxy, proxyURL, err)
+	}
+}
+
+// TestMaxConnsPerHost tests that MaxConnsPerHost caps the connections of the
+// shared transport and that the flag overrides the default
+func TestMaxConnsPerHost(t *testing.T) {
+	transport, err := newBaseTransport("", 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if transport.MaxConnsPerHost != 4 {
+		t.Errorf("expected MaxConnsPerHost 4, got %d", transport.MaxConnsPerHost)
+	}
+	if transport, _ := newBaseTransport("", 0); transport.MaxConnsPerHost != 0 {
+		t.Errorf("expected no limit with 0, got %d", transport.MaxConnsPerHost)
+	}
+
+	cfg, roundTripper, err := Initialize(CliFlags{})
+	if err != nil {
+		t.Fatalf("Failed to initialize config: %v", err)
+	}
+	if cfg.MaxConnsPerHost != DefaultMaxConnsPerHost {
+		t.Errorf("expected the default of %d, got %d", DefaultMaxConnsPerHost, cfg.MaxConnsPerHost)
+	}
+	if inner, ok := roundTripper.(*http.Transport); !ok || inner.MaxConnsPerHost != DefaultMaxConnsPerHost {
+		t.Errorf("expected the transport to be limited to %d connections per host, got %T", DefaultMaxConnsPerHost, roundTripper)
+	}
+
+	perHost := 2
+	cfg, _, err = Initialize(CliFlags{MaxConnsPerHost: &perHost})
+	if err != nil {
+		t.Fatalf("Failed to initialize config: %v", err)
+	}
+	if cfg.MaxConnsPerHost != 2 {
+		t.Errorf("expected --concurrency-per-host to override the default, got %d", cfg.MaxConnsPerHost)
+	}
+}
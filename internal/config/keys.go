@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/spf13/viper"
+)
+
+// knownConfigKeys maps every key accepted in the config file, lowercased and
+// dotted the way Viper reports them, to its canonical spelling
+// (e.g. "download.limit" -> "Download.Limit").
+func knownConfigKeys() map[string]string {
+	keys := make(map[string]string)
+	collectConfigKeys(reflect.TypeOf(models.Config{}), "", keys)
+	return keys
+}
+
+func collectConfigKeys(t reflect.Type, prefix string, keys map[string]string) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("toml"), ",")[0]
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+		if field.Type.Kind() == reflect.Struct {
+			collectConfigKeys(field.Type, name, keys)
+			continue
+		}
+		keys[strings.ToLower(name)] = name
+	}
+}
+
+// fileConfigKeys returns the keys set in the config file itself, without
+// Viper defaults or environment overrides mixed in.
+func fileConfigKeys(path string) ([]string, error) {
+	fv := viper.New()
+	fv.SetConfigFile(path)
+	if err := fv.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	return fv.AllKeys(), nil
+}
+
+// checkUnknownKeys returns one message per key that the config does not
+// recognise, pointing at the section it belongs in or the closest valid key.
+func checkUnknownKeys(fileKeys []string) []string {
+	known := knownConfigKeys()
+
+	var problems []string
+	for _, key := range fileKeys {
+		if _, ok := known[key]; ok || underKnownKey(key, known) {
+			continue
+		}
+		problems = append(problems, describeUnknownKey(key, known))
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// underKnownKey reports whether key is an entry of a known map setting, such
+// as "download.typefoldermap.lora" under Download.TypeFolderMap.
+func underKnownKey(key string, known map[string]string) bool {
+	for i := strings.LastIndex(key, "."); i > 0; i = strings.LastIndex(key[:i], ".") {
+		if _, ok := known[key[:i]]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func describeUnknownKey(key string, known map[string]string) string {
+	// Same name as a real key, just in the wrong table.
+	leaf := key[strings.LastIndex(key, ".")+1:]
+	var placements []string
+	for lower, canonical := range known {
+		if lower[strings.LastIndex(lower, ".")+1:] == leaf {
+			placements = append(placements, canonical)
+		}
+	}
+	if len(placements) > 0 {
+		sort.Strings(placements)
+		return fmt.Sprintf("config key %q is in the wrong section and is ignored, did you mean %s?", key, describePlacements(placements))
+	}
+
+	if suggestion := nearestKey(key, known); suggestion != "" {
+		return fmt.Sprintf("unknown config key %q: did you mean %s?", key, suggestion)
+	}
+	return fmt.Sprintf("unknown config key %q", key)
+}
+
+// describePlacements renders keys as "Limit under [Download]" style hints.
+func describePlacements(keys []string) string {
+	hints := make([]string, 0, len(keys))
+	for _, key := range keys {
+		i := strings.LastIndex(key, ".")
+		if i < 0 {
+			hints = append(hints, fmt.Sprintf("%s at the top level", key))
+			continue
+		}
+		hints = append(hints, fmt.Sprintf("%s under [%s]", key[i+1:], key[:i]))
+	}
+	return strings.Join(hints, " or ")
+}
+
+// nearestKey returns the canonical name of the known key closest to key,
+// or "" if nothing is close enough to be a plausible typo.
+func nearestKey(key string, known map[string]string) string {
+	best, bestDist := "", -1
+	for lower, canonical := range known {
+		d := levenshtein(key, lower)
+		if bestDist < 0 || d < bestDist || (d == bestDist && canonical < best) {
+			best, bestDist = canonical, d
+		}
+	}
+	maxDist := len(key) / 4
+	if maxDist < 2 {
+		maxDist = 2
+	}
+	if bestDist < 0 || bestDist > maxDist {
+		return ""
+	}
+	return best
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
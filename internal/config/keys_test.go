@@ -0,0 +1,139 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name string
+		key  string
+		want string // substring of the single problem; empty means no problem
+	}{
+		{"known top level", "savepath", ""},
+		{"known nested", "download.limit", ""},
+		{"known db verify", "db.verify.hashalgo", ""},
+		{"map entry", "download.typefoldermap.lora", ""},
+		{"typo", "download.concurency", "did you mean Download.Concurrency?"},
+		{"wrong section", "concurrency", "Concurrency under [Download]"},
+		{"wrong section table", "downloads.pruned", "Pruned under [Download]"},
+		{"nothing close", "download.somethingelse", `unknown config key "download.somethingelse"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := checkUnknownKeys([]string{tt.key})
+			if tt.want == "" {
+				if len(problems) != 0 {
+					t.Fatalf("expected %q to be known, got %v", tt.key, problems)
+				}
+				return
+			}
+			if len(problems) != 1 || !strings.Contains(problems[0], tt.want) {
+				t.Fatalf("expected one problem containing %q, got %v", tt.want, problems)
+			}
+		})
+	}
+}
+
+func TestCheckUnknownKeys_ExampleConfig(t *testing.T) {
+	example, err := os.ReadFile(filepath.Join("..", "..", "config.toml.example"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, example, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := fileConfigKeys(path)
+	if err != nil {
+		t.Fatalf("reading config.toml.example: %v", err)
+	}
+	if problems := checkUnknownKeys(keys); len(problems) != 0 {
+		t.Errorf("config.toml.example has unknown keys: %v", problems)
+	}
+}
+
+func TestInitialize_StrictConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := "SavePath = \"models\"\nLimit = 5\n\n[Download]\nConcurency = 2\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	flags := CliFlags{ConfigFilePaths: []string{path}}
+	if _, _, err := Initialize(flags); err != nil {
+		t.Fatalf("unknown keys should only warn without --strict-config: %v", err)
+	}
+
+	strict := true
+	flags.StrictConfig = &strict
+	_, _, err := Initialize(flags)
+	if err == nil {
+		t.Fatal("expected an error for unknown keys with --strict-config")
+	}
+	if !strings.Contains(err.Error(), "2 unknown key(s)") {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+// Download settings used to be silently dropped when they sat at the top level
+// or used a file key that differs from the Go field name (ModelInfo, MetaOnly...).
+func TestInitialize_DownloadKeysFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := `Limit = 10
+Sort = "Newest"
+
+[download]
+limit = 55
+ModelInfo = false
+VersionImages = true
+ModelImages = true
+MetaOnly = true
+
+[Images]
+Metadata = false
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, _, err := Initialize(CliFlags{ConfigFilePaths: []string{path}})
+	if err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+
+	if cfg.Download.Limit != 55 {
+		t.Errorf("Download.Limit = %d, want 55", cfg.Download.Limit)
+	}
+	if cfg.Download.Sort != "Most Downloaded" {
+		t.Errorf("top-level Sort should not leak into Download, got %q", cfg.Download.Sort)
+	}
+	if cfg.Download.SaveModelInfo {
+		t.Error("ModelInfo = false was ignored")
+	}
+	if !cfg.Download.SaveVersionImages || !cfg.Download.SaveModelImages || !cfg.Download.DownloadMetaOnly {
+		t.Errorf("VersionImages/ModelImages/MetaOnly were ignored: %+v", cfg.Download)
+	}
+	if cfg.Images.SaveMetadata {
+		t.Error("Images.Metadata = false was ignored")
+	}
+
+	keys, err := fileConfigKeys(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	problems := checkUnknownKeys(keys)
+	if len(problems) != 2 {
+		t.Fatalf("expected warnings for top-level Limit and Sort, got %v", problems)
+	}
+	for _, problem := range problems {
+		if !strings.Contains(problem, "under [Download]") {
+			t.Errorf("warning should point at [Download]: %s", problem)
+		}
+	}
+}
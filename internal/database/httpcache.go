@@ -0,0 +1,37 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// GetHTTPCache returns the ETag, Last-Modified value and body stored for url,
+// or ErrNotFound.
+func (d *DB) GetHTTPCache(url string) (etag, lastModified string, body []byte, err error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	err = d.db.QueryRow("SELECT etag, last_modified, body FROM http_cache WHERE url = ?", url).Scan(&etag, &lastModified, &body)
+	if err == sql.ErrNoRows {
+		return "", "", nil, ErrNotFound
+	} else if err != nil {
+		return "", "", nil, fmt.Errorf("error querying HTTP cache for %s: %w", url, err)
+	}
+	return etag, lastModified, body, nil
+}
+
+// PutHTTPCache stores the validators and body of the response for url,
+// replacing what was stored for it before.
+func (d *DB) PutHTTPCache(url, etag, lastModified string, body []byte) error {
+	d.Lock()
+	defer d.Unlock()
+
+	_, err := d.db.Exec(`
+		INSERT OR REPLACE INTO http_cache (url, etag, last_modified, body, updated_at)
+		VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+	`, url, etag, lastModified, body)
+	if err != nil {
+		return fmt.Errorf("error storing HTTP cache for %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPCache(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "http_cache.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	url := "https://civitai.com/api/v1/models/1"
+	_, _, _, err = db.GetHTTPCache(url)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, db.PutHTTPCache(url, `"abc"`, "", []byte(`{"id":1}`)))
+	etag, lastModified, body, err := db.GetHTTPCache(url)
+	require.NoError(t, err)
+	assert.Equal(t, `"abc"`, etag)
+	assert.Empty(t, lastModified)
+	assert.Equal(t, `{"id":1}`, string(body))
+
+	require.NoError(t, db.PutHTTPCache(url, "", "Wed, 01 May 2025 12:00:00 GMT", []byte(`{"id":1,"name":"new"}`)))
+	etag, lastModified, body, err = db.GetHTTPCache(url)
+	require.NoError(t, err)
+	assert.Empty(t, etag)
+	assert.Equal(t, "Wed, 01 May 2025 12:00:00 GMT", lastModified)
+	assert.Equal(t, `{"id":1,"name":"new"}`, string(body))
+}
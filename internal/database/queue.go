@@ -0,0 +1,157 @@
+package database
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Download queue item statuses.
+const (
+	QueueStatusQueued = "Queued"
+	QueueStatusDone   = "Done"
+	QueueStatusFailed = "Failed"
+)
+
+// downloadQueueColumns defines the columns of the download_queue table. A
+// version can queue several files, so rows are keyed by version and file.
+const downloadQueueColumns = `
+		version_id INTEGER NOT NULL,
+		file_id INTEGER NOT NULL DEFAULT 0,
+		priority INTEGER NOT NULL,
+		status TEXT NOT NULL CHECK (status IN ('Queued', 'Done', 'Failed')),
+		attempt_count INTEGER NOT NULL DEFAULT 0,
+		payload TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (version_id, file_id)
+`
+
+// migrateQueueKey recreates the download_queue table of databases that keyed
+// it by version only. The queue only holds the state of one interrupted run,
+// so it is discarded rather than converted.
+func (d *DB) migrateQueueKey() error {
+	hasFileID, err := d.columnExists("download_queue", "file_id")
+	if err != nil || hasFileID {
+		return err
+	}
+	log.Info("Recreating download queue keyed by version and file; an unfinished queue from an older version is discarded")
+	if _, err := d.db.Exec("DROP TABLE download_queue"); err != nil {
+		return fmt.Errorf("error dropping old download queue: %w", err)
+	}
+	if _, err := d.db.Exec("CREATE TABLE download_queue (" + downloadQueueColumns + ")"); err != nil {
+		return fmt.Errorf("error creating download queue: %w", err)
+	}
+	if _, err := d.db.Exec("CREATE INDEX IF NOT EXISTS idx_download_queue_order ON download_queue(status, priority)"); err != nil {
+		return fmt.Errorf("error indexing download queue: %w", err)
+	}
+	return nil
+}
+
+// QueueItem is one row of the persistent download queue.
+// Payload is opaque to the database; callers store whatever they need to
+// rebuild the download without going back to the API.
+type QueueItem struct {
+	Payload   []byte
+	Status    string
+	VersionID int
+	FileID    int
+	Priority  int // Lower values are downloaded first
+	Attempts  int
+}
+
+// ReplaceQueue discards the current download queue and stores items in its place.
+func (d *DB) ReplaceQueue(items []QueueItem) error {
+	d.Lock()
+	defer d.Unlock()
+
+	tx, err := d.db.Begin()
+	if err != nil {
+		return fmt.Errorf("error starting transaction for download queue: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.Exec("DELETE FROM download_queue"); err != nil {
+		return fmt.Errorf("error clearing download queue: %w", err)
+	}
+
+	stmt, err := tx.Prepare(`
+		INSERT OR REPLACE INTO download_queue (version_id, file_id, priority, status, attempt_count, payload)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`)
+	if err != nil {
+		return fmt.Errorf("error preparing download queue insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, item := range items {
+		status := item.Status
+		if status == "" {
+			status = QueueStatusQueued
+		}
+		if _, err := stmt.Exec(item.VersionID, item.FileID, item.Priority, status, item.Attempts, string(item.Payload)); err != nil {
+			return fmt.Errorf("error queueing file %d of version %d: %w", item.FileID, item.VersionID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing download queue: %w", err)
+	}
+
+	log.Debugf("Stored %d items in the download queue", len(items))
+	return nil
+}
+
+// QueuedItems returns the items not yet downloaded, in queue order.
+// Failed items are included so they can be retried.
+func (d *DB) QueuedItems() ([]QueueItem, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	rows, err := d.db.Query(`
+		SELECT version_id, file_id, priority, status, attempt_count, payload
+		FROM download_queue
+		WHERE status != ?
+		ORDER BY priority, version_id, file_id
+	`, QueueStatusDone)
+	if err != nil {
+		return nil, fmt.Errorf("error querying download queue: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var items []QueueItem
+	for rows.Next() {
+		var item QueueItem
+		var payload string
+		if err := rows.Scan(&item.VersionID, &item.FileID, &item.Priority, &item.Status, &item.Attempts, &payload); err != nil {
+			return nil, fmt.Errorf("error scanning download queue row: %w", err)
+		}
+		item.Payload = []byte(payload)
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+// MarkQueueItem records the status of a queued file. attempted tells whether
+// the file was actually downloaded (or tried), which counts as an attempt;
+// files found already downloaded are not. Files that are not in the queue are
+// ignored.
+func (d *DB) MarkQueueItem(versionID, fileID int, status string, attempted bool) error {
+	d.Lock()
+	defer d.Unlock()
+
+	increment := 0
+	if attempted {
+		increment = 1
+	}
+	_, err := d.db.Exec(`
+		UPDATE download_queue
+		SET status = ?, attempt_count = attempt_count + ?, updated_at = CURRENT_TIMESTAMP
+		WHERE version_id = ? AND file_id = ?
+	`, status, increment, versionID, fileID)
+	if err != nil {
+		return fmt.Errorf("error marking file %d of queued version %d as %s: %w", fileID, versionID, status, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,229 @@
+package database
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDownloadQueue(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "queue.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.ReplaceQueue([]QueueItem{
+		{VersionID: 30, FileID: 300, Priority: 0, Payload: []byte(`{"a":1}`)},
+		{VersionID: 10, FileID: 100, Priority: 1, Payload: []byte(`{"a":2}`)},
+		{VersionID: 20, FileID: 200, Priority: 2, Payload: []byte(`{"a":3}`)},
+		{VersionID: 20, FileID: 201, Priority: 3, Payload: []byte(`{"a":4}`)},
+	}))
+
+	require.NoError(t, db.MarkQueueItem(30, 300, QueueStatusDone, true))
+	require.NoError(t, db.MarkQueueItem(20, 200, QueueStatusDone, true))
+	require.NoError(t, db.MarkQueueItem(999, 1, QueueStatusDone, true), "unknown files are ignored")
+	require.NoError(t, db.MarkQueueItem(10, 100, QueueStatusFailed, true))
+	require.NoError(t, db.MarkQueueItem(20, 201, QueueStatusQueued, false))
+
+	items, err := db.QueuedItems()
+	require.NoError(t, err)
+	require.Len(t, items, 2)
+	assert.Equal(t, 10, items[0].VersionID, "queue order must follow priority, not version ID")
+	assert.Equal(t, 20, items[1].VersionID)
+	assert.Equal(t, 201, items[1].FileID, "only the marked file of a version leaves the queue")
+	assert.Equal(t, `{"a":2}`, string(items[0].Payload))
+	assert.Equal(t, QueueStatusFailed, items[0].Status)
+	assert.Equal(t, 1, items[0].Attempts, "a failed download counts as an attempt")
+	assert.Zero(t, items[1].Attempts, "a file that was not downloaded does not")
+
+	// Replacing the queue drops previous items.
+	require.NoError(t, db.ReplaceQueue([]QueueItem{{VersionID: 40, Payload: []byte(`{}`)}}))
+	items, err = db.QueuedItems()
+	require.NoError(t, err)
+	require.Len(t, items, 1)
+	assert.Equal(t, 40, items[0].VersionID)
+}
+
+func TestMigrateSchema_KeysQueueByFile(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "migrate.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Simulate a database whose queue was keyed by version only.
+	_, err = db.db.Exec("DROP TABLE download_queue")
+	require.NoError(t, err)
+	_, err = db.db.Exec("CREATE TABLE download_queue (version_id INTEGER PRIMARY KEY, priority INTEGER NOT NULL, status TEXT NOT NULL, attempt_count INTEGER NOT NULL DEFAULT 0, payload TEXT NOT NULL, updated_at DATETIME)")
+	require.NoError(t, err)
+
+	require.NoError(t, db.migrateSchema())
+	require.NoError(t, db.ReplaceQueue([]QueueItem{
+		{VersionID: 1, FileID: 10, Payload: []byte(`{}`)},
+		{VersionID: 1, FileID: 11, Priority: 1, Payload: []byte(`{}`)},
+	}))
+	items, err := db.QueuedItems()
+	require.NoError(t, err)
+	assert.Len(t, items, 2)
+}
+
+func TestMigrateSchema_AddsAttemptCount(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "migrate.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Simulate a database created before attempt_count existed.
+	_, err = db.db.Exec("ALTER TABLE models DROP COLUMN attempt_count")
+	require.NoError(t, err)
+	has, err := db.columnExists("models", "attempt_count")
+	require.NoError(t, err)
+	require.False(t, has)
+
+	require.NoError(t, db.migrateSchema())
+	has, err = db.columnExists("models", "attempt_count")
+	require.NoError(t, err)
+	assert.True(t, has)
+}
+
+func TestMigrateSchema_AllowsSkippedStatus(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "migrate.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Simulate a database created before the Skipped status existed.
+	oldColumns := strings.Replace(modelsTableColumns, ", 'Skipped'", "", 1)
+	require.NoError(t, db.rebuildModelsTable(oldColumns))
+
+	file := models.File{ID: 7, Name: "model.safetensors", Primary: true}
+	entry := models.DatabaseEntry{ModelID: 1, Version: models.ModelVersion{ID: 70, Files: []models.File{file}}, File: file, Filename: "model.safetensors", Folder: "lora", Status: models.StatusDownloaded}
+	entryBytes, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, db.Put([]byte("v_70"), entryBytes))
+
+	blockedFile := models.File{ID: 8, Name: "blocked.safetensors"}
+	blocked := models.DatabaseEntry{ModelID: 2, Version: models.ModelVersion{ID: 71, Files: []models.File{blockedFile}}, File: blockedFile, Filename: "blocked.safetensors", Folder: "lora", Status: models.StatusSkipped}
+	skippedBytes, err := json.Marshal(blocked)
+	require.NoError(t, err)
+	require.Error(t, db.Put([]byte("v_71"), skippedBytes))
+
+	require.NoError(t, db.migrateSchema())
+	require.NoError(t, db.Put([]byte("v_71"), skippedBytes))
+
+	// Rows in other tables referencing the rebuilt table are kept.
+	raw, err := db.Get([]byte("v_70"))
+	require.NoError(t, err)
+	var got models.DatabaseEntry
+	require.NoError(t, json.Unmarshal(raw, &got))
+	assert.Equal(t, models.StatusDownloaded, got.Status)
+	assert.Equal(t, "model.safetensors", got.File.Name)
+}
+
+func TestMigrateSchema_AllowsEarlyAccessStatus(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "migrate.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Simulate a database created before the EarlyAccess status existed.
+	oldColumns := strings.Replace(modelsTableColumns, ", 'EarlyAccess'", "", 1)
+	require.NoError(t, db.rebuildModelsTable(oldColumns))
+
+	file := models.File{ID: 9, Name: "early.safetensors"}
+	entry := models.DatabaseEntry{ModelID: 3, Version: models.ModelVersion{ID: 72, Files: []models.File{file}}, File: file, Filename: "early.safetensors", Folder: "lora", Status: models.StatusEarlyAccess}
+	entryBytes, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.Error(t, db.Put([]byte("v_72"), entryBytes))
+
+	require.NoError(t, db.migrateSchema())
+	require.NoError(t, db.Put([]byte("v_72"), entryBytes))
+}
+
+func TestSetVerification(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "verify.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Put([]byte("v_1"), []byte(`{"version":{"id":1},"status":"Downloaded","filename":"a","folder":"b"}`)))
+	require.NoError(t, db.SetVerification(1, 1700000000, "ABC"))
+
+	var entry models.DatabaseEntry
+	value, err := db.Get([]byte("v_1"))
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(value, &entry))
+	assert.Equal(t, int64(1700000000), entry.LastVerifiedAt)
+	assert.Equal(t, "ABC", entry.LastVerifiedHash)
+
+	// Rewriting the entry keeps the verification
+	entry.Status = models.StatusError
+	data, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, db.Put([]byte("v_1"), data))
+	value, err = db.Get([]byte("v_1"))
+	require.NoError(t, err)
+	var updated models.DatabaseEntry
+	require.NoError(t, json.Unmarshal(value, &updated))
+	assert.Equal(t, "ABC", updated.LastVerifiedHash)
+
+	require.NoError(t, db.SetVerification(1, 0, ""))
+	value, err = db.Get([]byte("v_1"))
+	require.NoError(t, err)
+	updated = models.DatabaseEntry{}
+	require.NoError(t, json.Unmarshal(value, &updated))
+	assert.Zero(t, updated.LastVerifiedAt)
+}
+
+func TestRawJSON(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "raw.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Put([]byte("v_1"), []byte(`{"version":{"id":1},"status":"Pending","filename":"a","folder":"b"}`)))
+	_, err = db.GetRawJSON(1)
+	assert.ErrorIs(t, err, ErrNotFound, "entries stored without raw JSON have none")
+
+	raw := `{"id":1,"name":"v1","futureField":{"nested":true}}`
+	require.NoError(t, db.Put([]byte("v_1"), []byte(`{"version":{"id":1},"status":"Pending","filename":"a","folder":"b","rawJson":`+raw+`}`)))
+	got, err := db.GetRawJSON(1)
+	require.NoError(t, err)
+	assert.JSONEq(t, raw, string(got))
+
+	// Rewriting the entry without raw JSON keeps the stored one
+	require.NoError(t, db.Put([]byte("v_1"), []byte(`{"version":{"id":1},"status":"Downloaded","filename":"a","folder":"b"}`)))
+	got, err = db.GetRawJSON(1)
+	require.NoError(t, err)
+	assert.JSONEq(t, raw, string(got))
+
+	_, err = db.GetRawJSON(2)
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestOpenMemory(t *testing.T) {
+	db, err := OpenMemory()
+	require.NoError(t, err)
+	defer db.Close()
+
+	require.NoError(t, db.Put([]byte("v_1"), []byte(`{"Version":{"id":1},"Status":"Pending"}`)))
+	assert.True(t, db.Has([]byte("v_1")))
+	_, err = db.Get([]byte("v_2"))
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ro.db")
+	_, err := OpenReadOnly(path)
+	assert.Error(t, err, "a missing database must not be created")
+
+	db, err := Open(path)
+	require.NoError(t, err)
+	require.NoError(t, db.Put([]byte("v_1"), []byte(`{"Version":{"id":1},"Status":"Downloaded"}`)))
+	require.NoError(t, db.Close())
+
+	ro, err := OpenReadOnly(path)
+	require.NoError(t, err)
+	defer ro.Close()
+
+	assert.True(t, ro.Has([]byte("v_1")))
+	assert.Error(t, ro.Put([]byte("v_2"), []byte(`{"Version":{"id":2}}`)), "writes must fail on a read-only database")
+}
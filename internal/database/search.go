@@ -0,0 +1,110 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// modelsFTSTable is a full-text index of the searchable text of each models
+// row. Its rowid is the version ID, and it is kept up to date by Put and Delete.
+const modelsFTSTable = `
+	CREATE VIRTUAL TABLE IF NOT EXISTS models_fts USING fts5(
+		model_name, version_name, version_description, trained_words, creator_username
+	);
+`
+
+// ftsExecer is implemented by both *sql.DB and *sql.Tx.
+type ftsExecer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// indexEntryFTS replaces the full-text index row of a version. trainedWords is
+// the JSON array stored in the models table; the tokenizer skips its quotes
+// and brackets.
+func indexEntryFTS(db ftsExecer, versionID int, modelName, versionName, description, trainedWords, creator string) error {
+	if _, err := db.Exec("DELETE FROM models_fts WHERE rowid = ?", versionID); err != nil {
+		return fmt.Errorf("error removing version %d from search index: %w", versionID, err)
+	}
+	_, err := db.Exec(`
+		INSERT INTO models_fts (rowid, model_name, version_name, version_description, trained_words, creator_username)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, versionID, modelName, versionName, description, trainedWords, creator)
+	if err != nil {
+		return fmt.Errorf("error indexing version %d for search: %w", versionID, err)
+	}
+	return nil
+}
+
+// migrateFTS creates the full-text index and fills it from the models table
+// when it is empty, e.g. on the first open of a database created before it.
+func (d *DB) migrateFTS() error {
+	if _, err := d.db.Exec(modelsFTSTable); err != nil {
+		return fmt.Errorf("error creating search index: %w", err)
+	}
+
+	var indexed bool
+	if err := d.db.QueryRow("SELECT EXISTS (SELECT 1 FROM models_fts)").Scan(&indexed); err != nil {
+		return fmt.Errorf("error reading search index: %w", err)
+	}
+	if indexed {
+		return nil
+	}
+	result, err := d.db.Exec(`
+		INSERT INTO models_fts (rowid, model_name, version_name, version_description, trained_words, creator_username)
+		SELECT version_id, model_name, version_name, COALESCE(version_description, ''), COALESCE(trained_words, ''), COALESCE(creator_username, '')
+		FROM models
+	`)
+	if err != nil {
+		return fmt.Errorf("error filling search index: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows > 0 {
+		log.Infof("Built search index for %d existing entries", rows)
+	}
+	return nil
+}
+
+// ftsMatchQuery turns free text into an FTS5 query matching entries that
+// contain every word. Each word is quoted so characters such as "." or "-"
+// in "SDXL 1.0" are not read as query syntax.
+func ftsMatchQuery(text string) string {
+	words := strings.Fields(text)
+	for i, word := range words {
+		words[i] = `"` + strings.ReplaceAll(word, `"`, `""`) + `"`
+	}
+	return strings.Join(words, " ")
+}
+
+// SearchText returns the version IDs of the entries whose model name, version
+// name, description, trained words or creator contain every word of text,
+// best match first. A limit of 0 or less returns all matches.
+func (d *DB) SearchText(text string, limit int) ([]int, error) {
+	query := ftsMatchQuery(text)
+	if query == "" {
+		return nil, nil
+	}
+	if limit <= 0 {
+		limit = -1 // No limit in SQLite
+	}
+
+	d.RLock()
+	defer d.RUnlock()
+
+	rows, err := d.db.Query("SELECT rowid FROM models_fts WHERE models_fts MATCH ? ORDER BY rank LIMIT ?", query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("error searching for %q: %w", text, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var versionIDs []int
+	for rows.Next() {
+		var versionID int
+		if err := rows.Scan(&versionID); err != nil {
+			return nil, fmt.Errorf("error scanning search result: %w", err)
+		}
+		versionIDs = append(versionIDs, versionID)
+	}
+	return versionIDs, rows.Err()
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func putSearchEntry(t *testing.T, db *DB, versionID int, modelName, description, creator string, trainedWords ...string) {
+	t.Helper()
+	entry := models.DatabaseEntry{
+		ModelName: modelName,
+		Filename:  "model.safetensors",
+		Status:    models.StatusDownloaded,
+		Creator:   models.Creator{Username: creator},
+		Version:   models.ModelVersion{ID: versionID, Name: "v1.0", Description: description, TrainedWords: trainedWords},
+	}
+	data, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, db.Put([]byte(fmt.Sprintf("v_%d", versionID)), data))
+}
+
+func TestSearchText(t *testing.T) {
+	db, err := OpenMemory()
+	require.NoError(t, err)
+	defer db.Close()
+
+	putSearchEntry(t, db, 1, "Watercolor Style", "<p>Soft watercolor paintings</p>", "alice", "wtrcolor")
+	putSearchEntry(t, db, 2, "Ink Sketch", "Pen and ink, looks like a watercolor wash", "bob", "inksketch")
+	putSearchEntry(t, db, 3, "Realistic Vision", "Photorealistic SDXL 1.0 checkpoint", "alice")
+
+	ids, err := db.SearchText("watercolor", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2}, ids, "the model named after the word ranks first")
+
+	ids, err = db.SearchText("INKSKETCH", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, ids, "trained words are searched, ignoring case")
+
+	ids, err = db.SearchText("alice sdxl 1.0", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{3}, ids, "every word must match, punctuation is not query syntax")
+
+	ids, err = db.SearchText("watercolor", 1)
+	require.NoError(t, err)
+	assert.Len(t, ids, 1)
+
+	// Rewriting an entry replaces its index row
+	putSearchEntry(t, db, 1, "Oil Painting", "", "alice")
+	ids, err = db.SearchText("watercolor", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{2}, ids)
+
+	require.NoError(t, db.Delete([]byte("v_2")))
+	ids, err = db.SearchText("watercolor", 0)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+
+	ids, err = db.SearchText("  ", 0)
+	require.NoError(t, err)
+	assert.Empty(t, ids)
+}
+
+func TestMigrateFTS_BackfillsExistingRows(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fts.db")
+	db, err := Open(path)
+	require.NoError(t, err)
+	putSearchEntry(t, db, 1, "Watercolor Style", "", "alice")
+	// A database from before the index existed
+	_, err = db.db.Exec("DROP TABLE models_fts")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	db, err = Open(path)
+	require.NoError(t, err)
+	defer db.Close()
+	ids, err := db.SearchText("watercolor", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []int{1}, ids)
+}
@@ -1,10 +1,14 @@
 package database
 
 import (
+	"bytes"
+	"compress/gzip"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -26,6 +30,7 @@ type DB struct {
 	closeErr     error     // 16-byte interface (pointer + type)
 	sync.RWMutex           // embedded struct (24 bytes)
 	closeOnce    sync.Once // struct with pointer (8 bytes)
+	tempPath     string    // Temporary copy removed on Close, see OpenCopy
 	closed       bool      // 1 byte
 }
 
@@ -66,31 +71,143 @@ func Open(path string) (*DB, error) {
 	return dbWrapper, nil
 }
 
+// OpenMemory returns an empty database that lives in memory until it is closed,
+// e.g. to run against when there is no database file yet and none may be created.
+func OpenMemory() (*DB, error) {
+	db, err := sql.Open("sqlite", "file::memory:?_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open in-memory sqlite database: %w", err)
+	}
+	// Every connection would get its own empty database
+	db.SetMaxOpenConns(1)
+
+	dbWrapper := &DB{db: db}
+	if err := dbWrapper.initSchema(); err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			log.WithError(closeErr).Warn("Failed to close database after schema initialization failure")
+		}
+		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
+	}
+	return dbWrapper, nil
+}
+
+// OpenReadOnly opens an existing database without creating, migrating or
+// otherwise writing to it, e.g. to compare it with another one.
+func OpenReadOnly(path string) (*DB, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, fmt.Errorf("database %s: %w", path, err)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database at %s: %w", path, err)
+	}
+	if err := db.Ping(); err != nil {
+		if closeErr := db.Close(); closeErr != nil {
+			log.WithError(closeErr).Warn("Failed to close database after ping failure")
+		}
+		return nil, fmt.Errorf("failed to ping sqlite database at %s: %w", path, err)
+	}
+
+	log.Infof("SQLite database opened read-only at %s", path)
+	return &DB{db: db}, nil
+}
+
+// OpenCopy opens a migrated copy of the existing database at path, so that a
+// database written by an older version can be read with the current schema
+// without changing it. The copy is a temporary file that Close removes.
+func OpenCopy(path string) (*DB, error) {
+	src, err := OpenReadOnly(path)
+	if err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp("", "civitai-db-copy-*.db")
+	if err != nil {
+		_ = src.Close()
+		return nil, fmt.Errorf("failed to create a temporary copy of %s: %w", path, err)
+	}
+	tempPath := tmp.Name()
+	// VACUUM INTO refuses to overwrite a file, even an empty one
+	_ = tmp.Close()
+	_ = os.Remove(tempPath)
+
+	_, err = src.db.Exec("VACUUM INTO ?", tempPath)
+	if closeErr := src.Close(); closeErr != nil {
+		log.WithError(closeErr).Warnf("Failed to close %s after copying it", path)
+	}
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return nil, fmt.Errorf("failed to copy database %s: %w", path, err)
+	}
+
+	db, err := Open(tempPath)
+	if err != nil {
+		removeDatabaseFiles(tempPath)
+		return nil, fmt.Errorf("failed to open the copy of %s: %w", path, err)
+	}
+	db.tempPath = tempPath
+	log.Debugf("Opened a migrated copy of %s at %s", path, tempPath)
+	return db, nil
+}
+
+// removeDatabaseFiles removes the database file at path with its WAL files.
+func removeDatabaseFiles(path string) {
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+			log.WithError(err).Warnf("Failed to remove %s", path+suffix)
+		}
+	}
+}
+
+// modelsTableColumns defines the columns of the models table.
+const modelsTableColumns = `
+	version_id INTEGER PRIMARY KEY,
+	model_id INTEGER NOT NULL,
+	model_name TEXT NOT NULL,
+	model_type TEXT NOT NULL,
+	version_name TEXT NOT NULL,
+	version_published_at TEXT,
+	version_updated_at TEXT,
+	version_description TEXT,
+	trained_words TEXT, -- JSON array
+	base_model TEXT,
+	early_access_timeframe INTEGER,
+	creator_username TEXT,
+	creator_image TEXT,
+	filename TEXT NOT NULL,
+	folder TEXT NOT NULL,
+	status TEXT NOT NULL CHECK (status IN ('Pending', 'Downloaded', 'Error', 'Skipped', 'EarlyAccess')),
+	error_details TEXT,
+	attempt_count INTEGER NOT NULL DEFAULT 0,
+	last_verified_at INTEGER NOT NULL DEFAULT 0,
+	last_verified_hash TEXT NOT NULL DEFAULT '',
+	object_path TEXT NOT NULL DEFAULT '', -- objects/<sha256[:2]>/<sha256> under Download.ContentAddressed
+	raw_json BLOB, -- gzipped API JSON of the version, when DB.StoreRawJSON is set
+	timestamp INTEGER NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+`
+
+// modelsTableIndexes creates the indexes and trigger of the models table, which
+// are dropped with it when the table is rebuilt.
+const modelsTableIndexes = `
+	CREATE INDEX IF NOT EXISTS idx_models_model_id ON models(model_id);
+	CREATE INDEX IF NOT EXISTS idx_models_status ON models(status);
+	CREATE INDEX IF NOT EXISTS idx_models_model_name ON models(model_name);
+	CREATE INDEX IF NOT EXISTS idx_models_creator ON models(creator_username);
+	CREATE TRIGGER IF NOT EXISTS update_models_timestamp 
+		AFTER UPDATE ON models
+		BEGIN
+			UPDATE models SET updated_at = CURRENT_TIMESTAMP WHERE version_id = NEW.version_id;
+		END;
+`
+
 // initSchema creates the database schema if it doesn't exist
 func (d *DB) initSchema() error {
 	schema := `
 	-- Main models table
 	CREATE TABLE IF NOT EXISTS models (
-		version_id INTEGER PRIMARY KEY,
-		model_id INTEGER NOT NULL,
-		model_name TEXT NOT NULL,
-		model_type TEXT NOT NULL,
-		version_name TEXT NOT NULL,
-		version_published_at TEXT,
-		version_updated_at TEXT,
-		version_description TEXT,
-		trained_words TEXT, -- JSON array
-		base_model TEXT,
-		early_access_timeframe INTEGER,
-		creator_username TEXT,
-		creator_image TEXT,
-		filename TEXT NOT NULL,
-		folder TEXT NOT NULL,
-		status TEXT NOT NULL CHECK (status IN ('Pending', 'Downloaded', 'Error')),
-		error_details TEXT,
-		timestamp INTEGER NOT NULL,
-		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	` + modelsTableColumns + `
 	);
 
 	-- Files table (normalized from File struct)
@@ -152,24 +269,39 @@ func (d *DB) initSchema() error {
 	CREATE TABLE IF NOT EXISTS pagination_state (
 		query_hash TEXT PRIMARY KEY,
 		current_page INTEGER NOT NULL,
+		cursor TEXT NOT NULL DEFAULT '', -- Cursor of the next page, for cursor-paginated endpoints
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Persistent download queue so a run can be resumed in the same order
+	CREATE TABLE IF NOT EXISTS download_queue (
+	` + downloadQueueColumns + `
+	);
+
+	-- Directory state each .torrent was generated from, to skip unchanged directories
+	CREATE TABLE IF NOT EXISTS torrent_state (
+		source_path TEXT PRIMARY KEY,
+		torrent_path TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Validators and body of API responses, for conditional re-fetches
+	CREATE TABLE IF NOT EXISTS http_cache (
+		url TEXT PRIMARY KEY,
+		etag TEXT NOT NULL DEFAULT '',
+		last_modified TEXT NOT NULL DEFAULT '',
+		body BLOB NOT NULL,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
 
 	-- Indexes for performance
-	CREATE INDEX IF NOT EXISTS idx_models_model_id ON models(model_id);
-	CREATE INDEX IF NOT EXISTS idx_models_status ON models(status);
-	CREATE INDEX IF NOT EXISTS idx_models_model_name ON models(model_name);
-	CREATE INDEX IF NOT EXISTS idx_models_creator ON models(creator_username);
+	` + modelsTableIndexes + `
 	CREATE INDEX IF NOT EXISTS idx_files_version_id ON files(version_id);
 	CREATE INDEX IF NOT EXISTS idx_files_primary ON files(is_primary);
+	CREATE INDEX IF NOT EXISTS idx_download_queue_order ON download_queue(status, priority);
 
 	-- Triggers to update updated_at timestamp
-	CREATE TRIGGER IF NOT EXISTS update_models_timestamp 
-		AFTER UPDATE ON models
-		BEGIN
-			UPDATE models SET updated_at = CURRENT_TIMESTAMP WHERE version_id = NEW.version_id;
-		END;
-
 	CREATE TRIGGER IF NOT EXISTS update_pagination_timestamp 
 		AFTER UPDATE ON pagination_state
 		BEGIN
@@ -177,8 +309,218 @@ func (d *DB) initSchema() error {
 		END;
 	`
 
-	_, err := d.db.Exec(schema)
-	return err
+	if _, err := d.db.Exec(schema); err != nil {
+		return err
+	}
+
+	return d.migrateSchema()
+}
+
+// migrateSchema adds columns introduced after a database may have been created.
+func (d *DB) migrateSchema() error {
+	columns := []struct{ table, name, definition string }{
+		{"models", "attempt_count", "INTEGER NOT NULL DEFAULT 0"},
+		{"models", "last_verified_at", "INTEGER NOT NULL DEFAULT 0"},
+		{"models", "last_verified_hash", "TEXT NOT NULL DEFAULT ''"},
+		{"models", "raw_json", "BLOB"},
+		{"models", "object_path", "TEXT NOT NULL DEFAULT ''"},
+		{"pagination_state", "cursor", "TEXT NOT NULL DEFAULT ''"},
+	}
+	for _, column := range columns {
+		hasColumn, err := d.columnExists(column.table, column.name)
+		if err != nil {
+			return err
+		}
+		if hasColumn {
+			continue
+		}
+		log.Infof("Adding %s column to %s table", column.name, column.table)
+		if _, err := d.db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", column.table, column.name, column.definition)); err != nil {
+			return fmt.Errorf("error adding %s column: %w", column.name, err)
+		}
+	}
+	if err := d.migrateStatusCheck(); err != nil {
+		return err
+	}
+	if err := d.migrateQueueKey(); err != nil {
+		return err
+	}
+	return d.migrateFTS()
+}
+
+// migrateStatusCheck rebuilds the models table of databases created before the
+// Skipped or EarlyAccess status existed, as SQLite cannot change a CHECK
+// constraint in place.
+func (d *DB) migrateStatusCheck() error {
+	var tableSQL string
+	if err := d.db.QueryRow("SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'models'").Scan(&tableSQL); err != nil {
+		return fmt.Errorf("error reading models table definition: %w", err)
+	}
+	var missing []string
+	for _, status := range []string{models.StatusSkipped, models.StatusEarlyAccess} {
+		if !strings.Contains(tableSQL, "'"+status+"'") {
+			missing = append(missing, status)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	log.Infof("Allowing %s status in models table", strings.Join(missing, " and "))
+	return d.rebuildModelsTable(modelsTableColumns)
+}
+
+// rebuildModelsTable recreates the models table with the given column
+// definitions and copies the existing rows over. Foreign keys are switched off
+// meanwhile so dropping the old table does not delete the rows referencing it.
+func (d *DB) rebuildModelsTable(columnDefs string) error {
+	ctx := context.Background()
+	conn, err := d.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting connection to rebuild models table: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return fmt.Errorf("error disabling foreign keys: %w", err)
+	}
+	defer func() {
+		if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = ON"); err != nil {
+			log.WithError(err).Warn("Failed to re-enable foreign keys after rebuilding models table")
+		}
+	}()
+
+	rows, err := conn.QueryContext(ctx, "SELECT name FROM pragma_table_info('models')")
+	if err != nil {
+		return fmt.Errorf("error reading columns of models: %w", err)
+	}
+	var columns []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("error scanning columns of models: %w", err)
+		}
+		columns = append(columns, name)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error reading columns of models: %w", err)
+	}
+	columnList := strings.Join(columns, ", ")
+
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error beginning transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	statements := []string{
+		"CREATE TABLE models_new (" + columnDefs + ")",
+		fmt.Sprintf("INSERT INTO models_new (%s) SELECT %s FROM models", columnList, columnList), // #nosec G201 -- column names come from the table itself
+		"DROP TABLE models",
+		"ALTER TABLE models_new RENAME TO models",
+		modelsTableIndexes,
+	}
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("error rebuilding models table: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// columnExists reports whether table has a column with the given name.
+func (d *DB) columnExists(table, column string) (bool, error) {
+	rows, err := d.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, fmt.Errorf("error reading columns of %s: %w", table, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, fmt.Errorf("error scanning columns of %s: %w", table, err)
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
+// CountExhaustedEntries returns the number of entries in Error status that
+// have failed at least maxAttempts times. A maxAttempts of 0 or less means
+// there is no limit, so nothing is exhausted.
+func (d *DB) CountExhaustedEntries(maxAttempts int) (int, error) {
+	if maxAttempts <= 0 {
+		return 0, nil
+	}
+
+	d.RLock()
+	defer d.RUnlock()
+
+	var count int
+	err := d.db.QueryRow("SELECT COUNT(*) FROM models WHERE status = ? AND attempt_count >= ?", models.StatusError, maxAttempts).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("error counting exhausted entries: %w", err)
+	}
+	return count, nil
+}
+
+// ModelIDs returns the distinct model IDs of all entries, lowest first. With
+// modelTypes only models of those types (case-insensitive) are returned.
+func (d *DB) ModelIDs(modelTypes []string) ([]int, error) {
+	query := "SELECT DISTINCT model_id FROM models"
+	args := make([]any, 0, len(modelTypes))
+	if len(modelTypes) > 0 {
+		placeholders := make([]string, len(modelTypes))
+		for i, modelType := range modelTypes {
+			placeholders[i] = "?"
+			args = append(args, strings.ToLower(modelType))
+		}
+		query += " WHERE LOWER(model_type) IN (" + strings.Join(placeholders, ", ") + ")"
+	}
+	query += " ORDER BY model_id"
+
+	d.RLock()
+	defer d.RUnlock()
+
+	rows, err := d.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("error querying model IDs: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var modelIDs []int
+	for rows.Next() {
+		var modelID int
+		if err := rows.Scan(&modelID); err != nil {
+			return nil, fmt.Errorf("error scanning model ID: %w", err)
+		}
+		modelIDs = append(modelIDs, modelID)
+	}
+	return modelIDs, rows.Err()
+}
+
+// SetVerification records the outcome of hashing an entry's file without
+// rewriting the rest of the entry. A verifiedAt of 0 and an empty hash clear it.
+func (d *DB) SetVerification(versionID int, verifiedAt int64, hash string) error {
+	d.Lock()
+	defer d.Unlock()
+
+	_, err := d.db.Exec("UPDATE models SET last_verified_at = ?, last_verified_hash = ? WHERE version_id = ?", verifiedAt, hash, versionID)
+	if err != nil {
+		return fmt.Errorf("error recording verification for version %d: %w", versionID, err)
+	}
+	return nil
 }
 
 // Lock acquires a write lock.
@@ -210,6 +552,9 @@ func (d *DB) Close() error {
 
 		d.closeErr = d.db.Close()
 		d.closed = true
+		if d.tempPath != "" {
+			removeDatabaseFiles(d.tempPath)
+		}
 
 		if d.closeErr != nil {
 			log.Errorf("Error during database close operation: %v", d.closeErr)
@@ -286,7 +631,8 @@ func (d *DB) getDatabaseEntry(key string) ([]byte, error) {
 			m.version_published_at, m.version_updated_at, m.version_description,
 			m.trained_words, m.base_model, m.early_access_timeframe,
 			m.creator_username, m.creator_image, m.filename, m.folder,
-			m.status, m.error_details, m.timestamp,
+			m.status, m.error_details, m.attempt_count, m.timestamp,
+			m.last_verified_at, m.last_verified_hash, m.object_path,
 			ms.download_count, ms.favorite_count, ms.comment_count, ms.rating_count, ms.rating
 		FROM models m
 		LEFT JOIN model_stats ms ON m.version_id = ms.version_id
@@ -296,7 +642,8 @@ func (d *DB) getDatabaseEntry(key string) ([]byte, error) {
 		&entry.Version.PublishedAt, &entry.Version.UpdatedAt, &entry.Version.Description,
 		&trainedWordsJSON, &entry.Version.BaseModel, &entry.Version.EarlyAccessTimeFrame,
 		&entry.Creator.Username, &entry.Creator.Image, &entry.Filename, &entry.Folder,
-		&entry.Status, &entry.ErrorDetails, &entry.Timestamp,
+		&entry.Status, &entry.ErrorDetails, &entry.AttemptCount, &entry.Timestamp,
+		&entry.LastVerifiedAt, &entry.LastVerifiedHash, &entry.ObjectPath,
 		&entry.Version.Stats.DownloadCount, &entry.Version.Stats.FavoriteCount,
 		&entry.Version.Stats.CommentCount, &entry.Version.Stats.RatingCount, &entry.Version.Stats.Rating,
 	)
@@ -399,6 +746,45 @@ func (d *DB) getDatabaseEntry(key string) ([]byte, error) {
 	return json.Marshal(entry)
 }
 
+// GetRawJSON returns the original API JSON stored for a version, or
+// ErrNotFound when the version has none (e.g. DB.StoreRawJSON was off).
+func (d *DB) GetRawJSON(versionID int) ([]byte, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	var compressed []byte
+	err := d.db.QueryRow("SELECT raw_json FROM models WHERE version_id = ?", versionID).Scan(&compressed)
+	if err == sql.ErrNoRows || (err == nil && len(compressed) == 0) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("error querying raw JSON for version %d: %w", versionID, err)
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing raw JSON for version %d: %w", versionID, err)
+	}
+	defer func() { _ = zr.Close() }()
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("error decompressing raw JSON for version %d: %w", versionID, err)
+	}
+	return raw, nil
+}
+
+// gzipBytes returns data gzip-compressed.
+func gzipBytes(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
 // getPageState retrieves pagination state
 func (d *DB) getPageState(key string) ([]byte, error) {
 	queryHash := strings.TrimPrefix(key, "current_page_")
@@ -454,6 +840,16 @@ func (d *DB) putDatabaseEntry(key string, value []byte) error {
 	// Marshal trained words to JSON
 	trainedWordsJSON, _ := json.Marshal(entry.Version.TrainedWords)
 
+	// Entries without raw JSON keep the one already stored
+	var rawJSON any
+	if len(entry.RawJSON) > 0 {
+		compressed, err := gzipBytes(entry.RawJSON)
+		if err != nil {
+			return fmt.Errorf("error compressing raw JSON for key %s: %w", key, err)
+		}
+		rawJSON = compressed
+	}
+
 	// Insert/update main model entry
 	_, err = tx.Exec(`
 		INSERT OR REPLACE INTO models (
@@ -461,18 +857,27 @@ func (d *DB) putDatabaseEntry(key string, value []byte) error {
 			version_published_at, version_updated_at, version_description,
 			trained_words, base_model, early_access_timeframe,
 			creator_username, creator_image, filename, folder,
-			status, error_details, timestamp
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			status, error_details, attempt_count, timestamp,
+			last_verified_at, last_verified_hash, object_path, raw_json
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?,
+			COALESCE(?, (SELECT raw_json FROM models WHERE version_id = ?)))
 	`, entry.Version.ID, entry.ModelID, entry.ModelName, entry.ModelType, entry.Version.Name,
 		entry.Version.PublishedAt, entry.Version.UpdatedAt, entry.Version.Description,
 		string(trainedWordsJSON), entry.Version.BaseModel, entry.Version.EarlyAccessTimeFrame,
 		entry.Creator.Username, entry.Creator.Image, entry.Filename, entry.Folder,
-		entry.Status, entry.ErrorDetails, entry.Timestamp)
+		entry.Status, entry.ErrorDetails, entry.AttemptCount, entry.Timestamp,
+		entry.LastVerifiedAt, entry.LastVerifiedHash, entry.ObjectPath, rawJSON, entry.Version.ID)
 
 	if err != nil {
 		return fmt.Errorf("error inserting model for key %s: %w", key, err)
 	}
 
+	err = indexEntryFTS(tx, entry.Version.ID, entry.ModelName, entry.Version.Name, entry.Version.Description,
+		string(trainedWordsJSON), entry.Creator.Username)
+	if err != nil {
+		return fmt.Errorf("error updating search index for key %s: %w", key, err)
+	}
+
 	// Insert/update stats
 	_, err = tx.Exec(`
 		INSERT OR REPLACE INTO model_stats (
@@ -587,6 +992,9 @@ func (d *DB) Delete(key []byte) error {
 			return ErrNotFound
 		}
 
+		if _, err := d.db.Exec("DELETE FROM models_fts WHERE rowid = ?", versionID); err != nil {
+			return fmt.Errorf("error removing key %s from search index: %w", keyStr, err)
+		}
 	} else if strings.HasPrefix(keyStr, "current_page_") {
 		queryHash := strings.TrimPrefix(keyStr, "current_page_")
 
@@ -745,6 +1153,41 @@ func (d *DB) SetPageState(queryHash string, nextPage int) error {
 	return nil
 }
 
+// GetPageCursor returns the saved cursor of the next page and the number of
+// pages fetched so far for a given query hash, or "" and 0 if none is saved.
+func (d *DB) GetPageCursor(queryHash string) (string, int, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	var cursor string
+	var pages int
+	err := d.db.QueryRow("SELECT cursor, current_page FROM pagination_state WHERE query_hash = ?", queryHash).Scan(&cursor, &pages)
+	if err == sql.ErrNoRows {
+		return "", 0, nil
+	} else if err != nil {
+		return "", 0, fmt.Errorf("error reading page cursor for %s: %w", queryHash, err)
+	}
+	return cursor, pages, nil
+}
+
+// SetPageCursor saves the cursor of the next page and the number of pages
+// fetched so far for a given query hash.
+func (d *DB) SetPageCursor(queryHash string, cursor string, pages int) error {
+	d.Lock()
+	defer d.Unlock()
+
+	_, err := d.db.Exec(`
+		INSERT OR REPLACE INTO pagination_state (query_hash, current_page, cursor)
+		VALUES (?, ?, ?)
+	`, queryHash, pages, cursor)
+	if err != nil {
+		return fmt.Errorf("error setting page cursor for %s: %w", queryHash, err)
+	}
+
+	log.WithField("queryHash", queryHash).Debugf("Set page cursor after %d pages: %s", pages, cursor)
+	return nil
+}
+
 // DeletePageState removes the saved page number for a given query hash.
 func (d *DB) DeletePageState(queryHash string) error {
 	d.Lock()
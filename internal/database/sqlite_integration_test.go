@@ -101,6 +101,24 @@ func TestSQLiteIntegrationBasicOperations(t *testing.T) {
 		assert.Equal(t, 1, deletedPage, "Page should return to default after deletion")
 	})
 
+	t.Run("Page Cursor", func(t *testing.T) {
+		cursor, pages, err := db.GetPageCursor("images_cursor_hash")
+		require.NoError(t, err)
+		assert.Equal(t, "", cursor, "No cursor before one is saved")
+		assert.Equal(t, 0, pages)
+
+		require.NoError(t, db.SetPageCursor("images_cursor_hash", "12345|1700000000", 11))
+		cursor, pages, err = db.GetPageCursor("images_cursor_hash")
+		require.NoError(t, err)
+		assert.Equal(t, "12345|1700000000", cursor)
+		assert.Equal(t, 11, pages)
+
+		require.NoError(t, db.DeletePageState("images_cursor_hash"))
+		cursor, _, err = db.GetPageCursor("images_cursor_hash")
+		require.NoError(t, err)
+		assert.Equal(t, "", cursor, "Deleting the page state removes the cursor")
+	})
+
 	// Test Fold operation
 	t.Run("Fold Operation", func(t *testing.T) {
 		foundEntries := make(map[string]bool)
@@ -271,6 +289,36 @@ func TestSQLitePerformance(t *testing.T) {
 }
 
 // createTestDatabaseEntry creates a realistic test entry
+func TestModelIDs(t *testing.T) {
+	db, err := OpenMemory()
+	require.NoError(t, err)
+	defer db.Close()
+
+	put := func(versionID, modelID int, modelType string) {
+		entry := models.DatabaseEntry{ModelID: modelID, ModelType: modelType, Status: models.StatusDownloaded,
+			Version: models.ModelVersion{ID: versionID}}
+		data, err := json.Marshal(entry)
+		require.NoError(t, err)
+		require.NoError(t, db.Put([]byte(fmt.Sprintf("v_%d", versionID)), data))
+	}
+	put(1, 30, "LORA")
+	put(2, 30, "LORA") // Second version of the same model
+	put(3, 10, "Checkpoint")
+	put(4, 20, "LoCon")
+
+	modelIDs, err := db.ModelIDs(nil)
+	require.NoError(t, err)
+	assert.Equal(t, []int{10, 20, 30}, modelIDs)
+
+	modelIDs, err = db.ModelIDs([]string{"lora", "LoCon"})
+	require.NoError(t, err)
+	assert.Equal(t, []int{20, 30}, modelIDs)
+
+	modelIDs, err = db.ModelIDs([]string{"VAE"})
+	require.NoError(t, err)
+	assert.Empty(t, modelIDs)
+}
+
 func createTestDatabaseEntry() models.DatabaseEntry {
 	return models.DatabaseEntry{
 		ModelID:   123456,
@@ -396,3 +444,41 @@ func createTestDatabaseEntry() models.DatabaseEntry {
 		ErrorDetails: "",
 	}
 }
+
+func TestOpenCopy_MigratesWithoutChangingTheOriginal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	db, err := Open(path)
+	require.NoError(t, err)
+	entry := models.DatabaseEntry{ModelID: 1, ModelName: "Model", ModelType: "LORA", Version: models.ModelVersion{ID: 10, Name: "v1"}, Filename: "10_model.safetensors", Folder: "lora", Status: models.StatusDownloaded}
+	data, err := json.Marshal(entry)
+	require.NoError(t, err)
+	require.NoError(t, db.Put([]byte("v_10"), data))
+	// Simulate a database created before attempt_count existed.
+	_, err = db.db.Exec("ALTER TABLE models DROP COLUMN attempt_count")
+	require.NoError(t, err)
+	require.NoError(t, db.Close())
+
+	ro, err := OpenReadOnly(path)
+	require.NoError(t, err)
+	_, err = ro.Get([]byte("v_10"))
+	assert.Error(t, err, "the old schema cannot be read as is")
+	require.NoError(t, ro.Close())
+
+	cp, err := OpenCopy(path)
+	require.NoError(t, err)
+	raw, err := cp.Get([]byte("v_10"))
+	require.NoError(t, err)
+	var got models.DatabaseEntry
+	require.NoError(t, json.Unmarshal(raw, &got))
+	assert.Equal(t, models.StatusDownloaded, got.Status)
+	tempPath := cp.tempPath
+	require.NoError(t, cp.Close())
+	assert.NoFileExists(t, tempPath, "the copy is removed on Close")
+
+	ro, err = OpenReadOnly(path)
+	require.NoError(t, err)
+	defer ro.Close()
+	has, err := ro.columnExists("models", "attempt_count")
+	require.NoError(t, err)
+	assert.False(t, has, "the original is left as it was")
+}
@@ -0,0 +1,128 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+
+	"go-civitai-download/internal/models"
+)
+
+// largestFilesLimit is the number of files listed in Stats.LargestFiles.
+const largestFilesLimit = 10
+
+// entrySizesCTE gives every models row the size of its file. The files table
+// holds all files of the version, so the primary one is taken, or the largest
+// if none is marked primary.
+const entrySizesCTE = `
+	WITH entry_sizes AS (
+		SELECT m.version_id, m.model_name, m.version_name, m.model_type, m.base_model, m.filename, m.status,
+			COALESCE((SELECT f.size_kb FROM files f WHERE f.version_id = m.version_id
+				ORDER BY f.is_primary DESC, f.size_kb DESC LIMIT 1), 0) AS size_kb
+		FROM models m
+	)
+`
+
+// StatsGroup is the number of entries sharing a status, model type or base
+// model, and the size of their files.
+type StatsGroup struct {
+	Name   string  `json:"name"`
+	Count  int     `json:"count"`
+	SizeKB float64 `json:"sizeKB"`
+}
+
+// StatsFile is one downloaded file listed in Stats.LargestFiles.
+type StatsFile struct {
+	ModelName   string  `json:"modelName"`
+	VersionName string  `json:"versionName"`
+	Filename    string  `json:"filename"`
+	VersionID   int     `json:"versionId"`
+	SizeKB      float64 `json:"sizeKB"`
+}
+
+// Stats are aggregate numbers over the whole database, as returned by DB.Stats.
+type Stats struct {
+	ByStatus     []StatsGroup `json:"byStatus"`
+	ByModelType  []StatsGroup `json:"byModelType"`
+	ByBaseModel  []StatsGroup `json:"byBaseModel"`
+	LargestFiles []StatsFile  `json:"largestFiles"` // Largest downloaded files, largest first
+	TotalEntries int          `json:"totalEntries"`
+	DownloadedKB float64      `json:"downloadedKB"` // Combined size of the files of Downloaded entries
+}
+
+// Stats computes the aggregate numbers of the database with SQL, without
+// reading the entries themselves.
+func (d *DB) Stats() (Stats, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	var stats Stats
+	err := d.db.QueryRow(entrySizesCTE+`
+		SELECT COUNT(*), COALESCE(SUM(CASE WHEN status = ? THEN size_kb ELSE 0 END), 0) FROM entry_sizes
+	`, models.StatusDownloaded).Scan(&stats.TotalEntries, &stats.DownloadedKB)
+	if err != nil {
+		return Stats{}, fmt.Errorf("error counting entries: %w", err)
+	}
+
+	for _, group := range []struct {
+		column string
+		dest   *[]StatsGroup
+	}{
+		{"status", &stats.ByStatus},
+		{"model_type", &stats.ByModelType},
+		{"base_model", &stats.ByBaseModel},
+	} {
+		groups, err := d.statsGroups(group.column)
+		if err != nil {
+			return Stats{}, err
+		}
+		*group.dest = groups
+	}
+
+	rows, err := d.db.Query(entrySizesCTE+`
+		SELECT version_id, model_name, version_name, filename, size_kb FROM entry_sizes
+		WHERE status = ? AND size_kb > 0
+		ORDER BY size_kb DESC, version_id LIMIT ?
+	`, models.StatusDownloaded, largestFilesLimit)
+	if err != nil {
+		return Stats{}, fmt.Errorf("error querying largest files: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+	for rows.Next() {
+		var file StatsFile
+		if err := rows.Scan(&file.VersionID, &file.ModelName, &file.VersionName, &file.Filename, &file.SizeKB); err != nil {
+			return Stats{}, fmt.Errorf("error scanning largest file row: %w", err)
+		}
+		stats.LargestFiles = append(stats.LargestFiles, file)
+	}
+	if err := rows.Err(); err != nil {
+		return Stats{}, fmt.Errorf("error reading largest files: %w", err)
+	}
+	return stats, nil
+}
+
+// statsGroups counts the entries per value of column, most entries first.
+// column is one of a fixed set of column names, never user input.
+func (d *DB) statsGroups(column string) ([]StatsGroup, error) {
+	rows, err := d.db.Query(entrySizesCTE + `
+		SELECT ` + column + `, COUNT(*), SUM(size_kb) FROM entry_sizes
+		GROUP BY ` + column + ` ORDER BY COUNT(*) DESC, ` + column)
+	if err != nil {
+		return nil, fmt.Errorf("error grouping entries by %s: %w", column, err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var groups []StatsGroup
+	for rows.Next() {
+		var name sql.NullString
+		var group StatsGroup
+		if err := rows.Scan(&name, &group.Count, &group.SizeKB); err != nil {
+			return nil, fmt.Errorf("error scanning %s group: %w", column, err)
+		}
+		group.Name = name.String
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error reading %s groups: %w", column, err)
+	}
+	return groups, nil
+}
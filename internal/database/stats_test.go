@@ -0,0 +1,66 @@
+package database
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"go-civitai-download/internal/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	db, err := OpenMemory()
+	require.NoError(t, err)
+	defer db.Close()
+
+	stats, err := db.Stats()
+	require.NoError(t, err)
+	assert.Zero(t, stats.TotalEntries)
+	assert.Empty(t, stats.LargestFiles)
+
+	put := func(versionID int, status, modelType, baseModel string, files ...models.File) {
+		entry := models.DatabaseEntry{
+			ModelName: fmt.Sprintf("Model %d", versionID),
+			ModelType: modelType,
+			Filename:  fmt.Sprintf("%d_model.safetensors", versionID),
+			Status:    status,
+			Version:   models.ModelVersion{ID: versionID, Name: "v1", BaseModel: baseModel, Files: files},
+		}
+		data, err := json.Marshal(entry)
+		require.NoError(t, err)
+		require.NoError(t, db.Put([]byte(fmt.Sprintf("v_%d", versionID)), data))
+	}
+	put(1, models.StatusDownloaded, "LORA", "SDXL 1.0",
+		models.File{ID: 10, SizeKB: 100, Primary: true}, models.File{ID: 11, SizeKB: 5000}) // primary file counts
+	put(2, models.StatusDownloaded, "LORA", "SD 1.5", models.File{ID: 20, SizeKB: 300}) // largest without a primary
+	put(3, models.StatusDownloaded, "Checkpoint", "SDXL 1.0", models.File{ID: 30, SizeKB: 2000, Primary: true})
+	put(4, models.StatusError, "LORA", "SDXL 1.0", models.File{ID: 40, SizeKB: 9000, Primary: true})
+	put(5, models.StatusPending, "LORA", "")
+
+	stats, err = db.Stats()
+	require.NoError(t, err)
+	assert.Equal(t, 5, stats.TotalEntries)
+	assert.Equal(t, 2400.0, stats.DownloadedKB)
+	assert.Equal(t, []StatsGroup{
+		{Name: models.StatusDownloaded, Count: 3, SizeKB: 2400},
+		{Name: models.StatusError, Count: 1, SizeKB: 9000},
+		{Name: models.StatusPending, Count: 1, SizeKB: 0},
+	}, stats.ByStatus)
+	assert.Equal(t, []StatsGroup{
+		{Name: "LORA", Count: 4, SizeKB: 9400},
+		{Name: "Checkpoint", Count: 1, SizeKB: 2000},
+	}, stats.ByModelType)
+	assert.Equal(t, []StatsGroup{
+		{Name: "SDXL 1.0", Count: 3, SizeKB: 11100},
+		{Name: "", Count: 1, SizeKB: 0},
+		{Name: "SD 1.5", Count: 1, SizeKB: 300},
+	}, stats.ByBaseModel)
+
+	require.Len(t, stats.LargestFiles, 3, "only downloaded files are listed")
+	assert.Equal(t, StatsFile{ModelName: "Model 3", VersionName: "v1", Filename: "3_model.safetensors", VersionID: 3, SizeKB: 2000}, stats.LargestFiles[0])
+	assert.Equal(t, 2, stats.LargestFiles[1].VersionID)
+	assert.Equal(t, 1, stats.LargestFiles[2].VersionID)
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// TorrentRecord remembers the state of a directory when its .torrent was
+// generated, so the torrent command can skip directories that did not change.
+// Fingerprint is opaque to the database.
+type TorrentRecord struct {
+	SourcePath  string
+	TorrentPath string
+	Fingerprint string
+}
+
+// GetTorrentRecord returns the record for sourcePath, or ErrNotFound.
+func (d *DB) GetTorrentRecord(sourcePath string) (TorrentRecord, error) {
+	d.RLock()
+	defer d.RUnlock()
+
+	record := TorrentRecord{SourcePath: sourcePath}
+	err := d.db.QueryRow("SELECT torrent_path, fingerprint FROM torrent_state WHERE source_path = ?", sourcePath).Scan(&record.TorrentPath, &record.Fingerprint)
+	if err == sql.ErrNoRows {
+		return TorrentRecord{}, ErrNotFound
+	} else if err != nil {
+		return TorrentRecord{}, fmt.Errorf("error querying torrent state for %s: %w", sourcePath, err)
+	}
+	return record, nil
+}
+
+// PutTorrentRecord stores record, replacing the one for the same directory.
+func (d *DB) PutTorrentRecord(record TorrentRecord) error {
+	d.Lock()
+	defer d.Unlock()
+
+	_, err := d.db.Exec(`
+		INSERT OR REPLACE INTO torrent_state (source_path, torrent_path, fingerprint, updated_at)
+		VALUES (?, ?, ?, CURRENT_TIMESTAMP)
+	`, record.SourcePath, record.TorrentPath, record.Fingerprint)
+	if err != nil {
+		return fmt.Errorf("error storing torrent state for %s: %w", record.SourcePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,30 @@
+package database
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTorrentRecord(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "torrents.db"))
+	require.NoError(t, err)
+	defer db.Close()
+
+	_, err = db.GetTorrentRecord("lora/model")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	record := TorrentRecord{SourcePath: "lora/model", TorrentPath: "lora/model/model.torrent", Fingerprint: "abc"}
+	require.NoError(t, db.PutTorrentRecord(record))
+	got, err := db.GetTorrentRecord("lora/model")
+	require.NoError(t, err)
+	assert.Equal(t, record, got)
+
+	record.Fingerprint = "def"
+	require.NoError(t, db.PutTorrentRecord(record))
+	got, err = db.GetTorrentRecord("lora/model")
+	require.NoError(t, err)
+	assert.Equal(t, "def", got.Fingerprint)
+}
@@ -1,6 +1,9 @@
 package downloader
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -11,12 +14,14 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"go-civitai-download/internal/helpers"
 	"go-civitai-download/internal/models"
 
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/time/rate"
 )
 
 // Custom Downloader Errors
@@ -27,19 +32,76 @@ var (
 	ErrHttpRequest  = errors.New("HTTP request creation/execution error")
 )
 
-// UserAgent is the browser User-Agent string used for HTTP requests to avoid 401 errors
-const UserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
-
 // Downloader handles downloading files with progress and hash checks.
 type Downloader struct {
 	client              *http.Client
-	apiKey              string // API key for token-based auth
-	sessionCookie       string // Browser session cookie for login-required downloads
-	detectImageMimeType bool   // Whether to detect actual MIME type for image downloads
+	apiKey              string        // API key for token-based auth
+	sessionCookie       string        // Browser session cookie for login-required downloads
+	userAgent           string        // User-Agent header, see SetUserAgent
+	detectImageMimeType bool          // Whether to detect actual MIME type for image downloads
+	overwrite           bool          // Download even when a matching file exists, see SetOverwrite
+	keepFileName        bool          // Ignore the server's file name, see SetKeepFileName
+	received            atomic.Uint64 // Bytes written by file downloads so far, see BytesReceived
+	limiter             *rate.Limiter // Caps the bytes read per second, see SetRateLimiter
+	segments            int           // Range requests per file download, see SetSegments
+}
+
+// statusError is returned when a download is answered with an unexpected HTTP
+// status. It matches ErrHttpStatus; StatusCode returns the code.
+type statusError struct {
+	code int
+	url  string
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%v: received status %d from %s", ErrHttpStatus, e.code, e.url)
+}
+
+func (e *statusError) Is(target error) bool {
+	return target == ErrHttpStatus
+}
+
+// StatusCode returns the HTTP status code a failed download was answered
+// with, or 0 if err is not such a failure.
+func StatusCode(err error) int {
+	var statusErr *statusError
+	if errors.As(err, &statusErr) {
+		return statusErr.code
+	}
+	return 0
+}
+
+// maxRateBurst caps the bytes a throttled read takes at once, so progress
+// keeps updating in small steps at low rates.
+const maxRateBurst = 64 * 1024
+
+// NewRateLimiter returns a limiter allowing bytesPerSecond bytes per second,
+// or nil (unlimited) when bytesPerSecond is 0 or less. Give the same limiter
+// to every Downloader of a run to cap their combined bandwidth.
+func NewRateLimiter(bytesPerSecond int64) *rate.Limiter {
+	if bytesPerSecond <= 0 {
+		return nil
+	}
+	return rate.NewLimiter(rate.Limit(bytesPerSecond), int(min(bytesPerSecond, maxRateBurst)))
+}
+
+// sessionCookieName is the cookie holding a logged in Civitai browser session.
+const sessionCookieName = "__Secure-civitai-token"
+
+// sessionCookieHeader returns the Cookie header for sessionCookie. A bare
+// token, as copied from the browser's cookie list, is sent as the session
+// cookie; a value containing "=" is sent as given.
+func sessionCookieHeader(sessionCookie string) string {
+	sessionCookie = strings.TrimSpace(sessionCookie)
+	if sessionCookie == "" || strings.Contains(sessionCookie, "=") {
+		return sessionCookie
+	}
+	return sessionCookieName + "=" + sessionCookie
 }
 
 // NewDownloader creates a new Downloader instance.
 // sessionCookie is optional - pass empty string if not using cookie auth.
+// It may be the full "__Secure-civitai-token=..." cookie or just its value.
 func NewDownloader(client *http.Client, apiKey string, sessionCookie string) *Downloader {
 	if client == nil {
 		// Create a client with custom redirect handling to preserve headers
@@ -51,7 +113,7 @@ func NewDownloader(client *http.Client, apiKey string, sessionCookie string) *Do
 				}
 				// Preserve User-Agent and Cookie headers on redirects
 				if len(via) > 0 {
-					req.Header.Set("User-Agent", UserAgent)
+					req.Header.Set("User-Agent", via[0].Header.Get("User-Agent"))
 					// Preserve cookies on redirect (important for Civitai auth)
 					if cookie := via[0].Header.Get("Cookie"); cookie != "" {
 						// #nosec G119 -- cookie preservation on redirect is required for Civitai auth
@@ -65,11 +127,63 @@ func NewDownloader(client *http.Client, apiKey string, sessionCookie string) *Do
 	return &Downloader{
 		client:              client,
 		apiKey:              apiKey,
-		sessionCookie:       sessionCookie,
+		sessionCookie:       sessionCookieHeader(sessionCookie),
+		userAgent:           models.DefaultUserAgent(),
 		detectImageMimeType: true, // Enabled by default
 	}
 }
 
+// SetUserAgent sets the User-Agent header sent with downloads. An empty
+// string keeps models.DefaultUserAgent.
+func (d *Downloader) SetUserAgent(userAgent string) {
+	if userAgent != "" {
+		d.userAgent = userAgent
+	}
+}
+
+// SetOverwrite makes DownloadFile fetch the file again even when a copy with
+// the expected hash is already on disk, replacing that copy.
+func (d *Downloader) SetOverwrite(enabled bool) {
+	d.overwrite = enabled
+}
+
+// SetKeepFileName makes DownloadFile save files under the base name of the
+// target path it is given rather than the name sent in Content-Disposition.
+// The version ID prefix is still added unless the name already carries it.
+func (d *Downloader) SetKeepFileName(enabled bool) {
+	d.keepFileName = enabled
+}
+
+// SetRateLimiter throttles the response bodies read by DownloadFile and
+// DownloadImage with limiter, which may be shared by several Downloaders and
+// workers. A nil limiter, the default, downloads at full speed.
+func (d *Downloader) SetRateLimiter(limiter *rate.Limiter) {
+	d.limiter = limiter
+}
+
+// BytesReceived returns the number of bytes of file downloads written so far,
+// including downloads still in progress. Sampling it gives the throughput.
+func (d *Downloader) BytesReceived() uint64 {
+	return d.received.Load()
+}
+
+// fileProgressKey is the context key of WithFileProgress.
+type fileProgressKey struct{}
+
+// WithFileProgress returns a copy of ctx that makes DownloadFileWithContext
+// keep received at the number of bytes of the file it has so far, including
+// those of a resumed partial file. BytesReceived adds up every download;
+// this follows a single one.
+func WithFileProgress(ctx context.Context, received *atomic.Uint64) context.Context {
+	return context.WithValue(ctx, fileProgressKey{}, received)
+}
+
+// fileProgress returns the counter set with WithFileProgress, or nil.
+func fileProgress(ctx context.Context) *atomic.Uint64 {
+	received, _ := ctx.Value(fileProgressKey{}).(*atomic.Uint64)
+	return received
+}
+
 // SetDetectImageMimeType enables or disables MIME type detection for image downloads.
 // When enabled (default), the downloader detects the actual content type and renames
 // files with the correct extension. When disabled, files keep their original URL-derived
@@ -81,7 +195,7 @@ func (d *Downloader) SetDetectImageMimeType(enabled bool) {
 // Helper function to check for existing file by base name and hash.
 // Now requires the expected file extension to avoid checking hashes on mismatched file types (e.g., .json vs .safetensors).
 func findExistingFileWithMatchingBaseAndHash(dirPath string, baseNameWithoutExt string, expectedExt string, hashes models.Hashes) (foundPath string, exists bool, err error) {
-	entries, err := os.ReadDir(dirPath)
+	entries, err := os.ReadDir(helpers.LongPath(dirPath))
 	if err != nil {
 		if os.IsNotExist(err) {
 			return "", false, nil // Directory doesn't exist, so file doesn't exist
@@ -153,7 +267,7 @@ func (d *Downloader) checkExistingFile(targetFilepath string, hashes models.Hash
 
 // createHTTPRequest creates and configures an HTTP request for downloading
 // Uses both token query parameter AND Authorization header for authentication
-func (d *Downloader) createHTTPRequest(downloadURL string) (*http.Request, error) {
+func (d *Downloader) createHTTPRequest(ctx context.Context, downloadURL string) (*http.Request, error) {
 	// Add token as query parameter if API key is set
 	// This is required because Authorization headers are stripped on redirect to S3
 	finalURL := downloadURL
@@ -171,13 +285,13 @@ func (d *Downloader) createHTTPRequest(downloadURL string) (*http.Request, error
 		log.Debug("No API Key found, skipping token parameter for download.")
 	}
 
-	req, err := http.NewRequest("GET", finalURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", finalURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("%w: creating download request for %s: %w", ErrHttpRequest, finalURL, err)
 	}
 
 	// Set User-Agent to avoid 401 errors from Civitai
-	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("User-Agent", d.userAgent)
 
 	// Also set Authorization header for initial request (before redirect)
 	// This helps with Civitai's auth check before redirecting to S3
@@ -197,6 +311,52 @@ func (d *Downloader) createHTTPRequest(downloadURL string) (*http.Request, error
 	return req, nil
 }
 
+// doDownloadRequest requests downloadURL, asking for the bytes from offset on
+// when offset is above 0.
+func (d *Downloader) doDownloadRequest(ctx context.Context, downloadURL string, offset int64) (*http.Response, error) {
+	req, err := d.createHTTPRequest(ctx, downloadURL)
+	if err != nil {
+		return nil, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			log.Infof("Download from %s cancelled before response", downloadURL)
+			return nil, fmt.Errorf("download of %s cancelled: %w", downloadURL, ctxErr)
+		}
+		log.WithError(err).Errorf("Error performing download request from %s", downloadURL)
+		return nil, fmt.Errorf("%w: performing request for %s: %v", ErrHttpRequest, downloadURL, err)
+	}
+	return resp, nil
+}
+
+// contentRangeStart returns the first byte of a "bytes start-end/size"
+// Content-Range header.
+func contentRangeStart(contentRange string) (int64, bool) {
+	spec, ok := strings.CutPrefix(contentRange, "bytes ")
+	if !ok {
+		return 0, false
+	}
+	startStr, _, ok := strings.Cut(spec, "-")
+	if !ok {
+		return 0, false
+	}
+	start, err := strconv.ParseInt(strings.TrimSpace(startStr), 10, 64)
+	return start, err == nil
+}
+
+// truncatePart empties a partial file to download it again from the start.
+func truncatePart(partFile *os.File) error {
+	if err := partFile.Truncate(0); err != nil {
+		return fmt.Errorf("%w: truncating partial file %s: %w", ErrFileSystem, partFile.Name(), err)
+	}
+	return nil
+}
+
 // extractFilenameFromResponse extracts filename from Content-Disposition header
 func extractFilenameFromResponse(resp *http.Response) string {
 	contentDisposition := resp.Header.Get("Content-Disposition")
@@ -219,6 +379,32 @@ func extractFilenameFromResponse(resp *http.Response) string {
 	return ""
 }
 
+// FinalPath returns the path DownloadFile saves targetFilepath to when the
+// server sends fileName in Content-Disposition, for callers that place a file
+// without downloading it.
+func (d *Downloader) FinalPath(targetFilepath, fileName string, modelVersionID int) string {
+	return d.finalPath(targetFilepath, fileName, modelVersionID)
+}
+
+// finalPath returns the path a download of targetFilepath is saved to, given
+// the file name sent by the server ("" if none).
+func (d *Downloader) finalPath(targetFilepath, apiFilename string, modelVersionID int) string {
+	if d.keepFileName {
+		return keptFinalPath(targetFilepath, modelVersionID)
+	}
+	return constructFinalPath(targetFilepath, apiFilename, modelVersionID)
+}
+
+// keptFinalPath returns the final file path for SetKeepFileName: originalPath
+// with the version ID prepended to its base name, unless it is already there.
+func keptFinalPath(originalPath string, modelVersionID int) string {
+	base := filepath.Base(originalPath)
+	if modelVersionID <= 0 || strings.HasPrefix(base, fmt.Sprintf("%d_", modelVersionID)) {
+		return originalPath
+	}
+	return filepath.Join(filepath.Dir(originalPath), fmt.Sprintf("%d_%s", modelVersionID, base))
+}
+
 // constructFinalPath creates the final file path with version ID and API filename
 func constructFinalPath(originalPath, apiFilename string, modelVersionID int) string {
 	var baseFilenameToUse string
@@ -240,12 +426,139 @@ func constructFinalPath(originalPath, apiFilename string, modelVersionID int) st
 	return pathBeforeId
 }
 
-// downloadToTemp downloads the response body to a temporary file
-func downloadToTemp(resp *http.Response, tempFile *os.File, targetPath string) error {
+// errorPageSniffLen is how much of a response body is inspected for error pages.
+const errorPageSniffLen = 2048
+
+// checkErrorPage returns an error describing the response if it is an HTML or
+// JSON error page rather than a file. Bodies served as a generic octet-stream
+// are trusted. Otherwise the start of the body is sniffed, so an error page
+// with a misleading Content-Type is still caught; resp.Body is replaced so the
+// sniffed bytes are still read by the download.
+func checkErrorPage(resp *http.Response) error {
+	contentType := strings.ToLower(resp.Header.Get("Content-Type"))
+	if strings.Contains(contentType, "application/octet-stream") {
+		return nil
+	}
+
+	br := bufio.NewReaderSize(resp.Body, errorPageSniffLen)
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{br, resp.Body}
+	preview, _ := br.Peek(errorPageSniffLen)
+	body := string(preview)
+	log.Debugf("Response body preview: %s", body)
+
+	switch {
+	case strings.Contains(contentType, "text/html") || looksLikeHTML(preview):
+		return fmt.Errorf("received HTML instead of the file: %s", htmlErrorReason(body))
+	case looksLikeJSONError(preview):
+		return fmt.Errorf("received a JSON error instead of the file: %s", strings.TrimSpace(truncate(body, 200)))
+	}
+	return nil
+}
+
+// htmlErrorReason guesses why Civitai served an HTML page instead of a file.
+func htmlErrorReason(body string) string {
+	switch {
+	case strings.Contains(body, "early access") || strings.Contains(body, "Early Access"):
+		return "model is in Early Access (requires Supporter membership)"
+	case strings.Contains(body, "login") || strings.Contains(body, "sign in") || strings.Contains(body, "Sign In"):
+		return "model requires login (creator has restricted downloads or the token has expired)"
+	case strings.Contains(body, "not found") || strings.Contains(body, "404"):
+		return "model or file not found"
+	case strings.Contains(body, "unavailable") || strings.Contains(body, "removed"):
+		return "model has been removed or is unavailable"
+	default:
+		return "download restricted or requires browser login"
+	}
+}
+
+func looksLikeHTML(preview []byte) bool {
+	start := strings.ToLower(strings.TrimSpace(string(preview)))
+	return strings.HasPrefix(start, "<!doctype html") || strings.HasPrefix(start, "<html")
+}
+
+// looksLikeJSONError matches API error bodies such as {"error": ...}: a small
+// JSON object with an "error" key, or a "message" key and little else. It is
+// deliberately narrow, as .json model files and configs are served as
+// application/json too: a body longer than the preview, or a safetensors file
+// (a binary header length followed by a JSON header), is no error body.
+func looksLikeJSONError(preview []byte) bool {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(preview, &fields); err != nil {
+		return false
+	}
+	if _, ok := fields["error"]; ok {
+		return true
+	}
+	_, ok := fields["message"]
+	return ok && len(fields) <= 3
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n] + "..."
+	}
+	return s
+}
+
+// receivedWriter adds the bytes written to the underlying writer to total, and
+// to file when set, as they arrive.
+type receivedWriter struct {
+	w     io.Writer
+	total *atomic.Uint64
+	file  *atomic.Uint64
+}
+
+func (rw receivedWriter) Write(p []byte) (int, error) {
+	n, err := rw.w.Write(p)
+	if n > 0 {
+		rw.total.Add(uint64(n))
+		if rw.file != nil {
+			rw.file.Add(uint64(n))
+		}
+	}
+	return n, err
+}
+
+// rateLimitedReader waits for limiter before handing out the bytes it reads,
+// reading at most the limiter's burst at a time.
+type rateLimitedReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+func (rr rateLimitedReader) Read(p []byte) (int, error) {
+	if burst := rr.limiter.Burst(); len(p) > burst {
+		p = p[:burst]
+	}
+	n, err := rr.r.Read(p)
+	if n > 0 {
+		if waitErr := rr.limiter.WaitN(rr.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
+
+// throttle wraps body in a rateLimitedReader when a rate limiter is set.
+func (d *Downloader) throttle(ctx context.Context, body io.Reader) io.Reader {
+	if d.limiter == nil {
+		return body
+	}
+	return rateLimitedReader{ctx: ctx, r: body, limiter: d.limiter}
+}
+
+// downloadToTemp downloads the response body to a temporary file, adding the
+// bytes written to received and, when set, file. When verifier is set the body
+// is also fed to it, so the file is hashed as it is written.
+func downloadToTemp(resp *http.Response, tempFile *os.File, targetPath string, received, file *atomic.Uint64, verifier *helpers.HashVerifier) error {
 	size, _ := strconv.ParseUint(resp.Header.Get("Content-Length"), 10, 64)
 
 	counter := &helpers.CounterWriter{
-		Writer: tempFile,
+		Writer: receivedWriter{w: tempFile, total: received, file: file},
 		Total:  0,
 	}
 
@@ -255,7 +568,11 @@ func downloadToTemp(resp *http.Response, tempFile *os.File, targetPath string) e
 		helpers.BytesToSize(size),
 	)
 
-	_, err := io.Copy(counter, resp.Body)
+	var body io.Reader = resp.Body
+	if verifier != nil {
+		body = io.TeeReader(resp.Body, verifier)
+	}
+	_, err := io.Copy(counter, body)
 	if err != nil {
 		_ = tempFile.Close()
 		return fmt.Errorf("writing to temporary file %s: %w", tempFile.Name(), err)
@@ -311,7 +628,7 @@ func detectMimeAndRename(tempFilePath, finalPath string) (string, error) {
 	if strings.EqualFold(finalExt, correctExt) || (strings.EqualFold(finalExt, ".jpeg") && strings.EqualFold(correctExt, ".jpg")) || (strings.EqualFold(finalExt, ".jpg") && strings.EqualFold(correctExt, ".jpeg")) {
 		log.Debugf("Extension '%s' is already correct for MIME type '%s'. Skipping rename.", finalExt, mimeType)
 		finalPathWithCorrectExt := filepath.Join(finalDir, finalBaseNameWithoutExt+finalExt)
-		if err := os.Rename(tempFilePath, finalPathWithCorrectExt); err != nil {
+		if err := os.Rename(tempFilePath, helpers.LongPath(finalPathWithCorrectExt)); err != nil {
 			return "", fmt.Errorf("%w: renaming temporary file %s to %s: %w", ErrFileSystem, tempFilePath, finalPathWithCorrectExt, err)
 		}
 		log.Infof("Successfully renamed temp file to %s", finalPathWithCorrectExt)
@@ -322,7 +639,7 @@ func detectMimeAndRename(tempFilePath, finalPath string) (string, error) {
 	log.Debugf("Final path with corrected extension based on MIME type: %s", finalPathWithCorrectExt)
 
 	log.Debugf("Renaming temporary file %s to final path %s", tempFilePath, finalPathWithCorrectExt)
-	if err := os.Rename(tempFilePath, finalPathWithCorrectExt); err != nil {
+	if err := os.Rename(tempFilePath, helpers.LongPath(finalPathWithCorrectExt)); err != nil {
 		return "", fmt.Errorf("%w: renaming temporary file %s to %s: %w", ErrFileSystem, tempFilePath, finalPathWithCorrectExt, err)
 	}
 
@@ -330,35 +647,45 @@ func detectMimeAndRename(tempFilePath, finalPath string) (string, error) {
 	return finalPathWithCorrectExt, nil
 }
 
-// verifyHash verifies the downloaded file hash if provided
-func verifyHash(filePath string, hashes models.Hashes) error {
-	hashesProvided := hashes.SHA256 != "" || hashes.BLAKE3 != "" || hashes.CRC32 != "" || hashes.AutoV2 != ""
-	if !hashesProvided {
-		log.Debugf("Skipping hash verification for %s (no expected hashes provided).", filePath)
-		return nil
+// hashPartPrefix feeds the first offset bytes of the partial file at path,
+// written by an earlier attempt, to verifier so the hash of a resumed download
+// covers the whole file. Only the already downloaded part is read back.
+func hashPartPrefix(path string, offset int64, verifier *helpers.HashVerifier) error {
+	// #nosec G304 -- path is derived from the internal target path
+	f, err := os.Open(path) //nolint:gosec
+	if err != nil {
+		return fmt.Errorf("%w: opening partial file %s for hashing: %w", ErrFileSystem, path, err)
 	}
-
-	log.Debugf("Verifying hash for final file: %s", filePath)
-	if !helpers.CheckHash(filePath, hashes) {
-		log.Errorf("Hash mismatch for downloaded file: %s", filePath)
-		return ErrHashMismatch
+	defer func() { _ = f.Close() }()
+	if _, err := io.CopyN(verifier, f, offset); err != nil {
+		return fmt.Errorf("%w: hashing partial file %s: %w", ErrFileSystem, path, err)
 	}
-
-	log.Infof("Hash verified for %s.", filePath)
 	return nil
 }
 
 // DownloadFile downloads a file from the specified URL to the target filepath.
 // It checks for existing files, verifies hashes, and attempts to use the
-// Content-Disposition header for the filename.
+// Content-Disposition header for the filename. The file is written to
+// targetFilepath+".part" first; a .part file left by an earlier attempt is
+// continued with a Range request when the server supports it. See SetSegments
+// for downloading a file over several connections.
 func (d *Downloader) DownloadFile(targetFilepath string, url string, hashes models.Hashes, modelVersionID int) (string, error) {
+	return d.DownloadFileWithContext(context.Background(), targetFilepath, url, hashes, modelVersionID)
+}
+
+// DownloadFileWithContext behaves like DownloadFile but aborts the transfer when ctx
+// is cancelled. The bytes received so far stay in the .part file and ctx.Err() is
+// returned wrapped.
+func (d *Downloader) DownloadFileWithContext(ctx context.Context, targetFilepath string, url string, hashes models.Hashes, modelVersionID int) (string, error) {
 	// Check for existing file first
-	existingPath, exists, err := d.checkExistingFile(targetFilepath, hashes)
-	if err != nil {
-		return "", err
-	}
-	if exists {
-		return existingPath, nil
+	if !d.overwrite {
+		existingPath, exists, err := d.checkExistingFile(targetFilepath, hashes)
+		if err != nil {
+			return "", err
+		}
+		if exists {
+			return existingPath, nil
+		}
 	}
 
 	// Ensure target directory exists
@@ -367,73 +694,84 @@ func (d *Downloader) DownloadFile(targetFilepath string, url string, hashes mode
 		return "", fmt.Errorf("%w: failed to create target directory %s", ErrFileSystem, targetDir)
 	}
 
-	// Create temporary file
-	baseName := filepath.Base(targetFilepath)
-	tempFile, err := os.CreateTemp(targetDir, baseName+".*.tmp")
+	// Download into <target>.part, which is kept when the transfer fails so
+	// the next attempt can continue it with a Range request
+	partPath := targetFilepath + ".part"
+	// #nosec G304 -- partPath is derived from the internal target path
+	partFile, err := os.OpenFile(helpers.LongPath(partPath), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600) //nolint:gosec
 	if err != nil {
-		return "", fmt.Errorf("%w: creating temporary file %s: %w", ErrFileSystem, targetFilepath, err)
+		return "", fmt.Errorf("%w: opening partial file %s: %w", ErrFileSystem, partPath, err)
+	}
+	var offset int64
+	if info, err := partFile.Stat(); err == nil {
+		offset = info.Size()
 	}
 
-	shouldCleanupTemp := true
+	partDone := false    // Renamed to the final path
+	discardPart := false // Not worth resuming
 	defer func() {
-		if shouldCleanupTemp {
-			log.Debugf("Cleaning up temporary file via defer: %s", tempFile.Name())
-			if removeErr := os.Remove(tempFile.Name()); removeErr != nil {
-				log.WithError(removeErr).Warnf("Failed to remove temporary file %s during defer cleanup", tempFile.Name())
-			}
+		if partDone {
+			return
+		}
+		_ = partFile.Close()
+		if info, err := os.Stat(partFile.Name()); err == nil && info.Size() > 0 && !discardPart {
+			log.Infof("Keeping %s (%s) to resume the download later", partPath, helpers.BytesToSize(uint64(info.Size())))
+			return
+		}
+		log.Debugf("Removing partial file %s", partPath)
+		if removeErr := os.Remove(partFile.Name()); removeErr != nil && !os.IsNotExist(removeErr) {
+			log.WithError(removeErr).Warnf("Failed to remove partial file %s", partPath)
 		}
 	}()
 
 	log.Info("Starting download process...")
 	log.Infof("Attempting to download from URL: %s", url)
 
-	// Create and execute HTTP request
-	req, err := d.createHTTPRequest(url)
+	resp, err := d.doDownloadRequest(ctx, url, offset)
 	if err != nil {
 		return "", err
 	}
-
-	resp, err := d.client.Do(req)
-	if err != nil {
-		log.WithError(err).Errorf("Error performing download request from %s", url)
-		return "", fmt.Errorf("%w: performing request for %s: %v", ErrHttpRequest, url, err)
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && offset > 0 {
+		// The partial file does not fit the file on the server (any more)
+		_ = resp.Body.Close()
+		log.Warnf("Server cannot resume %s at %s, downloading it again", partPath, helpers.BytesToSize(uint64(offset)))
+		if err := truncatePart(partFile); err != nil {
+			return "", err
+		}
+		offset = 0
+		if resp, err = d.doDownloadRequest(ctx, url, 0); err != nil {
+			return "", err
+		}
 	}
 	defer func() { _ = resp.Body.Close() }()
 	// Log final URL after redirects for debugging
 	log.Debugf("Final URL after redirects: %s", resp.Request.URL.String())
 
-	if resp.StatusCode != http.StatusOK {
+	switch {
+	case resp.StatusCode == http.StatusOK && offset > 0:
+		log.Infof("Server does not support resuming, downloading %s from the start", partPath)
+		if err := truncatePart(partFile); err != nil {
+			return "", err
+		}
+		offset = 0
+	case resp.StatusCode == http.StatusPartialContent:
+		if start, ok := contentRangeStart(resp.Header.Get("Content-Range")); !ok || start != offset {
+			discardPart = true
+			return "", fmt.Errorf("%w: resuming %s at %d bytes, got Content-Range %q", ErrHttpStatus, url, offset, resp.Header.Get("Content-Range"))
+		}
+		log.Infof("Resuming %s from %s", partPath, helpers.BytesToSize(uint64(offset)))
+	case resp.StatusCode != http.StatusOK:
 		log.Errorf("Error downloading file: Received status code %d from %s", resp.StatusCode, url)
-		return "", fmt.Errorf("%w: received status %d from %s", ErrHttpStatus, resp.StatusCode, url)
+		return "", &statusError{code: resp.StatusCode, url: url}
 	}
 
-	// Check Content-Type - if we get HTML, it's likely an error page (login required, etc.)
+	// Check Content-Type (and sniff the body) - an HTML or JSON body is an error page
+	// (login required, expired token, etc.), not the model file
 	contentType := resp.Header.Get("Content-Type")
-	if strings.Contains(contentType, "text/html") {
-		// Read a portion to analyze what we got
-		bodyPreview := make([]byte, 2048)
-		n, _ := resp.Body.Read(bodyPreview)
-		bodyStr := string(bodyPreview[:n])
-
-		// Try to detect specific error conditions
-		var errorReason string
-		switch {
-		case strings.Contains(bodyStr, "early access") || strings.Contains(bodyStr, "Early Access"):
-			errorReason = "model is in Early Access (requires Supporter membership)"
-		case strings.Contains(bodyStr, "login") || strings.Contains(bodyStr, "sign in") || strings.Contains(bodyStr, "Sign In"):
-			errorReason = "model requires login (creator has restricted downloads)"
-		case strings.Contains(bodyStr, "not found") || strings.Contains(bodyStr, "404"):
-			errorReason = "model or file not found"
-		case strings.Contains(bodyStr, "unavailable") || strings.Contains(bodyStr, "removed"):
-			errorReason = "model has been removed or is unavailable"
-		default:
-			errorReason = "download restricted or requires browser login"
-		}
-
-		log.Errorf("Received HTML response instead of file: %s", errorReason)
+	if err := checkErrorPage(resp); err != nil {
+		log.Errorf("Received an error page instead of file: %v", err)
 		log.Errorf("Content-Type: %s, Final URL: %s", contentType, resp.Request.URL.String())
-		log.Debugf("Response body preview: %s", bodyStr)
-		return "", fmt.Errorf("%w: %s - URL: %s", ErrHttpStatus, errorReason, url)
+		return "", fmt.Errorf("%w: %v - URL: %s", ErrHttpStatus, err, url)
 	}
 
 	// Check Content-Length - warn if 0 or suspiciously small
@@ -443,34 +781,82 @@ func (d *Downloader) DownloadFile(targetFilepath string, url string, hashes mode
 	}
 
 	// Extract filename from response and construct final path
-	apiFilename := extractFilenameFromResponse(resp)
-	finalFilepath := constructFinalPath(targetFilepath, apiFilename, modelVersionID)
+	finalFilepath := d.finalPath(targetFilepath, extractFilenameFromResponse(resp), modelVersionID)
 
 	// Check if final path already exists
-	existingFinalPath, existsFinal, err := d.checkExistingFile(finalFilepath, hashes)
-	if err != nil {
-		return "", err
+	if !d.overwrite {
+		existingFinalPath, existsFinal, err := d.checkExistingFile(finalFilepath, hashes)
+		if err != nil {
+			return "", err
+		}
+		if existsFinal {
+			discardPart = true
+			return existingFinalPath, nil
+		}
 	}
-	if existsFinal {
-		return existingFinalPath, nil
+
+	verifier := helpers.NewHashVerifier(hashes)
+	if file := fileProgress(ctx); file != nil {
+		file.Store(uint64(offset)) // #nosec G115 -- offset is the size of the partial file
 	}
+	if segments := d.segmentCount(resp, offset); segments > 1 {
+		// The partial file has holes until every segment is done, so it
+		// cannot be resumed; it is hashed once complete
+		discardPart = true
+		_ = partFile.Close()
+		if err := d.downloadSegments(ctx, resp, url, partFile.Name(), segments); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				log.Infof("Download of %s cancelled mid-transfer", finalFilepath)
+				return "", fmt.Errorf("download of %s cancelled: %w", finalFilepath, ctxErr)
+			}
+			return "", err
+		}
+		if verifier != nil {
+			if err := hashPartPrefix(partFile.Name(), resp.ContentLength, verifier); err != nil {
+				return "", err
+			}
+		}
+	} else {
+		// Hash the file while it is written; a resumed download hashes the
+		// bytes it already has first
+		if verifier != nil && offset > 0 {
+			if err := hashPartPrefix(partFile.Name(), offset, verifier); err != nil {
+				return "", err
+			}
+		}
 
-	// Download to temporary file
-	if err := downloadToTemp(resp, tempFile, finalFilepath); err != nil {
-		return "", err
+		// Download to the partial file
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{d.throttle(ctx, resp.Body), resp.Body}
+		if err := downloadToTemp(resp, partFile, finalFilepath, &d.received, fileProgress(ctx), verifier); err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				log.Infof("Download of %s cancelled mid-transfer", finalFilepath)
+				return "", fmt.Errorf("download of %s cancelled: %w", finalFilepath, ctxErr)
+			}
+			return "", err
+		}
 	}
 
-	// Detect MIME type and rename with correct extension
-	finalPath, err := detectMimeAndRename(tempFile.Name(), finalFilepath)
-	if err != nil {
-		return "", err
+	// Verify the streamed hash before the file gets its final name; a
+	// mismatching file is removed with the partial file
+	if verifier == nil {
+		log.Debugf("Skipping hash verification for %s (no expected hashes provided).", finalFilepath)
+	} else if !verifier.Verify(finalFilepath) {
+		log.Errorf("Hash mismatch for downloaded file: %s", finalFilepath)
+		discardPart = true
+		return "", ErrHashMismatch
+	} else {
+		log.Infof("Hash verified for %s.", finalFilepath)
 	}
-	shouldCleanupTemp = false // Success, don't cleanup
 
-	// Verify hash
-	if err := verifyHash(finalPath, hashes); err != nil {
+	// Detect MIME type and rename with correct extension
+	finalPath, err := detectMimeAndRename(partFile.Name(), finalFilepath)
+	if err != nil {
 		return "", err
 	}
+	partDone = true
 
 	log.Infof("Successfully downloaded and verified %s", finalPath)
 	return finalPath, nil
@@ -499,7 +885,7 @@ func (d *Downloader) DownloadImage(targetDir string, imageURL string) (string, e
 		return "", fmt.Errorf("%w: creating image request for %s: %w", ErrHttpRequest, finalURL, err)
 	}
 	// Set User-Agent to avoid 401 errors from Civitai
-	req.Header.Set("User-Agent", UserAgent)
+	req.Header.Set("User-Agent", d.userAgent)
 
 	// Set session cookie if provided
 	if d.sessionCookie != "" {
@@ -536,12 +922,12 @@ func (d *Downloader) DownloadImage(targetDir string, imageURL string) (string, e
 	}
 
 	// Ensure target directory exists
-	if err := os.MkdirAll(targetDir, 0750); err != nil {
+	if err := os.MkdirAll(helpers.LongPath(targetDir), 0750); err != nil {
 		return "", fmt.Errorf("%w: creating target directory %s: %w", ErrFileSystem, targetDir, err)
 	}
 
 	// Create temporary file
-	tempFile, err := os.CreateTemp(targetDir, baseName+".*.tmp")
+	tempFile, err := os.CreateTemp(helpers.LongPath(targetDir), baseName+".*.tmp")
 	if err != nil {
 		return "", fmt.Errorf("%w: creating temporary file for image %s: %w", ErrFileSystem, baseName, err)
 	}
@@ -557,7 +943,7 @@ func (d *Downloader) DownloadImage(targetDir string, imageURL string) (string, e
 	}()
 
 	// Copy the response body to the temp file
-	_, err = io.Copy(tempFile, resp.Body)
+	_, err = io.Copy(tempFile, d.throttle(context.Background(), resp.Body))
 	if err != nil {
 		_ = tempFile.Close()
 		return "", fmt.Errorf("writing to temporary image file %s: %w", tempFile.Name(), err)
@@ -575,7 +961,7 @@ func (d *Downloader) DownloadImage(targetDir string, imageURL string) (string, e
 		if err != nil {
 			log.WithError(err).Warnf("MIME detection/rename failed for image %s. Falling back to URL-derived filename.", imageURL)
 			// Fallback: move temp file to original path
-			if renameErr := os.Rename(tempFile.Name(), finalPath); renameErr != nil {
+			if renameErr := os.Rename(tempFile.Name(), helpers.LongPath(finalPath)); renameErr != nil {
 				return "", fmt.Errorf("%w: fallback rename failed for %s: %w", ErrFileSystem, finalPath, renameErr)
 			}
 			shouldCleanupTemp = false
@@ -587,7 +973,7 @@ func (d *Downloader) DownloadImage(targetDir string, imageURL string) (string, e
 	}
 
 	// MIME detection disabled: move temp file to original path
-	if err := os.Rename(tempFile.Name(), finalPath); err != nil {
+	if err := os.Rename(tempFile.Name(), helpers.LongPath(finalPath)); err != nil {
 		return "", fmt.Errorf("%w: rename failed for %s: %w", ErrFileSystem, finalPath, err)
 	}
 	shouldCleanupTemp = false
@@ -3,6 +3,7 @@ package downloader
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -54,6 +55,30 @@ func TestNewDownloader_NilClient(t *testing.T) {
 	}
 }
 
+// TestDownloadFile_SessionCookie tests that the session cookie is sent, with
+// the cookie name added to a bare token
+func TestDownloadFile_SessionCookie(t *testing.T) {
+	testData := []byte("login required content")
+	for _, cookie := range []string{"abc123", "__Secure-civitai-token=abc123"} {
+		var gotCookie string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotCookie = r.Header.Get("Cookie")
+			w.Header().Set("Content-Type", "application/octet-stream")
+			w.Write(testData)
+		}))
+
+		downloader := NewDownloader(&http.Client{Timeout: 30 * time.Second}, "", cookie)
+		_, err := downloader.DownloadFile(filepath.Join(t.TempDir(), "test-file.bin"), server.URL, models.Hashes{}, 1)
+		server.Close()
+		if err != nil {
+			t.Fatalf("DownloadFile failed: %v", err)
+		}
+		if gotCookie != "__Secure-civitai-token=abc123" {
+			t.Errorf("SessionCookie %q: expected cookie header __Secure-civitai-token=abc123, got %q", cookie, gotCookie)
+		}
+	}
+}
+
 // TestDownloadFile_Success tests successful file download
 func TestDownloadFile_Success(t *testing.T) {
 	// Create test data
@@ -137,6 +162,12 @@ func TestDownloadFile_HashMismatch(t *testing.T) {
 	if !strings.Contains(err.Error(), "hash") && !strings.Contains(err.Error(), "mismatch") {
 		t.Errorf("Expected error to mention hash mismatch, got: %v", err)
 	}
+
+	// Neither the file nor its partial download is left behind
+	entries, _ := os.ReadDir(tempDir)
+	if len(entries) != 0 {
+		t.Errorf("Expected the mismatching file to be removed, found %d file(s)", len(entries))
+	}
 }
 
 // TestDownloadFile_NetworkError tests network error handling
@@ -164,6 +195,15 @@ func TestDownloadFile_NetworkError(t *testing.T) {
 	if err == nil {
 		t.Error("Expected DownloadFile to fail with network error")
 	}
+	if !errors.Is(err, ErrHttpStatus) {
+		t.Errorf("Expected ErrHttpStatus, got %v", err)
+	}
+	if code := StatusCode(err); code != http.StatusInternalServerError {
+		t.Errorf("Expected StatusCode 500, got %d", code)
+	}
+	if code := StatusCode(errors.New("other")); code != 0 {
+		t.Errorf("Expected StatusCode 0 for other errors, got %d", code)
+	}
 }
 
 // TestDownloadFile_Timeout tests download timeout handling
@@ -264,6 +304,147 @@ func TestDownloadFile_Progress(t *testing.T) {
 		t.Errorf("Downloaded file size mismatch. Expected %d bytes, got %d bytes",
 			len(testData), len(downloadedContent))
 	}
+
+	if got := downloader.BytesReceived(); got != uint64(len(testData)) {
+		t.Errorf("BytesReceived() = %d, want %d", got, len(testData))
+	}
+}
+
+// TestDownloadFile_ResumePartial tests that an existing .part file is continued
+// with a Range request, or downloaded again when the server ignores the range
+func TestDownloadFile_ResumePartial(t *testing.T) {
+	testData := []byte(strings.Repeat("resumable model weights ", 100))
+	hash := blake3.Sum256(testData)
+	hashes := models.Hashes{BLAKE3: hex.EncodeToString(hash[:])}
+
+	tests := []struct {
+		name          string
+		supportsRange bool
+		partial       []byte
+		expectRange   string
+	}{
+		{name: "server resumes", supportsRange: true, partial: testData[:1000], expectRange: "bytes=1000-"},
+		{name: "server ignores range", supportsRange: false, partial: testData[:1000], expectRange: "bytes=1000-"},
+		{name: "stale partial is replaced", supportsRange: false, partial: []byte("garbage"), expectRange: "bytes=7-"},
+		{name: "no partial", supportsRange: true, expectRange: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotRange string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotRange = r.Header.Get("Range")
+				w.Header().Set("Content-Type", "application/octet-stream")
+				if tt.supportsRange {
+					http.ServeContent(w, r, "model.bin", time.Time{}, strings.NewReader(string(testData)))
+					return
+				}
+				w.Write(testData)
+			}))
+			defer server.Close()
+
+			targetPath := filepath.Join(t.TempDir(), "model.bin")
+			if tt.partial != nil {
+				if err := os.WriteFile(targetPath+".part", tt.partial, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			downloader := NewDownloader(&http.Client{Timeout: 30 * time.Second}, "", "")
+			finalPath, err := downloader.DownloadFile(targetPath, server.URL, hashes, 0)
+			if err != nil {
+				t.Fatalf("DownloadFile failed: %v", err)
+			}
+			if gotRange != tt.expectRange {
+				t.Errorf("Range header = %q, want %q", gotRange, tt.expectRange)
+			}
+			content, err := os.ReadFile(finalPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != string(testData) {
+				t.Errorf("downloaded %d bytes, want the %d bytes of the file", len(content), len(testData))
+			}
+			if _, err := os.Stat(targetPath + ".part"); !os.IsNotExist(err) {
+				t.Errorf("partial file should be gone after the download, stat error: %v", err)
+			}
+		})
+	}
+}
+
+// TestDownloadFile_KeepsPartialOnFailure tests that bytes received before a
+// transfer breaks off are kept in the .part file for the next attempt
+func TestDownloadFile_KeepsPartialOnFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", "4096")
+		w.Write(make([]byte, 1024))
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		// Drop the connection before the promised length was sent
+		if hj, ok := w.(http.Hijacker); ok {
+			conn, _, _ := hj.Hijack()
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+
+	targetPath := filepath.Join(t.TempDir(), "model.bin")
+	downloader := NewDownloader(&http.Client{Timeout: 30 * time.Second}, "", "")
+	if _, err := downloader.DownloadFile(targetPath, server.URL, models.Hashes{}, 0); err == nil {
+		t.Fatal("expected an error for the broken transfer")
+	}
+
+	info, err := os.Stat(targetPath + ".part")
+	if err != nil {
+		t.Fatalf("partial file should be kept: %v", err)
+	}
+	if info.Size() != 1024 {
+		t.Errorf("partial file has %d bytes, want 1024", info.Size())
+	}
+}
+
+// TestDownloadFile_RateLimited tests that a rate limiter slows the transfer down
+// while the received bytes keep growing, as the progress display needs
+func TestDownloadFile_RateLimited(t *testing.T) {
+	testData := make([]byte, 60*1024)
+	for i := range testData {
+		testData[i] = byte(i % 256)
+	}
+	hash := blake3.Sum256(testData)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", fmt.Sprintf("%d", len(testData)))
+		w.Write(testData)
+	}))
+	defer server.Close()
+
+	downloader := NewDownloader(&http.Client{Timeout: 30 * time.Second}, "", "")
+	// 40KB/s: the first 40KB pass at once, the other 20KB take half a second
+	downloader.SetRateLimiter(NewRateLimiter(40 * 1024))
+
+	midway := make(chan uint64, 1)
+	go func() {
+		time.Sleep(250 * time.Millisecond)
+		midway <- downloader.BytesReceived()
+	}()
+
+	start := time.Now()
+	targetPath := filepath.Join(t.TempDir(), "throttled.bin")
+	if _, err := downloader.DownloadFile(targetPath, server.URL, models.Hashes{BLAKE3: hex.EncodeToString(hash[:])}, 1); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("throttled download took %v, want at least 400ms", elapsed)
+	}
+	if got := <-midway; got == 0 || got >= uint64(len(testData)) {
+		t.Errorf("BytesReceived() midway = %d, want progress between 0 and %d", got, len(testData))
+	}
+
+	if NewRateLimiter(0) != nil {
+		t.Error("NewRateLimiter(0) should be nil (unlimited)")
+	}
 }
 
 // TestDownloadFile_Authentication tests that API key is used in requests via token query parameter
@@ -306,6 +487,167 @@ func TestDownloadFile_Authentication(t *testing.T) {
 	}
 }
 
+// TestDownloadFile_UserAgent tests that downloads send the default or configured User-Agent
+func TestDownloadFile_UserAgent(t *testing.T) {
+	var receivedAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedAgent = r.Header.Get("User-Agent")
+		w.Write([]byte("test content"))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	downloader := NewDownloader(&http.Client{Timeout: 30 * time.Second}, "", "")
+
+	if _, err := downloader.DownloadFile(filepath.Join(tempDir, "default.bin"), server.URL, models.Hashes{}, 1); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if receivedAgent != models.DefaultUserAgent() {
+		t.Errorf("Expected default User-Agent %q, got %q", models.DefaultUserAgent(), receivedAgent)
+	}
+
+	downloader.SetUserAgent("my-archiver/2.0")
+	if _, err := downloader.DownloadFile(filepath.Join(tempDir, "custom.bin"), server.URL, models.Hashes{}, 2); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if receivedAgent != "my-archiver/2.0" {
+		t.Errorf("Expected User-Agent 'my-archiver/2.0', got %q", receivedAgent)
+	}
+}
+
+func TestDownloadFile_Overwrite(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Write([]byte("fresh content"))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	targetPath := filepath.Join(tempDir, "model.bin")
+	if err := os.WriteFile(targetPath, []byte("stale content"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	downloader := NewDownloader(&http.Client{Timeout: 30 * time.Second}, "", "")
+
+	// Without hashes a file with the same name counts as already downloaded
+	if _, err := downloader.DownloadFile(targetPath, server.URL, models.Hashes{}, 1); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if requests != 0 {
+		t.Fatalf("Expected the existing file to be kept, got %d request(s)", requests)
+	}
+
+	downloader.SetOverwrite(true)
+	finalPath, err := downloader.DownloadFile(targetPath, server.URL, models.Hashes{}, 1)
+	if err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if requests != 1 {
+		t.Fatalf("Expected one request with overwrite set, got %d", requests)
+	}
+	content, err := os.ReadFile(finalPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "fresh content" {
+		t.Errorf("Expected the file to be replaced, got %q", content)
+	}
+}
+
+// TestDownloadFile_ErrorPages tests that HTML and JSON error bodies are not saved as model files
+func TestDownloadFile_ErrorPages(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     string
+	}{
+		{"html as text/plain", "text/plain", "<!DOCTYPE html><html><body>Please sign in</body></html>", "HTML"},
+		{"html without content type", "", "  <html><body>login</body></html>", "HTML"},
+		{"json error", "application/json", `{"error":"Unauthorized"}`, "JSON error"},
+		{"json error as text/plain", "text/plain", `{"message":"Token expired"}`, "JSON error"},
+		{"indented json error", "application/json", "{\n  \"error\": \"Unauthorized\"\n}", "JSON error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Header().Set("Content-Disposition", "attachment; filename=model.safetensors")
+				w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			tempDir := t.TempDir()
+			targetPath := filepath.Join(tempDir, "model.safetensors")
+			downloader := NewDownloader(&http.Client{Timeout: 30 * time.Second}, "test-key", "")
+
+			_, err := downloader.DownloadFile(targetPath, server.URL, models.Hashes{}, 12345)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Expected %s error, got: %v", tt.wantErr, err)
+			}
+
+			entries, _ := os.ReadDir(tempDir)
+			if len(entries) != 0 {
+				t.Errorf("Expected no files to be saved, found %d", len(entries))
+			}
+		})
+	}
+}
+
+// TestDownloadFile_JSONFile tests that a .json file served as application/json is saved
+func TestDownloadFile_JSONFile(t *testing.T) {
+	testData := []byte(`{"_class_name": "AutoencoderKL", "latent_channels": 4, "sample_size": 1024}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", "attachment; filename=config.json")
+		w.Write(testData)
+	}))
+	defer server.Close()
+
+	targetPath := filepath.Join(t.TempDir(), "config.json")
+	downloader := NewDownloader(&http.Client{Timeout: 30 * time.Second}, "test-key", "")
+
+	finalPath, err := downloader.DownloadFile(targetPath, server.URL, models.Hashes{}, 12345)
+	if err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	content, err := os.ReadFile(finalPath)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded file: %v", err)
+	}
+	if string(content) != string(testData) {
+		t.Errorf("Downloaded content doesn't match. Expected %s, got %s", testData, content)
+	}
+}
+
+// TestDownloadFile_OctetStreamNotSniffed tests that binary downloads are not inspected
+func TestDownloadFile_OctetStreamNotSniffed(t *testing.T) {
+	testData := []byte(`{"error" is unusual for a file header but allowed for octet-stream}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", "attachment; filename=test-file.bin")
+		w.Write(testData)
+	}))
+	defer server.Close()
+
+	targetPath := filepath.Join(t.TempDir(), "test-file.bin")
+	downloader := NewDownloader(&http.Client{Timeout: 30 * time.Second}, "test-key", "")
+
+	finalPath, err := downloader.DownloadFile(targetPath, server.URL, models.Hashes{}, 12345)
+	if err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	content, err := os.ReadFile(finalPath)
+	if err != nil {
+		t.Fatalf("Failed to read downloaded file: %v", err)
+	}
+	if string(content) != string(testData) {
+		t.Errorf("Downloaded content doesn't match. Expected %s, got %s", testData, content)
+	}
+}
+
 // TestDownloadFile_FileNaming tests file naming and path construction
 func TestDownloadFile_FileNaming(t *testing.T) {
 	testData := []byte("test file content")
@@ -346,6 +688,35 @@ func TestDownloadFile_FileNaming(t *testing.T) {
 	}
 }
 
+func TestDownloadFile_KeepFileName(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Disposition", "attachment; filename=server-provided-name.txt")
+		w.Write([]byte("test file content"))
+	}))
+	defer server.Close()
+
+	tempDir := t.TempDir()
+	downloader := NewDownloader(&http.Client{Timeout: 30 * time.Second}, "test-key", "")
+	downloader.SetKeepFileName(true)
+
+	finalPath, err := downloader.DownloadFile(filepath.Join(tempDir, "original-name.bin"), server.URL, models.Hashes{}, 12345)
+	if err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if want := filepath.Join(tempDir, "12345_original-name.bin"); finalPath != want {
+		t.Errorf("Expected %s, got %s", want, finalPath)
+	}
+
+	// A name that already carries the version ID is used as is
+	finalPath, err = downloader.DownloadFile(filepath.Join(tempDir, "12345_other.bin"), server.URL, models.Hashes{}, 12345)
+	if err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if want := filepath.Join(tempDir, "12345_other.bin"); finalPath != want {
+		t.Errorf("Expected %s, got %s", want, finalPath)
+	}
+}
+
 // TestDownloadImage_MimeDetection tests that DownloadImage detects the actual
 // MIME type and renames the file with the correct extension.
 func TestDownloadImage_MimeDetection(t *testing.T) {
@@ -0,0 +1,139 @@
+package downloader
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go-civitai-download/internal/models"
+
+	"lukechampine.com/blake3"
+)
+
+// TestDownloadFile_Segments tests that a file is downloaded with several
+// Range requests when the server accepts them, and in one stream otherwise.
+func TestDownloadFile_Segments(t *testing.T) {
+	defer func(size int64) { minSegmentSize = size }(minSegmentSize)
+	minSegmentSize = 1000
+
+	testData := []byte(strings.Repeat("segmented model weights ", 210)) // 5040 bytes
+	hash := blake3.Sum256(testData)
+	hashes := models.Hashes{BLAKE3: hex.EncodeToString(hash[:])}
+
+	tests := []struct {
+		name         string
+		acceptRanges bool
+		segments     int
+		expectRanges []string
+	}{
+		{name: "four segments", acceptRanges: true, segments: 4, expectRanges: []string{"", "bytes=1260-2519", "bytes=2520-3779", "bytes=3780-5039"}},
+		{name: "capped by size", acceptRanges: true, segments: 8, expectRanges: []string{"", "bytes=1008-2015", "bytes=2016-3023", "bytes=3024-4031", "bytes=4032-5039"}},
+		{name: "no range support", acceptRanges: false, segments: 4, expectRanges: []string{""}},
+		{name: "single segment", acceptRanges: true, segments: 1, expectRanges: []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var gotRanges []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				gotRanges = append(gotRanges, r.Header.Get("Range"))
+				mu.Unlock()
+				w.Header().Set("Content-Type", "application/octet-stream")
+				if tt.acceptRanges {
+					http.ServeContent(w, r, "model.bin", time.Time{}, strings.NewReader(string(testData)))
+					return
+				}
+				w.Write(testData)
+			}))
+			defer server.Close()
+
+			targetPath := filepath.Join(t.TempDir(), "model.bin")
+			downloader := NewDownloader(&http.Client{Timeout: 30 * time.Second}, "", "")
+			downloader.SetSegments(tt.segments)
+			var fileReceived atomic.Uint64
+			finalPath, err := downloader.DownloadFileWithContext(WithFileProgress(context.Background(), &fileReceived), targetPath, server.URL, hashes, 0)
+			if err != nil {
+				t.Fatalf("DownloadFile failed: %v", err)
+			}
+
+			sort.Strings(gotRanges)
+			if strings.Join(gotRanges, ",") != strings.Join(tt.expectRanges, ",") {
+				t.Errorf("Range headers = %q, want %q", gotRanges, tt.expectRanges)
+			}
+			content, err := os.ReadFile(finalPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != string(testData) {
+				t.Errorf("downloaded %d bytes that differ from the %d bytes of the file", len(content), len(testData))
+			}
+			if got := fileReceived.Load(); got != uint64(len(testData)) {
+				t.Errorf("file progress = %d, want %d", got, len(testData))
+			}
+			if got := downloader.BytesReceived(); got != uint64(len(testData)) {
+				t.Errorf("BytesReceived() = %d, want %d", got, len(testData))
+			}
+		})
+	}
+}
+
+// TestDownloadFile_SegmentFailure tests that a failed segment or a hash
+// mismatch of the assembled file fails the download and removes the partial
+// file, which cannot be resumed.
+func TestDownloadFile_SegmentFailure(t *testing.T) {
+	defer func(size int64) { minSegmentSize = size }(minSegmentSize)
+	minSegmentSize = 1000
+
+	testData := []byte(strings.Repeat("segmented model weights ", 210))
+	hash := blake3.Sum256(testData)
+
+	tests := []struct {
+		name      string
+		hashes    models.Hashes
+		failRange bool
+		wantErr   error
+	}{
+		{name: "segment refused", hashes: models.Hashes{BLAKE3: hex.EncodeToString(hash[:])}, failRange: true, wantErr: ErrHttpStatus},
+		{name: "hash mismatch", hashes: models.Hashes{BLAKE3: strings.Repeat("0", 64)}, wantErr: ErrHashMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.failRange && r.Header.Get("Range") == "bytes=2520-3779" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				w.Header().Set("Content-Type", "application/octet-stream")
+				http.ServeContent(w, r, "model.bin", time.Time{}, strings.NewReader(string(testData)))
+			}))
+			defer server.Close()
+
+			targetPath := filepath.Join(t.TempDir(), "model.bin")
+			downloader := NewDownloader(&http.Client{Timeout: 30 * time.Second}, "", "")
+			downloader.SetSegments(4)
+			_, err := downloader.DownloadFile(targetPath, server.URL, tt.hashes, 0)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("DownloadFile error = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := os.Stat(targetPath + ".part"); !os.IsNotExist(err) {
+				t.Errorf("partial file should be removed, stat error: %v", err)
+			}
+			if _, err := os.Stat(targetPath); !os.IsNotExist(err) {
+				t.Errorf("no file should be saved, stat error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// IsDiskFull reports whether err comes from a write that failed because the
+// disk is full (ENOSPC, or ERROR_DISK_FULL on Windows).
+func IsDiskFull(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, errno := range diskFullErrnos {
+		if errors.Is(err, errno) {
+			return true
+		}
+	}
+	return false
+}
+
+// FreeSpace returns the bytes available to this user on the filesystem
+// holding path, and whether they could be determined. A path that does not
+// exist yet is looked up through its nearest existing parent directory.
+func FreeSpace(path string) (uint64, bool) {
+	dir, err := filepath.Abs(path)
+	if err != nil {
+		return 0, false
+	}
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return freeSpace(dir)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return 0, false
+		}
+		dir = parent
+	}
+}
@@ -0,0 +1,41 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+)
+
+func TestIsDiskFull(t *testing.T) {
+	writeErr := &fs.PathError{Op: "write", Path: "model.safetensors.tmp", Err: syscall.ENOSPC}
+	if !IsDiskFull(fmt.Errorf("writing to temporary file: %w", writeErr)) {
+		t.Error("wrapped ENOSPC not detected")
+	}
+	if IsDiskFull(errors.New("no space left on device")) {
+		t.Error("an error without errno must not count as disk full")
+	}
+	if IsDiskFull(&fs.PathError{Op: "open", Path: "x", Err: syscall.ENOENT}) {
+		t.Error("ENOENT detected as disk full")
+	}
+	if IsDiskFull(nil) {
+		t.Error("nil detected as disk full")
+	}
+}
+
+func TestFreeSpace(t *testing.T) {
+	if runtime.GOOS != "linux" && runtime.GOOS != "darwin" && runtime.GOOS != "windows" {
+		t.Skip("free space is not reported on " + runtime.GOOS)
+	}
+	// Paths that do not exist yet use their nearest existing parent
+	free, ok := FreeSpace(filepath.Join(t.TempDir(), "not", "created", "yet"))
+	if !ok {
+		t.Fatal("FreeSpace failed")
+	}
+	if free == 0 {
+		t.Error("FreeSpace reported 0 bytes free")
+	}
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package helpers
+
+import "syscall"
+
+var diskFullErrnos = []syscall.Errno{syscall.ENOSPC}
+
+func freeSpace(dir string) (uint64, bool) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, false
+	}
+	return uint64(stat.Bavail) * uint64(stat.Bsize), true // #nosec G115 -- block counts and sizes are never negative
+}
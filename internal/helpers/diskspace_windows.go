@@ -0,0 +1,23 @@
+//go:build windows
+
+package helpers
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// ERROR_HANDLE_DISK_FULL and ERROR_DISK_FULL
+var diskFullErrnos = []syscall.Errno{syscall.ENOSPC, 39, 112}
+
+var procGetDiskFreeSpaceExW = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+func freeSpace(dir string) (uint64, bool) {
+	path, err := syscall.UTF16PtrFromString(dir)
+	if err != nil {
+		return 0, false
+	}
+	var available uint64
+	ok, _, _ := procGetDiskFreeSpaceExW.Call(uintptr(unsafe.Pointer(path)), uintptr(unsafe.Pointer(&available)), 0, 0) // #nosec G103 -- required by the Windows API
+	return available, ok != 0
+}
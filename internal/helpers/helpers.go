@@ -3,6 +3,8 @@ package helpers
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"hash"
 	"hash/crc32"
@@ -12,7 +14,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"go-civitai-download/internal/models" // Import the models package
 
@@ -117,6 +122,162 @@ func CheckHash(filePath string, hashes models.Hashes) bool {
 	return false
 }
 
+// Hash algorithm names accepted by CheckHashWithAlgo.
+const (
+	HashAlgoSHA256 = "sha256"
+	HashAlgoBLAKE3 = "blake3"
+	HashAlgoCRC32  = "crc32"
+	HashAlgoAutoV2 = "autov2"
+)
+
+// ErrHashUnavailable is returned by CheckHashWithAlgo when the requested
+// algorithm has no expected value to compare against.
+var ErrHashUnavailable = errors.New("hash not available")
+
+// ParseHashAlgo normalizes a user-supplied hash algorithm name.
+// An empty string is accepted and means "check whatever hashes are present".
+func ParseHashAlgo(algo string) (string, error) {
+	algo = strings.ToLower(strings.TrimSpace(algo))
+	switch algo {
+	case "", HashAlgoSHA256, HashAlgoBLAKE3, HashAlgoCRC32, HashAlgoAutoV2:
+		return algo, nil
+	default:
+		return "", fmt.Errorf("unsupported hash algorithm %q (expected sha256, blake3, crc32 or autov2)", algo)
+	}
+}
+
+// CheckHashWithAlgo verifies a file using only the given algorithm.
+// An empty algo falls back to CheckHash. It returns ErrHashUnavailable if
+// hashes has no value for the chosen algorithm.
+func CheckHashWithAlgo(filePath string, hashes models.Hashes, algo string) (bool, error) {
+	var expected string
+	var hasher hash.Hash
+	switch algo {
+	case "":
+		return CheckHash(filePath, hashes), nil
+	case HashAlgoSHA256:
+		expected, hasher = hashes.SHA256, sha256.New()
+	case HashAlgoBLAKE3:
+		expected, hasher = hashes.BLAKE3, blake3.New()
+	case HashAlgoCRC32:
+		expected, hasher = hashes.CRC32, crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case HashAlgoAutoV2:
+		expected, hasher = hashes.AutoV2, sha256.New()
+	default:
+		return false, fmt.Errorf("unsupported hash algorithm %q", algo)
+	}
+
+	if expected == "" {
+		return false, fmt.Errorf("%w: no %s hash recorded for %s", ErrHashUnavailable, algo, filePath)
+	}
+
+	calculatedHash, err := calculateHash(filePath, hasher)
+	if err != nil {
+		return false, err
+	}
+	if algo == HashAlgoAutoV2 && len(calculatedHash) >= 10 {
+		// AutoV2 is the first 10 characters of the SHA256
+		calculatedHash = calculatedHash[:10]
+	}
+
+	if !strings.EqualFold(calculatedHash, expected) {
+		log.Warnf("%s mismatch for %s: Expected %s, Got %s", strings.ToUpper(algo), filePath, expected, calculatedHash)
+		return false, nil
+	}
+	log.Debugf("%s match for %s", strings.ToUpper(algo), filePath)
+	return true, nil
+}
+
+// FileSHA256 returns the lowercase hex SHA256 of a file.
+func FileSHA256(filePath string) (string, error) {
+	return calculateHash(filePath, sha256.New())
+}
+
+// HashVerifier computes the hashes that have an expected value in hashes from
+// the bytes written to it, so a download can be verified as it streams to disk
+// instead of reading the file back. BLAKE3, SHA256 (also used for AutoV2) and
+// CRC32 are only computed when expected.
+type HashVerifier struct {
+	expected models.Hashes
+	blake3   hash.Hash
+	sha256   hash.Hash
+	crc32    hash.Hash
+	writers  []io.Writer
+}
+
+// NewHashVerifier returns a HashVerifier for hashes, or nil when hashes has no
+// expected values.
+func NewHashVerifier(hashes models.Hashes) *HashVerifier {
+	v := &HashVerifier{expected: hashes}
+	if hashes.BLAKE3 != "" {
+		v.blake3 = blake3.New()
+		v.writers = append(v.writers, v.blake3)
+	}
+	if hashes.SHA256 != "" || hashes.AutoV2 != "" {
+		v.sha256 = sha256.New()
+		v.writers = append(v.writers, v.sha256)
+	}
+	if hashes.CRC32 != "" {
+		v.crc32 = crc32.New(crc32.MakeTable(crc32.Castagnoli))
+		v.writers = append(v.writers, v.crc32)
+	}
+	if len(v.writers) == 0 {
+		return nil
+	}
+	return v
+}
+
+// Write implements io.Writer; hash writes never fail.
+func (v *HashVerifier) Write(p []byte) (int, error) {
+	for _, w := range v.writers {
+		_, _ = w.Write(p)
+	}
+	return len(p), nil
+}
+
+// Verify reports whether any of the expected hashes matches the bytes written
+// so far, checking in the same order as CheckHash. name is only used in logs.
+func (v *HashVerifier) Verify(name string) bool {
+	sum := func(h hash.Hash) string { return hex.EncodeToString(h.Sum(nil)) }
+	if v.blake3 != nil {
+		calculated := sum(v.blake3)
+		if strings.EqualFold(calculated, v.expected.BLAKE3) {
+			log.Debugf("BLAKE3 match for %s", name)
+			return true
+		}
+		log.Warnf("BLAKE3 mismatch for %s: Expected %s, Got %s", name, v.expected.BLAKE3, calculated)
+	}
+	var calculatedSha256 string
+	if v.sha256 != nil {
+		calculatedSha256 = sum(v.sha256)
+	}
+	if v.expected.SHA256 != "" {
+		if strings.EqualFold(calculatedSha256, v.expected.SHA256) {
+			log.Debugf("SHA256 match for %s", name)
+			return true
+		}
+		log.Warnf("SHA256 mismatch for %s: Expected %s, Got %s", name, v.expected.SHA256, calculatedSha256)
+	}
+	if v.crc32 != nil {
+		calculated := sum(v.crc32)
+		if strings.EqualFold(calculated, v.expected.CRC32) {
+			log.Debugf("CRC32 match for %s", name)
+			return true
+		}
+		log.Warnf("CRC32 mismatch for %s: Expected %s, Got %s", name, v.expected.CRC32, calculated)
+	}
+	if v.expected.AutoV2 != "" {
+		if strings.EqualFold(calculatedSha256[:10], v.expected.AutoV2) {
+			log.Debugf("AutoV2 match for %s", name)
+			return true
+		}
+		log.Warnf("AutoV2 mismatch for %s: Expected %s, Got %s (derived from SHA256: %s)", name, v.expected.AutoV2, calculatedSha256[:10], calculatedSha256)
+	}
+
+	log.Warnf("No matching hash found for %s after checking all provided types.", name)
+	return false
+}
+
 // CounterWriter tracks the number of bytes written to the underlying writer.
 // It's used to display download progress.
 // Note: Consider moving this to the 'downloader' package later.
@@ -150,6 +311,54 @@ func BytesToSize(bytes uint64) string {
 	return fmt.Sprintf("%.2f%s", float64(bytes)/math.Pow(1024, float64(i)), sizes[i])
 }
 
+// byteSizeUnits maps the unit suffixes accepted by ParseByteSize to their
+// multipliers, using the same 1024 steps as BytesToSize.
+var byteSizeUnits = map[string]float64{
+	"":    1,
+	"B":   1,
+	"K":   1 << 10,
+	"KB":  1 << 10,
+	"KIB": 1 << 10,
+	"M":   1 << 20,
+	"MB":  1 << 20,
+	"MIB": 1 << 20,
+	"G":   1 << 30,
+	"GB":  1 << 30,
+	"GIB": 1 << 30,
+}
+
+// ParseByteSize parses a size such as "2MB", "500k" or "1.5GiB" into bytes. A
+// plain number is taken as bytes, and a trailing "/s" is ignored so rates can
+// be written as "2MB/s". Units are case-insensitive.
+func ParseByteSize(s string) (uint64, error) {
+	str := strings.ToUpper(strings.TrimSpace(s))
+	str = strings.TrimSuffix(str, "/S")
+	numEnd := strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if numEnd == -1 {
+		numEnd = len(str)
+	}
+	multiplier, ok := byteSizeUnits[strings.TrimSpace(str[numEnd:])]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit, use B, KB, MB or GB", s)
+	}
+	value, err := strconv.ParseFloat(str[:numEnd], 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q: expected a number with an optional unit such as 2MB", s)
+	}
+	return uint64(value * multiplier), nil
+}
+
+// TransferRate returns the average throughput for bytes moved in d as MB/s
+// (1 MB = 1024*1024 bytes). It returns 0 for a zero or negative duration.
+func TransferRate(bytes uint64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(bytes) / (1024 * 1024) / d.Seconds()
+}
+
 // ConvertToSlug converts a string into a filesystem-friendly slug.
 func ConvertToSlug(str string) string {
 	str = strings.ReplaceAll(str, " ", "_")
@@ -186,7 +395,7 @@ func ConvertToSlug(str string) string {
 // Uses standard directory permissions (0700).
 func CheckAndMakeDir(dir string) bool {
 	// Use MkdirAll to create parent directories if they don't exist
-	err := os.MkdirAll(SanitizePath(dir), 0700)
+	err := os.MkdirAll(LongPath(SanitizePath(dir)), 0700)
 	if err != nil {
 		log.WithError(err).Errorf("Error creating directory %s", dir) // Use logrus
 		return false
@@ -242,7 +451,7 @@ func DetectImageTypeFromMagicBytes(data []byte) string {
 // -- Hashing Helper --
 func calculateHash(filePath string, hashAlgo hash.Hash) (string, error) {
 	// #nosec G304 -- filePath is internal, not user input
-	file, err := os.Open(filePath) //nolint:gosec
+	file, err := os.Open(LongPath(filePath)) //nolint:gosec
 	if err != nil {
 		return "", fmt.Errorf("opening file %s for hashing: %w", filePath, err)
 	}
@@ -272,6 +481,15 @@ func GetExtensionFromMimeType(mimeType string) (string, bool) {
 	return ext, ok
 }
 
+// MarshalMetadata encodes v for a metadata or info file on disk, indented for
+// readability unless compact is set (JSONCompact).
+func MarshalMetadata(v interface{}, compact bool) ([]byte, error) {
+	if compact {
+		return json.Marshal(v)
+	}
+	return json.MarshalIndent(v, "", "  ")
+}
+
 // StringSliceContains checks if a string slice contains a specific item (case-insensitive).
 func StringSliceContains(slice []string, item string) bool {
 	for _, s := range slice {
@@ -310,3 +528,35 @@ func SanitizePath(path string) string {
 
 	return safePath
 }
+
+// windowsMaxPath is the longest path the classic Windows API accepts (MAX_PATH
+// is 260 including the terminator). Directories are limited to 248 so that an
+// 8.3 file name still fits, which is why the check uses the lower value.
+const windowsMaxPath = 248
+
+// LongPath returns path in the form the filesystem calls should use. On Windows,
+// paths at or beyond MAX_PATH are made absolute and given the \\?\ prefix so the
+// extended-length API is used; deep path patterns with long model names
+// otherwise fail with "The system cannot find the path specified". Elsewhere,
+// and for short paths, path is returned unchanged.
+func LongPath(path string) string {
+	if runtime.GOOS != "windows" {
+		return path
+	}
+	return windowsLongPath(path)
+}
+
+func windowsLongPath(path string) string {
+	if strings.HasPrefix(path, `\\?\`) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || len(abs) < windowsMaxPath {
+		return path
+	}
+	if strings.HasPrefix(abs, `\\`) {
+		// UNC share: \\server\share\... becomes \\?\UNC\server\share\...
+		return `\\?\UNC\` + abs[2:]
+	}
+	return `\\?\` + abs
+}
@@ -2,9 +2,14 @@ package helpers
 
 import (
 	"bytes"
+	"errors"
+	"math"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"go-civitai-download/internal/models"
 )
@@ -140,6 +145,66 @@ func TestBytesToSize(t *testing.T) {
 	}
 }
 
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected uint64
+		wantErr  bool
+	}{
+		{input: "0", expected: 0},
+		{input: "1024", expected: 1024},
+		{input: "500k", expected: 500 * 1024},
+		{input: "2MB", expected: 2 * 1024 * 1024},
+		{input: "2 mb/s", expected: 2 * 1024 * 1024},
+		{input: "1.5GiB", expected: 1536 * 1024 * 1024},
+		{input: "", wantErr: true},
+		{input: "fast", wantErr: true},
+		{input: "2TB", wantErr: true},
+		{input: "-1MB", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseByteSize(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseByteSize(%q) = %d, want an error", tt.input, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseByteSize(%q) returned error: %v", tt.input, err)
+			}
+			if got != tt.expected {
+				t.Errorf("ParseByteSize(%q) = %d, want %d", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTransferRate(t *testing.T) {
+	tests := []struct {
+		name     string
+		bytes    uint64
+		duration time.Duration
+		expected float64
+	}{
+		{name: "one MB in one second", bytes: 1024 * 1024, duration: time.Second, expected: 1},
+		{name: "ten MB in two seconds", bytes: 10 * 1024 * 1024, duration: 2 * time.Second, expected: 5},
+		{name: "zero duration", bytes: 1024, duration: 0, expected: 0},
+		{name: "zero bytes", bytes: 0, duration: time.Second, expected: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TransferRate(tt.bytes, tt.duration)
+			if math.Abs(got-tt.expected) > 1e-9 {
+				t.Errorf("TransferRate(%d, %v) = %f, want %f", tt.bytes, tt.duration, got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestSanitizePath(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -188,6 +253,63 @@ func TestSanitizePath(t *testing.T) {
 	}
 }
 
+func TestMarshalMetadata(t *testing.T) {
+	v := map[string]int{"id": 1}
+
+	pretty, err := MarshalMetadata(v, false)
+	if err != nil {
+		t.Fatalf("MarshalMetadata(pretty) error: %v", err)
+	}
+	if string(pretty) != "{\n  \"id\": 1\n}" {
+		t.Errorf("MarshalMetadata(pretty) = %q", pretty)
+	}
+
+	compact, err := MarshalMetadata(v, true)
+	if err != nil {
+		t.Fatalf("MarshalMetadata(compact) error: %v", err)
+	}
+	if string(compact) != `{"id":1}` {
+		t.Errorf("MarshalMetadata(compact) = %q", compact)
+	}
+}
+
+func TestLongPath(t *testing.T) {
+	short := filepath.Join("models", "lora", "model.safetensors")
+	if got := LongPath(short); got != short {
+		t.Errorf("LongPath(%q) = %q, want it unchanged", short, got)
+	}
+
+	long := filepath.Join("models", strings.Repeat("a", 120), strings.Repeat("b", 120), "model.safetensors")
+	if runtime.GOOS != "windows" {
+		if got := LongPath(long); got != long {
+			t.Errorf("LongPath should not change paths outside Windows, got %q", got)
+		}
+		return
+	}
+
+	got := LongPath(long)
+	abs, _ := filepath.Abs(long)
+	if got != `\\?\`+abs {
+		t.Errorf("LongPath(%q) = %q, want the \\\\?\\ prefixed absolute path", long, got)
+	}
+	if LongPath(got) != got {
+		t.Error("LongPath should leave an already prefixed path unchanged")
+	}
+	if got := LongPath(`\\server\share\` + strings.Repeat("c", 260)); !strings.HasPrefix(got, `\\?\UNC\server\share\`) {
+		t.Errorf("expected UNC path to use the \\\\?\\UNC\\ prefix, got %q", got)
+	}
+
+	// The prefixed path must actually work for a file beyond MAX_PATH
+	dir := filepath.Join(t.TempDir(), strings.Repeat("d", 100), strings.Repeat("e", 100), strings.Repeat("f", 100))
+	if !CheckAndMakeDir(dir) {
+		t.Fatalf("CheckAndMakeDir failed for long path %s", dir)
+	}
+	file := filepath.Join(dir, "model.safetensors")
+	if err := os.WriteFile(LongPath(file), []byte("data"), 0600); err != nil {
+		t.Fatalf("writing long path: %v", err)
+	}
+}
+
 func TestStringSliceContains(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -414,6 +536,88 @@ func TestCheckHash(t *testing.T) {
 	})
 }
 
+func TestParseHashAlgo(t *testing.T) {
+	for _, in := range []string{"", "sha256", "BLAKE3", " crc32 ", "AutoV2"} {
+		if _, err := ParseHashAlgo(in); err != nil {
+			t.Errorf("ParseHashAlgo(%q) unexpected error: %v", in, err)
+		}
+	}
+	if got, _ := ParseHashAlgo("AutoV2"); got != HashAlgoAutoV2 {
+		t.Errorf("ParseHashAlgo(\"AutoV2\") = %q, want %q", got, HashAlgoAutoV2)
+	}
+	if _, err := ParseHashAlgo("md5"); err == nil {
+		t.Error("ParseHashAlgo(\"md5\") should return an error")
+	}
+}
+
+func TestCheckHashWithAlgo(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "test_file.txt")
+	if err := os.WriteFile(testFile, []byte("Hello, World!"), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	// SHA256 of "Hello, World!"
+	const sha = "dffd6021bb2bd5b0af676290809ec3a53191dd81c7f70a4b28688a362182986f"
+
+	tests := []struct {
+		name    string
+		hashes  models.Hashes
+		algo    string
+		want    bool
+		wantErr error
+	}{
+		{"sha256 match", models.Hashes{SHA256: sha}, HashAlgoSHA256, true, nil},
+		{"sha256 mismatch", models.Hashes{SHA256: "deadbeef"}, HashAlgoSHA256, false, nil},
+		{"autov2 match", models.Hashes{AutoV2: strings.ToUpper(sha[:10])}, HashAlgoAutoV2, true, nil},
+		{"autov2 ignores sha256", models.Hashes{SHA256: sha}, HashAlgoAutoV2, false, ErrHashUnavailable},
+		{"blake3 missing", models.Hashes{SHA256: sha, AutoV2: sha[:10]}, HashAlgoBLAKE3, false, ErrHashUnavailable},
+		{"empty algo falls back", models.Hashes{SHA256: sha}, "", true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CheckHashWithAlgo(testFile, tt.hashes, tt.algo)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CheckHashWithAlgo() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("CheckHashWithAlgo() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHashVerifier(t *testing.T) {
+	if NewHashVerifier(models.Hashes{}) != nil {
+		t.Error("NewHashVerifier() with no hashes should return nil")
+	}
+
+	// SHA256 of "Hello, World!"
+	const sha = "dffd6021bb2bd5b0af676290809ec3a53191dd81c7f70a4b28688a362182986f"
+
+	tests := []struct {
+		name   string
+		hashes models.Hashes
+		want   bool
+	}{
+		{"sha256 match", models.Hashes{SHA256: sha}, true},
+		{"autov2 match", models.Hashes{AutoV2: sha[:10]}, true},
+		{"any match wins", models.Hashes{BLAKE3: "deadbeef", SHA256: strings.ToUpper(sha)}, true},
+		{"all mismatch", models.Hashes{SHA256: "deadbeef", CRC32: "deadbeef"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewHashVerifier(tt.hashes)
+			// Written in pieces, as a download arrives
+			_, _ = v.Write([]byte("Hello, "))
+			_, _ = v.Write([]byte("World!"))
+			if got := v.Verify("test"); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestDetectImageTypeFromMagicBytes(t *testing.T) {
 	tests := []struct {
 		name     string
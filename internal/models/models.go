@@ -5,9 +5,27 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"regexp"
 	"strconv"
+	"strings"
+	"time"
 )
 
+// Version is the release version of the tool. Release builds set it with
+// -ldflags "-X go-civitai-download/internal/models.Version=<version>".
+var Version = "dev"
+
+// browserUserAgent is a browser User-Agent string; Civitai answers some
+// requests without one with 401 errors.
+const browserUserAgent = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36"
+
+// DefaultUserAgent returns the User-Agent sent when UserAgent is not
+// configured: the browser string followed by the tool name and version, so
+// Civitai can tell this tool's traffic apart.
+func DefaultUserAgent() string {
+	return browserUserAgent + " go-civitai-downloader/" + Version
+}
+
 // StringOrStringSlice is a custom type that can unmarshal from either
 // a JSON string or a JSON array of strings. This handles API responses
 // where a field may return either format.
@@ -127,21 +145,39 @@ type (
 	// Config holds the application's configuration settings.
 	Config struct {
 		SavePath            string         `toml:"SavePath" json:"SavePath"`
+		MetadataSavePath    string         `toml:"MetadataSavePath" json:"MetadataSavePath"` // Root for sidecar files instead of next to the models (empty = SavePath)
 		DatabasePath        string         `toml:"DatabasePath" json:"DatabasePath"`
 		BleveIndexPath      string         `toml:"BleveIndexPath" json:"BleveIndexPath"`
 		LogLevel            string         `toml:"LogLevel" json:"LogLevel"`
 		LogFormat           string         `toml:"LogFormat" json:"LogFormat"`
 		APIKey              string         `toml:"ApiKey" json:"ApiKey"`
 		SessionCookie       string         `toml:"SessionCookie" json:"SessionCookie"` // Browser session cookie for login-required downloads
+		UserAgent           string         `toml:"UserAgent" json:"UserAgent"`         // User-Agent header for API and download requests
+		Proxy               string         `toml:"Proxy" json:"Proxy"`                 // http(s):// or socks5:// proxy for every request (empty = HTTP_PROXY etc.)
 		Torrent             TorrentConfig  `toml:"Torrent" json:"Torrent"`
 		Download            DownloadConfig `toml:"Download" json:"Download"`
 		Images              ImagesConfig   `toml:"Images" json:"Images"`
 		APIDelayMs          int            `toml:"ApiDelayMs" json:"ApiDelayMs"`
+		RequestsPerMinute   int            `toml:"RequestsPerMinute" json:"RequestsPerMinute"` // Cap on API requests across all workers (0 = unlimited)
 		APIClientTimeoutSec int            `toml:"ApiClientTimeoutSec" json:"ApiClientTimeoutSec"`
+		MaxConnsPerHost     int            `toml:"MaxConnsPerHost" json:"MaxConnsPerHost"` // Open connections to one host across all workers (0 = unlimited)
 		MaxRetries          int            `toml:"MaxRetries" json:"MaxRetries"`
 		InitialRetryDelayMs int            `toml:"InitialRetryDelayMs" json:"InitialRetryDelayMs"`
+		MaxRetryDelayMs     int            `toml:"MaxRetryDelayMs" json:"MaxRetryDelayMs"`     // Cap on each retry backoff (0 = no cap)
+		MaxRetryElapsedMs   int            `toml:"MaxRetryElapsedMs" json:"MaxRetryElapsedMs"` // Stop retrying a request after this long (0 = no limit)
+		APILogMaxSizeMB     int            `toml:"ApiLogMaxSizeMB" json:"ApiLogMaxSizeMB"`     // Rotate api.log beyond this size (0 = never rotate)
+		APICacheTTLSec      int            `toml:"ApiCacheTTLSec" json:"ApiCacheTTLSec"`       // Reuse model details cached on disk for this long (0 = memory only)
 		DB                  DBConfig       `toml:"DB" json:"DB"`
 		LogApiRequests      bool           `toml:"LogApiRequests" json:"LogApiRequests"`
+		WaitForMaintenance  bool           `toml:"WaitForMaintenance" json:"WaitForMaintenance"` // Wait out repeated 503s instead of failing
+		JSONCompact         bool           `toml:"JsonCompact" json:"JsonCompact"`               // Write metadata/info JSON without indentation
+		RetryJitter         bool           `toml:"RetryJitter" json:"RetryJitter"`               // Sleep a random part of each retry backoff
+
+		// Failed API requests within a minute that pause all API requests (0 = disabled)
+		CircuitBreakerThreshold int `toml:"CircuitBreakerThreshold" json:"CircuitBreakerThreshold"`
+
+		// API base URL, for pointing at a mirror or a local mock (empty = https://civitai.com/api/v1)
+		APIBaseURL string `toml:"ApiBaseURL" json:"ApiBaseURL"`
 	}
 
 	// DownloadConfig holds settings specific to the 'download' command.
@@ -153,6 +189,20 @@ type (
 		Period               string `toml:"Period"`
 		VersionPathPattern   string `toml:"VersionPathPattern"`
 		ModelInfoPathPattern string `toml:"ModelInfoPathPattern"`
+		NameRegex            string `toml:"NameRegex"`  // Only keep models whose name matches (client-side)
+		QueueOrder           string `toml:"QueueOrder"` // size-asc, size-desc or none (API order)
+		MaxRuntime           string `toml:"MaxRuntime"` // Stop starting downloads after this long, e.g. "6h" (empty = no limit)
+		Since                string `toml:"Since"`      // Skip versions published before this date or age, e.g. "2024-05-01" or "7d"
+		// File of model/version IDs to never download, merged into BlockedModelIDs/BlockedVersionIDs
+		BlocklistFile string `toml:"BlocklistFile"`
+		// Path of the trained words .txt file, ending in {trainedWordsFilename}
+		TrainedWordsPathPattern string `toml:"TrainedWordsPathPattern"`
+		// Compiled NameRegex, set once the config is validated
+		NameRegexp *regexp.Regexp `toml:"-" json:"-"`
+		// MaxRuntime counted from the start of the run, set once the config is validated
+		Deadline time.Time `toml:"-" json:"-"`
+		// Since as a point in time, set once the config is validated
+		SinceTime time.Time `toml:"-" json:"-"`
 		// Slices (largest items)
 		ModelTypes            []string `toml:"ModelTypes"`
 		BaseModels            []string `toml:"BaseModels"`
@@ -160,25 +210,86 @@ type (
 		IgnoreBaseModels      []string `toml:"IgnoreBaseModels"`
 		IgnoreFileNameStrings []string `toml:"IgnoreFileNameStrings"`
 		IgnoreTags            []string `toml:"IgnoreTags"`
+		AllowedFormats        []string `toml:"AllowedFormats"`    // File formats to download, e.g. "SafeTensor", "GGUF" (empty = all)
+		BlockedModelIDs       []int    `toml:"BlockedModelIDs"`   // Models never downloaded, whatever the query
+		BlockedVersionIDs     []int    `toml:"BlockedVersionIDs"` // Versions never downloaded, whatever the query
+		// Folder name to use for {modelType} per API model type, e.g. "TextualInversion" -> "embeddings"
+		TypeFolderMap map[string]string `toml:"TypeFolderMap"`
 		// Integers
 		Concurrency    int `toml:"Concurrency"`
 		Limit          int `toml:"Limit"`
 		MaxPages       int `toml:"MaxPages"`
-		MaxImages      int `toml:"MaxImages"` // Maximum images to download per version (0 = unlimited)
+		MaxImages      int `toml:"MaxImages"`   // Maximum images to download per version (0 = unlimited)
+		MaxAttempts    int `toml:"MaxAttempts"` // Failed downloads are given up on after this many attempts (0 = never)
 		ModelVersionID int `toml:"ModelVersionID"`
+		BrowsingLevel  int `toml:"BrowsingLevel"`
 		ModelID        int `toml:"-"` // Flag only (`--model-id`)
+		AfterVersionID int `toml:"-"` // Flag only (`--after-version-id`), newer versions of ModelID only
+		FileID         int `toml:"-"` // Flag only (`--file-id`), the one file of ModelVersionID to download
+		// Downloads of the same model running at once, the queue is interleaved across models (0 = no cap)
+		PerModelConcurrency int `toml:"PerModelConcurrency"`
+		// Upper bound for AutoConcurrency
+		MaxConcurrency int `toml:"MaxConcurrency"`
+		// Candidates to save metadata for when above Limit; the extra ones get metadata only (0 = Limit)
+		MetadataLimit int `toml:"MetadataLimit"`
+		// Bytes per second all downloads of a run may read together (0 = unlimited)
+		MaxBytesPerSecond int64 `toml:"MaxBytesPerSecond"`
+		// Range requests each file is downloaded with at once (1 = a single stream)
+		SegmentsPerFile int `toml:"SegmentsPerFile"`
+		// Skip models with fewer downloads or ratings than this (0 = no minimum)
+		MinDownloads   int `toml:"MinDownloads"`
+		MinRatingCount int `toml:"MinRatingCount"`
+		// Floats
+		AutoConfirmUnderGB float64 `toml:"AutoConfirmUnderGB"` // Skip the prompt when the queue totals less than this (0 = always ask)
+		MinSizeMB          float64 `toml:"MinSizeMB"`          // Skip files smaller than this (0 = no lower bound)
+		MaxSizeMB          float64 `toml:"MaxSizeMB"`          // Skip files larger than this (0 = no upper bound)
+		MinRating          float64 `toml:"MinRating"`          // Skip models rated lower than this (0 = no minimum)
+		// Slices populated at runtime
+		ModelIDs []int `toml:"-"` // Flag only (`--from-stdin`), processed like repeated --model-id
 		// Bools (smallest)
-		Nsfw              bool `toml:"Nsfw"`
-		PrimaryOnly       bool `toml:"PrimaryOnly"`
-		Pruned            bool `toml:"Pruned"`
-		Fp16              bool `toml:"Fp16"`
-		AllVersions       bool `toml:"AllVersions"`
-		SkipConfirmation  bool `toml:"SkipConfirmation"`
-		SaveMetadata      bool `toml:"SaveMetadata"`
-		SaveModelInfo     bool `toml:"ModelInfo"`
-		SaveVersionImages bool `toml:"VersionImages"`
-		SaveModelImages   bool `toml:"ModelImages"`
-		DownloadMetaOnly  bool `toml:"MetaOnly"`
+		Nsfw             bool `toml:"Nsfw"`
+		PrimaryOnly      bool `toml:"PrimaryOnly"`
+		Pruned           bool `toml:"Pruned"`
+		Fp16             bool `toml:"Fp16"`
+		AllVersions      bool `toml:"AllVersions"`
+		SkipConfirmation bool `toml:"SkipConfirmation"`
+		Favorites        bool `toml:"Favorites"` // Only models liked by the API key's account
+		Hidden           bool `toml:"Hidden"`    // Only models hidden by the API key's account
+		SaveMetadata     bool `toml:"SaveMetadata"`
+		SaveCivitaiInfo  bool `toml:"SaveCivitaiInfo"`  // Write <model>.civitai.info for the WebUI Civitai Helper
+		SavePreview      bool `toml:"SavePreview"`      // Save <model>.preview.png from the version's images
+		SaveTrainedWords bool `toml:"SaveTrainedWords"` // Write the trained words to TrainedWordsPathPattern
+		// Field names differ from the file keys, so Viper needs the mapstructure tags too
+		SaveModelInfo     bool `toml:"ModelInfo" mapstructure:"ModelInfo"`
+		SaveVersionImages bool `toml:"VersionImages" mapstructure:"VersionImages"`
+		SaveModelImages   bool `toml:"ModelImages" mapstructure:"ModelImages"`
+		DownloadMetaOnly  bool `toml:"MetaOnly" mapstructure:"MetaOnly"`
+		FailFast          bool `toml:"FailFast"`         // Abort the whole run on the first download error
+		BackupOnReplace   bool `toml:"BackupOnReplace"`  // Keep the old copy as .bak when a changed file is re-downloaded
+		PrimaryImageOnly  bool `toml:"PrimaryImageOnly"` // Only save the first (cover) image of a gallery
+		SaveWorkflows     bool `toml:"SaveWorkflows"`    // Extract image workflows, put workflow attachments in workflows/
+		MaxRuntimeCancel  bool `toml:"MaxRuntimeCancel"` // Cancel in-flight downloads at the MaxRuntime deadline instead of letting them finish
+		RecordBlocked     bool `toml:"RecordBlocked"`    // Store blocked versions in the DB as Skipped
+		// Try versions still in early access instead of skipping them
+		IncludeEarlyAccess bool `toml:"IncludeEarlyAccess"`
+		// Save every file directly in SavePath instead of the VersionPathPattern folders
+		Flatten      bool   `toml:"Flatten"`
+		ForceRetry   bool   `toml:"-"` // Flag only (`--force-retry`), retry entries past MaxAttempts
+		Force        bool   `toml:"-"` // Flag only (`--force`), download again whatever the DB and disk hold
+		DryRun       bool   `toml:"-"` // Flag only (`--dry-run`), list the downloads without writing to the DB or disk
+		ProgressJSON string `toml:"-"` // Flag only (`--progress-json`), write progress events as JSON lines to this path, "-" for stderr
+		SummaryJSON  string `toml:"-"` // Flag only (`--summary-json`), write a JSON summary of the run to this path ("-" = stdout)
+		NoCache      bool   `toml:"-"` // Flag only (`--no-cache`), fetch model details in full instead of revalidating them
+		// Set by `update`: skip models the API reports as not modified since the last fetch
+		SkipUnchanged bool `toml:"-"`
+		// With PrimaryOnly, download the largest matching file of versions that flag no file as primary
+		PrimaryFileFallback bool `toml:"PrimaryFileFallback"`
+		// Store files once under objects/<sha256[:2]>/<sha256> and link them at their normal path
+		ContentAddressed bool `toml:"ContentAddressed"`
+		// Save each model gallery image once when it is listed under several versions
+		DedupeImages bool `toml:"DedupeImages"`
+		// Adjust the downloads running at once to the measured throughput, up to MaxConcurrency
+		AutoConcurrency bool `toml:"AutoConcurrency"`
 	}
 
 	// ImagesConfig holds settings specific to the 'images' command.
@@ -187,10 +298,14 @@ type (
 		// Strings first
 		Username    string `toml:"Username"`
 		Nsfw        string `toml:"Nsfw"`
+		NsfwMin     string `toml:"NsfwMin"` // Lowest nsfwLevel to keep (None, Soft, Mature, X), empty means None
+		NsfwMax     string `toml:"NsfwMax"` // Highest nsfwLevel to keep, empty means X
 		Sort        string `toml:"Sort"`
 		Period      string `toml:"Period"`
 		OutputDir   string `toml:"OutputDir"`
 		PathPattern string `toml:"PathPattern"`
+		// Model/version folder placed above PathPattern when GroupByModel is set
+		SubfolderPattern string `toml:"SubfolderPattern"`
 		// Integers
 		Limit          int `toml:"Limit"`
 		PostID         int `toml:"PostID"`
@@ -202,8 +317,9 @@ type (
 		Concurrency    int `toml:"Concurrency"`
 		BrowsingLevel  int `toml:"BrowsingLevel"` // Civitai browsing level bitmask (0=use Nsfw param, 1=PG, 3=SFW, 31=All)
 		// Bools
-		SaveMetadata        bool `toml:"Metadata"`
+		SaveMetadata        bool `toml:"Metadata" mapstructure:"Metadata"`
 		DetectImageMimeType bool `toml:"DetectImageMimeType"`
+		GroupByModel        bool `toml:"GroupByModel"` // Apply SubfolderPattern when --model-id/--model-version-id is set
 	}
 
 	// TorrentConfig holds settings specific to the 'torrent' command.
@@ -213,18 +329,29 @@ type (
 		Overwrite   bool   `toml:"Overwrite"`
 		MagnetLinks bool   `toml:"MagnetLinks"`
 		Concurrency int    `toml:"Concurrency"` // Separate from Download.Concurrency
+		// Piece length in KB, a power of two; 0 picks one from the directory size
+		PieceLengthKB int `toml:"PieceLengthKB"`
+		// Comma separated extensions of model files, e.g. ".safetensors,.ckpt"
+		IncludeExtensions string `toml:"IncludeExtensions"`
+		// Comma separated extensions never put in torrents, e.g. ".json,.txt"
+		ExcludeFileTypes string `toml:"ExcludeFileTypes"`
 	}
 
 	// DBConfig holds settings specific to the 'db' command group.
 	DBConfig struct {
-		Verify DBVerifyConfig `toml:"Verify"`
+		Verify       DBVerifyConfig `toml:"Verify"`
+		StoreRawJSON bool           `toml:"StoreRawJSON"` // Keep the original API JSON of each version (gzipped)
 	}
 
 	// DBVerifyConfig holds settings for the 'db verify' subcommand.
 	// Added to config for potential future use, primarily driven by flags now.
 	DBVerifyConfig struct {
-		CheckHash      bool `toml:"CheckHash"`
-		AutoRedownload bool `toml:"AutoRedownload"` // Corresponds to --yes flag
+		CheckHash      bool   `toml:"CheckHash"`
+		AutoRedownload bool   `toml:"AutoRedownload"` // Corresponds to --yes flag
+		HashAlgo       string `toml:"HashAlgo"`       // sha256, blake3, crc32, autov2; empty checks any present hash
+		// Skip files hashed OK within this long ("30d", "12h"), unless --force; empty always hashes
+		SkipIfVerifiedWithin string `toml:"SkipIfVerifiedWithin"`
+		Concurrency          int    `toml:"Concurrency"` // Files checked at once; 0 uses the number of CPUs
 	}
 
 	// Api Calls and Responses
@@ -240,10 +367,13 @@ type (
 		BaseModels             []string `json:"baseModels,omitempty"`
 		Limit                  int      `json:"limit"`
 		Page                   int      `json:"page,omitempty"`
+		BrowsingLevel          int      `json:"browsingLevel,omitempty"` // Sent instead of nsfw when set
 		PrimaryFileOnly        bool     `json:"primaryFileOnly,omitempty"`
 		AllowNoCredit          bool     `json:"allowNoCredit,omitempty"`
 		AllowDerivatives       bool     `json:"allowDerivatives,omitempty"`
 		AllowDifferentLicenses bool     `json:"allowDifferentLicenses,omitempty"`
+		Favorites              bool     `json:"favorites,omitempty"`
+		Hidden                 bool     `json:"hidden,omitempty"`
 		Nsfw                   bool     `json:"nsfw"`
 	}
 
@@ -305,7 +435,13 @@ type (
 		Stats                Stats         `json:"stats"`
 		ID                   int           `json:"id"`
 		ModelId              int           `json:"modelId"`
-		EarlyAccessTimeFrame int           `json:"earlyAccessTimeFrame"`
+		EarlyAccessTimeFrame int           `json:"earlyAccessTimeFrame"` // Days after publishing the version stays in early access
+		// End of the early access window and "EarlyAccess" while it is open, on newer API responses
+		EarlyAccessEndsAt string `json:"earlyAccessEndsAt,omitempty"`
+		Availability      string `json:"availability,omitempty"`
+
+		// The version exactly as the API returned it, kept for DB.StoreRawJSON
+		RawJSON json.RawMessage `json:"-"`
 	}
 
 	File struct {
@@ -395,6 +531,15 @@ type (
 		Version      ModelVersion `json:"version"`
 		Timestamp    int64        `json:"timestamp"`
 		ModelID      int          `json:"modelId"`
+		AttemptCount int          `json:"attemptCount,omitempty"` // Failed download attempts since the last success
+		// Set by db verify when the file last hashed correctly, cleared when it did not
+		LastVerifiedAt   int64  `json:"lastVerifiedAt,omitempty"` // Unix seconds
+		LastVerifiedHash string `json:"lastVerifiedHash,omitempty"`
+		// Object the file links to under Download.ContentAddressed, relative to SavePath
+		ObjectPath string `json:"objectPath,omitempty"`
+
+		// Original API JSON of the version; stored gzipped, read back with DB.GetRawJSON
+		RawJSON json.RawMessage `json:"rawJson,omitempty"`
 	}
 
 	// --- Start: /api/v1/images Endpoint Structures ---
@@ -415,6 +560,7 @@ type (
 		PostID         *int           `json:"postId,omitempty"`
 		URL            string         `json:"url"`
 		Hash           string         `json:"hash"`
+		CreatedAt      string         `json:"createdAt,omitempty"`
 		Username       FlexibleString `json:"username,omitempty"`
 		BaseModel      string         `json:"baseModel,omitempty"`
 		ID             int            `json:"id"`
@@ -444,11 +590,169 @@ type (
 
 // Database Status Constants
 const (
-	StatusPending    = "Pending"
-	StatusDownloaded = "Downloaded"
-	StatusError      = "Error"
+	StatusPending     = "Pending"
+	StatusDownloaded  = "Downloaded"
+	StatusError       = "Error"
+	StatusSkipped     = "Skipped"     // Blocked by the blocklist, see Download.RecordBlocked
+	StatusEarlyAccess = "EarlyAccess" // Download refused while the version is in early access
 )
 
+// Civitai browsing level bits. A browsingLevel query value is the sum of the
+// content levels to return.
+const (
+	BrowsingLevelPG   = 1
+	BrowsingLevelPG13 = 2
+	BrowsingLevelR    = 4
+	BrowsingLevelX    = 8
+	BrowsingLevelXXX  = 16
+
+	BrowsingLevelSFW = BrowsingLevelPG | BrowsingLevelPG13
+	BrowsingLevelAll = BrowsingLevelSFW | BrowsingLevelR | BrowsingLevelX | BrowsingLevelXXX
+)
+
+// browsingLevelNames maps the names accepted by ParseBrowsingLevel to their bits.
+var browsingLevelNames = map[string]int{
+	"pg":   BrowsingLevelPG,
+	"pg13": BrowsingLevelPG13,
+	"r":    BrowsingLevelR,
+	"x":    BrowsingLevelX,
+	"xxx":  BrowsingLevelXXX,
+	"sfw":  BrowsingLevelSFW,
+	"all":  BrowsingLevelAll,
+}
+
+// ParseBrowsingLevel parses a browsing level given either as a bitmask ("3")
+// or as comma separated level names ("PG,PG13,R"). Names are case-insensitive.
+func ParseBrowsingLevel(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 0 || n > BrowsingLevelAll {
+			return 0, fmt.Errorf("browsing level %d out of range (0-%d)", n, BrowsingLevelAll)
+		}
+		return n, nil
+	}
+
+	level := 0
+	for _, name := range strings.Split(s, ",") {
+		bits, ok := browsingLevelNames[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return 0, fmt.Errorf("unknown browsing level %q: use a bitmask or PG, PG13, R, X, XXX (also SFW, All)", strings.TrimSpace(name))
+		}
+		level |= bits
+	}
+	return level, nil
+}
+
+// ImageNsfwLevels are the nsfwLevel values of the images endpoint, from the
+// least to the most explicit. The index of a level is its rank.
+var ImageNsfwLevels = []string{"None", "Soft", "Mature", "X"}
+
+// imageNsfwBrowsingLevels holds the browsing level bits of each rank of
+// ImageNsfwLevels. X covers both the X and XXX browsing levels.
+var imageNsfwBrowsingLevels = []int{BrowsingLevelPG, BrowsingLevelPG13, BrowsingLevelR, BrowsingLevelX | BrowsingLevelXXX}
+
+// ParseImageNsfwRange parses the bounds of an images NSFW level range into
+// ranks of ImageNsfwLevels. Names are case-insensitive; an empty minimum is
+// None and an empty maximum is X.
+func ParseImageNsfwRange(minLevel, maxLevel string) (int, int, error) {
+	parse := func(s string, fallback int) (int, error) {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			return fallback, nil
+		}
+		for rank, name := range ImageNsfwLevels {
+			if strings.EqualFold(name, s) {
+				return rank, nil
+			}
+		}
+		return 0, fmt.Errorf("unknown NSFW level %q: use None, Soft, Mature or X", s)
+	}
+	lo, err := parse(minLevel, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+	hi, err := parse(maxLevel, len(ImageNsfwLevels)-1)
+	if err != nil {
+		return 0, 0, err
+	}
+	if lo > hi {
+		return 0, 0, fmt.Errorf("NSFW level range %s-%s is empty: the minimum is above the maximum", ImageNsfwLevels[lo], ImageNsfwLevels[hi])
+	}
+	return lo, hi, nil
+}
+
+// NsfwRangeBrowsingLevel returns the browsing level bitmask selecting the
+// images with a rank between lo and hi, inclusive.
+func NsfwRangeBrowsingLevel(lo, hi int) int {
+	level := 0
+	for rank := lo; rank <= hi && rank < len(imageNsfwBrowsingLevels); rank++ {
+		level |= imageNsfwBrowsingLevels[rank]
+	}
+	return level
+}
+
+// ImageNsfwRank returns the rank in ImageNsfwLevels of an image's nsfwLevel,
+// which the API sends either as a level name or as a browsing level number.
+// It reports false for values it does not recognise.
+func ImageNsfwRank(level interface{}) (int, bool) {
+	switch v := level.(type) {
+	case string:
+		for rank, name := range ImageNsfwLevels {
+			if strings.EqualFold(name, v) {
+				return rank, true
+			}
+		}
+		if n, err := strconv.Atoi(v); err == nil {
+			return ImageNsfwRank(float64(n))
+		}
+	case float64:
+		// The most explicit bit decides when several are set
+		n := int(v)
+		for rank := len(imageNsfwBrowsingLevels) - 1; rank >= 0; rank-- {
+			if n&imageNsfwBrowsingLevels[rank] != 0 {
+				return rank, true
+			}
+		}
+	case int:
+		return ImageNsfwRank(float64(v))
+	}
+	return 0, false
+}
+
+// KnownModelTypes are the model types the API accepts in the types filter.
+// Listed by the list types command; names are case-sensitive for the API.
+var KnownModelTypes = []string{
+	"Checkpoint", "TextualInversion", "Hypernetwork", "AestheticGradient", "LORA", "LoCon", "DoRA",
+	"Controlnet", "Upscaler", "MotionModule", "VAE", "Poses", "Wildcards", "Workflows", "Detection", "Other",
+}
+
+// KnownBaseModels are the base models the API accepts in the baseModels
+// filter. Listed by the list base-models command; Civitai adds new ones over
+// time, so values missing here are still sent.
+var KnownBaseModels = []string{
+	"SD 1.4", "SD 1.5", "SD 1.5 LCM", "SD 1.5 Hyper",
+	"SD 2.0", "SD 2.0 768", "SD 2.1", "SD 2.1 768", "SD 2.1 Unclip",
+	"SDXL 0.9", "SDXL 1.0", "SDXL 1.0 LCM", "SDXL Distilled", "SDXL Turbo", "SDXL Lightning", "SDXL Hyper",
+	"SD 3", "SD 3.5", "SD 3.5 Medium", "SD 3.5 Large", "SD 3.5 Large Turbo",
+	"Pony", "Flux.1 S", "Flux.1 D", "AuraFlow", "Stable Cascade", "SVD", "SVD XT",
+	"Playground v2", "PixArt a", "PixArt E", "Hunyuan 1", "Hunyuan Video", "Lumina", "Kolors",
+	"Illustrious", "Mochi", "LTXV", "CogVideoX", "NoobAI", "Wan Video", "HiDream", "Other",
+}
+
+// CanonicalName returns the entry of known equal to value ignoring case, and
+// whether there was one.
+func CanonicalName(known []string, value string) (string, bool) {
+	for _, name := range known {
+		if strings.EqualFold(name, value) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
 // ConstructApiUrl builds the Civitai API URL from query parameters.
 func ConstructApiUrl(params QueryParameters) string {
 	base := "https://civitai.com/api/v1/models"
@@ -499,11 +803,20 @@ func ConstructApiUrl(params QueryParameters) string {
 		values.Set("allowCommercialUse", params.AllowCommercialUse)
 	}
 
-	// Only add nsfw param if true
-	if params.Nsfw {
+	// browsingLevel replaces the nsfw param when set
+	if params.BrowsingLevel > 0 {
+		values.Set("browsingLevel", strconv.Itoa(params.BrowsingLevel))
+	} else if params.Nsfw { // Only add nsfw param if true
 		values.Set("nsfw", "true")
 	}
 
+	if params.Favorites {
+		values.Set("favorites", "true")
+	}
+	if params.Hidden {
+		values.Set("hidden", "true")
+	}
+
 	for _, bm := range params.BaseModels {
 		values.Add("baseModels", bm) // API uses camelCase
 	}
@@ -17,6 +17,9 @@ func TestStatusConstants(t *testing.T) {
 	if StatusError != "Error" {
 		t.Errorf("StatusError = %q, want %q", StatusError, "Error")
 	}
+	if StatusSkipped != "Skipped" {
+		t.Errorf("StatusSkipped = %q, want %q", StatusSkipped, "Skipped")
+	}
 }
 
 func TestStringOrStringSlice_UnmarshalString(t *testing.T) {
@@ -167,6 +170,129 @@ func TestConstructApiUrl_WithCursor(t *testing.T) {
 	}
 }
 
+func TestConstructApiUrl_WithFavorites(t *testing.T) {
+	url := ConstructApiUrl(QueryParameters{Favorites: true, Username: "alice"})
+
+	if !strings.Contains(url, "favorites=true") || !strings.Contains(url, "username=alice") {
+		t.Errorf("URL should contain favorites and username parameters, got: %s", url)
+	}
+	if strings.Contains(ConstructApiUrl(QueryParameters{}), "favorites") {
+		t.Error("favorites parameter should be omitted when not set")
+	}
+	if url := ConstructApiUrl(QueryParameters{Hidden: true}); !strings.Contains(url, "hidden=true") {
+		t.Errorf("URL should contain the hidden parameter, got: %s", url)
+	}
+}
+
+func TestConstructApiUrl_WithBrowsingLevel(t *testing.T) {
+	url := ConstructApiUrl(QueryParameters{BrowsingLevel: BrowsingLevelSFW, Nsfw: true})
+
+	if !strings.Contains(url, "browsingLevel=3") {
+		t.Errorf("URL should contain browsingLevel=3, got: %s", url)
+	}
+	if strings.Contains(url, "nsfw=") {
+		t.Errorf("nsfw should not be sent together with browsingLevel, got: %s", url)
+	}
+}
+
+func TestParseBrowsingLevel(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    int
+		wantErr bool
+	}{
+		{input: "", want: 0},
+		{input: "31", want: 31},
+		{input: "PG", want: 1},
+		{input: "pg, PG13", want: 3},
+		{input: "R,X,XXX", want: 28},
+		{input: "sfw,R", want: 7},
+		{input: "All", want: 31},
+		{input: "32", wantErr: true},
+		{input: "-1", wantErr: true},
+		{input: "PG,Mature", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBrowsingLevel(tt.input)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseBrowsingLevel(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseBrowsingLevel(%q) = %d, want %d", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseImageNsfwRange(t *testing.T) {
+	tests := []struct {
+		min, max  string
+		wantLo    int
+		wantHi    int
+		wantLevel int
+		wantErr   bool
+	}{
+		{wantLo: 0, wantHi: 3, wantLevel: 31},
+		{min: "soft", wantLo: 1, wantHi: 3, wantLevel: 30},
+		{max: "Soft", wantLo: 0, wantHi: 1, wantLevel: 3},
+		{min: "Mature", max: "Mature", wantLo: 2, wantHi: 2, wantLevel: 4},
+		{min: "X", max: "Soft", wantErr: true},
+		{min: "R", wantErr: true},
+	}
+	for _, tt := range tests {
+		lo, hi, err := ParseImageNsfwRange(tt.min, tt.max)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseImageNsfwRange(%q, %q) error = %v, wantErr %v", tt.min, tt.max, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if lo != tt.wantLo || hi != tt.wantHi {
+			t.Errorf("ParseImageNsfwRange(%q, %q) = %d, %d, want %d, %d", tt.min, tt.max, lo, hi, tt.wantLo, tt.wantHi)
+		}
+		if level := NsfwRangeBrowsingLevel(lo, hi); level != tt.wantLevel {
+			t.Errorf("NsfwRangeBrowsingLevel(%d, %d) = %d, want %d", lo, hi, level, tt.wantLevel)
+		}
+	}
+}
+
+func TestImageNsfwRank(t *testing.T) {
+	tests := []struct {
+		level  interface{}
+		want   int
+		wantOK bool
+	}{
+		{level: "None", want: 0, wantOK: true},
+		{level: "mature", want: 2, wantOK: true},
+		{level: float64(2), want: 1, wantOK: true},
+		{level: float64(16), want: 3, wantOK: true},
+		{level: float64(5), want: 2, wantOK: true},
+		{level: "8", want: 3, wantOK: true},
+		{level: nil},
+		{level: float64(0)},
+		{level: "Blocked"},
+	}
+	for _, tt := range tests {
+		got, ok := ImageNsfwRank(tt.level)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("ImageNsfwRank(%v) = %d, %v, want %d, %v", tt.level, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestCanonicalName(t *testing.T) {
+	if name, ok := CanonicalName(KnownModelTypes, "lora"); !ok || name != "LORA" {
+		t.Errorf("CanonicalName(lora) = %q, %v, want LORA, true", name, ok)
+	}
+	if name, ok := CanonicalName(KnownBaseModels, "Flux.1 D"); !ok || name != "Flux.1 D" {
+		t.Errorf("CanonicalName(Flux.1 D) = %q, %v, want Flux.1 D, true", name, ok)
+	}
+	if _, ok := CanonicalName(KnownModelTypes, "Lora2"); ok {
+		t.Error("CanonicalName(Lora2) found a match")
+	}
+}
+
 func TestConstructApiUrl_NoParams(t *testing.T) {
 	params := QueryParameters{}
 
@@ -20,25 +20,69 @@ const (
 	PlaceholderVersionName = "versionName"
 	PlaceholderBaseModel   = "baseModel"
 	PlaceholderImageID     = "imageId"
+	// File-level placeholders, from the model file being downloaded
+	PlaceholderFileExt    = "fileExt"
+	PlaceholderFileFormat = "fileFormat"
+	PlaceholderFp         = "fp"
+	PlaceholderSize       = "size"
+	// Image-level placeholders, from the images API, for Images.PathPattern
+	PlaceholderPostID    = "postId"
+	PlaceholderWidth     = "width"
+	PlaceholderHeight    = "height"
+	PlaceholderNsfwLevel = "nsfwLevel"
+	PlaceholderCreatedAt = "createdAt"
+	// File name of the trained words file, only used in TrainedWordsPathPattern
+	PlaceholderTrainedWordsFilename = "trainedWordsFilename"
 )
 
 // Define allowed tags using a map for easy lookup
 var allowedTags = map[string]struct{}{
-	PlaceholderModelID:     {},
-	PlaceholderModelName:   {},
-	PlaceholderModelType:   {},
-	PlaceholderCreatorName: {},
-	PlaceholderUsername:    {}, // For images API compatibility
-	PlaceholderVersionID:   {},
-	PlaceholderVersionName: {},
-	PlaceholderBaseModel:   {},
-	PlaceholderImageID:     {}, // For images API compatibility
+	PlaceholderModelID:              {},
+	PlaceholderModelName:            {},
+	PlaceholderModelType:            {},
+	PlaceholderCreatorName:          {},
+	PlaceholderUsername:             {}, // For images API compatibility
+	PlaceholderVersionID:            {},
+	PlaceholderVersionName:          {},
+	PlaceholderBaseModel:            {},
+	PlaceholderImageID:              {}, // For images API compatibility
+	PlaceholderTrainedWordsFilename: {},
+	PlaceholderFileExt:              {},
+	PlaceholderFileFormat:           {},
+	PlaceholderFp:                   {},
+	PlaceholderSize:                 {},
+	PlaceholderPostID:               {},
+	PlaceholderWidth:                {},
+	PlaceholderHeight:               {},
+	PlaceholderNsfwLevel:            {},
+	PlaceholderCreatedAt:            {},
 	// Add more tags here if needed in the future
 }
 
 // Regex to find tags like {tagName}
 var tagRegex = regexp.MustCompile(`\{([^}]+)\}`)
 
+// Characters that are not allowed in a folder name on Windows or Linux
+var unsafeFolderChars = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
+
+// FolderKey returns the data key holding a user-chosen folder name for tag.
+// When set, that name replaces {tag} instead of the slugged value, so names
+// such as "Lora" keep their case. Only characters that are unsafe in a path
+// are removed from it.
+func FolderKey(tag string) string {
+	return tag + ":folder"
+}
+
+// sanitizeFolderName makes a user-chosen folder name safe to use as a single
+// path segment. It returns "" if nothing usable is left.
+func sanitizeFolderName(name string) string {
+	name = strings.Trim(unsafeFolderChars.ReplaceAllString(name, ""), " .")
+	if name == "" || strings.Contains(name, "..") {
+		return ""
+	}
+	return name
+}
+
 // GeneratePath substitutes placeholders in a pattern string with sanitized values from the data map.
 // It returns the generated relative path string or an error if substitution fails.
 func GeneratePath(pattern string, data map[string]string) (string, error) {
@@ -59,6 +103,12 @@ func GeneratePath(pattern string, data map[string]string) (string, error) {
 			return "", fmt.Errorf("unknown tag found in path pattern: %s", tagWithBraces)
 		}
 
+		// A user-chosen folder name wins over the slugged value
+		if folder := sanitizeFolderName(data[FolderKey(tagName)]); folder != "" {
+			generatedPath = strings.ReplaceAll(generatedPath, tagWithBraces, folder)
+			continue
+		}
+
 		// Get the value from the data map
 		value, ok := data[tagName]
 
@@ -1,6 +1,7 @@
 package paths
 
 import (
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -234,6 +235,36 @@ func TestGeneratePath_PathTraversal(t *testing.T) {
 	}
 }
 
+func TestGeneratePath_FolderOverride(t *testing.T) {
+	tests := []struct {
+		name     string
+		folder   string
+		expected string
+	}{
+		{name: "case is kept", folder: "Lora", expected: "Lora/my_model"},
+		{name: "spaces are kept", folder: "Textual Inversion", expected: "Textual Inversion/my_model"},
+		{name: "separators are removed", folder: "models/Lora", expected: "modelsLora/my_model"},
+		{name: "traversal falls back to slug", folder: "..", expected: "lora/my_model"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := map[string]string{
+				"modelType":                     "LORA",
+				"modelName":                     "My Model",
+				FolderKey(PlaceholderModelType): tt.folder,
+			}
+			got, err := GeneratePath("{modelType}/{modelName}", data)
+			if err != nil {
+				t.Fatalf("GeneratePath() unexpected error: %v", err)
+			}
+			if got != filepath.FromSlash(tt.expected) {
+				t.Errorf("GeneratePath() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
 func TestGeneratePath_NoPlaceholders(t *testing.T) {
 	// Pattern without any placeholders
 	got, err := GeneratePath("static/path/here", map[string]string{})